@@ -0,0 +1,281 @@
+// =============================================================================
+// Analytics Module Test
+// Tests the Athena workgroup against tables in the storage module's catalog
+// =============================================================================
+
+package test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/arn"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/matrix"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tagaudit"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/wait"
+)
+
+// Bytes scanned cutoff passed to the module so the assertion does not rely on its default
+const bytesScannedCutoff = 1073741824
+
+// Seed rows written to the curated bucket, header first
+var seedRows = [][]string{
+	{"id", "name", "country"},
+	{"1", "alice", "SG"},
+	{"2", "bob", "US"},
+	{"3", "carol", "DE"},
+}
+
+// TestMain runs the suite against the account selected by TERRATEST_ASSUME_ROLE_ARN
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
+
+// TestAnalytics tests the Athena workgroup configuration and query execution
+func TestAnalytics(t *testing.T) {
+	t.Parallel()
+
+	matrix.Run(t, func(t *testing.T, region matrix.Region) {
+		awsRegion := region.Name
+
+		// Athena names cannot contain hyphens, so the region code is joined directly
+		uniqueID := matrix.RegionCode(awsRegion) + strings.ToLower(random.UniqueId())
+
+		storageStageDir := testutil.StageDir(t, "storage")
+		analyticsStageDir := testutil.StageDir(t, "analytics")
+
+		defer testutil.Teardown(t, storageStageDir)
+		storageOptions := testutil.Deploy(t, storageStageDir, func() *terraform.Options {
+			// Athena only supports lowercase letters, digits and underscores in
+			// database names, so the storage project name avoids the default hyphen
+			opts := region.Options(
+				testutil.WithUniqueSuffix(uniqueID),
+				testutil.WithVar("project_name", "athena_"+uniqueID),
+			)
+			return testutil.NewTerraformOptions(t, "../../storage", testutil.NewStorageVars(opts...), opts...)
+		})
+
+		resultsBucket := terraform.Output(t, storageOptions, "processed_bucket_id")
+		curatedBucket := terraform.Output(t, storageOptions, "curated_bucket_id")
+		kmsKeyArn := terraform.Output(t, storageOptions, "s3_kms_key_arn")
+		databaseName := terraform.Output(t, storageOptions, "curated_database_name")
+
+		defer testutil.Teardown(t, analyticsStageDir)
+		analyticsOptions := testutil.Deploy(t, analyticsStageDir, func() *terraform.Options {
+			opts := region.Options(
+				testutil.WithUniqueSuffix(uniqueID),
+				testutil.WithVar("athena_results_bucket", resultsBucket),
+				testutil.WithVar("kms_key_id", kmsKeyArn),
+				testutil.WithVar("glue_database_name", databaseName),
+				testutil.WithVar("bytes_scanned_cutoff_per_query", bytesScannedCutoff),
+			)
+			return testutil.NewTerraformOptions(t, "../", testutil.NewAnalyticsVars(opts...), opts...)
+		})
+
+		testutil.Validate(t, func() {
+			workgroupName := terraform.Output(t, analyticsOptions, "athena_workgroup_name")
+			convention := arn.Convention{
+				Project:     analyticsOptions.Vars["project_name"].(string),
+				Environment: analyticsOptions.Vars["environment"].(string),
+			}
+			convention.AssertName(t, workgroupName, "workgroup")
+			arn.Assert(t, terraform.Output(t, analyticsOptions, "athena_workgroup_arn"), arn.Want{
+				Service:      "athena",
+				Region:       awsRegion,
+				ResourceType: "workgroup",
+				Name:         workgroupName,
+			})
+
+			clients := awsclients.New(t, awsclients.WithRegion(awsRegion))
+
+			t.Run("WorkgroupConfiguration", func(t *testing.T) {
+				testWorkgroupConfiguration(t, clients, workgroupName, resultsBucket, kmsKeyArn)
+			})
+
+			t.Run("NamedQueries", func(t *testing.T) {
+				testNamedQueries(t, clients, analyticsOptions, workgroupName, databaseName)
+			})
+
+			// Each validate run seeds its own table so the stage can be repeated against one stack
+			t.Run("QueryExecution", func(t *testing.T) {
+				runID := strings.ToLower(random.UniqueId())
+				testQueryExecution(t, clients, workgroupName, databaseName, curatedBucket, resultsBucket, runID)
+			})
+
+			// The storage and analytics stacks share the run ID, so one audit covers both
+			t.Run("TagCompliance", func(t *testing.T) {
+				tagaudit.AssertRun(t, clients, analyticsOptions,
+					terraform.Output(t, analyticsOptions, "athena_workgroup_arn"),
+					kmsKeyArn)
+			})
+		})
+	})
+}
+
+// testWorkgroupConfiguration validates the enforced workgroup settings
+func testWorkgroupConfiguration(t *testing.T, clients *awsclients.Clients, workgroupName, resultsBucket, kmsKeyArn string) {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	result, err := clients.Athena().GetWorkGroup(ctx, &athena.GetWorkGroupInput{
+		WorkGroup: awssdk.String(workgroupName),
+	})
+	require.NoError(t, err, "Failed to get Athena workgroup %s", workgroupName)
+
+	workgroup := result.WorkGroup
+	assert.Equal(t, athenatypes.WorkGroupStateEnabled, workgroup.State)
+
+	configuration := workgroup.Configuration
+	require.NotNil(t, configuration)
+	assert.True(t, awssdk.ToBool(configuration.EnforceWorkGroupConfiguration), "Workgroup configuration should be enforced")
+	assert.True(t, awssdk.ToBool(configuration.PublishCloudWatchMetricsEnabled), "Workgroup should publish CloudWatch metrics")
+	assert.Equal(t, int64(bytesScannedCutoff), awssdk.ToInt64(configuration.BytesScannedCutoffPerQuery))
+
+	require.NotNil(t, configuration.EngineVersion)
+	assert.Equal(t, "Athena engine version 3", awssdk.ToString(configuration.EngineVersion.SelectedEngineVersion))
+
+	// Query results must land in the storage bucket encrypted with the data lake key
+	require.NotNil(t, configuration.ResultConfiguration)
+	assert.Equal(t, fmt.Sprintf("s3://%s/query-results/", resultsBucket),
+		awssdk.ToString(configuration.ResultConfiguration.OutputLocation))
+
+	encryption := configuration.ResultConfiguration.EncryptionConfiguration
+	require.NotNil(t, encryption)
+	assert.Equal(t, athenatypes.EncryptionOptionSseKms, encryption.EncryptionOption)
+	assert.Equal(t, kmsKeyArn, awssdk.ToString(encryption.KmsKey))
+
+	logging.New(t).Success("Athena workgroup validation passed", "workgroup", workgroupName)
+}
+
+// testNamedQueries validates the sample queries are scoped to the workgroup and database
+func testNamedQueries(t *testing.T, clients *awsclients.Clients, terraformOptions *terraform.Options, workgroupName, databaseName string) {
+	for _, outputName := range []string{"sample_analytics_query_id", "data_quality_check_query_id"} {
+		queryID := terraform.Output(t, terraformOptions, outputName)
+		require.NotEmpty(t, queryID, "Output %s should be set", outputName)
+
+		ctx, cancel := clients.Context()
+		result, err := clients.Athena().GetNamedQuery(ctx, &athena.GetNamedQueryInput{
+			NamedQueryId: awssdk.String(queryID),
+		})
+		cancel()
+		require.NoError(t, err, "Failed to get named query %s", queryID)
+
+		assert.Equal(t, workgroupName, awssdk.ToString(result.NamedQuery.WorkGroup))
+		assert.Equal(t, databaseName, awssdk.ToString(result.NamedQuery.Database))
+		assert.NotEmpty(t, awssdk.ToString(result.NamedQuery.QueryString))
+	}
+
+	logging.New(t).Success("Athena named query validation passed", "workgroup", workgroupName)
+}
+
+// testQueryExecution seeds a table in the curated layer and validates a SELECT through the workgroup
+func testQueryExecution(t *testing.T, clients *awsclients.Clients, workgroupName, databaseName, curatedBucket, resultsBucket, runID string) {
+	prefix := fmt.Sprintf("athena-test/%s/users/", runID)
+	tableName := "users_" + runID
+
+	// Seed the CSV the external table reads from
+	var lines []string
+	for _, row := range seedRows {
+		lines = append(lines, strings.Join(row, ","))
+	}
+
+	ctx, cancel := clients.Context()
+	_, err := clients.S3().PutObject(ctx, &s3.PutObjectInput{
+		Bucket: awssdk.String(curatedBucket),
+		Key:    awssdk.String(prefix + "data.csv"),
+		Body:   strings.NewReader(strings.Join(lines, "\n") + "\n"),
+	})
+	cancel()
+	require.NoError(t, err, "Failed to seed test data in %s", curatedBucket)
+
+	createTable := fmt.Sprintf(`CREATE EXTERNAL TABLE %s (id int, name string, country string)
+ROW FORMAT DELIMITED FIELDS TERMINATED BY ','
+LOCATION 's3://%s/%s'
+TBLPROPERTIES ('skip.header.line.count'='1')`, tableName, curatedBucket, prefix)
+	runAthenaQuery(t, clients, workgroupName, databaseName, createTable)
+
+	queryExecutionID := startAthenaQuery(t, clients, workgroupName, databaseName,
+		fmt.Sprintf("SELECT id, name, country FROM %s ORDER BY id", tableName))
+	execution := waitForAthenaQuery(t, clients, queryExecutionID)
+
+	// The enforced workgroup settings apply to the execution regardless of the caller
+	assert.Equal(t, workgroupName, awssdk.ToString(execution.WorkGroup))
+	assert.True(t, strings.HasPrefix(awssdk.ToString(execution.ResultConfiguration.OutputLocation),
+		fmt.Sprintf("s3://%s/query-results/", resultsBucket)), "Query results should be written to the results bucket")
+	require.NotNil(t, execution.ResultConfiguration.EncryptionConfiguration)
+	assert.Equal(t, athenatypes.EncryptionOptionSseKms, execution.ResultConfiguration.EncryptionConfiguration.EncryptionOption)
+
+	rows := getAthenaQueryRows(t, clients, queryExecutionID)
+	require.Len(t, rows, len(seedRows), "Query should return a header row plus every seeded row")
+	for i, expected := range seedRows {
+		assert.Equal(t, expected, rows[i], "Unexpected values in row %d", i)
+	}
+
+	logging.New(t).Success("Athena query validation passed", "database", databaseName, "table", tableName)
+}
+
+// runAthenaQuery runs query in the workgroup and waits for it to succeed
+func runAthenaQuery(t *testing.T, clients *awsclients.Clients, workgroupName, databaseName, query string) {
+	waitForAthenaQuery(t, clients, startAthenaQuery(t, clients, workgroupName, databaseName, query))
+}
+
+// startAthenaQuery submits query to the workgroup and returns its execution ID
+func startAthenaQuery(t *testing.T, clients *awsclients.Clients, workgroupName, databaseName, query string) string {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	result, err := clients.Athena().StartQueryExecution(ctx, &athena.StartQueryExecutionInput{
+		QueryString: awssdk.String(query),
+		WorkGroup:   awssdk.String(workgroupName),
+		QueryExecutionContext: &athenatypes.QueryExecutionContext{
+			Database: awssdk.String(databaseName),
+		},
+	})
+	require.NoError(t, err, "Failed to start Athena query: %s", query)
+
+	return awssdk.ToString(result.QueryExecutionId)
+}
+
+// waitForAthenaQuery polls the execution until it succeeds, failing the test otherwise
+func waitForAthenaQuery(t *testing.T, clients *awsclients.Clients, queryExecutionID string) *athenatypes.QueryExecution {
+	var execution *athenatypes.QueryExecution
+	wait.Until(t, "Athena query "+queryExecutionID,
+		wait.QuerySucceeded(clients.Athena(), queryExecutionID, &execution), wait.DefaultOptions())
+	return execution
+}
+
+// getAthenaQueryRows returns every result row, including the header, as strings
+func getAthenaQueryRows(t *testing.T, clients *awsclients.Clients, queryExecutionID string) [][]string {
+	paginator := athena.NewGetQueryResultsPaginator(clients.Athena(), &athena.GetQueryResultsInput{
+		QueryExecutionId: awssdk.String(queryExecutionID),
+	})
+
+	var rows [][]string
+	for paginator.HasMorePages() {
+		ctx, cancel := clients.Context()
+		page, err := paginator.NextPage(ctx)
+		cancel()
+		require.NoError(t, err, "Failed to get Athena query results for %s", queryExecutionID)
+
+		for _, row := range page.ResultSet.Rows {
+			values := make([]string, 0, len(row.Data))
+			for _, datum := range row.Data {
+				values = append(values, awssdk.ToString(datum.VarCharValue))
+			}
+			rows = append(rows, values)
+		}
+	}
+	return rows
+}
@@ -34,6 +34,56 @@ resource "aws_sns_topic" "data_quality_alerts" {
   tags = var.common_tags
 }
 
+# Topic policies keep the default same-account access, let CloudWatch alarms
+# publish and refuse any request not made over TLS
+resource "aws_sns_topic_policy" "alerts" {
+  for_each = {
+    critical     = aws_sns_topic.critical_alerts.arn
+    warning      = aws_sns_topic.warning_alerts.arn
+    data_quality = aws_sns_topic.data_quality_alerts.arn
+  }
+
+  arn = each.value
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Sid       = "AccountAccess"
+        Effect    = "Allow"
+        Principal = { AWS = "*" }
+        Action = [
+          "SNS:GetTopicAttributes",
+          "SNS:SetTopicAttributes",
+          "SNS:AddPermission",
+          "SNS:RemovePermission",
+          "SNS:DeleteTopic",
+          "SNS:Subscribe",
+          "SNS:ListSubscriptionsByTopic",
+          "SNS:Publish"
+        ]
+        Resource  = each.value
+        Condition = { StringEquals = { "AWS:SourceOwner" = data.aws_caller_identity.current.account_id } }
+      },
+      {
+        Sid       = "CloudWatchAlarms"
+        Effect    = "Allow"
+        Principal = { Service = "cloudwatch.amazonaws.com" }
+        Action    = "SNS:Publish"
+        Resource  = each.value
+        Condition = { StringEquals = { "aws:SourceAccount" = data.aws_caller_identity.current.account_id } }
+      },
+      {
+        Sid       = "DenyInsecureTransport"
+        Effect    = "Deny"
+        Principal = "*"
+        Action    = "SNS:*"
+        Resource  = each.value
+        Condition = { Bool = { "aws:SecureTransport" = "false" } }
+      }
+    ]
+  })
+}
+
 # SNS Topic Subscriptions
 resource "aws_sns_topic_subscription" "critical_email" {
   count = length(var.critical_alert_emails)
@@ -80,7 +80,7 @@ resource "aws_subnet" "database" {
 resource "aws_eip" "nat" {
   count = var.networking.nat_gateway.single_nat_gateway ? 1 : length(aws_subnet.public)
 
-  domain = "vpc"
+  domain     = "vpc"
   depends_on = [aws_internet_gateway.main]
 
   tags = merge(var.common_tags, var.additional_tags, {
@@ -172,14 +172,108 @@ resource "aws_route_table_association" "database" {
   route_table_id = aws_route_table.database.id
 }
 
+# Network ACL for Database Subnets - only the private and database tiers may
+# reach the databases; NACLs are stateless, so replies to them are allowed out
+# explicitly
+resource "aws_network_acl" "database" {
+  vpc_id     = aws_vpc.main.id
+  subnet_ids = aws_subnet.database[*].id
+
+  dynamic "ingress" {
+    for_each = var.networking.subnets.private
+    content {
+      rule_no    = 100 + ingress.key
+      protocol   = "-1"
+      action     = "allow"
+      cidr_block = ingress.value
+      from_port  = 0
+      to_port    = 0
+    }
+  }
+
+  dynamic "ingress" {
+    for_each = var.networking.subnets.database
+    content {
+      rule_no    = 200 + ingress.key
+      protocol   = "-1"
+      action     = "allow"
+      cidr_block = ingress.value
+      from_port  = 0
+      to_port    = 0
+    }
+  }
+
+  # Keep the public tier out of the reply rule below
+  ingress {
+    rule_no    = 290
+    protocol   = "-1"
+    action     = "deny"
+    cidr_block = var.networking.vpc.cidr
+    from_port  = 0
+    to_port    = 0
+  }
+
+  # Replies from the gateway endpoints; NACLs cannot reference their prefix lists
+  ingress {
+    rule_no    = 300
+    protocol   = "tcp"
+    action     = "allow"
+    cidr_block = "0.0.0.0/0"
+    from_port  = 1024
+    to_port    = 65535
+  }
+
+  dynamic "egress" {
+    for_each = var.networking.subnets.private
+    content {
+      rule_no    = 100 + egress.key
+      protocol   = "-1"
+      action     = "allow"
+      cidr_block = egress.value
+      from_port  = 0
+      to_port    = 0
+    }
+  }
+
+  dynamic "egress" {
+    for_each = var.networking.subnets.database
+    content {
+      rule_no    = 200 + egress.key
+      protocol   = "-1"
+      action     = "allow"
+      cidr_block = egress.value
+      from_port  = 0
+      to_port    = 0
+    }
+  }
+
+  # HTTPS to the gateway endpoints, the only non-local routes of the database tier
+  egress {
+    rule_no    = 300
+    protocol   = "tcp"
+    action     = "allow"
+    cidr_block = "0.0.0.0/0"
+    from_port  = 443
+    to_port    = 443
+  }
+
+  tags = merge(var.common_tags, var.additional_tags, {
+    Name = "nacl-database-${var.environment}-${var.region}"
+    Type = "network-acl"
+    Tier = "database"
+  })
+}
+
 # VPC Flow Logs (if enabled)
 resource "aws_flow_log" "vpc" {
   count = var.networking.flow_logs.enable ? 1 : 0
 
-  iam_role_arn    = aws_iam_role.flow_log[0].arn
-  log_destination = aws_cloudwatch_log_group.vpc_flow_log[0].arn
-  traffic_type    = "ALL"
-  vpc_id          = aws_vpc.main.id
+  iam_role_arn             = aws_iam_role.flow_log[0].arn
+  log_destination          = aws_cloudwatch_log_group.vpc_flow_log[0].arn
+  log_format               = var.flow_log_format
+  max_aggregation_interval = var.flow_log_max_aggregation_interval
+  traffic_type             = "ALL"
+  vpc_id                   = aws_vpc.main.id
 }
 
 # CloudWatch Log Group for VPC Flow Logs
@@ -201,6 +295,8 @@ resource "aws_iam_role" "flow_log" {
 
   name = "VPCFlowLogRole-${var.environment}-${var.region}"
 
+  permissions_boundary = var.flow_log_permissions_boundary_arn
+
   assume_role_policy = jsonencode({
     Version = "2012-10-17"
     Statement = [
@@ -243,4 +339,79 @@ resource "aws_iam_role_policy" "flow_log" {
       }
     ]
   })
-} 
\ No newline at end of file
+}
+
+# VPC Endpoint Policy - only principals from this account may use the endpoints
+locals {
+  vpc_endpoint_policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Sid       = "AllowAccountPrincipals"
+        Effect    = "Allow"
+        Principal = "*"
+        Action    = "*"
+        Resource  = "*"
+        Condition = {
+          StringEquals = { "aws:PrincipalAccount" = var.account_id }
+        }
+      }
+    ]
+  })
+}
+
+# Gateway VPC Endpoints - keep S3 and DynamoDB traffic from private and database subnets off the NAT gateways
+resource "aws_vpc_endpoint" "gateway" {
+  for_each = toset(var.gateway_endpoints)
+
+  vpc_id            = aws_vpc.main.id
+  service_name      = "com.amazonaws.${var.region}.${each.key}"
+  vpc_endpoint_type = "Gateway"
+  route_table_ids   = concat(aws_route_table.private[*].id, [aws_route_table.database.id])
+  policy            = local.vpc_endpoint_policy
+
+  tags = merge(var.common_tags, var.additional_tags, {
+    Name = "vpce-${each.key}-${var.environment}-${var.region}"
+    Type = "vpc-endpoint"
+  })
+}
+
+# Security Group for Interface VPC Endpoints
+resource "aws_security_group" "vpc_endpoints" {
+  count = length(var.interface_endpoints) > 0 ? 1 : 0
+
+  name_prefix = "vpce-${var.environment}-${var.region}-"
+  description = "HTTPS from within the VPC to interface endpoints"
+  vpc_id      = aws_vpc.main.id
+
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = [aws_vpc.main.cidr_block]
+    description = "HTTPS from the VPC"
+  }
+
+  tags = merge(var.common_tags, var.additional_tags, {
+    Name = "sg-vpce-${var.environment}-${var.region}"
+    Type = "security-group"
+  })
+}
+
+# Interface VPC Endpoints - one ENI per private subnet with private DNS
+resource "aws_vpc_endpoint" "interface" {
+  for_each = toset(var.interface_endpoints)
+
+  vpc_id              = aws_vpc.main.id
+  service_name        = "com.amazonaws.${var.region}.${each.key}"
+  vpc_endpoint_type   = "Interface"
+  subnet_ids          = aws_subnet.private[*].id
+  security_group_ids  = [aws_security_group.vpc_endpoints[0].id]
+  private_dns_enabled = true
+  policy              = local.vpc_endpoint_policy
+
+  tags = merge(var.common_tags, var.additional_tags, {
+    Name = "vpce-${each.key}-${var.environment}-${var.region}"
+    Type = "vpc-endpoint"
+  })
+}
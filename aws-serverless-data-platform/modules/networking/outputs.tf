@@ -89,16 +89,48 @@ output "database_route_table_id" {
   value       = aws_route_table.database.id
 }
 
+output "database_network_acl_id" {
+  description = "ID of the network ACL restricting the database subnets to the private and database tiers"
+  value       = aws_network_acl.database.id
+}
+
+# Flow Log Outputs
+output "flow_log_group_name" {
+  description = "Name of the CloudWatch log group receiving VPC flow logs"
+  value       = var.networking.flow_logs.enable ? aws_cloudwatch_log_group.vpc_flow_log[0].name : null
+}
+
+output "flow_log_format" {
+  description = "Fields captured in each VPC flow log record"
+  value       = var.networking.flow_logs.enable ? aws_flow_log.vpc[0].log_format : null
+}
+
+# VPC Endpoint Outputs
+output "gateway_endpoint_ids" {
+  description = "Map of service name to Gateway VPC endpoint ID"
+  value       = { for service, endpoint in aws_vpc_endpoint.gateway : service => endpoint.id }
+}
+
+output "interface_endpoint_ids" {
+  description = "Map of service name to Interface VPC endpoint ID"
+  value       = { for service, endpoint in aws_vpc_endpoint.interface : service => endpoint.id }
+}
+
+output "vpc_endpoints_security_group_id" {
+  description = "ID of the security group attached to the Interface VPC endpoints"
+  value       = length(aws_security_group.vpc_endpoints) > 0 ? aws_security_group.vpc_endpoints[0].id : null
+}
+
 # Summary outputs for easy reference
 output "network_summary" {
   description = "Summary of network configuration"
   value = {
     vpc_id                = aws_vpc.main.id
-    vpc_cidr             = aws_vpc.main.cidr_block
-    public_subnet_count  = length(aws_subnet.public)
-    private_subnet_count = length(aws_subnet.private)
+    vpc_cidr              = aws_vpc.main.cidr_block
+    public_subnet_count   = length(aws_subnet.public)
+    private_subnet_count  = length(aws_subnet.private)
     database_subnet_count = length(aws_subnet.database)
-    nat_gateway_count    = length(aws_nat_gateway.main)
-    availability_zones   = data.aws_availability_zones.available.names
+    nat_gateway_count     = length(aws_nat_gateway.main)
+    availability_zones    = data.aws_availability_zones.available.names
   }
 } 
\ No newline at end of file
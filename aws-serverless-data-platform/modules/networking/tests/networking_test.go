@@ -6,214 +6,491 @@
 package test
 
 import (
+	"flag"
+	"net"
+	"strings"
 	"testing"
+	"time"
 
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/gruntwork-io/terratest/modules/aws"
-	"github.com/gruntwork-io/terratest/modules/random"
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/flowlogs"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/iampolicy"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/inventory"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logs"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/matrix"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/naming"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/netpath"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/routing"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/secgroups"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tagaudit"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tfplan"
 )
 
+// update rewrites the plan snapshots instead of comparing against them: go test -run Plan -update
+var update = flag.Bool("update", false, "rewrite Terraform plan snapshots under "+tfplan.SnapshotDir)
+
+// TestMain runs the suite against the account selected by TERRATEST_ASSUME_ROLE_ARN
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
+
 // TestNetworking tests the networking module
 func TestNetworking(t *testing.T) {
 	t.Parallel()
 
-	// Generate a random ID for unique resource naming
-	uniqueID := random.UniqueId()
+	matrix.Run(t, func(t *testing.T, region matrix.Region) {
+		testNetworking(t, region)
+	})
+}
 
-	// AWS region for testing
-	awsRegion := "us-east-1"
+// testNetworking deploys and validates the networking module in one region
+func testNetworking(t *testing.T, region matrix.Region) {
+	awsRegion := region.Name
 
 	// Expected values
-	expectedEnvironment := "test"
-	expectedVPCCIDR := "10.0.0.0/16"
+	expectedVPCCIDR := region.VPCCIDR(0)
 	expectedAZCount := 3
+	expectedFlowLogRetention := 30
 
-	// Terraform options
-	terraformOptions := &terraform.Options{
-		// Path to the Terraform code that will be tested
-		TerraformDir: "../",
-
-		// Variables to pass to our Terraform code using -var options
-		Vars: map[string]interface{}{
-			"environment": expectedEnvironment,
-			"region":      awsRegion,
-			"account_id":  "356240508702",
-			"vpc_name":    "test-vpc-" + uniqueID,
-			"networking": map[string]interface{}{
-				"vpc": map[string]interface{}{
-					"cidr":                 expectedVPCCIDR,
-					"enable_dns_hostnames": true,
-					"enable_dns_support":   true,
-				},
-				"subnets": map[string]interface{}{
-					"private": []string{
-						"10.0.1.0/24",
-						"10.0.2.0/24",
-						"10.0.3.0/24",
-					},
-					"public": []string{
-						"10.0.101.0/24",
-						"10.0.102.0/24",
-						"10.0.103.0/24",
-					},
-					"database": []string{
-						"10.0.201.0/24",
-						"10.0.202.0/24",
-						"10.0.203.0/24",
-					},
-				},
-				"availability_zones": expectedAZCount,
-				"nat_gateway": map[string]interface{}{
-					"enable":             true,
-					"single_nat_gateway": false,
-				},
-				"flow_logs": map[string]interface{}{
-					"enable":         true,
-					"retention_days": 30,
-				},
-			},
-			"common_tags": map[string]interface{}{
-				"Environment": expectedEnvironment,
-				"Project":     "terratest",
-				"Testing":     "true",
-			},
-		},
-
-		// Environment variables to set when running Terraform
-		EnvVars: map[string]string{
-			"AWS_DEFAULT_REGION": awsRegion,
-		},
-	}
+	stageDir := testutil.StageDir(t)
 
 	// At the end of the test, run `terraform destroy` to clean up any resources that were created
-	defer terraform.Destroy(t, terraformOptions)
+	defer testutil.Teardown(t, stageDir)
 
 	// This will run `terraform init` and `terraform apply` and fail the test if there are any errors
-	terraform.InitAndApply(t, terraformOptions)
+	terraformOptions := testutil.Deploy(t, stageDir, func() *terraform.Options {
+		// Region-scoped CIDR and unique resource naming; one-minute flow log
+		// aggregation so records arrive while the test is still running
+		opts := region.Options(testutil.WithVar("flow_log_max_aggregation_interval", 60))
+		return testutil.NewTerraformOptions(t, "../", testutil.NewNetworkingVars(opts...), opts...)
+	})
+
+	testutil.Validate(t, func() {
+		// Run `terraform output` to get the value of output variables
+		vpcID := terraform.Output(t, terraformOptions, "vpc_id")
+		vpcCIDR := terraform.Output(t, terraformOptions, "vpc_cidr_block")
+		publicSubnetIDs := terraform.OutputList(t, terraformOptions, "public_subnet_ids")
+		privateSubnetIDs := terraform.OutputList(t, terraformOptions, "private_subnet_ids")
+		databaseSubnetIDs := terraform.OutputList(t, terraformOptions, "database_subnet_ids")
+
+		// Verify the VPC was created with expected configuration
+		assert.NotEmpty(t, vpcID)
+		assert.Equal(t, expectedVPCCIDR, vpcCIDR)
+
+		// Verify subnets were created
+		assert.Equal(t, expectedAZCount, len(publicSubnetIDs))
+		assert.Equal(t, expectedAZCount, len(privateSubnetIDs))
+		assert.Equal(t, expectedAZCount, len(databaseSubnetIDs))
+
+		// Verify VPC exists in AWS
+		vpc := aws.GetVpcById(t, vpcID, awsRegion)
+		assert.Equal(t, expectedVPCCIDR, *vpc.CidrBlock)
+
+		// Verify subnets were created by checking terraform outputs
+		// We rely on terraform outputs rather than AWS API calls for subnet verification
+		// since GetSubnetById and related methods don't exist in terratest
+		assert.NotEmpty(t, publicSubnetIDs, "Public subnets should be created")
+		assert.NotEmpty(t, privateSubnetIDs, "Private subnets should be created")
+		assert.NotEmpty(t, databaseSubnetIDs, "Database subnets should be created")
+
+		// Test the paths between tiers with the Reachability Analyzer, alongside
+		// the gateways they depend on
+		t.Run("NetworkConnectivity", func(t *testing.T) {
+			// Verify Internet Gateway exists
+			igwID := terraform.Output(t, terraformOptions, "internet_gateway_id")
+			assert.NotEmpty(t, igwID)
+
+			// Verify NAT Gateways exist
+			natGatewayIDs := terraform.OutputList(t, terraformOptions, "nat_gateway_ids")
+			assert.NotEmpty(t, natGatewayIDs)
+
+			// For non-single NAT gateway configuration, should have one per AZ
+			expectedNATCount := expectedAZCount
+			assert.Equal(t, expectedNATCount, len(natGatewayIDs))
+
+			testConnectivity(t, terraformOptions, awsRegion)
+		})
+
+		// Test each tier's subnets route through the right gateway, read from EC2
+		// rather than trusting the route table outputs
+		t.Run("Routing", func(t *testing.T) {
+			testRouting(t, terraformOptions, awsRegion)
+		})
+
+		// Test every security group in the VPC against the expected rule matrix
+		t.Run("SecurityConfiguration", func(t *testing.T) {
+			testSecurityGroups(t, terraformOptions, awsRegion)
+		})
+
+		// Test flow logs are delivered with every field of the configured format
+		t.Run("FlowLogs", func(t *testing.T) {
+			testFlowLogs(t, terraformOptions, awsRegion, expectedFlowLogRetention)
+		})
+
+		// Test DNS configuration - simplified test using terraform outputs
+		t.Run("DNSConfiguration", func(t *testing.T) {
+			// Since we can't easily test VPC attributes with terratest aws helpers,
+			// we'll rely on terraform configuration and outputs to verify DNS settings
+			// The terraform configuration enables DNS support and hostnames,
+			// so if terraform apply succeeded, these should be enabled
+
+			// Verify VPC exists (this confirms terraform applied successfully)
+			assert.NotEmpty(t, vpcID)
+			assert.Equal(t, expectedVPCCIDR, *vpc.CidrBlock)
+		})
+
+		// Test the gateway and interface endpoints keep service traffic inside the VPC
+		t.Run("VPCEndpoints", func(t *testing.T) {
+			testVPCEndpoints(t, terraformOptions, awsRegion, vpcID)
+		})
 
-	// Run `terraform output` to get the value of output variables
+		// Test the applied state holds exactly the declared resources
+		t.Run("Inventory", func(t *testing.T) {
+			inventory.AssertState(t, terraformOptions, "networking")
+		})
+
+		// Test every resource of the run carries the mandatory tags
+		t.Run("TagCompliance", func(t *testing.T) {
+			tagaudit.AssertRun(t, awsclients.New(t, awsclients.WithRegion(awsRegion)), terraformOptions,
+				terraform.Output(t, terraformOptions, "vpc_arn"))
+		})
+	})
+}
+
+// testRouting checks private subnets reach the internet through the NAT gateways
+// and public subnets through the internet gateway, while database subnets have
+// no internet route and their network ACL only admits the private and database
+// tiers and replies from the gateway endpoints
+func testRouting(t *testing.T, terraformOptions *terraform.Options, awsRegion string) {
+	vpcID := terraform.Output(t, terraformOptions, "vpc_id")
+	databaseSubnetIDs := terraform.OutputList(t, terraformOptions, "database_subnet_ids")
+	clients := awsclients.New(t, awsclients.WithRegion(awsRegion))
+
+	routing.AssertRoutes(t, clients, vpcID,
+		routing.Expectation{
+			Tier:           "public",
+			SubnetIDs:      terraform.OutputList(t, terraformOptions, "public_subnet_ids"),
+			DefaultTargets: []string{terraform.Output(t, terraformOptions, "internet_gateway_id")},
+		},
+		routing.Expectation{
+			Tier:           "private",
+			SubnetIDs:      terraform.OutputList(t, terraformOptions, "private_subnet_ids"),
+			DefaultTargets: terraform.OutputList(t, terraformOptions, "nat_gateway_ids"),
+		},
+		routing.Expectation{
+			Tier:      "database",
+			SubnetIDs: databaseSubnetIDs,
+		},
+	)
+
+	// The private and database tiers reach the databases; replies from the
+	// gateway endpoints come back from their public addresses
+	routing.AssertIngressOnlyFrom(t, clients, vpcID, "database", databaseSubnetIDs,
+		append(terraform.OutputList(t, terraformOptions, "private_subnet_cidrs"),
+			terraform.OutputList(t, terraformOptions, "database_subnet_cidrs")...),
+		[]string{"0.0.0.0/0"})
+
+	// The reply rule spans the database ports, so the public tier must be denied before it
+	routing.AssertDenied(t, clients, vpcID, "database", databaseSubnetIDs,
+		terraform.OutputList(t, terraformOptions, "public_subnet_cidrs"), "6", 5432)
+}
+
+// testSecurityGroups checks the VPC holds only the default group and the
+// interface endpoints' group, neither admitting traffic from outside the VPC
+func testSecurityGroups(t *testing.T, terraformOptions *terraform.Options, awsRegion string) {
 	vpcID := terraform.Output(t, terraformOptions, "vpc_id")
 	vpcCIDR := terraform.Output(t, terraformOptions, "vpc_cidr_block")
-	publicSubnetIDs := terraform.OutputList(t, terraformOptions, "public_subnet_ids")
+
+	secgroups.AssertMatrix(t, awsclients.New(t, awsclients.WithRegion(awsRegion)), vpcID,
+		// The module leaves the default group as AWS creates it: members may
+		// reach each other and anything outside
+		secgroups.Expectation{
+			Name:    "default",
+			Ingress: []secgroups.Rule{{Protocol: "-1", Source: secgroups.Self}},
+			Egress:  []secgroups.Rule{{Egress: true, Protocol: "-1", Source: "0.0.0.0/0"}},
+		},
+		// Terraform drops the implicit allow-all egress of groups it creates
+		secgroups.Expectation{
+			GroupID: terraform.Output(t, terraformOptions, "vpc_endpoints_security_group_id"),
+			Name:    "vpc-endpoints",
+			Ingress: []secgroups.Rule{{Protocol: "tcp", Ports: "443", Source: vpcCIDR}},
+		},
+	)
+}
+
+// testConnectivity analyzes paths between probe ENIs in each tier: the private
+// tier reaches its interface endpoints and the database tier, while the
+// database NACL keeps the public tier out and the database tier has no route to
+// the internet
+func testConnectivity(t *testing.T, terraformOptions *terraform.Options, awsRegion string) {
+	clients := awsclients.New(t, awsclients.WithRegion(awsRegion))
+	igwID := terraform.Output(t, terraformOptions, "internet_gateway_id")
+	interfaceIDs := terraform.OutputMap(t, terraformOptions, "interface_endpoint_ids")
+
+	private := netpath.NewProbe(t, clients, terraform.OutputList(t, terraformOptions, "private_subnet_ids")[0], "private")
+	public := netpath.NewProbe(t, clients, terraform.OutputList(t, terraformOptions, "public_subnet_ids")[0], "public")
+	database := netpath.NewProbe(t, clients, terraform.OutputList(t, terraformOptions, "database_subnet_ids")[0], "database")
+
+	paths := []netpath.Path{
+		{Description: "private to database", Source: private, Destination: database, Protocol: "tcp", Port: 5432, Reachable: true},
+		{Description: "public to database", Source: public, Destination: database, Protocol: "tcp", Port: 5432},
+		{Description: "database to internet", Source: database, Destination: igwID, Protocol: "tcp", Port: 443},
+	}
+	for service, id := range interfaceIDs {
+		paths = append(paths, netpath.Path{
+			Description: "private to " + service + " endpoint", Source: private, Destination: id,
+			Protocol: "tcp", Port: 443, Reachable: true,
+		})
+	}
+	netpath.Assert(t, clients, paths...)
+}
+
+// testVPCEndpoints checks the Gateway and Interface endpoints are available, attached to the
+// private tiers and restricted to this account, and that S3 routes bypass the NAT gateways
+func testVPCEndpoints(t *testing.T, terraformOptions *terraform.Options, awsRegion, vpcID string) {
+	gatewayIDs := terraform.OutputMap(t, terraformOptions, "gateway_endpoint_ids")
+	interfaceIDs := terraform.OutputMap(t, terraformOptions, "interface_endpoint_ids")
 	privateSubnetIDs := terraform.OutputList(t, terraformOptions, "private_subnet_ids")
-	databaseSubnetIDs := terraform.OutputList(t, terraformOptions, "database_subnet_ids")
+	securityGroupID := terraform.Output(t, terraformOptions, "vpc_endpoints_security_group_id")
+	routeTableIDs := append(terraform.OutputList(t, terraformOptions, "private_route_table_ids"),
+		terraform.Output(t, terraformOptions, "database_route_table_id"))
+	accountID := aws.GetAccountId(t)
 
-	// Verify the VPC was created with expected configuration
-	assert.NotEmpty(t, vpcID)
-	assert.Equal(t, expectedVPCCIDR, vpcCIDR)
-
-	// Verify subnets were created
-	assert.Equal(t, expectedAZCount, len(publicSubnetIDs))
-	assert.Equal(t, expectedAZCount, len(privateSubnetIDs))
-	assert.Equal(t, expectedAZCount, len(databaseSubnetIDs))
-
-	// Verify VPC exists in AWS
-	vpc := aws.GetVpcById(t, vpcID, awsRegion)
-	assert.Equal(t, expectedVPCCIDR, *vpc.CidrBlock)
-
-	// Verify subnets were created by checking terraform outputs
-	// We rely on terraform outputs rather than AWS API calls for subnet verification
-	// since GetSubnetById and related methods don't exist in terratest
-	assert.NotEmpty(t, publicSubnetIDs, "Public subnets should be created")
-	assert.NotEmpty(t, privateSubnetIDs, "Private subnets should be created")
-	assert.NotEmpty(t, databaseSubnetIDs, "Database subnets should be created")
-
-	// Test network connectivity (basic ping test)
-	// This could be expanded to test actual connectivity between subnets
-	t.Run("NetworkConnectivity", func(t *testing.T) {
-		// Verify Internet Gateway exists
-		igwID := terraform.Output(t, terraformOptions, "internet_gateway_id")
-		assert.NotEmpty(t, igwID)
-
-		// Verify NAT Gateways exist
-		natGatewayIDs := terraform.OutputList(t, terraformOptions, "nat_gateway_ids")
-		assert.NotEmpty(t, natGatewayIDs)
-
-		// For non-single NAT gateway configuration, should have one per AZ
-		expectedNATCount := expectedAZCount
-		assert.Equal(t, expectedNATCount, len(natGatewayIDs))
-	})
+	require.ElementsMatch(t, []string{"s3", "dynamodb"}, keys(gatewayIDs))
+	require.ElementsMatch(t, []string{"glue", "kinesis-streams", "sts"}, keys(interfaceIDs))
 
-	// Test security groups - simplified to basic VPC verification
-	t.Run("SecurityConfiguration", func(t *testing.T) {
-		// Verify VPC exists and has the expected CIDR
-		// Security group verification is simplified since GetSecurityGroupsForVpc
-		// and similar methods have compatibility issues
-		assert.NotEmpty(t, vpcID)
-		assert.Equal(t, expectedVPCCIDR, *vpc.CidrBlock)
+	clients := awsclients.New(t, awsclients.WithRegion(awsRegion))
+	ec2Client := clients.EC2()
+
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	output, err := ec2Client.DescribeVpcEndpoints(ctx, &ec2.DescribeVpcEndpointsInput{
+		Filters: []ec2types.Filter{{Name: awssdk.String("vpc-id"), Values: []string{vpcID}}},
 	})
+	require.NoError(t, err, "Failed to describe VPC endpoints")
 
-	// Test DNS configuration - simplified test using terraform outputs
-	t.Run("DNSConfiguration", func(t *testing.T) {
-		// Since we can't easily test VPC attributes with terratest aws helpers,
-		// we'll rely on terraform configuration and outputs to verify DNS settings
-		// The terraform configuration enables DNS support and hostnames,
-		// so if terraform apply succeeded, these should be enabled
+	endpoints := map[string]ec2types.VpcEndpoint{}
+	for _, endpoint := range output.VpcEndpoints {
+		endpoints[awssdk.ToString(endpoint.VpcEndpointId)] = endpoint
+	}
 
-		// Verify VPC exists (this confirms terraform applied successfully)
-		assert.NotEmpty(t, vpcID)
-		assert.Equal(t, expectedVPCCIDR, *vpc.CidrBlock)
+	assertEndpoint := func(t *testing.T, service, id string, endpointType ec2types.VpcEndpointType) ec2types.VpcEndpoint {
+		endpoint, ok := endpoints[id]
+		require.True(t, ok, "Endpoint %s for %s not found in VPC %s", id, service, vpcID)
+
+		assert.Equal(t, endpointType, endpoint.VpcEndpointType)
+		assert.Equal(t, "com.amazonaws."+awsRegion+"."+service, awssdk.ToString(endpoint.ServiceName))
+		assert.True(t, strings.EqualFold(string(ec2types.StateAvailable), string(endpoint.State)),
+			"Endpoint %s should be available, got %s", id, endpoint.State)
+		assertEndpointPolicy(t, awssdk.ToString(endpoint.PolicyDocument), accountID)
+		return endpoint
+	}
+
+	for service, id := range gatewayIDs {
+		t.Run(service, func(t *testing.T) {
+			endpoint := assertEndpoint(t, service, id, ec2types.VpcEndpointTypeGateway)
+			assert.ElementsMatch(t, routeTableIDs, endpoint.RouteTableIds,
+				"Gateway endpoint %s should be attached to the private and database route tables", service)
+		})
+	}
+
+	for service, id := range interfaceIDs {
+		t.Run(service, func(t *testing.T) {
+			endpoint := assertEndpoint(t, service, id, ec2types.VpcEndpointTypeInterface)
+			assert.ElementsMatch(t, privateSubnetIDs, endpoint.SubnetIds,
+				"Interface endpoint %s should have an ENI in every private subnet", service)
+			assert.True(t, awssdk.ToBool(endpoint.PrivateDnsEnabled), "Private DNS should be enabled for %s", service)
+
+			var groups []string
+			for _, group := range endpoint.Groups {
+				groups = append(groups, awssdk.ToString(group.GroupId))
+			}
+			assert.Equal(t, []string{securityGroupID}, groups)
+		})
+	}
+
+	// The S3 prefix list route is more specific than the default NAT route, so
+	// traffic to S3 from the private tiers leaves through the endpoint
+	t.Run("S3RoutesBypassNAT", func(t *testing.T) {
+		prefixLists, err := ec2Client.DescribePrefixLists(ctx, &ec2.DescribePrefixListsInput{
+			Filters: []ec2types.Filter{{Name: awssdk.String("prefix-list-name"), Values: []string{"com.amazonaws." + awsRegion + ".s3"}}},
+		})
+		require.NoError(t, err, "Failed to describe the S3 prefix list")
+		require.Len(t, prefixLists.PrefixLists, 1)
+		prefixListID := awssdk.ToString(prefixLists.PrefixLists[0].PrefixListId)
+
+		routeTables, err := ec2Client.DescribeRouteTables(ctx, &ec2.DescribeRouteTablesInput{RouteTableIds: routeTableIDs})
+		require.NoError(t, err, "Failed to describe route tables")
+
+		for _, routeTable := range routeTables.RouteTables {
+			routed := false
+			for _, route := range routeTable.Routes {
+				if awssdk.ToString(route.DestinationPrefixListId) == prefixListID {
+					routed = awssdk.ToString(route.GatewayId) == gatewayIDs["s3"]
+				}
+			}
+			assert.True(t, routed, "Route table %s should send %s to %s",
+				awssdk.ToString(routeTable.RouteTableId), prefixListID, gatewayIDs["s3"])
+		}
 	})
 }
 
+// assertEndpointPolicy checks an endpoint policy only admits principals from accountID
+func assertEndpointPolicy(t *testing.T, document, accountID string) {
+	policy, err := iampolicy.Parse(document)
+	require.NoError(t, err, "Failed to parse endpoint policy")
+
+	for i, statement := range policy.Statement {
+		if statement.Effect != "Allow" {
+			continue
+		}
+		assert.True(t, statement.Condition["StringEquals"]["aws:PrincipalAccount"].Contains(accountID),
+			"Endpoint policy statement %d should be restricted to account %s", i, accountID)
+	}
+}
+
+// testFlowLogs checks the flow log group's retention, sends traffic to the NAT gateways and
+// validates the records that arrive against the configured log format
+func testFlowLogs(t *testing.T, terraformOptions *terraform.Options, awsRegion string, expectedRetention int) {
+	logGroup := terraform.Output(t, terraformOptions, "flow_log_group_name")
+	format := terraform.Output(t, terraformOptions, "flow_log_format")
+	natIPs := terraform.OutputList(t, terraformOptions, "nat_gateway_public_ips")
+	accountID := aws.GetAccountId(t)
+
+	clients := awsclients.New(t, awsclients.WithRegion(awsRegion))
+
+	logs.AssertRetentionDays(t, clients, logGroup, expectedRetention)
+
+	// The VPC was created by this test, so every record in the group belongs to it
+	since := time.Now().Add(-time.Hour)
+
+	// Connection attempts to the NAT gateways' public addresses are recorded on their interfaces
+	for _, ip := range natIPs {
+		for i := 0; i < 3; i++ {
+			conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, "443"), 3*time.Second)
+			if err == nil {
+				conn.Close()
+			}
+		}
+	}
+
+	records := flowlogs.WaitForRecords(t, clients, logGroup, format, since, 15*time.Minute)
+	fields, err := flowlogs.Fields(format)
+	require.NoError(t, err)
+
+	for i, record := range records {
+		for _, problem := range flowlogs.Validate(record, accountID) {
+			t.Errorf("Flow log record %d: %s", i, problem)
+		}
+
+		// Records with data must populate every field of the format
+		if record["log-status"] != "OK" {
+			continue
+		}
+		for _, field := range fields {
+			assert.NotEqual(t, "-", record[field], "Flow log record %d is missing %s", i, field)
+		}
+	}
+
+	logging.New(t).Success("Flow log records match the format", "log_group", logGroup, "records", len(records), "format", format)
+}
+
+// keys returns the keys of m
+func keys(m map[string]string) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	return result
+}
+
 // TestNetworkingWithSingleNATGateway tests the networking module with single NAT gateway configuration
 func TestNetworkingWithSingleNATGateway(t *testing.T) {
 	t.Parallel()
 
-	uniqueID := random.UniqueId()
-	awsRegion := "us-west-2"
-
-	terraformOptions := &terraform.Options{
-		TerraformDir: "../",
-		Vars: map[string]interface{}{
-			"environment": "test",
-			"region":      awsRegion,
-			"account_id":  "356240508702",
-			"vpc_name":    "test-vpc-single-nat-" + uniqueID,
-			"networking": map[string]interface{}{
-				"vpc": map[string]interface{}{
-					"cidr":                 "10.1.0.0/16",
-					"enable_dns_hostnames": true,
-					"enable_dns_support":   true,
-				},
-				"subnets": map[string]interface{}{
-					"private": []string{
-						"10.1.1.0/24",
-						"10.1.2.0/24",
-						"10.1.3.0/24",
-					},
-					"public": []string{
-						"10.1.101.0/24",
-						"10.1.102.0/24",
-						"10.1.103.0/24",
-					},
-					"database": []string{
-						"10.1.201.0/24",
-						"10.1.202.0/24",
-						"10.1.203.0/24",
-					},
-				},
-				"availability_zones": 3,
-				"nat_gateway": map[string]interface{}{
-					"enable":             true,
-					"single_nat_gateway": true, // Single NAT gateway for cost savings
-				},
-				"flow_logs": map[string]interface{}{
-					"enable":         false, // Disabled for cost savings
-					"retention_days": 7,
-				},
-			},
-		},
-		EnvVars: map[string]string{
-			"AWS_DEFAULT_REGION": awsRegion,
-		},
+	matrix.Run(t, func(t *testing.T, region matrix.Region) {
+		stageDir := testutil.StageDir(t)
+
+		defer testutil.Teardown(t, stageDir)
+		terraformOptions := testutil.Deploy(t, stageDir, func() *terraform.Options {
+			opts := region.Options(
+				testutil.WithUniqueSuffix("single-nat-"+region.Suffix()),
+				testutil.WithVPCCIDR(region.VPCCIDR(1)),             // Does not overlap TestNetworking's VPC
+				testutil.WithSingleNATGateway(),                     // Single NAT gateway for cost savings
+				testutil.WithFlowLogs(false, 7),                     // Disabled for cost savings
+				testutil.WithVar("interface_endpoints", []string{}), // Disabled for cost savings
+			)
+			return testutil.NewTerraformOptions(t, "../", testutil.NewNetworkingVars(opts...), opts...)
+		})
+
+		testutil.Validate(t, func() {
+			// Verify single NAT gateway configuration
+			natGatewayIDs := terraform.OutputList(t, terraformOptions, "nat_gateway_ids")
+			assert.Equal(t, 1, len(natGatewayIDs), "Should have exactly one NAT gateway")
+
+			// Every private subnet routes through the shared NAT gateway
+			testRouting(t, terraformOptions, region.Name)
+		})
+	})
+}
+
+// TestNetworkingPlan validates the networking configuration without applying it
+func TestNetworkingPlan(t *testing.T) {
+	t.Parallel()
+
+	opts := []testutil.Option{
+		testutil.WithRegion(testutil.DefaultRegion),
+		// A fixed prefix and suffix keep resource names stable for the plan snapshot
+		testutil.WithRunPrefix("snapshot"),
+		testutil.WithUniqueSuffix("snapshot"),
+		// Environments pass the security module's boundary; any policy ARN plans the same
+		testutil.WithVar("flow_log_permissions_boundary_arn", "arn:aws:iam::"+aws.GetAccountId(t)+":policy/snapshot-permissions-boundary"),
 	}
+	terraformOptions := testutil.NewTerraformOptions(t, "../", testutil.NewNetworkingVars(opts...), opts...)
+	vpcCIDR := testutil.NewSettings(opts...).VPCCIDR
+
+	plan := tfplan.Run(t, terraformOptions)
+
+	// One VPC with three subnets per tier and one NAT gateway per AZ
+	tfplan.AssertResourceCount(t, plan, "aws_vpc", 1)
+	tfplan.AssertResourceCount(t, plan, "aws_subnet", 9)
+	tfplan.AssertResourceCount(t, plan, "aws_nat_gateway", 3)
+	tfplan.AssertResourceCount(t, plan, "aws_route_table", 5)
+	tfplan.AssertResourceCount(t, plan, "aws_network_acl", 1)
+	tfplan.AssertResourceCount(t, plan, "aws_flow_log", 1)
+	tfplan.AssertResourceCount(t, plan, "aws_vpc_endpoint", 5)
+
+	tfplan.AssertAttributeEquals(t, plan, "aws_vpc.main", "cidr_block", vpcCIDR)
+	tfplan.AssertAttributeEquals(t, plan, "aws_vpc.main", "enable_dns_hostnames", true)
+	tfplan.AssertAttributeEquals(t, plan, "aws_vpc.main", "enable_dns_support", true)
+	tfplan.AssertAttributeEquals(t, plan, "aws_subnet.database[0]", "cidr_block",
+		testutil.SubnetCIDRs(vpcCIDR, []int{201})[0])
+	tfplan.AssertAttributeEquals(t, plan, "aws_cloudwatch_log_group.vpc_flow_log[0]", "retention_in_days", 30)
+	tfplan.AssertAttributeEquals(t, plan, "aws_flow_log.vpc[0]", "log_format", flowlogs.DefaultFormat)
+	tfplan.AssertAttributeEquals(t, plan, `aws_vpc_endpoint.gateway["s3"]`, "vpc_endpoint_type", "Gateway")
+	tfplan.AssertAttributeEquals(t, plan, `aws_vpc_endpoint.interface["sts"]`, "private_dns_enabled", true)
+
+	// No role may be created without a permissions boundary
+	tfplan.AssertEverySets(t, plan, "aws_iam_role", "permissions_boundary")
+
+	// The plan must hold exactly the resources declared in testdata/inventory
+	inventory.AssertPlan(t, plan, "networking")
+
+	// Every planned name must be one AWS accepts, e.g. flow log role names within 64 characters
+	naming.AssertPlan(t, plan)
 
-	defer terraform.Destroy(t, terraformOptions)
-	terraform.InitAndApply(t, terraformOptions)
+	// Compare the full plan with testdata/snapshots; rerun with -update after an intended change
+	tfplan.AssertSnapshot(t, plan, "networking_plan", tfplan.Redact(aws.GetAccountId(t), "ACCOUNT_ID"), tfplan.Update(*update))
 
-	// Verify single NAT gateway configuration
-	natGatewayIDs := terraform.OutputList(t, terraformOptions, "nat_gateway_ids")
-	assert.Equal(t, 1, len(natGatewayIDs), "Should have exactly one NAT gateway")
+	tfplan.AssertNoDestroys(t, plan)
 }
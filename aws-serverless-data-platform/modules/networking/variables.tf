@@ -86,4 +86,41 @@ variable "enable_dns_support" {
   description = "Enable DNS support in the VPC"
   type        = bool
   default     = true
-} 
\ No newline at end of file
+}
+
+# VPC Flow Log record configuration
+variable "flow_log_format" {
+  description = "Fields captured in each flow log record; defaults to the AWS version 2 format"
+  type        = string
+  default     = "$${version} $${account-id} $${interface-id} $${srcaddr} $${dstaddr} $${srcport} $${dstport} $${protocol} $${packets} $${bytes} $${start} $${end} $${action} $${log-status}"
+}
+
+variable "flow_log_max_aggregation_interval" {
+  description = "Seconds over which flow log records are aggregated (60 or 600)"
+  type        = number
+  default     = 600
+
+  validation {
+    condition     = contains([60, 600], var.flow_log_max_aggregation_interval)
+    error_message = "Flow log aggregation interval must be 60 or 600 seconds."
+  }
+}
+
+variable "flow_log_permissions_boundary_arn" {
+  description = "Permissions boundary for the flow log role, e.g. the security module's permissions_boundary_arn output"
+  type        = string
+  default     = null
+}
+
+# VPC endpoint configuration
+variable "gateway_endpoints" {
+  description = "Services reached through Gateway VPC endpoints on the private and database route tables"
+  type        = list(string)
+  default     = ["s3", "dynamodb"]
+}
+
+variable "interface_endpoints" {
+  description = "Services reached through Interface VPC endpoints in the private subnets"
+  type        = list(string)
+  default     = ["glue", "kinesis-streams", "sts"]
+}
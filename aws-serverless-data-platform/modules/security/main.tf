@@ -75,6 +75,83 @@ resource "aws_kms_alias" "secrets_key" {
   target_key_id = aws_kms_key.secrets_key.key_id
 }
 
+# =============================================================================
+# Permissions Boundary for Platform Roles
+# =============================================================================
+
+locals {
+  boundary_policy_name = "${var.project_name}-permissions-boundary"
+
+  # Every role the module creates is capped by this boundary
+  permissions_boundary_arn = coalesce(var.permissions_boundary_arn, aws_iam_policy.permissions_boundary.arn)
+}
+
+# Caps what any platform role can do, whatever policies are later attached to it
+resource "aws_iam_policy" "permissions_boundary" {
+  name        = local.boundary_policy_name
+  description = "Permissions boundary for ${var.project_name} service roles"
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Sid    = "AllowDataPlatformServices"
+        Effect = "Allow"
+        Action = [
+          "s3:*",
+          "glue:*",
+          "athena:*",
+          "kinesis:*",
+          "kinesisanalytics:*",
+          "logs:*",
+          "cloudwatch:PutMetricData",
+          "kms:Decrypt",
+          "kms:Encrypt",
+          "kms:GenerateDataKey*",
+          "kms:DescribeKey",
+          "ec2:Describe*",
+          "ec2:CreateNetworkInterface",
+          "ec2:DeleteNetworkInterface",
+          "ec2:CreateTags",
+          "ec2:DeleteTags",
+          "iam:GetRole",
+          "iam:GetRolePolicy",
+          "iam:ListRolePolicies",
+          "iam:ListAttachedRolePolicies"
+        ]
+        Resource = "*"
+      },
+      {
+        Sid    = "DenyIdentityChanges"
+        Effect = "Deny"
+        Action = [
+          "iam:Create*",
+          "iam:Delete*",
+          "iam:Put*",
+          "iam:Attach*",
+          "iam:Detach*",
+          "iam:Update*",
+          "iam:SetDefaultPolicyVersion",
+          "iam:AddUserToGroup",
+          "iam:PassRole"
+        ]
+        Resource = "*"
+      },
+      {
+        Sid    = "DenyAccountChanges"
+        Effect = "Deny"
+        Action = [
+          "organizations:*",
+          "account:*"
+        ]
+        Resource = "*"
+      }
+    ]
+  })
+
+  tags = var.common_tags
+}
+
 # =============================================================================
 # IAM Roles for Data Platform Services
 # =============================================================================
@@ -83,17 +160,31 @@ resource "aws_kms_alias" "secrets_key" {
 resource "aws_iam_role" "glue_role" {
   name = "${var.project_name}-glue-role"
 
+  permissions_boundary = local.permissions_boundary_arn
+
   assume_role_policy = jsonencode({
     Version = "2012-10-17"
-    Statement = [
-      {
-        Action = "sts:AssumeRole"
-        Effect = "Allow"
-        Principal = {
-          Service = "glue.amazonaws.com"
+    Statement = concat(
+      [
+        {
+          Action = "sts:AssumeRole"
+          Effect = "Allow"
+          Principal = {
+            Service = "glue.amazonaws.com"
+          }
         }
-      }
-    ]
+      ],
+      # Additional principals allowed to act as the Glue role, e.g. for testing
+      length(var.cross_account_roles) > 0 ? [
+        {
+          Action = "sts:AssumeRole"
+          Effect = "Allow"
+          Principal = {
+            AWS = var.cross_account_roles
+          }
+        }
+      ] : []
+    )
   })
 
   tags = var.common_tags
@@ -108,6 +199,8 @@ resource "aws_iam_role_policy_attachment" "glue_service" {
 resource "aws_iam_role" "kinesis_analytics_role" {
   name = "${var.project_name}-kinesis-analytics-role"
 
+  permissions_boundary = local.permissions_boundary_arn
+
   assume_role_policy = jsonencode({
     Version = "2012-10-17"
     Statement = [
@@ -185,7 +278,11 @@ resource "aws_iam_policy" "glue_catalog_access" {
           "glue:UpdatePartition",
           "glue:DeletePartition"
         ]
-        Resource = "*"
+        Resource = [
+          "arn:aws:glue:${data.aws_region.current.name}:${data.aws_caller_identity.current.account_id}:catalog",
+          "arn:aws:glue:${data.aws_region.current.name}:${data.aws_caller_identity.current.account_id}:database/${var.project_name}_*",
+          "arn:aws:glue:${data.aws_region.current.name}:${data.aws_caller_identity.current.account_id}:table/${var.project_name}_*/*"
+        ]
       }
     ]
   })
@@ -34,6 +34,16 @@ output "glue_role_name" {
   value       = aws_iam_role.glue_role.name
 }
 
+output "kinesis_analytics_role_name" {
+  description = "Name of the Kinesis Analytics service role"
+  value       = aws_iam_role.kinesis_analytics_role.name
+}
+
+output "permissions_boundary_arn" {
+  description = "ARN of the permissions boundary attached to every role of the module"
+  value       = local.permissions_boundary_arn
+}
+
 output "data_processing_security_group_id" {
   description = "ID of the data processing security group"
   value       = aws_security_group.data_processing_sg.id
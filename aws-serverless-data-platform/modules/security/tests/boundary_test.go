@@ -0,0 +1,117 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	terratest_aws "github.com/gruntwork-io/terratest/modules/aws"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/fixture"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/matrix"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/policysim"
+)
+
+// TestPermissionsBoundary checks every role the module creates carries its
+// permissions boundary, and that the boundary denies actions a role's policies
+// would otherwise allow
+func TestPermissionsBoundary(t *testing.T) {
+	t.Parallel()
+
+	cells, err := policysim.Load("testdata/boundary-matrix.yaml")
+	require.NoError(t, err)
+
+	matrix.Run(t, func(t *testing.T, region matrix.Region) {
+		awsRegion := region.Name
+
+		network := fixture.GetNetwork(t, region)
+		stageDir := testutil.StageDir(t)
+
+		defer testutil.Teardown(t, stageDir)
+		terraformOptions := testutil.Deploy(t, stageDir, func() *terraform.Options {
+			opts := region.Options(testutil.WithVPCID(network.VPCID))
+			return testutil.NewTerraformOptions(t, "../", testutil.NewSecurityVars(opts...), opts...)
+		})
+
+		testutil.Validate(t, func() {
+			clients := awsclients.New(t, awsclients.WithRegion(awsRegion))
+			projectName := terraformOptions.Vars["project_name"].(string)
+			boundaryARN := terraform.Output(t, terraformOptions, "permissions_boundary_arn")
+			glueRoleName := terraform.Output(t, terraformOptions, "glue_role_name")
+			kinesisRoleName := terraform.Output(t, terraformOptions, "kinesis_analytics_role_name")
+
+			// Every role named after the project, not just the outputs, so a
+			// role added to the module without a boundary fails here
+			t.Run("Attached", func(t *testing.T) {
+				roles := projectRoles(t, clients, projectName)
+				assert.Contains(t, roles, glueRoleName)
+				assert.Contains(t, roles, kinesisRoleName)
+
+				for _, roleName := range roles {
+					assertRoleBoundary(t, clients, roleName, boundaryARN)
+				}
+			})
+
+			t.Run("Simulation", func(t *testing.T) {
+				glueRoleArn := terraform.Output(t, terraformOptions, "glue_role_arn")
+				accountID := terratest_aws.GetAccountId(t)
+
+				expanded, err := policysim.Expand(cells, map[string]string{
+					"project_name":  projectName,
+					"region":        awsRegion,
+					"account_id":    accountID,
+					"glue_role_arn": glueRoleArn,
+				})
+				require.NoError(t, err)
+
+				policysim.Run(t, clients, map[string]string{
+					"glue_role":              glueRoleArn,
+					"kinesis_analytics_role": "arn:aws:iam::" + accountID + ":role/" + kinesisRoleName,
+				}, expanded)
+			})
+		})
+	})
+}
+
+// projectRoles returns the names of the roles whose names start with the project name
+func projectRoles(t *testing.T, clients *awsclients.Clients, projectName string) []string {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	var names []string
+	paginator := iam.NewListRolesPaginator(clients.IAM(), &iam.ListRolesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		require.NoError(t, err, "Failed to list IAM roles")
+		for _, role := range page.Roles {
+			if name := awssdk.ToString(role.RoleName); strings.HasPrefix(name, projectName+"-") {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// assertRoleBoundary checks the role's permissions boundary is boundaryARN;
+// ListRoles omits boundaries, so each role is read individually
+func assertRoleBoundary(t *testing.T, clients *awsclients.Clients, roleName, boundaryARN string) {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	output, err := clients.IAM().GetRole(ctx, &iam.GetRoleInput{RoleName: awssdk.String(roleName)})
+	require.NoError(t, err, "Failed to get IAM role %s", roleName)
+
+	boundary := output.Role.PermissionsBoundary
+	if !assert.NotNil(t, boundary, "Role %s has no permissions boundary", roleName) {
+		return
+	}
+	if assert.Equal(t, boundaryARN, awssdk.ToString(boundary.PermissionsBoundaryArn), "Role %s has the wrong permissions boundary", roleName) {
+		logging.New(t).Success("Permissions boundary attached", "role", roleName)
+	}
+}
@@ -0,0 +1,100 @@
+package test
+
+import (
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	terratest_aws "github.com/gruntwork-io/terratest/modules/aws"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/fixture"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/leastprivilege"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/matrix"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/naming"
+)
+
+// TestLeastPrivilege assumes the Glue role and attempts actions its policies
+// must not allow, complementing the allowed decisions in TestPolicySimulation
+func TestLeastPrivilege(t *testing.T) {
+	t.Parallel()
+
+	matrix.Run(t, func(t *testing.T, region matrix.Region) {
+		awsRegion := region.Name
+		network := fixture.GetNetwork(t, region)
+
+		stageDir := testutil.StageDir(t)
+
+		defer testutil.Teardown(t, stageDir)
+		terraformOptions := testutil.Deploy(t, stageDir, func() *terraform.Options {
+			// Let the test account assume the Glue role
+			opts := region.Options(
+				testutil.WithVPCID(network.VPCID),
+				testutil.WithVar("cross_account_roles", []string{"arn:aws:iam::" + terratest_aws.GetAccountId(t) + ":root"}),
+			)
+			return testutil.NewTerraformOptions(t, "../", testutil.NewSecurityVars(opts...), opts...)
+		})
+
+		testutil.Validate(t, func() {
+			admin := awsclients.New(t, awsclients.WithRegion(awsRegion))
+			glue := leastprivilege.AssumeRole(t, admin, terraform.Output(t, terraformOptions, "glue_role_arn"))
+
+			dataKeyID := terraform.Output(t, terraformOptions, "data_kms_key_id")
+			bucket := createOutOfScopeBucket(t, admin)
+
+			probes := []leastprivilege.Probe{
+				leastprivilege.ScheduleKeyDeletion(dataKeyID, "the data key"),
+				leastprivilege.DisableKey(dataKeyID, "the data key"),
+				leastprivilege.PutObject(bucket, "a bucket outside the project"),
+				leastprivilege.AttachAdministratorAccess(terraform.Output(t, terraformOptions, "glue_role_name")),
+			}
+
+			// The test's own role stands in for an administrative role the Glue role must not hand to a job
+			ctx, cancel := admin.Context()
+			defer cancel()
+			adminRoleARN, err := leastprivilege.CallerRoleARN(ctx, admin)
+			require.NoError(t, err)
+			if adminRoleARN != "" {
+				probes = append(probes, leastprivilege.PassRole(adminRoleARN, "the test role", "s3://"+bucket+"/probe.py"))
+			} else {
+				logging.New(t).Info("Not running as a role; skipping the iam:PassRole probe")
+			}
+
+			leastprivilege.Assert(t, admin, glue, probes)
+		})
+	})
+}
+
+// createOutOfScopeBucket creates an empty bucket whose name is outside the
+// project_name-* prefix the Glue role may access, removing it when the test finishes
+func createOutOfScopeBucket(t *testing.T, admin *awsclients.Clients) string {
+	bucket := naming.Unique(t, naming.S3Bucket, "test-lp")
+	naming.AssertAvailable(t, admin, naming.S3Bucket, bucket)
+
+	ctx, cancel := admin.Context()
+	defer cancel()
+
+	input := &s3.CreateBucketInput{Bucket: awssdk.String(bucket)}
+	if admin.Config.Region != "us-east-1" {
+		input.CreateBucketConfiguration = &s3types.CreateBucketConfiguration{
+			LocationConstraint: s3types.BucketLocationConstraint(admin.Config.Region),
+		}
+	}
+	_, err := admin.S3().CreateBucket(ctx, input)
+	require.NoError(t, err, "Failed to create bucket %s", bucket)
+
+	t.Cleanup(func() {
+		ctx, cancel := admin.Context()
+		defer cancel()
+		terratest_aws.EmptyS3Bucket(t, admin.Config.Region, bucket)
+		_, err := admin.S3().DeleteBucket(ctx, &s3.DeleteBucketInput{Bucket: awssdk.String(bucket)})
+		if err != nil {
+			t.Errorf("Failed to delete bucket %s: %v", bucket, err)
+		}
+	})
+	return bucket
+}
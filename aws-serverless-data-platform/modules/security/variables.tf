@@ -51,11 +51,17 @@ variable "enable_flow_logs" {
 
 # IAM Configuration
 variable "cross_account_roles" {
-  description = "List of cross-account roles to trust"
+  description = "Role or account root ARNs trusted to assume the Glue role"
   type        = list(string)
   default     = []
 }
 
+variable "permissions_boundary_arn" {
+  description = "Permissions boundary for the module's roles; the module's own boundary policy is used when null"
+  type        = string
+  default     = null
+}
+
 variable "enable_mfa_requirement" {
   description = "Require MFA for sensitive operations"
   type        = bool
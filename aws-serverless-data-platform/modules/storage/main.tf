@@ -166,6 +166,71 @@ resource "aws_s3_bucket_public_access_block" "curated" {
   restrict_public_buckets = local.bucket_config.public_access_block
 }
 
+# S3 Bucket Policies - require TLS and reject uploads that request a different
+# encryption algorithm or KMS key than the bucket default
+locals {
+  data_lake_buckets = {
+    raw       = aws_s3_bucket.raw
+    processed = aws_s3_bucket.processed
+    curated   = aws_s3_bucket.curated
+  }
+  sse_algorithm = local.bucket_config.encryption == "aws:kms" ? "aws:kms" : "AES256"
+}
+
+resource "aws_s3_bucket_policy" "data_lake" {
+  for_each = local.data_lake_buckets
+
+  bucket = each.value.id
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = concat(
+      [
+        {
+          Sid       = "DenyInsecureTransport"
+          Effect    = "Deny"
+          Principal = "*"
+          Action    = "s3:*"
+          Resource  = [each.value.arn, "${each.value.arn}/*"]
+          Condition = {
+            Bool = { "aws:SecureTransport" = "false" }
+          }
+        },
+        {
+          Sid       = "DenyIncorrectEncryptionHeader"
+          Effect    = "Deny"
+          Principal = "*"
+          Action    = "s3:PutObject"
+          Resource  = "${each.value.arn}/*"
+          Condition = {
+            StringNotEquals = { "s3:x-amz-server-side-encryption" = local.sse_algorithm }
+            Null            = { "s3:x-amz-server-side-encryption" = "false" }
+          }
+        }
+      ],
+      local.sse_algorithm == "aws:kms" ? [
+        {
+          Sid       = "DenyIncorrectKMSKey"
+          Effect    = "Deny"
+          Principal = "*"
+          Action    = "s3:PutObject"
+          Resource  = "${each.value.arn}/*"
+          Condition = {
+            StringNotEquals = { "s3:x-amz-server-side-encryption-aws-kms-key-id" = aws_kms_key.s3.arn }
+            Null            = { "s3:x-amz-server-side-encryption-aws-kms-key-id" = "false" }
+          }
+        }
+      ] : []
+    )
+  })
+
+  # The public access block must exist first or S3 may reject the policy update
+  depends_on = [
+    aws_s3_bucket_public_access_block.raw,
+    aws_s3_bucket_public_access_block.processed,
+    aws_s3_bucket_public_access_block.curated,
+  ]
+}
+
 # S3 Bucket Lifecycle Configuration
 resource "aws_s3_bucket_lifecycle_configuration" "raw" {
   bucket = aws_s3_bucket.raw.id
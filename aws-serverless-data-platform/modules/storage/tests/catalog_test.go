@@ -0,0 +1,84 @@
+// =============================================================================
+// Data Catalog Test
+// Checks the Glue Data Catalog databases created by the storage module
+// =============================================================================
+
+package test
+
+import (
+	"strings"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/arn"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+)
+
+// validateDataCatalog checks each database output exists in the catalog with the expected settings
+func validateDataCatalog(t *testing.T, terraformOptions *terraform.Options, awsRegion string) {
+	clients := awsclients.New(t, awsclients.WithRegion(awsRegion))
+	glueClient := clients.Glue()
+
+	// Database ARNs are built in the caller's partition and account
+	ctx, cancel := clients.Context()
+	identity, err := clients.STS().GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	cancel()
+	require.NoError(t, err, "Failed to get the caller identity")
+	caller, err := arn.Parse(awssdk.ToString(identity.Arn))
+	require.NoError(t, err)
+	accountID := caller.AccountID
+
+	// Database output name mapped to the description prefix set by the module
+	databases := map[string]string{
+		"main_database_name":      "Main data catalog database",
+		"raw_database_name":       "Raw data catalog database",
+		"processed_database_name": "Processed data catalog database",
+		"curated_database_name":   "Curated data catalog database",
+	}
+
+	for outputName, descriptionPrefix := range databases {
+		t.Run(outputName, func(t *testing.T) {
+			databaseName := terraform.Output(t, terraformOptions, outputName)
+			require.NotEmpty(t, databaseName)
+
+			ctx, cancel := clients.Context()
+			defer cancel()
+
+			database, err := glueClient.GetDatabase(ctx, &glue.GetDatabaseInput{
+				Name: awssdk.String(databaseName),
+			})
+			require.NoError(t, err, "Failed to get Glue database %s", databaseName)
+
+			// Validate database properties
+			assert.Equal(t, databaseName, *database.Database.Name)
+			assert.Equal(t, accountID, *database.Database.CatalogId)
+			assert.True(t, strings.HasPrefix(awssdk.ToString(database.Database.Description), descriptionPrefix),
+				"Database %s description should start with %q", databaseName, descriptionPrefix)
+
+			// Freshly created databases should not contain any tables yet
+			tables, err := glueClient.GetTables(ctx, &glue.GetTablesInput{
+				DatabaseName: awssdk.String(databaseName),
+			})
+			require.NoError(t, err, "Failed to list tables in %s", databaseName)
+			assert.Empty(t, tables.TableList, "Database %s should not contain tables", databaseName)
+
+			// Validate the common tags were applied to the database
+			databaseArn := caller.Resource("glue", awsRegion, "database/"+databaseName)
+			tags, err := glueClient.GetTags(ctx, &glue.GetTagsInput{
+				ResourceArn: awssdk.String(databaseArn),
+			})
+			require.NoError(t, err, "Failed to get tags for %s", databaseName)
+			assert.Equal(t, "true", tags.Tags["Testing"])
+			assert.Equal(t, testutil.DefaultProjectTag, tags.Tags["Project"])
+
+			logging.New(t).Success("Glue database validation passed", "database", databaseName)
+		})
+	}
+}
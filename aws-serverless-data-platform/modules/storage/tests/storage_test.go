@@ -6,86 +6,267 @@
 package test
 
 import (
+	"flag"
+	"os"
+	"path"
+	"strings"
 	"testing"
+	"time"
 
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	terratest_aws "github.com/gruntwork-io/terratest/modules/aws"
+	"github.com/gruntwork-io/terratest/modules/random"
 	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/arn"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/inventory"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/kmskey"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/lifecycle"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/matrix"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/messaging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/naming"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/s3sec"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tagaudit"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tfplan"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/upgrade"
 )
 
+// update rewrites the plan snapshots instead of comparing against them: go test -run Plan -update
+var update = flag.Bool("update", false, "rewrite Terraform plan snapshots under "+tfplan.SnapshotDir)
+
+// TestMain runs the suite against the account selected by TERRATEST_ASSUME_ROLE_ARN
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
+
 // TestStorage tests the storage module
 func TestStorage(t *testing.T) {
 	t.Parallel()
 
-	// AWS region for testing
-	awsRegion := "us-east-1"
-
-	terraformOptions := &terraform.Options{
-		TerraformDir: "../",
-		Vars: map[string]interface{}{
-			"environment":  "test",
-			"project_name": "dl-test",
-			"region":       awsRegion,
-			"account_id":   "356240508702",
-			"storage": map[string]interface{}{
-				"s3": map[string]interface{}{
-					"versioning":          true,
-					"encryption":          "AES256",
-					"public_access_block": true,
-					"force_destroy":       true,
-				},
-				"lifecycle": map[string]interface{}{
-					"transition_ia_days":           90,
-					"transition_glacier_days":      210,
-					"transition_deep_archive_days": 365,
-					"expiration_days":              730,
-				},
-			},
-			"security": map[string]interface{}{
-				"kms": map[string]interface{}{
-					"deletion_window":     30,
-					"enable_key_rotation": true,
-				},
-			},
-			"common_tags": map[string]interface{}{
-				"Environment": "test",
-				"Project":     "terratest",
-				"Testing":     "true",
-			},
-		},
-		EnvVars: map[string]string{
-			"AWS_DEFAULT_REGION": awsRegion,
-		},
+	matrix.Run(t, func(t *testing.T, region matrix.Region) {
+		stageDir := testutil.StageDir(t)
+
+		defer testutil.Teardown(t, stageDir)
+
+		terraformOptions := testutil.Deploy(t, stageDir, func() *terraform.Options {
+			opts := region.Options()
+			return testutil.NewTerraformOptions(t, "../", testutil.NewStorageVars(opts...), opts...)
+		})
+
+		testutil.Validate(t, func() {
+			// Verify terraform outputs exist - this ensures resources were created successfully
+			terraform.Output(t, terraformOptions, "raw_bucket_id")
+			terraform.Output(t, terraformOptions, "processed_bucket_id")
+			terraform.Output(t, terraformOptions, "curated_bucket_id")
+
+			// Verify security resources exist
+			terraform.Output(t, terraformOptions, "s3_kms_key_id")
+			terraform.Output(t, terraformOptions, "s3_kms_key_arn")
+			terraform.Output(t, terraformOptions, "s3_kms_alias_arn")
+
+			// Verify the S3 key is rotated, only trusts the account and is reachable through its alias
+			t.Run("KMSKey", func(t *testing.T) {
+				aliasARN := terraform.Output(t, terraformOptions, "s3_kms_alias_arn")
+				kmskey.AssertKey(t, awsclients.New(t, awsclients.WithRegion(region.Name)),
+					terraform.Output(t, terraformOptions, "s3_kms_key_id"), kmskey.Expectations{
+						Alias:     aliasARN[strings.Index(aliasARN, "alias/"):],
+						AccountID: terratest_aws.GetAccountId(t),
+					})
+			})
+
+			// Verify each bucket's lifecycle rule is the one derived from the
+			// configured policy and moves objects to colder classes in order
+			t.Run("Lifecycle", func(t *testing.T) {
+				clients := awsclients.New(t, awsclients.WithRegion(region.Name))
+				policy, err := lifecycle.PolicyFromVars(terraformOptions.Vars)
+				require.NoError(t, err)
+
+				for _, layer := range []string{"raw", "processed", "curated"} {
+					terraform.Output(t, terraformOptions, layer+"_bucket_lifecycle_configuration")
+					bucket := terraform.Output(t, terraformOptions, layer+"_bucket_id")
+					want, err := policy.Expected(layer)
+					require.NoError(t, err)
+					t.Run(layer, func(t *testing.T) {
+						lifecycle.AssertBucket(t, clients, bucket, want)
+					})
+				}
+			})
+
+			// Verify encryption configurations exist
+			terraform.Output(t, terraformOptions, "raw_bucket_encryption")
+			terraform.Output(t, terraformOptions, "processed_bucket_encryption")
+			terraform.Output(t, terraformOptions, "curated_bucket_encryption")
+
+			// Verify the Glue databases follow the naming convention; catalog names use underscores
+			catalog := arn.Convention{
+				Project:     terraformOptions.Vars["project_name"].(string),
+				Environment: terraformOptions.Vars["environment"].(string),
+				Separator:   "_",
+			}
+			for _, layer := range []string{"raw", "processed", "curated"} {
+				catalog.AssertName(t, terraform.Output(t, terraformOptions, layer+"_database_name"), layer)
+			}
+
+			// Verify the Glue job log group is in the stack's region
+			logGroupName := terraform.Output(t, terraformOptions, "glue_log_group_name")
+			arn.Assert(t, terraform.Output(t, terraformOptions, "glue_log_group_arn"), arn.Want{
+				Service:      "logs",
+				Region:       region.Name,
+				ResourceType: "log-group",
+				Name:         path.Base(logGroupName),
+			})
+
+			// Verify every data lake bucket refuses public, cross-account, plaintext and mis-encrypted access
+			t.Run("BucketSecurity", func(t *testing.T) {
+				clients := awsclients.New(t, awsclients.WithRegion(region.Name))
+				opts := s3sec.Options{
+					SSEAlgorithm:        "AES256",
+					CrossAccountRoleARN: os.Getenv(s3sec.CrossAccountRoleEnvVar),
+				}
+
+				for _, layer := range []string{"raw", "processed", "curated"} {
+					bucket := terraform.Output(t, terraformOptions, layer+"_bucket_id")
+					t.Run(layer, func(t *testing.T) {
+						s3sec.AssertBucketSecure(t, clients, bucket, opts)
+					})
+				}
+			})
+
+			// Verify new objects are announced on EventBridge for downstream pipelines
+			// and that rules filtering on key prefix and suffix only see their objects
+			t.Run("EventNotifications", func(t *testing.T) {
+				clients := awsclients.New(t, awsclients.WithRegion(region.Name))
+				bucket := terraform.Output(t, terraformOptions, "raw_bucket_id")
+				run := strings.ToLower(random.UniqueId())
+
+				ctx, cancel := clients.Context()
+				defer cancel()
+
+				// Routing belongs to EventBridge rules, so the bucket must not also
+				// notify functions, queues or topics directly
+				notifications, err := clients.S3().GetBucketNotificationConfiguration(ctx, &s3.GetBucketNotificationConfigurationInput{
+					Bucket: awssdk.String(bucket),
+				})
+				require.NoError(t, err, "Failed to get notification configuration of %s", bucket)
+				assert.NotNil(t, notifications.EventBridgeConfiguration, "Bucket %s should send events to EventBridge", bucket)
+				assert.Empty(t, notifications.LambdaFunctionConfigurations, "Bucket %s should not invoke functions directly", bucket)
+				assert.Empty(t, notifications.QueueConfigurations, "Bucket %s should not notify queues directly", bucket)
+				assert.Empty(t, notifications.TopicConfigurations, "Bucket %s should not notify topics directly", bucket)
+
+				prefix := "terratest/events/" + run + "/"
+				queue := messaging.NewQueue(t, clients, "s3-events")
+				queue.SubscribeEvents(t, messaging.ObjectCreatedPatternFor(bucket, prefix, ".csv"))
+
+				key := prefix + "sample.csv"
+				ignored := []string{
+					"terratest/other/" + run + "/sample.csv",
+					prefix + "sample.json",
+				}
+				for _, object := range append(ignored, key) {
+					_, err := clients.S3().PutObject(ctx, &s3.PutObjectInput{
+						Bucket: awssdk.String(bucket),
+						Key:    awssdk.String(object),
+						Body:   strings.NewReader("id,value\n1,probe\n"),
+					})
+					require.NoError(t, err, "Failed to upload %s", object)
+				}
+
+				message := queue.WaitForMessage(t, 5*time.Minute, messaging.ObjectCreated(bucket, key))
+				messaging.AssertFields(t, message, map[string]interface{}{"reason": "PutObject"})
+
+				for _, object := range ignored {
+					queue.AssertNoMessage(t, time.Minute, messaging.ObjectCreated(bucket, object))
+				}
+			})
+
+			// Verify the Glue databases for each data lake layer
+			t.Run("DataCatalog", func(t *testing.T) {
+				validateDataCatalog(t, terraformOptions, region.Name)
+			})
+
+			// Verify the applied state holds exactly the declared resources
+			t.Run("Inventory", func(t *testing.T) {
+				inventory.AssertState(t, terraformOptions, "storage")
+			})
+
+			// Verify every resource of the run carries the mandatory tags
+			t.Run("TagCompliance", func(t *testing.T) {
+				expected := []string{terraform.Output(t, terraformOptions, "s3_kms_key_arn")}
+				for _, layer := range []string{"raw", "processed", "curated"} {
+					expected = append(expected, "arn:aws:s3:::"+terraform.Output(t, terraformOptions, layer+"_bucket_id"))
+				}
+				tagaudit.AssertRun(t, awsclients.New(t, awsclients.WithRegion(region.Name)), terraformOptions, expected...)
+			})
+		})
+	})
+}
+
+// TestStoragePlan validates the storage configuration without applying it
+func TestStoragePlan(t *testing.T) {
+	t.Parallel()
+
+	awsRegion := testutil.DefaultRegion
+
+	opts := []testutil.Option{
+		testutil.WithRegion(awsRegion),
+		// A fixed prefix and suffix keep resource names stable for the plan snapshot
+		testutil.WithRunPrefix("snapshot"),
+		testutil.WithUniqueSuffix("snapshot"),
 	}
+	terraformOptions := testutil.NewTerraformOptions(t, "../", testutil.NewStorageVars(opts...), opts...)
 
-	defer terraform.Destroy(t, terraformOptions)
+	plan := tfplan.Run(t, terraformOptions)
 
-	terraform.InitAndApply(t, terraformOptions)
+	// Three data lake layers, each with versioning, encryption, public access blocks and a bucket policy
+	tfplan.AssertResourceCount(t, plan, "aws_s3_bucket", 3)
+	tfplan.AssertResourceCount(t, plan, "aws_s3_bucket_versioning", 3)
+	tfplan.AssertResourceCount(t, plan, "aws_s3_bucket_server_side_encryption_configuration", 3)
+	tfplan.AssertResourceCount(t, plan, "aws_s3_bucket_public_access_block", 3)
+	tfplan.AssertResourceCount(t, plan, "aws_s3_bucket_policy", 3)
+	tfplan.AssertResourceCount(t, plan, "aws_kms_key", 1)
+	tfplan.AssertResourceCount(t, plan, "aws_glue_catalog_database", 4)
 
-	// Verify terraform outputs exist - this ensures resources were created successfully
-	terraform.Output(t, terraformOptions, "raw_bucket_id")
-	terraform.Output(t, terraformOptions, "processed_bucket_id")
-	terraform.Output(t, terraformOptions, "curated_bucket_id")
+	for _, layer := range []string{"raw", "processed", "curated"} {
+		tfplan.AssertAttributeEquals(t, plan, "aws_s3_bucket_versioning."+layer,
+			"versioning_configuration.0.status", "Enabled")
+		tfplan.AssertAttributeEquals(t, plan, "aws_s3_bucket_public_access_block."+layer,
+			"block_public_policy", true)
+	}
 
-	// Verify security resources exist
-	terraform.Output(t, terraformOptions, "s3_kms_key_id")
-	terraform.Output(t, terraformOptions, "s3_kms_key_arn")
-	terraform.Output(t, terraformOptions, "s3_kms_alias_arn")
+	tfplan.AssertAttributeEquals(t, plan, "aws_kms_key.s3", "enable_key_rotation", true)
+	tfplan.AssertAttributeEquals(t, plan, "aws_kms_key.s3", "deletion_window_in_days", 30)
 
-	// Verify lifecycle configurations exist
-	terraform.Output(t, terraformOptions, "raw_bucket_lifecycle_configuration")
-	terraform.Output(t, terraformOptions, "processed_bucket_lifecycle_configuration")
-	terraform.Output(t, terraformOptions, "curated_bucket_lifecycle_configuration")
+	// The plan must hold exactly the resources declared in testdata/inventory
+	inventory.AssertPlan(t, plan, "storage")
 
-	// Verify encryption configurations exist
-	terraform.Output(t, terraformOptions, "raw_bucket_encryption")
-	terraform.Output(t, terraformOptions, "processed_bucket_encryption")
-	terraform.Output(t, terraformOptions, "curated_bucket_encryption")
+	// Every planned name must be one AWS accepts, e.g. at most 63 characters for buckets
+	naming.AssertPlan(t, plan)
 
-	// Verify Glue database resources exist
-	terraform.Output(t, terraformOptions, "raw_database_name")
-	terraform.Output(t, terraformOptions, "processed_database_name")
-	terraform.Output(t, terraformOptions, "curated_database_name")
+	// Compare the full plan with testdata/snapshots; rerun with -update after an intended change
+	tfplan.AssertSnapshot(t, plan, "storage_plan", tfplan.Redact(terratest_aws.GetAccountId(t), "ACCOUNT_ID"), tfplan.Update(*update))
 
-	// Verify Glue role exists
-	terraform.Output(t, terraformOptions, "glue_log_group_name")
-	terraform.Output(t, terraformOptions, "glue_log_group_arn")
+	tfplan.AssertNoDestroys(t, plan)
+}
+
+// TestStorageUpgrade applies the module's previous release and plans the
+// working tree over it; the data lake buckets, their KMS key and the catalog
+// databases must all be updated in place. It does not run in parallel:
+// the module's KMS alias is named after the environment and region, which
+// TestStorage's deploy in the same region shares
+func TestStorageUpgrade(t *testing.T) {
+	// Both plans must see the same names, so the suffix is chosen once
+	opts := []testutil.Option{
+		testutil.WithRegion(testutil.DefaultRegion),
+		testutil.WithUniqueSuffix(strings.ToLower(random.UniqueId())),
+	}
+	upgrade.Run(t, upgrade.Options{
+		ModuleDir: "../",
+		NewOptions: func(terraformDir string) *terraform.Options {
+			return testutil.NewTerraformOptions(t, terraformDir, testutil.NewStorageVars(opts...), opts...)
+		},
+	})
 }
@@ -13,11 +13,15 @@ locals {
   common      = yamldecode(file("${get_repo_root()}/config/common.yaml"))
   accounts    = yamldecode(file("${get_repo_root()}/config/accounts.yaml"))
   env_config  = yamldecode(file("${get_repo_root()}/config/environments/${local.environment}.yaml"))
+
+  # An environment deploys to its own accounts.yaml entry unless its config
+  # names another, as the personal sandboxes created by cmd/sandbox do
+  account = try(local.env_config.account, local.environment)
   
   # Merge configurations with precedence: environment > accounts > common
   config = merge(
     local.common,
-    local.accounts[local.environment],
+    local.accounts[local.account],
     local.env_config
   )
 
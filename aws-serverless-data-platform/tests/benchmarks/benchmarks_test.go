@@ -0,0 +1,374 @@
+//go:build benchmark
+
+package benchmarks
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/athena"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/loadgen"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/runprefix"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/wait"
+)
+
+var (
+	region     = flag.String("region", testutil.DefaultRegion, "region of the environment under benchmark")
+	rawBucket  = flag.String("raw-bucket", "", "raw data lake bucket to upload to and query; the S3 and Athena benchmarks skip without it")
+	database   = flag.String("database", "", "Glue database the reference table is created in; the Athena benchmark skips without it")
+	workgroup  = flag.String("workgroup", athena.DefaultWorkgroup, "Athena workgroup the reference queries run in")
+	stream     = flag.String("stream", "", "Kinesis stream to put records to; the Kinesis benchmark skips without it")
+	glueJob    = flag.String("glue-job", "", "Glue job to start and stop; the Glue benchmark skips without it")
+	uploadSize = flag.Int("upload-size", 64<<20, "bytes per S3 multipart upload")
+	partSize   = flag.Int64("part-size", manager.DefaultUploadPartSize*2, "bytes per S3 upload part")
+	recordSize = flag.Int("record-size", 1024, "bytes per Kinesis record")
+	rows       = flag.Int("reference-rows", 100000, "rows in the reference dataset the Athena queries scan")
+
+	historyPath      = flag.String("history", "benchmark-history.csv", "CSV file every run's results are appended to")
+	revision         = flag.String("revision", os.Getenv("GITHUB_SHA"), "revision recorded with the results")
+	tolerance        = flag.Float64("regression-tolerance", 0.2, "fraction by which a metric may be worse than its recent median")
+	failOnRegression = flag.Bool("fail-on-regression", false, "exit non-zero when a metric regressed beyond the tolerance")
+)
+
+// referenceQueries are the Athena queries timed against the reference table
+var referenceQueries = map[string]string{
+	"Count":   "SELECT count(*) FROM %s",
+	"GroupBy": "SELECT country, count(*) AS events FROM %s GROUP BY country ORDER BY events DESC",
+	"Lookup":  "SELECT id, name, country FROM %s WHERE id = 4242",
+}
+
+// TestMain assumes the test role like every suite and records the results in the history
+func TestMain(m *testing.M) {
+	testutil.Main(historyRunner{m})
+}
+
+// historyRunner saves the results to -history once every benchmark has run
+type historyRunner struct {
+	m *testing.M
+}
+
+func (r historyRunner) Run() int {
+	code := r.m.Run()
+
+	regressions, err := saveHistory(time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to update benchmark history: %v\n", err)
+		return 1
+	}
+	for _, regression := range regressions {
+		fmt.Fprintf(os.Stdout, "REGRESSION %s\n", regression)
+	}
+	if len(regressions) > 0 && *failOnRegression && code == 0 {
+		code = 1
+	}
+	return code
+}
+
+// results holds each benchmark's metrics from its final, largest b.N run;
+// testing calls a benchmark with growing b.N, and every call overwrites the last
+var results = struct {
+	sync.Mutex
+	metrics map[string]map[string]float64
+}{metrics: map[string]map[string]float64{}}
+
+// report reports metric for b and keeps it for the history
+func report(b *testing.B, value float64, metric string) {
+	b.ReportMetric(value, metric)
+
+	results.Lock()
+	defer results.Unlock()
+	if results.metrics[b.Name()] == nil {
+		results.metrics[b.Name()] = map[string]float64{}
+	}
+	results.metrics[b.Name()][metric] = value
+}
+
+// reportPerOp reports the timed duration of one iteration
+func reportPerOp(b *testing.B) {
+	report(b, float64(b.Elapsed().Nanoseconds())/float64(b.N), "ns/op")
+}
+
+// saveHistory compares this run's results with the history and appends them to it
+func saveHistory(now time.Time) ([]string, error) {
+	results.Lock()
+	defer results.Unlock()
+
+	var current []Sample
+	for benchmark, metrics := range results.metrics {
+		for metric, value := range metrics {
+			current = append(current, Sample{Time: now, Revision: *revision, Benchmark: benchmark, Metric: metric, Value: value})
+		}
+	}
+	if len(current) == 0 {
+		return nil, nil
+	}
+	sort.Slice(current, func(i, j int) bool {
+		if current[i].Benchmark != current[j].Benchmark {
+			return current[i].Benchmark < current[j].Benchmark
+		}
+		return current[i].Metric < current[j].Metric
+	})
+
+	history, err := ReadHistory(*historyPath)
+	if err != nil {
+		return nil, err
+	}
+	return Regressions(history, current, *tolerance), AppendHistory(*historyPath, current)
+}
+
+// requireFlag skips b when the flag naming its target is not set
+func requireFlag(b *testing.B, value, name string) {
+	if value == "" {
+		b.Skipf("-%s not set", name)
+	}
+}
+
+// keyPrefix is where b writes in the raw bucket, unique to the run
+func keyPrefix(b *testing.B) string {
+	prefix, err := runprefix.Get()
+	require.NoError(b, err)
+	return fmt.Sprintf("benchmarks/%s/%s/", prefix, strings.ToLower(strings.ReplaceAll(b.Name(), "/", "-")))
+}
+
+// deletePrefix removes every object b wrote under prefix of the raw bucket
+func deletePrefix(b *testing.B, clients *awsclients.Clients, prefix string) {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	client := clients.S3()
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{Bucket: rawBucket, Prefix: awssdk.String(prefix)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			b.Logf("Failed to list s3://%s/%s for cleanup: %v", *rawBucket, prefix, err)
+			return
+		}
+		if len(page.Contents) == 0 {
+			continue
+		}
+
+		objects := make([]s3types.ObjectIdentifier, 0, len(page.Contents))
+		for _, object := range page.Contents {
+			objects = append(objects, s3types.ObjectIdentifier{Key: object.Key})
+		}
+		if _, err := client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: rawBucket,
+			Delete: &s3types.Delete{Objects: objects, Quiet: awssdk.Bool(true)},
+		}); err != nil {
+			b.Logf("Failed to delete s3://%s/%s: %v", *rawBucket, prefix, err)
+			return
+		}
+	}
+}
+
+// referenceDataset renders the table the Athena benchmark queries; the same
+// row count always produces the same bytes, so runs stay comparable
+func referenceDataset(count int) []byte {
+	countries := []string{"SG", "US", "DE", "JP", "BR", "IN", "GB", "AU"}
+
+	var buffer bytes.Buffer
+	buffer.WriteString("id,name,country\n")
+	for id := 1; id <= count; id++ {
+		fmt.Fprintf(&buffer, "%d,user%d,%s\n", id, id%997, countries[id%len(countries)])
+	}
+	return buffer.Bytes()
+}
+
+// BenchmarkS3MultipartUpload uploads -upload-size objects to the raw bucket
+// in -part-size parts
+func BenchmarkS3MultipartUpload(b *testing.B) {
+	requireFlag(b, *rawBucket, "raw-bucket")
+	clients := awsclients.New(b, awsclients.WithRegion(*region))
+	prefix := keyPrefix(b)
+	b.Cleanup(func() { deletePrefix(b, clients, prefix) })
+
+	uploader := manager.NewUploader(clients.S3(), func(u *manager.Uploader) {
+		u.PartSize = *partSize
+	})
+	body := bytes.Repeat([]byte("0123456789abcdef"), *uploadSize/16)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := uploader.Upload(context.Background(), &s3.PutObjectInput{
+			Bucket: rawBucket,
+			Key:    awssdk.String(prefix + strconv.Itoa(i)),
+			Body:   bytes.NewReader(body),
+		})
+		require.NoError(b, err, "Failed to upload to s3://%s/%s", *rawBucket, prefix)
+	}
+	b.StopTimer()
+
+	reportPerOp(b)
+	report(b, float64(len(body)*b.N)/1e6/b.Elapsed().Seconds(), "MB/s")
+}
+
+// BenchmarkKinesisPutRecords puts full batches of -record-size records;
+// throttled records count against the throughput rather than being retried
+func BenchmarkKinesisPutRecords(b *testing.B) {
+	requireFlag(b, *stream, "stream")
+	clients := awsclients.New(b, awsclients.WithRegion(*region), awsclients.WithMaxAttempts(1))
+	client := clients.Kinesis()
+
+	entries := make([]kinesistypes.PutRecordsRequestEntry, loadgen.MaxBatchSize)
+	for i := range entries {
+		entries[i] = kinesistypes.PutRecordsRequestEntry{
+			Data:         loadgen.Payload(int64(i), *recordSize),
+			PartitionKey: awssdk.String(strconv.Itoa(i)),
+		}
+	}
+
+	var accepted int64
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		output, err := client.PutRecords(context.Background(), &kinesis.PutRecordsInput{
+			StreamName: stream,
+			Records:    entries,
+		})
+		require.NoError(b, err, "Failed to put records to %s", *stream)
+		accepted += int64(len(entries)) - int64(awssdk.ToInt32(output.FailedRecordCount))
+	}
+	b.StopTimer()
+
+	reportPerOp(b)
+	report(b, float64(accepted)/b.Elapsed().Seconds(), "records/s")
+	report(b, float64(accepted*int64(*recordSize))/1e6/b.Elapsed().Seconds(), "MB/s")
+}
+
+// BenchmarkAthenaQuery times the reference queries over -reference-rows rows
+// uploaded to the raw bucket and catalogued in -database
+func BenchmarkAthenaQuery(b *testing.B) {
+	requireFlag(b, *rawBucket, "raw-bucket")
+	requireFlag(b, *database, "database")
+	clients := awsclients.New(b, awsclients.WithRegion(*region))
+	prefix := keyPrefix(b)
+	b.Cleanup(func() { deletePrefix(b, clients, prefix) })
+
+	ctx, cancel := clients.Context()
+	_, err := clients.S3().PutObject(ctx, &s3.PutObjectInput{
+		Bucket: rawBucket,
+		Key:    awssdk.String(prefix + "events/data.csv"),
+		Body:   bytes.NewReader(referenceDataset(*rows)),
+	})
+	cancel()
+	require.NoError(b, err, "Failed to upload the reference dataset to %s", *rawBucket)
+
+	runID, err := runprefix.Get()
+	require.NoError(b, err)
+	table := fmt.Sprintf("benchmark_%s_events", runID)
+
+	createTable := fmt.Sprintf(`CREATE EXTERNAL TABLE %s (id int, name string, country string)
+ROW FORMAT DELIMITED FIELDS TERMINATED BY ','
+LOCATION 's3://%s/%sevents/'
+TBLPROPERTIES ('skip.header.line.count'='1')`, table, *rawBucket, prefix)
+	runQuery(b, clients, createTable)
+	b.Cleanup(func() { runQuery(b, clients, "DROP TABLE IF EXISTS "+table) })
+
+	names := make([]string, 0, len(referenceQueries))
+	for name := range referenceQueries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		sql := fmt.Sprintf(referenceQueries[name], table)
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				result, err := athena.RunQuery(context.Background(), clients, athena.Query{SQL: sql, Database: *database, Workgroup: *workgroup})
+				b.StopTimer()
+				if result != nil {
+					cleanupResult(b, clients, result)
+				}
+				require.NoError(b, err, "Reference query %s failed", name)
+				b.StartTimer()
+			}
+			reportPerOp(b)
+		})
+	}
+}
+
+// runQuery runs an untimed statement against -database and removes its result file
+func runQuery(b *testing.B, clients *awsclients.Clients, sql string) {
+	result, err := athena.RunQuery(context.Background(), clients, athena.Query{SQL: sql, Database: *database, Workgroup: *workgroup})
+	if result != nil {
+		cleanupResult(b, clients, result)
+	}
+	require.NoError(b, err, "Query failed: %s", sql)
+}
+
+// cleanupResult removes the files Athena wrote for result
+func cleanupResult(b *testing.B, clients *awsclients.Clients, result *athena.Result) {
+	ctx, cancel := clients.Context()
+	defer cancel()
+	if err := result.Cleanup(ctx, clients); err != nil {
+		b.Logf("Failed to clean up query %s: %v", result.QueryExecutionID, err)
+	}
+}
+
+// BenchmarkGlueJobStart times how long -glue-job takes to go from StartJobRun
+// to RUNNING, stopping each run once it starts. Every run takes tens of
+// seconds, so pass e.g. -benchtime 5x for a stable figure.
+func BenchmarkGlueJobStart(b *testing.B) {
+	requireFlag(b, *glueJob, "glue-job")
+	clients := awsclients.New(b, awsclients.WithRegion(*region))
+	client := clients.Glue()
+	started := wait.DefaultOptions().WithTimeout(10 * time.Minute).WithInterval(time.Second)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		run, err := client.StartJobRun(context.Background(), &glue.StartJobRunInput{JobName: glueJob})
+		require.NoError(b, err, "Failed to start %s", *glueJob)
+		runID := awssdk.ToString(run.JobRunId)
+
+		err = wait.WaitFor(context.Background(), wait.JobRunStarted(client, *glueJob, runID), started)
+		b.StopTimer()
+		stopJobRun(b, client, runID)
+		require.NoError(b, err)
+		b.StartTimer()
+	}
+	b.StopTimer()
+
+	reportPerOp(b)
+}
+
+// stopJobRun stops the run and waits for it to end, so the next one is not
+// refused for exceeding the job's concurrent runs
+func stopJobRun(b *testing.B, client *glue.Client, runID string) {
+	ctx := context.Background()
+	_, err := client.BatchStopJobRun(ctx, &glue.BatchStopJobRunInput{JobName: glueJob, JobRunIds: []string{runID}})
+	require.NoError(b, err, "Failed to stop run %s of %s", runID, *glueJob)
+
+	err = wait.WaitFor(ctx, func(ctx context.Context) (bool, error) {
+		output, err := client.GetJobRun(ctx, &glue.GetJobRunInput{JobName: glueJob, RunId: awssdk.String(runID)})
+		if err != nil {
+			return false, err
+		}
+		switch output.JobRun.JobRunState {
+		case gluetypes.JobRunStateStopped, gluetypes.JobRunStateSucceeded, gluetypes.JobRunStateFailed,
+			gluetypes.JobRunStateError, gluetypes.JobRunStateTimeout:
+			return true, nil
+		default:
+			return false, fmt.Errorf("job run %s is %s", runID, output.JobRun.JobRunState)
+		}
+	}, wait.DefaultOptions())
+	require.NoError(b, err, "Run %s of %s did not stop", runID, *glueJob)
+}
@@ -0,0 +1,179 @@
+// =============================================================================
+// Benchmark History
+// Keeps every run's results in a CSV file and flags regressions against it
+// =============================================================================
+
+// Package benchmarks measures platform operations against a deployed
+// environment: S3 multipart uploads to the raw bucket, Kinesis PutRecords,
+// Athena queries over the reference dataset and Glue job starts. The
+// benchmarks only build with the benchmark tag, since every one of them calls
+// AWS:
+//
+//	go test -tags benchmark -run '^$' -bench . ./benchmarks -raw-bucket <bucket> -database <database>
+//
+// Each run appends its results to a CSV history and reports the metrics that
+// are worse than the recent median by more than the tolerance.
+package benchmarks
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HistoryWindow is how many earlier runs of a metric form its baseline
+const HistoryWindow = 10
+
+// historyHeader names the CSV columns
+var historyHeader = []string{"time", "revision", "benchmark", "metric", "value"}
+
+// Sample is one metric reported by one benchmark run
+type Sample struct {
+	Time      time.Time
+	Revision  string
+	Benchmark string
+
+	// Metric is the unit the benchmark reported, e.g. "ns/op" or "MB/s"
+	Metric string
+	Value  float64
+}
+
+// HigherIsBetter reports whether larger values of metric are improvements;
+// rates such as "MB/s" are, durations such as "ns/op" are not
+func HigherIsBetter(metric string) bool {
+	return strings.HasSuffix(metric, "/s")
+}
+
+// ReadHistory reads the samples recorded in path; a missing file is an empty history
+func ReadHistory(path string) ([]Sample, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = len(historyHeader)
+	if _, err := reader.Read(); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var samples []Sample
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return samples, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		recorded, err := time.Parse(time.RFC3339, record[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid time %q: %w", path, record[0], err)
+		}
+		value, err := strconv.ParseFloat(record[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid value %q: %w", path, record[4], err)
+		}
+		samples = append(samples, Sample{
+			Time:      recorded,
+			Revision:  record[1],
+			Benchmark: record[2],
+			Metric:    record[3],
+			Value:     value,
+		})
+	}
+}
+
+// AppendHistory adds samples to path, writing the header when the file is new
+func AppendHistory(path string, samples []Sample) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	writer := csv.NewWriter(file)
+	if info.Size() == 0 {
+		writer.Write(historyHeader)
+	}
+	for _, sample := range samples {
+		writer.Write([]string{
+			sample.Time.UTC().Format(time.RFC3339),
+			sample.Revision,
+			sample.Benchmark,
+			sample.Metric,
+			strconv.FormatFloat(sample.Value, 'g', -1, 64),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Regressions lists the current samples that are worse than the median of the
+// last HistoryWindow recorded values of the same benchmark and metric by more
+// than tolerance, e.g. 0.2 for 20%; metrics without history are not compared
+func Regressions(history, current []Sample, tolerance float64) []string {
+	var problems []string
+	for _, sample := range current {
+		var values []float64
+		for _, recorded := range history {
+			if recorded.Benchmark == sample.Benchmark && recorded.Metric == sample.Metric {
+				values = append(values, recorded.Value)
+			}
+		}
+		if len(values) > HistoryWindow {
+			values = values[len(values)-HistoryWindow:]
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		baseline := median(values)
+		if baseline == 0 {
+			continue
+		}
+		change := (sample.Value - baseline) / baseline
+		if HigherIsBetter(sample.Metric) {
+			change = -change
+		}
+		if change > tolerance {
+			problems = append(problems, fmt.Sprintf("%s %s: %.4g is %.0f%% worse than the median %.4g of the last %d runs",
+				sample.Benchmark, sample.Metric, sample.Value, math.Round(change*100), baseline, len(values)))
+		}
+	}
+	return problems
+}
+
+// median returns the middle of values, averaging the two middle ones of an even count
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
@@ -0,0 +1,66 @@
+package benchmarks
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.csv")
+
+	samples, err := ReadHistory(path)
+	require.NoError(t, err)
+	assert.Empty(t, samples)
+
+	recorded := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	first := []Sample{{Time: recorded, Revision: "abc123", Benchmark: "BenchmarkS3MultipartUpload", Metric: "MB/s", Value: 84.5}}
+	second := []Sample{{Time: recorded.Add(time.Hour), Revision: "def456", Benchmark: "BenchmarkAthenaQuery", Metric: "ns/op", Value: 2.1e9}}
+	require.NoError(t, AppendHistory(path, first))
+	require.NoError(t, AppendHistory(path, second))
+
+	samples, err = ReadHistory(path)
+	require.NoError(t, err)
+	assert.Equal(t, append(first, second...), samples)
+}
+
+func TestRegressions(t *testing.T) {
+	var history []Sample
+	for _, value := range []float64{100, 90, 110, 100, 95} {
+		history = append(history,
+			Sample{Benchmark: "BenchmarkS3MultipartUpload", Metric: "MB/s", Value: value},
+			Sample{Benchmark: "BenchmarkS3MultipartUpload", Metric: "ns/op", Value: value * 1e6},
+		)
+	}
+
+	assert.Equal(t, []string{
+		"BenchmarkS3MultipartUpload MB/s: 70 is 30% worse than the median 100 of the last 5 runs",
+		"BenchmarkS3MultipartUpload ns/op: 1.3e+08 is 30% worse than the median 1e+08 of the last 5 runs",
+	}, Regressions(history, []Sample{
+		{Benchmark: "BenchmarkS3MultipartUpload", Metric: "MB/s", Value: 70},
+		{Benchmark: "BenchmarkS3MultipartUpload", Metric: "ns/op", Value: 1.3e8},
+		{Benchmark: "BenchmarkKinesisPutRecords", Metric: "records/s", Value: 1},
+	}, 0.2))
+
+	assert.Empty(t, Regressions(history, []Sample{
+		{Benchmark: "BenchmarkS3MultipartUpload", Metric: "MB/s", Value: 130},
+		{Benchmark: "BenchmarkS3MultipartUpload", Metric: "ns/op", Value: 1.1e8},
+	}, 0.2), "improvements and changes within the tolerance pass")
+}
+
+func TestRegressionsWindow(t *testing.T) {
+	var history []Sample
+	for i := 0; i < 20; i++ {
+		value := 1000.0
+		if i >= 20-HistoryWindow {
+			value = 100
+		}
+		history = append(history, Sample{Benchmark: "BenchmarkAthenaQuery", Metric: "ns/op", Value: value})
+	}
+
+	// Only the last HistoryWindow runs count, so the older slow runs do not hide a regression
+	assert.Len(t, Regressions(history, []Sample{{Benchmark: "BenchmarkAthenaQuery", Metric: "ns/op", Value: 200}}, 0.2), 1)
+}
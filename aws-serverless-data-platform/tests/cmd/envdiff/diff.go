@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tfplan"
+)
+
+// defaultIntentional matches the settings expected to differ between
+// environments: identity, networks, sizing, counts and retention
+const defaultIntentional = `(?i)(^aws\.|^tags\.|account_id|assume_role_arn|state_bucket|cidr|subnets|` +
+	`instance_type|instance_class|environment_class|instance_count|node|workers|shard_count|capacity|_size|single_nat_gateway|` +
+	`retention|_days|force_destroy|mfa_delete|#count)`
+
+// Difference kinds, naming where a difference was found
+const (
+	kindConfig   = "config"
+	kindUnit     = "unit"
+	kindSource   = "source"
+	kindInput    = "input"
+	kindResource = "resource"
+	kindPlan     = "plan"
+)
+
+// missing stands in for the value of a setting an environment does not have
+const missing = "<missing>"
+
+// report is the comparison of two environments
+type report struct {
+	From        string       `json:"from"`
+	To          string       `json:"to"`
+	Intentional []difference `json:"intentional"`
+	Drift       []difference `json:"drift"`
+}
+
+// difference is one setting, unit or resource that is not the same in both environments
+type difference struct {
+	Kind string `json:"kind"`
+
+	// Unit is the Terragrunt unit the difference is in, empty for configuration
+	Unit string `json:"unit,omitempty"`
+
+	// Key is the dotted configuration path, input name or resource address
+	Key string `json:"key"`
+
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// compare lists the differences between two environments, classifying those
+// whose key matches intentional as intended and the rest as drift
+func compare(a, b *environment, intentional *regexp.Regexp) *report {
+	r := &report{From: a.Name, To: b.Name}
+	classify := func(d difference) {
+		// Missing units, changed sources and missing resources are never intended
+		if d.Kind != kindUnit && d.Kind != kindSource && d.From != missing && d.To != missing && intentional.MatchString(d.Key) {
+			r.Intentional = append(r.Intentional, d)
+		} else {
+			r.Drift = append(r.Drift, d)
+		}
+	}
+
+	for _, d := range diffValues(kindConfig, "", a.Config, b.Config) {
+		classify(d)
+	}
+
+	for _, name := range union(a.Units, b.Units) {
+		ua, inA := a.Units[name]
+		ub, inB := b.Units[name]
+		if !inA || !inB {
+			classify(difference{Kind: kindUnit, Unit: name, Key: name, From: present(inA), To: present(inB)})
+			continue
+		}
+		if ua.Source != ub.Source {
+			classify(difference{Kind: kindSource, Unit: name, Key: "terraform.source", From: ua.Source, To: ub.Source})
+		}
+		for _, d := range diffValues(kindInput, name, ua.Inputs, ub.Inputs) {
+			classify(d)
+		}
+	}
+
+	if a.Plans != nil && b.Plans != nil {
+		for _, name := range union(a.Plans, b.Plans) {
+			pa, inA := a.Plans[name]
+			pb, inB := b.Plans[name]
+			if !inA || !inB {
+				classify(difference{Kind: kindPlan, Unit: name, Key: name, From: present(inA), To: present(inB)})
+				continue
+			}
+			for _, d := range diffPlans(name, pa, pb) {
+				classify(d)
+			}
+		}
+	}
+
+	return r
+}
+
+// diffValues compares two flat maps of settings
+func diffValues(kind, unitName string, a, b map[string]string) []difference {
+	var diffs []difference
+	for _, key := range union(a, b) {
+		va, inA := a[key]
+		vb, inB := b[key]
+		if inA && inB && va == vb {
+			continue
+		}
+		if !inA {
+			va = missing
+		}
+		if !inB {
+			vb = missing
+		}
+		diffs = append(diffs, difference{Kind: kind, Unit: unitName, Key: key, From: va, To: vb})
+	}
+	return diffs
+}
+
+// diffPlans compares two plans of one unit by resource block, ignoring
+// instance indexes: blocks missing from one plan, different instance counts,
+// and the top-level attributes of each block's first instance
+func diffPlans(unitName string, a, b *tfplan.Plan) []difference {
+	var diffs []difference
+	if a.TerraformVersion != b.TerraformVersion {
+		diffs = append(diffs, difference{Kind: kindPlan, Unit: unitName, Key: "terraform_version", From: a.TerraformVersion, To: b.TerraformVersion})
+	}
+
+	blocksA, blocksB := blocks(a), blocks(b)
+	for _, address := range union(blocksA, blocksB) {
+		ra, inA := blocksA[address]
+		rb, inB := blocksB[address]
+		if !inA || !inB {
+			diffs = append(diffs, difference{Kind: kindResource, Unit: unitName, Key: address, From: present(inA), To: present(inB)})
+			continue
+		}
+		if len(ra) != len(rb) {
+			diffs = append(diffs, difference{Kind: kindResource, Unit: unitName, Key: address + "#count",
+				From: fmt.Sprint(len(ra)), To: fmt.Sprint(len(rb))})
+		}
+		diffs = append(diffs, diffValues(kindResource, unitName, attributes(address, ra[0]), attributes(address, rb[0]))...)
+	}
+	return diffs
+}
+
+// indexSuffix matches the [0] or ["key"] instance index at the end of an address
+var indexSuffix = regexp.MustCompile(`\[[^\]]*\]$`)
+
+// blocks groups a plan's managed resources by address without instance index
+func blocks(plan *tfplan.Plan) map[string][]tfplan.Resource {
+	grouped := map[string][]tfplan.Resource{}
+	for _, resource := range plan.Resources() {
+		address := indexSuffix.ReplaceAllString(resource.Address, "")
+		grouped[address] = append(grouped[address], resource)
+	}
+	return grouped
+}
+
+// attributes flattens a resource's scalar top-level attributes under address.attribute;
+// nested blocks, and values only known after apply, are left out
+func attributes(address string, resource tfplan.Resource) map[string]string {
+	values := map[string]string{}
+	for name, value := range resource.Values {
+		switch value.(type) {
+		case string, bool, float64:
+			rendered, _ := json.Marshal(value)
+			values[address+"."+name] = string(rendered)
+		}
+	}
+	return values
+}
+
+// union returns the keys of both maps in sorted order
+func union[V any](a, b map[string]V) []string {
+	seen := map[string]bool{}
+	for key := range a {
+		seen[key] = true
+	}
+	for key := range b {
+		seen[key] = true
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// present renders whether an environment has a unit or resource
+func present(ok bool) string {
+	if ok {
+		return "present"
+	}
+	return missing
+}
+
+// summarize writes the drift, then the intended differences
+func summarize(out io.Writer, r *report) {
+	fmt.Fprintf(out, "Comparing %s with %s\n", r.From, r.To)
+
+	section := func(title string, diffs []difference) {
+		fmt.Fprintf(out, "\n%s (%d)\n", title, len(diffs))
+		for _, d := range diffs {
+			location := d.Key
+			if d.Unit != "" && d.Unit != d.Key {
+				location = d.Unit + ": " + d.Key
+			}
+			fmt.Fprintf(out, "  %-8s %-60s %s → %s\n", d.Kind, location, truncate(d.From), truncate(d.To))
+		}
+	}
+	section("❌ Drift", r.Drift)
+	section("✅ Intentional differences", r.Intentional)
+}
+
+// truncate shortens long values such as rendered lists for the summary
+func truncate(value string) string {
+	const limit = 40
+	if len(value) <= limit {
+		return value
+	}
+	return value[:limit-1] + "…"
+}
+
+// writeJSON writes the report as indented JSON
+func writeJSON(w io.Writer, r *report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// String renders a difference for test failures
+func (d difference) String() string {
+	return strings.Join([]string{d.Kind, d.Unit, d.Key, d.From, d.To}, "|")
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tfplan"
+)
+
+// writeFiles creates files below root from a map of relative paths to contents
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+const storageUnit = `
+terraform {
+  source = "${get_repo_root()}/modules//storage%s"
+}
+
+inputs = {
+  raw_bucket_name = "raw-${local.environment}"
+  retention_days  = %s
+  %s
+}
+`
+
+func testRepo(t *testing.T) string {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"config/common.yaml":               "terraform:\n  version: \"1.5.7\"\nnetworking:\n  vpc:\n    cidr: \"10.9.0.0/16\"\n    enable_flow_logs: true\n",
+		"config/accounts.yaml":             "staging:\n  aws:\n    account_id: \"111111111111\"\nprod:\n  aws:\n    account_id: \"222222222222\"\n",
+		"config/environments/staging.yaml": "networking:\n  vpc:\n    cidr: \"10.1.0.0/16\"\n",
+		"config/environments/prod.yaml":    "networking:\n  vpc:\n    cidr: \"10.2.0.0/16\"\n    enable_flow_logs: true\n",
+
+		"environments/staging/us-east-1/01-networking/terragrunt.hcl": "terraform {\n  source = \"${get_repo_root()}/modules//networking\"\n}\n",
+		"environments/staging/us-east-1/03-storage/terragrunt.hcl":    fmt.Sprintf(storageUnit, "", "7", ""),
+		"environments/prod/us-east-1/03-storage/terragrunt.hcl":       fmt.Sprintf(storageUnit, "?ref=v2.0.0", "365", `force_destroy = false`),
+	})
+	return root
+}
+
+func TestCompare(t *testing.T) {
+	root := testRepo(t)
+
+	staging, err := load(root, "staging", "")
+	require.NoError(t, err)
+	prod, err := load(root, "prod/us-east-1", "")
+	require.NoError(t, err)
+	assert.Equal(t, "staging/us-east-1", staging.Name)
+
+	r := compare(staging, prod, regexp.MustCompile(defaultIntentional))
+
+	assert.ElementsMatch(t, []string{
+		`config||aws.account_id|"111111111111"|"222222222222"`,
+		`config||networking.vpc.cidr|"10.1.0.0/16"|"10.2.0.0/16"`,
+		`input|03-storage|retention_days|7|365`,
+	}, rendered(r.Intentional))
+
+	assert.ElementsMatch(t, []string{
+		`config||networking.vpc.enable_flow_logs|<missing>|true`,
+		`unit|01-networking|01-networking|present|<missing>`,
+		`source|03-storage|terraform.source|modules//storage|modules//storage?ref=v2.0.0`,
+		`input|03-storage|force_destroy|<missing>|false`,
+	}, rendered(r.Drift), "Environment sections replace common ones, as in root.hcl")
+}
+
+func TestLoadRejectsUnknownEnvironment(t *testing.T) {
+	_, err := load(testRepo(t), "qa", "")
+	assert.ErrorContains(t, err, "config/environments/qa.yaml")
+}
+
+func TestLoadRequiresRegionWhenSeveral(t *testing.T) {
+	root := testRepo(t)
+	writeFiles(t, root, map[string]string{"environments/prod/eu-west-1/03-storage/terragrunt.hcl": "inputs = {}\n"})
+
+	_, err := load(root, "prod", "")
+	assert.ErrorContains(t, err, "several regions")
+}
+
+func TestDiffPlans(t *testing.T) {
+	staging, err := tfplan.Parse(`{"terraform_version": "1.5.7", "planned_values": {"root_module": {"resources": [
+		{"address": "aws_nat_gateway.main[0]", "mode": "managed", "type": "aws_nat_gateway", "values": {"connectivity_type": "public"}},
+		{"address": "aws_flow_log.vpc", "mode": "managed", "type": "aws_flow_log", "values": {}},
+		{"address": "aws_cloudwatch_log_group.flow", "mode": "managed", "type": "aws_cloudwatch_log_group", "values": {"retention_in_days": 7, "name": "staging"}}
+	]}}}`)
+	require.NoError(t, err)
+	prod, err := tfplan.Parse(`{"terraform_version": "1.5.7", "planned_values": {"root_module": {"resources": [
+		{"address": "aws_nat_gateway.main[0]", "mode": "managed", "type": "aws_nat_gateway", "values": {"connectivity_type": "public"}},
+		{"address": "aws_nat_gateway.main[1]", "mode": "managed", "type": "aws_nat_gateway", "values": {"connectivity_type": "public"}},
+		{"address": "aws_cloudwatch_log_group.flow", "mode": "managed", "type": "aws_cloudwatch_log_group", "values": {"retention_in_days": 90, "name": "prod", "tags": {"a": "b"}}}
+	]}}}`)
+	require.NoError(t, err)
+
+	r := compare(&environment{Name: "staging", Plans: map[string]*tfplan.Plan{"01-networking": staging}},
+		&environment{Name: "prod", Plans: map[string]*tfplan.Plan{"01-networking": prod}},
+		regexp.MustCompile(defaultIntentional))
+
+	assert.ElementsMatch(t, []string{
+		`resource|01-networking|aws_cloudwatch_log_group.flow.retention_in_days|7|90`,
+		`resource|01-networking|aws_nat_gateway.main#count|1|2`,
+	}, rendered(r.Intentional))
+	assert.ElementsMatch(t, []string{
+		`resource|01-networking|aws_cloudwatch_log_group.flow.name|"staging"|"prod"`,
+		`resource|01-networking|aws_flow_log.vpc|present|<missing>`,
+	}, rendered(r.Drift))
+}
+
+func TestParseUnitNormalizesLayout(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"a/terragrunt.hcl": "inputs = {\n  tags = {\n    Layer = \"data\"\n  }\n  name = \"x\"\n}\n",
+		"b/terragrunt.hcl": "inputs = {\n  name = \"x\"\n  tags = { Layer = \"data\" }\n}\n",
+	})
+
+	a, err := parseUnit(filepath.Join(root, "a/terragrunt.hcl"))
+	require.NoError(t, err)
+	b, err := parseUnit(filepath.Join(root, "b/terragrunt.hcl"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "x", a.Inputs["name"])
+	assert.Equal(t, b.Inputs["name"], a.Inputs["name"])
+	assert.Equal(t, `{ Layer = "data" }`, a.Inputs["tags"])
+	assert.Equal(t, b.Inputs["tags"], a.Inputs["tags"])
+}
+
+// rendered renders differences for comparison
+func rendered(diffs []difference) []string {
+	var out []string
+	for _, d := range diffs {
+		out = append(out, d.String())
+	}
+	return out
+}
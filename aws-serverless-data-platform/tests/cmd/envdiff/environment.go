@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tfplan"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tggraph"
+	"gopkg.in/yaml.v3"
+)
+
+// environment is everything envdiff compares about one environment
+type environment struct {
+	// Name is <env>/<region>, or <env> when it has no Terragrunt units yet
+	Name string
+
+	// Config maps dotted paths such as networking.vpc.cidr to values rendered as JSON
+	Config map[string]string
+
+	// Units maps Terragrunt unit names such as 03-storage to their configuration
+	Units map[string]unit
+
+	// Plans maps unit names to their rendered plans; nil when no plans were given
+	Plans map[string]*tfplan.Plan
+}
+
+// unit is the part of a terragrunt.hcl that must match between environments
+type unit struct {
+	// Source is the module the unit deploys, e.g. modules//storage?ref=v1.2.0
+	Source string
+
+	// Inputs maps each input to its expression as written, so references to
+	// local.environment compare equal across environments
+	Inputs map[string]string
+}
+
+// sourcePrefix is stripped from unit sources so only the module path and ref remain
+var sourcePrefix = regexp.MustCompile(`^.*?(modules//)`)
+
+// load reads the configuration, units and plans of the environment named
+// <env> or <env>/<region>
+func load(root, name, plansDir string) (*environment, error) {
+	env, region, _ := strings.Cut(name, "/")
+	if region == "" {
+		regions, err := filepath.Glob(filepath.Join(root, "environments", env, "*"))
+		if err != nil {
+			return nil, err
+		}
+		switch len(regions) {
+		case 0:
+		case 1:
+			region = filepath.Base(regions[0])
+		default:
+			return nil, fmt.Errorf("environment %s has several regions; name one, e.g. %s/%s", env, env, filepath.Base(regions[0]))
+		}
+	}
+
+	e := &environment{Name: env, Units: map[string]unit{}}
+	if region != "" {
+		e.Name = env + "/" + region
+	}
+
+	var err error
+	if e.Config, err = loadConfig(root, env); err != nil {
+		return nil, err
+	}
+	if region != "" {
+		if e.Units, err = loadUnits(filepath.Join(root, "environments", env, region)); err != nil {
+			return nil, err
+		}
+	}
+	if plansDir != "" {
+		if e.Plans, err = loadPlans(plansDir); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// loadConfig merges config/common.yaml, the environment's entry in
+// config/accounts.yaml, or the entry its account key names, and
+// config/environments/<env>.yaml the way root.hcl does, replacing whole
+// top-level sections, and flattens the result
+func loadConfig(root, env string) (map[string]string, error) {
+	merged := map[string]interface{}{}
+
+	common, err := readYAML(filepath.Join(root, "config", "common.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := readYAML(filepath.Join(root, "config", "accounts.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := readYAML(filepath.Join(root, "config", "environments", env+".yaml"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("environment %s has no config/environments/%s.yaml", env, env)
+	}
+	if err != nil {
+		return nil, err
+	}
+	accountName, ok := overrides["account"].(string)
+	if !ok {
+		accountName = env
+	}
+	account, _ := accounts[accountName].(map[string]interface{})
+
+	for _, layer := range []map[string]interface{}{common, account, overrides} {
+		for key, value := range layer {
+			merged[key] = value
+		}
+	}
+
+	config := map[string]string{}
+	flatten("", merged, config)
+	return config, nil
+}
+
+// readYAML decodes a YAML mapping
+func readYAML(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// flatten records every leaf of value under its dotted path; lists are leaves
+func flatten(prefix string, value interface{}, out map[string]string) {
+	if values, ok := value.(map[string]interface{}); ok {
+		for key, child := range values {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flatten(path, child, out)
+		}
+		return
+	}
+
+	rendered, err := json.Marshal(value)
+	if err != nil {
+		rendered = []byte(fmt.Sprint(value))
+	}
+	out[prefix] = string(rendered)
+}
+
+// loadUnits reads the source and inputs of every unit in a region directory
+func loadUnits(dir string) (map[string]unit, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*", tggraph.ConfigFile))
+	if err != nil {
+		return nil, err
+	}
+
+	units := map[string]unit{}
+	for _, path := range files {
+		u, err := parseUnit(path)
+		if err != nil {
+			return nil, err
+		}
+		units[filepath.Base(filepath.Dir(path))] = u
+	}
+	return units, nil
+}
+
+// parseUnit reads terraform.source and the inputs of one terragrunt.hcl
+func parseUnit(path string) (unit, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return unit{}, err
+	}
+	file, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return unit{}, fmt.Errorf("failed to parse %s: %s", path, diags.Error())
+	}
+	body := file.Body.(*hclsyntax.Body)
+
+	u := unit{Inputs: map[string]string{}}
+	for _, block := range body.Blocks {
+		if block.Type != "terraform" {
+			continue
+		}
+		if source, ok := block.Body.Attributes["source"]; ok {
+			u.Source = sourcePrefix.ReplaceAllString(expressionText(src, source.Expr), "$1")
+		}
+	}
+
+	inputs, ok := body.Attributes["inputs"]
+	if !ok {
+		return u, nil
+	}
+	object, ok := inputs.Expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return unit{}, fmt.Errorf("%s: inputs must be an object", path)
+	}
+	for _, item := range object.Items {
+		key := strings.Trim(expressionText(src, item.KeyExpr), `"`)
+		u.Inputs[key] = expressionText(src, item.ValueExpr)
+	}
+	return u, nil
+}
+
+// whitespace collapses the layout of an expression so alignment changes do not count
+var whitespace = regexp.MustCompile(`\s+`)
+
+// expressionText returns the source of expr with whitespace collapsed and quotes trimmed
+func expressionText(src []byte, expr hclsyntax.Expression) string {
+	text := string(expr.Range().SliceBytes(src))
+	return strings.Trim(whitespace.ReplaceAllString(strings.TrimSpace(text), " "), `"`)
+}
+
+// loadPlans parses every <unit>.json plan in dir
+func loadPlans(dir string) (map[string]*tfplan.Plan, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no <unit>.json plans in %s", dir)
+	}
+	sort.Strings(files)
+
+	plans := map[string]*tfplan.Plan{}
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		plan, err := tfplan.Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		plans[strings.TrimSuffix(filepath.Base(path), ".json")] = plan
+	}
+	return plans, nil
+}
@@ -0,0 +1,102 @@
+// =============================================================================
+// Environment Promotion Diff CLI
+// Separates intended differences between two environments from drift
+// =============================================================================
+
+// Command envdiff compares two environments before a release: their merged
+// YAML configuration (common, accounts and config/environments/<env>.yaml, as
+// root.hcl merges them), the Terragrunt units under environments/<env>/<region>
+// with their module sources and inputs, and optionally their rendered plans.
+//
+// Differences in sizing and retention settings, CIDRs and account identity are
+// expected between environments and reported as intentional. Everything else
+// is drift: units or resources missing from one environment, modules at
+// different sources or versions, and any other setting that differs. The
+// command exits 1 when there is drift; widen -intentional to accept more.
+//
+// Plans are read from directories of `terraform show -json` files named after
+// each unit, e.g. plans/prod/03-storage.json.
+//
+// Usage:
+//
+//	go run ./cmd/envdiff -from staging -to prod
+//	go run ./cmd/envdiff -from dev/ap-southeast-1 -to prod/ap-southeast-1 -from-plans plans/dev -to-plans plans/prod -output envdiff.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/testconfig"
+)
+
+func main() {
+	from := flag.String("from", "staging", "environment promoted from, as <env> or <env>/<region>")
+	to := flag.String("to", "prod", "environment promoted to, as <env> or <env>/<region>")
+	fromPlans := flag.String("from-plans", "", "directory of JSON plans for -from, one <unit>.json per unit")
+	toPlans := flag.String("to-plans", "", "directory of JSON plans for -to, one <unit>.json per unit")
+	intentional := flag.String("intentional", defaultIntentional, "regular expression matching setting paths expected to differ")
+	output := flag.String("output", "", "file to write the JSON report to, - for stdout")
+	flag.Parse()
+
+	pattern, err := regexp.Compile(*intentional)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -intentional: %v\n", err)
+		os.Exit(2)
+	}
+
+	r, err := run(*from, *to, *fromPlans, *toPlans, pattern)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	summarize(os.Stderr, r)
+	if *output != "" {
+		if err := write(*output, r); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+	if len(r.Drift) > 0 {
+		os.Exit(1)
+	}
+}
+
+// run loads both environments and compares them
+func run(from, to, fromPlans, toPlans string, intentional *regexp.Regexp) (*report, error) {
+	root, err := testconfig.FindRoot()
+	if err != nil {
+		return nil, err
+	}
+	if root == "" {
+		return nil, fmt.Errorf("run envdiff from inside the repository; %s was not found", testconfig.BaseFile)
+	}
+
+	a, err := load(root, from, fromPlans)
+	if err != nil {
+		return nil, err
+	}
+	b, err := load(root, to, toPlans)
+	if err != nil {
+		return nil, err
+	}
+	return compare(a, b, intentional), nil
+}
+
+// write writes the report to path, or stdout for "-"
+func write(path string, r *report) error {
+	var out io.Writer = os.Stdout
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	return writeJSON(out, r)
+}
@@ -0,0 +1,128 @@
+// =============================================================================
+// Load Generator CLI
+// Benchmarks the ingestion Kinesis stream before an environment is promoted
+// =============================================================================
+
+// Command loadgen pushes a fixed rate of synthetic JSON records at a Kinesis
+// stream, then reports put latency, throttling, consumer iterator age and
+// whether the stream has enough shards for the load. It exits non-zero when
+// any threshold is breached.
+//
+// Usage:
+//
+//	go run ./cmd/loadgen -stream data-platform-dev-ingestion -rate 2000 -duration 10m -json report.json
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/loadgen"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/wait"
+)
+
+func main() {
+	cfg := loadgen.DefaultConfig("")
+	thresholds := loadgen.DefaultThresholds()
+
+	region := flag.String("region", testutil.DefaultRegion, "AWS region of the stream")
+	roleARN := flag.String("role-arn", os.Getenv(awsclients.RoleARNEnvVar), "IAM role to assume before generating load")
+	externalID := flag.String("external-id", os.Getenv(awsclients.ExternalIDEnvVar), "external ID required by the role's trust policy")
+	flag.StringVar(&cfg.StreamName, "stream", "", "name of the Kinesis stream to load (required)")
+	flag.IntVar(&cfg.Rate, "rate", cfg.Rate, "records offered per second")
+	flag.DurationVar(&cfg.Duration, "duration", cfg.Duration, "how long to generate load")
+	flag.IntVar(&cfg.MinPayload, "min-payload", cfg.MinPayload, "smallest record in bytes")
+	flag.IntVar(&cfg.MaxPayload, "max-payload", cfg.MaxPayload, "largest record in bytes")
+	flag.IntVar(&cfg.BatchSize, "batch-size", cfg.BatchSize, "records per PutRecords call")
+	flag.IntVar(&cfg.Concurrency, "concurrency", cfg.Concurrency, "PutRecords calls in flight")
+	flag.Float64Var(&thresholds.MaxThrottleRate, "max-throttle", thresholds.MaxThrottleRate, "largest tolerated fraction of throttled records")
+	flag.DurationVar(&thresholds.MaxP99Latency, "max-p99", thresholds.MaxP99Latency, "largest tolerated p99 put latency")
+	flag.DurationVar(&thresholds.MaxIteratorAge, "max-iterator-age", thresholds.MaxIteratorAge, "largest tolerated consumer iterator age; 0 disables the check")
+	metricsDelay := flag.Duration("metrics-delay", 2*time.Minute, "time to let CloudWatch catch up before reading metrics; 0 skips them")
+	jsonPath := flag.String("json", "", "also write the report as JSON to this file")
+	flag.Parse()
+
+	passed, err := run(*region, *roleARN, *externalID, cfg, thresholds, *metricsDelay, *jsonPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if !passed {
+		os.Exit(2)
+	}
+}
+
+// run generates the load, gathers the stream's metrics and prints the report
+func run(region, roleARN, externalID string, cfg loadgen.Config, thresholds loadgen.Thresholds, metricsDelay time.Duration, jsonPath string) (bool, error) {
+	if err := cfg.Validate(); err != nil {
+		return false, err
+	}
+
+	// Ctrl-C ends the run early but still reports what was measured
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// A single attempt per call, so throttling is measured rather than retried away
+	awsConfig, _, err := awsclients.LoadConfig(ctx,
+		awsclients.WithRegion(region),
+		awsclients.WithAssumeRole(roleARN),
+		awsclients.WithExternalID(externalID),
+		awsclients.WithSessionName("loadgen"),
+		awsclients.WithMaxAttempts(1),
+	)
+	if err != nil {
+		return false, err
+	}
+	kinesisClient := kinesis.NewFromConfig(awsConfig)
+
+	err = wait.WaitFor(ctx, wait.StreamActive(kinesisClient, cfg.StreamName), wait.DefaultOptions())
+	if err != nil {
+		return false, fmt.Errorf("stream %s is not ready: %w", cfg.StreamName, err)
+	}
+
+	fmt.Printf("Offering %d records/sec to %s for %s\n", cfg.Rate, cfg.StreamName, cfg.Duration)
+	result, err := loadgen.Run(ctx, kinesisClient, cfg)
+	if err != nil {
+		return false, err
+	}
+
+	var metrics *loadgen.StreamMetrics
+	if metricsDelay > 0 {
+		fmt.Printf("Waiting %s for CloudWatch metrics\n", metricsDelay)
+		time.Sleep(metricsDelay)
+
+		// Metrics are read with a fresh context so an interrupted run still gets them
+		metricsCtx, cancel := context.WithTimeout(context.Background(), awsclients.DefaultTimeout)
+		defer cancel()
+		metrics, err = loadgen.FetchMetrics(metricsCtx, kinesisClient, cloudwatch.NewFromConfig(awsConfig),
+			cfg.StreamName, result.Started, result.Started.Add(result.Elapsed))
+		if err != nil {
+			return false, err
+		}
+	}
+
+	report := loadgen.NewReport(result, metrics, thresholds)
+	if err := report.WriteText(os.Stdout); err != nil {
+		return false, err
+	}
+
+	if jsonPath != "" {
+		file, err := os.Create(jsonPath)
+		if err != nil {
+			return false, err
+		}
+		defer file.Close()
+		if err := report.WriteJSON(file); err != nil {
+			return false, fmt.Errorf("failed to write %s: %w", jsonPath, err)
+		}
+	}
+	return report.Passed(), nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// targets are the parts of the repository a change touches
+type targets struct {
+	// Modules are Terraform module directories, e.g. modules/storage
+	Modules []string `json:"modules"`
+
+	// Regions are Terragrunt region directories, e.g. environments/dev/ap-southeast-1
+	Regions []string `json:"regions"`
+
+	// Contracts is set when modules or the shared test helpers changed
+	Contracts bool `json:"contracts"`
+}
+
+// empty reports whether the change touches nothing precheck checks
+func (t targets) empty() bool {
+	return len(t.Modules) == 0 && len(t.Regions) == 0 && !t.Contracts
+}
+
+// changedFiles lists the files changed between base and the working tree,
+// relative to root, including uncommitted and untracked files
+func changedFiles(ctx context.Context, root, base string) ([]string, error) {
+	committed, err := git(ctx, root, "diff", "--name-only", "--relative", base+"...HEAD")
+	if err != nil {
+		return nil, err
+	}
+	uncommitted, err := git(ctx, root, "diff", "--name-only", "--relative", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(ctx, root, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	return append(append(committed, uncommitted...), untracked...), nil
+}
+
+// gitPrefix returns the path of root within its git repository, "" at the top
+// level, so annotations name files the way GitHub does
+func gitPrefix(ctx context.Context, root string) (string, error) {
+	lines, err := git(ctx, root, "rev-parse", "--show-prefix")
+	if err != nil || len(lines) == 0 {
+		return "", err
+	}
+	return lines[0], nil
+}
+
+// git runs a git command in dir and returns its non-empty output lines
+func git(ctx context.Context, dir string, args ...string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// changedTargets maps changed files to the modules and Terragrunt regions they
+// belong to; a change to root.hcl or config/ affects every region
+func changedTargets(root string, files []string) (targets, error) {
+	modules := map[string]bool{}
+	regions := map[string]bool{}
+	var t targets
+
+	for _, file := range files {
+		parts := strings.Split(filepath.ToSlash(file), "/")
+		switch {
+		case parts[0] == "modules" && len(parts) > 2:
+			modules["modules/"+parts[1]] = true
+			t.Contracts = true
+		case parts[0] == "environments" && len(parts) > 3:
+			regions[strings.Join(parts[:3], "/")] = true
+		case file == "root.hcl" || parts[0] == "config":
+			all, err := allTargets(root)
+			if err != nil {
+				return targets{}, err
+			}
+			for _, region := range all.Regions {
+				regions[region] = true
+			}
+		case parts[0] == "tests" && len(parts) > 1 && (parts[1] == "testutil" || parts[1] == "contract"):
+			t.Contracts = true
+		}
+	}
+
+	for dir := range modules {
+		if exists(filepath.Join(root, dir)) {
+			t.Modules = append(t.Modules, dir)
+		}
+	}
+	for dir := range regions {
+		if exists(filepath.Join(root, dir)) {
+			t.Regions = append(t.Regions, dir)
+		}
+	}
+	sort.Strings(t.Modules)
+	sort.Strings(t.Regions)
+	return t, nil
+}
+
+// allTargets returns every module and Terragrunt region in the repository
+func allTargets(root string) (targets, error) {
+	t := targets{Contracts: true}
+
+	modules, err := filepath.Glob(filepath.Join(root, "modules", "*", "main.tf"))
+	if err != nil {
+		return targets{}, err
+	}
+	for _, file := range modules {
+		dir, _ := filepath.Rel(root, filepath.Dir(file))
+		t.Modules = append(t.Modules, filepath.ToSlash(dir))
+	}
+
+	units, err := filepath.Glob(filepath.Join(root, "environments", "*", "*", "*", "terragrunt.hcl"))
+	if err != nil {
+		return targets{}, err
+	}
+	seen := map[string]bool{}
+	for _, file := range units {
+		dir, _ := filepath.Rel(root, filepath.Dir(filepath.Dir(file)))
+		if dir = filepath.ToSlash(dir); !seen[dir] {
+			seen[dir] = true
+			t.Regions = append(t.Regions, dir)
+		}
+	}
+
+	sort.Strings(t.Modules)
+	sort.Strings(t.Regions)
+	return t, nil
+}
+
+// exists reports whether path exists, so deleted modules are not checked
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// check runs one kind of check against every target it applies to
+type check func(ctx context.Context, root string, t targets) []result
+
+// checkNames lists the checks in the order they run
+var checkNames = []string{"fmt", "validate", "lint", "plan", "contracts", "policy"}
+
+var checksByName = map[string]check{
+	"fmt":       checkFormat,
+	"validate":  checkValidate,
+	"lint":      checkLint,
+	"plan":      checkPlans,
+	"contracts": checkContracts,
+	"policy":    checkPolicy,
+}
+
+// checkFormat runs terraform fmt on modules and terragrunt hclfmt on regions
+func checkFormat(ctx context.Context, root string, t targets) []result {
+	var results []result
+	for _, dir := range t.Modules {
+		res := execute(ctx, "fmt", dir, root, "terraform", "fmt", "-check", "-recursive", "-list=true", dir)
+		res.Findings = append(res.Findings, fmtFindings(res.Output, "needs terraform fmt")...)
+		results = append(results, res)
+	}
+	for _, dir := range t.Regions {
+		res := execute(ctx, "fmt", dir, root, "terragrunt", "hclfmt", "--terragrunt-check", "--terragrunt-working-dir", dir)
+		res.Findings = append(res.Findings, hclfmtFindings(res.Output)...)
+		results = append(results, res)
+	}
+	return results
+}
+
+// checkValidate runs terraform validate on modules and terragrunt run-all validate on regions
+func checkValidate(ctx context.Context, root string, t targets) []result {
+	var results []result
+	for _, dir := range t.Modules {
+		moduleDir := filepath.Join(root, dir)
+		if res := execute(ctx, "validate", dir, moduleDir, "terraform", "init", "-backend=false", "-input=false"); !res.Passed {
+			results = append(results, res)
+			continue
+		}
+
+		res := execute(ctx, "validate", dir, moduleDir, "terraform", "validate", "-json", "-no-color")
+		findings, err := validateFindings(res.Output, dir)
+		if err != nil {
+			res.fail(finding{Message: err.Error()})
+		}
+		res.Findings = append(res.Findings, findings...)
+		res.Output = ""
+		results = append(results, res)
+	}
+	for _, dir := range t.Regions {
+		results = append(results, execute(ctx, "validate", dir, root,
+			"terragrunt", "run-all", "validate", "--terragrunt-non-interactive", "--terragrunt-working-dir", dir))
+	}
+	return results
+}
+
+// checkLint runs tflint on modules
+func checkLint(ctx context.Context, root string, t targets) []result {
+	var results []result
+	for _, dir := range t.Modules {
+		res := execute(ctx, "lint", dir, root, "tflint", "--chdir="+dir, "--format=compact", "--no-color")
+		res.Findings = append(res.Findings, lintFindings(res.Output, dir)...)
+		results = append(results, res)
+	}
+	return results
+}
+
+// checkPlans runs the plan-only tests, those named Test*Plan, of every module with a test suite
+func checkPlans(ctx context.Context, root string, t targets) []result {
+	var results []result
+	for _, dir := range t.Modules {
+		testDir := filepath.Join(dir, "tests")
+		if !exists(filepath.Join(root, testDir, "go.mod")) {
+			continue
+		}
+		res := execute(ctx, "plan", dir, filepath.Join(root, testDir), "go", "test", "-run", "Plan$", "-count=1", "./...")
+		res.Findings = append(res.Findings, testFindings(res.Output, testDir)...)
+		results = append(results, res)
+	}
+	return results
+}
+
+// checkContracts runs the module contract tests in tests/contract
+func checkContracts(ctx context.Context, root string, t targets) []result {
+	if !t.Contracts {
+		return nil
+	}
+	res := execute(ctx, "contracts", "tests/contract", filepath.Join(root, "tests"), "go", "test", "-count=1", "./contract/")
+	res.Findings = append(res.Findings, testFindings(res.Output, "tests")...)
+	return []result{res}
+}
+
+// checkPolicy applies the policy rules to the Terraform and Terragrunt files of every target
+func checkPolicy(ctx context.Context, root string, t targets) []result {
+	var results []result
+	for _, dir := range append(append([]string{}, t.Modules...), t.Regions...) {
+		start := time.Now()
+		res := result{Check: "policy", Target: dir, Passed: true}
+
+		findings, err := scanDir(root, dir, policyRules)
+		if err != nil {
+			res.fail(finding{Message: err.Error()})
+		}
+		for _, f := range findings {
+			res.fail(f)
+		}
+		res.Duration = time.Since(start).Round(time.Millisecond).String()
+		results = append(results, res)
+	}
+	return results
+}
+
+// execute runs a command in dir and records whether it exited zero; a
+// failure without findings is reported with the command's output
+func execute(ctx context.Context, checkName, target, dir, name string, args ...string) result {
+	start := time.Now()
+	res := result{Check: checkName, Target: target, Passed: true}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "TF_IN_AUTOMATION=1")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err := cmd.Run()
+	res.Output = out.String()
+	res.Duration = time.Since(start).Round(time.Millisecond).String()
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		res.Passed = false
+	case err != nil:
+		res.fail(finding{Message: fmt.Sprintf("%s %s: %v", name, strings.Join(args, " "), err)})
+	}
+	return res
+}
+
+// fmtFindings turns the file list printed by terraform fmt -list into findings
+func fmtFindings(output, message string) []finding {
+	var findings []finding
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasSuffix(line, ".tf") || strings.HasSuffix(line, ".tfvars") {
+			findings = append(findings, finding{File: filepath.ToSlash(line), Message: message})
+		}
+	}
+	return findings
+}
+
+// hclfmtPattern matches the files terragrunt hclfmt --terragrunt-check reports
+var hclfmtPattern = regexp.MustCompile(`Invalid file format (\S+\.hcl)`)
+
+// hclfmtFindings turns the files terragrunt hclfmt reports into findings
+func hclfmtFindings(output string) []finding {
+	var findings []finding
+	for _, match := range hclfmtPattern.FindAllStringSubmatch(output, -1) {
+		findings = append(findings, finding{File: filepath.ToSlash(match[1]), Message: "needs terragrunt hclfmt"})
+	}
+	return findings
+}
+
+// lintPattern matches an issue in tflint's compact format: file:line:column: Severity - message (rule)
+var lintPattern = regexp.MustCompile(`^(\S+):(\d+):\d+: (\w+) - (.*?)(?: \((\S+)\))?$`)
+
+// lintFindings turns the issues tflint reports, run in dir, into findings
+func lintFindings(output, dir string) []finding {
+	var findings []finding
+	for _, line := range strings.Split(output, "\n") {
+		match := lintPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		lineNumber, _ := strconv.Atoi(match[2])
+		findings = append(findings, finding{
+			File:    filepath.ToSlash(filepath.Join(dir, match[1])),
+			Line:    lineNumber,
+			Rule:    match[5],
+			Message: match[3] + ": " + match[4],
+		})
+	}
+	return findings
+}
+
+// validateOutput is the subset of terraform validate -json output precheck reports
+type validateOutput struct {
+	Diagnostics []struct {
+		Severity string `json:"severity"`
+		Summary  string `json:"summary"`
+		Detail   string `json:"detail"`
+		Range    *struct {
+			Filename string `json:"filename"`
+			Start    struct {
+				Line int `json:"line"`
+			} `json:"start"`
+		} `json:"range"`
+	} `json:"diagnostics"`
+}
+
+// validateFindings turns the error diagnostics of terraform validate -json,
+// run in dir, into findings with paths relative to the repository root
+func validateFindings(output, dir string) ([]finding, error) {
+	var v validateOutput
+	if err := json.Unmarshal([]byte(output), &v); err != nil {
+		return nil, fmt.Errorf("failed to parse terraform validate output: %w", err)
+	}
+
+	var findings []finding
+	for _, d := range v.Diagnostics {
+		if d.Severity != "error" {
+			continue
+		}
+		f := finding{Message: d.Summary}
+		if d.Detail != "" {
+			f.Message += ": " + d.Detail
+		}
+		if d.Range != nil {
+			f.File = filepath.ToSlash(filepath.Join(dir, d.Range.Filename))
+			f.Line = d.Range.Start.Line
+		}
+		findings = append(findings, f)
+	}
+	return findings, nil
+}
+
+// testFailurePattern matches the file:line prefix go test puts on failed assertions
+var testFailurePattern = regexp.MustCompile(`^\s+(\S+_test\.go):(\d+): (.*)$`)
+
+// testFindings turns the failure locations in go test output, run in dir, into findings
+func testFindings(output, dir string) []finding {
+	var findings []finding
+	for _, line := range strings.Split(output, "\n") {
+		match := testFailurePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		lineNumber, _ := strconv.Atoi(match[2])
+		findings = append(findings, finding{
+			File:    filepath.ToSlash(filepath.Join(dir, match[1])),
+			Line:    lineNumber,
+			Message: strings.TrimSpace(match[3]),
+		})
+	}
+	return findings
+}
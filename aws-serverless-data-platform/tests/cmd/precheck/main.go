@@ -0,0 +1,146 @@
+// =============================================================================
+// Pre-merge Check CLI
+// Runs the checks CI would run against the modules a branch changes
+// =============================================================================
+
+// Command precheck runs the checks a pull request must pass on the Terraform
+// modules and Terragrunt units a branch changes, as found by git diff against
+// -base: terraform fmt, terraform validate, terragrunt run-all validate (the
+// successor of validate-all), tflint, each module's plan-only tests, the module
+// contracts and the policy rules in policy.go.
+// Every check runs even after one fails; the report lists each finding with
+// its file and line, as GitHub annotations when -annotate is set, and the
+// command exits non-zero when any check failed.
+//
+// The plan-only tests run terraform plan and need AWS credentials; pass
+// -checks fmt,validate,lint,policy to stay offline.
+//
+// Usage:
+//
+//	go run ./cmd/precheck -base origin/main
+//	go run ./cmd/precheck -all -checks fmt,policy -output precheck.json
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/testconfig"
+)
+
+func main() {
+	base := flag.String("base", "origin/main", "git revision the branch is compared with to find changed files")
+	all := flag.Bool("all", false, "check every module and unit instead of the changed ones")
+	checks := flag.String("checks", strings.Join(checkNames, ","), "comma-separated checks to run")
+	annotate := flag.Bool("annotate", os.Getenv("GITHUB_ACTIONS") == "true", "print findings as GitHub workflow annotations")
+	output := flag.String("output", "", "file to write the JSON report to, - for stdout")
+	timeout := flag.Duration("timeout", 30*time.Minute, "overall deadline for the checks")
+	flag.Parse()
+
+	selected, err := selectChecks(*checks)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	r, err := run(*base, *all, selected, *timeout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	summarize(os.Stderr, r)
+	if *annotate {
+		writeAnnotations(os.Stdout, r)
+	}
+	if *output != "" {
+		if err := write(*output, r); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if !r.Passed {
+		os.Exit(1)
+	}
+}
+
+// run finds the changed targets and runs the selected checks against them
+func run(base string, all bool, selected []string, timeout time.Duration) (*report, error) {
+	root, err := testconfig.FindRoot()
+	if err != nil {
+		return nil, err
+	}
+	if root == "" {
+		return nil, fmt.Errorf("run precheck from inside the repository; %s was not found", testconfig.BaseFile)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var t targets
+	if all {
+		t, err = allTargets(root)
+	} else {
+		var files []string
+		if files, err = changedFiles(ctx, root, base); err == nil {
+			t, err = changedTargets(root, files)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, err := gitPrefix(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &report{Base: base, Prefix: prefix, Targets: t, Passed: true}
+	if all {
+		r.Base = ""
+	}
+	for _, name := range selected {
+		for _, res := range checksByName[name](ctx, root, t) {
+			r.add(res)
+		}
+	}
+	return r, nil
+}
+
+// selectChecks splits -checks, rejecting unknown names
+func selectChecks(list string) ([]string, error) {
+	var selected []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := checksByName[name]; !ok {
+			return nil, fmt.Errorf("unknown check %q; choose from %s", name, strings.Join(checkNames, ", "))
+		}
+		selected = append(selected, name)
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("-checks selects no checks")
+	}
+	return selected, nil
+}
+
+// write writes the report to path, or stdout for "-"
+func write(path string, r *report) error {
+	var out io.Writer = os.Stdout
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	return writeJSON(out, r)
+}
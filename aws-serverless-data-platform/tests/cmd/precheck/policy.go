@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreMarker on a line, followed by a rule ID, suppresses that rule there,
+// e.g. `Resource = "*" # precheck:ignore wildcard-action`
+const ignoreMarker = "precheck:ignore"
+
+// rule is a policy-as-code check applied to every line of .tf and .hcl files
+type rule struct {
+	ID      string
+	Pattern *regexp.Regexp
+	Message string
+
+	// Unless exempts matches whose enclosing block, e.g. the policy statement, matches it
+	Unless *regexp.Regexp
+}
+
+// deniedOrConditional matches policy statements that deny, or only allow under a condition
+var deniedOrConditional = regexp.MustCompile(`Effect\s*=\s*"Deny"|\bCondition\s*=|\bcondition\s*\{`)
+
+// policyRules are the repository's policy-as-code rules
+var policyRules = []rule{
+	{
+		ID:      "public-acl",
+		Pattern: regexp.MustCompile(`\bacl\s*=\s*"(public-read|public-read-write|authenticated-read)"`),
+		Message: "S3 ACLs must not grant public or all-AWS-users access",
+	},
+	{
+		ID:      "hardcoded-secret",
+		Pattern: regexp.MustCompile(`(?i)\b(password|secret|secret_string|access_key|secret_key)\s*=\s*"[^"$]{4,}"`),
+		Message: "secrets must come from variables or Secrets Manager, not literals",
+	},
+	{
+		ID:      "wildcard-action",
+		Pattern: regexp.MustCompile(`\b(Action|actions)\s*=\s*(\[\s*)?"\*"`),
+		Message: `IAM statements must not allow every action ("*") without a condition`,
+		Unless:  deniedOrConditional,
+	},
+	{
+		ID:      "wildcard-principal",
+		Pattern: regexp.MustCompile(`\b(Principal|AWS|identifiers)\s*=\s*(\[\s*)?"\*"`),
+		Message: `resource policies must not trust every principal ("*") without a condition`,
+		Unless:  deniedOrConditional,
+	},
+	{
+		ID:      "open-ingress",
+		Pattern: regexp.MustCompile(`\bcidr_blocks\s*=\s*\[\s*"0\.0\.0\.0/0"`),
+		Message: "review rules open to 0.0.0.0/0; only egress may be unrestricted",
+	},
+}
+
+// scanDir applies rules to the .tf and .hcl files under dir, skipping
+// Terraform and Terragrunt caches
+func scanDir(root, dir string, rules []rule) ([]finding, error) {
+	var findings []finding
+	err := filepath.WalkDir(filepath.Join(root, dir), func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			switch entry.Name() {
+			case ".terraform", ".terragrunt-cache", "tests":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".tf" && ext != ".hcl" {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, path)
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		found, err := scan(bufio.NewScanner(file), filepath.ToSlash(rel), rules)
+		findings = append(findings, found...)
+		return err
+	})
+	return findings, err
+}
+
+// scan applies rules to every line of one file
+func scan(scanner *bufio.Scanner, name string, rules []rule) ([]finding, error) {
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var findings []finding
+	egress := false
+	for i, text := range lines {
+		trimmed := strings.TrimSpace(text)
+		if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+
+		// Track egress blocks, where 0.0.0.0/0 is expected
+		if strings.HasPrefix(trimmed, "egress") && strings.HasSuffix(trimmed, "{") {
+			egress = true
+		} else if strings.HasPrefix(trimmed, "ingress") && strings.HasSuffix(trimmed, "{") {
+			egress = false
+		}
+
+		for _, r := range rules {
+			if !r.Pattern.MatchString(text) || ignored(text, r.ID) {
+				continue
+			}
+			if r.ID == "open-ingress" && egress {
+				continue
+			}
+			if r.Unless != nil && r.Unless.MatchString(enclosingStatement(lines, i)) {
+				continue
+			}
+			findings = append(findings, finding{File: name, Line: i + 1, Rule: r.ID, Message: r.Message})
+		}
+	}
+	return findings, nil
+}
+
+// enclosingStatement returns the text of the innermost brace-delimited block
+// around lines[i] that sets an Effect, such as a policy statement around a
+// Principal map, or the innermost block when none does
+func enclosingStatement(lines []string, i int) string {
+	innermost := ""
+	for end := i; ; {
+		start := blockStart(lines, end)
+		if start < 0 {
+			if innermost == "" {
+				return lines[i]
+			}
+			return innermost
+		}
+
+		block := strings.Join(lines[start:blockEnd(lines, start)+1], "\n")
+		if innermost == "" {
+			innermost = block
+		}
+		if strings.Contains(block, "Effect") {
+			return block
+		}
+		end = start
+	}
+}
+
+// blockStart returns the line opening the block that encloses lines[i], or -1
+func blockStart(lines []string, i int) int {
+	depth := 0
+	for j := i - 1; j >= 0; j-- {
+		depth += strings.Count(lines[j], "}") - strings.Count(lines[j], "{")
+		if depth < 0 {
+			return j
+		}
+	}
+	return -1
+}
+
+// blockEnd returns the line closing the block lines[start] opens
+func blockEnd(lines []string, start int) int {
+	depth := 0
+	for j := start; j < len(lines); j++ {
+		depth += strings.Count(lines[j], "{") - strings.Count(lines[j], "}")
+		if depth <= 0 {
+			return j
+		}
+	}
+	return len(lines) - 1
+}
+
+// ignored reports whether the line suppresses rule with the ignore marker
+func ignored(line, ruleID string) bool {
+	i := strings.Index(line, ignoreMarker)
+	if i < 0 {
+		return false
+	}
+	for _, id := range strings.FieldsFunc(line[i+len(ignoreMarker):], func(r rune) bool { return r == ' ' || r == ',' }) {
+		if id == ruleID {
+			return true
+		}
+	}
+	return false
+}
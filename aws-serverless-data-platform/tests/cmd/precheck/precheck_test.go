@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedTargets(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"modules/storage", "modules/security", "environments/dev/us-east-1/03-storage", "environments/prod/us-east-1/01-networking"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0o755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, "environments/prod/us-east-1/01-networking/terragrunt.hcl"), nil, 0o644))
+
+	targets, err := changedTargets(root, []string{
+		"modules/storage/main.tf",
+		"modules/storage/tests/storage_test.go",
+		"modules/removed/main.tf",
+		"environments/dev/us-east-1/03-storage/terragrunt.hcl",
+		"README.md",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"modules/storage"}, targets.Modules, "Deleted modules are not checked")
+	assert.Equal(t, []string{"environments/dev/us-east-1"}, targets.Regions)
+	assert.True(t, targets.Contracts)
+
+	targets, err = changedTargets(root, []string{"root.hcl"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"environments/prod/us-east-1"}, targets.Regions, "root.hcl affects every region with units")
+	assert.False(t, targets.Contracts)
+
+	targets, err = changedTargets(root, []string{"tests/testutil/vars.go"})
+	require.NoError(t, err)
+	assert.True(t, targets.Contracts)
+	assert.Empty(t, targets.Modules)
+
+	targets, err = changedTargets(root, []string{"docs/guide.md"})
+	require.NoError(t, err)
+	assert.True(t, targets.empty())
+}
+
+func TestSelectChecks(t *testing.T) {
+	selected, err := selectChecks("fmt, policy")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fmt", "policy"}, selected)
+
+	_, err = selectChecks("fmt,tfsec")
+	assert.ErrorContains(t, err, `unknown check "tfsec"`)
+
+	_, err = selectChecks(",")
+	assert.Error(t, err)
+}
+
+func TestFmtFindings(t *testing.T) {
+	findings := fmtFindings("modules/storage/main.tf\nmodules/storage/variables.tf\n", "needs terraform fmt")
+	assert.Equal(t, []finding{
+		{File: "modules/storage/main.tf", Message: "needs terraform fmt"},
+		{File: "modules/storage/variables.tf", Message: "needs terraform fmt"},
+	}, findings)
+
+	assert.Equal(t, []finding{{File: "environments/dev/us-east-1/01-networking/terragrunt.hcl", Message: "needs terragrunt hclfmt"}},
+		hclfmtFindings("ERRO[0000] Invalid file format environments/dev/us-east-1/01-networking/terragrunt.hcl\n"))
+}
+
+func TestLintFindings(t *testing.T) {
+	output := "2 issue(s) found:\n\n" +
+		"main.tf:14:1: Warning - variable \"unused\" is declared but not used (terraform_unused_declarations)\n" +
+		"versions.tf:3:3: Error - Missing version constraint for provider \"aws\"\n"
+
+	assert.Equal(t, []finding{
+		{File: "modules/storage/main.tf", Line: 14, Rule: "terraform_unused_declarations", Message: `Warning: variable "unused" is declared but not used`},
+		{File: "modules/storage/versions.tf", Line: 3, Message: `Error: Missing version constraint for provider "aws"`},
+	}, lintFindings(output, "modules/storage"))
+}
+
+func TestValidateFindings(t *testing.T) {
+	output := `{
+  "valid": false,
+  "diagnostics": [
+    {"severity": "warning", "summary": "Deprecated attribute"},
+    {"severity": "error", "summary": "Unsupported argument", "detail": "An argument named \"acl\" is not expected here.",
+     "range": {"filename": "main.tf", "start": {"line": 42, "column": 3}}},
+    {"severity": "error", "summary": "Missing required provider"}
+  ]
+}`
+
+	findings, err := validateFindings(output, "modules/storage")
+	require.NoError(t, err)
+	assert.Equal(t, []finding{
+		{File: "modules/storage/main.tf", Line: 42, Message: `Unsupported argument: An argument named "acl" is not expected here.`},
+		{Message: "Missing required provider"},
+	}, findings)
+
+	_, err = validateFindings("Error: Module not installed", "modules/storage")
+	assert.Error(t, err)
+}
+
+func TestTestFindings(t *testing.T) {
+	output := `--- FAIL: TestStoragePlan (12.01s)
+    storage_test.go:163: 
+        	Error Trace:	/src/modules/storage/tests/storage_test.go:163
+        	Error:      	Not equal
+    plan.go:20: not a finding
+FAIL`
+
+	assert.Equal(t, []finding{{File: "modules/storage/tests/storage_test.go", Line: 163, Message: ""}},
+		testFindings(output, "modules/storage/tests"))
+}
+
+func TestScanRules(t *testing.T) {
+	source := `resource "aws_s3_bucket_acl" "bad" {
+  acl = "public-read"
+}
+
+locals {
+  password = "hunter22"
+  token    = "${var.token}"
+}
+
+data "aws_iam_policy_document" "deny" {
+  statement {
+    effect = "Deny"
+  }
+}
+
+resource "aws_iam_policy" "admin" {
+  policy = jsonencode({
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = "*"
+        Resource = "*"
+      },
+      {
+        Effect    = "Deny"
+        Principal = "*"
+        Action    = "s3:*"
+      },
+      {
+        Effect = "Allow"
+        Principal = {
+          AWS = "*"
+        }
+        Condition = {
+          IpAddress = { "aws:SourceIp" = var.cidrs }
+        }
+      },
+      {
+        Effect    = "Allow"
+        Principal = "*" # precheck:ignore wildcard-principal
+      }
+    ]
+  })
+}
+
+resource "aws_security_group" "sg" {
+  ingress {
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+  egress {
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}
+`
+
+	findings, err := scan(bufio.NewScanner(strings.NewReader(source)), "main.tf", policyRules)
+	require.NoError(t, err)
+
+	var found []string
+	for _, f := range findings {
+		found = append(found, f.Rule+"@"+strconv.Itoa(f.Line))
+	}
+	assert.Equal(t, []string{"public-acl@2", "hardcoded-secret@6", "wildcard-action@21", "open-ingress@48"}, found)
+}
+
+func TestIgnored(t *testing.T) {
+	assert.True(t, ignored(`acl = "public-read" # precheck:ignore public-acl`, "public-acl"))
+	assert.True(t, ignored(`x = "*" # precheck:ignore wildcard-action,wildcard-principal`, "wildcard-principal"))
+	assert.False(t, ignored(`acl = "public-read" # precheck:ignore hardcoded-secret`, "public-acl"))
+	assert.False(t, ignored(`acl = "public-read"`, "public-acl"))
+}
+
+func TestWriteAnnotations(t *testing.T) {
+	r := &report{Prefix: "platform/", Passed: true}
+	r.add(result{Check: "policy", Target: "modules/storage", Passed: true})
+	r.add(result{Check: "policy", Target: "modules/security", Findings: []finding{
+		{File: "modules/security/main.tf", Line: 12, Rule: "wildcard-action", Message: "100% wildcard"},
+	}})
+	r.add(result{Check: "validate", Target: "environments/dev/us-east-1", Output: "Error: boom"})
+
+	var out bytes.Buffer
+	writeAnnotations(&out, r)
+	assert.Equal(t,
+		"::error file=platform/modules/security/main.tf,line=12,title=precheck policy::[wildcard-action] 100%25 wildcard\n"+
+			"::error title=precheck validate::validate failed on environments/dev/us-east-1\n",
+		out.String())
+	assert.False(t, r.Passed)
+	assert.Equal(t, "Error: boom", r.Results[2].Output, "Failures without findings keep their output")
+}
+
+func TestTail(t *testing.T) {
+	assert.Equal(t, "c\nd", tail("a\nb\nc\nd\n", 2))
+	assert.Equal(t, "a", tail("a", 5))
+}
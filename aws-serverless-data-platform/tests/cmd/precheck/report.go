@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// outputTail is how many trailing lines of a failed command's output are kept
+// when the failure could not be attributed to files
+const outputTail = 20
+
+// report is the outcome of every check
+type report struct {
+	// Base is the revision compared with, empty for -all
+	Base string `json:"base,omitempty"`
+
+	// Prefix is the repository root's path within its git repository
+	Prefix string `json:"-"`
+
+	Targets targets  `json:"targets"`
+	Results []result `json:"results"`
+	Passed  bool     `json:"passed"`
+}
+
+// result is the outcome of one check against one target
+type result struct {
+	Check    string    `json:"check"`
+	Target   string    `json:"target"`
+	Passed   bool      `json:"passed"`
+	Duration string    `json:"duration"`
+	Findings []finding `json:"findings,omitempty"`
+	Output   string    `json:"output,omitempty"`
+}
+
+// finding is one problem, located in a file when the check could tell where
+type finding struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+// fail marks the result failed with f
+func (r *result) fail(f finding) {
+	r.Passed = false
+	r.Findings = append(r.Findings, f)
+}
+
+// add records res, keeping only the tail of the output of failures that have no findings
+func (r *report) add(res result) {
+	if res.Passed || len(res.Findings) > 0 {
+		res.Output = ""
+	} else {
+		res.Output = tail(res.Output, outputTail)
+	}
+	if !res.Passed {
+		r.Passed = false
+	}
+	r.Results = append(r.Results, res)
+}
+
+// tail returns the last n lines of s
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// summarize writes one line per result followed by its findings
+func summarize(out io.Writer, r *report) {
+	if r.Targets.empty() {
+		fmt.Fprintf(out, "No modules or environments changed since %s\n", r.Base)
+		return
+	}
+
+	for _, res := range r.Results {
+		status := "✅"
+		if !res.Passed {
+			status = "❌"
+		}
+		fmt.Fprintf(out, "%s %-9s %-40s %s\n", status, res.Check, res.Target, res.Duration)
+		for _, f := range res.Findings {
+			fmt.Fprintf(out, "     %s\n", f)
+		}
+		if res.Output != "" {
+			fmt.Fprintf(out, "     %s\n", strings.ReplaceAll(res.Output, "\n", "\n     "))
+		}
+	}
+
+	if r.Passed {
+		fmt.Fprintln(out, "All checks passed")
+	} else {
+		fmt.Fprintln(out, "Some checks failed")
+	}
+}
+
+// String formats the finding as file:line: [rule] message
+func (f finding) String() string {
+	var b strings.Builder
+	if f.File != "" {
+		b.WriteString(f.File)
+		if f.Line > 0 {
+			fmt.Fprintf(&b, ":%d", f.Line)
+		}
+		b.WriteString(": ")
+	}
+	if f.Rule != "" {
+		fmt.Fprintf(&b, "[%s] ", f.Rule)
+	}
+	b.WriteString(f.Message)
+	return b.String()
+}
+
+// writeAnnotations prints every finding of a failed check as a GitHub workflow
+// error annotation, and failures without findings as one annotation each
+func writeAnnotations(out io.Writer, r *report) {
+	for _, res := range r.Results {
+		if res.Passed {
+			continue
+		}
+		title := fmt.Sprintf("precheck %s", res.Check)
+		if len(res.Findings) == 0 {
+			fmt.Fprintf(out, "::error title=%s::%s failed on %s\n", title, res.Check, escapeAnnotation(res.Target))
+			continue
+		}
+		for _, f := range res.Findings {
+			message := f.Message
+			if f.Rule != "" {
+				message = "[" + f.Rule + "] " + message
+			}
+			if f.File == "" {
+				fmt.Fprintf(out, "::error title=%s::%s: %s\n", title, escapeAnnotation(res.Target), escapeAnnotation(message))
+				continue
+			}
+			location := "file=" + r.Prefix + f.File
+			if f.Line > 0 {
+				location += fmt.Sprintf(",line=%d", f.Line)
+			}
+			fmt.Fprintf(out, "::error %s,title=%s::%s\n", location, title, escapeAnnotation(message))
+		}
+	}
+}
+
+// escapeAnnotation escapes the characters workflow commands reserve in messages
+func escapeAnnotation(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// writeJSON writes the report as indented JSON
+func writeJSON(w io.Writer, r *report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
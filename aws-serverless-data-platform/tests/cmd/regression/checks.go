@@ -0,0 +1,383 @@
+// =============================================================================
+// Regression Checks
+// Read-only checks run against a deployed environment
+// =============================================================================
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsarn "github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	taggingtypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/athena"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/lifecycle"
+	"gopkg.in/yaml.v3"
+)
+
+// storageUnit deploys the data lake buckets and Glue databases
+const storageUnit = "03-storage"
+
+// layers are the data lake layers, each with a bucket and a Glue database
+var layers = []string{"raw", "processed", "curated"}
+
+// check is one read-only verification; run returns the resources it examined
+// and what is wrong with them
+type check struct {
+	id    string
+	title string
+	run   func(ctx context.Context, env *environment) (resources, problems []string, err error)
+}
+
+// checks are run in order and appear in the report in the same order
+var checks = []check{
+	{"outputs", "Every unit is deployed and has outputs", checkOutputs},
+	{"buckets", "Data lake buckets are versioned, encrypted and block public access", checkBuckets},
+	{"lifecycle", "Data lake lifecycle rules match the configured policy", checkLifecycle},
+	{"alarms", "No CloudWatch alarm of the environment is in ALARM", checkAlarms},
+	{"athena", "Athena can query every data lake database", checkAthena},
+	{"drift", "Terraform plans no changes for any unit", checkDrift},
+}
+
+// checkOutputs reports units whose outputs cannot be read or are empty
+func checkOutputs(ctx context.Context, env *environment) ([]string, []string, error) {
+	var problems []string
+	for _, unit := range env.units {
+		if err, ok := env.outputErrors[unit]; ok {
+			problems = append(problems, fmt.Sprintf("%s: %v", unit, err))
+		} else if len(env.outputs[unit]) == 0 {
+			problems = append(problems, unit+" has no outputs")
+		}
+	}
+	return env.units, problems, nil
+}
+
+// bucketState is what checkBuckets reads about a bucket
+type bucketState struct {
+	name       string
+	versioning s3types.BucketVersioningStatus
+
+	// encryption is the default encryption algorithm, empty when there is none
+	encryption string
+
+	publicAccessBlock *s3types.PublicAccessBlockConfiguration
+}
+
+// checkBuckets reads the versioning, default encryption and public access
+// block of each data lake bucket
+func checkBuckets(ctx context.Context, env *environment) ([]string, []string, error) {
+	client := env.clients.S3()
+	var buckets, problems []string
+	for _, layer := range layers {
+		bucket, err := env.stringOutput(storageUnit, layer+"_bucket_id")
+		if err != nil {
+			return buckets, problems, err
+		}
+		buckets = append(buckets, bucket)
+		state := bucketState{name: bucket}
+
+		versioning, err := client.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{Bucket: aws.String(bucket)})
+		if err != nil {
+			return buckets, problems, err
+		}
+		state.versioning = versioning.Status
+
+		encryption, err := client.GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{Bucket: aws.String(bucket)})
+		if err != nil && !isAPIError(err, "ServerSideEncryptionConfigurationNotFoundError") {
+			return buckets, problems, err
+		}
+		if err == nil && encryption.ServerSideEncryptionConfiguration != nil {
+			for _, rule := range encryption.ServerSideEncryptionConfiguration.Rules {
+				if rule.ApplyServerSideEncryptionByDefault != nil {
+					state.encryption = string(rule.ApplyServerSideEncryptionByDefault.SSEAlgorithm)
+				}
+			}
+		}
+
+		block, err := client.GetPublicAccessBlock(ctx, &s3.GetPublicAccessBlockInput{Bucket: aws.String(bucket)})
+		if err != nil && !isAPIError(err, "NoSuchPublicAccessBlockConfiguration") {
+			return buckets, problems, err
+		}
+		if err == nil {
+			state.publicAccessBlock = block.PublicAccessBlockConfiguration
+		}
+
+		problems = append(problems, bucketProblems(state)...)
+	}
+	return buckets, problems, nil
+}
+
+// bucketProblems lists what a data lake bucket is missing
+func bucketProblems(state bucketState) []string {
+	var problems []string
+	if state.versioning != s3types.BucketVersioningStatusEnabled {
+		problems = append(problems, state.name+" is not versioned")
+	}
+	if state.encryption == "" {
+		problems = append(problems, state.name+" has no default encryption")
+	}
+
+	block := state.publicAccessBlock
+	if block == nil {
+		return append(problems, state.name+" has no public access block")
+	}
+	for _, setting := range []struct {
+		name    string
+		enabled *bool
+	}{
+		{"BlockPublicAcls", block.BlockPublicAcls},
+		{"IgnorePublicAcls", block.IgnorePublicAcls},
+		{"BlockPublicPolicy", block.BlockPublicPolicy},
+		{"RestrictPublicBuckets", block.RestrictPublicBuckets},
+	} {
+		if !aws.ToBool(setting.enabled) {
+			problems = append(problems, fmt.Sprintf("%s has %s disabled", state.name, setting.name))
+		}
+	}
+	return problems
+}
+
+// checkLifecycle compares each data lake bucket's lifecycle rules with the
+// ones the storage module derives from the environment's configured policy
+func checkLifecycle(ctx context.Context, env *environment) ([]string, []string, error) {
+	policy, err := lifecycle.LoadPolicy(env.root, env.environment)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var buckets, problems []string
+	for _, layer := range layers {
+		bucket, err := env.stringOutput(storageUnit, layer+"_bucket_id")
+		if err != nil {
+			return buckets, problems, err
+		}
+		buckets = append(buckets, bucket)
+
+		want, err := policy.Expected(layer)
+		if err != nil {
+			return buckets, problems, err
+		}
+		rules, err := lifecycle.Get(ctx, env.clients.S3(), bucket)
+		if err != nil {
+			return buckets, problems, err
+		}
+		problems = append(problems, lifecycleProblems(bucket, want, rules)...)
+	}
+	return buckets, problems, nil
+}
+
+// lifecycleProblems lists how a bucket's rules differ from the single rule want
+func lifecycleProblems(bucket string, want lifecycle.Rule, rules []lifecycle.Rule) []string {
+	if len(rules) != 1 {
+		return []string{fmt.Sprintf("%s has %d lifecycle rules, want 1", bucket, len(rules))}
+	}
+
+	var problems []string
+	if got := rules[0]; !reflect.DeepEqual(got, want) {
+		problems = append(problems, fmt.Sprintf("%s has lifecycle rule %+v, want %+v", bucket, got, want))
+	}
+	for _, problem := range lifecycle.Check(rules[0]) {
+		problems = append(problems, bucket+": "+problem)
+	}
+	if uncovered := lifecycle.Uncovered(rules, ""); len(uncovered) > 0 {
+		problems = append(problems, bucket+" has objects no lifecycle rule covers")
+	}
+	return problems
+}
+
+// alarmState is the state of one alarm
+type alarmState struct {
+	name   string
+	state  cwtypes.StateValue
+	reason string
+}
+
+// checkAlarms reads the state of every CloudWatch alarm tagged with the
+// environment and project
+func checkAlarms(ctx context.Context, env *environment) ([]string, []string, error) {
+	project, err := projectName(env.root, env.environment)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var names []string
+	paginator := resourcegroupstaggingapi.NewGetResourcesPaginator(env.clients.Tagging(), &resourcegroupstaggingapi.GetResourcesInput{
+		ResourceTypeFilters: []string{"cloudwatch:alarm"},
+		TagFilters: []taggingtypes.TagFilter{
+			{Key: aws.String("Environment"), Values: []string{env.environment}},
+			{Key: aws.String("Project"), Values: []string{project}},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, mapping := range page.ResourceTagMappingList {
+			parsed, err := awsarn.Parse(aws.ToString(mapping.ResourceARN))
+			if err != nil {
+				return nil, nil, err
+			}
+			names = append(names, strings.TrimPrefix(parsed.Resource, "alarm:"))
+		}
+	}
+
+	var states []alarmState
+	for start := 0; start < len(names); start += 100 {
+		batch := names[start:min(start+100, len(names))]
+		output, err := env.clients.CloudWatch().DescribeAlarms(ctx, &cloudwatch.DescribeAlarmsInput{
+			AlarmNames: batch,
+			AlarmTypes: []cwtypes.AlarmType{cwtypes.AlarmTypeMetricAlarm, cwtypes.AlarmTypeCompositeAlarm},
+		})
+		if err != nil {
+			return names, nil, err
+		}
+		for _, alarm := range output.MetricAlarms {
+			states = append(states, alarmState{aws.ToString(alarm.AlarmName), alarm.StateValue, aws.ToString(alarm.StateReason)})
+		}
+		for _, alarm := range output.CompositeAlarms {
+			states = append(states, alarmState{aws.ToString(alarm.AlarmName), alarm.StateValue, aws.ToString(alarm.StateReason)})
+		}
+	}
+	return names, alarmProblems(states), nil
+}
+
+// alarmProblems lists the alarms in ALARM; missing data is not a regression
+// since idle environments have none
+func alarmProblems(states []alarmState) []string {
+	var problems []string
+	for _, alarm := range states {
+		if alarm.state == cwtypes.StateValueAlarm {
+			problems = append(problems, fmt.Sprintf("%s is in ALARM: %s", alarm.name, alarm.reason))
+		}
+	}
+	return problems
+}
+
+// projectName returns project.name from the configuration, which root.hcl
+// tags every resource with
+func projectName(root, environment string) (string, error) {
+	var name string
+	for _, path := range []string{
+		filepath.Join(root, "config", "common.yaml"),
+		filepath.Join(root, "config", "environments", environment+".yaml"),
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		var config struct {
+			Project *struct {
+				Name string `yaml:"name"`
+			} `yaml:"project"`
+		}
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return "", fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if config.Project != nil {
+			name = config.Project.Name
+		}
+	}
+	if name == "" {
+		return "", errors.New("config/common.yaml sets no project.name")
+	}
+	return name, nil
+}
+
+// checkAthena lists the tables of every data lake database through Athena,
+// which exercises the workgroup, its result location and the Glue catalog
+func checkAthena(ctx context.Context, env *environment) ([]string, []string, error) {
+	var databases, problems []string
+	for _, layer := range layers {
+		database, err := env.stringOutput(storageUnit, layer+"_database_name")
+		if err != nil {
+			return databases, problems, err
+		}
+		databases = append(databases, database)
+
+		result, err := athena.Execute(ctx, env.clients.Athena(), athena.Query{
+			SQL:            fmt.Sprintf("SHOW TABLES IN `%s`", database),
+			Workgroup:      env.workgroup,
+			OutputLocation: env.outputLocation,
+		})
+		if result != nil {
+			if cleanupErr := result.Cleanup(ctx, env.clients); cleanupErr != nil {
+				fmt.Fprintf(os.Stderr, "failed to clean up Athena query %s: %v\n", result.QueryExecutionID, cleanupErr)
+			}
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("querying %s failed: %v", database, err))
+		}
+	}
+	return databases, problems, nil
+}
+
+// planSummary matches Terraform's one-line plan summary
+var planSummary = regexp.MustCompile(`Plan: \d+ to add, \d+ to change, \d+ to destroy`)
+
+// checkDrift plans every unit without locking its state; a plan with changes
+// means the deployed resources or the code moved since the last apply
+func checkDrift(ctx context.Context, env *environment) ([]string, []string, error) {
+	var problems []string
+	for _, unit := range env.units {
+		var stdout, stderr bytes.Buffer
+		err := terragrunt(ctx, filepath.Join(env.dir, unit), env.credentials, &stdout, &stderr,
+			"plan", "-detailed-exitcode", "-lock=false", "-input=false", "-no-color")
+		drifted, err := planDrifted(err)
+		if err != nil {
+			return env.units, problems, fmt.Errorf("failed to plan %s: %w: %s", unit, err, lastLine(stderr.String()))
+		}
+		if drifted {
+			problems = append(problems, fmt.Sprintf("%s has drifted: %s", unit, summary(stdout.String())))
+		}
+	}
+	return env.units, problems, nil
+}
+
+// planDrifted interprets the error of `plan -detailed-exitcode`, which exits 2
+// when the plan has changes
+func planDrifted(err error) (bool, error) {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 2 {
+		return true, nil
+	}
+	return false, err
+}
+
+// summary returns the plan summary line of plan output, or a placeholder
+func summary(plan string) string {
+	if match := planSummary.FindString(plan); match != "" {
+		return match
+	}
+	return "changes planned"
+}
+
+// isAPIError reports whether err is an AWS API error with one of codes
+func isAPIError(err error, codes ...string) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, code := range codes {
+		if apiErr.ErrorCode() == code {
+			return true
+		}
+	}
+	return false
+}
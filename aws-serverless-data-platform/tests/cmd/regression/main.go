@@ -0,0 +1,115 @@
+// =============================================================================
+// Regression CLI
+// Continuously verifies already deployed environments without changing them
+// =============================================================================
+
+// Command regression re-verifies environments that are already deployed,
+// without applying or destroying anything, so it can run on a schedule against
+// long-lived environments between the provisioning-heavy Terratest suites. For
+// each environment it reads the outputs of the units under
+// environments/<env>/<region> and runs the selected read-only checks: unit
+// outputs, data lake bucket settings, lifecycle rules against the configured
+// policy, CloudWatch alarm states, Athena queries against the Glue databases
+// and a Terraform plan for drift.
+//
+// Each environment is verified with the role of its config/accounts.yaml entry
+// unless -role-arn is given. The command writes a JSON report and exits
+// non-zero when any check fails.
+//
+// Usage:
+//
+//	go run ./cmd/regression -environments dev
+//	go run ./cmd/regression -environments dev,staging -checks buckets,alarms,drift -output regression.json
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/athena"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/testconfig"
+)
+
+func main() {
+	environments := flag.String("environments", "dev", "comma-separated environments to verify")
+	region := flag.String("region", "", "region of each environment to verify; required when one has several")
+	selected := flag.String("checks", strings.Join(checkIDs(), ","), "comma-separated checks to run")
+	roleARN := flag.String("role-arn", os.Getenv(awsclients.RoleARNEnvVar), "IAM role to assume instead of each environment's assume_role_arn")
+	externalID := flag.String("external-id", os.Getenv(awsclients.ExternalIDEnvVar), "external ID required by the role's trust policy")
+	workgroup := flag.String("workgroup", athena.DefaultWorkgroup, "Athena workgroup the queries run in")
+	outputLocation := flag.String("athena-output", "", "s3:// prefix for Athena results; empty uses the workgroup's location")
+	output := flag.String("output", "-", "file to write the JSON report to, - for stdout")
+	timeout := flag.Duration("timeout", 30*time.Minute, "overall deadline for the run")
+	flag.Parse()
+
+	run, err := selectChecks(*selected)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	root, err := testconfig.FindRoot()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	r := &report{GeneratedAt: time.Now().UTC()}
+	for _, name := range strings.Split(*environments, ",") {
+		o := options{
+			root:           root,
+			environment:    strings.TrimSpace(name),
+			region:         *region,
+			roleARN:        *roleARN,
+			externalID:     *externalID,
+			workgroup:      *workgroup,
+			outputLocation: *outputLocation,
+		}
+		r.Environments = append(r.Environments, verifyEnvironment(ctx, o, run))
+	}
+
+	summarize(os.Stderr, r)
+	if err := write(*output, r); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if !r.passed() {
+		os.Exit(2)
+	}
+}
+
+// verifyEnvironment loads one environment and runs the checks against it; an
+// environment that cannot be loaded is reported rather than stopping the run
+func verifyEnvironment(ctx context.Context, o options, run []check) environmentReport {
+	fmt.Fprintf(os.Stderr, "==> %s\n", o.environment)
+	env, err := load(ctx, o)
+	if err != nil {
+		return environmentReport{Environment: o.environment, Region: o.region, Error: err.Error()}
+	}
+	return verify(ctx, env, run)
+}
+
+// write writes the report to path, or stdout for "-"
+func write(path string, r *report) error {
+	var out io.Writer = os.Stdout
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	return writeJSON(out, r)
+}
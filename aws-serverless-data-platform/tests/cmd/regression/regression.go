@@ -0,0 +1,381 @@
+// =============================================================================
+// Regression Run
+// Environment loading, check selection and the JSON report
+// =============================================================================
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tggraph"
+	"gopkg.in/yaml.v3"
+)
+
+// status is the outcome of one check
+type status string
+
+const (
+	statusPass  status = "pass"
+	statusFail  status = "fail"
+	statusError status = "error"
+)
+
+// options selects an environment and how it is verified
+type options struct {
+	root        string
+	environment string
+
+	// region is empty to use the environment's only region
+	region string
+
+	// roleARN overrides the environment's assume_role_arn
+	roleARN    string
+	externalID string
+
+	workgroup      string
+	outputLocation string
+}
+
+// output is one value from `terragrunt output -json`
+type output struct {
+	Value     json.RawMessage `json:"value"`
+	Sensitive bool            `json:"sensitive"`
+}
+
+// environment is a deployed environment the checks run against
+type environment struct {
+	options
+
+	// dir holds the environment's units
+	dir string
+
+	// account is the environment's config/accounts.yaml entry
+	account account
+
+	// units are the environment's units in apply order
+	units []string
+
+	// outputs are each unit's outputs, and outputErrors the units whose outputs
+	// could not be read, which usually means they are not deployed
+	outputs      map[string]map[string]output
+	outputErrors map[string]error
+
+	clients *awsclients.Clients
+
+	// credentials are the environment variables that make Terragrunt act as
+	// the same principal as clients
+	credentials map[string]string
+}
+
+// account is an environment's entry in config/accounts.yaml
+type account struct {
+	AWS struct {
+		AccountID     string `yaml:"account_id"`
+		AssumeRoleARN string `yaml:"assume_role_arn"`
+	} `yaml:"aws"`
+}
+
+// load resolves the environment's region, units, outputs and credentials, and
+// makes sure the credentials are for the environment's account
+func load(ctx context.Context, o options) (*environment, error) {
+	if o.region == "" {
+		region, err := onlyRegion(o.root, o.environment)
+		if err != nil {
+			return nil, err
+		}
+		o.region = region
+	}
+	env := &environment{
+		options:      o,
+		dir:          filepath.Join(o.root, "environments", o.environment, o.region),
+		outputs:      map[string]map[string]output{},
+		outputErrors: map[string]error{},
+	}
+
+	var err error
+	if env.account, err = loadAccount(o.root, o.environment); err != nil {
+		return nil, err
+	}
+	roleARN := o.roleARN
+	if roleARN == "" {
+		roleARN = env.account.AWS.AssumeRoleARN
+	}
+	cfg, _, err := awsclients.LoadConfig(ctx,
+		awsclients.WithRegion(o.region),
+		awsclients.WithAssumeRole(roleARN),
+		awsclients.WithExternalID(o.externalID),
+		awsclients.WithSessionName("regression"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the verified account: %w", err)
+	}
+	if expected := env.account.AWS.AccountID; expected != "" && expected != aws.ToString(identity.Account) {
+		return nil, fmt.Errorf("credentials are for account %s but %s is account %s", aws.ToString(identity.Account), o.environment, expected)
+	}
+	env.clients = awsclients.FromConfig(cfg)
+
+	graph, err := tggraph.Load(env.dir)
+	if err != nil {
+		return nil, err
+	}
+	if env.units, err = graph.ApplyOrder(); err != nil {
+		return nil, err
+	}
+
+	// Terragrunt runs with the resolved credentials so it reads the same account
+	if env.credentials, err = awsclients.CredentialEnvVars(ctx, cfg); err != nil {
+		return nil, err
+	}
+	for _, unit := range env.units {
+		outputs, err := readOutputs(ctx, filepath.Join(env.dir, unit), env.credentials)
+		if err != nil {
+			env.outputErrors[unit] = err
+			continue
+		}
+		env.outputs[unit] = outputs
+	}
+	return env, nil
+}
+
+// onlyRegion returns the region of environment, failing when it has none or several
+func onlyRegion(root, environment string) (string, error) {
+	regions, err := filepath.Glob(filepath.Join(root, "environments", environment, "*"))
+	if err != nil {
+		return "", err
+	}
+	switch len(regions) {
+	case 0:
+		return "", fmt.Errorf("environment %s has no regions under environments/%s", environment, environment)
+	case 1:
+		return filepath.Base(regions[0]), nil
+	}
+	return "", fmt.Errorf("environment %s has several regions; choose one with -region, e.g. -region %s", environment, filepath.Base(regions[0]))
+}
+
+// loadAccount returns the environment's config/accounts.yaml entry, which is
+// empty when the environment has none
+func loadAccount(root, environment string) (account, error) {
+	path := filepath.Join(root, "config", "accounts.yaml")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return account{}, nil
+	}
+	if err != nil {
+		return account{}, err
+	}
+	var accounts map[string]account
+	if err := yaml.Unmarshal(data, &accounts); err != nil {
+		return account{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return accounts[environment], nil
+}
+
+// readOutputs reads a unit's outputs with `terragrunt output -json`
+func readOutputs(ctx context.Context, dir string, credentials map[string]string) (map[string]output, error) {
+	var stdout, stderr bytes.Buffer
+	if err := terragrunt(ctx, dir, credentials, &stdout, &stderr, "output", "-json"); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, lastLine(stderr.String()))
+	}
+	outputs := map[string]output{}
+	if err := json.Unmarshal(stdout.Bytes(), &outputs); err != nil {
+		return nil, fmt.Errorf("failed to parse outputs: %w", err)
+	}
+	return outputs, nil
+}
+
+// terragrunt runs terragrunt with args in dir as the principal in credentials
+func terragrunt(ctx context.Context, dir string, credentials map[string]string, stdout, stderr io.Writer, args ...string) error {
+	cmd := exec.CommandContext(ctx, "terragrunt", append(args, "--terragrunt-non-interactive")...)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	for name, value := range credentials {
+		cmd.Env = append(cmd.Env, name+"="+value)
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+// lastLine returns the last non-empty line of s, which is where Terraform and
+// Terragrunt put the error that stopped them
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// stringOutput returns a unit's string output
+func (e *environment) stringOutput(unit, name string) (string, error) {
+	if err, ok := e.outputErrors[unit]; ok {
+		return "", fmt.Errorf("outputs of %s are unavailable: %w", unit, err)
+	}
+	outputs, ok := e.outputs[unit]
+	if !ok {
+		return "", fmt.Errorf("environment %s has no %s unit", e.environment, unit)
+	}
+	out, ok := outputs[name]
+	if !ok {
+		return "", fmt.Errorf("unit %s has no output %s", unit, name)
+	}
+	var value string
+	if err := json.Unmarshal(out.Value, &value); err != nil {
+		return "", fmt.Errorf("output %s of %s is not a string: %w", name, unit, err)
+	}
+	return value, nil
+}
+
+// finding is the outcome of one check in the report
+type finding struct {
+	ID        string   `json:"id"`
+	Title     string   `json:"title"`
+	Status    status   `json:"status"`
+	Duration  string   `json:"duration"`
+	Resources []string `json:"resources"`
+	Problems  []string `json:"problems,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// environmentReport is the outcome of verifying one environment; Error is set
+// when it could not be loaded and no check ran
+type environmentReport struct {
+	Environment string    `json:"environment"`
+	Region      string    `json:"region"`
+	AccountID   string    `json:"account_id,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Findings    []finding `json:"findings"`
+}
+
+// report is the result of a regression run
+type report struct {
+	GeneratedAt  time.Time           `json:"generated_at"`
+	Environments []environmentReport `json:"environments"`
+}
+
+// passed reports whether every environment loaded and every check passed
+func (r *report) passed() bool {
+	for _, env := range r.Environments {
+		if env.Error != "" {
+			return false
+		}
+		for _, f := range env.Findings {
+			if f.Status != statusPass {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// selectChecks returns the checks named in the comma-separated list, in
+// registry order
+func selectChecks(list string) ([]check, error) {
+	wanted := map[string]bool{}
+	for _, id := range strings.Split(list, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			wanted[id] = true
+		}
+	}
+
+	var selected []check
+	for _, c := range checks {
+		if wanted[c.id] {
+			selected = append(selected, c)
+			delete(wanted, c.id)
+		}
+	}
+	for id := range wanted {
+		return nil, fmt.Errorf("unknown check %q; choose from %s", id, strings.Join(checkIDs(), ", "))
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no checks selected; choose from %s", strings.Join(checkIDs(), ", "))
+	}
+	return selected, nil
+}
+
+// checkIDs lists every check in registry order
+func checkIDs() []string {
+	ids := make([]string, 0, len(checks))
+	for _, c := range checks {
+		ids = append(ids, c.id)
+	}
+	return ids
+}
+
+// verify runs every check; a check that errors is reported rather than stopping the run
+func verify(ctx context.Context, env *environment, run []check) environmentReport {
+	r := environmentReport{
+		Environment: env.environment,
+		Region:      env.region,
+		AccountID:   env.account.AWS.AccountID,
+	}
+
+	for _, c := range run {
+		started := time.Now()
+		resources, problems, err := c.run(ctx, env)
+
+		f := finding{
+			ID:        c.id,
+			Title:     c.title,
+			Status:    statusPass,
+			Duration:  time.Since(started).Round(time.Millisecond).String(),
+			Resources: append([]string{}, resources...),
+			Problems:  problems,
+		}
+		switch {
+		case err != nil:
+			f.Status = statusError
+			f.Error = err.Error()
+		case len(problems) > 0:
+			f.Status = statusFail
+		}
+		r.Findings = append(r.Findings, f)
+	}
+	return r
+}
+
+// summarize writes one line per finding
+func summarize(out io.Writer, r *report) {
+	for _, env := range r.Environments {
+		if env.Error != "" {
+			fmt.Fprintf(out, "⚠️  %s: %s\n", env.Environment, env.Error)
+			continue
+		}
+		fmt.Fprintf(out, "%s (%s)\n", env.Environment, env.Region)
+		for _, f := range env.Findings {
+			switch f.Status {
+			case statusPass:
+				fmt.Fprintf(out, "  ✅ %-9s %s\n", f.ID, f.Title)
+			case statusFail:
+				fmt.Fprintf(out, "  ❌ %-9s %s\n", f.ID, f.Title)
+				for _, problem := range f.Problems {
+					fmt.Fprintf(out, "               %s\n", problem)
+				}
+			case statusError:
+				fmt.Fprintf(out, "  ⚠️  %-9s %s: %s\n", f.ID, f.Title, f.Error)
+			}
+		}
+	}
+}
+
+// writeJSON writes the report as indented JSON
+func writeJSON(w io.Writer, r *report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/lifecycle"
+)
+
+func TestSelectChecks(t *testing.T) {
+	selected, err := selectChecks("drift, buckets")
+	require.NoError(t, err)
+	require.Len(t, selected, 2)
+	assert.Equal(t, "buckets", selected[0].id, "checks run in registry order")
+	assert.Equal(t, "drift", selected[1].id)
+
+	all, err := selectChecks("outputs,buckets,lifecycle,alarms,athena,drift")
+	require.NoError(t, err)
+	assert.Len(t, all, len(checks))
+
+	_, err = selectChecks("buckets,plan")
+	assert.ErrorContains(t, err, `unknown check "plan"`)
+
+	_, err = selectChecks(" , ")
+	assert.ErrorContains(t, err, "no checks selected")
+}
+
+func TestVerifyReportsEachCheck(t *testing.T) {
+	result := func(problems []string, err error) func(context.Context, *environment) ([]string, []string, error) {
+		return func(context.Context, *environment) ([]string, []string, error) {
+			return []string{"resource"}, problems, err
+		}
+	}
+
+	env := &environment{options: options{environment: "dev", region: "ap-southeast-1"}}
+	env.account.AWS.AccountID = "123456789012"
+	r := verify(context.Background(), env, []check{
+		{"a", "passes", result(nil, nil)},
+		{"b", "fails", result([]string{"broken"}, nil)},
+		{"c", "errors", result(nil, errors.New("access denied"))},
+	})
+
+	assert.Equal(t, "123456789012", r.AccountID)
+	require.Len(t, r.Findings, 3)
+	assert.Equal(t, statusPass, r.Findings[0].Status)
+	assert.Equal(t, statusFail, r.Findings[1].Status)
+	assert.Equal(t, []string{"broken"}, r.Findings[1].Problems)
+	assert.Equal(t, statusError, r.Findings[2].Status)
+	assert.Equal(t, "access denied", r.Findings[2].Error)
+
+	assert.False(t, (&report{Environments: []environmentReport{r}}).passed())
+	assert.True(t, (&report{Environments: []environmentReport{{Findings: r.Findings[:1]}}}).passed())
+	assert.False(t, (&report{Environments: []environmentReport{{Error: "no credentials"}}}).passed())
+}
+
+func TestStringOutput(t *testing.T) {
+	env := &environment{
+		options: options{environment: "dev"},
+		outputs: map[string]map[string]output{
+			storageUnit: {
+				"raw_bucket_id":   {Value: json.RawMessage(`"dl-raw-dev"`)},
+				"raw_bucket_tags": {Value: json.RawMessage(`{"Project":"dl"}`)},
+			},
+		},
+		outputErrors: map[string]error{"01-networking": errors.New("no state")},
+	}
+
+	bucket, err := env.stringOutput(storageUnit, "raw_bucket_id")
+	require.NoError(t, err)
+	assert.Equal(t, "dl-raw-dev", bucket)
+
+	_, err = env.stringOutput(storageUnit, "curated_bucket_id")
+	assert.ErrorContains(t, err, "has no output curated_bucket_id")
+	_, err = env.stringOutput(storageUnit, "raw_bucket_tags")
+	assert.ErrorContains(t, err, "is not a string")
+	_, err = env.stringOutput("01-networking", "vpc_id")
+	assert.ErrorContains(t, err, "no state")
+	_, err = env.stringOutput("05-analytics", "workgroup")
+	assert.ErrorContains(t, err, "has no 05-analytics unit")
+}
+
+func TestCheckOutputs(t *testing.T) {
+	env := &environment{
+		units:        []string{"01-networking", "03-storage", "05-analytics"},
+		outputs:      map[string]map[string]output{"01-networking": {"vpc_id": {}}, "03-storage": {}},
+		outputErrors: map[string]error{"05-analytics": errors.New("no state")},
+	}
+	units, problems, err := checkOutputs(context.Background(), env)
+	require.NoError(t, err)
+	assert.Equal(t, env.units, units)
+	assert.Equal(t, []string{"03-storage has no outputs", "05-analytics: no state"}, problems)
+}
+
+func TestBucketProblems(t *testing.T) {
+	blocked := &s3types.PublicAccessBlockConfiguration{
+		BlockPublicAcls:       aws.Bool(true),
+		IgnorePublicAcls:      aws.Bool(true),
+		BlockPublicPolicy:     aws.Bool(true),
+		RestrictPublicBuckets: aws.Bool(true),
+	}
+	assert.Empty(t, bucketProblems(bucketState{name: "raw", versioning: s3types.BucketVersioningStatusEnabled, encryption: "AES256", publicAccessBlock: blocked}))
+
+	assert.Equal(t, []string{
+		"raw is not versioned",
+		"raw has BlockPublicPolicy disabled",
+		"raw has RestrictPublicBuckets disabled",
+	}, bucketProblems(bucketState{
+		name:       "raw",
+		versioning: s3types.BucketVersioningStatusSuspended,
+		encryption: "aws:kms",
+		publicAccessBlock: &s3types.PublicAccessBlockConfiguration{
+			BlockPublicAcls:  aws.Bool(true),
+			IgnorePublicAcls: aws.Bool(true),
+		},
+	}))
+
+	assert.Equal(t, []string{"raw has no default encryption", "raw has no public access block"},
+		bucketProblems(bucketState{name: "raw", versioning: s3types.BucketVersioningStatusEnabled}))
+}
+
+func TestLifecycleProblems(t *testing.T) {
+	want, err := lifecycle.Policy{TransitionIADays: 30, TransitionGlacierDays: 90, TransitionDeepArchiveDays: 365, ExpirationDays: 730}.Expected("raw")
+	require.NoError(t, err)
+	assert.Empty(t, lifecycleProblems("raw", want, []lifecycle.Rule{want}))
+
+	assert.Equal(t, []string{"raw has 0 lifecycle rules, want 1"}, lifecycleProblems("raw", want, nil))
+
+	// A bucket still on an older policy with a misordered transition
+	stale := want
+	stale.Transitions = []lifecycle.Transition{{Days: 90, StorageClass: "GLACIER"}, {Days: 120, StorageClass: "STANDARD_IA"}}
+	problems := lifecycleProblems("raw", want, []lifecycle.Rule{stale})
+	require.Len(t, problems, 2)
+	assert.Contains(t, problems[0], "raw has lifecycle rule")
+	assert.Equal(t, "raw: rule raw_data_lifecycle moves objects to STANDARD_IA at 120 days, which is not colder than GLACIER at 90 days", problems[1])
+}
+
+func TestAlarmProblems(t *testing.T) {
+	assert.Equal(t, []string{"dl-dev-high-error-rate is in ALARM: Threshold Crossed"}, alarmProblems([]alarmState{
+		{"dl-dev-high-error-rate", cwtypes.StateValueAlarm, "Threshold Crossed"},
+		{"dl-dev-data-quality-issues", cwtypes.StateValueInsufficientData, "no datapoints"},
+		{"dl-dev-lambda-errors-ingest", cwtypes.StateValueOk, ""},
+	}))
+}
+
+func TestProjectName(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "config", "environments"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "config", "common.yaml"), []byte("project:\n  name: data-platform\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "config", "environments", "dev.yaml"), []byte("storage: {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "config", "environments", "demo.yaml"), []byte("project:\n  name: demo-platform\n"), 0o644))
+
+	name, err := projectName(root, "dev")
+	require.NoError(t, err)
+	assert.Equal(t, "data-platform", name)
+
+	name, err = projectName(root, "demo")
+	require.NoError(t, err)
+	assert.Equal(t, "demo-platform", name)
+}
+
+func TestPlanDrifted(t *testing.T) {
+	drifted, err := planDrifted(nil)
+	assert.False(t, drifted)
+	assert.NoError(t, err)
+
+	drifted, err = planDrifted(exec.Command("sh", "-c", "exit 2").Run())
+	assert.True(t, drifted)
+	assert.NoError(t, err)
+
+	drifted, err = planDrifted(exec.Command("sh", "-c", "exit 1").Run())
+	assert.False(t, drifted)
+	assert.Error(t, err)
+
+	assert.Equal(t, "Plan: 0 to add, 1 to change, 0 to destroy", summary("...\nPlan: 0 to add, 1 to change, 0 to destroy.\n"))
+	assert.Equal(t, "changes planned", summary("Changes to Outputs:"))
+}
+
+func TestOnlyRegion(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "environments", "dev", "ap-southeast-1"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "environments", "prod", "us-east-1"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "environments", "prod", "eu-west-1"), 0o755))
+
+	region, err := onlyRegion(root, "dev")
+	require.NoError(t, err)
+	assert.Equal(t, "ap-southeast-1", region)
+
+	_, err = onlyRegion(root, "prod")
+	assert.ErrorContains(t, err, "several regions")
+	_, err = onlyRegion(root, "staging")
+	assert.ErrorContains(t, err, "no regions")
+}
@@ -0,0 +1,149 @@
+// =============================================================================
+// Personal Sandbox CLI
+// Creates and destroys a per-developer copy of the dev environment
+// =============================================================================
+
+// Command sandbox gives a developer their own copy of an environment to
+// experiment in. `up` clones the networking, security and storage units of
+// environments/<source>/<region> into environments/sandbox-<name>/<region>,
+// with config/environments/sandbox-<name>.yaml copied from the source's
+// config, applies them in dependency order and prints their outputs. Every
+// name the units derive from the environment, and their state key, then
+// carries sandbox-<name>, so sandboxes never collide with the source or each
+// other. The sandbox deploys to the source's account, through the account key
+// root.hcl reads from its config.
+//
+// `outputs` prints the outputs again and `down` destroys the units in reverse
+// dependency order, removing the generated files once everything is gone. The
+// generated files are ignored by git.
+//
+// Usage:
+//
+//	go run ./cmd/sandbox up
+//	go run ./cmd/sandbox up -name jdoe -source dev -region ap-southeast-1
+//	go run ./cmd/sandbox outputs -name jdoe
+//	go run ./cmd/sandbox down -name jdoe
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/testconfig"
+)
+
+// defaultModules is the smallest set of modules a useful sandbox needs
+const defaultModules = "networking,security,storage"
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	name := flags.String("name", defaultName(), "sandbox owner, 1-12 lower case letters and digits; defaults to $USER")
+	source := flags.String("source", "dev", "environment the sandbox is cloned from")
+	region := flags.String("region", "", "region of -source to clone; required when it has several")
+	modules := flags.String("modules", defaultModules, "comma-separated modules whose units are cloned")
+	if err := flags.Parse(os.Args[2:]); err != nil {
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	s, err := newSandbox(*name, *source, *region)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	switch command {
+	case "up":
+		err = up(ctx, s, strings.Split(*modules, ","))
+	case "outputs":
+		err = outputs(ctx, s)
+	case "down":
+		err = down(ctx, s)
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// usage describes the commands and exits
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: sandbox up|outputs|down [-name name] [-source env] [-region region] [-modules list]")
+	os.Exit(2)
+}
+
+// newSandbox locates the repository and the region of source to clone
+func newSandbox(name, source, region string) (sandbox, error) {
+	root, err := testconfig.FindRoot()
+	if err != nil {
+		return sandbox{}, err
+	}
+	if root == "" {
+		return sandbox{}, fmt.Errorf("run sandbox from inside the repository; %s was not found", testconfig.BaseFile)
+	}
+	if err := validateName(name); err != nil {
+		return sandbox{}, err
+	}
+	if region == "" {
+		if region, err = onlyRegion(root, source); err != nil {
+			return sandbox{}, err
+		}
+	}
+	return sandbox{Root: root, Name: name, Source: source, Region: region}, nil
+}
+
+// up clones the source units and applies them, then prints their outputs
+func up(ctx context.Context, s sandbox, modules []string) error {
+	missing, err := clone(s, modules)
+	if err != nil {
+		return err
+	}
+	for _, module := range missing {
+		fmt.Fprintf(os.Stderr, "%s/%s has no %s unit; the sandbox is created without it\n", s.Source, s.Region, module)
+	}
+
+	fmt.Fprintf(os.Stderr, "Applying sandbox %s in %s\n", s.Environment(), s.Region)
+	if err := apply(ctx, s); err != nil {
+		return fmt.Errorf("%w; fix it and rerun up, or remove what was created with down", err)
+	}
+	return outputs(ctx, s)
+}
+
+// down destroys the sandbox and removes its generated files
+func down(ctx context.Context, s sandbox) error {
+	if _, err := os.Stat(s.Dir()); err != nil {
+		return fmt.Errorf("sandbox %s has no units in %s: %w", s.Environment(), s.Dir(), err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Destroying sandbox %s in %s\n", s.Environment(), s.Region)
+	if err := destroy(ctx, s); err != nil {
+		return fmt.Errorf("%w; its files are kept so down can be rerun", err)
+	}
+	if err := s.remove(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Sandbox %s destroyed\n", s.Environment())
+	return nil
+}
+
+// outputs prints every unit's outputs to stdout
+func outputs(ctx context.Context, s sandbox) error {
+	values, err := collectOutputs(ctx, s)
+	if err != nil {
+		return err
+	}
+	return writeOutputs(os.Stdout, values)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tggraph"
+	"gopkg.in/yaml.v3"
+)
+
+// sandbox is one developer's copy of a source environment in one region
+type sandbox struct {
+	// Root is the repository root holding environments/ and config/
+	Root string
+
+	// Name is the developer the sandbox belongs to, e.g. "jdoe"
+	Name string
+
+	// Source is the environment cloned, e.g. "dev"
+	Source string
+	Region string
+}
+
+// Environment is the environment name the sandbox deploys as, e.g. "sandbox-jdoe"
+func (s sandbox) Environment() string {
+	return "sandbox-" + s.Name
+}
+
+// Dir is the directory holding the sandbox's units
+func (s sandbox) Dir() string {
+	return filepath.Join(s.Root, "environments", s.Environment(), s.Region)
+}
+
+// configPath is the sandbox's config/environments file
+func (s sandbox) configPath() string {
+	return filepath.Join(s.Root, "config", "environments", s.Environment()+".yaml")
+}
+
+// remove deletes the sandbox's units and config
+func (s sandbox) remove() error {
+	if err := os.RemoveAll(filepath.Join(s.Root, "environments", s.Environment())); err != nil {
+		return err
+	}
+	if err := os.Remove(s.configPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// validName keeps sandbox names short enough that the longest bucket name the
+// units derive, aws-data-platform-datalake-sandbox-<name>-<region>, fits in 63
+// characters
+var validName = regexp.MustCompile(`^[a-z][a-z0-9]{0,11}$`)
+
+// validateName checks name can be used in every resource name
+func validateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid sandbox name %q: use 1-12 lower case letters and digits, starting with a letter", name)
+	}
+	return nil
+}
+
+// defaultName derives a sandbox name from the current user, dropping the
+// characters names may not contain
+func defaultName() string {
+	name := os.Getenv("USER")
+	if current, err := user.Current(); name == "" && err == nil {
+		name = current.Username
+	}
+	name = regexp.MustCompile(`[^a-z0-9]`).ReplaceAllString(strings.ToLower(name), "")
+	if len(name) > 12 {
+		name = name[:12]
+	}
+	return name
+}
+
+// onlyRegion returns the region of source, failing when it has none or several
+func onlyRegion(root, source string) (string, error) {
+	regions, err := filepath.Glob(filepath.Join(root, "environments", source, "*"))
+	if err != nil {
+		return "", err
+	}
+	switch len(regions) {
+	case 0:
+		return "", fmt.Errorf("environment %s has no regions under environments/%s", source, source)
+	case 1:
+		return filepath.Base(regions[0]), nil
+	}
+	return "", fmt.Errorf("environment %s has several regions; choose one with -region, e.g. -region %s", source, filepath.Base(regions[0]))
+}
+
+// moduleSource finds the module a unit deploys, e.g. "storage" in
+// source = "${get_repo_root()}/modules//storage"
+var moduleSource = regexp.MustCompile(`modules//([\w-]+)`)
+
+// clone writes the sandbox's config and copies of the source units that
+// deploy modules, returning the modules the source has no unit for; rerunning
+// it refreshes the copies from the source
+func clone(s sandbox, modules []string) ([]string, error) {
+	if err := writeConfig(s); err != nil {
+		return nil, err
+	}
+
+	sourceDir := filepath.Join(s.Root, "environments", s.Source, s.Region)
+	files, err := filepath.Glob(filepath.Join(sourceDir, "*", tggraph.ConfigFile))
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := map[string]bool{}
+	for _, module := range modules {
+		if module = strings.TrimSpace(module); module != "" {
+			wanted[module] = true
+		}
+	}
+
+	// The source's environment local is used in names, such as the storage
+	// bucket names, so it becomes the sandbox's
+	environmentLocal := regexp.MustCompile(`(?m)^(\s*environment\s*=\s*)"` + regexp.QuoteMeta(s.Source) + `"`)
+
+	found := map[string]bool{}
+	for _, path := range files {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		match := moduleSource.FindSubmatch(src)
+		if match == nil || !wanted[string(match[1])] {
+			continue
+		}
+		found[string(match[1])] = true
+
+		unit := filepath.Base(filepath.Dir(path))
+		header := fmt.Sprintf("# Generated by cmd/sandbox from environments/%s/%s/%s for the %s sandbox;\n"+
+			"# edits are lost when it is cloned again\n\n", s.Source, s.Region, unit, s.Name)
+		cloned := environmentLocal.ReplaceAll(src, []byte(`${1}"`+s.Environment()+`"`))
+
+		target := filepath.Join(s.Dir(), unit, tggraph.ConfigFile)
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(target, append([]byte(header), cloned...), 0o644); err != nil {
+			return nil, err
+		}
+	}
+
+	var missing []string
+	for module := range wanted {
+		if !found[module] {
+			missing = append(missing, module)
+		}
+	}
+	sort.Strings(missing)
+	if len(found) == 0 {
+		return missing, fmt.Errorf("environments/%s/%s has no units for %s", s.Source, s.Region, strings.Join(missing, ", "))
+	}
+
+	// A cloned unit depending on one that was not cloned could never apply
+	graph, err := tggraph.Load(s.Dir())
+	if err != nil {
+		return missing, err
+	}
+	return missing, graph.Validate()
+}
+
+// writeConfig copies the source's config/environments file for the sandbox,
+// pointing it at the source's account and tagging everything with its owner
+func writeConfig(s sandbox) error {
+	sourcePath := filepath.Join(s.Root, "config", "environments", s.Source+".yaml")
+	data, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return err
+	}
+	config := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("parse %s: %w", sourcePath, err)
+	}
+
+	if _, ok := config["account"]; !ok {
+		config["account"] = s.Source
+	}
+	tags, _ := config["tags"].(map[string]interface{})
+	if tags == nil {
+		tags = map[string]interface{}{}
+	}
+	tags["Sandbox"] = s.Name
+	config["tags"] = tags
+
+	rendered, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	header := fmt.Sprintf("# Generated by cmd/sandbox from config/environments/%s.yaml for the %s sandbox\n\n", s.Source, s.Name)
+	return os.WriteFile(s.configPath(), append([]byte(header), rendered...), 0o644)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// writeFiles creates files below root from a map of relative paths to contents
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+const storageUnit = `terraform {
+  source = "${get_repo_root()}/modules//storage"
+}
+
+dependency "networking" {
+  config_path = "../01-networking"
+}
+
+locals {
+  environment = "dev"
+}
+
+inputs = {
+  raw_bucket_name = "aws-data-platform-raw-${local.environment}"
+}
+`
+
+func testSandbox(t *testing.T) sandbox {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"config/environments/dev.yaml": "networking:\n  vpc:\n    cidr: \"10.0.0.0/16\"\ntags:\n  Environment: \"development\"\n",
+
+		"environments/dev/ap-southeast-1/01-networking/terragrunt.hcl": "terraform {\n  source = \"${get_repo_root()}/modules//networking\"\n}\n",
+		"environments/dev/ap-southeast-1/03-storage/terragrunt.hcl":    storageUnit,
+		"environments/dev/ap-southeast-1/05-analytics/terragrunt.hcl":  "terraform {\n  source = \"${get_repo_root()}/modules//analytics\"\n}\n",
+	})
+	return sandbox{Root: root, Name: "jdoe", Source: "dev", Region: "ap-southeast-1"}
+}
+
+func TestClone(t *testing.T) {
+	s := testSandbox(t)
+
+	missing, err := clone(s, []string{"networking", "security", "storage"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"security"}, missing)
+
+	units, err := filepath.Glob(filepath.Join(s.Dir(), "*"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(s.Root, "environments/sandbox-jdoe/ap-southeast-1/01-networking"),
+		filepath.Join(s.Root, "environments/sandbox-jdoe/ap-southeast-1/03-storage"),
+	}, units, "analytics is not in the module set")
+
+	storage, err := os.ReadFile(filepath.Join(s.Dir(), "03-storage", "terragrunt.hcl"))
+	require.NoError(t, err)
+	assert.Contains(t, string(storage), `environment = "sandbox-jdoe"`)
+	assert.NotContains(t, string(storage), `environment = "dev"`)
+	assert.Contains(t, string(storage), "# Generated by cmd/sandbox from environments/dev/ap-southeast-1/03-storage")
+
+	data, err := os.ReadFile(filepath.Join(s.Root, "config/environments/sandbox-jdoe.yaml"))
+	require.NoError(t, err)
+	config := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal(data, &config))
+	assert.Equal(t, "dev", config["account"])
+	assert.Equal(t, map[string]interface{}{"Environment": "development", "Sandbox": "jdoe"}, config["tags"])
+	assert.Contains(t, config, "networking")
+
+	require.NoError(t, s.remove())
+	assert.NoDirExists(t, filepath.Join(s.Root, "environments/sandbox-jdoe"))
+	assert.NoFileExists(t, filepath.Join(s.Root, "config/environments/sandbox-jdoe.yaml"))
+	assert.FileExists(t, filepath.Join(s.Root, "environments/dev/ap-southeast-1/03-storage/terragrunt.hcl"))
+}
+
+func TestCloneMissingDependency(t *testing.T) {
+	s := testSandbox(t)
+
+	_, err := clone(s, []string{"storage"})
+	assert.ErrorContains(t, err, "03-storage depends on missing unit 01-networking")
+
+	_, err = clone(s, []string{"streaming"})
+	assert.ErrorContains(t, err, "environments/dev/ap-southeast-1 has no units for streaming")
+}
+
+func TestValidateName(t *testing.T) {
+	assert.NoError(t, validateName("jdoe"))
+	assert.NoError(t, validateName("a1b2c3d4e5f6"))
+	assert.Error(t, validateName(""))
+	assert.Error(t, validateName("JDoe"))
+	assert.Error(t, validateName("j-doe"))
+	assert.Error(t, validateName("1jdoe"))
+	assert.Error(t, validateName("a1b2c3d4e5f6g"))
+}
+
+func TestWriteOutputs(t *testing.T) {
+	// Names are aligned within each unit
+	var out bytes.Buffer
+	require.NoError(t, writeOutputs(&out, []unitOutputs{
+		{Unit: "01-networking", Outputs: map[string]output{
+			"vpc_id":             {Value: json.RawMessage(`"vpc-0abc"`)},
+			"private_subnet_ids": {Value: json.RawMessage(`[ "subnet-1", "subnet-2" ]`)},
+		}},
+		{Unit: "03-storage", Outputs: map[string]output{
+			"raw_bucket_name": {Value: json.RawMessage(`"aws-data-platform-raw-sandbox-jdoe"`)},
+			"kms_key_secret":  {Value: json.RawMessage(`"hunter2"`), Sensitive: true},
+		}},
+	}))
+
+	assert.Equal(t, `01-networking
+  private_subnet_ids  ["subnet-1","subnet-2"]
+  vpc_id              "vpc-0abc"
+03-storage
+  kms_key_secret   (sensitive)
+  raw_bucket_name  "aws-data-platform-raw-sandbox-jdoe"
+`, out.String())
+}
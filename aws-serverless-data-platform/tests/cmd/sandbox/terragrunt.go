@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tggraph"
+)
+
+// apply initialises and applies every unit, dependencies first
+func apply(ctx context.Context, s sandbox) error {
+	order, err := unitOrder(s, false)
+	if err != nil {
+		return err
+	}
+	for _, unit := range order {
+		fmt.Fprintf(os.Stderr, "==> %s\n", unit)
+		dir := filepath.Join(s.Dir(), unit)
+		if err := terragrunt(ctx, dir, os.Stderr, "init"); err != nil {
+			return fmt.Errorf("failed to initialise %s: %w", unit, err)
+		}
+		if err := terragrunt(ctx, dir, os.Stderr, "apply", "-auto-approve"); err != nil {
+			return fmt.Errorf("failed to apply %s: %w", unit, err)
+		}
+	}
+	return nil
+}
+
+// destroy destroys every unit, dependents first, stopping at the first failure
+// so no unit is destroyed while something still depends on it
+func destroy(ctx context.Context, s sandbox) error {
+	order, err := unitOrder(s, true)
+	if err != nil {
+		return err
+	}
+	for _, unit := range order {
+		fmt.Fprintf(os.Stderr, "==> %s\n", unit)
+		if err := terragrunt(ctx, filepath.Join(s.Dir(), unit), os.Stderr, "destroy", "-auto-approve"); err != nil {
+			return fmt.Errorf("failed to destroy %s: %w", unit, err)
+		}
+	}
+	return nil
+}
+
+// unitOrder returns the sandbox's units in apply order, or destroy order when reverse is set
+func unitOrder(s sandbox, reverse bool) ([]string, error) {
+	graph, err := tggraph.Load(s.Dir())
+	if err != nil {
+		return nil, err
+	}
+	if reverse {
+		return graph.DestroyOrder()
+	}
+	return graph.ApplyOrder()
+}
+
+// output is one value from `terragrunt output -json`
+type output struct {
+	Value     json.RawMessage `json:"value"`
+	Sensitive bool            `json:"sensitive"`
+}
+
+// unitOutputs are the outputs of one unit
+type unitOutputs struct {
+	Unit    string
+	Outputs map[string]output
+}
+
+// collectOutputs reads the outputs of every unit in apply order
+func collectOutputs(ctx context.Context, s sandbox) ([]unitOutputs, error) {
+	order, err := unitOrder(s, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var collected []unitOutputs
+	for _, unit := range order {
+		var stdout bytes.Buffer
+		if err := terragrunt(ctx, filepath.Join(s.Dir(), unit), &stdout, "output", "-json"); err != nil {
+			return nil, fmt.Errorf("failed to read the outputs of %s: %w", unit, err)
+		}
+		outputs := map[string]output{}
+		if err := json.Unmarshal(stdout.Bytes(), &outputs); err != nil {
+			return nil, fmt.Errorf("failed to parse the outputs of %s: %w", unit, err)
+		}
+		collected = append(collected, unitOutputs{Unit: unit, Outputs: outputs})
+	}
+	return collected, nil
+}
+
+// writeOutputs prints each unit's outputs sorted by name, hiding sensitive values
+func writeOutputs(w io.Writer, units []unitOutputs) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, unit := range units {
+		fmt.Fprintf(tw, "%s\n", unit.Unit)
+
+		names := make([]string, 0, len(unit.Outputs))
+		for name := range unit.Outputs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			value := "(sensitive)"
+			if out := unit.Outputs[name]; !out.Sensitive {
+				var compact bytes.Buffer
+				if err := json.Compact(&compact, out.Value); err != nil {
+					return fmt.Errorf("output %s of %s: %w", name, unit.Unit, err)
+				}
+				value = compact.String()
+			}
+			fmt.Fprintf(tw, "  %s\t%s\n", name, value)
+		}
+	}
+	return tw.Flush()
+}
+
+// terragrunt runs terragrunt with args in dir, writing its output to stdout
+// and its diagnostics to stderr
+func terragrunt(ctx context.Context, dir string, stdout io.Writer, args ...string) error {
+	cmd := exec.CommandContext(ctx, "terragrunt", append(args, "--terragrunt-non-interactive")...)
+	cmd.Dir = dir
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,79 @@
+// =============================================================================
+// CloudTrail Posture
+// Multi-region trail logging to the audit bucket
+// =============================================================================
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+)
+
+// trailState is the part of a trail the check looks at
+type trailState struct {
+	arn         string
+	bucket      string
+	multiRegion bool
+	logging     bool
+}
+
+// checkCloudTrail requires a multi-region trail that is logging to the audit bucket
+func checkCloudTrail(ctx context.Context, cfg aws.Config, t target) ([]string, []string, error) {
+	client := cloudtrail.NewFromConfig(cfg)
+
+	output, err := client.DescribeTrails(ctx, &cloudtrail.DescribeTrailsInput{IncludeShadowTrails: aws.Bool(true)})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to describe trails: %w", err)
+	}
+
+	var trails []trailState
+	var resources []string
+	for _, trail := range output.TrailList {
+		arn := aws.ToString(trail.TrailARN)
+		status, err := client.GetTrailStatus(ctx, &cloudtrail.GetTrailStatusInput{Name: trail.TrailARN})
+		if err != nil {
+			return resources, nil, fmt.Errorf("failed to get the status of trail %s: %w", arn, err)
+		}
+
+		resources = append(resources, arn)
+		trails = append(trails, trailState{
+			arn:         arn,
+			bucket:      aws.ToString(trail.S3BucketName),
+			multiRegion: aws.ToBool(trail.IsMultiRegionTrail),
+			logging:     aws.ToBool(status.IsLogging),
+		})
+	}
+	return resources, trailProblems(trails, t.trailBucket), nil
+}
+
+// trailProblems explains why no trail satisfies the control, or returns nil if one does
+func trailProblems(trails []trailState, bucket string) []string {
+	if len(trails) == 0 {
+		return []string{"no CloudTrail trails exist"}
+	}
+
+	var problems []string
+	for _, trail := range trails {
+		var reasons []string
+		if !trail.multiRegion {
+			reasons = append(reasons, "is single-region")
+		}
+		if !trail.logging {
+			reasons = append(reasons, "is not logging")
+		}
+		if bucket != "" && trail.bucket != bucket {
+			reasons = append(reasons, fmt.Sprintf("delivers to %s, not %s", trail.bucket, bucket))
+		}
+		if len(reasons) == 0 {
+			return nil
+		}
+		for _, reason := range reasons {
+			problems = append(problems, fmt.Sprintf("trail %s %s", trail.arn, reason))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,61 @@
+// =============================================================================
+// Threat Detection Posture
+// GuardDuty detectors and the Security Hub subscription
+// =============================================================================
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/guardduty"
+	guarddutytypes "github.com/aws/aws-sdk-go-v2/service/guardduty/types"
+	"github.com/aws/aws-sdk-go-v2/service/securityhub"
+	"github.com/aws/smithy-go"
+)
+
+// checkGuardDuty requires an enabled detector in the scanned region
+func checkGuardDuty(ctx context.Context, cfg aws.Config, _ target) ([]string, []string, error) {
+	client := guardduty.NewFromConfig(cfg)
+
+	var detectorIDs []string
+	paginator := guardduty.NewListDetectorsPaginator(client, &guardduty.ListDetectorsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list GuardDuty detectors: %w", err)
+		}
+		detectorIDs = append(detectorIDs, page.DetectorIds...)
+	}
+	if len(detectorIDs) == 0 {
+		return nil, []string{fmt.Sprintf("no GuardDuty detector exists in %s", cfg.Region)}, nil
+	}
+
+	var problems []string
+	for _, id := range detectorIDs {
+		detector, err := client.GetDetector(ctx, &guardduty.GetDetectorInput{DetectorId: aws.String(id)})
+		if err != nil {
+			return detectorIDs, nil, fmt.Errorf("failed to get GuardDuty detector %s: %w", id, err)
+		}
+		if detector.Status != guarddutytypes.DetectorStatusEnabled {
+			problems = append(problems, fmt.Sprintf("detector %s is %s", id, detector.Status))
+		}
+	}
+	return detectorIDs, problems, nil
+}
+
+// checkSecurityHub requires the account to be subscribed to Security Hub
+func checkSecurityHub(ctx context.Context, cfg aws.Config, _ target) ([]string, []string, error) {
+	output, err := securityhub.NewFromConfig(cfg).DescribeHub(ctx, &securityhub.DescribeHubInput{})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidAccessException" {
+			return nil, []string{fmt.Sprintf("Security Hub is not enabled in %s", cfg.Region)}, nil
+		}
+		return nil, nil, fmt.Errorf("failed to describe Security Hub: %w", err)
+	}
+	return []string{aws.ToString(output.HubArn)}, nil, nil
+}
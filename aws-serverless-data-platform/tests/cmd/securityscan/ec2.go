@@ -0,0 +1,50 @@
+// =============================================================================
+// EC2 Posture
+// Default security groups of every VPC in the region
+// =============================================================================
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// checkDefaultSecurityGroups requires every default security group to be empty,
+// so resources launched without an explicit group get no network access
+func checkDefaultSecurityGroups(ctx context.Context, cfg aws.Config, _ target) ([]string, []string, error) {
+	var groups []ec2types.SecurityGroup
+	paginator := ec2.NewDescribeSecurityGroupsPaginator(ec2.NewFromConfig(cfg), &ec2.DescribeSecurityGroupsInput{
+		Filters: []ec2types.Filter{{Name: aws.String("group-name"), Values: []string{"default"}}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to describe default security groups: %w", err)
+		}
+		groups = append(groups, page.SecurityGroups...)
+	}
+
+	resources := make([]string, len(groups))
+	for i, group := range groups {
+		resources[i] = aws.ToString(group.GroupId)
+	}
+	return resources, defaultGroupProblems(groups), nil
+}
+
+// defaultGroupProblems lists the default groups that still have rules
+func defaultGroupProblems(groups []ec2types.SecurityGroup) []string {
+	var problems []string
+	for _, group := range groups {
+		ingress, egress := len(group.IpPermissions), len(group.IpPermissionsEgress)
+		if ingress > 0 || egress > 0 {
+			problems = append(problems, fmt.Sprintf("%s in %s has %d inbound and %d outbound rules",
+				aws.ToString(group.GroupId), aws.ToString(group.VpcId), ingress, egress))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,95 @@
+// =============================================================================
+// KMS Posture
+// Key policies of the data-lake encryption keys
+// =============================================================================
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/iampolicy"
+)
+
+// checkDataLakeKeys requires that no data-lake key policy grants access to
+// anyone outside the account without a condition
+func checkDataLakeKeys(ctx context.Context, cfg aws.Config, t target) ([]string, []string, error) {
+	client := kms.NewFromConfig(cfg)
+
+	keys := map[string]string{}
+	paginator := kms.NewListAliasesPaginator(client, &kms.ListAliasesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list KMS aliases: %w", err)
+		}
+		for _, alias := range page.Aliases {
+			name := aws.ToString(alias.AliasName)
+			if alias.TargetKeyId != nil && matchesAlias(t.keyAliases, name) {
+				keys[name] = aws.ToString(alias.TargetKeyId)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, []string{fmt.Sprintf("no KMS aliases match %v", t.keyAliases)}, nil
+	}
+
+	aliases := make([]string, 0, len(keys))
+	for alias := range keys {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	var problems []string
+	for _, alias := range aliases {
+		output, err := client.GetKeyPolicy(ctx, &kms.GetKeyPolicyInput{
+			KeyId:      aws.String(keys[alias]),
+			PolicyName: aws.String("default"),
+		})
+		if err != nil {
+			return aliases, nil, fmt.Errorf("failed to get the key policy of %s: %w", alias, err)
+		}
+
+		found, err := keyPolicyProblems(aws.ToString(output.Policy), t.accountID)
+		if err != nil {
+			return aliases, nil, fmt.Errorf("%s: %w", alias, err)
+		}
+		for _, problem := range found {
+			problems = append(problems, alias+": "+problem)
+		}
+	}
+	return aliases, problems, nil
+}
+
+// keyPolicyProblems reports statements that open the key to anonymous or
+// other-account principals without a condition
+func keyPolicyProblems(policy, accountID string) ([]string, error) {
+	doc, err := iampolicy.Parse(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, f := range iampolicy.Lint(doc, iampolicy.Options{AccountID: accountID, Skip: nonPublicRules}) {
+		problems = append(problems, f.String())
+	}
+	return problems, nil
+}
+
+// nonPublicRules are the built-in lint rules unrelated to public exposure
+var nonPublicRules = []string{"policy-structure", "no-wildcard-action", "no-wildcard-resource-write"}
+
+// matchesAlias reports whether name matches any of the alias patterns
+func matchesAlias(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+// =============================================================================
+// Security Scan CLI
+// Scores the account-wide security posture an environment relies on
+// =============================================================================
+
+// Command securityscan checks the account-level controls the data platform
+// depends on but does not deploy itself: the S3 account public access block,
+// CloudTrail, GuardDuty, Security Hub, empty default security groups and
+// non-public data-lake KMS keys. It writes a JSON report with a weighted score
+// and exits non-zero when the score is below -min-score.
+//
+// Usage:
+//
+//	go run ./cmd/securityscan -environment dev -trail-bucket org-cloudtrail-logs -output posture.json
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/testconfig"
+	"gopkg.in/yaml.v3"
+)
+
+// accountsFile maps each environment to its AWS account, relative to the repository root
+const accountsFile = "config/accounts.yaml"
+
+// defaultKeyAliases select the storage module's S3 key and the security module's
+// data and secrets keys; {env} is replaced with the environment name
+const defaultKeyAliases = "alias/s3-{env}-*,alias/*-data-key,alias/*-secrets-key"
+
+func main() {
+	environment := flag.String("environment", "dev", "environment whose account is scanned, as named in "+accountsFile)
+	region := flag.String("region", "", "AWS region to scan (default: the environment's region)")
+	roleARN := flag.String("role-arn", os.Getenv(awsclients.RoleARNEnvVar), "IAM role to assume before scanning")
+	externalID := flag.String("external-id", os.Getenv(awsclients.ExternalIDEnvVar), "external ID required by the role's trust policy")
+	trailBucket := flag.String("trail-bucket", "", "bucket CloudTrail must deliver to; empty accepts any bucket")
+	keyAliases := flag.String("key-aliases", defaultKeyAliases, "comma-separated alias patterns of the data-lake KMS keys")
+	output := flag.String("output", "-", "file to write the JSON report to, - for stdout")
+	minScore := flag.Int("min-score", 100, "lowest passing score")
+	timeout := flag.Duration("timeout", 10*time.Minute, "overall deadline for the scan")
+	flag.Parse()
+
+	t := target{
+		environment: *environment,
+		trailBucket: *trailBucket,
+		keyAliases:  strings.Split(strings.ReplaceAll(*keyAliases, "{env}", *environment), ","),
+	}
+
+	r, err := run(t, *region, *roleARN, *externalID, *timeout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	summarize(os.Stderr, r)
+	if err := write(*output, r); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if r.Score < *minScore {
+		os.Exit(2)
+	}
+}
+
+// run resolves the environment's account and scans it
+func run(t target, region, roleARN, externalID string, timeout time.Duration) (*report, error) {
+	expected, err := loadAccount(t.environment)
+	if err != nil {
+		return nil, err
+	}
+	if region == "" {
+		region = expected.Region
+	}
+	if region == "" {
+		region = testutil.DefaultRegion
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cfg, _, err := awsclients.LoadConfig(ctx,
+		awsclients.WithRegion(region),
+		awsclients.WithAssumeRole(roleARN),
+		awsclients.WithExternalID(externalID),
+		awsclients.WithSessionName("securityscan"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the scanned account: %w", err)
+	}
+	t.accountID = aws.ToString(identity.Account)
+
+	// Scanning the wrong account would produce a convincing but meaningless report
+	if expected.AccountID != "" && expected.AccountID != t.accountID {
+		return nil, fmt.Errorf("credentials are for account %s but %s is account %s", t.accountID, t.environment, expected.AccountID)
+	}
+
+	return scan(ctx, cfg, t, checks), nil
+}
+
+// account is an environment's entry in accountsFile
+type account struct {
+	AccountID string `yaml:"account_id"`
+	Region    string `yaml:"region"`
+}
+
+// loadAccount returns the environment's account; environments missing from
+// accountsFile, or a tree without one, get an empty account
+func loadAccount(environment string) (account, error) {
+	root, err := testconfig.FindRoot()
+	if err != nil || root == "" {
+		return account{}, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, accountsFile))
+	if os.IsNotExist(err) {
+		return account{}, nil
+	}
+	if err != nil {
+		return account{}, err
+	}
+
+	var accounts map[string]struct {
+		AWS account `yaml:"aws"`
+	}
+	if err := yaml.Unmarshal(data, &accounts); err != nil {
+		return account{}, fmt.Errorf("failed to parse %s: %w", accountsFile, err)
+	}
+	return accounts[environment].AWS, nil
+}
+
+// write writes the report to path, or stdout for "-"
+func write(path string, r *report) error {
+	var out io.Writer = os.Stdout
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	return writeJSON(out, r)
+}
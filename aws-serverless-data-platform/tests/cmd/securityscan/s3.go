@@ -0,0 +1,59 @@
+// =============================================================================
+// S3 Posture
+// Account-level S3 public access block
+// =============================================================================
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3control"
+	s3controltypes "github.com/aws/aws-sdk-go-v2/service/s3control/types"
+	"github.com/aws/smithy-go"
+)
+
+// checkAccountPublicAccessBlock requires all four account-level block settings
+func checkAccountPublicAccessBlock(ctx context.Context, cfg aws.Config, t target) ([]string, []string, error) {
+	resources := []string{"account/" + t.accountID}
+
+	output, err := s3control.NewFromConfig(cfg).GetPublicAccessBlock(ctx, &s3control.GetPublicAccessBlockInput{
+		AccountId: aws.String(t.accountID),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchPublicAccessBlockConfiguration" {
+			return resources, []string{"no account-level public access block is configured"}, nil
+		}
+		return resources, nil, fmt.Errorf("failed to get the account public access block: %w", err)
+	}
+	return resources, publicAccessBlockProblems(output.PublicAccessBlockConfiguration), nil
+}
+
+// publicAccessBlockProblems lists the settings that are not enabled
+func publicAccessBlockProblems(block *s3controltypes.PublicAccessBlockConfiguration) []string {
+	if block == nil {
+		return []string{"no account-level public access block is configured"}
+	}
+
+	settings := []struct {
+		name    string
+		enabled *bool
+	}{
+		{"BlockPublicAcls", block.BlockPublicAcls},
+		{"IgnorePublicAcls", block.IgnorePublicAcls},
+		{"BlockPublicPolicy", block.BlockPublicPolicy},
+		{"RestrictPublicBuckets", block.RestrictPublicBuckets},
+	}
+
+	var problems []string
+	for _, setting := range settings {
+		if !aws.ToBool(setting.enabled) {
+			problems = append(problems, setting.name+" is disabled")
+		}
+	}
+	return problems
+}
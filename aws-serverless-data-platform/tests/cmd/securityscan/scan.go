@@ -0,0 +1,172 @@
+// =============================================================================
+// Security Posture Scan
+// Check registry, scoring and the JSON report
+// =============================================================================
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// severity weighs a check in the posture score
+type severity string
+
+const (
+	severityCritical severity = "critical"
+	severityHigh     severity = "high"
+	severityMedium   severity = "medium"
+	severityLow      severity = "low"
+)
+
+// weights is each severity's share of the score
+var weights = map[severity]int{
+	severityCritical: 10,
+	severityHigh:     5,
+	severityMedium:   3,
+	severityLow:      1,
+}
+
+// status is the outcome of one check
+type status string
+
+const (
+	statusPass  status = "pass"
+	statusFail  status = "fail"
+	statusError status = "error"
+)
+
+// target is what the scan is run against
+type target struct {
+	environment string
+	accountID   string
+
+	// trailBucket is the bucket CloudTrail must deliver to; empty accepts any bucket
+	trailBucket string
+
+	// keyAliases are path.Match patterns selecting the data-lake KMS keys
+	keyAliases []string
+}
+
+// check is one posture control; run returns the resources it examined and
+// what is wrong with them
+type check struct {
+	id       string
+	title    string
+	severity severity
+	run      func(ctx context.Context, cfg aws.Config, t target) (resources, problems []string, err error)
+}
+
+// checks are run in order and appear in the report in the same order
+var checks = []check{
+	{"S3.1", "S3 account-level public access block is fully enabled", severityCritical, checkAccountPublicAccessBlock},
+	{"CT.1", "A multi-region CloudTrail trail is logging to the audit bucket", severityHigh, checkCloudTrail},
+	{"GD.1", "GuardDuty is enabled", severityHigh, checkGuardDuty},
+	{"SH.1", "Security Hub is enabled", severityMedium, checkSecurityHub},
+	{"EC2.1", "Default security groups have no rules", severityHigh, checkDefaultSecurityGroups},
+	{"KMS.1", "Data-lake KMS keys are not public", severityCritical, checkDataLakeKeys},
+}
+
+// finding is the outcome of one check in the report
+type finding struct {
+	ID        string   `json:"id"`
+	Title     string   `json:"title"`
+	Severity  severity `json:"severity"`
+	Status    status   `json:"status"`
+	Resources []string `json:"resources"`
+	Problems  []string `json:"problems,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// report is the scored result of a scan
+type report struct {
+	Environment string    `json:"environment"`
+	AccountID   string    `json:"account_id"`
+	Region      string    `json:"region"`
+	GeneratedAt time.Time `json:"generated_at"`
+
+	// Score is the weighted percentage of checks that passed; errors count as failures
+	Score    int       `json:"score"`
+	Findings []finding `json:"findings"`
+}
+
+// scan runs every check; a check that errors is reported rather than stopping the scan
+func scan(ctx context.Context, cfg aws.Config, t target, checks []check) *report {
+	r := &report{
+		Environment: t.environment,
+		AccountID:   t.accountID,
+		Region:      cfg.Region,
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	for _, c := range checks {
+		resources, problems, err := c.run(ctx, cfg, t)
+
+		f := finding{
+			ID:        c.id,
+			Title:     c.title,
+			Severity:  c.severity,
+			Status:    statusPass,
+			Resources: append([]string{}, resources...),
+			Problems:  problems,
+		}
+		switch {
+		case err != nil:
+			f.Status = statusError
+			f.Error = err.Error()
+		case len(problems) > 0:
+			f.Status = statusFail
+		}
+		r.Findings = append(r.Findings, f)
+	}
+
+	r.Score = score(r.Findings)
+	return r
+}
+
+// score returns the weighted percentage of passing findings
+func score(findings []finding) int {
+	var passed, total int
+	for _, f := range findings {
+		total += weights[f.Severity]
+		if f.Status == statusPass {
+			passed += weights[f.Severity]
+		}
+	}
+	if total == 0 {
+		return 100
+	}
+	return int(math.Round(100 * float64(passed) / float64(total)))
+}
+
+// summarize writes one line per finding and the score
+func summarize(out io.Writer, r *report) {
+	for _, f := range r.Findings {
+		switch f.Status {
+		case statusPass:
+			fmt.Fprintf(out, "✅ %-6s %s\n", f.ID, f.Title)
+		case statusFail:
+			fmt.Fprintf(out, "❌ %-6s %s\n", f.ID, f.Title)
+			for _, problem := range f.Problems {
+				fmt.Fprintf(out, "          %s\n", problem)
+			}
+		case statusError:
+			fmt.Fprintf(out, "⚠️  %-6s %s: %s\n", f.ID, f.Title, f.Error)
+		}
+	}
+	fmt.Fprintf(out, "Posture score for %s (%s): %d/100\n", r.Environment, r.AccountID, r.Score)
+}
+
+// writeJSON writes the report as indented JSON
+func writeJSON(w io.Writer, r *report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
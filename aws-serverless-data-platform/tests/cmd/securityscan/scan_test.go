@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	s3controltypes "github.com/aws/aws-sdk-go-v2/service/s3control/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanScoresBySeverity(t *testing.T) {
+	result := func(problems []string, err error) func(context.Context, aws.Config, target) ([]string, []string, error) {
+		return func(context.Context, aws.Config, target) ([]string, []string, error) {
+			return []string{"resource"}, problems, err
+		}
+	}
+
+	r := scan(context.Background(), aws.Config{Region: "us-east-1"}, target{environment: "dev"}, []check{
+		{"A", "passes", severityCritical, result(nil, nil)},
+		{"B", "fails", severityHigh, result([]string{"broken"}, nil)},
+		{"C", "errors", severityMedium, result(nil, errors.New("access denied"))},
+		{"D", "passes", severityLow, result(nil, nil)},
+	})
+
+	require.Len(t, r.Findings, 4)
+	assert.Equal(t, statusPass, r.Findings[0].Status)
+	assert.Equal(t, statusFail, r.Findings[1].Status)
+	assert.Equal(t, statusError, r.Findings[2].Status)
+	assert.Equal(t, "access denied", r.Findings[2].Error)
+	assert.Equal(t, 58, r.Score, "11 of 19 weighted points pass")
+}
+
+func TestScoreWithoutFindings(t *testing.T) {
+	assert.Equal(t, 100, score(nil))
+}
+
+func TestPublicAccessBlockProblems(t *testing.T) {
+	assert.Empty(t, publicAccessBlockProblems(&s3controltypes.PublicAccessBlockConfiguration{
+		BlockPublicAcls:       aws.Bool(true),
+		IgnorePublicAcls:      aws.Bool(true),
+		BlockPublicPolicy:     aws.Bool(true),
+		RestrictPublicBuckets: aws.Bool(true),
+	}))
+
+	problems := publicAccessBlockProblems(&s3controltypes.PublicAccessBlockConfiguration{
+		BlockPublicAcls:   aws.Bool(true),
+		IgnorePublicAcls:  aws.Bool(true),
+		BlockPublicPolicy: aws.Bool(false),
+	})
+	assert.Equal(t, []string{"BlockPublicPolicy is disabled", "RestrictPublicBuckets is disabled"}, problems)
+	assert.Len(t, publicAccessBlockProblems(nil), 1)
+}
+
+func TestTrailProblems(t *testing.T) {
+	good := trailState{arn: "org-trail", bucket: "audit-logs", multiRegion: true, logging: true}
+	regional := trailState{arn: "regional", bucket: "audit-logs", logging: true}
+
+	assert.Empty(t, trailProblems([]trailState{regional, good}, "audit-logs"), "One compliant trail is enough")
+	assert.Empty(t, trailProblems([]trailState{good}, ""), "Any bucket is accepted without -trail-bucket")
+	assert.Equal(t, []string{"trail regional is single-region"}, trailProblems([]trailState{regional}, ""))
+	assert.Equal(t, []string{"trail org-trail delivers to audit-logs, not other-bucket"},
+		trailProblems([]trailState{good}, "other-bucket"))
+	assert.Equal(t, []string{"no CloudTrail trails exist"}, trailProblems(nil, ""))
+}
+
+func TestDefaultGroupProblems(t *testing.T) {
+	groups := []ec2types.SecurityGroup{
+		{GroupId: aws.String("sg-empty"), VpcId: aws.String("vpc-1")},
+		{
+			GroupId:             aws.String("sg-open"),
+			VpcId:               aws.String("vpc-2"),
+			IpPermissions:       []ec2types.IpPermission{{IpProtocol: aws.String("-1")}},
+			IpPermissionsEgress: []ec2types.IpPermission{{IpProtocol: aws.String("-1")}},
+		},
+	}
+
+	assert.Equal(t, []string{"sg-open in vpc-2 has 1 inbound and 1 outbound rules"}, defaultGroupProblems(groups))
+}
+
+func TestKeyPolicyProblems(t *testing.T) {
+	private := `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111111111111:root"},"Action":"kms:*","Resource":"*"},
+		{"Effect":"Allow","Principal":{"Service":"s3.amazonaws.com"},"Action":"kms:Decrypt","Resource":"*"}]}`
+	problems, err := keyPolicyProblems(private, "111111111111")
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	public := `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Principal":"*","Action":"kms:Decrypt","Resource":"*"},
+		{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::222222222222:root"},"Action":"kms:Decrypt","Resource":"*"},
+		{"Effect":"Allow","Principal":"*","Action":"kms:Decrypt","Resource":"*",
+		 "Condition":{"StringEquals":{"kms:CallerAccount":"111111111111"}}}]}`
+	problems, err = keyPolicyProblems(public, "111111111111")
+	require.NoError(t, err)
+	assert.Len(t, problems, 2, "Anonymous and cross-account grants without a condition: %v", problems)
+}
+
+func TestMatchesAlias(t *testing.T) {
+	patterns := []string{"alias/s3-dev-*", "alias/*-data-key"}
+
+	assert.True(t, matchesAlias(patterns, "alias/s3-dev-ap-southeast-1"))
+	assert.True(t, matchesAlias(patterns, "alias/data-platform-data-key"))
+	assert.False(t, matchesAlias(patterns, "alias/s3-prod-ap-southeast-1"))
+	assert.False(t, matchesAlias(patterns, "alias/aws/s3"))
+}
@@ -0,0 +1,294 @@
+// =============================================================================
+// VPC Sweeper
+// VPCs and their dependencies left behind by the networking module tests
+// =============================================================================
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// natGatewayDeleteTimeout bounds the wait for NAT gateways to release their addresses
+const natGatewayDeleteTimeout = 10 * time.Minute
+
+// vpcEndpointDeleteTimeout bounds the wait for interface endpoints to release their subnet interfaces
+const vpcEndpointDeleteTimeout = 5 * time.Minute
+
+// scanVPCs finds orphaned non-default VPCs
+func scanVPCs(ctx context.Context, cfg aws.Config, m matcher) ([]resource, error) {
+	client := ec2.NewFromConfig(cfg)
+
+	var resources []resource
+	paginator := ec2.NewDescribeVpcsPaginator(client, &ec2.DescribeVpcsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list VPCs: %w", err)
+		}
+
+		for _, vpc := range page.Vpcs {
+			if aws.ToBool(vpc.IsDefault) {
+				continue
+			}
+			vpcID := aws.ToString(vpc.VpcId)
+			tags := ec2Tags(vpc.Tags)
+
+			reason := m.reason(tags["Name"], tags)
+			if reason == "" {
+				continue
+			}
+
+			created, err := vpcCreated(ctx, client, vpcID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to date VPC %s: %w", vpcID, err)
+			}
+
+			resources = append(resources, resource{
+				kind:    kindVPC,
+				region:  cfg.Region,
+				id:      fmt.Sprintf("%s (%s)", vpcID, tags["Name"]),
+				reason:  reason,
+				created: created,
+				delete: func(ctx context.Context) error {
+					return deleteVPC(ctx, client, vpcID)
+				},
+			})
+		}
+	}
+	return resources, nil
+}
+
+// vpcCreated approximates when the VPC was created, since EC2 does not report it,
+// by the oldest of its flow logs and NAT gateways; zero when it has neither
+func vpcCreated(ctx context.Context, client *ec2.Client, vpcID string) (time.Time, error) {
+	var created time.Time
+	oldest := func(t *time.Time) {
+		if t != nil && (created.IsZero() || t.Before(created)) {
+			created = *t
+		}
+	}
+
+	flowLogs, err := client.DescribeFlowLogs(ctx, &ec2.DescribeFlowLogsInput{
+		Filter: []ec2types.Filter{{Name: aws.String("resource-id"), Values: []string{vpcID}}},
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, flowLog := range flowLogs.FlowLogs {
+		oldest(flowLog.CreationTime)
+	}
+
+	natGateways, err := client.DescribeNatGateways(ctx, &ec2.DescribeNatGatewaysInput{
+		Filter: []ec2types.Filter{{Name: aws.String("vpc-id"), Values: []string{vpcID}}},
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, natGateway := range natGateways.NatGateways {
+		oldest(natGateway.CreateTime)
+	}
+	return created, nil
+}
+
+// deleteVPC removes the VPC's flow logs, endpoints, NAT gateways, internet gateways,
+// subnets, route tables and security groups before deleting the VPC itself
+func deleteVPC(ctx context.Context, client *ec2.Client, vpcID string) error {
+	vpcFilter := []ec2types.Filter{{Name: aws.String("vpc-id"), Values: []string{vpcID}}}
+
+	flowLogs, err := client.DescribeFlowLogs(ctx, &ec2.DescribeFlowLogsInput{
+		Filter: []ec2types.Filter{{Name: aws.String("resource-id"), Values: []string{vpcID}}},
+	})
+	if err != nil {
+		return err
+	}
+	var flowLogIDs []string
+	for _, flowLog := range flowLogs.FlowLogs {
+		flowLogIDs = append(flowLogIDs, aws.ToString(flowLog.FlowLogId))
+	}
+	if len(flowLogIDs) > 0 {
+		if _, err := client.DeleteFlowLogs(ctx, &ec2.DeleteFlowLogsInput{FlowLogIds: flowLogIDs}); err != nil {
+			return err
+		}
+	}
+
+	if err := deleteVPCEndpoints(ctx, client, vpcFilter); err != nil {
+		return err
+	}
+
+	// NAT gateways hold Elastic IPs and subnet interfaces until fully deleted
+	natGateways, err := client.DescribeNatGateways(ctx, &ec2.DescribeNatGatewaysInput{
+		Filter: append(vpcFilter, ec2types.Filter{Name: aws.String("state"), Values: []string{"pending", "available"}}),
+	})
+	if err != nil {
+		return err
+	}
+	var natGatewayIDs, allocationIDs []string
+	for _, natGateway := range natGateways.NatGateways {
+		natGatewayIDs = append(natGatewayIDs, aws.ToString(natGateway.NatGatewayId))
+		for _, address := range natGateway.NatGatewayAddresses {
+			if address.AllocationId != nil {
+				allocationIDs = append(allocationIDs, aws.ToString(address.AllocationId))
+			}
+		}
+		if _, err := client.DeleteNatGateway(ctx, &ec2.DeleteNatGatewayInput{NatGatewayId: natGateway.NatGatewayId}); err != nil {
+			return err
+		}
+	}
+	if len(natGatewayIDs) > 0 {
+		waiter := ec2.NewNatGatewayDeletedWaiter(client)
+		if err := waiter.Wait(ctx, &ec2.DescribeNatGatewaysInput{NatGatewayIds: natGatewayIDs}, natGatewayDeleteTimeout); err != nil {
+			return err
+		}
+	}
+	for _, allocationID := range allocationIDs {
+		if _, err := client.ReleaseAddress(ctx, &ec2.ReleaseAddressInput{AllocationId: aws.String(allocationID)}); err != nil {
+			return err
+		}
+	}
+
+	internetGateways, err := client.DescribeInternetGateways(ctx, &ec2.DescribeInternetGatewaysInput{
+		Filters: []ec2types.Filter{{Name: aws.String("attachment.vpc-id"), Values: []string{vpcID}}},
+	})
+	if err != nil {
+		return err
+	}
+	for _, internetGateway := range internetGateways.InternetGateways {
+		if _, err := client.DetachInternetGateway(ctx, &ec2.DetachInternetGatewayInput{
+			InternetGatewayId: internetGateway.InternetGatewayId,
+			VpcId:             aws.String(vpcID),
+		}); err != nil {
+			return err
+		}
+		if _, err := client.DeleteInternetGateway(ctx, &ec2.DeleteInternetGatewayInput{
+			InternetGatewayId: internetGateway.InternetGatewayId,
+		}); err != nil {
+			return err
+		}
+	}
+
+	subnets, err := client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{Filters: vpcFilter})
+	if err != nil {
+		return err
+	}
+	for _, subnet := range subnets.Subnets {
+		if _, err := client.DeleteSubnet(ctx, &ec2.DeleteSubnetInput{SubnetId: subnet.SubnetId}); err != nil {
+			return err
+		}
+	}
+
+	routeTables, err := client.DescribeRouteTables(ctx, &ec2.DescribeRouteTablesInput{Filters: vpcFilter})
+	if err != nil {
+		return err
+	}
+	for _, routeTable := range routeTables.RouteTables {
+		if isMainRouteTable(routeTable) {
+			continue
+		}
+		if _, err := client.DeleteRouteTable(ctx, &ec2.DeleteRouteTableInput{RouteTableId: routeTable.RouteTableId}); err != nil {
+			return err
+		}
+	}
+
+	// Rules are revoked first so groups that reference each other can be deleted
+	securityGroups, err := client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{Filters: vpcFilter})
+	if err != nil {
+		return err
+	}
+	var groupIDs []string
+	for _, group := range securityGroups.SecurityGroups {
+		if aws.ToString(group.GroupName) == "default" {
+			continue
+		}
+		groupIDs = append(groupIDs, aws.ToString(group.GroupId))
+		if len(group.IpPermissions) > 0 {
+			if _, err := client.RevokeSecurityGroupIngress(ctx, &ec2.RevokeSecurityGroupIngressInput{
+				GroupId:       group.GroupId,
+				IpPermissions: group.IpPermissions,
+			}); err != nil {
+				return err
+			}
+		}
+		if len(group.IpPermissionsEgress) > 0 {
+			if _, err := client.RevokeSecurityGroupEgress(ctx, &ec2.RevokeSecurityGroupEgressInput{
+				GroupId:       group.GroupId,
+				IpPermissions: group.IpPermissionsEgress,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	for _, groupID := range groupIDs {
+		if _, err := client.DeleteSecurityGroup(ctx, &ec2.DeleteSecurityGroupInput{GroupId: aws.String(groupID)}); err != nil {
+			return err
+		}
+	}
+
+	_, err = client.DeleteVpc(ctx, &ec2.DeleteVpcInput{VpcId: aws.String(vpcID)})
+	return err
+}
+
+// deleteVPCEndpoints deletes the VPC's endpoints and waits until their interfaces are gone
+func deleteVPCEndpoints(ctx context.Context, client *ec2.Client, vpcFilter []ec2types.Filter) error {
+	deadline := time.Now().Add(vpcEndpointDeleteTimeout)
+	requested := false
+
+	for {
+		endpoints, err := client.DescribeVpcEndpoints(ctx, &ec2.DescribeVpcEndpointsInput{Filters: vpcFilter})
+		if err != nil {
+			return err
+		}
+		var endpointIDs []string
+		for _, endpoint := range endpoints.VpcEndpoints {
+			if !strings.EqualFold(string(endpoint.State), "deleted") {
+				endpointIDs = append(endpointIDs, aws.ToString(endpoint.VpcEndpointId))
+			}
+		}
+		if len(endpointIDs) == 0 {
+			return nil
+		}
+
+		if !requested {
+			if _, err := client.DeleteVpcEndpoints(ctx, &ec2.DeleteVpcEndpointsInput{VpcEndpointIds: endpointIDs}); err != nil {
+				return err
+			}
+			requested = true
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("VPC endpoints %s still deleting after %s", strings.Join(endpointIDs, ", "), vpcEndpointDeleteTimeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+// isMainRouteTable reports whether the route table is the VPC's main table,
+// which is deleted together with the VPC
+func isMainRouteTable(routeTable ec2types.RouteTable) bool {
+	for _, association := range routeTable.Associations {
+		if aws.ToBool(association.Main) {
+			return true
+		}
+	}
+	return false
+}
+
+// ec2Tags converts EC2 tags to a map
+func ec2Tags(tags []ec2types.Tag) map[string]string {
+	result := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		result[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return result
+}
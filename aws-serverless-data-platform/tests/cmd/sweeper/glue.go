@@ -0,0 +1,63 @@
+// =============================================================================
+// Glue Sweeper
+// Glue Data Catalog databases left behind by the storage module tests
+// =============================================================================
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// scanGlueDatabases finds orphaned Glue databases; deleting a database also deletes its tables
+func scanGlueDatabases(ctx context.Context, cfg aws.Config, m matcher) ([]resource, error) {
+	client := glue.NewFromConfig(cfg)
+
+	identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get caller identity: %w", err)
+	}
+
+	var resources []resource
+	paginator := glue.NewGetDatabasesPaginator(client, &glue.GetDatabasesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Glue databases: %w", err)
+		}
+
+		for _, database := range page.DatabaseList {
+			name := aws.ToString(database.Name)
+
+			databaseArn := fmt.Sprintf("arn:aws:glue:%s:%s:database/%s",
+				cfg.Region, aws.ToString(identity.Account), name)
+			tags, err := client.GetTags(ctx, &glue.GetTagsInput{ResourceArn: aws.String(databaseArn)})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get tags for Glue database %s: %w", name, err)
+			}
+
+			reason := m.reason(name, tags.Tags)
+			if reason == "" {
+				continue
+			}
+
+			resources = append(resources, resource{
+				kind:    kindGlueDatabase,
+				region:  cfg.Region,
+				id:      name,
+				reason:  reason,
+				created: aws.ToTime(database.CreateTime),
+				delete: func(ctx context.Context) error {
+					_, err := client.DeleteDatabase(ctx, &glue.DeleteDatabaseInput{Name: aws.String(name)})
+					return err
+				},
+			})
+		}
+	}
+	return resources, nil
+}
@@ -0,0 +1,220 @@
+// =============================================================================
+// IAM Sweeper
+// IAM roles and customer managed policies left behind by the security tests
+// =============================================================================
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+// serviceLinkedRolePath is owned by AWS and never swept
+const serviceLinkedRolePath = "/aws-service-role/"
+
+// scanIAMRoles finds orphaned IAM roles
+func scanIAMRoles(ctx context.Context, cfg aws.Config, m matcher) ([]resource, error) {
+	client := iam.NewFromConfig(cfg)
+
+	var resources []resource
+	paginator := iam.NewListRolesPaginator(client, &iam.ListRolesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list IAM roles: %w", err)
+		}
+
+		for _, role := range page.Roles {
+			if strings.HasPrefix(aws.ToString(role.Path), serviceLinkedRolePath) {
+				continue
+			}
+			name := aws.ToString(role.RoleName)
+
+			tags, err := client.ListRoleTags(ctx, &iam.ListRoleTagsInput{RoleName: aws.String(name)})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get tags for IAM role %s: %w", name, err)
+			}
+
+			reason := m.reason(name, iamTags(tags.Tags))
+			if reason == "" {
+				continue
+			}
+
+			resources = append(resources, resource{
+				kind:    kindIAMRole,
+				region:  globalRegion,
+				id:      name,
+				reason:  reason,
+				created: aws.ToTime(role.CreateDate),
+				delete: func(ctx context.Context) error {
+					return deleteIAMRole(ctx, client, name)
+				},
+			})
+		}
+	}
+	return resources, nil
+}
+
+// deleteIAMRole detaches policies and instance profiles before deleting the role
+func deleteIAMRole(ctx context.Context, client *iam.Client, name string) error {
+	attached := iam.NewListAttachedRolePoliciesPaginator(client, &iam.ListAttachedRolePoliciesInput{RoleName: aws.String(name)})
+	for attached.HasMorePages() {
+		page, err := attached.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, policy := range page.AttachedPolicies {
+			if _, err := client.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
+				RoleName:  aws.String(name),
+				PolicyArn: policy.PolicyArn,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	inline := iam.NewListRolePoliciesPaginator(client, &iam.ListRolePoliciesInput{RoleName: aws.String(name)})
+	for inline.HasMorePages() {
+		page, err := inline.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, policyName := range page.PolicyNames {
+			if _, err := client.DeleteRolePolicy(ctx, &iam.DeleteRolePolicyInput{
+				RoleName:   aws.String(name),
+				PolicyName: aws.String(policyName),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	profiles := iam.NewListInstanceProfilesForRolePaginator(client, &iam.ListInstanceProfilesForRoleInput{RoleName: aws.String(name)})
+	for profiles.HasMorePages() {
+		page, err := profiles.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, profile := range page.InstanceProfiles {
+			if _, err := client.RemoveRoleFromInstanceProfile(ctx, &iam.RemoveRoleFromInstanceProfileInput{
+				RoleName:            aws.String(name),
+				InstanceProfileName: profile.InstanceProfileName,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := client.DeleteRole(ctx, &iam.DeleteRoleInput{RoleName: aws.String(name)})
+	return err
+}
+
+// scanIAMPolicies finds orphaned customer managed IAM policies
+func scanIAMPolicies(ctx context.Context, cfg aws.Config, m matcher) ([]resource, error) {
+	client := iam.NewFromConfig(cfg)
+
+	var resources []resource
+	paginator := iam.NewListPoliciesPaginator(client, &iam.ListPoliciesInput{Scope: iamtypes.PolicyScopeTypeLocal})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list IAM policies: %w", err)
+		}
+
+		for _, policy := range page.Policies {
+			name := aws.ToString(policy.PolicyName)
+			policyArn := aws.ToString(policy.Arn)
+
+			tags, err := client.ListPolicyTags(ctx, &iam.ListPolicyTagsInput{PolicyArn: aws.String(policyArn)})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get tags for IAM policy %s: %w", name, err)
+			}
+
+			reason := m.reason(name, iamTags(tags.Tags))
+			if reason == "" {
+				continue
+			}
+
+			resources = append(resources, resource{
+				kind:    kindIAMPolicy,
+				region:  globalRegion,
+				id:      policyArn,
+				reason:  reason,
+				created: aws.ToTime(policy.CreateDate),
+				delete: func(ctx context.Context) error {
+					return deleteIAMPolicy(ctx, client, policyArn)
+				},
+			})
+		}
+	}
+	return resources, nil
+}
+
+// deleteIAMPolicy detaches the policy everywhere and removes old versions before deleting it
+func deleteIAMPolicy(ctx context.Context, client *iam.Client, policyArn string) error {
+	entities := iam.NewListEntitiesForPolicyPaginator(client, &iam.ListEntitiesForPolicyInput{PolicyArn: aws.String(policyArn)})
+	for entities.HasMorePages() {
+		page, err := entities.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, role := range page.PolicyRoles {
+			if _, err := client.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
+				RoleName:  role.RoleName,
+				PolicyArn: aws.String(policyArn),
+			}); err != nil {
+				return err
+			}
+		}
+		for _, user := range page.PolicyUsers {
+			if _, err := client.DetachUserPolicy(ctx, &iam.DetachUserPolicyInput{
+				UserName:  user.UserName,
+				PolicyArn: aws.String(policyArn),
+			}); err != nil {
+				return err
+			}
+		}
+		for _, group := range page.PolicyGroups {
+			if _, err := client.DetachGroupPolicy(ctx, &iam.DetachGroupPolicyInput{
+				GroupName: group.GroupName,
+				PolicyArn: aws.String(policyArn),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	versions, err := client.ListPolicyVersions(ctx, &iam.ListPolicyVersionsInput{PolicyArn: aws.String(policyArn)})
+	if err != nil {
+		return err
+	}
+	for _, version := range versions.Versions {
+		if version.IsDefaultVersion {
+			continue
+		}
+		if _, err := client.DeletePolicyVersion(ctx, &iam.DeletePolicyVersionInput{
+			PolicyArn: aws.String(policyArn),
+			VersionId: version.VersionId,
+		}); err != nil {
+			return err
+		}
+	}
+
+	_, err = client.DeletePolicy(ctx, &iam.DeletePolicyInput{PolicyArn: aws.String(policyArn)})
+	return err
+}
+
+// iamTags converts IAM tags to a map
+func iamTags(tags []iamtypes.Tag) map[string]string {
+	result := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		result[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return result
+}
@@ -0,0 +1,175 @@
+// =============================================================================
+// Sweeper CLI
+// Finds and deletes resources orphaned by failed test runs
+// =============================================================================
+
+// Command sweeper removes VPCs, S3 buckets, IAM roles and policies, and Glue
+// databases that were left behind by failed test runs. A resource is swept
+// when it is tagged Testing=true or its name matches *-test-*, unless it is
+// tagged TestBaseStack=true as part of the long-lived test base stack.
+//
+// With -run-prefix or -manifest only the resources of those test runs are
+// swept, so one engineer's cleanup leaves a colleague's concurrent run alone.
+// A run's resources carry its prefix in the TestRunPrefix tag, and its
+// manifest in .test-runs lists the prefixes used by each suite. The stacks of
+// tests kept with KEEP_ON_FAILURE=true are listed before the run is swept.
+//
+// Resources created less than -min-age ago are left alone, since they may
+// belong to a run that is still in progress; pass -min-age 0 to sweep a run
+// known to have finished. IAM is global, so roles and policies are swept once
+// however many regions -region lists.
+//
+// Usage:
+//
+//	go run ./cmd/sweeper -region us-east-1,ap-southeast-1 -dry-run
+//	go run ./cmd/sweeper -manifest ../.test-runs/k3x9qa.jsonl -min-age 0
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/identity"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/runprefix"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/testconfig"
+)
+
+// scanner lists the orphaned resources of one kind
+type scanner func(ctx context.Context, cfg aws.Config, m matcher) ([]resource, error)
+
+// regionalScanners list resources in the region of the config they are given
+var regionalScanners = []scanner{
+	scanGlueDatabases,
+	scanS3Buckets,
+	scanVPCs,
+}
+
+// globalScanners list resources shared by every region, and run once per sweep
+var globalScanners = []scanner{
+	scanIAMRoles,
+	scanIAMPolicies,
+}
+
+func main() {
+	regions := flag.String("region", testutil.DefaultRegion, "comma-separated AWS regions to sweep")
+	roleARN := flag.String("role-arn", os.Getenv(awsclients.RoleARNEnvVar), "IAM role to assume before sweeping")
+	externalID := flag.String("external-id", os.Getenv(awsclients.ExternalIDEnvVar), "external ID required by the role's trust policy")
+	dryRun := flag.Bool("dry-run", false, "report orphaned resources without deleting them")
+	timeout := flag.Duration("timeout", 30*time.Minute, "overall deadline for the sweep")
+	minAge := flag.Duration("min-age", 3*time.Hour, "leave resources created more recently than this; 0 sweeps them all")
+	runPrefixes := flag.String("run-prefix", "", "comma-separated test run prefixes to sweep; empty sweeps every run")
+	manifest := flag.String("manifest", "", "run manifest whose prefixes are swept, added to -run-prefix")
+	flag.Parse()
+
+	m, err := newMatcher(*runPrefixes, *manifest)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *manifest != "" {
+		if err := reportKept(os.Stdout, *manifest); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	if err := run(splitRegions(*regions), *roleARN, *externalID, *dryRun, *timeout, *minAge, m); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// newMatcher returns the matcher for the prefixes in runPrefixes and manifest
+func newMatcher(runPrefixes, manifest string) (matcher, error) {
+	var m matcher
+	for _, prefix := range strings.Split(runPrefixes, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix == "" {
+			continue
+		}
+		if err := runprefix.Validate(prefix); err != nil {
+			return matcher{}, err
+		}
+		m.runPrefixes = append(m.runPrefixes, prefix)
+	}
+
+	if manifest != "" {
+		entries, err := runprefix.ReadManifest(manifest)
+		if err != nil {
+			return matcher{}, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		if len(entries) == 0 {
+			return matcher{}, fmt.Errorf("manifest %s records no test runs", manifest)
+		}
+		m.runPrefixes = append(m.runPrefixes, runprefix.Prefixes(entries)...)
+	}
+	return m, nil
+}
+
+// splitRegions returns the regions in a comma-separated list, without duplicates
+func splitRegions(list string) []string {
+	var regions []string
+	for _, region := range strings.Split(list, ",") {
+		if region = strings.TrimSpace(region); region != "" && !slices.Contains(regions, region) {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// run scans every region, and IAM once, then sweeps what it finds
+func run(regions []string, roleARN, externalID string, dryRun bool, timeout, minAge time.Duration, m matcher) error {
+	if len(regions) == 0 {
+		return fmt.Errorf("no region to sweep")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var resources []resource
+	for i, region := range regions {
+		cfg, _, err := awsclients.LoadConfig(ctx,
+			awsclients.WithRegion(region),
+			awsclients.WithAssumeRole(roleARN),
+			awsclients.WithExternalID(externalID),
+			awsclients.WithSessionName("sweeper"),
+		)
+		if err != nil {
+			return err
+		}
+
+		scanners := regionalScanners
+		if i == 0 {
+			// Deleting needs the same credential hygiene as the suites whose leftovers are swept
+			if !dryRun {
+				config, err := testconfig.Load()
+				if err != nil {
+					return err
+				}
+				if err := identity.Verify(ctx, cfg, config); err != nil {
+					return err
+				}
+			}
+			scanners = append(slices.Clone(globalScanners), regionalScanners...)
+		}
+
+		for _, scan := range scanners {
+			found, err := scan(ctx, cfg, m)
+			if err != nil {
+				return err
+			}
+			resources = append(resources, found...)
+		}
+	}
+
+	resources = filterByAge(os.Stdout, resources, minAge, time.Now())
+	return sweep(ctx, os.Stdout, resources, dryRun)
+}
@@ -0,0 +1,112 @@
+// =============================================================================
+// S3 Sweeper
+// Data lake buckets left behind by the storage module tests
+// =============================================================================
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// scanS3Buckets finds orphaned buckets in the configured region
+func scanS3Buckets(ctx context.Context, cfg aws.Config, m matcher) ([]resource, error) {
+	client := s3.NewFromConfig(cfg)
+
+	var resources []resource
+	paginator := s3.NewListBucketsPaginator(client, &s3.ListBucketsInput{BucketRegion: aws.String(cfg.Region)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list S3 buckets: %w", err)
+		}
+
+		for _, bucket := range page.Buckets {
+			name := aws.ToString(bucket.Name)
+
+			tags, err := bucketTags(ctx, client, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get tags for S3 bucket %s: %w", name, err)
+			}
+
+			reason := m.reason(name, tags)
+			if reason == "" {
+				continue
+			}
+
+			resources = append(resources, resource{
+				kind:    kindS3Bucket,
+				region:  cfg.Region,
+				id:      name,
+				reason:  reason,
+				created: aws.ToTime(bucket.CreationDate),
+				delete: func(ctx context.Context) error {
+					return deleteS3Bucket(ctx, client, name)
+				},
+			})
+		}
+	}
+	return resources, nil
+}
+
+// bucketTags returns the bucket's tags, treating a missing tag set as empty
+func bucketTags(ctx context.Context, client *s3.Client, name string) (map[string]string, error) {
+	output, err := client.GetBucketTagging(ctx, &s3.GetBucketTaggingInput{Bucket: aws.String(name)})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchTagSet" {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+
+	tags := make(map[string]string, len(output.TagSet))
+	for _, tag := range output.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+// deleteS3Bucket removes every object version and delete marker before deleting the bucket
+func deleteS3Bucket(ctx context.Context, client *s3.Client, name string) error {
+	paginator := s3.NewListObjectVersionsPaginator(client, &s3.ListObjectVersionsInput{Bucket: aws.String(name)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+
+		var objects []s3types.ObjectIdentifier
+		for _, version := range page.Versions {
+			objects = append(objects, s3types.ObjectIdentifier{Key: version.Key, VersionId: version.VersionId})
+		}
+		for _, marker := range page.DeleteMarkers {
+			objects = append(objects, s3types.ObjectIdentifier{Key: marker.Key, VersionId: marker.VersionId})
+		}
+		if len(objects) == 0 {
+			continue
+		}
+
+		output, err := client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(name),
+			Delete: &s3types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return err
+		}
+		if len(output.Errors) > 0 {
+			return fmt.Errorf("failed to delete %d objects, first error: %s",
+				len(output.Errors), aws.ToString(output.Errors[0].Message))
+		}
+	}
+
+	_, err := client.DeleteBucket(ctx, &s3.DeleteBucketInput{Bucket: aws.String(name)})
+	return err
+}
@@ -0,0 +1,192 @@
+// =============================================================================
+// Orphaned Resource Sweeper
+// Matching, ordering and deletion of resources left behind by failed tests
+// =============================================================================
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/runprefix"
+)
+
+// testNamePattern matches the names generated by the test variable builders
+const testNamePattern = "*-test-*"
+
+// kind identifies a resource type; resources are deleted in kind order so
+// that dependents are removed before the resources they depend on
+type kind int
+
+const (
+	kindGlueDatabase kind = iota
+	kindIAMRole
+	kindIAMPolicy
+	kindS3Bucket
+	kindVPC
+)
+
+// String returns the label used in the sweep report
+func (k kind) String() string {
+	switch k {
+	case kindGlueDatabase:
+		return "glue-database"
+	case kindIAMRole:
+		return "iam-role"
+	case kindIAMPolicy:
+		return "iam-policy"
+	case kindS3Bucket:
+		return "s3-bucket"
+	case kindVPC:
+		return "vpc"
+	default:
+		return fmt.Sprintf("kind(%d)", int(k))
+	}
+}
+
+// globalRegion labels resources, such as IAM roles, that belong to no region
+const globalRegion = "global"
+
+// resource is a single orphaned resource found by a scanner
+type resource struct {
+	kind   kind
+	region string
+	id     string
+	reason string
+	// created is when the resource was created; zero when AWS does not report it
+	created time.Time
+	delete  func(ctx context.Context) error
+}
+
+// matchReason reports why a resource is considered test debris, or "" if it is not
+func matchReason(name string, tags map[string]string) string {
+	if strings.EqualFold(tags["Testing"], "true") {
+		return "tag Testing=true"
+	}
+	if matched, _ := path.Match(testNamePattern, name); matched {
+		return "name matches " + testNamePattern
+	}
+	return ""
+}
+
+// matcher selects the resources a sweep deletes
+type matcher struct {
+	// runPrefixes limits the sweep to the test runs with these prefixes;
+	// empty sweeps the debris of every run
+	runPrefixes []string
+}
+
+// reason reports why a resource is swept, or "" if it is not; a run's
+// resources carry its prefix tag, or are test debris named after the prefix.
+// The test base stack is never swept.
+func (m matcher) reason(name string, tags map[string]string) string {
+	if strings.EqualFold(tags[testutil.BaseStackTag], "true") {
+		return ""
+	}
+	if len(m.runPrefixes) == 0 {
+		return matchReason(name, tags)
+	}
+
+	for _, prefix := range m.runPrefixes {
+		if tags[testutil.RunPrefixTag] == prefix {
+			return fmt.Sprintf("tag %s=%s", testutil.RunPrefixTag, prefix)
+		}
+		named := strings.HasPrefix(name, prefix+"-") || strings.HasPrefix(name, prefix+"_")
+		if named && matchReason(name, tags) != "" {
+			return fmt.Sprintf("test resource named with run prefix %s", prefix)
+		}
+	}
+	return ""
+}
+
+// sortResources orders resources for deletion, dependents first
+func sortResources(resources []resource) {
+	sort.SliceStable(resources, func(i, j int) bool {
+		if resources[i].kind != resources[j].kind {
+			return resources[i].kind < resources[j].kind
+		}
+		if resources[i].region != resources[j].region {
+			return resources[i].region < resources[j].region
+		}
+		return resources[i].id < resources[j].id
+	})
+}
+
+// filterByAge drops the resources created less than minAge before now, which may
+// belong to a test run still in progress. Resources of unknown age are dropped
+// too; a zero minAge keeps every resource.
+func filterByAge(out io.Writer, resources []resource, minAge time.Duration, now time.Time) []resource {
+	if minAge <= 0 {
+		return resources
+	}
+
+	var old []resource
+	for _, r := range resources {
+		switch age := now.Sub(r.created); {
+		case r.created.IsZero():
+			fmt.Fprintf(out, "Skipping %s %s: creation time unknown, sweep with -min-age 0 to delete it\n", r.kind, r.id)
+		case age < minAge:
+			fmt.Fprintf(out, "Skipping %s %s: created %s ago, under -min-age %s\n", r.kind, r.id, age.Round(time.Minute), minAge)
+		default:
+			old = append(old, r)
+		}
+	}
+	return old
+}
+
+// sweep reports every resource and, unless dryRun is set, deletes them in order;
+// a failed deletion does not stop the remaining ones
+func sweep(ctx context.Context, out io.Writer, resources []resource, dryRun bool) error {
+	sortResources(resources)
+
+	for _, r := range resources {
+		fmt.Fprintf(out, "%-14s %-14s %-60s %s\n", r.kind, r.region, r.id, r.reason)
+	}
+	fmt.Fprintf(out, "Found %d orphaned resources\n", len(resources))
+
+	if dryRun {
+		fmt.Fprintln(out, "Dry run, nothing deleted")
+		return nil
+	}
+
+	var errs []error
+	deleted := 0
+	for _, r := range resources {
+		if err := r.delete(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete %s %s: %w", r.kind, r.id, err))
+			fmt.Fprintf(out, "❌ %s %s: %v\n", r.kind, r.id, err)
+			continue
+		}
+		deleted++
+		fmt.Fprintf(out, "✅ Deleted %s %s\n", r.kind, r.id)
+	}
+	fmt.Fprintf(out, "Deleted %d of %d orphaned resources\n", deleted, len(resources))
+
+	return errors.Join(errs...)
+}
+
+// reportKept lists the stacks failed tests kept for triage under the run
+// manifest; sweeping the run removes them with the rest of its resources
+func reportKept(out io.Writer, manifest string) error {
+	kept, err := runprefix.ReadKept(runprefix.KeptPath(manifest))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read kept stacks: %w", err)
+	}
+
+	for _, k := range kept {
+		fmt.Fprintf(out, "Kept for triage: %s %s (%s), cleanup: %s\n", k.Suite, k.Test, k.Triage, k.Cleanup)
+	}
+	return nil
+}
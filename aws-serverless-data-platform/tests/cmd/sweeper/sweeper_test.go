@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/runprefix"
+)
+
+func TestMatchReason(t *testing.T) {
+	assert.Equal(t, "tag Testing=true", matchReason("prod-vpc", map[string]string{"Testing": "true"}))
+	assert.Equal(t, "name matches *-test-*", matchReason("dl-test-abc123-test-raw", nil))
+	assert.Equal(t, "name matches *-test-*", matchReason("security-test-abc123-test-glue-role", nil))
+	assert.Empty(t, matchReason("dev-data-lake-raw", map[string]string{"Testing": "false"}))
+	assert.Empty(t, matchReason("test-vpc", nil), "Names must contain -test- to match")
+}
+
+func TestMatcherRunPrefix(t *testing.T) {
+	all := matcher{}
+	assert.Equal(t, "tag Testing=true", all.reason("k3x9qa-dl-raw-dev", map[string]string{"Testing": "true"}))
+
+	m := matcher{runPrefixes: []string{"k3x9qa"}}
+	assert.Equal(t, "tag TestRunPrefix=k3x9qa",
+		m.reason("k3x9qa-dl-raw-dev", map[string]string{"Testing": "true", "TestRunPrefix": "k3x9qa"}))
+	assert.Equal(t, "test resource named with run prefix k3x9qa", m.reason("k3x9qa-security-test-abc123-glue-role", nil))
+	assert.Empty(t, m.reason("b7m2zp-dl-test-abc123", map[string]string{"Testing": "true", "TestRunPrefix": "b7m2zp"}),
+		"Another engineer's run is left alone")
+	assert.Empty(t, m.reason("k3x9qa-prod-data", nil), "The prefix alone does not make a resource test debris")
+}
+
+func TestMatcherSkipsBaseStack(t *testing.T) {
+	tags := map[string]string{"TestBaseStack": "true"}
+	assert.Empty(t, matcher{}.reason("terratest-base-test-glue-role", tags))
+	assert.Empty(t, matcher{runPrefixes: []string{"terratest"}}.reason("terratest-base-test-glue-role", tags))
+}
+
+func TestNewMatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "k3x9qa.jsonl")
+	require.NoError(t, runprefix.Append(path, runprefix.NewEntry("k3x9qa", "storage", nil)))
+	require.NoError(t, runprefix.Append(path, runprefix.NewEntry("b7m2zp", "security", nil)))
+
+	m, err := newMatcher("jdoe1, ", path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"jdoe1", "b7m2zp", "k3x9qa"}, m.runPrefixes)
+
+	_, err = newMatcher("J-Doe", "")
+	assert.Error(t, err)
+}
+
+func TestReportKept(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "k3x9qa.jsonl")
+
+	var out bytes.Buffer
+	require.NoError(t, reportKept(&out, manifest), "A run without kept stacks has no kept manifest")
+	assert.Empty(t, out.String())
+
+	require.NoError(t, runprefix.AppendKept(runprefix.KeptPath(manifest), runprefix.Kept{
+		Prefix:  "k3x9qa",
+		Suite:   "storage",
+		Test:    "TestStorage/us-east-1",
+		Triage:  ".test-data/TestStorage_us-east-1/triage.json",
+		Cleanup: "go test -run TestStorage",
+	}))
+	require.NoError(t, reportKept(&out, manifest))
+	assert.Equal(t, "Kept for triage: storage TestStorage/us-east-1 (.test-data/TestStorage_us-east-1/triage.json), cleanup: go test -run TestStorage\n", out.String())
+}
+
+func TestSweepOrdersDependentsFirst(t *testing.T) {
+	var deleted []string
+	record := func(id string) func(context.Context) error {
+		return func(context.Context) error {
+			deleted = append(deleted, id)
+			return nil
+		}
+	}
+
+	resources := []resource{
+		{kind: kindVPC, id: "vpc-1", delete: record("vpc-1")},
+		{kind: kindIAMPolicy, id: "policy", delete: record("policy")},
+		{kind: kindS3Bucket, id: "bucket", delete: record("bucket")},
+		{kind: kindIAMRole, id: "role", delete: record("role")},
+		{kind: kindGlueDatabase, id: "db", delete: record("db")},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, sweep(context.Background(), &out, resources, false))
+	assert.Equal(t, []string{"db", "role", "policy", "bucket", "vpc-1"}, deleted)
+}
+
+func TestSweepOrdersRegionsWithinKind(t *testing.T) {
+	var deleted []string
+	record := func(id string) func(context.Context) error {
+		return func(context.Context) error {
+			deleted = append(deleted, id)
+			return nil
+		}
+	}
+
+	resources := []resource{
+		{kind: kindS3Bucket, region: "us-east-1", id: "a", delete: record("us-east-1/a")},
+		{kind: kindS3Bucket, region: "ap-southeast-1", id: "b", delete: record("ap-southeast-1/b")},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, sweep(context.Background(), &out, resources, false))
+	assert.Equal(t, []string{"ap-southeast-1/b", "us-east-1/a"}, deleted)
+}
+
+func TestFilterByAge(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	resources := []resource{
+		{kind: kindS3Bucket, id: "old", created: now.Add(-4 * time.Hour)},
+		{kind: kindS3Bucket, id: "running", created: now.Add(-20 * time.Minute)},
+		{kind: kindVPC, id: "vpc-1 (undated)"},
+	}
+
+	var out bytes.Buffer
+	kept := filterByAge(&out, resources, 3*time.Hour, now)
+	require.Len(t, kept, 1)
+	assert.Equal(t, "old", kept[0].id)
+	assert.Contains(t, out.String(), "Skipping s3-bucket running: created 20m0s ago, under -min-age 3h0m0s")
+	assert.Contains(t, out.String(), "Skipping vpc vpc-1 (undated): creation time unknown")
+
+	out.Reset()
+	assert.Len(t, filterByAge(&out, resources, 0, now), 3, "A zero minimum age sweeps everything")
+	assert.Empty(t, out.String())
+}
+
+func TestSplitRegions(t *testing.T) {
+	assert.Equal(t, []string{"us-east-1", "ap-southeast-1"}, splitRegions(" us-east-1, ap-southeast-1,,us-east-1"))
+	assert.Empty(t, splitRegions(""))
+}
+
+func TestSweepDryRunDeletesNothing(t *testing.T) {
+	resources := []resource{
+		{kind: kindS3Bucket, id: "bucket", delete: func(context.Context) error {
+			t.Fatal("dry run should not delete")
+			return nil
+		}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, sweep(context.Background(), &out, resources, true))
+	assert.Contains(t, out.String(), "bucket")
+	assert.Contains(t, out.String(), "Dry run")
+}
+
+func TestSweepContinuesAfterFailure(t *testing.T) {
+	deleted := false
+	resources := []resource{
+		{kind: kindIAMRole, id: "role", delete: func(context.Context) error {
+			return errors.New("access denied")
+		}},
+		{kind: kindS3Bucket, id: "bucket", delete: func(context.Context) error {
+			deleted = true
+			return nil
+		}},
+	}
+
+	var out bytes.Buffer
+	err := sweep(context.Background(), &out, resources, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "iam-role role")
+	assert.True(t, deleted, "Remaining resources should still be deleted")
+}
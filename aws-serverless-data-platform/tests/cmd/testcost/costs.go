@@ -0,0 +1,189 @@
+// =============================================================================
+// Test Run Cost Attribution
+// Cost Explorer spend of a test run, broken down by suite
+// =============================================================================
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	cetypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/runprefix"
+)
+
+const (
+	// costMetric is the Cost Explorer metric reported
+	costMetric = "UnblendedCost"
+
+	// dateLayout is the date format of Cost Explorer time periods
+	dateLayout = "2006-01-02"
+
+	// unattributed collects spend whose TestRun tag is not in the resource manifest
+	unattributed = "(unattributed)"
+)
+
+// spend is the cost of one TestRun tag value; the empty value is spend
+// carrying the run prefix tag but no TestRun tag
+type spend map[string]float64
+
+// querySpend returns the cost of resources tagged with any of the prefixes
+// between start and end, exclusive, grouped by their TestRun tag
+func querySpend(ctx context.Context, client *costexplorer.Client, prefixes []string, start, end time.Time) (spend, string, error) {
+	input := &costexplorer.GetCostAndUsageInput{
+		TimePeriod: &cetypes.DateInterval{
+			Start: aws.String(start.Format(dateLayout)),
+			End:   aws.String(end.Format(dateLayout)),
+		},
+		Granularity: cetypes.GranularityDaily,
+		Metrics:     []string{costMetric},
+		Filter: &cetypes.Expression{
+			Tags: &cetypes.TagValues{
+				Key:          aws.String(testutil.RunPrefixTag),
+				Values:       prefixes,
+				MatchOptions: []cetypes.MatchOption{cetypes.MatchOptionEquals},
+			},
+		},
+		GroupBy: []cetypes.GroupDefinition{{Type: cetypes.GroupDefinitionTypeTag, Key: aws.String(testutil.RunTag)}},
+	}
+
+	costs := spend{}
+	unit := ""
+	for {
+		output, err := client.GetCostAndUsage(ctx, input)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get cost and usage: %w", err)
+		}
+
+		for _, result := range output.ResultsByTime {
+			for _, group := range result.Groups {
+				metric, ok := group.Metrics[costMetric]
+				if !ok || len(group.Keys) == 0 {
+					continue
+				}
+				amount, err := strconv.ParseFloat(aws.ToString(metric.Amount), 64)
+				if err != nil {
+					return nil, "", fmt.Errorf("invalid cost amount %q: %w", aws.ToString(metric.Amount), err)
+				}
+				costs[tagValue(group.Keys[0])] += amount
+				unit = aws.ToString(metric.Unit)
+			}
+		}
+
+		if output.NextPageToken == nil {
+			return costs, unit, nil
+		}
+		input.NextPageToken = output.NextPageToken
+	}
+}
+
+// tagValue strips the "key$" prefix Cost Explorer puts on tag group keys
+func tagValue(key string) string {
+	if i := strings.Index(key, "$"); i >= 0 {
+		return key[i+1:]
+	}
+	return key
+}
+
+// suiteCost is what one suite deployed and what it cost
+type suiteCost struct {
+	Suite       string   `json:"suite"`
+	Cost        float64  `json:"cost"`
+	Deployments int      `json:"deployments"`
+	Resources   int      `json:"resources"`
+	Tests       []string `json:"tests"`
+}
+
+// report is the cost of a test run by suite, most expensive first
+type report struct {
+	Prefixes []string    `json:"prefixes"`
+	Start    string      `json:"start"`
+	End      string      `json:"end"`
+	Unit     string      `json:"unit"`
+	Total    float64     `json:"total"`
+	Suites   []suiteCost `json:"suites"`
+}
+
+// attribute assigns the spend of each TestRun tag value to the suite whose
+// deployments carried it; spend without a known tag value is unattributed
+func attribute(deployments []runprefix.Deployment, costs spend) []suiteCost {
+	suites := map[string]*suiteCost{}
+	owners := map[string]string{}
+	for _, d := range deployments {
+		s, ok := suites[d.Suite]
+		if !ok {
+			s = &suiteCost{Suite: d.Suite}
+			suites[d.Suite] = s
+		}
+		s.Deployments++
+		s.Resources += len(d.Resources)
+		if !contains(s.Tests, d.Test) {
+			s.Tests = append(s.Tests, d.Test)
+		}
+		if d.RunID != "" {
+			owners[d.RunID] = d.Suite
+		}
+	}
+
+	for runID, amount := range costs {
+		suite, ok := owners[runID]
+		if !ok {
+			suite = unattributed
+		}
+		s, ok := suites[suite]
+		if !ok {
+			s = &suiteCost{Suite: suite}
+			suites[suite] = s
+		}
+		s.Cost += amount
+	}
+
+	result := make([]suiteCost, 0, len(suites))
+	for _, s := range suites {
+		sort.Strings(s.Tests)
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Cost != result[j].Cost {
+			return result[i].Cost > result[j].Cost
+		}
+		return result[i].Suite < result[j].Suite
+	})
+	return result
+}
+
+// contains reports whether value is in values
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// summarize writes the suites as a table, most expensive first
+func summarize(out io.Writer, r *report) {
+	fmt.Fprintf(out, "Cost of test run %s from %s to %s\n", strings.Join(r.Prefixes, ","), r.Start, r.End)
+	for _, s := range r.Suites {
+		fmt.Fprintf(out, "%-16s %10.2f %s  %3d deployments %4d resources\n", s.Suite, s.Cost, r.Unit, s.Deployments, s.Resources)
+	}
+	fmt.Fprintf(out, "%-16s %10.2f %s\n", "total", r.Total, r.Unit)
+}
+
+// writeJSON writes the report as indented JSON
+func writeJSON(w io.Writer, r *report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
@@ -0,0 +1,186 @@
+// =============================================================================
+// Test Cost CLI
+// Reports what each suite of a test run actually cost
+// =============================================================================
+
+// Command testcost reads the resource manifest a test run wrote to .test-runs
+// and queries Cost Explorer for the spend tagged with the run's prefix,
+// attributing it to suites through each deployment's TestRun tag. Run it a day
+// after the tests: Cost Explorer data lags by up to 24 hours.
+//
+// The TestRunPrefix and TestRun tags must be activated as cost allocation tags
+// in the billing console before the run for Cost Explorer to group by them.
+//
+// Usage:
+//
+//	go run ./cmd/testcost -manifest ../.test-runs/k3x9qa.jsonl -output cost.json
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/runprefix"
+)
+
+// costExplorerRegion is the only region serving the Cost Explorer API
+const costExplorerRegion = "us-east-1"
+
+// costDataLag is how long Cost Explorer can take to show spend
+const costDataLag = 24 * time.Hour
+
+func main() {
+	manifest := flag.String("manifest", "", "run manifest written by the test run, e.g. ../.test-runs/<prefix>.jsonl")
+	start := flag.String("start", "", "first day to report, YYYY-MM-DD (default: the day of the first deployment)")
+	end := flag.String("end", "", "last day to report, YYYY-MM-DD (default: today)")
+	roleARN := flag.String("role-arn", os.Getenv(awsclients.RoleARNEnvVar), "IAM role to assume before querying Cost Explorer")
+	externalID := flag.String("external-id", os.Getenv(awsclients.ExternalIDEnvVar), "external ID required by the role's trust policy")
+	output := flag.String("output", "-", "file to write the JSON report to, - for stdout")
+	timeout := flag.Duration("timeout", 5*time.Minute, "overall deadline for the queries")
+	flag.Parse()
+
+	if *manifest == "" {
+		fmt.Fprintln(os.Stderr, "-manifest is required")
+		os.Exit(2)
+	}
+
+	r, err := run(*manifest, *start, *end, *roleARN, *externalID, *timeout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	summarize(os.Stderr, r)
+	if err := write(*output, r); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run reads the run's manifests and attributes its spend to suites
+func run(manifest, start, end, roleARN, externalID string, timeout time.Duration) (*report, error) {
+	entries, err := runprefix.ReadManifest(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("manifest %s records no test runs", manifest)
+	}
+	deployments, err := runprefix.ReadDeployments(runprefix.ResourcesPath(manifest))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read resource manifest: %w", err)
+	}
+
+	period, err := newPeriod(entries, deployments, start, end, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if last := lastApplied(deployments); time.Since(last) < costDataLag {
+		fmt.Fprintf(os.Stderr, "⚠️  The last deployment was %s ago; Cost Explorer may not show all of its spend yet\n",
+			time.Since(last).Round(time.Minute))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cfg, _, err := awsclients.LoadConfig(ctx,
+		awsclients.WithRegion(costExplorerRegion),
+		awsclients.WithAssumeRole(roleARN),
+		awsclients.WithExternalID(externalID),
+		awsclients.WithSessionName("testcost"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	prefixes := runprefix.Prefixes(entries)
+	costs, unit, err := querySpend(ctx, costexplorer.NewFromConfig(cfg), prefixes, period.start, period.end)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &report{
+		Prefixes: prefixes,
+		Start:    period.start.Format(dateLayout),
+		End:      period.end.AddDate(0, 0, -1).Format(dateLayout),
+		Unit:     unit,
+		Suites:   attribute(deployments, costs),
+	}
+	for _, s := range r.Suites {
+		r.Total += s.Cost
+	}
+	return r, nil
+}
+
+// period is the days reported; end is exclusive, as Cost Explorer expects
+type period struct {
+	start, end time.Time
+}
+
+// newPeriod parses the -start and -end days, defaulting to the day of the
+// first deployment, or suite start, and today
+func newPeriod(entries []runprefix.Entry, deployments []runprefix.Deployment, start, end string, now time.Time) (period, error) {
+	var p period
+	var err error
+
+	if start == "" {
+		first := entries[0].Started
+		for _, entry := range entries {
+			if entry.Started.Before(first) {
+				first = entry.Started
+			}
+		}
+		for _, d := range deployments {
+			if d.Applied.Before(first) {
+				first = d.Applied
+			}
+		}
+		p.start = first.Truncate(24 * time.Hour)
+	} else if p.start, err = time.Parse(dateLayout, start); err != nil {
+		return period{}, fmt.Errorf("invalid -start: %w", err)
+	}
+
+	if end == "" {
+		p.end = now.Truncate(24 * time.Hour)
+	} else if p.end, err = time.Parse(dateLayout, end); err != nil {
+		return period{}, fmt.Errorf("invalid -end: %w", err)
+	}
+	p.end = p.end.AddDate(0, 0, 1)
+
+	if !p.start.Before(p.end) {
+		return period{}, fmt.Errorf("the period starts on %s, after it ends", p.start.Format(dateLayout))
+	}
+	return p, nil
+}
+
+// lastApplied returns when the last deployment was applied, or the zero time
+func lastApplied(deployments []runprefix.Deployment) time.Time {
+	var last time.Time
+	for _, d := range deployments {
+		if d.Applied.After(last) {
+			last = d.Applied
+		}
+	}
+	return last
+}
+
+// write writes the report to path, or stdout for "-"
+func write(path string, r *report) error {
+	var out io.Writer = os.Stdout
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	return writeJSON(out, r)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/runprefix"
+)
+
+func TestTagValue(t *testing.T) {
+	assert.Equal(t, "use1-abc123", tagValue("TestRun$use1-abc123"))
+	assert.Equal(t, "", tagValue("TestRun$"), "Spend without the tag is grouped under an empty value")
+}
+
+func TestAttribute(t *testing.T) {
+	deployments := []runprefix.Deployment{
+		{Suite: "storage", Test: "TestStorage/us-east-1", RunID: "use1-abc123", Resources: make([]runprefix.Resource, 12)},
+		{Suite: "analytics", Test: "TestAnalytics/us-east-1", RunID: "use1-def456", Resources: make([]runprefix.Resource, 20)},
+		{Suite: "analytics", Test: "TestAnalytics/us-east-1", RunID: "use1-def456", Resources: make([]runprefix.Resource, 6)},
+		{Suite: "security", Test: "TestSecurityPlan"},
+	}
+	costs := spend{"use1-abc123": 0.42, "use1-def456": 3.10, "": 0.05}
+
+	suites := attribute(deployments, costs)
+	require.Len(t, suites, 4)
+
+	assert.Equal(t, "analytics", suites[0].Suite)
+	assert.InDelta(t, 3.10, suites[0].Cost, 1e-9)
+	assert.Equal(t, 2, suites[0].Deployments)
+	assert.Equal(t, 26, suites[0].Resources)
+	assert.Equal(t, []string{"TestAnalytics/us-east-1"}, suites[0].Tests)
+
+	assert.Equal(t, "storage", suites[1].Suite)
+	assert.Equal(t, unattributed, suites[2].Suite)
+	assert.InDelta(t, 0.05, suites[2].Cost, 1e-9)
+	assert.Equal(t, "security", suites[3].Suite, "Suites without spend are still listed")
+}
+
+func TestNewPeriod(t *testing.T) {
+	now := time.Date(2024, 11, 22, 15, 0, 0, 0, time.UTC)
+	entries := []runprefix.Entry{
+		{Prefix: "k3x9qa", Started: time.Date(2024, 11, 20, 23, 50, 0, 0, time.UTC)},
+		{Prefix: "k3x9qa", Started: time.Date(2024, 11, 21, 0, 10, 0, 0, time.UTC)},
+	}
+
+	p, err := newPeriod(entries, nil, "", "", now)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-11-20", p.start.Format(dateLayout))
+	assert.Equal(t, "2024-11-23", p.end.Format(dateLayout), "The end is exclusive, so today is included")
+
+	p, err = newPeriod(entries, nil, "2024-11-01", "2024-11-15", now)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-11-16", p.end.Format(dateLayout))
+
+	_, err = newPeriod(entries, nil, "2024-11-30", "2024-11-15", now)
+	assert.Error(t, err)
+	_, err = newPeriod(entries, nil, "yesterday", "", now)
+	assert.ErrorContains(t, err, "invalid -start")
+}
+
+func TestSummarize(t *testing.T) {
+	var out bytes.Buffer
+	summarize(&out, &report{
+		Prefixes: []string{"k3x9qa"},
+		Start:    "2024-11-20",
+		End:      "2024-11-22",
+		Unit:     "USD",
+		Total:    3.52,
+		Suites:   []suiteCost{{Suite: "analytics", Cost: 3.10, Deployments: 2, Resources: 26}},
+	})
+
+	assert.Contains(t, out.String(), "Cost of test run k3x9qa from 2024-11-20 to 2024-11-22")
+	assert.Contains(t, out.String(), "analytics")
+	assert.Contains(t, out.String(), "3.52 USD")
+}
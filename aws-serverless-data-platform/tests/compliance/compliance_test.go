@@ -0,0 +1,142 @@
+// =============================================================================
+// Compliance Suite Setup
+// Scopes the controls to the deployed environment and writes the evidence
+// =============================================================================
+
+package compliance
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	terratest_aws "github.com/gruntwork-io/terratest/modules/aws"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/report"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tagaudit"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/testconfig"
+)
+
+// TestMain runs the suite against the account selected by TERRATEST_ASSUME_ROLE_ARN
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
+
+// scope is the deployed environment every control is checked against
+type scope struct {
+	clients *awsclients.Clients
+
+	// tags are the Project and Environment tags Terragrunt puts on the environment's resources
+	tags map[string]string
+
+	// resources maps the ARN of every resource carrying tags to all of its tags
+	resources map[string]map[string]string
+
+	// consumers are the accounts the Data Catalog may be shared with
+	consumers []string
+}
+
+var (
+	setupOnce sync.Once
+	shared    *scope
+	evidence  *Evidence
+)
+
+// setup skips in short mode, discovers the environment's resources on first use
+// and writes the evidence report when the test finishes
+func setup(t *testing.T, control Control) *scope {
+	if testing.Short() {
+		t.Skip("Skipping compliance checks in short mode")
+	}
+	report.Track(t)
+
+	setupOnce.Do(func() {
+		config, err := testconfig.Load()
+		require.NoError(t, err)
+
+		integration := config.Integration
+		clients := awsclients.New(t, awsclients.WithRegion(integration.Region))
+		tags := map[string]string{"Project": integration.Project, "Environment": integration.Environment}
+
+		shared = &scope{
+			clients:   clients,
+			tags:      tags,
+			resources: tagaudit.Resources(t, clients, tags),
+			consumers: integration.CatalogConsumers,
+		}
+		evidence = NewEvidence(integration.Environment, integration.Region, terratest_aws.GetAccountId(t))
+	})
+	require.NotNil(t, shared, "Discovering the environment's resources failed in an earlier test")
+
+	evidence.Declare(control)
+	t.Cleanup(func() { writeEvidence(t) })
+	return shared
+}
+
+// arns returns the in-scope ARNs of service whose resource part starts with prefix
+func (s *scope) arns(service, prefix string) []string {
+	var arns []string
+	for resourceARN := range s.resources {
+		parsed, err := arn.Parse(resourceARN)
+		if err == nil && parsed.Service == service && strings.HasPrefix(parsed.Resource, prefix) {
+			arns = append(arns, resourceARN)
+		}
+	}
+	sort.Strings(arns)
+	return arns
+}
+
+// assess checks every resource against control in its own subtest, recording
+// the evidence; problems returns what is wrong with one resource, including
+// API errors, so that every resource is recorded
+func (s *scope) assess(t *testing.T, control Control, resources []string, problems func(ctx context.Context, resource string) []string) {
+	if len(resources) == 0 {
+		t.Skipf("No resources in scope for %s", control.ID)
+	}
+
+	for _, resource := range resources {
+		t.Run(resource, func(t *testing.T) {
+			ctx, cancel := s.clients.Context()
+			defer cancel()
+
+			found := problems(ctx, resource)
+			evidence.Record(control, resource, found)
+			for _, problem := range found {
+				t.Errorf("%s %s: %s", control.ID, resource, problem)
+			}
+		})
+	}
+	logging.New(t).Info("Checked resources against control", "control", control.ID, "title", control.Title, "resources", len(resources))
+}
+
+// writeEvidence rewrites the evidence and encryption reports in the report
+// directory, so the files are complete after whichever test finishes last
+func writeEvidence(t *testing.T) {
+	dir := os.Getenv(report.DirEnvVar)
+	if dir == "" {
+		return
+	}
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+
+	writeReport(t, filepath.Join(dir, EvidenceFile), evidence.Write)
+	writeReport(t, filepath.Join(dir, EncryptionFile), func(w io.Writer) error {
+		return evidence.WriteResources(w, encryptionControls...)
+	})
+}
+
+// writeReport creates path and writes one report to it
+func writeReport(t *testing.T, path string, write func(io.Writer) error) {
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+	require.NoError(t, write(file))
+}
@@ -0,0 +1,247 @@
+// =============================================================================
+// Encryption In Transit and At Rest
+// KMS and TLS-only controls for every service integration point
+// =============================================================================
+
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	firehosetypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/iampolicy"
+)
+
+// encryptionControls are the controls reported per resource in EncryptionFile.
+// Controls with neither a CIS nor an FSBP counterpart use Platform IDs.
+var encryptionControls = []string{
+	"CIS 2.1.1",
+	"CIS 2.1.2",
+	"FSBP Athena.1",
+	"FSBP DataFirehose.1",
+	"FSBP SNS.1",
+	"Platform ENC.1",
+	"Platform ENC.2",
+	"Platform ENC.3",
+	"Platform GLUE.1",
+}
+
+// resourceName returns the last segment of an ARN's resource, e.g. the
+// function name of arn:aws:lambda:region:account:function:name
+func resourceName(resourceARN string) string {
+	return resourceARN[strings.LastIndexAny(resourceARN, ":/")+1:]
+}
+
+func TestFSBP_SNS_1_TopicEncryptionAtRest(t *testing.T) {
+	control := Control{ID: "FSBP SNS.1", Title: "SNS topics should be encrypted at-rest using AWS KMS"}
+	s := setup(t, control)
+
+	s.assess(t, control, s.arns("sns", ""), func(ctx context.Context, topicARN string) []string {
+		output, err := s.clients.SNS().GetTopicAttributes(ctx, &sns.GetTopicAttributesInput{TopicArn: awssdk.String(topicARN)})
+		if err != nil {
+			return []string{fmt.Sprintf("failed to get topic attributes: %v", err)}
+		}
+		if output.Attributes["KmsMasterKeyId"] == "" {
+			return []string{"no KMS key is configured"}
+		}
+		return nil
+	})
+}
+
+func TestPlatform_ENC_1_SNSDenyInsecureTransport(t *testing.T) {
+	control := Control{ID: "Platform ENC.1", Title: "SNS topic policies should deny requests not made over TLS"}
+	s := setup(t, control)
+
+	s.assess(t, control, s.arns("sns", ""), func(ctx context.Context, topicARN string) []string {
+		output, err := s.clients.SNS().GetTopicAttributes(ctx, &sns.GetTopicAttributesInput{TopicArn: awssdk.String(topicARN)})
+		if err != nil {
+			return []string{fmt.Sprintf("failed to get topic attributes: %v", err)}
+		}
+
+		doc, err := iampolicy.Parse(output.Attributes["Policy"])
+		if err != nil {
+			return []string{err.Error()}
+		}
+		if !deniesInsecureTransport(doc, "sns:Publish", topicARN) {
+			return []string{"topic policy does not deny sns:Publish without aws:SecureTransport"}
+		}
+		return nil
+	})
+}
+
+// deniesInsecureTransport reports whether a Deny statement covering action on
+// resource applies to requests made without TLS; actions match case-insensitively
+func deniesInsecureTransport(doc *iampolicy.Document, action, resource string) bool {
+	for _, statement := range doc.Statement {
+		if statement.Effect != "Deny" || !statement.Condition["Bool"]["aws:SecureTransport"].Contains("false") {
+			continue
+		}
+		if policyMatches(statement.Action, strings.ToLower(action), true) && policyMatches(statement.Resource, resource, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// policyMatches reports whether any pattern matches value, treating a trailing
+// * as a prefix wildcard
+func policyMatches(patterns iampolicy.StringList, value string, foldCase bool) bool {
+	for _, pattern := range patterns {
+		if foldCase {
+			pattern = strings.ToLower(pattern)
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); pattern == value || ok && strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFSBP_Athena_1_WorkGroupEncryption(t *testing.T) {
+	control := Control{ID: "FSBP Athena.1", Title: "Athena workgroups should be encrypted at rest"}
+	s := setup(t, control)
+
+	s.assess(t, control, s.arns("athena", "workgroup/"), func(ctx context.Context, workGroupARN string) []string {
+		output, err := s.clients.Athena().GetWorkGroup(ctx, &athena.GetWorkGroupInput{WorkGroup: awssdk.String(resourceName(workGroupARN))})
+		if err != nil {
+			return []string{fmt.Sprintf("failed to get workgroup: %v", err)}
+		}
+		return workGroupProblems(output.WorkGroup.Configuration)
+	})
+}
+
+// workGroupProblems reports a workgroup whose query results are not encrypted
+// with KMS, or whose clients may override that
+func workGroupProblems(config *athenatypes.WorkGroupConfiguration) []string {
+	if config == nil {
+		return []string{"no workgroup configuration"}
+	}
+
+	var problems []string
+	if config.ResultConfiguration == nil || config.ResultConfiguration.EncryptionConfiguration == nil {
+		problems = append(problems, "query results are not encrypted")
+	} else if option := config.ResultConfiguration.EncryptionConfiguration.EncryptionOption; option == athenatypes.EncryptionOptionSseS3 {
+		problems = append(problems, fmt.Sprintf("query results use %s rather than a KMS key", option))
+	}
+	if !awssdk.ToBool(config.EnforceWorkGroupConfiguration) {
+		problems = append(problems, "clients may override the workgroup's result encryption")
+	}
+	return problems
+}
+
+func TestFSBP_DataFirehose_1_DeliveryStreamEncryption(t *testing.T) {
+	control := Control{ID: "FSBP DataFirehose.1", Title: "Firehose delivery streams should be encrypted at rest"}
+	s := setup(t, control)
+
+	s.assess(t, control, s.arns("firehose", "deliverystream/"), func(ctx context.Context, streamARN string) []string {
+		output, err := s.clients.Firehose().DescribeDeliveryStream(ctx, &firehose.DescribeDeliveryStreamInput{
+			DeliveryStreamName: awssdk.String(resourceName(streamARN)),
+		})
+		if err != nil {
+			return []string{fmt.Sprintf("failed to describe delivery stream: %v", err)}
+		}
+		return deliveryStreamProblems(output.DeliveryStreamDescription)
+	})
+}
+
+// deliveryStreamProblems reports a Direct PUT stream without server-side
+// encryption and any S3 destination written without a KMS key; streams reading
+// from Kinesis are encrypted by their source stream
+func deliveryStreamProblems(stream *firehosetypes.DeliveryStreamDescription) []string {
+	var problems []string
+	if stream.DeliveryStreamType == firehosetypes.DeliveryStreamTypeDirectPut {
+		if sse := stream.DeliveryStreamEncryptionConfiguration; sse == nil || sse.Status != firehosetypes.DeliveryStreamEncryptionStatusEnabled {
+			problems = append(problems, "server-side encryption is not enabled")
+		}
+	}
+
+	for _, destination := range stream.Destinations {
+		var encryption *firehosetypes.EncryptionConfiguration
+		switch {
+		case destination.ExtendedS3DestinationDescription != nil:
+			encryption = destination.ExtendedS3DestinationDescription.EncryptionConfiguration
+		case destination.S3DestinationDescription != nil:
+			encryption = destination.S3DestinationDescription.EncryptionConfiguration
+		default:
+			continue
+		}
+		if encryption == nil || encryption.KMSEncryptionConfig == nil {
+			problems = append(problems, fmt.Sprintf("destination %s writes to S3 without a KMS key", awssdk.ToString(destination.DestinationId)))
+		}
+	}
+	return problems
+}
+
+func TestPlatform_ENC_2_LambdaEnvironmentEncryption(t *testing.T) {
+	control := Control{ID: "Platform ENC.2", Title: "Lambda environment variables should be encrypted with a customer managed KMS key"}
+	s := setup(t, control)
+
+	s.assess(t, control, s.arns("lambda", "function:"), func(ctx context.Context, functionARN string) []string {
+		output, err := s.clients.Lambda().GetFunctionConfiguration(ctx, &lambda.GetFunctionConfigurationInput{
+			FunctionName: awssdk.String(functionARN),
+		})
+		if err != nil {
+			return []string{fmt.Sprintf("failed to get function configuration: %v", err)}
+		}
+		if output.Environment != nil && len(output.Environment.Variables) > 0 && awssdk.ToString(output.KMSKeyArn) == "" {
+			return []string{"environment variables are encrypted with the AWS managed key"}
+		}
+		return nil
+	})
+}
+
+func TestPlatform_ENC_3_GlueJobSecurityConfiguration(t *testing.T) {
+	control := Control{ID: "Platform ENC.3", Title: "Glue jobs should use a security configuration encrypting S3 output, logs and bookmarks with KMS"}
+	s := setup(t, control)
+
+	s.assess(t, control, s.arns("glue", "job/"), func(ctx context.Context, jobARN string) []string {
+		job, err := s.clients.Glue().GetJob(ctx, &glue.GetJobInput{JobName: awssdk.String(resourceName(jobARN))})
+		if err != nil {
+			return []string{fmt.Sprintf("failed to get job: %v", err)}
+		}
+		name := awssdk.ToString(job.Job.SecurityConfiguration)
+		if name == "" {
+			return []string{"no security configuration is attached"}
+		}
+
+		config, err := s.clients.Glue().GetSecurityConfiguration(ctx, &glue.GetSecurityConfigurationInput{Name: awssdk.String(name)})
+		if err != nil {
+			return []string{fmt.Sprintf("failed to get security configuration %s: %v", name, err)}
+		}
+		return glueEncryptionProblems(config.SecurityConfiguration.EncryptionConfiguration)
+	})
+}
+
+// glueEncryptionProblems lists what a Glue security configuration leaves unencrypted
+func glueEncryptionProblems(config *gluetypes.EncryptionConfiguration) []string {
+	if config == nil {
+		return []string{"no encryption configuration"}
+	}
+
+	var problems []string
+	encryptsS3 := len(config.S3Encryption) > 0
+	for _, s3 := range config.S3Encryption {
+		encryptsS3 = encryptsS3 && s3.S3EncryptionMode == gluetypes.S3EncryptionModeSsekms
+	}
+	if !encryptsS3 {
+		problems = append(problems, "S3 output is not encrypted with SSE-KMS")
+	}
+	if config.CloudWatchEncryption == nil || config.CloudWatchEncryption.CloudWatchEncryptionMode != gluetypes.CloudWatchEncryptionModeSsekms {
+		problems = append(problems, "CloudWatch logs are not encrypted with SSE-KMS")
+	}
+	if config.JobBookmarksEncryption == nil || config.JobBookmarksEncryption.JobBookmarksEncryptionMode != gluetypes.JobBookmarksEncryptionModeCsekms {
+		problems = append(problems, "job bookmarks are not encrypted with CSE-KMS")
+	}
+	return problems
+}
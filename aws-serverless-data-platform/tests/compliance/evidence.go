@@ -0,0 +1,229 @@
+// =============================================================================
+// Compliance Evidence
+// Maps each control to the resources checked and their result
+// =============================================================================
+
+// Package compliance checks the deployed environment against the CIS AWS
+// Foundations controls the platform is responsible for. Every test is named
+// after its control ID and records what it checked in an evidence report.
+package compliance
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// EvidenceFile is the report written to the run's report directory
+	EvidenceFile = "compliance-evidence.json"
+
+	// EncryptionFile is the per-resource view of the encryption controls,
+	// written next to EvidenceFile
+	EncryptionFile = "encryption-report.json"
+)
+
+// Result is the outcome of a control or of one resource check
+type Result string
+
+// Results
+const (
+	Pass          Result = "pass"
+	Fail          Result = "fail"
+	NotApplicable Result = "not-applicable"
+)
+
+// Control is one benchmark control
+type Control struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// Check is the result of a control for one resource
+type Check struct {
+	Resource string `json:"resource"`
+	Result   Result `json:"result"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// ControlEvidence is everything checked for one control; it fails when any
+// check failed and is not applicable when nothing was in scope
+type ControlEvidence struct {
+	Control
+	Result Result  `json:"result"`
+	Checks []Check `json:"checks"`
+}
+
+// ResourceCheck is the result of one control for a resource
+type ResourceCheck struct {
+	Control string `json:"control"`
+	Title   string `json:"title"`
+	Result  Result `json:"result"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// ResourceEvidence is every check of one resource; it fails when any check failed
+type ResourceEvidence struct {
+	Resource string          `json:"resource"`
+	Result   Result          `json:"result"`
+	Checks   []ResourceCheck `json:"checks"`
+}
+
+// Evidence collects checks from concurrently running tests
+type Evidence struct {
+	Environment string
+	Region      string
+	AccountID   string
+
+	mu       sync.Mutex
+	controls map[string]*ControlEvidence
+}
+
+// NewEvidence starts an empty report for the scanned environment
+func NewEvidence(environment, region, accountID string) *Evidence {
+	return &Evidence{
+		Environment: environment,
+		Region:      region,
+		AccountID:   accountID,
+		controls:    map[string]*ControlEvidence{},
+	}
+}
+
+// Declare adds a control to the report, so one with nothing in scope still appears
+func (e *Evidence) Declare(control Control) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.declare(control)
+}
+
+func (e *Evidence) declare(control Control) *ControlEvidence {
+	evidence, ok := e.controls[control.ID]
+	if !ok {
+		evidence = &ControlEvidence{Control: control, Checks: []Check{}}
+		e.controls[control.ID] = evidence
+	}
+	return evidence
+}
+
+// Record adds the check of resource against control; no problems means it passed
+func (e *Evidence) Record(control Control, resource string, problems []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	check := Check{Resource: resource, Result: Pass}
+	if len(problems) > 0 {
+		check.Result = Fail
+		check.Detail = strings.Join(problems, "; ")
+	}
+
+	evidence := e.declare(control)
+	evidence.Checks = append(evidence.Checks, check)
+}
+
+// Controls returns the evidence for every control, ordered by control ID
+func (e *Evidence) Controls() []ControlEvidence {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	controls := make([]ControlEvidence, 0, len(e.controls))
+	for _, evidence := range e.controls {
+		c := *evidence
+		c.Checks = append([]Check{}, evidence.Checks...)
+		sort.Slice(c.Checks, func(i, j int) bool { return c.Checks[i].Resource < c.Checks[j].Resource })
+
+		c.Result = NotApplicable
+		for _, check := range c.Checks {
+			if check.Result == Fail {
+				c.Result = Fail
+				break
+			}
+			c.Result = Pass
+		}
+		controls = append(controls, c)
+	}
+	sort.Slice(controls, func(i, j int) bool { return controls[i].ID < controls[j].ID })
+	return controls
+}
+
+// Resources pivots the checks of the controls with controlIDs by resource,
+// ordered by resource and then control ID
+func (e *Evidence) Resources(controlIDs ...string) []ResourceEvidence {
+	wanted := map[string]bool{}
+	for _, id := range controlIDs {
+		wanted[id] = true
+	}
+
+	byResource := map[string]*ResourceEvidence{}
+	for _, control := range e.Controls() {
+		if !wanted[control.ID] {
+			continue
+		}
+		for _, check := range control.Checks {
+			resource, ok := byResource[check.Resource]
+			if !ok {
+				resource = &ResourceEvidence{Resource: check.Resource, Result: Pass}
+				byResource[check.Resource] = resource
+			}
+			resource.Checks = append(resource.Checks, ResourceCheck{
+				Control: control.ID,
+				Title:   control.Title,
+				Result:  check.Result,
+				Detail:  check.Detail,
+			})
+			if check.Result == Fail {
+				resource.Result = Fail
+			}
+		}
+	}
+
+	resources := make([]ResourceEvidence, 0, len(byResource))
+	for _, resource := range byResource {
+		resources = append(resources, *resource)
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Resource < resources[j].Resource })
+	return resources
+}
+
+// Write writes the evidence report as JSON
+func (e *Evidence) Write(w io.Writer) error {
+	return encode(w, struct {
+		Environment string            `json:"environment"`
+		Region      string            `json:"region"`
+		AccountID   string            `json:"account_id"`
+		GeneratedAt time.Time         `json:"generated_at"`
+		Controls    []ControlEvidence `json:"controls"`
+	}{
+		Environment: e.Environment,
+		Region:      e.Region,
+		AccountID:   e.AccountID,
+		GeneratedAt: time.Now().UTC(),
+		Controls:    e.Controls(),
+	})
+}
+
+// WriteResources writes the checks of the controls with controlIDs by resource as JSON
+func (e *Evidence) WriteResources(w io.Writer, controlIDs ...string) error {
+	return encode(w, struct {
+		Environment string             `json:"environment"`
+		Region      string             `json:"region"`
+		AccountID   string             `json:"account_id"`
+		GeneratedAt time.Time          `json:"generated_at"`
+		Resources   []ResourceEvidence `json:"resources"`
+	}{
+		Environment: e.Environment,
+		Region:      e.Region,
+		AccountID:   e.AccountID,
+		GeneratedAt: time.Now().UTC(),
+		Resources:   e.Resources(controlIDs...),
+	})
+}
+
+// encode writes report as indented JSON
+func encode(w io.Writer, report interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
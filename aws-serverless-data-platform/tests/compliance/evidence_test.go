@@ -0,0 +1,230 @@
+package compliance
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	firehosetypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/iampolicy"
+)
+
+func TestEvidenceControls(t *testing.T) {
+	encryption := Control{ID: "CIS 2.1.1", Title: "encryption"}
+	transport := Control{ID: "CIS 2.1.2", Title: "transport"}
+	flowLogs := Control{ID: "CIS 3.9", Title: "flow logs"}
+
+	e := NewEvidence("dev", "ap-southeast-1", "111111111111")
+	e.Declare(flowLogs)
+	e.Record(transport, "arn:aws:s3:::raw", nil)
+	e.Record(encryption, "arn:aws:s3:::raw", nil)
+	e.Record(encryption, "arn:aws:s3:::curated", []string{"no default encryption", "second"})
+
+	controls := e.Controls()
+	require.Len(t, controls, 3)
+
+	assert.Equal(t, "CIS 2.1.1", controls[0].ID)
+	assert.Equal(t, Fail, controls[0].Result)
+	assert.Equal(t, []Check{
+		{Resource: "arn:aws:s3:::curated", Result: Fail, Detail: "no default encryption; second"},
+		{Resource: "arn:aws:s3:::raw", Result: Pass},
+	}, controls[0].Checks)
+	assert.Equal(t, Pass, controls[1].Result)
+	assert.Equal(t, NotApplicable, controls[2].Result, "A control with nothing in scope is not applicable")
+
+	var buf bytes.Buffer
+	require.NoError(t, e.Write(&buf))
+
+	var written struct {
+		AccountID string            `json:"account_id"`
+		Controls  []ControlEvidence `json:"controls"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &written))
+	assert.Equal(t, "111111111111", written.AccountID)
+	assert.Equal(t, controls, written.Controls)
+}
+
+func TestEvidenceResources(t *testing.T) {
+	encryption := Control{ID: "CIS 2.1.1", Title: "encryption"}
+	transport := Control{ID: "CIS 2.1.2", Title: "transport"}
+	publicAccess := Control{ID: "CIS 2.1.5", Title: "public access"}
+
+	e := NewEvidence("dev", "ap-southeast-1", "111111111111")
+	e.Record(transport, "arn:aws:s3:::raw", []string{"does not deny requests without aws:SecureTransport"})
+	e.Record(encryption, "arn:aws:s3:::raw", nil)
+	e.Record(encryption, "arn:aws:s3:::curated", nil)
+	e.Record(publicAccess, "arn:aws:s3:::curated", []string{"BlockPublicAcls is disabled"})
+
+	assert.Equal(t, []ResourceEvidence{
+		{Resource: "arn:aws:s3:::curated", Result: Pass, Checks: []ResourceCheck{
+			{Control: "CIS 2.1.1", Title: "encryption", Result: Pass},
+		}},
+		{Resource: "arn:aws:s3:::raw", Result: Fail, Checks: []ResourceCheck{
+			{Control: "CIS 2.1.1", Title: "encryption", Result: Pass},
+			{Control: "CIS 2.1.2", Title: "transport", Result: Fail, Detail: "does not deny requests without aws:SecureTransport"},
+		}},
+	}, e.Resources("CIS 2.1.1", "CIS 2.1.2"), "Controls not asked for are left out")
+
+	var buf bytes.Buffer
+	require.NoError(t, e.WriteResources(&buf, "CIS 2.1.1"))
+
+	var written struct {
+		Environment string             `json:"environment"`
+		Resources   []ResourceEvidence `json:"resources"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &written))
+	assert.Equal(t, "dev", written.Environment)
+	assert.Len(t, written.Resources, 2)
+
+	assert.Empty(t, e.Resources("FSBP SNS.1"))
+}
+
+func TestGrantsFullAdmin(t *testing.T) {
+	admin, err := grantsFullAdmin(`{"Version":"2012-10-17","Statement":{"Effect":"Allow","Action":"*","Resource":"*"}}`)
+	require.NoError(t, err)
+	assert.True(t, admin)
+
+	admin, err = grantsFullAdmin(`{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Action":"s3:*","Resource":"*"},
+		{"Effect":"Deny","Action":"*","Resource":"*"}]}`)
+	require.NoError(t, err)
+	assert.False(t, admin)
+
+	admin, err = grantsFullAdmin(`%7B%22Version%22%3A%222012-10-17%22%2C%22Statement%22%3A%7B%22Effect%22%3A%22Allow%22%2C%22Action%22%3A%22%2A%22%2C%22Resource%22%3A%22%2A%22%7D%7D`)
+	require.NoError(t, err)
+	assert.True(t, admin, "IAM returns documents URL-encoded")
+}
+
+func TestResourceProblems(t *testing.T) {
+	assert.Empty(t, publicAccessBlockProblems(&s3types.PublicAccessBlockConfiguration{
+		BlockPublicAcls:       awssdk.Bool(true),
+		BlockPublicPolicy:     awssdk.Bool(true),
+		IgnorePublicAcls:      awssdk.Bool(true),
+		RestrictPublicBuckets: awssdk.Bool(true),
+	}))
+	assert.Equal(t, []string{"IgnorePublicAcls is disabled", "RestrictPublicBuckets is disabled"},
+		publicAccessBlockProblems(&s3types.PublicAccessBlockConfiguration{
+			BlockPublicAcls:   awssdk.Bool(true),
+			BlockPublicPolicy: awssdk.Bool(true),
+		}))
+
+	assert.Empty(t, flowLogProblems([]ec2types.FlowLog{
+		{FlowLogStatus: awssdk.String("ACTIVE"), TrafficType: ec2types.TrafficTypeAccept},
+		{FlowLogStatus: awssdk.String("ACTIVE"), TrafficType: ec2types.TrafficTypeReject},
+	}))
+	assert.Len(t, flowLogProblems([]ec2types.FlowLog{{FlowLogStatus: awssdk.String("ACTIVE"), TrafficType: ec2types.TrafficTypeAccept}}), 1)
+
+	assert.Empty(t, retentionProblems(awssdk.Int32(30)))
+	assert.Len(t, retentionProblems(awssdk.Int32(3)), 1)
+	assert.Len(t, retentionProblems(nil), 1)
+}
+
+func TestEncryptionProblems(t *testing.T) {
+	doc, err := iampolicy.Parse(`{"Version":"2012-10-17","Statement":[
+		{"Sid":"AccountAccess","Effect":"Allow","Principal":{"AWS":"*"},"Action":["SNS:Publish","SNS:Subscribe"],"Resource":"arn:aws:sns:ap-southeast-1:111111111111:alerts"},
+		{"Sid":"DenyInsecureTransport","Effect":"Deny","Principal":"*","Action":"SNS:*","Resource":"arn:aws:sns:ap-southeast-1:111111111111:alerts",
+		 "Condition":{"Bool":{"aws:SecureTransport":"false"}}}]}`)
+	require.NoError(t, err)
+	assert.True(t, deniesInsecureTransport(doc, "sns:Publish", "arn:aws:sns:ap-southeast-1:111111111111:alerts"), "SNS actions match case-insensitively")
+	assert.False(t, deniesInsecureTransport(doc, "sns:Publish", "arn:aws:sns:ap-southeast-1:111111111111:other"))
+
+	assert.Empty(t, workGroupProblems(&athenatypes.WorkGroupConfiguration{
+		EnforceWorkGroupConfiguration: awssdk.Bool(true),
+		ResultConfiguration: &athenatypes.ResultConfiguration{
+			EncryptionConfiguration: &athenatypes.EncryptionConfiguration{EncryptionOption: athenatypes.EncryptionOptionSseKms},
+		},
+	}))
+	assert.Equal(t, []string{"query results are not encrypted", "clients may override the workgroup's result encryption"},
+		workGroupProblems(&athenatypes.WorkGroupConfiguration{}))
+
+	assert.Empty(t, deliveryStreamProblems(&firehosetypes.DeliveryStreamDescription{
+		DeliveryStreamType:                    firehosetypes.DeliveryStreamTypeDirectPut,
+		DeliveryStreamEncryptionConfiguration: &firehosetypes.DeliveryStreamEncryptionConfiguration{Status: firehosetypes.DeliveryStreamEncryptionStatusEnabled},
+		Destinations: []firehosetypes.DestinationDescription{{
+			DestinationId: awssdk.String("destinationId-000000000001"),
+			ExtendedS3DestinationDescription: &firehosetypes.ExtendedS3DestinationDescription{
+				EncryptionConfiguration: &firehosetypes.EncryptionConfiguration{KMSEncryptionConfig: &firehosetypes.KMSEncryptionConfig{}},
+			},
+		}},
+	}))
+	assert.Equal(t, []string{"destination destinationId-000000000001 writes to S3 without a KMS key"},
+		deliveryStreamProblems(&firehosetypes.DeliveryStreamDescription{
+			DeliveryStreamType: firehosetypes.DeliveryStreamTypeKinesisStreamAsSource,
+			Destinations: []firehosetypes.DestinationDescription{{
+				DestinationId: awssdk.String("destinationId-000000000001"),
+				S3DestinationDescription: &firehosetypes.S3DestinationDescription{
+					EncryptionConfiguration: &firehosetypes.EncryptionConfiguration{NoEncryptionConfig: firehosetypes.NoEncryptionConfigNoEncryption},
+				},
+			}},
+		}), "Kinesis-sourced streams need no server-side encryption of their own")
+
+	assert.Empty(t, glueEncryptionProblems(&gluetypes.EncryptionConfiguration{
+		S3Encryption:           []gluetypes.S3Encryption{{S3EncryptionMode: gluetypes.S3EncryptionModeSsekms}},
+		CloudWatchEncryption:   &gluetypes.CloudWatchEncryption{CloudWatchEncryptionMode: gluetypes.CloudWatchEncryptionModeSsekms},
+		JobBookmarksEncryption: &gluetypes.JobBookmarksEncryption{JobBookmarksEncryptionMode: gluetypes.JobBookmarksEncryptionModeCsekms},
+	}))
+	assert.Len(t, glueEncryptionProblems(&gluetypes.EncryptionConfiguration{
+		S3Encryption: []gluetypes.S3Encryption{{S3EncryptionMode: gluetypes.S3EncryptionModeSses3}},
+	}), 3)
+
+	assert.Equal(t, "etl", resourceName("arn:aws:glue:ap-southeast-1:111111111111:job/etl"))
+	assert.Equal(t, "ingest", resourceName("arn:aws:lambda:ap-southeast-1:111111111111:function:ingest"))
+}
+
+func TestCatalogEncryptionProblems(t *testing.T) {
+	platformKey := func(keyID string) bool { return keyID == "alias/dl-data" }
+
+	assert.Empty(t, catalogEncryptionProblems(&gluetypes.DataCatalogEncryptionSettings{
+		EncryptionAtRest:             &gluetypes.EncryptionAtRest{CatalogEncryptionMode: gluetypes.CatalogEncryptionModeSsekms, SseAwsKmsKeyId: awssdk.String("alias/dl-data")},
+		ConnectionPasswordEncryption: &gluetypes.ConnectionPasswordEncryption{ReturnConnectionPasswordEncrypted: true, AwsKmsKeyId: awssdk.String("alias/dl-data")},
+	}, platformKey))
+
+	assert.Equal(t, []string{"metadata is not encrypted", "connection passwords are not encrypted"},
+		catalogEncryptionProblems(&gluetypes.DataCatalogEncryptionSettings{
+			EncryptionAtRest: &gluetypes.EncryptionAtRest{CatalogEncryptionMode: gluetypes.CatalogEncryptionModeDisabled},
+		}, platformKey))
+	assert.Equal(t, []string{"metadata is encrypted with the AWS managed key", "connection passwords are encrypted with alias/other, not a platform key"},
+		catalogEncryptionProblems(&gluetypes.DataCatalogEncryptionSettings{
+			EncryptionAtRest:             &gluetypes.EncryptionAtRest{CatalogEncryptionMode: gluetypes.CatalogEncryptionModeSsekms},
+			ConnectionPasswordEncryption: &gluetypes.ConnectionPasswordEncryption{ReturnConnectionPasswordEncrypted: true, AwsKmsKeyId: awssdk.String("alias/other")},
+		}, platformKey))
+	assert.Equal(t, []string{"no encryption settings"}, catalogEncryptionProblems(nil, platformKey))
+}
+
+func TestCatalogPolicyProblems(t *testing.T) {
+	platformRoles := map[string]bool{"arn:aws:iam::111111111111:role/dl-glue-role": true}
+
+	doc, err := iampolicy.Parse(`{"Version":"2012-10-17","Statement":[
+		{"Sid":"Platform","Effect":"Allow","Principal":{"AWS":["arn:aws:iam::111111111111:role/dl-glue-role","arn:aws:iam::111111111111:root"]},
+		 "Action":"glue:GetTable","Resource":"arn:aws:glue:ap-southeast-1:111111111111:*"},
+		{"Sid":"Consumers","Effect":"Allow","Principal":{"AWS":"222222222222"},"Action":"glue:GetTable","Resource":"*"},
+		{"Sid":"LakeFormation","Effect":"Allow","Principal":{"Service":"ram.amazonaws.com"},"Action":"glue:ShareResource","Resource":"*"},
+		{"Sid":"DenyOthers","Effect":"Deny","Principal":"*","Action":"glue:*","Resource":"*"}]}`)
+	require.NoError(t, err)
+	assert.Empty(t, catalogPolicyProblems(doc, "111111111111", platformRoles, []string{"222222222222"}))
+
+	assert.Equal(t, []string{
+		"Consumers grants account 222222222222, which is not an approved consumer",
+		"approved consumer 333333333333 is not granted access",
+	}, catalogPolicyProblems(doc, "111111111111", platformRoles, []string{"333333333333"}))
+
+	doc, err = iampolicy.Parse(`{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111111111111:role/analyst"},"Action":"glue:*","Resource":"*"},
+		{"Effect":"Allow","Principal":"*","Action":"glue:GetDatabases","Resource":"*"}]}`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"statement 0 grants arn:aws:iam::111111111111:role/analyst, which is not a platform role",
+		"statement 1 grants access to any principal",
+	}, catalogPolicyProblems(doc, "111111111111", platformRoles, nil))
+
+	assert.Empty(t, catalogPolicyProblems(nil, "111111111111", platformRoles, nil), "A catalog without a policy shares nothing")
+	assert.Equal(t, []string{"approved consumer 222222222222 is not granted access"},
+		catalogPolicyProblems(nil, "111111111111", platformRoles, []string{"222222222222"}))
+}
@@ -0,0 +1,203 @@
+// =============================================================================
+// Glue Data Catalog
+// Catalog encryption and resource policy controls
+// =============================================================================
+
+package compliance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/iampolicy"
+)
+
+// accountIDPattern matches a bare account ID principal
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+func TestPlatform_GLUE_1_CatalogEncryption(t *testing.T) {
+	control := Control{ID: "Platform GLUE.1", Title: "The Glue Data Catalog should encrypt metadata and connection passwords with a platform KMS key"}
+	s := setup(t, control)
+
+	s.assess(t, control, s.catalogs(), func(ctx context.Context, catalogARN string) []string {
+		output, err := s.clients.Glue().GetDataCatalogEncryptionSettings(ctx, &glue.GetDataCatalogEncryptionSettingsInput{
+			CatalogId: awssdk.String(catalogAccount(catalogARN)),
+		})
+		if err != nil {
+			return []string{fmt.Sprintf("failed to get catalog encryption settings: %v", err)}
+		}
+		return catalogEncryptionProblems(output.DataCatalogEncryptionSettings, func(keyID string) bool {
+			return s.isPlatformKey(ctx, keyID)
+		})
+	})
+}
+
+func TestPlatform_GLUE_2_CatalogResourcePolicy(t *testing.T) {
+	control := Control{ID: "Platform GLUE.2", Title: "The Glue Data Catalog resource policy should only grant access to platform roles and approved consumer accounts"}
+	s := setup(t, control)
+
+	catalogs := s.catalogs()
+	platformRoles := map[string]bool{}
+	if len(catalogs) > 0 {
+		for _, roleARN := range s.roles(t) {
+			platformRoles[roleARN] = true
+		}
+	}
+
+	s.assess(t, control, catalogs, func(ctx context.Context, catalogARN string) []string {
+		output, err := s.clients.Glue().GetResourcePolicy(ctx, &glue.GetResourcePolicyInput{})
+		var notFound *gluetypes.EntityNotFoundException
+		if errors.As(err, &notFound) {
+			return catalogPolicyProblems(nil, catalogAccount(catalogARN), platformRoles, s.consumers)
+		}
+		if err != nil {
+			return []string{fmt.Sprintf("failed to get catalog resource policy: %v", err)}
+		}
+
+		doc, err := iampolicy.Parse(awssdk.ToString(output.PolicyInJson))
+		if err != nil {
+			return []string{err.Error()}
+		}
+		return catalogPolicyProblems(doc, catalogAccount(catalogARN), platformRoles, s.consumers)
+	})
+}
+
+// catalogs returns the ARN of the Data Catalog holding the environment's Glue
+// databases; the catalog itself carries no tags
+func (s *scope) catalogs() []string {
+	found := map[string]bool{}
+	for _, databaseARN := range s.arns("glue", "database/") {
+		parsed, err := arn.Parse(databaseARN)
+		if err != nil {
+			continue
+		}
+		parsed.Resource = "catalog"
+		found[parsed.String()] = true
+	}
+
+	catalogs := make([]string, 0, len(found))
+	for catalogARN := range found {
+		catalogs = append(catalogs, catalogARN)
+	}
+	sort.Strings(catalogs)
+	return catalogs
+}
+
+// catalogAccount returns the account owning a catalog, which is its catalog ID
+func catalogAccount(catalogARN string) string {
+	parsed, _ := arn.Parse(catalogARN)
+	return parsed.AccountID
+}
+
+// isPlatformKey reports whether keyID, a key ID, ARN or alias, names one of the
+// environment's KMS keys
+func (s *scope) isPlatformKey(ctx context.Context, keyID string) bool {
+	output, err := s.clients.KMS().DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: awssdk.String(keyID)})
+	if err != nil {
+		return false
+	}
+	_, ok := s.resources[awssdk.ToString(output.KeyMetadata.Arn)]
+	return ok
+}
+
+// catalogEncryptionProblems lists what the catalog's encryption settings leave
+// unencrypted or encrypt with a key the platform does not manage
+func catalogEncryptionProblems(settings *gluetypes.DataCatalogEncryptionSettings, platformKey func(keyID string) bool) []string {
+	if settings == nil {
+		return []string{"no encryption settings"}
+	}
+
+	var problems []string
+	atRest := settings.EncryptionAtRest
+	switch {
+	case atRest == nil || atRest.CatalogEncryptionMode == "" || atRest.CatalogEncryptionMode == gluetypes.CatalogEncryptionModeDisabled:
+		problems = append(problems, "metadata is not encrypted")
+	case awssdk.ToString(atRest.SseAwsKmsKeyId) == "":
+		problems = append(problems, "metadata is encrypted with the AWS managed key")
+	case !platformKey(awssdk.ToString(atRest.SseAwsKmsKeyId)):
+		problems = append(problems, fmt.Sprintf("metadata is encrypted with %s, not a platform key", awssdk.ToString(atRest.SseAwsKmsKeyId)))
+	}
+
+	passwords := settings.ConnectionPasswordEncryption
+	switch {
+	case passwords == nil || !passwords.ReturnConnectionPasswordEncrypted:
+		problems = append(problems, "connection passwords are not encrypted")
+	case !platformKey(awssdk.ToString(passwords.AwsKmsKeyId)):
+		problems = append(problems, fmt.Sprintf("connection passwords are encrypted with %s, not a platform key", awssdk.ToString(passwords.AwsKmsKeyId)))
+	}
+	return problems
+}
+
+// catalogPolicyProblems lists the grants of the catalog resource policy doc to
+// anyone but the owning account's root, the platform roles and the approved
+// consumer accounts, and the consumers it does not grant anything; a nil doc
+// is a catalog without a policy
+func catalogPolicyProblems(doc *iampolicy.Document, account string, platformRoles map[string]bool, consumers []string) []string {
+	approved := map[string]bool{}
+	for _, consumer := range consumers {
+		approved[consumer] = true
+	}
+
+	var problems []string
+	granted := map[string]bool{}
+	var statements iampolicy.Statements
+	if doc != nil {
+		statements = doc.Statement
+	}
+	for i, statement := range statements {
+		if statement.Effect != "Allow" || statement.Principal == nil {
+			continue
+		}
+		label := statement.Sid
+		if label == "" {
+			label = fmt.Sprintf("statement %d", i)
+		}
+		if statement.Principal.All {
+			problems = append(problems, label+" grants access to any principal")
+			continue
+		}
+
+		for _, principal := range statement.Principal.AWS {
+			owner := principalAccount(principal)
+			switch {
+			case owner == "":
+				problems = append(problems, fmt.Sprintf("%s grants unrecognized principal %s", label, principal))
+			case owner != account && approved[owner]:
+				granted[owner] = true
+			case owner != account:
+				problems = append(problems, fmt.Sprintf("%s grants account %s, which is not an approved consumer", label, owner))
+			case principal != owner && principal != fmt.Sprintf("arn:aws:iam::%s:root", owner) && !platformRoles[principal]:
+				problems = append(problems, fmt.Sprintf("%s grants %s, which is not a platform role", label, principal))
+			}
+		}
+	}
+
+	for _, consumer := range consumers {
+		if !granted[consumer] {
+			problems = append(problems, fmt.Sprintf("approved consumer %s is not granted access", consumer))
+		}
+	}
+	return problems
+}
+
+// principalAccount returns the account of an AWS principal given as an
+// account ID or an ARN, or "" when it is neither
+func principalAccount(principal string) string {
+	if accountIDPattern.MatchString(principal) {
+		return principal
+	}
+	parsed, err := arn.Parse(principal)
+	if err != nil {
+		return ""
+	}
+	return parsed.AccountID
+}
@@ -0,0 +1,165 @@
+// =============================================================================
+// CIS 1 - Identity and Access Management
+// Administrative privilege controls for the platform's IAM roles
+// =============================================================================
+
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/iampolicy"
+)
+
+// administratorAccess is the AWS managed policy granting full administrative privileges
+const administratorAccess = "arn:aws:iam::aws:policy/AdministratorAccess"
+
+func TestCIS_1_16_NoFullAdminPolicies(t *testing.T) {
+	control := Control{ID: "CIS 1.16", Title: "Ensure IAM policies that allow full \"*:*\" administrative privileges are not attached"}
+	s := setup(t, control)
+
+	s.assess(t, control, s.roles(t), func(ctx context.Context, roleARN string) []string {
+		roleName := roleARN[strings.LastIndex(roleARN, "/")+1:]
+		problems, err := s.roleAdminProblems(ctx, roleName)
+		if err != nil {
+			return []string{err.Error()}
+		}
+		return problems
+	})
+}
+
+// roles returns the ARNs of the IAM roles carrying the environment's tags; the
+// tagging API does not return IAM roles, so they are listed directly
+func (s *scope) roles(t *testing.T) []string {
+	ctx, cancel := s.clients.Context()
+	defer cancel()
+
+	var roles []string
+	paginator := iam.NewListRolesPaginator(s.clients.IAM(), &iam.ListRolesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		require.NoError(t, err)
+
+		for _, role := range page.Roles {
+			tags, err := s.clients.IAM().ListRoleTags(ctx, &iam.ListRoleTagsInput{RoleName: role.RoleName})
+			require.NoError(t, err)
+
+			found := map[string]string{}
+			for _, tag := range tags.Tags {
+				found[awssdk.ToString(tag.Key)] = awssdk.ToString(tag.Value)
+			}
+			if matchesTags(found, s.tags) {
+				roles = append(roles, awssdk.ToString(role.Arn))
+			}
+		}
+	}
+	return roles
+}
+
+// matchesTags reports whether tags has every key and value in want
+func matchesTags(tags, want map[string]string) bool {
+	for key, value := range want {
+		if tags[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// roleAdminProblems lists the inline and attached policies of a role that grant "*:*"
+func (s *scope) roleAdminProblems(ctx context.Context, roleName string) ([]string, error) {
+	client := s.clients.IAM()
+	var problems []string
+
+	inline := iam.NewListRolePoliciesPaginator(client, &iam.ListRolePoliciesInput{RoleName: awssdk.String(roleName)})
+	for inline.HasMorePages() {
+		page, err := inline.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list inline policies: %w", err)
+		}
+		for _, name := range page.PolicyNames {
+			policy, err := client.GetRolePolicy(ctx, &iam.GetRolePolicyInput{RoleName: awssdk.String(roleName), PolicyName: awssdk.String(name)})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get inline policy %s: %w", name, err)
+			}
+			if admin, err := grantsFullAdmin(awssdk.ToString(policy.PolicyDocument)); err != nil || admin {
+				problems = append(problems, policyProblem("inline policy "+name, err))
+			}
+		}
+	}
+
+	attached := iam.NewListAttachedRolePoliciesPaginator(client, &iam.ListAttachedRolePoliciesInput{RoleName: awssdk.String(roleName)})
+	for attached.HasMorePages() {
+		page, err := attached.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list attached policies: %w", err)
+		}
+		for _, policy := range page.AttachedPolicies {
+			policyARN := awssdk.ToString(policy.PolicyArn)
+			if policyARN == administratorAccess {
+				problems = append(problems, "AdministratorAccess is attached")
+				continue
+			}
+			// AWS managed policies other than AdministratorAccess are scoped by AWS
+			if strings.HasPrefix(policyARN, "arn:aws:iam::aws:policy/") {
+				continue
+			}
+
+			document, err := s.defaultPolicyVersion(ctx, policyARN)
+			if err != nil {
+				return nil, err
+			}
+			if admin, err := grantsFullAdmin(document); err != nil || admin {
+				problems = append(problems, policyProblem("attached policy "+policyARN, err))
+			}
+		}
+	}
+	return problems, nil
+}
+
+// defaultPolicyVersion returns the document of a managed policy's default version
+func (s *scope) defaultPolicyVersion(ctx context.Context, policyARN string) (string, error) {
+	policy, err := s.clients.IAM().GetPolicy(ctx, &iam.GetPolicyInput{PolicyArn: awssdk.String(policyARN)})
+	if err != nil {
+		return "", fmt.Errorf("failed to get policy %s: %w", policyARN, err)
+	}
+
+	version, err := s.clients.IAM().GetPolicyVersion(ctx, &iam.GetPolicyVersionInput{
+		PolicyArn: awssdk.String(policyARN),
+		VersionId: policy.Policy.DefaultVersionId,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get the default version of %s: %w", policyARN, err)
+	}
+
+	return awssdk.ToString(version.PolicyVersion.Document), nil
+}
+
+// grantsFullAdmin reports whether any statement allows Action "*" on Resource "*"
+func grantsFullAdmin(document string) (bool, error) {
+	doc, err := iampolicy.Parse(document)
+	if err != nil {
+		return false, err
+	}
+
+	for _, statement := range doc.Statement {
+		if statement.Effect == "Allow" && statement.Action.Contains("*") && statement.Resource.Contains("*") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// policyProblem describes a policy that grants "*:*" or could not be parsed
+func policyProblem(policy string, err error) string {
+	if err != nil {
+		return fmt.Sprintf("%s could not be parsed: %v", policy, err)
+	}
+	return policy + ` allows "*:*"`
+}
@@ -0,0 +1,79 @@
+// =============================================================================
+// CIS 3 - Logging
+// VPC flow logs and CloudWatch Logs retention for the environment
+// =============================================================================
+
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logs"
+)
+
+// minLogRetentionDays is the shortest retention accepted for a log group
+const minLogRetentionDays = 7
+
+func TestCIS_3_9_VPCFlowLogs(t *testing.T) {
+	control := Control{ID: "CIS 3.9", Title: "Ensure VPC flow logging is enabled in all VPCs"}
+	s := setup(t, control)
+
+	s.assess(t, control, s.arns("ec2", "vpc/"), func(ctx context.Context, vpcARN string) []string {
+		vpcID := strings.TrimPrefix(vpcARN[strings.LastIndex(vpcARN, ":")+1:], "vpc/")
+		output, err := s.clients.EC2().DescribeFlowLogs(ctx, &ec2.DescribeFlowLogsInput{
+			Filter: []ec2types.Filter{{Name: awssdk.String("resource-id"), Values: []string{vpcID}}},
+		})
+		if err != nil {
+			return []string{fmt.Sprintf("failed to describe flow logs: %v", err)}
+		}
+		return flowLogProblems(output.FlowLogs)
+	})
+}
+
+// flowLogProblems reports a VPC without an active flow log capturing rejected traffic
+func flowLogProblems(flowLogs []ec2types.FlowLog) []string {
+	for _, flowLog := range flowLogs {
+		active := awssdk.ToString(flowLog.FlowLogStatus) == "ACTIVE"
+		rejects := flowLog.TrafficType == ec2types.TrafficTypeAll || flowLog.TrafficType == ec2types.TrafficTypeReject
+		if active && rejects {
+			return nil
+		}
+	}
+	return []string{"no active flow log captures ALL or REJECT traffic"}
+}
+
+// TestFSBP_CloudWatch_16_LogGroupRetention has no CIS counterpart; it follows
+// the AWS Foundational Security Best Practices control CloudWatch.16
+func TestFSBP_CloudWatch_16_LogGroupRetention(t *testing.T) {
+	control := Control{ID: "FSBP CloudWatch.16", Title: "CloudWatch log groups should be retained for a specified time period"}
+	s := setup(t, control)
+
+	s.assess(t, control, s.arns("logs", "log-group:"), func(ctx context.Context, logGroupARN string) []string {
+		name := strings.TrimSuffix(logGroupARN[strings.Index(logGroupARN, "log-group:")+len("log-group:"):], ":*")
+		group, err := logs.Describe(ctx, s.clients.Logs(), name)
+		if err != nil {
+			return []string{fmt.Sprintf("failed to describe log group: %v", err)}
+		}
+		if group == nil {
+			return []string{"log group not found"}
+		}
+		return retentionProblems(group.RetentionInDays)
+	})
+}
+
+// retentionProblems reports a retention that is unset, and so never expires, or too short
+func retentionProblems(days *int32) []string {
+	switch {
+	case days == nil:
+		return []string{"retention is not set, so events are kept forever"}
+	case *days < minLogRetentionDays:
+		return []string{fmt.Sprintf("retention is %d days, want at least %d", *days, minLogRetentionDays)}
+	}
+	return nil
+}
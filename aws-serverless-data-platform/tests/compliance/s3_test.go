@@ -0,0 +1,102 @@
+// =============================================================================
+// CIS 2.1 - Simple Storage Service
+// Encryption, transport and public access controls for data-lake buckets
+// =============================================================================
+
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/iampolicy"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/s3sec"
+)
+
+// bucketName returns the bucket an S3 bucket ARN names
+func bucketName(bucketARN string) string {
+	return strings.TrimPrefix(bucketARN, "arn:aws:s3:::")
+}
+
+func TestCIS_2_1_1_S3EncryptionAtRest(t *testing.T) {
+	control := Control{ID: "CIS 2.1.1", Title: "Ensure all S3 buckets employ encryption-at-rest"}
+	s := setup(t, control)
+
+	s.assess(t, control, s.arns("s3", ""), func(ctx context.Context, bucketARN string) []string {
+		output, err := s.clients.S3().GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{
+			Bucket: awssdk.String(bucketName(bucketARN)),
+		})
+		if err != nil {
+			return []string{fmt.Sprintf("failed to get default encryption: %v", err)}
+		}
+
+		for _, rule := range output.ServerSideEncryptionConfiguration.Rules {
+			if rule.ApplyServerSideEncryptionByDefault != nil {
+				return nil
+			}
+		}
+		return []string{"no default encryption rule is configured"}
+	})
+}
+
+func TestCIS_2_1_2_S3DenyInsecureTransport(t *testing.T) {
+	control := Control{ID: "CIS 2.1.2", Title: "Ensure S3 Bucket Policy is set to deny HTTP requests"}
+	s := setup(t, control)
+
+	s.assess(t, control, s.arns("s3", ""), func(ctx context.Context, bucketARN string) []string {
+		output, err := s.clients.S3().GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{
+			Bucket: awssdk.String(bucketName(bucketARN)),
+		})
+		if err != nil {
+			return []string{fmt.Sprintf("failed to get bucket policy: %v", err)}
+		}
+
+		doc, err := iampolicy.Parse(awssdk.ToString(output.Policy))
+		if err != nil {
+			return []string{err.Error()}
+		}
+		return s3sec.CheckPolicy(doc, bucketARN, s3sec.Options{})
+	})
+}
+
+func TestCIS_2_1_5_S3BlockPublicAccess(t *testing.T) {
+	control := Control{ID: "CIS 2.1.5", Title: "Ensure that S3 Buckets are configured with 'Block public access (bucket settings)'"}
+	s := setup(t, control)
+
+	s.assess(t, control, s.arns("s3", ""), func(ctx context.Context, bucketARN string) []string {
+		output, err := s.clients.S3().GetPublicAccessBlock(ctx, &s3.GetPublicAccessBlockInput{
+			Bucket: awssdk.String(bucketName(bucketARN)),
+		})
+		if err != nil {
+			return []string{fmt.Sprintf("failed to get public access block: %v", err)}
+		}
+		return publicAccessBlockProblems(output.PublicAccessBlockConfiguration)
+	})
+}
+
+// publicAccessBlockProblems lists the bucket public access block settings that are off
+func publicAccessBlockProblems(config *s3types.PublicAccessBlockConfiguration) []string {
+	if config == nil {
+		return []string{"no public access block is configured"}
+	}
+
+	settings := map[string]*bool{
+		"BlockPublicAcls":       config.BlockPublicAcls,
+		"BlockPublicPolicy":     config.BlockPublicPolicy,
+		"IgnorePublicAcls":      config.IgnorePublicAcls,
+		"RestrictPublicBuckets": config.RestrictPublicBuckets,
+	}
+
+	var problems []string
+	for _, name := range []string{"BlockPublicAcls", "BlockPublicPolicy", "IgnorePublicAcls", "RestrictPublicBuckets"} {
+		if !awssdk.ToBool(settings[name]) {
+			problems = append(problems, name+" is disabled")
+		}
+	}
+	return problems
+}
@@ -0,0 +1,215 @@
+// =============================================================================
+// Module Contract Tests
+// Static checks of the variables and outputs each module promises its callers
+// =============================================================================
+
+// Package contract pins the interface of every Terraform module: the variables
+// callers must set and the outputs other modules, Terragrunt units and tests
+// read, and the provider versions and module sources they pin. It parses the
+// modules' HCL only, so it needs neither Terraform nor AWS.
+package contract
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tfmodule"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tggraph"
+)
+
+const (
+	// modulesDir and environmentsDir are relative to this package
+	modulesDir      = "../../modules"
+	environmentsDir = "../../environments"
+)
+
+// contract is the interface a module must keep
+type contract struct {
+	// required are all the variables without a default; adding one breaks every caller
+	required []string
+
+	// outputs are the outputs read by other modules, Terragrunt units and tests
+	outputs []string
+
+	// vars builds the variables the module tests pass, which must all be declared
+	vars func(opts ...testutil.Option) map[string]interface{}
+}
+
+var contracts = map[string]contract{
+	"networking": {
+		required: []string{"account_id", "environment", "networking", "region", "vpc_name"},
+		outputs: []string{
+			"vpc_id", "vpc_arn", "vpc_cidr_block", "internet_gateway_id",
+			"public_subnet_ids", "private_subnet_ids", "database_subnet_ids", "private_subnet_cidrs",
+			"nat_gateway_ids", "nat_gateway_public_ips", "private_route_table_ids", "database_route_table_id",
+			"flow_log_group_name", "flow_log_format",
+			"gateway_endpoint_ids", "interface_endpoint_ids", "vpc_endpoints_security_group_id",
+		},
+		vars: testutil.NewNetworkingVars,
+	},
+	"storage": {
+		required: []string{"account_id", "environment", "project_name", "region", "security", "storage"},
+		outputs: []string{
+			"raw_bucket_id", "processed_bucket_id", "curated_bucket_id",
+			"raw_bucket_encryption", "processed_bucket_encryption", "curated_bucket_encryption",
+			"raw_bucket_lifecycle_configuration", "processed_bucket_lifecycle_configuration",
+			"curated_bucket_lifecycle_configuration",
+			"raw_database_name", "processed_database_name", "curated_database_name",
+			"glue_log_group_name", "glue_log_group_arn",
+			// Bucket inputs of the orchestration and monitoring modules
+			"raw_bucket_arn", "processed_bucket_arn", "curated_bucket_arn", "all_bucket_ids", "main_database_name",
+		},
+		vars: testutil.NewStorageVars,
+	},
+	"security": {
+		required: []string{"environment", "project_name", "vpc_id"},
+		outputs: []string{
+			"data_kms_key_id", "data_kms_key_arn", "secrets_kms_key_id", "secrets_kms_key_arn",
+			"glue_role_arn", "glue_role_name", "glue_catalog_access_policy_arn",
+			"data_processing_security_group_id", "kinesis_analytics_role_name", "permissions_boundary_arn",
+		},
+		vars: testutil.NewSecurityVars,
+	},
+	"analytics": {
+		required: []string{"athena_results_bucket", "environment", "glue_database_name", "kms_key_id", "project_name", "vpc_id"},
+		outputs:  []string{"athena_workgroup_name", "athena_workgroup_arn"},
+		vars:     testutil.NewAnalyticsVars,
+	},
+	"orchestration": {
+		required: []string{
+			"curated_data_bucket", "environment", "error_bucket", "eventbridge_role_arn", "glue_database_name",
+			"kms_key_id", "lambda_role_arn", "lambda_security_group_id", "lambda_subnet_ids",
+			"processed_data_bucket", "project_name", "raw_data_bucket", "step_functions_role_arn", "vpc_id",
+		},
+		// Inputs of the monitoring module
+		outputs: []string{
+			"data_ingestion_lambda_name", "data_transformation_lambda_name", "data_quality_lambda_name",
+			"step_functions_state_machine_name", "step_functions_state_machine_arn", "dlq_arn",
+		},
+	},
+	"monitoring": {
+		required: []string{"environment", "kms_key_id", "project_name"},
+		outputs:  []string{"critical_alerts_topic_arn", "warning_alerts_topic_arn", "data_quality_alerts_topic_arn"},
+	},
+}
+
+func TestModuleContracts(t *testing.T) {
+	dirs, err := filepath.Glob(filepath.Join(modulesDir, "*", "variables.tf"))
+	require.NoError(t, err)
+	require.Len(t, dirs, len(contracts), "Every module needs a contract")
+
+	for name, c := range contracts {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			module, err := tfmodule.Load(filepath.Join(modulesDir, name))
+			require.NoError(t, err)
+
+			assert.Equal(t, c.required, module.RequiredVariables(),
+				"Required variables changed; every caller must be updated with the contract")
+			assert.Empty(t, module.MissingOutputs(c.outputs), "Outputs other modules and tests depend on were removed")
+
+			if c.vars != nil {
+				var names []string
+				for variable := range c.vars() {
+					names = append(names, variable)
+				}
+				assert.Empty(t, module.MissingVariables(names), "The testutil builder sets variables the module does not declare")
+			}
+		})
+	}
+}
+
+// moduleSourcePattern extracts the module name from a unit's terraform source
+var moduleSourcePattern = regexp.MustCompile(`modules//([\w-]+)`)
+
+func TestTerragruntDependencyOutputs(t *testing.T) {
+	environments, err := filepath.Glob(filepath.Join(environmentsDir, "*", "*"))
+	require.NoError(t, err)
+	require.NotEmpty(t, environments)
+
+	for _, environment := range environments {
+		graph, err := tggraph.Load(environment)
+		require.NoError(t, err)
+
+		modules := map[string]string{}
+		for name, unit := range graph.Units {
+			modules[name] = unitModule(t, unit.Dir)
+		}
+
+		for name, unit := range graph.Units {
+			for dependency, outputs := range dependencyOutputs(t, unit.Dir) {
+				rel, err := filepath.Rel(graph.Root, filepath.Join(unit.Dir, dependency))
+				require.NoError(t, err)
+				dependencyModule, ok := modules[filepath.ToSlash(rel)]
+				require.True(t, ok, "%s depends on %s, which is not a unit", name, dependency)
+
+				module, err := tfmodule.Load(filepath.Join(modulesDir, dependencyModule))
+				require.NoError(t, err)
+				assert.Empty(t, module.MissingOutputs(outputs),
+					"%s reads outputs of %s that the %s module does not declare", name, rel, dependencyModule)
+				assert.Subset(t, contracts[dependencyModule].outputs, outputs,
+					"Add the outputs %s reads to the %s contract", name, dependencyModule)
+			}
+		}
+	}
+}
+
+// unitModule returns the name of the module a Terragrunt unit deploys
+func unitModule(t *testing.T, dir string) string {
+	src, err := os.ReadFile(filepath.Join(dir, tggraph.ConfigFile))
+	require.NoError(t, err)
+
+	match := moduleSourcePattern.FindSubmatch(src)
+	require.NotNil(t, match, "%s must deploy a module from %s", dir, modulesDir)
+	return string(match[1])
+}
+
+// dependencyOutputs maps the config_path of each dependency block in the unit
+// to the outputs its inputs read from it
+func dependencyOutputs(t *testing.T, dir string) map[string][]string {
+	path := filepath.Join(dir, tggraph.ConfigFile)
+	src, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	file, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	body := file.Body.(*hclsyntax.Body)
+
+	paths := map[string]string{}
+	for _, block := range body.Blocks {
+		if block.Type != "dependency" {
+			continue
+		}
+		value, diags := block.Body.Attributes["config_path"].Expr.Value(nil)
+		require.False(t, diags.HasErrors(), "%s: dependency %q needs a literal config_path", path, block.Labels[0])
+		paths[block.Labels[0]] = value.AsString()
+	}
+
+	outputs := map[string][]string{}
+	inputs, ok := body.Attributes["inputs"]
+	if !ok {
+		return outputs
+	}
+	for _, traversal := range inputs.Expr.Variables() {
+		if traversal.RootName() != "dependency" || len(traversal) < 4 {
+			continue
+		}
+		name := traversal[1].(hcl.TraverseAttr).Name
+		output := traversal[3].(hcl.TraverseAttr).Name
+		require.Contains(t, paths, name, "%s reads dependency.%s, which is not declared", path, name)
+		outputs[paths[name]] = append(outputs[paths[name]], output)
+	}
+	for dependency := range outputs {
+		sort.Strings(outputs[dependency])
+	}
+	return outputs
+}
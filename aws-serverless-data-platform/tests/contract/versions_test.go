@@ -0,0 +1,27 @@
+package contract
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/pinning"
+)
+
+// approvedProviders are the provider versions modules may allow and lock;
+// widen a range here when a provider upgrade has been tested
+var approvedProviders = pinning.Policy{
+	"hashicorp/aws":    pinning.NewRange("5.0", "6.0"),
+	"hashicorp/random": pinning.NewRange("3.5", "4.0"),
+}
+
+func TestVersionPinning(t *testing.T) {
+	offenders, err := pinning.Audit("../..", approvedProviders)
+	require.NoError(t, err)
+
+	if len(offenders) > 0 {
+		var table strings.Builder
+		require.NoError(t, pinning.WriteTable(&table, offenders))
+		t.Fatalf("%d version pins break the policy:\n%s", len(offenders), table.String())
+	}
+}
@@ -0,0 +1,89 @@
+# =============================================================================
+# Test Base Stack - Long-Lived Networking and Security for Module Tests
+# =============================================================================
+# Module tests attach to this stack instead of applying networking and security
+# themselves. It exports its outputs to SSM Parameter Store, where the fixture
+# package discovers them when config/testing.yaml sets base_parameter_path:
+#
+#   terraform -chdir=tests/fixtures/base apply \
+#     -var region=us-east-1 -var account_id=356240508702
+
+locals {
+  name = "terratest-base"
+
+  subnets = {
+    private  = [for octet in [1, 2, 3] : cidrsubnet(var.vpc_cidr, 8, octet)]
+    public   = [for octet in [101, 102, 103] : cidrsubnet(var.vpc_cidr, 8, octet)]
+    database = [for octet in [201, 202, 203] : cidrsubnet(var.vpc_cidr, 8, octet)]
+  }
+}
+
+module "networking" {
+  source = "../../../modules/networking"
+
+  environment = "test"
+  region      = var.region
+  account_id  = var.account_id
+  vpc_name    = local.name
+
+  networking = {
+    vpc = {
+      cidr                 = var.vpc_cidr
+      enable_dns_hostnames = true
+      enable_dns_support   = true
+    }
+    subnets            = local.subnets
+    availability_zones = 3
+    nat_gateway = {
+      enable             = true
+      single_nat_gateway = true
+    }
+    flow_logs = {
+      enable         = false
+      retention_days = 7
+    }
+  }
+
+  # Interface endpoints are billed per hour and no module test needs them
+  interface_endpoints = []
+
+  common_tags = var.common_tags
+}
+
+module "security" {
+  source = "../../../modules/security"
+
+  project_name = local.name
+  environment  = "test"
+  vpc_id       = module.networking.vpc_id
+
+  common_tags = var.common_tags
+}
+
+# =============================================================================
+# Parameter Store Exports
+# =============================================================================
+
+locals {
+  exports = {
+    vpc_id                            = module.networking.vpc_id
+    vpc_cidr                          = module.networking.vpc_cidr_block
+    private_subnet_ids                = join(",", module.networking.private_subnet_ids)
+    database_subnet_ids               = join(",", module.networking.database_subnet_ids)
+    data_kms_key_arn                  = module.security.data_kms_key_arn
+    glue_role_arn                     = module.security.glue_role_arn
+    glue_role_name                    = module.security.glue_role_name
+    data_processing_security_group_id = module.security.data_processing_security_group_id
+  }
+
+  list_exports = ["private_subnet_ids", "database_subnet_ids"]
+}
+
+resource "aws_ssm_parameter" "exports" {
+  for_each = local.exports
+
+  name        = "${var.parameter_path}/${each.key}"
+  description = "Test base stack output ${each.key}"
+  type        = contains(local.list_exports, each.key) ? "StringList" : "String"
+  value       = each.value
+}
@@ -0,0 +1,13 @@
+# =============================================================================
+# Test Base Stack - Outputs
+# =============================================================================
+
+output "parameter_path" {
+  description = "SSM Parameter Store path the outputs are exported under"
+  value       = var.parameter_path
+}
+
+output "parameter_names" {
+  description = "Names of the exported parameters"
+  value       = [for parameter in aws_ssm_parameter.exports : parameter.name]
+}
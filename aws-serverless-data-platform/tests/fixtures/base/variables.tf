@@ -0,0 +1,42 @@
+# =============================================================================
+# Test Base Stack - Input Variables
+# =============================================================================
+
+variable "region" {
+  description = "AWS region the base stack is deployed in; deploy one stack per test region"
+  type        = string
+}
+
+variable "account_id" {
+  description = "AWS account ID the module tests run in"
+  type        = string
+}
+
+variable "parameter_path" {
+  description = "SSM Parameter Store path the stack exports its outputs under"
+  type        = string
+  default     = "/terratest/base"
+
+  validation {
+    condition     = can(regex("^/[A-Za-z0-9_./-]+[^/]$", var.parameter_path))
+    error_message = "The parameter path must start with a slash and not end with one."
+  }
+}
+
+variable "vpc_cidr" {
+  description = "CIDR block of the shared VPC; keep it clear of the ranges ephemeral module tests use"
+  type        = string
+  default     = "10.240.0.0/16"
+}
+
+variable "common_tags" {
+  description = "Tags applied to every resource in the stack"
+  type        = map(string)
+  default = {
+    Project     = "terratest"
+    Environment = "test"
+    Owner       = "data-platform"
+    CostCenter  = "DataEngineering-Test"
+    Purpose     = "test-base"
+  }
+}
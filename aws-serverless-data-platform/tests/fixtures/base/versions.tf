@@ -0,0 +1,24 @@
+# =============================================================================
+# Test Base Stack - Terraform and Provider Requirements
+# =============================================================================
+
+terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  region = var.region
+
+  # The sweeper skips resources tagged TestBaseStack, even though the
+  # security module's names match its *-test-* pattern
+  default_tags {
+    tags = merge(var.common_tags, { TestBaseStack = "true" })
+  }
+}
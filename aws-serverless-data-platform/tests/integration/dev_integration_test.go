@@ -6,80 +6,249 @@
 package integration
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gruntwork-io/terratest/modules/aws"
+	"github.com/gruntwork-io/terratest/modules/random"
 	"github.com/gruntwork-io/terratest/modules/shell"
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/athena"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/backend"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/cost"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/dataquality"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/deadline"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/integrity"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/lifecycle"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/report"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/residue"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/runall"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/testconfig"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tfplan"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tggraph"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/transient"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/wait"
 )
 
+var (
+	includeUnits = flag.String("units", "", "comma-separated environment units to deploy, with the units they depend on; empty deploys every unit")
+	parallelism  = flag.Int("parallelism", 0, "units Terragrunt applies or destroys at once; zero leaves it to Terragrunt")
+)
+
+// TestMain runs the suite against the account selected by TERRATEST_ASSUME_ROLE_ARN
+func TestMain(m *testing.M) {
+	testutil.Main(m)
+}
+
 // TestDevEnvironmentIntegration performs end-to-end testing of the dev environment
 func TestDevEnvironmentIntegration(t *testing.T) {
 	// Skip long-running integration tests in short mode
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
 	}
+	report.Track(t)
 
-	awsRegion := "us-east-1"
-	environment := "dev"
+	config, err := testconfig.Load()
+	require.NoError(t, err)
+	awsRegion := config.Integration.Region
+	environment := config.Integration.Environment
 
 	// Terragrunt options for the entire environment
 	terragruntOptions := &terraform.Options{
 		TerraformDir: fmt.Sprintf("../../environments/%s/%s", environment, awsRegion),
-		EnvVars: map[string]string{
-			"AWS_DEFAULT_REGION": awsRegion,
-		},
+		EnvVars:      testutil.EnvVars(t, testutil.WithRegion(awsRegion)),
 	}
 
-	// Ensure cleanup happens
+	// Fail fast on a state backend the applies would trip over, e.g. a lock left by a crashed run
+	root, err := testconfig.FindRoot()
+	require.NoError(t, err)
+	backendConfig, err := backend.FromRepo(root, environment, awsRegion)
+	require.NoError(t, err)
+	backend.Preflight(t, backendConfig)
+
+	// Apply and destroy orders come from the units' dependency blocks; -units
+	// narrows the graph to the selected units and their dependencies, as
+	// Terragrunt's include filters do
+	graph, err := tggraph.Load(terragruntOptions.TerraformDir)
+	require.NoError(t, err)
+	selected := selectedUnits(*includeUnits)
+	if len(selected) > 0 {
+		graph, err = graph.Subgraph(selected...)
+		require.NoError(t, err)
+	}
+	applyOrder, err := graph.ApplyOrder()
+	require.NoError(t, err)
+	destroyOrder, err := graph.DestroyOrder()
+	require.NoError(t, err)
+
+	runOptions := runall.Options{
+		Dir:         terragruntOptions.TerraformDir,
+		EnvVars:     terragruntOptions.EnvVars,
+		IncludeDirs: selected,
+		Parallelism: *parallelism,
+	}
+
+	// Phases stop cleanupReserve before the -timeout deadline so cleanup always gets to run
+	watchdog := deadline.Start(t, cleanupReserve)
+
+	// Ensure cleanup happens, unless the environment is kept for triage
 	defer func() {
-		t.Log("Starting cleanup of integration test resources...")
-		cleanupIntegrationTest(t, terragruntOptions)
+		if keepForTriage(t, terragruntOptions, runOptions, applyOrder) {
+			return
+		}
+		logging.New(t).Info("Starting cleanup of integration test resources")
+		ctx, cancel := watchdog.CleanupContext()
+		defer cancel()
+		cleanupIntegrationTest(ctx, t, terragruntOptions, runOptions, destroyOrder)
 	}()
 
-	// Test deployment in phases
-	t.Run("Phase1_Networking", func(t *testing.T) {
-		testNetworkingDeployment(t, terragruntOptions, environment, awsRegion)
-	})
+	// Gate the deployment on the estimated monthly cost of the environment
+	if !t.Run("Phase0_CostEstimate", func(t *testing.T) {
+		testCostEstimate(t, terragruntOptions, applyOrder, awsRegion)
+	}) {
+		t.Fatal("Estimated cost check failed, skipping deployment")
+	}
 
-	t.Run("Phase2_Storage", func(t *testing.T) {
-		testStorageDeployment(t, terragruntOptions, environment, awsRegion)
+	// Deploy every unit with one run-all apply, which skips the units whose
+	// dependency failed, then record and validate each unit that deployed
+	t.Run("Phase1_Deploy", func(t *testing.T) {
+		result := deployEnvironment(t, watchdog, runOptions, applyOrder)
+		for _, unit := range applyOrder {
+			t.Run(unit, func(t *testing.T) {
+				unitOptions := &terraform.Options{
+					TerraformDir:    fmt.Sprintf("%s/%s", terragruntOptions.TerraformDir, unit),
+					TerraformBinary: "terragrunt",
+					EnvVars:         terragruntOptions.EnvVars,
+				}
+				report.Apply(t, unitOptions, func() {
+					requireDeployed(t, result.Unit(unit))
+				})
+				if validate, ok := unitValidations[unit]; ok {
+					validate(t, terragruntOptions, environment, awsRegion)
+				}
+			})
+		}
 	})
 
-	t.Run("Phase3_EndToEnd", func(t *testing.T) {
+	t.Run("Phase2_EndToEnd", func(t *testing.T) {
+		if watchdog.Expired() {
+			t.Skip("Out of time before the end-to-end workflow, leaving it for cleanup")
+		}
+		if !slices.Contains(applyOrder, "03-storage") {
+			t.Skip("03-storage is not among the -units deployed")
+		}
 		testEndToEndWorkflow(t, terragruntOptions, environment, awsRegion)
 	})
+
+	t.Run("Phase3_Iceberg", func(t *testing.T) {
+		if watchdog.Expired() {
+			t.Skip("Out of time before the Iceberg table test, leaving it for cleanup")
+		}
+		if !slices.Contains(applyOrder, "03-storage") {
+			t.Skip("03-storage is not among the -units deployed")
+		}
+		testIcebergTable(t, terragruntOptions, awsRegion)
+	})
 }
 
-// testNetworkingDeployment tests the networking module deployment
-func testNetworkingDeployment(t *testing.T, terragruntOptions *terraform.Options, environment, region string) {
-	networkingDir := fmt.Sprintf("%s/01-networking", terragruntOptions.TerraformDir)
+const (
+	// cleanupReserve is kept back from the -timeout deadline for destroying the environment
+	cleanupReserve = 20 * time.Minute
+	// unitTimeout is the share of the run-all apply each environment unit gets
+	unitTimeout = 25 * time.Minute
+)
+
+// unitValidations holds the post-apply checks for each environment unit
+var unitValidations = map[string]func(t *testing.T, terragruntOptions *terraform.Options, environment, region string){
+	"01-networking": validateNetworkingDeployment,
+	"03-storage":    validateStorageDeployment,
+}
 
-	networkingOptions := &terraform.Options{
-		TerraformDir: networkingDir,
-		EnvVars:      terragruntOptions.EnvVars,
+// testCostEstimate plans each environment unit and checks the combined monthly cost against the budget
+func testCostEstimate(t *testing.T, terragruntOptions *terraform.Options, units []string, region string) {
+	var plans []*tfplan.Plan
+	for _, unit := range units {
+		plans = append(plans, tfplan.Run(t, &terraform.Options{
+			TerraformDir:    fmt.Sprintf("%s/%s", terragruntOptions.TerraformDir, unit),
+			TerraformBinary: "terragrunt",
+			EnvVars:         terragruntOptions.EnvVars,
+		}))
 	}
 
-	// Deploy networking
-	t.Log("Deploying networking infrastructure...")
-	shell.RunCommand(t, shell.Command{
-		Command:    "terragrunt",
-		Args:       []string{"init"},
-		WorkingDir: networkingDir,
-	})
+	clients := awsclients.New(t, awsclients.WithRegion(region))
+	cost.AssertWithinBudget(t, cost.NewPricingAPI(clients.Pricing()), region, plans...)
+}
 
-	shell.RunCommand(t, shell.Command{
-		Command:    "terragrunt",
-		Args:       []string{"apply", "-auto-approve"},
-		WorkingDir: networkingDir,
+// selectedUnits splits the -units flag into unit names
+func selectedUnits(list string) []string {
+	var units []string
+	for _, unit := range strings.Split(list, ",") {
+		if unit = strings.TrimSpace(unit); unit != "" {
+			units = append(units, unit)
+		}
+	}
+	return units
+}
+
+// deployEnvironment applies the units with `terragrunt run-all apply` within
+// unitTimeout per unit, retrying known transient errors and snapshotting the
+// state and outputs of the units that had not finished when it runs out of
+// time; a failed run fails t but returns the result so each unit is reported
+func deployEnvironment(t *testing.T, watchdog *deadline.Watchdog, runOptions runall.Options, units []string) *runall.Result {
+	result := &runall.Result{}
+	err := watchdog.Phase(t, "deploy", time.Duration(len(units))*unitTimeout, func(ctx context.Context) error {
+		_, err := transient.Do(ctx, t, "run-all apply", func() (string, error) {
+			var err error
+			result, err = runall.Run(ctx, t, "apply", runOptions)
+			return result.Output, err
+		})
+		return err
 	})
+	if errors.Is(err, deadline.ErrTimeout) {
+		for _, unit := range units {
+			if result.Unit(unit).Status == runall.StatusNotFinished {
+				deadline.Snapshot(t, filepath.Join(runOptions.Dir, unit), runOptions.EnvVars, "terragrunt")
+			}
+		}
+	}
+	if err != nil {
+		t.Errorf("Failed to deploy the environment: %v", err)
+	}
+	return result
+}
+
+// requireDeployed stops the test unless unit was applied
+func requireDeployed(t *testing.T, unit runall.Unit) {
+	switch unit.Status {
+	case runall.StatusSucceeded:
+	case runall.StatusFailed:
+		t.Fatalf("Deployment of %s failed:\n%s", unit.Name, strings.Join(unit.Errors, "\n"))
+	case runall.StatusDependencyFailed:
+		t.Fatalf("Deployment of %s skipped because a unit it depends on failed", unit.Name)
+	default:
+		t.Fatalf("Deployment of %s did not finish", unit.Name)
+	}
+}
 
-	// Wait a bit for resources to be fully created
-	time.Sleep(30 * time.Second)
+// validateNetworkingDeployment checks the applied networking unit
+func validateNetworkingDeployment(t *testing.T, terragruntOptions *terraform.Options, environment, region string) {
+	networkingDir := fmt.Sprintf("%s/01-networking", terragruntOptions.TerraformDir)
 
 	// Validate networking outputs
 	vpcID := shell.RunCommandAndGetOutput(t, shell.Command{
@@ -90,35 +259,22 @@ func testNetworkingDeployment(t *testing.T, terragruntOptions *terraform.Options
 
 	require.NotEmpty(t, vpcID, "VPC ID should not be empty")
 
-	// Verify VPC exists in AWS
+	// Verify VPC exists in AWS once it is available
+	clients := awsclients.New(t, awsclients.WithRegion(region))
+	wait.Until(t, "VPC "+vpcID+" to be available", wait.VPCAvailable(clients.EC2(), vpcID), wait.DefaultOptions())
 	vpc := aws.GetVpcById(t, vpcID, region)
 	assert.NotNil(t, vpc)
-	assert.Equal(t, "10.0.0.0/16", *vpc.CidrBlock) // Dev environment CIDR
+	config, err := testconfig.Load()
+	require.NoError(t, err)
+	assert.Equal(t, config.Integration.VPCCIDR, *vpc.CidrBlock)
 
-	t.Logf("✅ Networking deployment successful. VPC ID: %s", vpcID)
+	logging.New(t).Success("Networking deployment successful", "vpc", vpcID)
 }
 
-// testStorageDeployment tests the storage module deployment
-func testStorageDeployment(t *testing.T, terragruntOptions *terraform.Options, environment, region string) {
+// validateStorageDeployment checks the applied storage unit
+func validateStorageDeployment(t *testing.T, terragruntOptions *terraform.Options, environment, region string) {
 	storageDir := fmt.Sprintf("%s/03-storage", terragruntOptions.TerraformDir)
 
-	// Deploy storage
-	t.Log("Deploying storage infrastructure...")
-	shell.RunCommand(t, shell.Command{
-		Command:    "terragrunt",
-		Args:       []string{"init"},
-		WorkingDir: storageDir,
-	})
-
-	shell.RunCommand(t, shell.Command{
-		Command:    "terragrunt",
-		Args:       []string{"apply", "-auto-approve"},
-		WorkingDir: storageDir,
-	})
-
-	// Wait for S3 eventual consistency
-	time.Sleep(15 * time.Second)
-
 	// Validate storage outputs
 	rawBucketID := shell.RunCommandAndGetOutput(t, shell.Command{
 		Command:    "terragrunt",
@@ -142,122 +298,250 @@ func testStorageDeployment(t *testing.T, terragruntOptions *terraform.Options, e
 	require.NotEmpty(t, processedBucketID, "Processed bucket ID should not be empty")
 	require.NotEmpty(t, curatedBucketID, "Curated bucket ID should not be empty")
 
-	// Verify buckets exist and have correct configuration
-	assert.True(t, aws.S3BucketExists(t, region, rawBucketID))
-	assert.True(t, aws.S3BucketExists(t, region, processedBucketID))
-	assert.True(t, aws.S3BucketExists(t, region, curatedBucketID))
+	// Wait for S3 eventual consistency, then verify the buckets' configuration
+	clients := awsclients.New(t, awsclients.WithRegion(region))
+	for _, bucketID := range []string{rawBucketID, processedBucketID, curatedBucketID} {
+		wait.Until(t, "bucket "+bucketID, wait.BucketExists(clients.S3(), bucketID), wait.DefaultOptions())
+	}
+	aws.AssertS3BucketExists(t, region, rawBucketID)
+	aws.AssertS3BucketExists(t, region, processedBucketID)
+	aws.AssertS3BucketExists(t, region, curatedBucketID)
 
 	// Test bucket encryption
-	assert.True(t, aws.AssertS3BucketHasDefaultEncryption(t, region, rawBucketID))
-	assert.True(t, aws.AssertS3BucketHasDefaultEncryption(t, region, processedBucketID))
-	assert.True(t, aws.AssertS3BucketHasDefaultEncryption(t, region, curatedBucketID))
+	assertS3BucketHasDefaultEncryption(t, region, rawBucketID)
+	assertS3BucketHasDefaultEncryption(t, region, processedBucketID)
+	assertS3BucketHasDefaultEncryption(t, region, curatedBucketID)
 
 	// Test bucket versioning
-	assert.True(t, aws.GetS3BucketVersioning(t, region, rawBucketID))
-	assert.True(t, aws.GetS3BucketVersioning(t, region, processedBucketID))
-	assert.True(t, aws.GetS3BucketVersioning(t, region, curatedBucketID))
+	assert.Equal(t, "Enabled", aws.GetS3BucketVersioning(t, region, rawBucketID))
+	assert.Equal(t, "Enabled", aws.GetS3BucketVersioning(t, region, processedBucketID))
+	assert.Equal(t, "Enabled", aws.GetS3BucketVersioning(t, region, curatedBucketID))
+
+	// Test lifecycle rules against the environment's configured policy
+	root, err := testconfig.FindRoot()
+	require.NoError(t, err)
+	policy, err := lifecycle.LoadPolicy(root, environment)
+	require.NoError(t, err)
+	for layer, bucketID := range map[string]string{"raw": rawBucketID, "processed": processedBucketID, "curated": curatedBucketID} {
+		want, err := policy.Expected(layer)
+		require.NoError(t, err)
+		lifecycle.AssertBucket(t, clients, bucketID, want)
+	}
 
-	t.Logf("✅ Storage deployment successful. Buckets: %s, %s, %s",
-		rawBucketID, processedBucketID, curatedBucketID)
+	logging.New(t).Success("Storage deployment successful",
+		"raw_bucket", rawBucketID, "processed_bucket", processedBucketID, "curated_bucket", curatedBucketID)
 }
 
-// testEndToEndWorkflow tests a simple data flow through the platform
+// testEndToEndWorkflow runs sample data through the platform: it is uploaded to the
+// raw bucket, catalogued by a Glue crawler and queried back with Athena
 func testEndToEndWorkflow(t *testing.T, terragruntOptions *terraform.Options, environment, region string) {
-	t.Log("Testing end-to-end data workflow...")
+	logger := logging.New(t)
+	defer logger.Phase("end-to-end")()
 
-	// Get storage bucket information
+	// Get storage bucket and catalog information
 	storageDir := fmt.Sprintf("%s/03-storage", terragruntOptions.TerraformDir)
 
-	rawBucketID := shell.RunCommandAndGetOutput(t, shell.Command{
-		Command:    "terragrunt",
-		Args:       []string{"output", "-raw", "raw_bucket_id"},
-		WorkingDir: storageDir,
-	})
+	rawBucketID := terragruntOutput(t, storageDir, "raw_bucket_id")
+	processedBucketID := terragruntOutput(t, storageDir, "processed_bucket_id")
+	rawDatabaseName := terragruntOutput(t, storageDir, "raw_database_name")
+
+	clients := awsclients.New(t, awsclients.WithRegion(region))
 
-	// Test data upload to raw bucket
-	testData := "test-data-" + fmt.Sprintf("%d", time.Now().Unix())
-	testKey := "test/sample-data.txt"
+	runID := strings.ToLower(random.UniqueId())
+	dataPrefix := fmt.Sprintf("e2e/%s/events/", runID)
+	testKey := dataPrefix + "events.csv"
+	fixture, expectedRows := loadFixture(t, "events.csv")
 
-	t.Log("Uploading test data to raw bucket...")
-	aws.PutS3BucketObject(t, region, rawBucketID, testKey, testData)
+	// Upload the fixture to the raw bucket with a checksum S3 validates on put
+	logger.Info("Uploading sample data to raw bucket", "bucket", rawBucketID, "key", testKey)
+	integrity.Put(t, clients, rawBucketID, testKey, []byte(fixture))
+	defer func() {
+		ctx, cancel := clients.Context()
+		defer cancel()
+
+		_, err := clients.S3().DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: awssdk.String(rawBucketID),
+			Key:    awssdk.String(testKey),
+		})
+		assert.NoError(t, err, "Failed to delete test object")
+	}()
 
 	// Verify data was uploaded
 	actualData := aws.GetS3ObjectContents(t, region, rawBucketID, testKey)
-	assert.Equal(t, testData, actualData)
+	assert.Equal(t, fixture, actualData)
 
-	// Test data lifecycle (verify object transitions would work)
-	// Note: Actual lifecycle transitions take time, so we just verify the policies exist
-	bucketPolicy := aws.GetS3BucketPolicy(t, region, rawBucketID)
-	assert.NotNil(t, bucketPolicy) // Should have lifecycle policies
+	// Outside the crawled prefix, so a stored corrupt object could not reach the table
+	integrity.AssertRejectsCorruptUpload(t, clients, rawBucketID, fmt.Sprintf("e2e/%s/integrity/corrupt.csv", runID))
 
-	// Cleanup test data
-	aws.DeleteS3Object(t, region, rawBucketID, testKey)
+	// Catalog the data with a crawler scoped to this run's prefix
+	logger.Info("Crawling sample data into the raw database", "database", rawDatabaseName)
+	roleArn, deleteRole := createCrawlerRole(t, clients, "e2e-crawler-"+runID, rawBucketID)
+	defer deleteRole()
 
-	t.Log("✅ End-to-end workflow test completed successfully")
-}
+	crawlerName := "e2e-" + runID
+	tablePrefix := fmt.Sprintf("e2e_%s_", runID)
+	deleteCrawler := createCrawler(t, clients, crawlerName, roleArn, rawDatabaseName, tablePrefix,
+		fmt.Sprintf("s3://%s/%s", rawBucketID, dataPrefix))
+	defer deleteCrawler()
+
+	runCrawler(t, clients, crawlerName)
+
+	// The crawler names the table after the last path segment
+	tableName := tablePrefix + "events"
+	table := waitForTable(t, clients, rawDatabaseName, tableName)
+	defer func() {
+		ctx, cancel := clients.Context()
+		defer cancel()
+
+		_, err := clients.Glue().DeleteTable(ctx, &glue.DeleteTableInput{
+			DatabaseName: awssdk.String(rawDatabaseName),
+			Name:         awssdk.String(tableName),
+		})
+		assert.NoError(t, err, "Failed to delete crawled table")
+	}()
 
-// cleanupIntegrationTest performs cleanup of integration test resources
-func cleanupIntegrationTest(t *testing.T, terragruntOptions *terraform.Options) {
-	t.Log("Performing integration test cleanup...")
-
-	// Destruction order (reverse of creation)
-	destructionOrder := []string{
-		"08-monitoring",
-		"07-analytics",
-		"06-orchestration",
-		"05-streaming",
-		"04-data-catalog",
-		"03-storage",
-		"02-security",
-		"01-networking",
+	var columns []string
+	for _, column := range table.StorageDescriptor.Columns {
+		columns = append(columns, awssdk.ToString(column.Name))
 	}
+	assert.Equal(t, expectedRows[0], columns, "Crawled table should use the fixture header as columns")
+
+	// Query the crawled table and compare against the fixture
+	logger.Info("Querying crawled table with Athena")
+	resultsLocation := fmt.Sprintf("s3://%s/athena-results/e2e/%s/", processedBucketID, runID)
+	result := athena.Run(t, clients, athena.Query{
+		SQL:            fmt.Sprintf(`SELECT %s FROM "%s"."%s" ORDER BY id`, strings.Join(columns, ", "), rawDatabaseName, tableName),
+		Database:       rawDatabaseName,
+		OutputLocation: resultsLocation,
+	})
 
-	for _, module := range destructionOrder {
-		moduleDir := fmt.Sprintf("%s/%s", terragruntOptions.TerraformDir, module)
+	require.Len(t, result.Rows, len(expectedRows)-1, "Query should return every fixture row")
+	for i, expected := range expectedRows[1:] {
+		assert.Equal(t, expected, result.Rows[i], "Unexpected values in row %d", i+1)
+	}
 
-		// Check if module directory exists
-		if shell.CommandExists("test") {
-			if err := shell.RunCommandE(t, shell.Command{
-				Command: "test",
-				Args:    []string{"-d", moduleDir},
-			}); err != nil {
-				continue // Skip if directory doesn't exist
-			}
-		}
+	// Check the crawled data is usable, not just present
+	logger.Info("Running data quality checks with Athena")
+	dataquality.Assert(t, dataquality.Suite{
+		Database: rawDatabaseName,
+		Table:    tableName,
+		Expectations: []dataquality.Expectation{
+			dataquality.RowCount{Min: int64(len(expectedRows) - 1), Max: int64(len(expectedRows) - 1)},
+			dataquality.NullRate{Column: "id", Max: 0},
+			dataquality.NullRate{Column: "name", Max: 0},
+			dataquality.Unique{Columns: []string{"id"}},
+		},
+	}, func(query string) ([][]string, error) {
+		return athena.Run(t, clients, athena.Query{SQL: query, Database: rawDatabaseName, OutputLocation: resultsLocation}).Rows, nil
+	})
 
-		t.Logf("Destroying module: %s", module)
+	// The pipeline only reads the raw data, so it must still match its upload checksum;
+	// no Glue job or Firehose stream writes to the processed or curated buckets yet
+	logger.Info("Verifying checksums of the pipeline's data")
+	integrity.AssertSample(t, clients, rawBucketID, dataPrefix, 10)
 
-		// Destroy with retry logic
-		maxRetries := 3
-		for i := 0; i < maxRetries; i++ {
-			err := shell.RunCommandE(t, shell.Command{
-				Command:    "terragrunt",
-				Args:       []string{"destroy", "-auto-approve"},
-				WorkingDir: moduleDir,
-			})
+	logger.Success("End-to-end pipeline test completed successfully")
+}
 
-			if err == nil {
-				break
-			}
+// terragruntOutput returns a raw output of the Terragrunt unit in dir
+func terragruntOutput(t *testing.T, dir, name string) string {
+	return shell.RunCommandAndGetOutput(t, shell.Command{
+		Command:    "terragrunt",
+		Args:       []string{"output", "-raw", name},
+		WorkingDir: dir,
+	})
+}
 
-			if i == maxRetries-1 {
-				t.Logf("⚠️  Failed to destroy module %s after %d attempts: %v", module, maxRetries, err)
-			} else {
-				t.Logf("Retry %d/%d for destroying module %s", i+1, maxRetries, module)
-				time.Sleep(30 * time.Second)
-			}
+// assertS3BucketHasDefaultEncryption verifies the bucket has a default server-side encryption rule
+func assertS3BucketHasDefaultEncryption(t *testing.T, region, bucketID string) {
+	clients := awsclients.New(t, awsclients.WithRegion(region))
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	encryption, err := clients.S3().GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{
+		Bucket: awssdk.String(bucketID),
+	})
+	require.NoError(t, err, "Failed to get encryption for bucket %s", bucketID)
+	require.NotNil(t, encryption.ServerSideEncryptionConfiguration)
+	assert.NotEmpty(t, encryption.ServerSideEncryptionConfiguration.Rules,
+		"Bucket %s should have a default encryption rule", bucketID)
+}
+
+// destroyOptions retries a failed destroy, e.g. one racing ENI cleanup, for up to half an hour
+var destroyOptions = wait.Options{
+	Timeout:    30 * time.Minute,
+	Initial:    30 * time.Second,
+	Max:        2 * time.Minute,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// cleanupIntegrationTest destroys the environment units with `terragrunt
+// run-all destroy`, which goes on past a unit that fails so the others are
+// still destroyed, retrying until ctx ends, then checks nothing the units
+// recorded is left
+func cleanupIntegrationTest(ctx context.Context, t *testing.T, terragruntOptions *terraform.Options, runOptions runall.Options, destroyOrder []string) {
+	var recorded []report.Resource
+	for _, unit := range destroyOrder {
+		recorded = append(recorded, residue.Record(t, &terraform.Options{
+			TerraformDir:    fmt.Sprintf("%s/%s", terragruntOptions.TerraformDir, unit),
+			TerraformBinary: "terragrunt",
+			EnvVars:         terragruntOptions.EnvVars,
+		})...)
+	}
+
+	logger := logging.New(t)
+	done := logger.Phase("destroy")
+	runOptions.IgnoreDependencyErrors = true
+	attempts := 0
+	err := wait.WaitFor(ctx, wait.Succeeds(func(ctx context.Context) error {
+		attempts++
+		if attempts > 1 {
+			logger.Info("Retrying destroy", "retry", attempts-1)
 		}
+		_, err := runall.Run(ctx, t, "destroy", runOptions)
+		return err
+	}), destroyOptions)
+	done()
+	if err != nil {
+		logger.Warn("Failed to destroy the environment", "attempts", attempts, "error", err)
 	}
 
-	t.Log("✅ Integration test cleanup completed")
+	region := terragruntOptions.EnvVars["AWS_DEFAULT_REGION"]
+	residue.Assert(t, awsclients.New(t, awsclients.WithRegion(region)), recorded)
+	logging.New(t).Success("Integration test cleanup completed")
+}
+
+// keepForTriage leaves the environment deployed when the test failed with
+// KEEP_ON_FAILURE=true, describing every unit in its triage file
+func keepForTriage(t *testing.T, terragruntOptions *terraform.Options, runOptions runall.Options, units []string) bool {
+	dir, err := filepath.Abs(terragruntOptions.TerraformDir)
+	require.NoError(t, err)
+
+	stacks := make([]*terraform.Options, 0, len(units))
+	for _, unit := range units {
+		stacks = append(stacks, &terraform.Options{
+			TerraformDir:    filepath.Join(dir, unit),
+			TerraformBinary: "terragrunt",
+			EnvVars:         terragruntOptions.EnvVars,
+		})
+	}
+	runOptions.Dir = dir
+	cleanup := "terragrunt " + strings.Join(runOptions.Args("destroy"), " ")
+	return testutil.KeepOnFailure(t, testutil.StageDir(t), cleanup, stacks...)
 }
 
 // TestDevEnvironmentValidation performs validation tests without deployment
 func TestDevEnvironmentValidation(t *testing.T) {
-	awsRegion := "us-east-1"
-	environment := "dev"
+	config, err := testconfig.Load()
+	require.NoError(t, err)
+	awsRegion := config.Integration.Region
+	environment := config.Integration.Environment
 
 	terragruntDir := fmt.Sprintf("../../environments/%s/%s", environment, awsRegion)
+	if _, err := os.Stat(terragruntDir); errors.Is(err, os.ErrNotExist) {
+		t.Skipf("No Terragrunt environment at %s; point integration.environment and integration.region in config/testing.yaml at one under environments/", terragruntDir)
+	}
 
 	// Test Terragrunt configuration validation
 	t.Run("TerragruntValidation", func(t *testing.T) {
@@ -269,6 +553,16 @@ func TestDevEnvironmentValidation(t *testing.T) {
 		})
 	})
 
+	// Test the units form a valid dependency graph
+	t.Run("DependencyGraph", func(t *testing.T) {
+		graph, err := tggraph.Load(terragruntDir)
+		require.NoError(t, err)
+
+		order, err := graph.ApplyOrder()
+		require.NoError(t, err)
+		logging.New(t).Info("Apply order", "units", strings.Join(order, ","))
+	})
+
 	// Test Terraform formatting
 	t.Run("TerraformFormatting", func(t *testing.T) {
 		shell.RunCommand(t, shell.Command{
@@ -278,5 +572,5 @@ func TestDevEnvironmentValidation(t *testing.T) {
 		})
 	})
 
-	t.Log("✅ Dev environment validation completed successfully")
+	logging.New(t).Success("Dev environment validation completed successfully")
 }
@@ -0,0 +1,103 @@
+// =============================================================================
+// Iceberg Table Test
+// Writes, time-travels and compacts an Iceberg table in the curated database
+// =============================================================================
+
+package integration
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/athena"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/iceberg"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+)
+
+// testIcebergTable creates an Iceberg table in the curated database with
+// Athena, appends the fixture to it in two commits and checks the snapshots
+// in the catalog and S3, a time-travel query to the first commit and that a
+// compaction keeps every row
+func testIcebergTable(t *testing.T, terragruntOptions *terraform.Options, region string) {
+	logger := logging.New(t)
+	defer logger.Phase("iceberg")()
+
+	storageDir := fmt.Sprintf("%s/03-storage", terragruntOptions.TerraformDir)
+	curatedBucketID := terragruntOutput(t, storageDir, "curated_bucket_id")
+	processedBucketID := terragruntOutput(t, storageDir, "processed_bucket_id")
+	curatedDatabaseName := terragruntOutput(t, storageDir, "curated_database_name")
+
+	clients := awsclients.New(t, awsclients.WithRegion(region))
+	runID := strings.ToLower(random.UniqueId())
+	table := fmt.Sprintf("%s.e2e_%s_events", curatedDatabaseName, runID)
+	resultsLocation := fmt.Sprintf("s3://%s/athena-results/iceberg/%s/", processedBucketID, runID)
+	query := func(sql string) *athena.Result {
+		return athena.Run(t, clients, athena.Query{SQL: sql, Database: curatedDatabaseName, OutputLocation: resultsLocation})
+	}
+
+	// The table and everything under its location are dropped when the test ends
+	logger.Info("Creating Iceberg table", "table", table)
+	query(fmt.Sprintf(`CREATE TABLE %s (id int, name string, country string)
+LOCATION 's3://%s/iceberg/e2e/%s/events/'
+TBLPROPERTIES ('table_type' = 'ICEBERG', 'format' = 'parquet')`, table, curatedBucketID, runID))
+
+	_, rows := loadFixture(t, "events.csv")
+	rows = rows[1:]
+	require.GreaterOrEqual(t, len(rows), 2, "The fixture needs a row for each commit")
+	first, second := rows[:len(rows)/2], rows[len(rows)/2:]
+
+	logger.Info("Appending the fixture in two commits")
+	query(fmt.Sprintf("INSERT INTO %s VALUES %s", table, values(first)))
+	query(fmt.Sprintf("INSERT INTO %s VALUES %s", table, values(second)))
+
+	database, name, _ := strings.Cut(table, ".")
+	metadata := iceberg.AssertTable(t, clients, database, name)
+	lineage := metadata.Lineage()
+	require.Len(t, lineage, 2, "Each INSERT INTO should commit one snapshot")
+	for i, expected := range []int{len(first), len(rows)} {
+		assert.Equal(t, iceberg.OperationAppend, lineage[i].Operation())
+		total, err := lineage[i].TotalRecords()
+		require.NoError(t, err)
+		assert.EqualValues(t, expected, total, "Rows in the table as of snapshot %d", lineage[i].ID)
+	}
+
+	// Athena reads the same snapshots from the table's metadata
+	snapshots := query(fmt.Sprintf(`SELECT snapshot_id, operation FROM "%s"."%s$snapshots" ORDER BY committed_at`, database, name))
+	require.Len(t, snapshots.Rows, len(lineage))
+	for i, snapshot := range lineage {
+		assert.Equal(t, []string{strconv.FormatInt(snapshot.ID, 10), snapshot.Operation()}, snapshots.Rows[i])
+	}
+
+	logger.Info("Querying the table as of its first commit")
+	assert.Equal(t, rows, query(fmt.Sprintf("SELECT id, name, country FROM %s ORDER BY id", table)).Rows)
+	assert.Equal(t, first, query(fmt.Sprintf("SELECT id, name, country FROM %s FOR VERSION AS OF %d ORDER BY id", table, lineage[0].ID)).Rows)
+
+	// Bin packing rewrites the small files of the two commits; a rewrite that
+	// changes the rows would break every reader of a compacted table
+	logger.Info("Compacting the table")
+	query(fmt.Sprintf("OPTIMIZE %s REWRITE DATA USING BIN_PACK", table))
+	compacted := iceberg.AssertTable(t, clients, database, name).Lineage()
+	require.GreaterOrEqual(t, len(compacted), len(lineage))
+	for _, snapshot := range compacted[len(lineage):] {
+		assert.Equal(t, iceberg.OperationReplace, snapshot.Operation(), "Compaction should only replace files")
+	}
+	assert.Equal(t, rows, query(fmt.Sprintf("SELECT id, name, country FROM %s ORDER BY id", table)).Rows)
+
+	logger.Success("Iceberg table test completed successfully", "snapshots", len(compacted))
+}
+
+// values renders fixture rows of id, name and country as a VALUES list
+func values(rows [][]string) string {
+	tuples := make([]string, 0, len(rows))
+	for _, row := range rows {
+		tuples = append(tuples, fmt.Sprintf("(%s, '%s', '%s')", row[0], row[1], row[2]))
+	}
+	return strings.Join(tuples, ", ")
+}
@@ -0,0 +1,162 @@
+// =============================================================================
+// Data Pipeline Helpers
+// Glue crawler steps for the end-to-end pipeline test
+// =============================================================================
+
+package integration
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/wait"
+)
+
+// glueServiceRolePolicyArn grants the crawler access to the Data Catalog and CloudWatch Logs
+const glueServiceRolePolicyArn = "arn:aws:iam::aws:policy/service-role/AWSGlueServiceRole"
+
+// roleOptions retries calls that fail until a new IAM role has propagated
+var roleOptions = wait.DefaultOptions().WithTimeout(2 * time.Minute).WithInterval(10 * time.Second)
+
+// loadFixture reads a CSV fixture from testdata, header row included
+func loadFixture(t *testing.T, name string) (string, [][]string) {
+	content, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err, "Failed to read fixture %s", name)
+
+	rows, err := csv.NewReader(strings.NewReader(string(content))).ReadAll()
+	require.NoError(t, err, "Failed to parse fixture %s", name)
+
+	return string(content), rows
+}
+
+// createCrawlerRole creates a role the Glue crawler can assume to read bucketID
+// and returns its ARN along with a function that deletes it
+func createCrawlerRole(t *testing.T, clients *awsclients.Clients, roleName, bucketID string) (string, func()) {
+	iamClient := clients.IAM()
+
+	trustPolicy, err := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{{
+			"Effect":    "Allow",
+			"Principal": map[string]string{"Service": "glue.amazonaws.com"},
+			"Action":    "sts:AssumeRole",
+		}},
+	})
+	require.NoError(t, err)
+
+	readPolicy, err := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{{
+			"Effect": "Allow",
+			"Action": []string{"s3:GetObject", "s3:ListBucket"},
+			"Resource": []string{
+				fmt.Sprintf("arn:aws:s3:::%s", bucketID),
+				fmt.Sprintf("arn:aws:s3:::%s/*", bucketID),
+			},
+		}},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	role, err := iamClient.CreateRole(ctx, &iam.CreateRoleInput{
+		RoleName:                 awssdk.String(roleName),
+		AssumeRolePolicyDocument: awssdk.String(string(trustPolicy)),
+	})
+	require.NoError(t, err, "Failed to create crawler role %s", roleName)
+
+	cleanup := func() {
+		ctx, cancel := clients.Context()
+		defer cancel()
+
+		_, _ = iamClient.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
+			RoleName:  awssdk.String(roleName),
+			PolicyArn: awssdk.String(glueServiceRolePolicyArn),
+		})
+		_, _ = iamClient.DeleteRolePolicy(ctx, &iam.DeleteRolePolicyInput{
+			RoleName:   awssdk.String(roleName),
+			PolicyName: awssdk.String("read-source-bucket"),
+		})
+		if _, err := iamClient.DeleteRole(ctx, &iam.DeleteRoleInput{RoleName: awssdk.String(roleName)}); err != nil {
+			logging.New(t).Warn("Failed to delete crawler role", "role", roleName, "error", err)
+		}
+	}
+
+	_, err = iamClient.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
+		RoleName:  awssdk.String(roleName),
+		PolicyArn: awssdk.String(glueServiceRolePolicyArn),
+	})
+	require.NoError(t, err, "Failed to attach Glue service policy to %s", roleName)
+
+	_, err = iamClient.PutRolePolicy(ctx, &iam.PutRolePolicyInput{
+		RoleName:       awssdk.String(roleName),
+		PolicyName:     awssdk.String("read-source-bucket"),
+		PolicyDocument: awssdk.String(string(readPolicy)),
+	})
+	require.NoError(t, err, "Failed to grant %s read access to %s", roleName, bucketID)
+
+	return awssdk.ToString(role.Role.Arn), cleanup
+}
+
+// createCrawler creates a crawler over s3Path, retrying while the new role propagates,
+// and returns a function that deletes it
+func createCrawler(t *testing.T, clients *awsclients.Clients, crawlerName, roleArn, databaseName, tablePrefix, s3Path string) func() {
+	glueClient := clients.Glue()
+
+	wait.Until(t, "Glue crawler "+crawlerName+" to be created", wait.Succeeds(func(ctx context.Context) error {
+		_, err := glueClient.CreateCrawler(ctx, &glue.CreateCrawlerInput{
+			Name:         awssdk.String(crawlerName),
+			Role:         awssdk.String(roleArn),
+			DatabaseName: awssdk.String(databaseName),
+			TablePrefix:  awssdk.String(tablePrefix),
+			Targets: &gluetypes.CrawlerTargets{
+				S3Targets: []gluetypes.S3Target{{Path: awssdk.String(s3Path)}},
+			},
+		})
+		return err
+	}), roleOptions)
+
+	return func() {
+		ctx, cancel := clients.Context()
+		defer cancel()
+
+		if _, err := glueClient.DeleteCrawler(ctx, &glue.DeleteCrawlerInput{Name: awssdk.String(crawlerName)}); err != nil {
+			logging.New(t).Warn("Failed to delete crawler", "crawler", crawlerName, "error", err)
+		}
+	}
+}
+
+// runCrawler starts the crawler and waits for the crawl to succeed
+func runCrawler(t *testing.T, clients *awsclients.Clients, crawlerName string) {
+	glueClient := clients.Glue()
+
+	wait.Until(t, "Glue crawler "+crawlerName+" to start", wait.Succeeds(func(ctx context.Context) error {
+		_, err := glueClient.StartCrawler(ctx, &glue.StartCrawlerInput{Name: awssdk.String(crawlerName)})
+		return err
+	}), roleOptions)
+
+	wait.Until(t, "Glue crawler "+crawlerName+" to finish", wait.CrawlerFinished(glueClient, crawlerName),
+		wait.DefaultOptions().WithTimeout(10*time.Minute))
+}
+
+// waitForTable waits for the crawler's table to appear in the catalog
+func waitForTable(t *testing.T, clients *awsclients.Clients, databaseName, tableName string) *gluetypes.Table {
+	var table *gluetypes.Table
+	wait.Until(t, fmt.Sprintf("table %s.%s", databaseName, tableName),
+		wait.TableExists(clients.Glue(), databaseName, tableName, &table), wait.DefaultOptions())
+	return table
+}
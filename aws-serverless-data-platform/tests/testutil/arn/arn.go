@@ -0,0 +1,199 @@
+// =============================================================================
+// ARN Assertions
+// Parses ARNs and checks their segments and the platform's naming convention
+// =============================================================================
+
+// Package arn parses the ARNs modules output into typed segments and checks
+// them against what the test expects: the service, a regional or global
+// region, the account, the resource type and the resource's name. It also
+// checks names against the platform's <project>-<env>-<component> convention,
+// and each problem names the segment and the expected value.
+package arn
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	awsarn "github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/stretchr/testify/require"
+)
+
+// ARN is a parsed ARN
+type ARN struct {
+	Partition string
+	Service   string
+	Region    string
+	AccountID string
+
+	// ResourceType is the part of the resource before the first "/" or ":",
+	// e.g. "role" or "log-group"; S3 ARNs have none
+	ResourceType string
+
+	// ResourceID is the rest of the resource, e.g. "service-role/etl" for a
+	// role with a path, or "bucket/key" for an S3 object
+	ResourceID string
+}
+
+// Parse splits s into its segments
+func Parse(s string) (ARN, error) {
+	parsed, err := awsarn.Parse(s)
+	if err != nil {
+		return ARN{}, fmt.Errorf("%q is not an ARN: %w", s, err)
+	}
+	if parsed.Resource == "" {
+		return ARN{}, fmt.Errorf("%q is not an ARN: no resource", s)
+	}
+
+	a := ARN{
+		Partition:  parsed.Partition,
+		Service:    parsed.Service,
+		Region:     parsed.Region,
+		AccountID:  parsed.AccountID,
+		ResourceID: parsed.Resource,
+	}
+	if a.Service != "s3" {
+		if i := strings.IndexAny(parsed.Resource, "/:"); i > 0 {
+			a.ResourceType, a.ResourceID = parsed.Resource[:i], parsed.Resource[i+1:]
+		}
+	}
+	return a, nil
+}
+
+// Name returns the last "/" segment of the resource ID, which is the name of
+// an IAM role or policy with a path; a log group ARN's trailing ":*" is dropped
+func (a ARN) Name() string {
+	id := strings.TrimSuffix(a.ResourceID, ":*")
+	return id[strings.LastIndex(id, "/")+1:]
+}
+
+// Resource returns the ARN of resource, e.g. "database/dl_raw", in service and
+// region, in a's partition and account; a caller identity ARN gives both
+func (a ARN) Resource(service, region, resource string) string {
+	return awsarn.ARN{Partition: a.Partition, Service: service, Region: region, AccountID: a.AccountID, Resource: resource}.String()
+}
+
+// Want is what an ARN is expected to hold; empty fields are not checked
+type Want struct {
+	// Partition defaults to "aws"
+	Partition string
+
+	Service string
+
+	// Region is the region of a regional resource
+	Region string
+
+	// Global requires an empty region, as IAM and S3 ARNs have
+	Global bool
+
+	AccountID    string
+	ResourceType string
+
+	// Name is the resource's name as returned by ARN.Name
+	Name string
+}
+
+// Check lists every segment of a that differs from want
+func (a ARN) Check(want Want) []string {
+	var problems []string
+	mismatch := func(segment, got, expected string) {
+		if expected != "" && got != expected {
+			problems = append(problems, fmt.Sprintf("%s is %q, want %q", segment, got, expected))
+		}
+	}
+
+	partition := want.Partition
+	if partition == "" {
+		partition = "aws"
+	}
+	mismatch("partition", a.Partition, partition)
+	mismatch("service", a.Service, want.Service)
+	mismatch("region", a.Region, want.Region)
+	if want.Global && a.Region != "" {
+		problems = append(problems, fmt.Sprintf("region is %q, want none for a global %s resource", a.Region, a.Service))
+	}
+	mismatch("account", a.AccountID, want.AccountID)
+	mismatch("resource type", a.ResourceType, want.ResourceType)
+	mismatch("resource name", a.Name(), want.Name)
+	return problems
+}
+
+// Assert parses s and fails t for every segment that differs from want
+func Assert(t testing.TB, s string, want Want) ARN {
+	t.Helper()
+
+	parsed, err := Parse(s)
+	require.NoError(t, err)
+	for _, problem := range parsed.Check(want) {
+		t.Errorf("%s: %s", s, problem)
+	}
+	return parsed
+}
+
+// Convention is the platform's naming convention: names are the project,
+// the environment and a component, joined by Separator
+type Convention struct {
+	Project string
+
+	// Environment is empty for names without one, such as the security
+	// module's account-wide IAM roles and policies
+	Environment string
+
+	// Separator defaults to "-"; Glue databases use "_"
+	Separator string
+}
+
+// Name returns the conventional name of component
+func (c Convention) Name(component string) string {
+	return c.prefix() + component
+}
+
+// Check lists how name breaks the convention; an empty component accepts any
+func (c Convention) Check(name, component string) []string {
+	prefix := c.prefix()
+	if !strings.HasPrefix(name, prefix) {
+		return []string{fmt.Sprintf("name %q does not start with %q (%s)", name, prefix, c.pattern())}
+	}
+
+	got := strings.TrimPrefix(name, prefix)
+	switch {
+	case got == "":
+		return []string{fmt.Sprintf("name %q has no component after %q (%s)", name, prefix, c.pattern())}
+	case component != "" && got != component:
+		return []string{fmt.Sprintf("name %q has component %q, want %q", name, got, component)}
+	}
+	return nil
+}
+
+// AssertName fails t if name breaks the convention
+func (c Convention) AssertName(t testing.TB, name, component string) {
+	t.Helper()
+	for _, problem := range c.Check(name, component) {
+		t.Error(problem)
+	}
+}
+
+// prefix is the part of every name before the component
+func (c Convention) prefix() string {
+	separator := c.separator()
+	if c.Environment == "" {
+		return c.Project + separator
+	}
+	return c.Project + separator + c.Environment + separator
+}
+
+// pattern describes the convention in messages
+func (c Convention) pattern() string {
+	separator := c.separator()
+	if c.Environment == "" {
+		return "<project>" + separator + "<component>"
+	}
+	return "<project>" + separator + "<env>" + separator + "<component>"
+}
+
+func (c Convention) separator() string {
+	if c.Separator == "" {
+		return "-"
+	}
+	return c.Separator
+}
@@ -0,0 +1,90 @@
+package arn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]ARN{
+		"arn:aws:iam::123456789012:role/dl-glue-role":                       {"aws", "iam", "", "123456789012", "role", "dl-glue-role"},
+		"arn:aws:iam::123456789012:role/service-role/etl":                   {"aws", "iam", "", "123456789012", "role", "service-role/etl"},
+		"arn:aws:s3:::dl-raw-dev/events/2024/01.csv":                        {"aws", "s3", "", "", "", "dl-raw-dev/events/2024/01.csv"},
+		"arn:aws:logs:ap-southeast-1:123456789012:log-group:/aws-glue/jobs": {"aws", "logs", "ap-southeast-1", "123456789012", "log-group", "/aws-glue/jobs"},
+		"arn:aws:sns:ap-southeast-1:123456789012:dl-dev-critical-alerts":    {"aws", "sns", "ap-southeast-1", "123456789012", "", "dl-dev-critical-alerts"},
+		"arn:aws-cn:athena:cn-north-1:123456789012:workgroup/dl-workgroup":  {"aws-cn", "athena", "cn-north-1", "123456789012", "workgroup", "dl-workgroup"},
+	}
+	for s, want := range tests {
+		got, err := Parse(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, got, s)
+	}
+
+	_, err := Parse("dl-glue-role")
+	assert.ErrorContains(t, err, `"dl-glue-role" is not an ARN`)
+	_, err = Parse("arn:aws:iam::123456789012:")
+	assert.ErrorContains(t, err, "no resource")
+}
+
+func TestName(t *testing.T) {
+	for s, name := range map[string]string{
+		"arn:aws:iam::123456789012:role/service-role/etl":                        "etl",
+		"arn:aws:iam::aws:policy/AdministratorAccess":                            "AdministratorAccess",
+		"arn:aws:logs:ap-southeast-1:123456789012:log-group:/aws-glue/jobs/dl:*": "dl",
+		"arn:aws:s3:::dl-raw-dev":                                                "dl-raw-dev",
+	} {
+		parsed, err := Parse(s)
+		require.NoError(t, err)
+		assert.Equal(t, name, parsed.Name(), s)
+	}
+}
+
+func TestResource(t *testing.T) {
+	caller, err := Parse("arn:aws-cn:sts::123456789012:assumed-role/TestRunner/terratest")
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws-cn:glue:cn-north-1:123456789012:database/dl_raw", caller.Resource("glue", "cn-north-1", "database/dl_raw"))
+}
+
+func TestCheck(t *testing.T) {
+	role, err := Parse("arn:aws:iam::123456789012:role/dl-glue-role")
+	require.NoError(t, err)
+	assert.Empty(t, role.Check(Want{Service: "iam", Global: true, AccountID: "123456789012", ResourceType: "role", Name: "dl-glue-role"}))
+
+	assert.Equal(t, []string{
+		`service is "iam", want "sts"`,
+		`account is "123456789012", want "210987654321"`,
+		`resource type is "role", want "assumed-role"`,
+		`resource name is "dl-glue-role", want "dl-etl-role"`,
+	}, role.Check(Want{Service: "sts", AccountID: "210987654321", ResourceType: "assumed-role", Name: "dl-etl-role"}))
+
+	workgroup, err := Parse("arn:aws:athena:us-east-1:123456789012:workgroup/dl-dev-workgroup")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`region is "us-east-1", want "ap-southeast-1"`,
+		`region is "us-east-1", want none for a global athena resource`,
+	}, workgroup.Check(Want{Region: "ap-southeast-1", Global: true}))
+	assert.Equal(t, []string{`partition is "aws", want "aws-us-gov"`}, workgroup.Check(Want{Partition: "aws-us-gov"}))
+}
+
+func TestConvention(t *testing.T) {
+	dev := Convention{Project: "dl", Environment: "dev"}
+	assert.Equal(t, "dl-dev-workgroup", dev.Name("workgroup"))
+	assert.Empty(t, dev.Check("dl-dev-workgroup", "workgroup"))
+	assert.Empty(t, dev.Check("dl-dev-high-error-rate", ""), "An empty component accepts any")
+
+	assert.Equal(t, []string{`name "dl-prod-workgroup" does not start with "dl-dev-" (<project>-<env>-<component>)`},
+		dev.Check("dl-prod-workgroup", "workgroup"))
+	assert.Equal(t, []string{`name "dl-dev-" has no component after "dl-dev-" (<project>-<env>-<component>)`},
+		dev.Check("dl-dev-", ""))
+	assert.Equal(t, []string{`name "dl-dev-search" has component "search", want "workgroup"`},
+		dev.Check("dl-dev-search", "workgroup"))
+
+	catalog := Convention{Project: "dl", Environment: "dev", Separator: "_"}
+	assert.Empty(t, catalog.Check("dl_dev_curated", "curated"))
+
+	account := Convention{Project: "dl"}
+	assert.Empty(t, account.Check("dl-glue-role", "glue-role"))
+	assert.Equal(t, []string{`name "glue-role" does not start with "dl-" (<project>-<component>)`}, account.Check("glue-role", ""))
+}
@@ -0,0 +1,205 @@
+// =============================================================================
+// Athena Queries
+// Runs queries to completion and scans their results into Go values
+// =============================================================================
+
+// Package athena runs Athena queries for analytics and data-quality tests:
+// it submits a query, waits for it, pages through the results and removes
+// the result files, and the table and data of a CREATE TABLE AS SELECT or
+// of an Iceberg table, when the test finishes.
+package athena
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	athenasdk "github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/wait"
+)
+
+// DefaultWorkgroup is the workgroup queries run in when Query.Workgroup is empty
+const DefaultWorkgroup = "primary"
+
+// Query is one statement to run
+type Query struct {
+	SQL string
+
+	// Database is the default database for unqualified table names
+	Database string
+
+	// Workgroup defaults to DefaultWorkgroup
+	Workgroup string
+
+	// OutputLocation is the s3:// prefix results are written under; empty uses
+	// the workgroup's configured location
+	OutputLocation string
+
+	// Timeout bounds how long the query may run; zero uses wait.DefaultOptions
+	Timeout time.Duration
+}
+
+// API is the part of the Athena client queries use
+type API interface {
+	StartQueryExecution(ctx context.Context, params *athenasdk.StartQueryExecutionInput, optFns ...func(*athenasdk.Options)) (*athenasdk.StartQueryExecutionOutput, error)
+	GetQueryExecution(ctx context.Context, params *athenasdk.GetQueryExecutionInput, optFns ...func(*athenasdk.Options)) (*athenasdk.GetQueryExecutionOutput, error)
+	GetQueryResults(ctx context.Context, params *athenasdk.GetQueryResultsInput, optFns ...func(*athenasdk.Options)) (*athenasdk.GetQueryResultsOutput, error)
+}
+
+// Column describes one result column
+type Column struct {
+	Name string
+	Type string
+}
+
+// Result is a finished query's output
+type Result struct {
+	QueryExecutionID string
+
+	// OutputFile is the s3:// URI of the result file Athena wrote
+	OutputFile string
+
+	Columns []Column
+
+	// Rows are the data rows without the header row; NULL reads as ""
+	Rows [][]string
+
+	// createdTable is the database.table a CREATE TABLE AS SELECT created
+	createdTable string
+}
+
+// ctasPattern matches a CREATE TABLE AS SELECT and captures the table name
+var ctasPattern = regexp.MustCompile("(?is)^\\s*CREATE\\s+TABLE\\s+(?:IF\\s+NOT\\s+EXISTS\\s+)?([\\w.\"`]+)\\s.*?\\bAS\\s+(?:SELECT|WITH)\\b")
+
+// icebergPattern matches a CREATE TABLE of an Iceberg table, whose data
+// Athena writes like a CTAS, and captures the table name
+var icebergPattern = regexp.MustCompile("(?is)^\\s*CREATE\\s+TABLE\\s+(?:IF\\s+NOT\\s+EXISTS\\s+)?([\\w.\"`]+)\\s.*\\bTBLPROPERTIES\\s*\\(.*'table_type'\\s*=\\s*'ICEBERG'")
+
+// Run runs q, failing t if it does not succeed, and removes what it wrote when t finishes
+func Run(t *testing.T, clients *awsclients.Clients, q Query) *Result {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), q.timeout()+time.Minute)
+	defer cancel()
+
+	result, err := RunQuery(ctx, clients, q)
+	if result != nil {
+		t.Cleanup(func() {
+			ctx, cancel := clients.Context()
+			defer cancel()
+			if err := result.Cleanup(ctx, clients); err != nil {
+				logging.New(t).Warn("Failed to clean up Athena query", "query", result.QueryExecutionID, "error", err)
+			}
+		})
+	}
+	require.NoError(t, err, "Athena query failed: %s", q.SQL)
+	return result
+}
+
+// RunQuery submits q, waits for it to finish and reads every result page.
+// The result is returned with an error when the query started but failed,
+// so callers can still clean up after it
+func RunQuery(ctx context.Context, clients *awsclients.Clients, q Query) (*Result, error) {
+	return Execute(ctx, clients.Athena(), q)
+}
+
+// Execute is RunQuery against client
+func Execute(ctx context.Context, client API, q Query) (*Result, error) {
+	input := &athenasdk.StartQueryExecutionInput{
+		QueryString: awssdk.String(q.SQL),
+		WorkGroup:   awssdk.String(q.workgroup()),
+	}
+	if q.Database != "" {
+		input.QueryExecutionContext = &athenatypes.QueryExecutionContext{Database: awssdk.String(q.Database)}
+	}
+	if q.OutputLocation != "" {
+		input.ResultConfiguration = &athenatypes.ResultConfiguration{OutputLocation: awssdk.String(q.OutputLocation)}
+	}
+
+	start, err := client.StartQueryExecution(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("start query: %w", err)
+	}
+
+	result := &Result{QueryExecutionID: awssdk.ToString(start.QueryExecutionId)}
+	match := ctasPattern.FindStringSubmatch(q.SQL)
+	if match == nil {
+		match = icebergPattern.FindStringSubmatch(q.SQL)
+	}
+	if match != nil {
+		result.createdTable = qualify(strings.NewReplacer(`"`, "", "`", "").Replace(match[1]), q.Database)
+	}
+
+	var execution *athenatypes.QueryExecution
+	opts := wait.DefaultOptions()
+	if q.Timeout > 0 {
+		opts = opts.WithTimeout(q.Timeout)
+	}
+	waitErr := wait.WaitFor(ctx, wait.QuerySucceeded(client, result.QueryExecutionID, &execution), opts)
+	if execution != nil && execution.ResultConfiguration != nil {
+		result.OutputFile = awssdk.ToString(execution.ResultConfiguration.OutputLocation)
+	}
+	if waitErr != nil {
+		return result, waitErr
+	}
+
+	header := execution.StatementType == athenatypes.StatementTypeDml
+	paginator := athenasdk.NewGetQueryResultsPaginator(client, &athenasdk.GetQueryResultsInput{
+		QueryExecutionId: awssdk.String(result.QueryExecutionID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return result, fmt.Errorf("get results of %s: %w", result.QueryExecutionID, err)
+		}
+		if result.Columns == nil && page.ResultSet.ResultSetMetadata != nil {
+			for _, info := range page.ResultSet.ResultSetMetadata.ColumnInfo {
+				result.Columns = append(result.Columns, Column{Name: awssdk.ToString(info.Name), Type: awssdk.ToString(info.Type)})
+			}
+		}
+
+		rows := page.ResultSet.Rows
+		if header && len(rows) > 0 {
+			// Only the first page of a SELECT starts with the column names
+			rows, header = rows[1:], false
+		}
+		for _, row := range rows {
+			values := make([]string, len(row.Data))
+			for i, datum := range row.Data {
+				values[i] = awssdk.ToString(datum.VarCharValue)
+			}
+			result.Rows = append(result.Rows, values)
+		}
+	}
+	return result, nil
+}
+
+// qualify prefixes an unqualified table name with database
+func qualify(table, database string) string {
+	if strings.Contains(table, ".") || database == "" {
+		return table
+	}
+	return database + "." + table
+}
+
+func (q Query) workgroup() string {
+	if q.Workgroup == "" {
+		return DefaultWorkgroup
+	}
+	return q.Workgroup
+}
+
+func (q Query) timeout() time.Duration {
+	if q.Timeout > 0 {
+		return q.Timeout
+	}
+	return wait.DefaultOptions().Timeout
+}
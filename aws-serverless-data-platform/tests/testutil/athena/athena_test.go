@@ -0,0 +1,261 @@
+package athena
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	athenasdk "github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQuery is how fakeAthena answers one statement
+type fakeQuery struct {
+	state         athenatypes.QueryExecutionState
+	reason        string
+	statementType athenatypes.StatementType
+	columns       []Column
+	rows          [][]string
+}
+
+// fakeAthena answers queries by SQL text, finishing them as soon as they start
+// and returning pageSize rows per results page the way Athena does: a SELECT's
+// first page starts with the column names
+type fakeAthena struct {
+	queries  map[string]fakeQuery
+	pageSize int
+
+	started    []*athenasdk.StartQueryExecutionInput
+	executions map[string]string
+}
+
+func (f *fakeAthena) StartQueryExecution(_ context.Context, params *athenasdk.StartQueryExecutionInput, _ ...func(*athenasdk.Options)) (*athenasdk.StartQueryExecutionOutput, error) {
+	sql := awssdk.ToString(params.QueryString)
+	if _, ok := f.queries[sql]; !ok {
+		return nil, fmt.Errorf("unexpected query: %s", sql)
+	}
+	f.started = append(f.started, params)
+	if f.executions == nil {
+		f.executions = map[string]string{}
+	}
+	id := fmt.Sprintf("query-%d", len(f.started))
+	f.executions[id] = sql
+	return &athenasdk.StartQueryExecutionOutput{QueryExecutionId: awssdk.String(id)}, nil
+}
+
+func (f *fakeAthena) GetQueryExecution(_ context.Context, params *athenasdk.GetQueryExecutionInput, _ ...func(*athenasdk.Options)) (*athenasdk.GetQueryExecutionOutput, error) {
+	id := awssdk.ToString(params.QueryExecutionId)
+	query := f.queries[f.executions[id]]
+	return &athenasdk.GetQueryExecutionOutput{QueryExecution: &athenatypes.QueryExecution{
+		QueryExecutionId:    awssdk.String(id),
+		StatementType:       query.statementType,
+		ResultConfiguration: &athenatypes.ResultConfiguration{OutputLocation: awssdk.String("s3://results/" + id + ".csv")},
+		Status:              &athenatypes.QueryExecutionStatus{State: query.state, StateChangeReason: awssdk.String(query.reason)},
+	}}, nil
+}
+
+func (f *fakeAthena) GetQueryResults(_ context.Context, params *athenasdk.GetQueryResultsInput, _ ...func(*athenasdk.Options)) (*athenasdk.GetQueryResultsOutput, error) {
+	query := f.queries[f.executions[awssdk.ToString(params.QueryExecutionId)]]
+
+	var rows []athenatypes.Row
+	var info []athenatypes.ColumnInfo
+	for _, column := range query.columns {
+		info = append(info, athenatypes.ColumnInfo{Name: awssdk.String(column.Name), Type: awssdk.String(column.Type)})
+	}
+	if query.statementType == athenatypes.StatementTypeDml {
+		rows = append(rows, toRow(columnNames(query.columns)))
+	}
+	for _, values := range query.rows {
+		rows = append(rows, toRow(values))
+	}
+
+	start, _ := strconv.Atoi(awssdk.ToString(params.NextToken))
+	end := min(start+f.pageSize, len(rows))
+	output := &athenasdk.GetQueryResultsOutput{ResultSet: &athenatypes.ResultSet{
+		Rows:              rows[start:end],
+		ResultSetMetadata: &athenatypes.ResultSetMetadata{ColumnInfo: info},
+	}}
+	if end < len(rows) {
+		output.NextToken = awssdk.String(strconv.Itoa(end))
+	}
+	return output, nil
+}
+
+func columnNames(columns []Column) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+func toRow(values []string) athenatypes.Row {
+	row := athenatypes.Row{}
+	for _, value := range values {
+		datum := athenatypes.Datum{}
+		if value != "" {
+			datum.VarCharValue = awssdk.String(value)
+		}
+		row.Data = append(row.Data, datum)
+	}
+	return row
+}
+
+func testResult() *Result {
+	return &Result{
+		Columns: []Column{{Name: "id", Type: "integer"}, {Name: "name", Type: "varchar"}, {Name: "amount", Type: "double"},
+			{Name: "event_time", Type: "timestamp"}, {Name: "active", Type: "boolean"}, {Name: "ignored", Type: "varchar"}},
+		Rows: [][]string{
+			{"1", "alice", "12.5", "2024-03-01 10:15:00.000", "true", "x"},
+			{"2", "bob", "", "", "false", "y"},
+		},
+	}
+}
+
+func TestMaps(t *testing.T) {
+	rows := testResult().Maps()
+	require.Len(t, rows, 2)
+	assert.Equal(t, "alice", rows[0]["name"])
+	assert.Equal(t, "", rows[1]["amount"], "NULL reads as an empty string")
+}
+
+func TestScan(t *testing.T) {
+	type event struct {
+		ID        int
+		Name      string
+		Amount    *float64
+		EventTime time.Time
+		Enabled   bool `athena:"active"`
+		internal  string
+	}
+
+	var events []event
+	require.NoError(t, testResult().Scan(&events))
+	require.Len(t, events, 2)
+
+	assert.Equal(t, 1, events[0].ID)
+	assert.Equal(t, "alice", events[0].Name)
+	require.NotNil(t, events[0].Amount)
+	assert.Equal(t, 12.5, *events[0].Amount)
+	assert.Equal(t, time.Date(2024, 3, 1, 10, 15, 0, 0, time.UTC), events[0].EventTime)
+	assert.True(t, events[0].Enabled)
+
+	assert.Nil(t, events[1].Amount, "Empty values leave pointer fields nil")
+	assert.True(t, events[1].EventTime.IsZero())
+	assert.False(t, events[1].Enabled)
+}
+
+func TestScanErrors(t *testing.T) {
+	var notSlice struct{}
+	assert.Error(t, testResult().Scan(&notSlice))
+
+	var wrongType []struct{ Name int }
+	assert.ErrorContains(t, testResult().Scan(&wrongType), "row 0, column name")
+}
+
+func TestExecute(t *testing.T) {
+	sql := "SELECT id, name FROM events"
+	client := &fakeAthena{pageSize: 2, queries: map[string]fakeQuery{sql: {
+		state:         athenatypes.QueryExecutionStateSucceeded,
+		statementType: athenatypes.StatementTypeDml,
+		columns:       []Column{{Name: "id", Type: "integer"}, {Name: "name", Type: "varchar"}},
+		rows:          [][]string{{"1", "alice"}, {"2", ""}, {"3", "carol"}},
+	}}}
+
+	result, err := Execute(context.Background(), client, Query{SQL: sql, Database: "raw", Workgroup: "analytics"})
+	require.NoError(t, err)
+	assert.Equal(t, "query-1", result.QueryExecutionID)
+	assert.Equal(t, "s3://results/query-1.csv", result.OutputFile)
+	assert.Equal(t, []Column{{Name: "id", Type: "integer"}, {Name: "name", Type: "varchar"}}, result.Columns)
+	assert.Equal(t, [][]string{{"1", "alice"}, {"2", ""}, {"3", "carol"}}, result.Rows,
+		"Only the header row of the first page is dropped, and NULL reads as an empty string")
+	assert.Empty(t, result.createdTable)
+
+	require.Len(t, client.started, 1)
+	started := client.started[0]
+	assert.Equal(t, "analytics", awssdk.ToString(started.WorkGroup))
+	assert.Equal(t, "raw", awssdk.ToString(started.QueryExecutionContext.Database))
+	assert.Nil(t, started.ResultConfiguration, "The workgroup's output location is used unless one is given")
+}
+
+func TestExecuteCTAS(t *testing.T) {
+	sql := "CREATE TABLE daily AS SELECT * FROM events"
+	client := &fakeAthena{pageSize: 10, queries: map[string]fakeQuery{sql: {
+		state:         athenatypes.QueryExecutionStateSucceeded,
+		statementType: athenatypes.StatementTypeDdl,
+	}}}
+
+	result, err := Execute(context.Background(), client, Query{SQL: sql, Database: "curated", OutputLocation: "s3://results/ctas/"})
+	require.NoError(t, err)
+	assert.Equal(t, "curated.daily", result.createdTable, "The created table is dropped on cleanup")
+	assert.Empty(t, result.Rows)
+	assert.Equal(t, "s3://results/ctas/", awssdk.ToString(client.started[0].ResultConfiguration.OutputLocation))
+	assert.Equal(t, DefaultWorkgroup, awssdk.ToString(client.started[0].WorkGroup))
+}
+
+func TestExecuteFailedQuery(t *testing.T) {
+	sql := "SELECT * FROM missing"
+	client := &fakeAthena{pageSize: 10, queries: map[string]fakeQuery{sql: {
+		state:  athenatypes.QueryExecutionStateFailed,
+		reason: "TABLE_NOT_FOUND: line 1:15: Table 'awsdatacatalog.raw.missing' does not exist",
+	}}}
+
+	result, err := Execute(context.Background(), client, Query{SQL: sql})
+	require.ErrorContains(t, err, "TABLE_NOT_FOUND")
+	require.NotNil(t, result, "A query that started is returned so it can be cleaned up")
+	assert.Equal(t, "s3://results/query-1.csv", result.OutputFile)
+
+	_, err = Execute(context.Background(), client, Query{SQL: "SELECT 1"})
+	assert.ErrorContains(t, err, "start query")
+}
+
+func TestCTASPattern(t *testing.T) {
+	tests := map[string]string{
+		`CREATE TABLE curated.daily AS SELECT * FROM raw.events`:                           "curated.daily",
+		"create table if not exists `daily_totals`\nWITH (format = 'PARQUET') AS SELECT 1": "`daily_totals`",
+		`CREATE TABLE "db"."t" AS WITH x AS (SELECT 1) SELECT * FROM x`:                    `"db"."t"`,
+	}
+	for sql, table := range tests {
+		match := ctasPattern.FindStringSubmatch(sql)
+		if assert.NotNil(t, match, sql) {
+			assert.Equal(t, table, match[1])
+		}
+	}
+
+	assert.Nil(t, ctasPattern.FindStringSubmatch(`CREATE EXTERNAL TABLE raw.events (id int) LOCATION 's3://b/p/'`))
+	assert.Nil(t, ctasPattern.FindStringSubmatch(`SELECT * FROM t`))
+}
+
+func TestIcebergPattern(t *testing.T) {
+	sql := `CREATE TABLE curated.events (id int, name string)
+LOCATION 's3://curated/iceberg/events/'
+TBLPROPERTIES ('table_type' = 'ICEBERG', 'format' = 'parquet')`
+	match := icebergPattern.FindStringSubmatch(sql)
+	if assert.NotNil(t, match) {
+		assert.Equal(t, "curated.events", match[1])
+	}
+
+	assert.Nil(t, icebergPattern.FindStringSubmatch(`CREATE EXTERNAL TABLE raw.events (id int) LOCATION 's3://b/p/' TBLPROPERTIES ('has_encrypted_data' = 'false')`))
+	assert.Nil(t, icebergPattern.FindStringSubmatch(`INSERT INTO curated.events VALUES (1, 'alice')`))
+}
+
+func TestQualify(t *testing.T) {
+	assert.Equal(t, "raw.events", qualify("events", "raw"))
+	assert.Equal(t, "curated.events", qualify("curated.events", "raw"))
+	assert.Equal(t, "events", qualify("events", ""))
+}
+
+func TestParseS3URI(t *testing.T) {
+	bucket, key, err := parseS3URI("s3://results-bucket/athena/abc-123.csv")
+	require.NoError(t, err)
+	assert.Equal(t, "results-bucket", bucket)
+	assert.Equal(t, "athena/abc-123.csv", key)
+
+	_, _, err = parseS3URI("https://example.com/x")
+	assert.Error(t, err)
+}
@@ -0,0 +1,99 @@
+package athena
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+)
+
+// Cleanup deletes the query's result file and its metadata and, for a CREATE
+// TABLE AS SELECT or of an Iceberg table, the table and the data under it
+func (r *Result) Cleanup(ctx context.Context, clients *awsclients.Clients) error {
+	var errs []error
+
+	if r.createdTable != "" {
+		errs = append(errs, dropTable(ctx, clients, r.createdTable))
+	}
+	if r.OutputFile != "" {
+		// The result file has a .metadata sibling, and a CTAS its manifest; all share the prefix
+		errs = append(errs, deletePrefix(ctx, clients.S3(), r.OutputFile))
+	}
+	return errors.Join(errs...)
+}
+
+// dropTable deletes a table from the Glue Data Catalog along with the objects under its location
+func dropTable(ctx context.Context, clients *awsclients.Clients, qualified string) error {
+	database, table, ok := strings.Cut(qualified, ".")
+	if !ok {
+		return fmt.Errorf("cannot drop %s: no database", qualified)
+	}
+
+	output, err := clients.Glue().GetTable(ctx, &glue.GetTableInput{DatabaseName: awssdk.String(database), Name: awssdk.String(table)})
+	var notFound *gluetypes.EntityNotFoundException
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := clients.Glue().DeleteTable(ctx, &glue.DeleteTableInput{DatabaseName: awssdk.String(database), Name: awssdk.String(table)}); err != nil {
+		return err
+	}
+	if descriptor := output.Table.StorageDescriptor; descriptor != nil && descriptor.Location != nil {
+		return deletePrefix(ctx, clients.S3(), strings.TrimSuffix(awssdk.ToString(descriptor.Location), "/")+"/")
+	}
+	return nil
+}
+
+// deletePrefix deletes every object whose key starts with the key of an s3:// URI
+func deletePrefix(ctx context.Context, client *s3.Client, uri string) error {
+	bucket, prefix, err := parseS3URI(uri)
+	if err != nil {
+		return err
+	}
+	if prefix == "" || prefix == "/" {
+		return fmt.Errorf("refusing to empty the whole bucket %s", bucket)
+	}
+
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{Bucket: awssdk.String(bucket), Prefix: awssdk.String(prefix)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		if len(page.Contents) == 0 {
+			continue
+		}
+
+		objects := make([]s3types.ObjectIdentifier, 0, len(page.Contents))
+		for _, object := range page.Contents {
+			objects = append(objects, s3types.ObjectIdentifier{Key: object.Key})
+		}
+		if _, err := client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: awssdk.String(bucket),
+			Delete: &s3types.Delete{Objects: objects, Quiet: awssdk.Bool(true)},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseS3URI splits s3://bucket/key into its bucket and key
+func parseS3URI(uri string) (bucket, key string, err error) {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "s3" || parsed.Host == "" {
+		return "", "", fmt.Errorf("not an s3:// URI: %q", uri)
+	}
+	return parsed.Host, strings.TrimPrefix(parsed.Path, "/"), nil
+}
@@ -0,0 +1,146 @@
+package athena
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts are the formats Athena renders timestamp and date values in
+var timeLayouts = []string{"2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05Z07:00", "2006-01-02"}
+
+// Maps returns each row keyed by column name
+func (r *Result) Maps() []map[string]string {
+	rows := make([]map[string]string, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		values := make(map[string]string, len(r.Columns))
+		for i, column := range r.Columns {
+			if i < len(row) {
+				values[column.Name] = row[i]
+			}
+		}
+		rows = append(rows, values)
+	}
+	return rows
+}
+
+// Scan appends every row to dest, a pointer to a slice of structs. Columns
+// are matched to fields by an `athena:"name"` tag, or else by field name
+// ignoring case; columns without a field are skipped. Fields may be strings,
+// booleans, numbers, time.Time or pointers to those, which stay nil for empty values
+func (r *Result) Scan(dest interface{}) error {
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice || slice.Elem().Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("scan destination must be a pointer to a slice of structs, got %T", dest)
+	}
+	slice = slice.Elem()
+	rowType := slice.Type().Elem()
+
+	fields := make([]int, len(r.Columns))
+	for i, column := range r.Columns {
+		fields[i] = fieldIndex(rowType, column.Name)
+	}
+
+	for n, row := range r.Rows {
+		value := reflect.New(rowType).Elem()
+		for i, field := range fields {
+			if field < 0 || i >= len(row) {
+				continue
+			}
+			if err := set(value.Field(field), row[i]); err != nil {
+				return fmt.Errorf("row %d, column %s: %w", n, r.Columns[i].Name, err)
+			}
+		}
+		slice.Set(reflect.Append(slice, value))
+	}
+	return nil
+}
+
+// fieldIndex returns the index of the exported field column maps to, or -1
+func fieldIndex(t reflect.Type, column string) int {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if tag, ok := field.Tag.Lookup("athena"); ok {
+			if tag == column {
+				return i
+			}
+			continue
+		}
+		if strings.EqualFold(field.Name, strings.ReplaceAll(column, "_", "")) {
+			return i
+		}
+	}
+	return -1
+}
+
+// set parses raw into field according to its type
+func set(field reflect.Value, raw string) error {
+	if field.Kind() == reflect.Ptr {
+		if raw == "" {
+			return nil
+		}
+		target := reflect.New(field.Type().Elem())
+		if err := set(target.Elem(), raw); err != nil {
+			return err
+		}
+		field.Set(target)
+		return nil
+	}
+
+	if field.Type() == reflect.TypeOf(time.Time{}) {
+		if raw == "" {
+			return nil
+		}
+		for _, layout := range timeLayouts {
+			if parsed, err := time.Parse(layout, raw); err == nil {
+				field.Set(reflect.ValueOf(parsed))
+				return nil
+			}
+		}
+		return fmt.Errorf("cannot parse %q as a time", raw)
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+		return nil
+	}
+	if raw == "" {
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(parsed)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
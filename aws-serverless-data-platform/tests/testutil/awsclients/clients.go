@@ -0,0 +1,446 @@
+// =============================================================================
+// AWS Client Factory
+// Shared aws-sdk-go-v2 configuration for all test suites
+// =============================================================================
+
+package awsclients
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/accessanalyzer"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// DefaultTimeout bounds each AWS API call made through Clients.Context
+	DefaultTimeout = 2 * time.Minute
+
+	// DefaultMaxAttempts is the adaptive retryer's attempt budget per call
+	DefaultMaxAttempts = 5
+
+	// DefaultSessionName is used when assuming a role without an explicit session name
+	DefaultSessionName = "terratest"
+
+	// RoleARNEnvVar names the role every suite assumes when no role option is given,
+	// letting one suite target sandbox, dev and pre-prod accounts
+	RoleARNEnvVar = "TERRATEST_ASSUME_ROLE_ARN"
+
+	// ExternalIDEnvVar names the external ID presented when assuming RoleARNEnvVar
+	ExternalIDEnvVar = "TERRATEST_ASSUME_ROLE_EXTERNAL_ID"
+
+	// PricingRegion hosts the Pricing API endpoint used for every region's prices
+	PricingRegion = "us-east-1"
+)
+
+// Options configures how the shared AWS configuration is loaded
+type Options struct {
+	Region             string
+	RoleARN            string
+	ExternalID         string
+	SessionName        string
+	WebIdentityRoleARN string
+	SessionTags        map[string]string
+	SessionPolicy      string
+	MaxAttempts        int
+	Timeout            time.Duration
+}
+
+// Option mutates Options before the configuration is loaded
+type Option func(*Options)
+
+// WithRegion sets the region used by every client
+func WithRegion(region string) Option {
+	return func(o *Options) {
+		o.Region = region
+	}
+}
+
+// WithAssumeRole makes every client use credentials from assuming roleARN
+func WithAssumeRole(roleARN string) Option {
+	return func(o *Options) {
+		o.RoleARN = roleARN
+	}
+}
+
+// WithExternalID sets the external ID required by the assumed role's trust policy
+func WithExternalID(externalID string) Option {
+	return func(o *Options) {
+		o.ExternalID = externalID
+	}
+}
+
+// WithSessionName sets the session name used when assuming a role
+func WithSessionName(name string) Option {
+	return func(o *Options) {
+		o.SessionName = name
+	}
+}
+
+// WithWebIdentityRole makes the clients exchange the CI job's OIDC token for
+// credentials of roleARN, which then assume the WithAssumeRole role, if any
+func WithWebIdentityRole(roleARN string) Option {
+	return func(o *Options) {
+		o.WebIdentityRoleARN = roleARN
+	}
+}
+
+// WithSessionTags adds session tags to the assumed role's session; its trust
+// policy must allow sts:TagSession
+func WithSessionTags(tags map[string]string) Option {
+	return func(o *Options) {
+		if o.SessionTags == nil {
+			o.SessionTags = map[string]string{}
+		}
+		for key, value := range tags {
+			o.SessionTags[key] = value
+		}
+	}
+}
+
+// WithSessionPolicy limits the final session to what policy, a JSON policy
+// document such as TestingPolicy's, also allows
+func WithSessionPolicy(policy string) Option {
+	return func(o *Options) {
+		o.SessionPolicy = policy
+	}
+}
+
+// WithMaxAttempts sets the adaptive retryer's attempt budget
+func WithMaxAttempts(attempts int) Option {
+	return func(o *Options) {
+		o.MaxAttempts = attempts
+	}
+}
+
+// WithTimeout sets the per-call deadline returned by Clients.Context
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = timeout
+	}
+}
+
+// LoadConfig resolves an aws.Config with adaptive retries and optional role assumption;
+// the roles and session policy default to RoleARNEnvVar, ExternalIDEnvVar,
+// WebIdentityRoleARNEnvVar and SessionPolicyEnvVar when set
+func LoadConfig(ctx context.Context, opts ...Option) (aws.Config, *Options, error) {
+	defaultPolicy, err := sessionPolicy(os.Getenv)
+	if err != nil {
+		return aws.Config{}, nil, err
+	}
+	options := &Options{
+		RoleARN:            os.Getenv(RoleARNEnvVar),
+		ExternalID:         os.Getenv(ExternalIDEnvVar),
+		SessionName:        DefaultSessionName,
+		WebIdentityRoleARN: os.Getenv(WebIdentityRoleARNEnvVar),
+		SessionPolicy:      defaultPolicy,
+		MaxAttempts:        DefaultMaxAttempts,
+		Timeout:            DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.SessionPolicy != "" && options.RoleARN == "" && options.WebIdentityRoleARN == "" {
+		return aws.Config{}, nil, fmt.Errorf("a session policy needs a role to assume; set %s or %s", RoleARNEnvVar, WebIdentityRoleARNEnvVar)
+	}
+
+	loadOptions := []func(*config.LoadOptions) error{
+		config.WithRetryer(func() aws.Retryer {
+			return retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+				o.StandardOptions = append(o.StandardOptions, func(so *retry.StandardOptions) {
+					so.MaxAttempts = options.MaxAttempts
+				})
+			})
+		}),
+	}
+	if options.Region != "" {
+		loadOptions = append(loadOptions, config.WithRegion(options.Region))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return aws.Config{}, nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	// The session policy belongs to the last session, the one the clients use
+	var policy *string
+	if options.SessionPolicy != "" {
+		policy = aws.String(options.SessionPolicy)
+	}
+
+	tags := options.SessionTags
+	if options.WebIdentityRoleARN != "" {
+		ci := detectCI(os.Getenv)
+		if ci == nil {
+			return aws.Config{}, nil, fmt.Errorf("web identity role %s needs a CI OIDC token, but none was found", options.WebIdentityRoleARN)
+		}
+		provider := stscreds.NewWebIdentityRoleProvider(sts.NewFromConfig(cfg), options.WebIdentityRoleARN, ci,
+			func(o *stscreds.WebIdentityRoleOptions) {
+				o.RoleSessionName = ci.SessionName()
+				if options.RoleARN == "" {
+					o.Policy = policy
+				}
+			})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+
+		// AssumeRoleWithWebIdentity takes no tags, so the run metadata goes on
+		// the test role's session
+		tags = ci.SessionTags()
+		for key, value := range options.SessionTags {
+			tags[key] = value
+		}
+		if options.SessionName == DefaultSessionName {
+			options.SessionName = ci.SessionName()
+		}
+	}
+
+	if options.RoleARN != "" {
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), options.RoleARN,
+			func(o *stscreds.AssumeRoleOptions) {
+				o.RoleSessionName = options.SessionName
+				if options.ExternalID != "" {
+					o.ExternalID = aws.String(options.ExternalID)
+				}
+				o.Policy = policy
+				o.Tags = sessionTags(tags)
+			})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+
+	return cfg, options, nil
+}
+
+// sessionTags converts tags for AssumeRole, sorted by key
+func sessionTags(tags map[string]string) []ststypes.Tag {
+	if len(tags) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	converted := make([]ststypes.Tag, 0, len(keys))
+	for _, key := range keys {
+		converted = append(converted, ststypes.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	return converted
+}
+
+// CredentialEnvVars resolves cfg's credentials into the environment variables read by
+// Terraform, Terragrunt and the AWS CLI, so child processes act as the same principal
+func CredentialEnvVars(ctx context.Context, cfg aws.Config) (map[string]string, error) {
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+
+	// An explicit profile outranks static credentials in the SDK's chain, so clear it
+	env := map[string]string{
+		"AWS_ACCESS_KEY_ID":     creds.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY": creds.SecretAccessKey,
+		"AWS_SESSION_TOKEN":     creds.SessionToken,
+		"AWS_PROFILE":           "",
+	}
+	if cfg.Region != "" {
+		env["AWS_REGION"] = cfg.Region
+		env["AWS_DEFAULT_REGION"] = cfg.Region
+	}
+	return env, nil
+}
+
+// ExportCredentials assumes the roles named by RoleARNEnvVar and
+// WebIdentityRoleARNEnvVar, if any, and replaces the process credentials with
+// the result so terratest's own AWS helpers and child processes act in the
+// target account; call it from TestMain before any test runs
+func ExportCredentials(ctx context.Context, opts ...Option) error {
+	if os.Getenv(RoleARNEnvVar) == "" && os.Getenv(WebIdentityRoleARNEnvVar) == "" {
+		return nil
+	}
+
+	cfg, _, err := LoadConfig(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	env, err := CredentialEnvVars(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range env {
+		if err := os.Setenv(name, value); err != nil {
+			return err
+		}
+	}
+
+	// The exported credentials already belong to the role; assuming it again would chain
+	os.Unsetenv(RoleARNEnvVar)
+	os.Unsetenv(ExternalIDEnvVar)
+	os.Unsetenv(WebIdentityRoleARNEnvVar)
+	os.Unsetenv(SessionPolicyEnvVar)
+	return nil
+}
+
+// Clients builds service clients from one shared configuration
+type Clients struct {
+	Config  aws.Config
+	timeout time.Duration
+}
+
+// New loads the shared configuration and fails the test if it cannot be resolved
+func New(t testing.TB, opts ...Option) *Clients {
+	cfg, options, err := LoadConfig(context.Background(), opts...)
+	require.NoError(t, err)
+
+	return &Clients{
+		Config:  cfg,
+		timeout: options.Timeout,
+	}
+}
+
+// FromConfig builds clients from an already loaded configuration, for
+// commands that run outside a test
+func FromConfig(cfg aws.Config) *Clients {
+	return &Clients{
+		Config:  cfg,
+		timeout: DefaultTimeout,
+	}
+}
+
+// Context returns a context bounded by the configured per-call timeout
+func (c *Clients) Context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), c.timeout)
+}
+
+// AccessAnalyzer returns an IAM Access Analyzer client
+func (c *Clients) AccessAnalyzer() *accessanalyzer.Client {
+	return accessanalyzer.NewFromConfig(c.Config)
+}
+
+// Athena returns an Athena client
+func (c *Clients) Athena() *athena.Client {
+	return athena.NewFromConfig(c.Config)
+}
+
+// CloudWatch returns a CloudWatch client
+func (c *Clients) CloudWatch() *cloudwatch.Client {
+	return cloudwatch.NewFromConfig(c.Config)
+}
+
+// DynamoDB returns a DynamoDB client
+func (c *Clients) DynamoDB() *dynamodb.Client {
+	return dynamodb.NewFromConfig(c.Config)
+}
+
+// EC2 returns an EC2 client
+func (c *Clients) EC2() *ec2.Client {
+	return ec2.NewFromConfig(c.Config)
+}
+
+// EventBridge returns an EventBridge client
+func (c *Clients) EventBridge() *eventbridge.Client {
+	return eventbridge.NewFromConfig(c.Config)
+}
+
+// Firehose returns a Data Firehose client
+func (c *Clients) Firehose() *firehose.Client {
+	return firehose.NewFromConfig(c.Config)
+}
+
+// Glue returns a Glue client
+func (c *Clients) Glue() *glue.Client {
+	return glue.NewFromConfig(c.Config)
+}
+
+// IAM returns an IAM client
+func (c *Clients) IAM() *iam.Client {
+	return iam.NewFromConfig(c.Config)
+}
+
+// Kinesis returns a Kinesis Data Streams client
+func (c *Clients) Kinesis() *kinesis.Client {
+	return kinesis.NewFromConfig(c.Config)
+}
+
+// KMS returns a KMS client
+func (c *Clients) KMS() *kms.Client {
+	return kms.NewFromConfig(c.Config)
+}
+
+// Lambda returns a Lambda client
+func (c *Clients) Lambda() *lambda.Client {
+	return lambda.NewFromConfig(c.Config)
+}
+
+// Logs returns a CloudWatch Logs client
+func (c *Clients) Logs() *cloudwatchlogs.Client {
+	return cloudwatchlogs.NewFromConfig(c.Config)
+}
+
+// Pricing returns a Pricing API client; the API is only served from PricingRegion
+func (c *Clients) Pricing() *pricing.Client {
+	return pricing.NewFromConfig(c.Config, func(o *pricing.Options) {
+		o.Region = PricingRegion
+	})
+}
+
+// S3 returns an S3 client
+func (c *Clients) S3() *s3.Client {
+	return s3.NewFromConfig(c.Config)
+}
+
+// SNS returns an SNS client
+func (c *Clients) SNS() *sns.Client {
+	return sns.NewFromConfig(c.Config)
+}
+
+// SQS returns an SQS client
+func (c *Clients) SQS() *sqs.Client {
+	return sqs.NewFromConfig(c.Config)
+}
+
+// SSM returns a Systems Manager client
+func (c *Clients) SSM() *ssm.Client {
+	return ssm.NewFromConfig(c.Config)
+}
+
+// STS returns an STS client
+func (c *Clients) STS() *sts.Client {
+	return sts.NewFromConfig(c.Config)
+}
+
+// Tagging returns a Resource Groups Tagging API client
+func (c *Clients) Tagging() *resourcegroupstaggingapi.Client {
+	return resourcegroupstaggingapi.NewFromConfig(c.Config)
+}
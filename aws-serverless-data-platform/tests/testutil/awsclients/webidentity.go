@@ -0,0 +1,265 @@
+// =============================================================================
+// CI Workload Identity
+// OIDC web identity credentials, session tags and session policies for CI runs
+// =============================================================================
+
+package awsclients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// WebIdentityRoleARNEnvVar names the role CI exchanges its OIDC token for;
+	// when RoleARNEnvVar is also set, that role is assumed from this one
+	WebIdentityRoleARNEnvVar = "TERRATEST_WEB_IDENTITY_ROLE_ARN"
+
+	// WebIdentityTokenEnvVar holds the OIDC token on GitLab, whose id_tokens
+	// keyword must name it; GitHub Actions tokens are requested on demand
+	WebIdentityTokenEnvVar = "TERRATEST_WEB_IDENTITY_TOKEN"
+
+	// SessionPolicyEnvVar selects the session policy of the test run's
+	// credentials; "testing" limits them to Testing=true tagged resources
+	SessionPolicyEnvVar = "TERRATEST_SESSION_POLICY"
+
+	// SessionResourcesEnvVar lists, comma separated, the ARNs the "testing"
+	// session policy also allows, such as the Terraform state bucket and lock
+	// table, which are not test resources
+	SessionResourcesEnvVar = "TERRATEST_SESSION_RESOURCES"
+
+	// TestingSessionPolicy is the SessionPolicyEnvVar value selecting
+	// TestingPolicy
+	TestingSessionPolicy = "testing"
+
+	// webIdentityAudience is the audience STS accepts in OIDC tokens
+	webIdentityAudience = "sts.amazonaws.com"
+
+	// maxSessionPolicyLength is the packed size limit STS puts on session policies
+	maxSessionPolicyLength = 2048
+)
+
+// readOnlyActions are allowed on untagged resources by TestingPolicy, since
+// tests look up shared and AWS managed resources that carry no Testing tag
+var readOnlyActions = []string{
+	"access-analyzer:ValidatePolicy",
+	"athena:Get*",
+	"athena:List*",
+	"cloudwatch:Describe*",
+	"cloudwatch:Get*",
+	"cloudwatch:List*",
+	"ec2:Describe*",
+	"glue:Get*",
+	"iam:Get*",
+	"iam:List*",
+	"iam:SimulatePrincipalPolicy",
+	"kms:Describe*",
+	"kms:List*",
+	"logs:Describe*",
+	"pricing:*",
+	"s3:GetBucketLocation",
+	"s3:ListAllMyBuckets",
+	"sts:GetCallerIdentity",
+	"tag:Get*",
+}
+
+// sessionNameUnsafe matches the characters STS rejects in role session names
+var sessionNameUnsafe = regexp.MustCompile(`[^\w+=,.@-]`)
+
+// sessionTagUnsafe matches the characters STS rejects in session tag values
+var sessionTagUnsafe = regexp.MustCompile(`[^\p{L}\p{Z}\p{N}_.:/=+\-@]`)
+
+// ciIdentity is the OIDC identity of a CI job; it implements
+// stscreds.IdentityTokenRetriever
+type ciIdentity struct {
+	// Provider is "github" or "gitlab"
+	Provider   string
+	Repository string
+	RunID      string
+	Ref        string
+
+	// token returns the job's OIDC token
+	token func() (string, error)
+}
+
+// detectCI returns the OIDC identity of the CI job the process runs in, or nil
+// outside CI or when the job was not granted a token: GitHub Actions needs the
+// id-token: write permission and GitLab an id_tokens entry named
+// WebIdentityTokenEnvVar
+func detectCI(getenv func(string) string) *ciIdentity {
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true" && getenv("ACTIONS_ID_TOKEN_REQUEST_URL") != "":
+		requestURL, requestToken := getenv("ACTIONS_ID_TOKEN_REQUEST_URL"), getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+		return &ciIdentity{
+			Provider:   "github",
+			Repository: getenv("GITHUB_REPOSITORY"),
+			RunID:      getenv("GITHUB_RUN_ID"),
+			Ref:        getenv("GITHUB_REF_NAME"),
+			token: func() (string, error) {
+				return requestGitHubToken(requestURL, requestToken)
+			},
+		}
+	case getenv("GITLAB_CI") == "true" && getenv(WebIdentityTokenEnvVar) != "":
+		token := getenv(WebIdentityTokenEnvVar)
+		return &ciIdentity{
+			Provider:   "gitlab",
+			Repository: getenv("CI_PROJECT_PATH"),
+			RunID:      getenv("CI_PIPELINE_ID"),
+			Ref:        getenv("CI_COMMIT_REF_NAME"),
+			token:      func() (string, error) { return token, nil },
+		}
+	}
+	return nil
+}
+
+// GetIdentityToken returns the job's OIDC token
+func (c *ciIdentity) GetIdentityToken() ([]byte, error) {
+	token, err := c.token()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(token), nil
+}
+
+// SessionName names the role session after the CI run so CloudTrail shows
+// which run made each call
+func (c *ciIdentity) SessionName() string {
+	name := sessionNameUnsafe.ReplaceAllString(strings.Join([]string{DefaultSessionName, c.Provider, c.RunID}, "-"), "-")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return strings.TrimRight(name, "-")
+}
+
+// SessionTags returns the run metadata attached to the test role's session;
+// empty values are left out
+func (c *ciIdentity) SessionTags() map[string]string {
+	tags := map[string]string{}
+	for key, value := range map[string]string{
+		"CIProvider":   c.Provider,
+		"CIRepository": c.Repository,
+		"CIRunID":      c.RunID,
+		"CIRef":        c.Ref,
+	} {
+		value = sessionTagUnsafe.ReplaceAllString(value, "-")
+		if len(value) > 256 {
+			value = value[:256]
+		}
+		if value != "" {
+			tags[key] = value
+		}
+	}
+	return tags
+}
+
+// requestGitHubToken asks the GitHub Actions token service for an OIDC token
+// with the STS audience
+func requestGitHubToken(requestURL, requestToken string) (string, error) {
+	parsed, err := url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid GitHub OIDC token URL: %w", err)
+	}
+	query := parsed.Query()
+	query.Set("audience", webIdentityAudience)
+	parsed.RawQuery = query.Encode()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "bearer "+requestToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request GitHub OIDC token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to request GitHub OIDC token: %s", resp.Status)
+	}
+
+	var body struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode GitHub OIDC token: %w", err)
+	}
+	if body.Value == "" {
+		return "", errors.New("GitHub returned an empty OIDC token")
+	}
+	return body.Value, nil
+}
+
+// TestingPolicy returns a session policy that limits a session to resources
+// tagged Testing=true, creating resources with that tag, read-only lookups and
+// the given resources
+func TestingPolicy(resources ...string) (string, error) {
+	statements := []map[string]interface{}{
+		{
+			"Sid":       "TaggedResources",
+			"Effect":    "Allow",
+			"Action":    "*",
+			"Resource":  "*",
+			"Condition": map[string]interface{}{"StringEquals": map[string]string{"aws:ResourceTag/Testing": "true"}},
+		},
+		{
+			"Sid":       "TaggedCreates",
+			"Effect":    "Allow",
+			"Action":    "*",
+			"Resource":  "*",
+			"Condition": map[string]interface{}{"StringEquals": map[string]string{"aws:RequestTag/Testing": "true"}},
+		},
+		{
+			"Sid":      "ReadOnly",
+			"Effect":   "Allow",
+			"Action":   readOnlyActions,
+			"Resource": "*",
+		},
+	}
+	if len(resources) > 0 {
+		sort.Strings(resources)
+		statements = append(statements, map[string]interface{}{
+			"Sid":      "Resources",
+			"Effect":   "Allow",
+			"Action":   "*",
+			"Resource": resources,
+		})
+	}
+
+	policy, err := json.Marshal(map[string]interface{}{"Version": "2012-10-17", "Statement": statements})
+	if err != nil {
+		return "", err
+	}
+	if len(policy) > maxSessionPolicyLength {
+		return "", fmt.Errorf("session policy is %d characters, over the %d STS accepts", len(policy), maxSessionPolicyLength)
+	}
+	return string(policy), nil
+}
+
+// sessionPolicy resolves the session policy selected by SessionPolicyEnvVar
+func sessionPolicy(getenv func(string) string) (string, error) {
+	switch name := getenv(SessionPolicyEnvVar); name {
+	case "":
+		return "", nil
+	case TestingSessionPolicy:
+		var resources []string
+		for _, resource := range strings.Split(getenv(SessionResourcesEnvVar), ",") {
+			if resource = strings.TrimSpace(resource); resource != "" {
+				resources = append(resources, resource)
+			}
+		}
+		return TestingPolicy(resources...)
+	default:
+		return "", fmt.Errorf("unknown %s %q; use %q", SessionPolicyEnvVar, name, TestingSessionPolicy)
+	}
+}
@@ -0,0 +1,147 @@
+package awsclients
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// env returns a getenv over vars
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func TestDetectCI(t *testing.T) {
+	assert.Nil(t, detectCI(env(nil)))
+
+	// A workflow without id-token: write gets no token request URL
+	assert.Nil(t, detectCI(env(map[string]string{"GITHUB_ACTIONS": "true"})))
+
+	// A GitLab job without an id_tokens entry has nothing to exchange
+	assert.Nil(t, detectCI(env(map[string]string{"GITLAB_CI": "true"})))
+
+	gitlab := detectCI(env(map[string]string{
+		"GITLAB_CI":            "true",
+		WebIdentityTokenEnvVar: "eyJ.gitlab",
+		"CI_PROJECT_PATH":      "data/platform",
+		"CI_PIPELINE_ID":       "4242",
+		"CI_COMMIT_REF_NAME":   "main",
+	}))
+	require.NotNil(t, gitlab)
+	assert.Equal(t, "gitlab", gitlab.Provider)
+	token, err := gitlab.GetIdentityToken()
+	require.NoError(t, err)
+	assert.Equal(t, "eyJ.gitlab", string(token))
+	assert.Equal(t, "terratest-gitlab-4242", gitlab.SessionName())
+	assert.Equal(t, map[string]string{
+		"CIProvider":   "gitlab",
+		"CIRepository": "data/platform",
+		"CIRunID":      "4242",
+		"CIRef":        "main",
+	}, gitlab.SessionTags())
+}
+
+func TestGitHubToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "bearer request-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "sts.amazonaws.com", r.URL.Query().Get("audience"))
+		assert.Equal(t, "1", r.URL.Query().Get("api-version"))
+		w.Write([]byte(`{"value":"eyJ.github"}`))
+	}))
+	defer server.Close()
+
+	github := detectCI(env(map[string]string{
+		"GITHUB_ACTIONS":                 "true",
+		"ACTIONS_ID_TOKEN_REQUEST_URL":   server.URL + "/token?api-version=1",
+		"ACTIONS_ID_TOKEN_REQUEST_TOKEN": "request-token",
+		"GITHUB_REPOSITORY":              "your-org/aws-serverless-data-platform",
+		"GITHUB_RUN_ID":                  "987654",
+		"GITHUB_REF_NAME":                "feature/oidc",
+	}))
+	require.NotNil(t, github)
+	token, err := github.GetIdentityToken()
+	require.NoError(t, err)
+	assert.Equal(t, "eyJ.github", string(token))
+	assert.Equal(t, "terratest-github-987654", github.SessionName())
+	assert.Equal(t, "feature/oidc", github.SessionTags()["CIRef"])
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer failing.Close()
+	_, err = requestGitHubToken(failing.URL, "request-token")
+	assert.ErrorContains(t, err, "403")
+}
+
+func TestSessionNameAndTagsAreSanitized(t *testing.T) {
+	ci := &ciIdentity{Provider: "gitlab", RunID: "run #7/" + strings.Repeat("9", 80), Ref: "fix: a&b", Repository: ""}
+
+	name := ci.SessionName()
+	assert.Len(t, name, 64)
+	assert.True(t, strings.HasPrefix(name, "terratest-gitlab-run--7-9"), name)
+
+	tags := ci.SessionTags()
+	assert.Equal(t, "fix: a-b", tags["CIRef"])
+	assert.NotContains(t, tags, "CIRepository")
+}
+
+func TestTestingPolicy(t *testing.T) {
+	policy, err := TestingPolicy("arn:aws:s3:::tf-state/*", "arn:aws:dynamodb:us-east-1:123456789012:table/tf-locks")
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(policy), maxSessionPolicyLength)
+
+	var document struct {
+		Statement []struct {
+			Sid       string
+			Action    interface{}
+			Resource  interface{}
+			Condition map[string]map[string]string
+		}
+	}
+	require.NoError(t, json.Unmarshal([]byte(policy), &document))
+	require.Len(t, document.Statement, 4)
+	assert.Equal(t, "true", document.Statement[0].Condition["StringEquals"]["aws:ResourceTag/Testing"])
+	assert.Equal(t, "true", document.Statement[1].Condition["StringEquals"]["aws:RequestTag/Testing"])
+	assert.Contains(t, document.Statement[2].Action, "sts:GetCallerIdentity")
+	assert.Equal(t, []interface{}{"arn:aws:dynamodb:us-east-1:123456789012:table/tf-locks", "arn:aws:s3:::tf-state/*"}, document.Statement[3].Resource)
+
+	// Without extra resources there is no statement granting them
+	policy, err = TestingPolicy()
+	require.NoError(t, err)
+	assert.NotContains(t, policy, `"Resources"`)
+
+	_, err = TestingPolicy(strings.Repeat("arn:aws:s3:::bucket,", 100))
+	assert.ErrorContains(t, err, "over the 2048")
+}
+
+func TestSessionPolicy(t *testing.T) {
+	policy, err := sessionPolicy(env(nil))
+	require.NoError(t, err)
+	assert.Empty(t, policy)
+
+	policy, err = sessionPolicy(env(map[string]string{
+		SessionPolicyEnvVar:    "testing",
+		SessionResourcesEnvVar: " arn:aws:s3:::tf-state/* , ,arn:aws:s3:::tf-state",
+	}))
+	require.NoError(t, err)
+	assert.Contains(t, policy, `"arn:aws:s3:::tf-state/*"`)
+	assert.Contains(t, policy, `"arn:aws:s3:::tf-state"`)
+
+	_, err = sessionPolicy(env(map[string]string{SessionPolicyEnvVar: "admin"}))
+	assert.ErrorContains(t, err, `unknown TERRATEST_SESSION_POLICY "admin"`)
+}
+
+func TestSessionTags(t *testing.T) {
+	assert.Nil(t, sessionTags(nil))
+	assert.Equal(t, []ststypes.Tag{
+		{Key: aws.String("CIProvider"), Value: aws.String("github")},
+		{Key: aws.String("CIRunID"), Value: aws.String("1")},
+	}, sessionTags(map[string]string{"CIRunID": "1", "CIProvider": "github"}))
+}
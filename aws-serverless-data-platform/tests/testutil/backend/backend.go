@@ -0,0 +1,135 @@
+// =============================================================================
+// State Backend Pre-flight
+// Checks the Terraform state backend before anything is applied against it
+// =============================================================================
+
+// Package backend checks the S3 state backend Terragrunt configures in
+// root.hcl before a suite applies against it, so a missing bucket, missing
+// permissions or a lock left by a crashed run fail the test in seconds with
+// the fix, rather than minutes into an apply.
+package backend
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultStaleAfter is the age past which a lock is reported as stale rather
+// than held by a run in progress
+const DefaultStaleAfter = time.Hour
+
+// lockOwners adds who holds each lock, and since when, to the failure
+var lockOwners = flag.Bool("lock-owners", false, "report the owner of every state lock the backend pre-flight check finds")
+
+// Config is the state backend of one environment
+type Config struct {
+	Bucket string
+	Region string
+	// LockTable is the DynamoDB lock table; empty when Terragrunt locks with
+	// S3 lock files (use_lockfile)
+	LockTable string
+	// Prefix is the part of the state keys shared by the environment's units
+	Prefix string
+}
+
+// repoConfig is the part of config/common.yaml and config/accounts.yaml that
+// describes the state backend
+type repoConfig struct {
+	Terraform struct {
+		StateBucket string `yaml:"state_bucket"`
+		StateRegion string `yaml:"state_region"`
+		LockTable   string `yaml:"lock_table"`
+	} `yaml:"terraform"`
+}
+
+// FromRepo reads the state backend of environment in region from the
+// repository at root, as root.hcl does: the account's terraform block in
+// config/accounts.yaml, with the state region from config/common.yaml when
+// the account does not set one
+func FromRepo(root, environment, region string) (Config, error) {
+	var common repoConfig
+	if err := readYAML(filepath.Join(root, "config", "common.yaml"), &common); err != nil {
+		return Config{}, err
+	}
+	var accounts map[string]repoConfig
+	if err := readYAML(filepath.Join(root, "config", "accounts.yaml"), &accounts); err != nil {
+		return Config{}, err
+	}
+	account, ok := accounts[environment]
+	if !ok {
+		return Config{}, fmt.Errorf("config/accounts.yaml has no %s account", environment)
+	}
+
+	config := Config{
+		Bucket:    account.Terraform.StateBucket,
+		Region:    account.Terraform.StateRegion,
+		LockTable: account.Terraform.LockTable,
+		Prefix:    environment + "/" + region + "/",
+	}
+	if config.Region == "" {
+		config.Region = common.Terraform.StateRegion
+	}
+	if config.Bucket == "" {
+		return Config{}, fmt.Errorf("config/accounts.yaml sets no terraform.state_bucket for %s", environment)
+	}
+	if config.Region == "" {
+		return Config{}, errors.New("no terraform.state_region in config/accounts.yaml or config/common.yaml")
+	}
+	return config, nil
+}
+
+// readYAML decodes the YAML file at path into out
+func readYAML(path string, out interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// Preflight checks the state bucket exists with versioning and encryption,
+// the lock table exists when one is configured, the caller can read and write
+// state under the environment's prefix, and no unit's state is locked; the
+// test fails listing every problem with its fix
+func Preflight(t *testing.T, config Config) {
+	t.Helper()
+	logger := logging.New(t, "bucket", config.Bucket, "region", config.Region)
+	defer logger.Phase("preflight")()
+
+	clients := awsclients.New(t, awsclients.WithRegion(config.Region))
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	problems := CheckBucket(ctx, clients.S3(), config)
+	if len(problems) == 0 {
+		problems = append(problems, CheckAccess(ctx, clients.S3(), config)...)
+	}
+	if config.LockTable != "" {
+		problems = append(problems, CheckLockTable(ctx, clients.DynamoDB(), config)...)
+	} else {
+		logger.Debug("No lock table configured, Terragrunt locks with S3 lock files")
+	}
+
+	locks, err := ListLocks(ctx, clients.S3(), clients.DynamoDB(), config)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("cannot list state locks under s3://%s/%s: %v", config.Bucket, config.Prefix, err))
+	}
+	problems = append(problems, CheckLocks(locks, time.Now(), DefaultStaleAfter, *lockOwners)...)
+
+	require.Empty(t, problems, "State backend is not ready:\n  %s", strings.Join(problems, "\n  "))
+	logger.Success("State backend is ready", "prefix", config.Prefix, "lock_table", config.LockTable)
+}
@@ -0,0 +1,91 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/testconfig"
+)
+
+// writeRepo lays out config/common.yaml and config/accounts.yaml under a temporary root
+func writeRepo(t *testing.T, common, accounts string) string {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "config"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "config", "common.yaml"), []byte(common), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "config", "accounts.yaml"), []byte(accounts), 0o644))
+	return root
+}
+
+func TestFromRepo(t *testing.T) {
+	root := writeRepo(t, "terraform:\n  state_region: ap-southeast-1\n", `
+dev:
+  terraform:
+    state_bucket: state-dev
+prod:
+  terraform:
+    state_bucket: state-prod
+    state_region: eu-west-1
+    lock_table: lock-prod
+`)
+
+	config, err := FromRepo(root, "dev", "us-east-1")
+	require.NoError(t, err)
+	assert.Equal(t, Config{Bucket: "state-dev", Region: "ap-southeast-1", Prefix: "dev/us-east-1/"}, config)
+
+	config, err = FromRepo(root, "prod", "eu-west-1")
+	require.NoError(t, err)
+	assert.Equal(t, Config{Bucket: "state-prod", Region: "eu-west-1", LockTable: "lock-prod", Prefix: "prod/eu-west-1/"}, config)
+
+	_, err = FromRepo(root, "staging", "us-east-1")
+	assert.ErrorContains(t, err, "no staging account")
+
+	_, err = FromRepo(writeRepo(t, "terraform: {}\n", "dev:\n  terraform:\n    state_bucket: state-dev\n"), "dev", "us-east-1")
+	assert.ErrorContains(t, err, "state_region")
+}
+
+func TestFromRepoConfig(t *testing.T) {
+	root, err := testconfig.FindRoot()
+	require.NoError(t, err)
+	if root == "" {
+		t.Skip("Repository root not found")
+	}
+
+	// The integration environment's backend must resolve from the committed configuration
+	config, err := FromRepo(root, "dev", "us-east-1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, config.Bucket)
+	assert.NotEmpty(t, config.Region)
+}
+
+func TestCheckLocks(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	info, err := ParseLockInfo([]byte(`{"ID":"0d7b-11ef","Operation":"OperationTypeApply","Who":"runner@ci","Version":"1.9.5","Created":"2024-06-01T09:00:00Z","Path":"state/dev/us-east-1/03-storage/terraform.tfstate"}`))
+	require.NoError(t, err)
+
+	locks := []Lock{
+		{State: "dev/us-east-1/03-storage/terraform.tfstate", Info: info},
+		{State: "dev/us-east-1/01-networking/terraform.tfstate", Modified: now.Add(-5 * time.Minute)},
+	}
+
+	assert.Equal(t, []string{
+		"dev/us-east-1/01-networking/terraform.tfstate was locked 5m0s ago by a run that may still be applying; wait for it to finish",
+		"dev/us-east-1/03-storage/terraform.tfstate has been locked for 3h0m0s, likely by a crashed run; once no apply is running, " +
+			"run terragrunt force-unlock 0d7b-11ef in its unit",
+	}, CheckLocks(locks, now, DefaultStaleAfter, false))
+
+	assert.Equal(t, []string{
+		"dev/us-east-1/01-networking/terraform.tfstate was locked 5m0s ago by a run that may still be applying; wait for it to finish " +
+			"(held by unknown for unknown since 2024-06-01T11:55:00Z)",
+		"dev/us-east-1/03-storage/terraform.tfstate has been locked for 3h0m0s, likely by a crashed run; once no apply is running, " +
+			"run terragrunt force-unlock 0d7b-11ef in its unit (held by runner@ci for OperationTypeApply since 2024-06-01T09:00:00Z)",
+	}, CheckLocks(locks, now, DefaultStaleAfter, true))
+
+	assert.Empty(t, CheckLocks(nil, now, DefaultStaleAfter, true))
+
+	_, err = ParseLockInfo([]byte("not json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,120 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// setupHint is how a missing or misconfigured backend is fixed
+const setupHint = "run scripts/setup-backend.sh for the environment"
+
+// CheckBucket checks the state bucket exists in the configured region, keeps
+// every version of the state and encrypts it by default
+func CheckBucket(ctx context.Context, client *s3.Client, config Config) []string {
+	bucket := "s3://" + config.Bucket
+	if _, err := client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: awssdk.String(config.Bucket)}); err != nil {
+		switch errorCode(err) {
+		case "NotFound", "NoSuchBucket":
+			return []string{fmt.Sprintf("state bucket %s does not exist; %s", bucket, setupHint)}
+		case "Forbidden", "AccessDenied", "403":
+			return []string{fmt.Sprintf("caller may not access state bucket %s; grant s3:ListBucket on it", bucket)}
+		case "PermanentRedirect", "301":
+			return []string{fmt.Sprintf("state bucket %s is not in %s; fix terraform.state_region", bucket, config.Region)}
+		}
+		return []string{fmt.Sprintf("cannot reach state bucket %s: %v", bucket, err)}
+	}
+
+	var problems []string
+	versioning, err := client.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{Bucket: awssdk.String(config.Bucket)})
+	switch {
+	case err != nil:
+		problems = append(problems, fmt.Sprintf("cannot read versioning of %s: %v", bucket, err))
+	case versioning.Status != s3types.BucketVersioningStatusEnabled:
+		problems = append(problems, fmt.Sprintf("state bucket %s does not have versioning enabled, so a corrupted state cannot be recovered; "+
+			"aws s3api put-bucket-versioning --bucket %s --versioning-configuration Status=Enabled", bucket, config.Bucket))
+	}
+
+	encryption, err := client.GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{Bucket: awssdk.String(config.Bucket)})
+	switch {
+	case errorCode(err) == "ServerSideEncryptionConfigurationNotFoundError":
+		problems = append(problems, fmt.Sprintf("state bucket %s has no default encryption; %s", bucket, setupHint))
+	case err != nil:
+		problems = append(problems, fmt.Sprintf("cannot read encryption of %s: %v", bucket, err))
+	case encryption.ServerSideEncryptionConfiguration == nil || len(encryption.ServerSideEncryptionConfiguration.Rules) == 0:
+		problems = append(problems, fmt.Sprintf("state bucket %s has no default encryption; %s", bucket, setupHint))
+	}
+	return problems
+}
+
+// CheckAccess writes, reads and deletes a probe object under the
+// environment's prefix, as terraform does with state and lock files
+func CheckAccess(ctx context.Context, client *s3.Client, config Config) []string {
+	key := fmt.Sprintf("%s.preflight-%d", config.Prefix, time.Now().UnixNano())
+	location := fmt.Sprintf("s3://%s/%s", config.Bucket, config.Prefix)
+
+	put, err := client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: awssdk.String(config.Bucket),
+		Key:    awssdk.String(key),
+		Body:   strings.NewReader("preflight"),
+	})
+	if err != nil {
+		return []string{fmt.Sprintf("caller may not write state under %s; grant s3:PutObject: %v", location, err)}
+	}
+
+	var problems []string
+	if _, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: awssdk.String(config.Bucket), Key: awssdk.String(key)}); err != nil {
+		problems = append(problems, fmt.Sprintf("caller may not read state under %s; grant s3:GetObject: %v", location, err))
+	}
+
+	// Deleting the version itself leaves no delete marker behind in a versioned bucket
+	if _, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket:    awssdk.String(config.Bucket),
+		Key:       awssdk.String(key),
+		VersionId: put.VersionId,
+	}); err != nil {
+		problems = append(problems, fmt.Sprintf("caller may not delete lock files under %s; grant s3:DeleteObject and s3:DeleteObjectVersion: %v", location, err))
+	}
+	return problems
+}
+
+// CheckLockTable checks the DynamoDB lock table exists, is active and is keyed
+// by LockID as the S3 backend expects
+func CheckLockTable(ctx context.Context, client *dynamodb.Client, config Config) []string {
+	output, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: awssdk.String(config.LockTable)})
+	var notFound *dynamodbtypes.ResourceNotFoundException
+	switch {
+	case errors.As(err, &notFound):
+		return []string{fmt.Sprintf("lock table %s does not exist in %s; %s", config.LockTable, config.Region, setupHint)}
+	case err != nil:
+		return []string{fmt.Sprintf("cannot describe lock table %s: %v", config.LockTable, err)}
+	}
+
+	var problems []string
+	table := output.Table
+	if table.TableStatus != dynamodbtypes.TableStatusActive {
+		problems = append(problems, fmt.Sprintf("lock table %s is %s, not ACTIVE", config.LockTable, table.TableStatus))
+	}
+	if len(table.KeySchema) != 1 || awssdk.ToString(table.KeySchema[0].AttributeName) != "LockID" {
+		problems = append(problems, fmt.Sprintf("lock table %s must have the single hash key LockID", config.LockTable))
+	}
+	return problems
+}
+
+// errorCode returns the API error code of err, or "" for other errors
+func errorCode(err error) string {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return ""
+	}
+	return apiErr.ErrorCode()
+}
@@ -0,0 +1,156 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// LockSuffix ends the lock file Terraform writes next to a state file when
+// the S3 backend sets use_lockfile
+const LockSuffix = ".tflock"
+
+// LockInfo is the record Terraform stores in a lock
+type LockInfo struct {
+	ID        string    `json:"ID"`
+	Operation string    `json:"Operation"`
+	Who       string    `json:"Who"`
+	Version   string    `json:"Version"`
+	Created   time.Time `json:"Created"`
+	Path      string    `json:"Path"`
+}
+
+// Lock is a lock held on one unit's state
+type Lock struct {
+	// State is the state file the lock is held on, relative to the bucket
+	State string
+	// Modified is when the lock was written, for locks whose record is unreadable
+	Modified time.Time
+	Info     LockInfo
+}
+
+// ListLocks returns the S3 lock files, and the lock table entries when a
+// table is configured, held on state under the environment's prefix
+func ListLocks(ctx context.Context, s3Client *s3.Client, dynamoClient *dynamodb.Client, config Config) ([]Lock, error) {
+	var locks []Lock
+	paginator := s3.NewListObjectsV2Paginator(s3Client, &s3.ListObjectsV2Input{
+		Bucket: awssdk.String(config.Bucket),
+		Prefix: awssdk.String(config.Prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range page.Contents {
+			key := awssdk.ToString(object.Key)
+			if !strings.HasSuffix(key, LockSuffix) {
+				continue
+			}
+			lock := Lock{State: strings.TrimSuffix(key, LockSuffix), Modified: awssdk.ToTime(object.LastModified)}
+			if output, err := s3Client.GetObject(ctx, &s3.GetObjectInput{Bucket: awssdk.String(config.Bucket), Key: object.Key}); err == nil {
+				content, _ := io.ReadAll(output.Body)
+				output.Body.Close()
+				lock.Info, _ = ParseLockInfo(content)
+			}
+			locks = append(locks, lock)
+		}
+	}
+
+	if config.LockTable == "" {
+		return locks, nil
+	}
+
+	// Entries without Info are the state checksums the backend keeps in the same table
+	scan := dynamodb.NewScanPaginator(dynamoClient, &dynamodb.ScanInput{
+		TableName:        awssdk.String(config.LockTable),
+		FilterExpression: awssdk.String("begins_with(LockID, :prefix) AND attribute_exists(Info)"),
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+			":prefix": &dynamodbtypes.AttributeValueMemberS{Value: config.Bucket + "/" + config.Prefix},
+		},
+	})
+	for scan.HasMorePages() {
+		page, err := scan.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			lock := Lock{State: strings.TrimPrefix(stringAttribute(item, "LockID"), config.Bucket+"/")}
+			lock.Info, _ = ParseLockInfo([]byte(stringAttribute(item, "Info")))
+			locks = append(locks, lock)
+		}
+	}
+	return locks, nil
+}
+
+// stringAttribute returns the string attribute name of item, or ""
+func stringAttribute(item map[string]dynamodbtypes.AttributeValue, name string) string {
+	if value, ok := item[name].(*dynamodbtypes.AttributeValueMemberS); ok {
+		return value.Value
+	}
+	return ""
+}
+
+// ParseLockInfo decodes the record Terraform stores in a lock
+func ParseLockInfo(content []byte) (LockInfo, error) {
+	var info LockInfo
+	if err := json.Unmarshal(content, &info); err != nil {
+		return LockInfo{}, fmt.Errorf("invalid lock record: %w", err)
+	}
+	return info, nil
+}
+
+// CheckLocks describes every lock as a problem, since an apply would wait on
+// it, telling stale locks from ones a run may still hold; with owners set
+// each includes who took the lock and for what
+func CheckLocks(locks []Lock, now time.Time, staleAfter time.Duration, owners bool) []string {
+	sort.Slice(locks, func(i, j int) bool { return locks[i].State < locks[j].State })
+
+	var problems []string
+	for _, lock := range locks {
+		created := lock.Info.Created
+		if created.IsZero() {
+			created = lock.Modified
+		}
+		age := now.Sub(created).Round(time.Minute)
+
+		var problem string
+		if age >= staleAfter {
+			problem = fmt.Sprintf("%s has been locked for %s, likely by a crashed run; once no apply is running, "+
+				"run terragrunt force-unlock %s in its unit", lock.State, age, lockID(lock))
+		} else {
+			problem = fmt.Sprintf("%s was locked %s ago by a run that may still be applying; wait for it to finish", lock.State, age)
+		}
+		if owners {
+			problem += fmt.Sprintf(" (held by %s for %s since %s)", orUnknown(lock.Info.Who), orUnknown(lock.Info.Operation),
+				created.UTC().Format(time.RFC3339))
+		}
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+// lockID returns the ID force-unlock needs, or a placeholder when the lock record is unreadable
+func lockID(lock Lock) string {
+	if lock.Info.ID == "" {
+		return "<lock ID from the apply error>"
+	}
+	return lock.Info.ID
+}
+
+// orUnknown returns value, or "unknown" when it is empty
+func orUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
@@ -0,0 +1,120 @@
+// =============================================================================
+// Chaos Experiments
+// Injects controlled failures into a deployed pipeline and checks it recovers
+// =============================================================================
+
+package chaos
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/wait"
+)
+
+// DefaultAlarmTimeout covers two five-minute evaluation periods, the longest the
+// monitoring module's alarms take to change state
+const DefaultAlarmTimeout = 15 * time.Minute
+
+// Fault is a failure injected into deployed resources. Inject records whatever
+// Restore needs to put the original state back.
+type Fault struct {
+	Name    string
+	Inject  func(ctx context.Context, clients *awsclients.Clients) error
+	Restore func(ctx context.Context, clients *awsclients.Clients) error
+}
+
+// Inject injects the fault and restores it when the test finishes. The returned
+// function restores it earlier, e.g. to check recovery, and is safe to call again.
+func Inject(t *testing.T, clients *awsclients.Clients, fault Fault) func() {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	require.NoError(t, fault.Inject(ctx, clients), "Failed to inject fault %s", fault.Name)
+	logging.New(t).Info("💥 Injected fault", "fault", fault.Name)
+
+	var once sync.Once
+	restore := func() {
+		once.Do(func() {
+			ctx, cancel := clients.Context()
+			defer cancel()
+
+			if err := fault.Restore(ctx, clients); err != nil {
+				t.Errorf("Failed to restore fault %s, the resources are left broken: %v", fault.Name, err)
+				return
+			}
+			logging.New(t).Success("Restored fault", "fault", fault.Name)
+		})
+	}
+	t.Cleanup(restore)
+	return restore
+}
+
+// Experiment injects Fault, expects every alarm in Alarms to fire, then restores
+// the fault and expects the alarms to clear and Steady to pass again
+type Experiment struct {
+	Fault  Fault
+	Alarms []string
+
+	// Steady checks the pipeline works; it runs before the fault is injected
+	// and again after it is restored to prove recovery
+	Steady func(t *testing.T)
+
+	// During runs while the fault is active, e.g. to push data that should fail
+	During func(t *testing.T)
+
+	// AlarmTimeout bounds each wait for an alarm, DefaultAlarmTimeout when zero
+	AlarmTimeout time.Duration
+}
+
+// Run performs the experiment
+func Run(t *testing.T, clients *awsclients.Clients, experiment Experiment) {
+	timeout := experiment.AlarmTimeout
+	if timeout == 0 {
+		timeout = DefaultAlarmTimeout
+	}
+
+	if experiment.Steady != nil {
+		experiment.Steady(t)
+	}
+
+	restore := Inject(t, clients, experiment.Fault)
+	if experiment.During != nil {
+		experiment.During(t)
+	}
+	for _, alarm := range experiment.Alarms {
+		AssertAlarmFires(t, clients, alarm, timeout)
+	}
+
+	restore()
+	for _, alarm := range experiment.Alarms {
+		AssertAlarmClears(t, clients, alarm, timeout)
+	}
+	if experiment.Steady != nil {
+		experiment.Steady(t)
+	}
+
+	logging.New(t).Success("Pipeline recovered", "fault", experiment.Fault.Name)
+}
+
+// AssertAlarmFires waits for the alarm to reach the ALARM state
+func AssertAlarmFires(t *testing.T, clients *awsclients.Clients, alarm string, timeout time.Duration) {
+	wait.Until(t, "alarm "+alarm+" to fire", wait.AlarmInState(clients.CloudWatch(), alarm, cwtypes.StateValueAlarm),
+		wait.DefaultOptions().WithTimeout(timeout).WithInterval(30*time.Second))
+	logging.New(t).Success("Alarm fired", "alarm", alarm)
+}
+
+// AssertAlarmClears waits for the alarm to leave the ALARM state; alarms that treat
+// missing data as missing settle in INSUFFICIENT_DATA rather than OK
+func AssertAlarmClears(t *testing.T, clients *awsclients.Clients, alarm string, timeout time.Duration) {
+	wait.Until(t, "alarm "+alarm+" to clear", wait.AlarmInState(clients.CloudWatch(), alarm,
+		cwtypes.StateValueOk, cwtypes.StateValueInsufficientData),
+		wait.DefaultOptions().WithTimeout(timeout).WithInterval(30*time.Second))
+	logging.New(t).Success("Alarm cleared", "alarm", alarm)
+}
@@ -0,0 +1,76 @@
+package chaos
+
+import (
+	"context"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/iampolicy"
+)
+
+func TestInjectRestoresOnce(t *testing.T) {
+	var injected, restored int
+	fault := Fault{
+		Name:    "test fault",
+		Inject:  func(context.Context, *awsclients.Clients) error { injected++; return nil },
+		Restore: func(context.Context, *awsclients.Clients) error { restored++; return nil },
+	}
+
+	t.Run("inject", func(t *testing.T) {
+		restore := Inject(t, &awsclients.Clients{}, fault)
+		assert.Equal(t, 1, injected)
+		assert.Equal(t, 0, restored)
+
+		restore()
+		assert.Equal(t, 1, restored)
+	})
+	assert.Equal(t, 1, restored, "The cleanup must not restore a fault restored early")
+}
+
+func TestDenyPolicy(t *testing.T) {
+	document, err := denyPolicy([]string{"s3:GetObject"}, []string{"arn:aws:s3:::raw/*"})
+	require.NoError(t, err)
+
+	policy, err := iampolicy.Parse(document)
+	require.NoError(t, err)
+	require.Len(t, policy.Statement, 1)
+	assert.Equal(t, "Deny", policy.Statement[0].Effect)
+
+	document, err = denyPolicy([]string{"kms:Decrypt"}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, document, `"Resource":["*"]`, "No resources denies the actions everywhere")
+
+	_, err = denyPolicy(nil, nil)
+	assert.Error(t, err)
+}
+
+func TestRevokePolicyName(t *testing.T) {
+	name := revokePolicyName([]string{"s3:GetObject"}, []string{"arn:aws:s3:::raw/*"})
+	assert.Regexp(t, `^chaos-revoke-[0-9a-f]{8}$`, name)
+	assert.Equal(t, name, revokePolicyName([]string{"s3:GetObject"}, []string{"arn:aws:s3:::raw/*"}))
+	assert.NotEqual(t, name, revokePolicyName([]string{"s3:PutObject"}, []string{"arn:aws:s3:::raw/*"}))
+}
+
+func TestCreateTriggerInput(t *testing.T) {
+	trigger := gluetypes.Trigger{
+		Name:         awssdk.String("process-raw"),
+		Type:         gluetypes.TriggerTypeConditional,
+		State:        gluetypes.TriggerStateActivated,
+		WorkflowName: awssdk.String("pipeline"),
+		Actions:      []gluetypes.Action{{JobName: awssdk.String("raw-to-processed")}},
+	}
+
+	input := createTriggerInput(trigger, map[string]string{"Project": "data-platform"})
+	assert.Equal(t, "process-raw", awssdk.ToString(input.Name))
+	assert.Equal(t, gluetypes.TriggerTypeConditional, input.Type)
+	assert.Equal(t, trigger.Actions, input.Actions)
+	assert.Equal(t, "data-platform", input.Tags["Project"])
+	assert.True(t, input.StartOnCreation, "An active trigger is recreated active")
+
+	trigger.State = gluetypes.TriggerStateDeactivated
+	assert.False(t, createTriggerInput(trigger, nil).StartOnCreation)
+}
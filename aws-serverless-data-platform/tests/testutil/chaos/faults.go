@@ -0,0 +1,176 @@
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+)
+
+// DisableFunction sets a Lambda function's reserved concurrency to zero so every
+// invocation is throttled, then restores the previous reservation
+func DisableFunction(functionName string) Fault {
+	var previous *int32
+	return Fault{
+		Name: "disable Lambda function " + functionName,
+		Inject: func(ctx context.Context, clients *awsclients.Clients) error {
+			output, err := clients.Lambda().GetFunctionConcurrency(ctx, &lambda.GetFunctionConcurrencyInput{
+				FunctionName: awssdk.String(functionName),
+			})
+			if err != nil {
+				return err
+			}
+			previous = output.ReservedConcurrentExecutions
+
+			_, err = clients.Lambda().PutFunctionConcurrency(ctx, &lambda.PutFunctionConcurrencyInput{
+				FunctionName:                 awssdk.String(functionName),
+				ReservedConcurrentExecutions: awssdk.Int32(0),
+			})
+			return err
+		},
+		Restore: func(ctx context.Context, clients *awsclients.Clients) error {
+			if previous == nil {
+				_, err := clients.Lambda().DeleteFunctionConcurrency(ctx, &lambda.DeleteFunctionConcurrencyInput{
+					FunctionName: awssdk.String(functionName),
+				})
+				return err
+			}
+			_, err := clients.Lambda().PutFunctionConcurrency(ctx, &lambda.PutFunctionConcurrencyInput{
+				FunctionName:                 awssdk.String(functionName),
+				ReservedConcurrentExecutions: previous,
+			})
+			return err
+		},
+	}
+}
+
+// RevokePermission denies actions on resources to a role with an inline policy,
+// which overrides any allow, and deletes the policy on restore. IAM takes a few
+// seconds to propagate the deny, so check for it before relying on it.
+func RevokePermission(roleName string, actions []string, resources ...string) Fault {
+	policyName := revokePolicyName(actions, resources)
+	return Fault{
+		Name: fmt.Sprintf("revoke %s from role %s", strings.Join(actions, ", "), roleName),
+		Inject: func(ctx context.Context, clients *awsclients.Clients) error {
+			document, err := denyPolicy(actions, resources)
+			if err != nil {
+				return err
+			}
+			_, err = clients.IAM().PutRolePolicy(ctx, &iam.PutRolePolicyInput{
+				RoleName:       awssdk.String(roleName),
+				PolicyName:     awssdk.String(policyName),
+				PolicyDocument: awssdk.String(document),
+			})
+			return err
+		},
+		Restore: func(ctx context.Context, clients *awsclients.Clients) error {
+			_, err := clients.IAM().DeleteRolePolicy(ctx, &iam.DeleteRolePolicyInput{
+				RoleName:   awssdk.String(roleName),
+				PolicyName: awssdk.String(policyName),
+			})
+			return err
+		},
+	}
+}
+
+// revokePolicyName names the inline deny policy after what it denies, so two
+// revocations on one role do not overwrite each other
+func revokePolicyName(actions, resources []string) string {
+	hash := fnv.New32a()
+	for _, value := range append(append([]string{}, actions...), resources...) {
+		hash.Write([]byte(value + "\n"))
+	}
+	return fmt.Sprintf("chaos-revoke-%08x", hash.Sum32())
+}
+
+// denyPolicy renders a policy denying actions on resources, or on every resource
+// when none are given
+func denyPolicy(actions, resources []string) (string, error) {
+	if len(actions) == 0 {
+		return "", fmt.Errorf("at least one action to revoke is required")
+	}
+	if len(resources) == 0 {
+		resources = []string{"*"}
+	}
+
+	document, err := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{{
+			"Effect":   "Deny",
+			"Action":   actions,
+			"Resource": resources,
+		}},
+	})
+	return string(document), err
+}
+
+// DeleteTrigger deletes a Glue trigger, e.g. while its workflow is running, and
+// recreates it from the saved definition and tags on restore
+func DeleteTrigger(triggerName string) Fault {
+	var (
+		trigger gluetypes.Trigger
+		tags    map[string]string
+	)
+	return Fault{
+		Name: "delete Glue trigger " + triggerName,
+		Inject: func(ctx context.Context, clients *awsclients.Clients) error {
+			output, err := clients.Glue().GetTrigger(ctx, &glue.GetTriggerInput{Name: awssdk.String(triggerName)})
+			if err != nil {
+				return err
+			}
+			trigger = *output.Trigger
+
+			arn, err := triggerARN(ctx, clients, triggerName)
+			if err != nil {
+				return err
+			}
+			tagsOutput, err := clients.Glue().GetTags(ctx, &glue.GetTagsInput{ResourceArn: awssdk.String(arn)})
+			if err != nil {
+				return err
+			}
+			tags = tagsOutput.Tags
+
+			_, err = clients.Glue().DeleteTrigger(ctx, &glue.DeleteTriggerInput{Name: awssdk.String(triggerName)})
+			return err
+		},
+		Restore: func(ctx context.Context, clients *awsclients.Clients) error {
+			_, err := clients.Glue().CreateTrigger(ctx, createTriggerInput(trigger, tags))
+			return err
+		},
+	}
+}
+
+// triggerARN builds the ARN Glue tags a trigger under
+func triggerARN(ctx context.Context, clients *awsclients.Clients, triggerName string) (string, error) {
+	identity, err := clients.STS().GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("arn:aws:glue:%s:%s:trigger/%s", clients.Config.Region, awssdk.ToString(identity.Account), triggerName), nil
+}
+
+// createTriggerInput recreates trigger as it was, activated if it was active
+func createTriggerInput(trigger gluetypes.Trigger, tags map[string]string) *glue.CreateTriggerInput {
+	return &glue.CreateTriggerInput{
+		Name:                   trigger.Name,
+		Type:                   trigger.Type,
+		Actions:                trigger.Actions,
+		Description:            trigger.Description,
+		EventBatchingCondition: trigger.EventBatchingCondition,
+		Predicate:              trigger.Predicate,
+		Schedule:               trigger.Schedule,
+		WorkflowName:           trigger.WorkflowName,
+		Tags:                   tags,
+		StartOnCreation: trigger.State == gluetypes.TriggerStateActivated ||
+			trigger.State == gluetypes.TriggerStateActivating,
+	}
+}
@@ -0,0 +1,98 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/loadgen"
+)
+
+// floodPayloadSize keeps each flood batch at 500 KiB, well over the 1,000
+// records per second a shard accepts when sent back to back
+const floodPayloadSize = 1024
+
+// ThrottleShard floods one shard of a Kinesis stream past its write capacity, so
+// producers hashing to it are throttled, until restored. An empty shardID picks
+// the stream's first open shard.
+func ThrottleShard(streamName, shardID string) Fault {
+	var (
+		cancel context.CancelFunc
+		done   sync.WaitGroup
+	)
+	return Fault{
+		Name: fmt.Sprintf("throttle shard %q of Kinesis stream %s", shardID, streamName),
+		Inject: func(ctx context.Context, clients *awsclients.Clients) error {
+			client := clients.Kinesis()
+			hashKey, err := shardHashKey(ctx, client, streamName, shardID)
+			if err != nil {
+				return err
+			}
+
+			var floodCtx context.Context
+			floodCtx, cancel = context.WithCancel(context.Background())
+			done.Add(1)
+			go func() {
+				defer done.Done()
+				flood(floodCtx, client, streamName, hashKey)
+			}()
+			return nil
+		},
+		Restore: func(ctx context.Context, clients *awsclients.Clients) error {
+			cancel()
+			done.Wait()
+			return nil
+		},
+	}
+}
+
+// shardHashKey returns the starting hash key of shardID, or of the first open
+// shard when shardID is empty
+func shardHashKey(ctx context.Context, client *kinesis.Client, streamName, shardID string) (string, error) {
+	input := &kinesis.ListShardsInput{StreamName: awssdk.String(streamName)}
+	for {
+		page, err := client.ListShards(ctx, input)
+		if err != nil {
+			return "", err
+		}
+		for _, shard := range page.Shards {
+			open := shard.SequenceNumberRange == nil || shard.SequenceNumberRange.EndingSequenceNumber == nil
+			if (shardID == "" && open) || awssdk.ToString(shard.ShardId) == shardID {
+				return awssdk.ToString(shard.HashKeyRange.StartingHashKey), nil
+			}
+		}
+		if page.NextToken == nil {
+			break
+		}
+		// ListShards rejects the stream name alongside a token
+		input = &kinesis.ListShardsInput{NextToken: page.NextToken}
+	}
+	if shardID == "" {
+		return "", fmt.Errorf("stream %s has no open shard", streamName)
+	}
+	return "", fmt.Errorf("shard %s not found in stream %s", shardID, streamName)
+}
+
+// flood puts full batches at hashKey until ctx is cancelled, ignoring throttling
+func flood(ctx context.Context, client *kinesis.Client, streamName, hashKey string) {
+	var sequence int64
+	for ctx.Err() == nil {
+		entries := make([]kinesistypes.PutRecordsRequestEntry, loadgen.MaxBatchSize)
+		for i := range entries {
+			entries[i] = kinesistypes.PutRecordsRequestEntry{
+				Data:            loadgen.Payload(sequence, floodPayloadSize),
+				PartitionKey:    awssdk.String("chaos"),
+				ExplicitHashKey: awssdk.String(hashKey),
+			}
+			sequence++
+		}
+		_, _ = client.PutRecords(ctx, &kinesis.PutRecordsInput{
+			StreamName: awssdk.String(streamName),
+			Records:    entries,
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// =============================================================================
+// Cost Budget Assertions
+// Fails tests whose planned infrastructure exceeds the monthly budget
+// =============================================================================
+
+package cost
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tfplan"
+)
+
+const (
+	// MaxMonthlyCostEnvVar overrides the budget, in USD per month
+	MaxMonthlyCostEnvVar = "MAX_MONTHLY_COST"
+
+	// DefaultMaxMonthlyCost is the budget used when MAX_MONTHLY_COST is unset
+	DefaultMaxMonthlyCost = 500.0
+)
+
+// MaxMonthlyCost returns the budget from MAX_MONTHLY_COST or the default
+func MaxMonthlyCost() (float64, error) {
+	value, ok := os.LookupEnv(MaxMonthlyCostEnvVar)
+	if !ok || value == "" {
+		return DefaultMaxMonthlyCost, nil
+	}
+
+	budget, err := strconv.ParseFloat(value, 64)
+	if err != nil || budget < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative number", MaxMonthlyCostEnvVar, value)
+	}
+	return budget, nil
+}
+
+// AssertWithinBudget checks the estimated monthly cost of the plans does not exceed the budget
+func AssertWithinBudget(t *testing.T, pricer Pricer, region string, plans ...*tfplan.Plan) bool {
+	t.Helper()
+
+	budget, err := MaxMonthlyCost()
+	require.NoError(t, err)
+
+	estimate, err := EstimatePlans(context.Background(), pricer, region, plans...)
+	require.NoError(t, err, "Failed to estimate monthly cost")
+
+	logger := logging.New(t, "region", region)
+	for _, item := range estimate.Items {
+		logger.Info("Estimated resource cost", "address", item.Address, "quantity", item.Quantity, "unit_price", item.UnitPrice, "monthly_cost", item.MonthlyCost)
+	}
+
+	if !assert.LessOrEqual(t, estimate.Total, budget,
+		"Estimated monthly cost $%.2f exceeds the $%.2f budget (%s)", estimate.Total, budget, MaxMonthlyCostEnvVar) {
+		return false
+	}
+
+	logger.Success("Estimated monthly cost is within the budget", "monthly_cost", estimate.Total, "budget", budget)
+	return true
+}
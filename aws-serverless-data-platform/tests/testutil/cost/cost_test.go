@@ -0,0 +1,75 @@
+package cost
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tfplan"
+)
+
+// fakePricer prices queries by service code
+type fakePricer map[string]float64
+
+func (f fakePricer) UnitPrice(_ context.Context, query Query) (float64, error) {
+	return f[query.ServiceCode], nil
+}
+
+func loadPlan(t *testing.T) *tfplan.Plan {
+	planJSON, err := os.ReadFile("testdata/plan.json")
+	require.NoError(t, err)
+
+	plan, err := tfplan.Parse(string(planJSON))
+	require.NoError(t, err)
+	return plan
+}
+
+func TestEstimatePlans(t *testing.T) {
+	pricer := fakePricer{"AmazonEC2": 0.045, "awskms": 1, "AmazonVPC": 0.01}
+
+	estimate, err := EstimatePlans(context.Background(), pricer, "us-east-1", loadPlan(t))
+	require.NoError(t, err)
+
+	// Two NAT gateways, one KMS key and one interface endpoint in two AZs;
+	// the gateway endpoint and the bucket have no fixed cost
+	require.Len(t, estimate.Items, 4)
+	assert.Equal(t, "aws_vpc_endpoint.glue", estimate.Items[3].Address)
+	assert.InDelta(t, 2*HoursPerMonth, estimate.Items[3].Quantity, 0.001)
+	assert.InDelta(t, 2*730*0.045+1+2*730*0.01, estimate.Total, 0.001)
+}
+
+func TestMaxOnDemandPrice(t *testing.T) {
+	priceList := []string{
+		`{"terms":{"OnDemand":{"A.1":{"priceDimensions":{
+			"A.1.1":{"unit":"Hrs","beginRange":"0","pricePerUnit":{"USD":"0.0450000000"}},
+			"A.1.2":{"unit":"GB","beginRange":"0","pricePerUnit":{"USD":"0.0450000000"}}}}}}}`,
+		`{"terms":{"OnDemand":{"B.1":{"priceDimensions":{
+			"B.1.1":{"unit":"Hrs","beginRange":"0","pricePerUnit":{"USD":"0.0590000000"}},
+			"B.1.2":{"unit":"Hrs","beginRange":"100","pricePerUnit":{"USD":"0.0900000000"}}}}}}}`,
+	}
+
+	price, err := maxOnDemandPrice(priceList, "Hrs")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.059, price, 0.0000001)
+
+	_, err = maxOnDemandPrice(priceList, "Requests")
+	assert.Error(t, err)
+}
+
+func TestMaxMonthlyCost(t *testing.T) {
+	t.Setenv(MaxMonthlyCostEnvVar, "")
+	budget, err := MaxMonthlyCost()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultMaxMonthlyCost, budget)
+
+	t.Setenv(MaxMonthlyCostEnvVar, "120.5")
+	budget, err = MaxMonthlyCost()
+	require.NoError(t, err)
+	assert.Equal(t, 120.5, budget)
+
+	t.Setenv(MaxMonthlyCostEnvVar, "lots")
+	_, err = MaxMonthlyCost()
+	assert.Error(t, err)
+}
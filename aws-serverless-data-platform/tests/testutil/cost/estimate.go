@@ -0,0 +1,147 @@
+// =============================================================================
+// Monthly Cost Estimation
+// Prices the fixed-cost resources in a Terraform plan
+// =============================================================================
+
+package cost
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tfplan"
+)
+
+// HoursPerMonth is the number of hours AWS uses to convert hourly to monthly prices
+const HoursPerMonth = 730
+
+// rate describes how one resource type maps to a price list entry. Only
+// resources with a fixed hourly or monthly charge are priced; usage-based
+// services such as S3, Glue and Athena do not appear in the estimate
+type rate struct {
+	serviceCode string
+	unit        string
+	filters     func(resource tfplan.Resource) map[string]string
+	quantity    func(resource tfplan.Resource) float64
+}
+
+// rates maps Terraform resource types to their pricing
+var rates = map[string]rate{
+	"aws_nat_gateway": {
+		serviceCode: "AmazonEC2",
+		unit:        "Hrs",
+		filters:     staticFilters(map[string]string{"productFamily": "NAT Gateway"}),
+		quantity:    hourly(1),
+	},
+	"aws_kms_key": {
+		serviceCode: "awskms",
+		filters:     staticFilters(map[string]string{"productFamily": "Encryption Key"}),
+		quantity:    func(tfplan.Resource) float64 { return 1 },
+	},
+	"aws_vpc_endpoint": {
+		serviceCode: "AmazonVPC",
+		unit:        "Hrs",
+		filters:     staticFilters(map[string]string{"productFamily": "VpcEndpoint"}),
+		quantity: func(resource tfplan.Resource) float64 {
+			// Gateway endpoints are free; interface endpoints bill per AZ
+			if endpointType, _ := resource.Attribute("vpc_endpoint_type"); endpointType != "Interface" {
+				return 0
+			}
+			subnets, _ := resource.Attribute("subnet_ids")
+			ids, _ := subnets.([]interface{})
+			return float64(max(len(ids), 1)) * HoursPerMonth
+		},
+	},
+	"aws_opensearch_domain": {
+		serviceCode: "AmazonES",
+		unit:        "Hrs",
+		filters: func(resource tfplan.Resource) map[string]string {
+			instanceType, _ := resource.Attribute("cluster_config.0.instance_type")
+			return map[string]string{
+				"productFamily": "Amazon OpenSearch Service Instance",
+				"instanceType":  fmt.Sprint(instanceType),
+			}
+		},
+		quantity: func(resource tfplan.Resource) float64 {
+			count, _ := resource.Attribute("cluster_config.0.instance_count")
+			instances, _ := count.(float64)
+			return max(instances, 1) * HoursPerMonth
+		},
+	},
+}
+
+// LineItem is the estimated monthly cost of one planned resource
+type LineItem struct {
+	Address     string
+	Type        string
+	Quantity    float64
+	UnitPrice   float64
+	MonthlyCost float64
+}
+
+// Estimate is the estimated monthly cost of one or more plans
+type Estimate struct {
+	Items []LineItem
+	Total float64
+}
+
+// EstimatePlans prices every fixed-cost resource the plans would create in region
+func EstimatePlans(ctx context.Context, pricer Pricer, region string, plans ...*tfplan.Plan) (*Estimate, error) {
+	estimate := &Estimate{}
+
+	for _, plan := range plans {
+		for _, resource := range plan.Resources() {
+			rate, ok := rates[resource.Type]
+			if !ok {
+				continue
+			}
+
+			quantity := rate.quantity(resource)
+			if quantity == 0 {
+				continue
+			}
+
+			filters := rate.filters(resource)
+			filters["regionCode"] = region
+
+			unitPrice, err := pricer.UnitPrice(ctx, Query{
+				ServiceCode: rate.serviceCode,
+				Filters:     filters,
+				Unit:        rate.unit,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to price %s: %w", resource.Address, err)
+			}
+
+			item := LineItem{
+				Address:     resource.Address,
+				Type:        resource.Type,
+				Quantity:    quantity,
+				UnitPrice:   unitPrice,
+				MonthlyCost: quantity * unitPrice,
+			}
+			estimate.Items = append(estimate.Items, item)
+			estimate.Total += item.MonthlyCost
+		}
+	}
+
+	return estimate, nil
+}
+
+// staticFilters returns a filter function that always yields a copy of filters
+func staticFilters(filters map[string]string) func(tfplan.Resource) map[string]string {
+	return func(tfplan.Resource) map[string]string {
+		result := make(map[string]string, len(filters)+1)
+		for field, value := range filters {
+			result[field] = value
+		}
+		return result
+	}
+}
+
+// hourly returns a quantity function billing count units for every hour of the month
+func hourly(count float64) func(tfplan.Resource) float64 {
+	return func(tfplan.Resource) float64 {
+		return count * HoursPerMonth
+	}
+}
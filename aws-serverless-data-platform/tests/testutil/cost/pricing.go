@@ -0,0 +1,151 @@
+// =============================================================================
+// AWS Pricing Lookup
+// On-demand unit prices from the AWS Pricing API
+// =============================================================================
+
+package cost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/pricing"
+	pricingtypes "github.com/aws/aws-sdk-go-v2/service/pricing/types"
+)
+
+// Query identifies one on-demand price in the AWS price list
+type Query struct {
+	ServiceCode string
+	Filters     map[string]string
+
+	// Unit selects the price dimension, e.g. "Hrs"; empty accepts any unit
+	Unit string
+}
+
+// key returns a stable cache key for the query
+func (q Query) key() string {
+	fields := make([]string, 0, len(q.Filters))
+	for field, value := range q.Filters {
+		fields = append(fields, field+"="+value)
+	}
+	sort.Strings(fields)
+	return q.ServiceCode + "|" + q.Unit + "|" + strings.Join(fields, ",")
+}
+
+// Pricer returns the USD unit price matching a query
+type Pricer interface {
+	UnitPrice(ctx context.Context, query Query) (float64, error)
+}
+
+// PricingAPI is a Pricer backed by the AWS Pricing API, caching each lookup
+type PricingAPI struct {
+	client *pricing.Client
+
+	mu    sync.Mutex
+	cache map[string]float64
+}
+
+// NewPricingAPI wraps a Pricing API client
+func NewPricingAPI(client *pricing.Client) *PricingAPI {
+	return &PricingAPI{
+		client: client,
+		cache:  make(map[string]float64),
+	}
+}
+
+// UnitPrice returns the highest first-tier on-demand price matching query, so
+// that an ambiguous filter errs on the side of overestimating
+func (p *PricingAPI) UnitPrice(ctx context.Context, query Query) (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if price, ok := p.cache[query.key()]; ok {
+		return price, nil
+	}
+
+	filters := make([]pricingtypes.Filter, 0, len(query.Filters))
+	for field, value := range query.Filters {
+		filters = append(filters, pricingtypes.Filter{
+			Field: aws.String(field),
+			Value: aws.String(value),
+			Type:  pricingtypes.FilterTypeTermMatch,
+		})
+	}
+
+	var priceList []string
+	paginator := pricing.NewGetProductsPaginator(p.client, &pricing.GetProductsInput{
+		ServiceCode: aws.String(query.ServiceCode),
+		Filters:     filters,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get %s products: %w", query.ServiceCode, err)
+		}
+		priceList = append(priceList, page.PriceList...)
+	}
+
+	price, err := maxOnDemandPrice(priceList, query.Unit)
+	if err != nil {
+		return 0, fmt.Errorf("%s %v: %w", query.ServiceCode, query.Filters, err)
+	}
+
+	p.cache[query.key()] = price
+	return price, nil
+}
+
+// priceListItem is the subset of a Pricing API price list document used here
+type priceListItem struct {
+	Terms struct {
+		OnDemand map[string]struct {
+			PriceDimensions map[string]struct {
+				Unit         string            `json:"unit"`
+				BeginRange   string            `json:"beginRange"`
+				PricePerUnit map[string]string `json:"pricePerUnit"`
+			} `json:"priceDimensions"`
+		} `json:"OnDemand"`
+	} `json:"terms"`
+}
+
+// maxOnDemandPrice returns the highest non-zero first-tier USD price with the given unit
+func maxOnDemandPrice(priceList []string, unit string) (float64, error) {
+	found := false
+	var highest float64
+
+	for _, document := range priceList {
+		var item priceListItem
+		if err := json.Unmarshal([]byte(document), &item); err != nil {
+			return 0, fmt.Errorf("failed to parse price list: %w", err)
+		}
+
+		for _, term := range item.Terms.OnDemand {
+			for _, dimension := range term.PriceDimensions {
+				if unit != "" && !strings.EqualFold(dimension.Unit, unit) {
+					continue
+				}
+				if dimension.BeginRange != "" && dimension.BeginRange != "0" {
+					continue
+				}
+				price, err := strconv.ParseFloat(dimension.PricePerUnit["USD"], 64)
+				if err != nil || price == 0 {
+					continue
+				}
+				if !found || price > highest {
+					highest = price
+					found = true
+				}
+			}
+		}
+	}
+
+	if !found {
+		return 0, fmt.Errorf("no on-demand price found for unit %q", unit)
+	}
+	return highest, nil
+}
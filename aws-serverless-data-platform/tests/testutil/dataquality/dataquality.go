@@ -0,0 +1,256 @@
+// =============================================================================
+// Data Quality Expectations
+// Declarative table checks compiled to Athena SQL
+// =============================================================================
+
+package dataquality
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+)
+
+// QueryFunc runs a query and returns its data rows without the header row
+type QueryFunc func(query string) ([][]string, error)
+
+// Expectation is one check against a table; its query must return a single row
+type Expectation interface {
+	// Name describes the expectation in results
+	Name() string
+
+	// SQL returns the query for table, a quoted "database"."table" reference
+	SQL(table string) string
+
+	// Evaluate decides the outcome from the query's single result row
+	Evaluate(row []string) (passed bool, observed string, err error)
+}
+
+// Suite is the set of expectations for one table
+type Suite struct {
+	Database     string
+	Table        string
+	Expectations []Expectation
+}
+
+// Result is the outcome of one expectation
+type Result struct {
+	Table       string
+	Expectation string
+	Passed      bool
+	Observed    string
+	SQL         string
+}
+
+// String formats the result for test logs
+func (r Result) String() string {
+	status := "PASS"
+	if !r.Passed {
+		status = "FAIL"
+	}
+	return fmt.Sprintf("[%s] %s: %s (observed %s)", status, r.Table, r.Expectation, r.Observed)
+}
+
+// Run compiles and runs every expectation in the suite
+func Run(suite Suite, query QueryFunc) ([]Result, error) {
+	table := Identifier(suite.Database, suite.Table)
+
+	results := make([]Result, 0, len(suite.Expectations))
+	for _, expectation := range suite.Expectations {
+		sql := expectation.SQL(table)
+
+		rows, err := query(sql)
+		if err != nil {
+			return results, fmt.Errorf("%s: %w", expectation.Name(), err)
+		}
+		if len(rows) != 1 {
+			return results, fmt.Errorf("%s: query returned %d rows, want 1", expectation.Name(), len(rows))
+		}
+
+		passed, observed, err := expectation.Evaluate(rows[0])
+		if err != nil {
+			return results, fmt.Errorf("%s: %w", expectation.Name(), err)
+		}
+
+		results = append(results, Result{
+			Table:       suite.Table,
+			Expectation: expectation.Name(),
+			Passed:      passed,
+			Observed:    observed,
+			SQL:         sql,
+		})
+	}
+	return results, nil
+}
+
+// Assert runs the suite and fails the test for every expectation that does not hold
+func Assert(t *testing.T, suite Suite, query QueryFunc) []Result {
+	t.Helper()
+
+	results, err := Run(suite, query)
+	if err != nil {
+		t.Fatalf("Data quality checks on %s stopped: %v", suite.Table, err)
+	}
+
+	logger := logging.New(t, "table", suite.Table)
+	failed := 0
+	for _, result := range results {
+		if result.Passed {
+			logger.Success(result.String())
+			continue
+		}
+		failed++
+		t.Errorf("%s\n%s", result, result.SQL)
+	}
+	if failed == 0 {
+		logger.Success("All data quality expectations hold", "expectations", len(results))
+	}
+	return results
+}
+
+// Identifier quotes each part of a dotted Athena identifier
+func Identifier(parts ...string) string {
+	quoted := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		quoted = append(quoted, `"`+strings.ReplaceAll(part, `"`, `""`)+`"`)
+	}
+	return strings.Join(quoted, ".")
+}
+
+// RowCount expects the table to hold between Min and Max rows; Max 0 means unbounded
+type RowCount struct {
+	Min int64
+	Max int64
+}
+
+// Name describes the expectation
+func (e RowCount) Name() string {
+	if e.Max == 0 {
+		return fmt.Sprintf("row count >= %d", e.Min)
+	}
+	return fmt.Sprintf("row count in [%d, %d]", e.Min, e.Max)
+}
+
+// SQL counts the table's rows
+func (e RowCount) SQL(table string) string {
+	return fmt.Sprintf("SELECT COUNT(*) FROM %s", table)
+}
+
+// Evaluate compares the count against the range
+func (e RowCount) Evaluate(row []string) (bool, string, error) {
+	count, err := parseInt(row, 0)
+	if err != nil {
+		return false, "", err
+	}
+	return count >= e.Min && (e.Max == 0 || count <= e.Max), strconv.FormatInt(count, 10), nil
+}
+
+// NullRate expects at most Max (0 to 1) of Column's values to be null
+type NullRate struct {
+	Column string
+	Max    float64
+}
+
+// Name describes the expectation
+func (e NullRate) Name() string {
+	return fmt.Sprintf("null rate of %s <= %.2f%%", e.Column, e.Max*100)
+}
+
+// SQL counts all rows and the rows where the column is set
+func (e NullRate) SQL(table string) string {
+	return fmt.Sprintf("SELECT COUNT(*), COUNT(%s) FROM %s", Identifier(e.Column), table)
+}
+
+// Evaluate computes the null rate; an empty table has none
+func (e NullRate) Evaluate(row []string) (bool, string, error) {
+	total, err := parseInt(row, 0)
+	if err != nil {
+		return false, "", err
+	}
+	set, err := parseInt(row, 1)
+	if err != nil {
+		return false, "", err
+	}
+
+	rate := 0.0
+	if total > 0 {
+		rate = float64(total-set) / float64(total)
+	}
+	return rate <= e.Max, fmt.Sprintf("%.2f%% (%d of %d)", rate*100, total-set, total), nil
+}
+
+// Unique expects no two rows to share the same values of Columns
+type Unique struct {
+	Columns []string
+}
+
+// Name describes the expectation
+func (e Unique) Name() string {
+	return fmt.Sprintf("unique (%s)", strings.Join(e.Columns, ", "))
+}
+
+// SQL counts the key values that occur more than once
+func (e Unique) SQL(table string) string {
+	columns := make([]string, 0, len(e.Columns))
+	for _, column := range e.Columns {
+		columns = append(columns, Identifier(column))
+	}
+	key := strings.Join(columns, ", ")
+	return fmt.Sprintf("SELECT COUNT(*) FROM (SELECT %s FROM %s GROUP BY %s HAVING COUNT(*) > 1)", key, table, key)
+}
+
+// Evaluate passes when there are no duplicated keys
+func (e Unique) Evaluate(row []string) (bool, string, error) {
+	duplicates, err := parseInt(row, 0)
+	if err != nil {
+		return false, "", err
+	}
+	return duplicates == 0, fmt.Sprintf("%d duplicated keys", duplicates), nil
+}
+
+// References expects every non-null Column value to exist in RefColumn of the referenced table
+type References struct {
+	Column      string
+	RefDatabase string
+	RefTable    string
+	RefColumn   string
+}
+
+// Name describes the expectation
+func (e References) Name() string {
+	return fmt.Sprintf("%s references %s.%s", e.Column, e.RefTable, e.RefColumn)
+}
+
+// SQL counts the rows whose value has no match in the referenced table
+func (e References) SQL(table string) string {
+	return fmt.Sprintf("SELECT COUNT(*) FROM %s AS t LEFT JOIN %s AS r ON t.%s = r.%s WHERE t.%s IS NOT NULL AND r.%s IS NULL",
+		table, Identifier(e.RefDatabase, e.RefTable),
+		Identifier(e.Column), Identifier(e.RefColumn), Identifier(e.Column), Identifier(e.RefColumn))
+}
+
+// Evaluate passes when there are no orphaned rows
+func (e References) Evaluate(row []string) (bool, string, error) {
+	orphans, err := parseInt(row, 0)
+	if err != nil {
+		return false, "", err
+	}
+	return orphans == 0, fmt.Sprintf("%d orphaned rows", orphans), nil
+}
+
+// parseInt reads an integer column from a result row
+func parseInt(row []string, index int) (int64, error) {
+	if index >= len(row) {
+		return 0, fmt.Errorf("result row has %d columns, want at least %d", len(row), index+1)
+	}
+	value, err := strconv.ParseInt(row[index], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("column %d is %q, want an integer", index, row[index])
+	}
+	return value, nil
+}
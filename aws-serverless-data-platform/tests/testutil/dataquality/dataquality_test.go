@@ -0,0 +1,107 @@
+package dataquality
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQuery answers each compiled query with a canned row
+func fakeQuery(answers map[string][]string) QueryFunc {
+	return func(query string) ([][]string, error) {
+		row, ok := answers[query]
+		if !ok {
+			return nil, errors.New("unexpected query: " + query)
+		}
+		return [][]string{row}, nil
+	}
+}
+
+func TestSQL(t *testing.T) {
+	table := Identifier("curated", "orders")
+	assert.Equal(t, `"curated"."orders"`, table)
+	assert.Equal(t, `"weird""name"`, Identifier(`weird"name`))
+
+	assert.Equal(t, `SELECT COUNT(*) FROM "curated"."orders"`, RowCount{Min: 1}.SQL(table))
+	assert.Equal(t, `SELECT COUNT(*), COUNT("customer_id") FROM "curated"."orders"`,
+		NullRate{Column: "customer_id"}.SQL(table))
+	assert.Equal(t,
+		`SELECT COUNT(*) FROM (SELECT "order_id", "line" FROM "curated"."orders" GROUP BY "order_id", "line" HAVING COUNT(*) > 1)`,
+		Unique{Columns: []string{"order_id", "line"}}.SQL(table))
+	assert.Equal(t,
+		`SELECT COUNT(*) FROM "curated"."orders" AS t LEFT JOIN "curated"."customers" AS r ON t."customer_id" = r."id" WHERE t."customer_id" IS NOT NULL AND r."id" IS NULL`,
+		References{Column: "customer_id", RefDatabase: "curated", RefTable: "customers", RefColumn: "id"}.SQL(table))
+}
+
+func TestRun(t *testing.T) {
+	suite := Suite{
+		Database: "curated",
+		Table:    "orders",
+		Expectations: []Expectation{
+			RowCount{Min: 1, Max: 100},
+			NullRate{Column: "customer_id", Max: 0.05},
+			Unique{Columns: []string{"order_id"}},
+			References{Column: "customer_id", RefTable: "customers", RefColumn: "id"},
+		},
+	}
+	table := `"curated"."orders"`
+
+	results, err := Run(suite, fakeQuery(map[string][]string{
+		suite.Expectations[0].SQL(table): {"40"},
+		suite.Expectations[1].SQL(table): {"40", "36"},
+		suite.Expectations[2].SQL(table): {"0"},
+		suite.Expectations[3].SQL(table): {"2"},
+	}))
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	assert.True(t, results[0].Passed)
+	assert.Equal(t, "40", results[0].Observed)
+
+	// 4 of 40 rows are null, above the 5% threshold
+	assert.False(t, results[1].Passed)
+	assert.Equal(t, "10.00% (4 of 40)", results[1].Observed)
+
+	assert.True(t, results[2].Passed)
+
+	assert.False(t, results[3].Passed)
+	assert.Equal(t, "2 orphaned rows", results[3].Observed)
+	assert.Equal(t, "[FAIL] orders: customer_id references customers.id (observed 2 orphaned rows)", results[3].String())
+}
+
+func TestEvaluate(t *testing.T) {
+	passed, _, err := RowCount{Min: 1}.Evaluate([]string{"1000000"})
+	require.NoError(t, err)
+	assert.True(t, passed, "Max 0 is unbounded")
+
+	passed, _, err = RowCount{Min: 1}.Evaluate([]string{"0"})
+	require.NoError(t, err)
+	assert.False(t, passed)
+
+	passed, observed, err := NullRate{Column: "c"}.Evaluate([]string{"0", "0"})
+	require.NoError(t, err)
+	assert.True(t, passed, "An empty table has no nulls")
+	assert.Equal(t, "0.00% (0 of 0)", observed)
+
+	passed, _, err = Unique{Columns: []string{"id"}}.Evaluate([]string{"3"})
+	require.NoError(t, err)
+	assert.False(t, passed)
+
+	_, _, err = RowCount{}.Evaluate([]string{"many"})
+	assert.Error(t, err)
+
+	_, _, err = NullRate{Column: "c"}.Evaluate([]string{"1"})
+	assert.Error(t, err)
+}
+
+func TestRunErrors(t *testing.T) {
+	suite := Suite{Table: "orders", Expectations: []Expectation{RowCount{Min: 1}}}
+
+	_, err := Run(suite, func(string) ([][]string, error) { return nil, errors.New("query failed") })
+	assert.ErrorContains(t, err, "query failed")
+
+	_, err = Run(suite, func(string) ([][]string, error) { return [][]string{{"1"}, {"2"}}, nil })
+	assert.ErrorContains(t, err, "returned 2 rows")
+}
@@ -0,0 +1,125 @@
+package deadline
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/report"
+)
+
+// GracePeriod is how long an interrupted command has to exit before it is killed
+const GracePeriod = 2 * time.Minute
+
+// snapshotTimeout bounds each command a snapshot runs
+const snapshotTimeout = 2 * time.Minute
+
+// Command runs name with args in dir and env, streaming its output to the
+// test log and returning it; when ctx ends the process is interrupted, as
+// Ctrl-C would, and killed if it has not exited after GracePeriod
+func Command(ctx context.Context, t testing.TB, dir string, env map[string]string, name string, args ...string) (string, error) {
+	return Stream(ctx, dir, env, func(line string) { t.Log(line) }, name, args...)
+}
+
+// Stream runs name like Command, but passes each line of its combined output
+// to handle as it is written instead of logging it
+func Stream(ctx context.Context, dir string, env map[string]string, handle func(line string), name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = GracePeriod
+
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	var output strings.Builder
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			handle(scanner.Text())
+			output.WriteString(scanner.Text() + "\n")
+		}
+		_, _ = io.Copy(io.Discard, reader)
+	}()
+
+	err := cmd.Run()
+	writer.Close()
+	wg.Wait()
+
+	command := strings.Join(append([]string{name}, args...), " ")
+	if ctx.Err() != nil {
+		return output.String(), fmt.Errorf("%s in %s interrupted: %w", command, dir, ctx.Err())
+	}
+	if err != nil {
+		return output.String(), fmt.Errorf("%s in %s: %w", command, dir, err)
+	}
+	return output.String(), nil
+}
+
+// Snapshot saves the state resource list and outputs of the unit in dir,
+// read with binary (terraform or terragrunt), for debugging a phase that ran
+// out of time; they are written under report.DirEnvVar when it is set and
+// logged otherwise
+func Snapshot(t testing.TB, dir string, env map[string]string, binary string) {
+	logger := logging.New(t, "dir", dir)
+
+	for _, capture := range []struct {
+		file string
+		args []string
+	}{
+		{"state.txt", []string{"state", "list"}},
+		{"outputs.json", []string{"output", "-json"}},
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), snapshotTimeout)
+		output, err := Command(ctx, t, dir, env, binary, capture.args...)
+		cancel()
+		if err != nil {
+			logger.Warn("Failed to snapshot "+capture.file, "error", err)
+			continue
+		}
+
+		path, err := writeSnapshot(os.Getenv(report.DirEnvVar), t.Name(), filepath.Base(dir), capture.file, output)
+		switch {
+		case err != nil:
+			logger.Warn("Failed to write snapshot "+capture.file, "error", err)
+		case path != "":
+			logger.Info("Saved snapshot", "path", path)
+		}
+	}
+}
+
+// unsafe matches characters not kept in snapshot directory names
+var unsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// writeSnapshot writes content to <root>/snapshots/<test>/<unit>-<file>,
+// returning "" without writing when root is empty
+func writeSnapshot(root, test, unit, file, content string) (string, error) {
+	if root == "" {
+		return "", nil
+	}
+	dir := filepath.Join(root, "snapshots", unsafe.ReplaceAllString(test, "_"))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, unit+"-"+file)
+	return path, os.WriteFile(path, []byte(content), 0o644)
+}
@@ -0,0 +1,92 @@
+// =============================================================================
+// Phase Deadlines
+// Per-phase time limits that leave room for cleanup before go test's -timeout
+// =============================================================================
+
+// Package deadline keeps long runs from hitting go test's -timeout, which
+// panics without running deferred cleanup. A Watchdog ends every phase a
+// reserve ahead of the binary's deadline, phases get their own time limits,
+// and commands are interrupted rather than killed so Terraform can release
+// its state lock before cleanup starts.
+package deadline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+)
+
+// ErrTimeout is wrapped by the error of a phase that ran out of time or
+// could not start because the phases' time was used up
+var ErrTimeout = errors.New("phase deadline exceeded")
+
+// reportMargin is left after cleanup for go test to report results
+const reportMargin = 30 * time.Second
+
+// Watchdog bounds the phases of one test by the time left before the test
+// binary's deadline, less a reserve kept for cleanup
+type Watchdog struct {
+	t       *testing.T
+	ctx     context.Context
+	reserve time.Duration
+}
+
+// Start returns a watchdog for t whose phases end reserve before the -timeout
+// deadline; without a deadline phases are only bounded by their own limits
+func Start(t *testing.T, reserve time.Duration) *Watchdog {
+	ctx, cancel := context.WithCancel(context.Background())
+	if deadline, ok := t.Deadline(); ok {
+		ctx, cancel = context.WithDeadline(context.Background(), deadline.Add(-reserve))
+	}
+	t.Cleanup(cancel)
+
+	return &Watchdog{t: t, ctx: ctx, reserve: reserve}
+}
+
+// Expired reports whether the time for phases is used up, so only cleanup remains
+func (w *Watchdog) Expired() bool {
+	return w.ctx.Err() != nil
+}
+
+// Remaining returns the time left for phases, or -1 without a deadline
+func (w *Watchdog) Remaining() time.Duration {
+	deadline, ok := w.ctx.Deadline()
+	if !ok {
+		return -1
+	}
+	return time.Until(deadline)
+}
+
+// Phase runs fn with a context that ends after timeout or when the phases'
+// time runs out, whichever is first, and records its duration; an overrun
+// returns an error wrapping ErrTimeout
+func (w *Watchdog) Phase(t testing.TB, phase string, timeout time.Duration, fn func(ctx context.Context) error) error {
+	t.Helper()
+	if w.Expired() {
+		return fmt.Errorf("%s not started, no time left before cleanup: %w", phase, ErrTimeout)
+	}
+	defer logging.New(t).Phase(phase)()
+
+	ctx, cancel := context.WithTimeout(w.ctx, timeout)
+	defer cancel()
+
+	err := fn(ctx)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		logging.New(t).Warn("Phase ran out of time", "phase", phase, "timeout", timeout, "error", err)
+		return fmt.Errorf("%s did not finish within %s: %w", phase, timeout, ErrTimeout)
+	}
+	return err
+}
+
+// CleanupContext returns a context for cleanup that outlives the phases,
+// ending just before the test binary's deadline or after the reserve
+func (w *Watchdog) CleanupContext() (context.Context, context.CancelFunc) {
+	if deadline, ok := w.t.Deadline(); ok {
+		return context.WithDeadline(context.Background(), deadline.Add(-reportMargin))
+	}
+	return context.WithTimeout(context.Background(), w.reserve)
+}
@@ -0,0 +1,83 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhase(t *testing.T) {
+	watchdog := Start(t, time.Minute)
+
+	err := watchdog.Phase(t, "quick", time.Minute, func(ctx context.Context) error { return nil })
+	assert.NoError(t, err)
+
+	failure := errors.New("apply failed")
+	err = watchdog.Phase(t, "failing", time.Minute, func(ctx context.Context) error { return failure })
+	assert.ErrorIs(t, err, failure)
+	assert.NotErrorIs(t, err, ErrTimeout)
+
+	err = watchdog.Phase(t, "slow", 10*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.False(t, watchdog.Expired(), "one phase overrunning must not end the others")
+}
+
+func TestExpired(t *testing.T) {
+	if _, ok := t.Deadline(); !ok {
+		t.Skip("Test binary has no -timeout deadline")
+	}
+
+	// A reserve longer than any -timeout leaves no time for phases
+	watchdog := Start(t, 1000*time.Hour)
+	assert.True(t, watchdog.Expired())
+
+	err := watchdog.Phase(t, "deploy", time.Minute, func(ctx context.Context) error {
+		t.Fatal("Phase must not start once the watchdog has expired")
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrTimeout)
+
+	ctx, cancel := watchdog.CleanupContext()
+	defer cancel()
+	assert.NoError(t, ctx.Err(), "cleanup must still get time after the phases expire")
+}
+
+func TestCommand(t *testing.T) {
+	output, err := Command(context.Background(), t, t.TempDir(), map[string]string{"GREETING": "hello"}, "sh", "-c", "echo $GREETING")
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", output)
+
+	_, err = Command(context.Background(), t, t.TempDir(), nil, "sh", "-c", "exit 3")
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = Command(ctx, t, t.TempDir(), nil, "sleep", "30")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 10*time.Second, "sleep should exit on interrupt")
+}
+
+func TestWriteSnapshot(t *testing.T) {
+	path, err := writeSnapshot("", "TestDev/Phase1", "01-networking", "state.txt", "aws_vpc.main\n")
+	require.NoError(t, err)
+	assert.Empty(t, path)
+
+	root := t.TempDir()
+	path, err = writeSnapshot(root, "TestDev/Phase1 Deploy", "01-networking", "state.txt", "aws_vpc.main\n")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "snapshots", "TestDev_Phase1_Deploy", "01-networking-state.txt"), path)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "aws_vpc.main\n", string(content))
+}
@@ -0,0 +1,144 @@
+// =============================================================================
+// Base Infrastructure Fixtures
+// Attaches module tests to the long-lived test base stack or provisions their own
+// =============================================================================
+
+package fixture
+
+import (
+	"flag"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/matrix"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/testconfig"
+)
+
+// ephemeral ignores the test base stack, e.g. to test a networking or security
+// change that the long-lived stack does not have yet
+var ephemeral = flag.Bool("ephemeral", false, "provision networking and security per test instead of using the test base stack")
+
+// Network is the VPC dependent module tests attach to
+type Network struct {
+	VPCID             string
+	VPCCIDR           string
+	PrivateSubnetIDs  []string
+	DatabaseSubnetIDs []string
+}
+
+// Security is the encryption key and service role dependent module tests use
+type Security struct {
+	DataKMSKeyARN   string
+	GlueRoleARN     string
+	GlueRoleName    string
+	SecurityGroupID string
+}
+
+// Base is the networking and security infrastructure module tests build on
+type Base struct {
+	Network
+	Security
+
+	// Ephemeral reports whether the test provisioned the base itself
+	Ephemeral bool
+}
+
+// GetNetwork returns the test base stack's network in region, or applies the
+// networking module for this test when no base stack is configured or the
+// suite runs with -ephemeral; an applied network is destroyed on cleanup
+func GetNetwork(t *testing.T, region matrix.Region) *Network {
+	if base := discover(t, region); base != nil {
+		return &base.Network
+	}
+	return provisionNetwork(t, region)
+}
+
+// Get is GetNetwork for tests that also need the security module's key and roles
+func Get(t *testing.T, region matrix.Region) *Base {
+	if base := discover(t, region); base != nil {
+		return base
+	}
+
+	network := provisionNetwork(t, region)
+	return &Base{
+		Network:   *network,
+		Security:  *provisionSecurity(t, region, network),
+		Ephemeral: true,
+	}
+}
+
+// discover reads the test base stack from Parameter Store, or returns nil when
+// the test should provision its own
+func discover(t *testing.T, region matrix.Region) *Base {
+	config, err := testconfig.Load()
+	require.NoError(t, err)
+	if config.BaseParameterPath == "" || *ephemeral {
+		return nil
+	}
+
+	clients := awsclients.New(t, awsclients.WithRegion(region.Name))
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	base, err := Discover(ctx, clients.SSM(), config.BaseParameterPath)
+	require.NoError(t, err, "Failed to discover the test base stack in %s; deploy tests/fixtures/base there or run with -ephemeral", region.Name)
+
+	logging.New(t).Success("Using test base stack", "path", config.BaseParameterPath, "region", region.Name, "vpc", base.VPCID)
+	return base
+}
+
+// provisionNetwork applies the networking module with the cheapest settings
+// module tests can use
+func provisionNetwork(t *testing.T, region matrix.Region) *Network {
+	stageDir := testutil.StageDir(t, "base-networking")
+	t.Cleanup(func() { testutil.Teardown(t, stageDir) })
+
+	terraformOptions := testutil.Deploy(t, stageDir, func() *terraform.Options {
+		opts := region.Options(
+			testutil.WithVPCCIDR(region.VPCCIDR(2)), // Does not overlap the networking suite's VPCs
+			testutil.WithSingleNATGateway(),
+			testutil.WithFlowLogs(false, 7),
+			testutil.WithVar("interface_endpoints", []string{}),
+		)
+		return testutil.NewTerraformOptions(t, moduleDir(t, "networking"), testutil.NewNetworkingVars(opts...), opts...)
+	})
+
+	return &Network{
+		VPCID:             terraform.Output(t, terraformOptions, "vpc_id"),
+		VPCCIDR:           terraform.Output(t, terraformOptions, "vpc_cidr_block"),
+		PrivateSubnetIDs:  terraform.OutputList(t, terraformOptions, "private_subnet_ids"),
+		DatabaseSubnetIDs: terraform.OutputList(t, terraformOptions, "database_subnet_ids"),
+	}
+}
+
+// provisionSecurity applies the security module in network
+func provisionSecurity(t *testing.T, region matrix.Region, network *Network) *Security {
+	stageDir := testutil.StageDir(t, "base-security")
+	t.Cleanup(func() { testutil.Teardown(t, stageDir) })
+
+	terraformOptions := testutil.Deploy(t, stageDir, func() *terraform.Options {
+		opts := region.Options(testutil.WithVPCID(network.VPCID))
+		return testutil.NewTerraformOptions(t, moduleDir(t, "security"), testutil.NewSecurityVars(opts...), opts...)
+	})
+
+	return &Security{
+		DataKMSKeyARN:   terraform.Output(t, terraformOptions, "data_kms_key_arn"),
+		GlueRoleARN:     terraform.Output(t, terraformOptions, "glue_role_arn"),
+		GlueRoleName:    terraform.Output(t, terraformOptions, "glue_role_name"),
+		SecurityGroupID: terraform.Output(t, terraformOptions, "data_processing_security_group_id"),
+	}
+}
+
+// moduleDir returns the directory of a module under modules/, so the fixtures
+// work from any suite
+func moduleDir(t *testing.T, name string) string {
+	root, err := testconfig.FindRoot()
+	require.NoError(t, err)
+	require.NotEmpty(t, root, "The repository root must be found to provision %s", name)
+	return filepath.Join(root, "modules", name)
+}
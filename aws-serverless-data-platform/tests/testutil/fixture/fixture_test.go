@@ -0,0 +1,64 @@
+package fixture
+
+import (
+	"context"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSSM serves parameters one page at a time
+type fakeSSM struct {
+	pages [][]ssmtypes.Parameter
+}
+
+func (f *fakeSSM) GetParametersByPath(_ context.Context, input *ssm.GetParametersByPathInput, _ ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	page := 0
+	if input.NextToken != nil {
+		page = 1
+	}
+	output := &ssm.GetParametersByPathOutput{Parameters: f.pages[page]}
+	if page+1 < len(f.pages) {
+		output.NextToken = awssdk.String("next")
+	}
+	return output, nil
+}
+
+func parameter(name, value string) ssmtypes.Parameter {
+	return ssmtypes.Parameter{Name: awssdk.String("/terratest/base/" + name), Value: awssdk.String(value)}
+}
+
+func TestDiscover(t *testing.T) {
+	client := &fakeSSM{pages: [][]ssmtypes.Parameter{
+		{
+			parameter(ParamVPCID, "vpc-0abc"),
+			parameter(ParamVPCCIDR, "10.240.0.0/16"),
+			parameter(ParamPrivateSubnetIDs, "subnet-1,subnet-2,subnet-3"),
+			parameter(ParamDatabaseSubnetIDs, "subnet-7,subnet-8,subnet-9"),
+		},
+		{
+			parameter(ParamDataKMSKeyARN, "arn:aws:kms:us-east-1:356240508702:key/1234"),
+			parameter(ParamGlueRoleARN, "arn:aws:iam::356240508702:role/terratest-base-glue"),
+			parameter(ParamGlueRoleName, "terratest-base-glue"),
+			parameter(ParamSecurityGroupID, "sg-0abc"),
+		},
+	}}
+
+	base, err := Discover(context.Background(), client, "/terratest/base")
+	require.NoError(t, err)
+
+	assert.Equal(t, "vpc-0abc", base.VPCID)
+	assert.Equal(t, []string{"subnet-1", "subnet-2", "subnet-3"}, base.PrivateSubnetIDs)
+	assert.Equal(t, "terratest-base-glue", base.GlueRoleName, "Parameters on later pages are read")
+	assert.False(t, base.Ephemeral)
+}
+
+func TestParseBaseMissing(t *testing.T) {
+	_, err := parseBase("/terratest/base", map[string]string{ParamVPCID: "vpc-0abc", ParamGlueRoleARN: ""})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "data_kms_key_arn, data_processing_security_group_id, database_subnet_ids")
+}
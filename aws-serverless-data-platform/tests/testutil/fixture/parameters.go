@@ -0,0 +1,84 @@
+package fixture
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// Parameter names the base stack exports under its path; see tests/fixtures/base
+const (
+	ParamVPCID             = "vpc_id"
+	ParamVPCCIDR           = "vpc_cidr"
+	ParamPrivateSubnetIDs  = "private_subnet_ids"
+	ParamDatabaseSubnetIDs = "database_subnet_ids"
+	ParamDataKMSKeyARN     = "data_kms_key_arn"
+	ParamGlueRoleARN       = "glue_role_arn"
+	ParamGlueRoleName      = "glue_role_name"
+	ParamSecurityGroupID   = "data_processing_security_group_id"
+)
+
+// Discover reads the base stack exported under path
+func Discover(ctx context.Context, client ssm.GetParametersByPathAPIClient, path string) (*Base, error) {
+	values := map[string]string{}
+	paginator := ssm.NewGetParametersByPathPaginator(client, &ssm.GetParametersByPathInput{
+		Path:           awssdk.String(path),
+		WithDecryption: awssdk.Bool(true),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read parameters under %s: %w", path, err)
+		}
+		for _, parameter := range page.Parameters {
+			name := strings.TrimPrefix(awssdk.ToString(parameter.Name), path+"/")
+			values[name] = awssdk.ToString(parameter.Value)
+		}
+	}
+	return parseBase(path, values)
+}
+
+// parseBase builds the base from the exported values, keyed by parameter name
+// relative to path; every export is required
+func parseBase(path string, values map[string]string) (*Base, error) {
+	var missing []string
+	get := func(name string) string {
+		value := values[name]
+		if value == "" {
+			missing = append(missing, name)
+		}
+		return value
+	}
+	list := func(name string) []string {
+		value := get(name)
+		if value == "" {
+			return nil
+		}
+		return strings.Split(value, ",")
+	}
+
+	base := &Base{
+		Network: Network{
+			VPCID:             get(ParamVPCID),
+			VPCCIDR:           get(ParamVPCCIDR),
+			PrivateSubnetIDs:  list(ParamPrivateSubnetIDs),
+			DatabaseSubnetIDs: list(ParamDatabaseSubnetIDs),
+		},
+		Security: Security{
+			DataKMSKeyARN:   get(ParamDataKMSKeyARN),
+			GlueRoleARN:     get(ParamGlueRoleARN),
+			GlueRoleName:    get(ParamGlueRoleName),
+			SecurityGroupID: get(ParamSecurityGroupID),
+		},
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("test base stack under %s is missing %s", path, strings.Join(missing, ", "))
+	}
+	return base, nil
+}
@@ -0,0 +1,158 @@
+// =============================================================================
+// VPC Flow Log Records
+// Parses and validates flow log records delivered to CloudWatch Logs
+// =============================================================================
+
+package flowlogs
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logs"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/wait"
+)
+
+// DefaultFormat is the AWS version 2 record format
+const DefaultFormat = "${version} ${account-id} ${interface-id} ${srcaddr} ${dstaddr} ${srcport} ${dstport} " +
+	"${protocol} ${packets} ${bytes} ${start} ${end} ${action} ${log-status}"
+
+// fieldPattern matches one ${field} placeholder in a log format
+var fieldPattern = regexp.MustCompile(`^\$\{([a-z0-9-]+)\}$`)
+
+// Record is one flow log record keyed by field name; absent values are "-"
+type Record map[string]string
+
+// Fields returns the field names of a log format in order
+func Fields(format string) ([]string, error) {
+	var fields []string
+	for _, token := range strings.Fields(format) {
+		match := fieldPattern.FindStringSubmatch(token)
+		if match == nil {
+			return nil, fmt.Errorf("invalid flow log format token %q", token)
+		}
+		fields = append(fields, match[1])
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("flow log format has no fields")
+	}
+	return fields, nil
+}
+
+// Parse splits a record line into the given fields
+func Parse(fields []string, line string) (Record, error) {
+	values := strings.Fields(line)
+	if len(values) != len(fields) {
+		return nil, fmt.Errorf("record has %d values, format has %d fields: %q", len(values), len(fields), line)
+	}
+
+	record := Record{}
+	for i, field := range fields {
+		record[field] = values[i]
+	}
+	return record, nil
+}
+
+// numericFields must hold integers whenever they are present
+var numericFields = []string{"version", "srcport", "dstport", "protocol", "packets", "bytes", "start", "end"}
+
+// allowedValues restricts enumerated fields to the values AWS documents
+var allowedValues = map[string][]string{
+	"action":     {"ACCEPT", "REJECT"},
+	"log-status": {"OK", "NODATA", "SKIPDATA"},
+}
+
+// Validate returns the ways a record's values do not match their field types
+func Validate(record Record, accountID string) []string {
+	var problems []string
+
+	for _, field := range numericFields {
+		value, ok := record[field]
+		if !ok || value == "-" {
+			continue
+		}
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			problems = append(problems, fmt.Sprintf("%s is %q, want an integer", field, value))
+		}
+	}
+	for field, allowed := range allowedValues {
+		value, ok := record[field]
+		if !ok || value == "-" {
+			continue
+		}
+		if !contains(allowed, value) {
+			problems = append(problems, fmt.Sprintf("%s is %q, want one of %s", field, value, strings.Join(allowed, ", ")))
+		}
+	}
+	if value, ok := record["interface-id"]; ok && value != "-" && !strings.HasPrefix(value, "eni-") {
+		problems = append(problems, fmt.Sprintf("interface-id is %q, want an ENI ID", value))
+	}
+	if value, ok := record["account-id"]; ok && accountID != "" && value != accountID {
+		problems = append(problems, fmt.Sprintf("account-id is %q, want %s", value, accountID))
+	}
+	start, startErr := strconv.ParseInt(record["start"], 10, 64)
+	end, endErr := strconv.ParseInt(record["end"], 10, 64)
+	if startErr == nil && endErr == nil && start > end {
+		problems = append(problems, fmt.Sprintf("start %d is after end %d", start, end))
+	}
+	return problems
+}
+
+// Fetch returns the raw records delivered to logGroup since the given time
+func Fetch(ctx context.Context, client *cloudwatchlogs.Client, logGroup string, since time.Time) ([]string, error) {
+	return logs.Messages(ctx, client, logGroup, "", since)
+}
+
+// WaitForRecords polls logGroup until records with log-status OK arrive and returns
+// every record parsed with format; delivery lags the aggregation interval by minutes
+func WaitForRecords(t *testing.T, clients *awsclients.Clients, logGroup, format string, since time.Time, timeout time.Duration) []Record {
+	fields, err := Fields(format)
+	require.NoError(t, err)
+
+	var records []Record
+
+	wait.Until(t, "flow log records in "+logGroup, func(ctx context.Context) (bool, error) {
+		lines, err := Fetch(ctx, clients.Logs(), logGroup, since)
+		if err != nil {
+			return false, err
+		}
+
+		records = records[:0]
+		delivered := 0
+		for _, line := range lines {
+			record, err := Parse(fields, line)
+			if err != nil {
+				return false, wait.Permanent(err)
+			}
+			records = append(records, record)
+			if record["log-status"] == "OK" {
+				delivered++
+			}
+		}
+
+		if delivered == 0 {
+			return false, fmt.Errorf("no flow log records with data yet (%d total)", len(records))
+		}
+		return true, nil
+	}, wait.DefaultOptions().WithTimeout(timeout).WithInterval(30*time.Second))
+
+	return records
+}
+
+// contains reports whether value is in values
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package flowlogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const accountID = "123456789012"
+
+func TestFields(t *testing.T) {
+	fields, err := Fields(DefaultFormat)
+	require.NoError(t, err)
+	assert.Len(t, fields, 14)
+	assert.Equal(t, "version", fields[0])
+	assert.Equal(t, "log-status", fields[13])
+
+	fields, err = Fields("${vpc-id} ${flow-direction}")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vpc-id", "flow-direction"}, fields)
+
+	_, err = Fields("${version} account-id")
+	assert.Error(t, err)
+
+	_, err = Fields("  ")
+	assert.Error(t, err)
+}
+
+func TestParseAndValidate(t *testing.T) {
+	fields, err := Fields(DefaultFormat)
+	require.NoError(t, err)
+
+	record, err := Parse(fields, "2 123456789012 eni-0a1b2c3d 10.0.1.15 52.94.0.10 49152 443 6 10 840 1700000000 1700000060 ACCEPT OK")
+	require.NoError(t, err)
+	assert.Equal(t, "443", record["dstport"])
+	assert.Equal(t, "ACCEPT", record["action"])
+	assert.Empty(t, Validate(record, accountID))
+
+	// NODATA records leave traffic fields empty
+	record, err = Parse(fields, "2 123456789012 eni-0a1b2c3d - - - - - - - 1700000000 1700000060 - NODATA")
+	require.NoError(t, err)
+	assert.Empty(t, Validate(record, accountID))
+
+	_, err = Parse(fields, "2 123456789012 eni-0a1b2c3d")
+	assert.Error(t, err)
+}
+
+func TestValidateProblems(t *testing.T) {
+	fields, err := Fields(DefaultFormat)
+	require.NoError(t, err)
+
+	record, err := Parse(fields, "2 210987654321 i-0a1b2c3d 10.0.1.15 52.94.0.10 http 443 6 10 840 1700000060 1700000000 DROP PARTIAL")
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		`srcport is "http", want an integer`,
+		`action is "DROP", want one of ACCEPT, REJECT`,
+		`log-status is "PARTIAL", want one of OK, NODATA, SKIPDATA`,
+		`interface-id is "i-0a1b2c3d", want an ENI ID`,
+		`account-id is "210987654321", want 123456789012`,
+		"start 1700000060 is after end 1700000000",
+	}, Validate(record, accountID))
+}
@@ -0,0 +1,157 @@
+// =============================================================================
+// IAM Access Analyzer Checks
+// Managed policy validation and public access checks for policy documents
+// =============================================================================
+
+package iampolicy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/accessanalyzer"
+	aatypes "github.com/aws/aws-sdk-go-v2/service/accessanalyzer/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+)
+
+// Kind tells Access Analyzer what a policy document is attached to
+type Kind struct {
+	Name string
+
+	PolicyType aatypes.PolicyType
+
+	// ValidateResourceType enables resource-specific checks in ValidatePolicy; optional
+	ValidateResourceType aatypes.ValidatePolicyResourceType
+
+	// PublicAccessResourceType enables CheckNoPublicAccess; empty for identity policies
+	PublicAccessResourceType aatypes.AccessCheckResourceType
+}
+
+// Policy kinds emitted by the platform modules
+var (
+	IdentityPolicy = Kind{
+		Name:       "identity policy",
+		PolicyType: aatypes.PolicyTypeIdentityPolicy,
+	}
+	TrustPolicy = Kind{
+		Name:                     "trust policy",
+		PolicyType:               aatypes.PolicyTypeResourcePolicy,
+		ValidateResourceType:     aatypes.ValidatePolicyResourceTypeRoleTrust,
+		PublicAccessResourceType: aatypes.AccessCheckResourceTypeRoleTrust,
+	}
+	KMSKeyPolicy = Kind{
+		Name:                     "KMS key policy",
+		PolicyType:               aatypes.PolicyTypeResourcePolicy,
+		PublicAccessResourceType: aatypes.AccessCheckResourceTypeKmsKey,
+	}
+	S3BucketPolicy = Kind{
+		Name:                     "S3 bucket policy",
+		PolicyType:               aatypes.PolicyTypeResourcePolicy,
+		ValidateResourceType:     aatypes.ValidatePolicyResourceTypeS3Bucket,
+		PublicAccessResourceType: aatypes.AccessCheckResourceTypeS3Bucket,
+	}
+)
+
+// blockingFindingTypes are the ValidatePolicy finding types that fail a test
+var blockingFindingTypes = map[aatypes.ValidatePolicyFindingType]bool{
+	aatypes.ValidatePolicyFindingTypeError:           true,
+	aatypes.ValidatePolicyFindingTypeSecurityWarning: true,
+}
+
+// Analyzer is the part of the Access Analyzer client policy validation uses
+type Analyzer interface {
+	accessanalyzer.ValidatePolicyAPIClient
+	CheckNoPublicAccess(ctx context.Context, params *accessanalyzer.CheckNoPublicAccessInput, optFns ...func(*accessanalyzer.Options)) (*accessanalyzer.CheckNoPublicAccessOutput, error)
+}
+
+// Validation is what Access Analyzer reported on a policy document
+type Validation struct {
+	Findings []aatypes.ValidatePolicyFinding
+
+	// Public is the CheckNoPublicAccess result, nil for identity policies
+	Public *accessanalyzer.CheckNoPublicAccessOutput
+}
+
+// Validate runs ValidatePolicy on document, following every page, and for
+// resource policies CheckNoPublicAccess
+func Validate(ctx context.Context, client Analyzer, document string, kind Kind) (*Validation, error) {
+	decoded, err := Decode(document)
+	if err != nil {
+		return nil, err
+	}
+
+	validation := &Validation{}
+	paginator := accessanalyzer.NewValidatePolicyPaginator(client, &accessanalyzer.ValidatePolicyInput{
+		PolicyDocument:             aws.String(decoded),
+		PolicyType:                 kind.PolicyType,
+		ValidatePolicyResourceType: kind.ValidateResourceType,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("validate %s: %w", kind.Name, err)
+		}
+		validation.Findings = append(validation.Findings, page.Findings...)
+	}
+
+	if kind.PublicAccessResourceType != "" {
+		validation.Public, err = client.CheckNoPublicAccess(ctx, &accessanalyzer.CheckNoPublicAccessInput{
+			PolicyDocument: aws.String(decoded),
+			ResourceType:   kind.PublicAccessResourceType,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("check %s for public access: %w", kind.Name, err)
+		}
+	}
+	return validation, nil
+}
+
+// AssertAccessAnalyzerClean validates document with Access Analyzer, failing on
+// ERROR and SECURITY_WARNING findings and, for resource policies, on public access
+func AssertAccessAnalyzerClean(t *testing.T, client Analyzer, document string, kind Kind) bool {
+	t.Helper()
+
+	validation, err := Validate(context.Background(), client, document, kind)
+	require.NoError(t, err, "Failed to validate %s with Access Analyzer", kind.Name)
+
+	blocking := BlockingFindings(validation.Findings)
+	for _, finding := range validation.Findings {
+		if !blockingFindingTypes[finding.FindingType] {
+			logging.New(t).Info("Access Analyzer finding", "type", finding.FindingType, "check", kind.Name, "finding", FormatFinding(finding))
+		}
+	}
+
+	messages := make([]string, 0, len(blocking))
+	for _, finding := range blocking {
+		messages = append(messages, FormatFinding(finding))
+	}
+	passed := assert.Empty(t, blocking, "Access Analyzer findings on %s:\n%s", kind.Name, strings.Join(messages, "\n"))
+
+	if public := validation.Public; public != nil {
+		passed = assert.NotEqual(t, aatypes.CheckNoPublicAccessResultFail, public.Result,
+			"%s grants public access: %s", kind.Name, aws.ToString(public.Message)) && passed
+	}
+
+	return passed
+}
+
+// BlockingFindings returns the findings of severity ERROR or SECURITY_WARNING
+func BlockingFindings(findings []aatypes.ValidatePolicyFinding) []aatypes.ValidatePolicyFinding {
+	var blocking []aatypes.ValidatePolicyFinding
+	for _, finding := range findings {
+		if blockingFindingTypes[finding.FindingType] {
+			blocking = append(blocking, finding)
+		}
+	}
+	return blocking
+}
+
+// FormatFinding renders a ValidatePolicy finding for test output
+func FormatFinding(finding aatypes.ValidatePolicyFinding) string {
+	return fmt.Sprintf("[%s] %s: %s", finding.FindingType, aws.ToString(finding.IssueCode), aws.ToString(finding.FindingDetails))
+}
@@ -0,0 +1,31 @@
+// =============================================================================
+// IAM Policy Assertions
+// Test helpers that fail on lint findings
+// =============================================================================
+
+package iampolicy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// AssertCompliant parses document and checks it passes every rule of the default linter
+func AssertCompliant(t *testing.T, document string, opts Options) *Document {
+	t.Helper()
+
+	doc, err := Parse(document)
+	require.NoError(t, err)
+
+	findings := Lint(doc, opts)
+	messages := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		messages = append(messages, finding.String())
+	}
+	assert.Empty(t, findings, "Policy violates least-privilege rules:\n%s", strings.Join(messages, "\n"))
+
+	return doc
+}
@@ -0,0 +1,146 @@
+// =============================================================================
+// IAM Policy Document Model
+// Typed representation of identity, resource and trust policy documents
+// =============================================================================
+
+package iampolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Version is the only policy language version the rules accept
+const Version = "2012-10-17"
+
+// Document is an IAM policy document
+type Document struct {
+	Version   string     `json:"Version"`
+	ID        string     `json:"Id,omitempty"`
+	Statement Statements `json:"Statement"`
+}
+
+// Statement is a single policy statement
+type Statement struct {
+	Sid          string                           `json:"Sid,omitempty"`
+	Effect       string                           `json:"Effect"`
+	Principal    *Principal                       `json:"Principal,omitempty"`
+	NotPrincipal *Principal                       `json:"NotPrincipal,omitempty"`
+	Action       StringList                       `json:"Action,omitempty"`
+	NotAction    StringList                       `json:"NotAction,omitempty"`
+	Resource     StringList                       `json:"Resource,omitempty"`
+	NotResource  StringList                       `json:"NotResource,omitempty"`
+	Condition    map[string]map[string]StringList `json:"Condition,omitempty"`
+}
+
+// Statements accepts either a single statement object or an array of them
+type Statements []Statement
+
+// UnmarshalJSON decodes a statement object or array
+func (s *Statements) UnmarshalJSON(data []byte) error {
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		var statement Statement
+		if err := json.Unmarshal(data, &statement); err != nil {
+			return err
+		}
+		*s = Statements{statement}
+		return nil
+	}
+
+	var statements []Statement
+	if err := json.Unmarshal(data, &statements); err != nil {
+		return err
+	}
+	*s = statements
+	return nil
+}
+
+// StringList accepts either a single string or an array of strings
+type StringList []string
+
+// UnmarshalJSON decodes a string or string array
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = StringList{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("expected a string or array of strings: %w", err)
+	}
+	*l = list
+	return nil
+}
+
+// Contains reports whether value is in the list
+func (l StringList) Contains(value string) bool {
+	for _, item := range l {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Principal is the principal of a resource or trust policy statement
+type Principal struct {
+	// All is set for the anonymous principal "*"
+	All           bool
+	AWS           StringList `json:"AWS,omitempty"`
+	Service       StringList `json:"Service,omitempty"`
+	Federated     StringList `json:"Federated,omitempty"`
+	CanonicalUser StringList `json:"CanonicalUser,omitempty"`
+}
+
+// UnmarshalJSON decodes "*" or a principal map
+func (p *Principal) UnmarshalJSON(data []byte) error {
+	var wildcard string
+	if err := json.Unmarshal(data, &wildcard); err == nil {
+		if wildcard != "*" {
+			return fmt.Errorf("unexpected principal %q", wildcard)
+		}
+		*p = Principal{All: true}
+		return nil
+	}
+
+	type principal Principal
+	var decoded principal
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*p = Principal(decoded)
+	p.All = p.AWS.Contains("*")
+	return nil
+}
+
+// Decode returns the JSON form of a document, undoing the URL encoding used by the IAM API
+func Decode(document string) (string, error) {
+	trimmed := strings.TrimSpace(document)
+	if !strings.HasPrefix(trimmed, "%") {
+		return trimmed, nil
+	}
+
+	decoded, err := url.QueryUnescape(trimmed)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode policy document: %w", err)
+	}
+	return decoded, nil
+}
+
+// Parse decodes a policy document, accepting the URL-encoded form returned by the IAM API
+func Parse(document string) (*Document, error) {
+	decoded, err := Decode(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc Document
+	if err := json.Unmarshal([]byte(decoded), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse policy document: %w", err)
+	}
+	return &doc, nil
+}
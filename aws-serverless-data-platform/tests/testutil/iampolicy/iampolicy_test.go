@@ -0,0 +1,212 @@
+package iampolicy
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/accessanalyzer"
+	aatypes "github.com/aws/aws-sdk-go-v2/service/accessanalyzer/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const accountID = "123456789012"
+
+// fakeAnalyzer returns its findings in pages of one and a fixed public access
+// result, recording the requests it receives
+type fakeAnalyzer struct {
+	findings []aatypes.ValidatePolicyFinding
+	public   aatypes.CheckNoPublicAccessResult
+	err      error
+
+	validated []*accessanalyzer.ValidatePolicyInput
+	checked   []*accessanalyzer.CheckNoPublicAccessInput
+}
+
+func (f *fakeAnalyzer) ValidatePolicy(_ context.Context, params *accessanalyzer.ValidatePolicyInput, _ ...func(*accessanalyzer.Options)) (*accessanalyzer.ValidatePolicyOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.validated = append(f.validated, params)
+
+	page := len(f.validated) - 1
+	output := &accessanalyzer.ValidatePolicyOutput{}
+	if page < len(f.findings) {
+		output.Findings = f.findings[page : page+1]
+	}
+	if page+1 < len(f.findings) {
+		output.NextToken = aws.String("next")
+	}
+	return output, nil
+}
+
+func (f *fakeAnalyzer) CheckNoPublicAccess(_ context.Context, params *accessanalyzer.CheckNoPublicAccessInput, _ ...func(*accessanalyzer.Options)) (*accessanalyzer.CheckNoPublicAccessOutput, error) {
+	f.checked = append(f.checked, params)
+	return &accessanalyzer.CheckNoPublicAccessOutput{Result: f.public, Message: aws.String("policy allows public access")}, nil
+}
+
+func ruleNames(findings []Finding) []string {
+	names := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		names = append(names, finding.Rule)
+	}
+	return names
+}
+
+func TestParse(t *testing.T) {
+	document := `{"Version":"2012-10-17","Statement":{"Effect":"Allow","Action":"s3:GetObject","Resource":["arn:aws:s3:::b/*"],
+		"Principal":{"AWS":"arn:aws:iam::123456789012:root"},"Condition":{"Bool":{"aws:SecureTransport":"true"}}}}`
+
+	doc, err := Parse(url.QueryEscape(document))
+	require.NoError(t, err)
+	require.Len(t, doc.Statement, 1)
+
+	statement := doc.Statement[0]
+	assert.Equal(t, StringList{"s3:GetObject"}, statement.Action)
+	assert.Equal(t, StringList{"arn:aws:s3:::b/*"}, statement.Resource)
+	assert.Equal(t, StringList{"arn:aws:iam::123456789012:root"}, statement.Principal.AWS)
+	assert.Equal(t, StringList{"true"}, statement.Condition["Bool"]["aws:SecureTransport"])
+
+	doc, err = Parse(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject"}]}`)
+	require.NoError(t, err)
+	assert.True(t, doc.Statement[0].Principal.All)
+}
+
+func TestBuiltinRules(t *testing.T) {
+	cases := map[string]struct {
+		document string
+		rules    []string
+	}{
+		"scoped identity policy": {
+			document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:GetObject","s3:PutObject"],"Resource":"arn:aws:s3:::b/*"}]}`,
+		},
+		"read-only on every resource": {
+			document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["glue:GetTable","s3:List*"],"Resource":"*"}]}`,
+		},
+		"wildcard action": {
+			document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"*","Resource":"arn:aws:s3:::b"}]}`,
+			rules:    []string{"no-wildcard-action"},
+		},
+		"write on every resource": {
+			document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["glue:GetTable","glue:CreateTable"],"Resource":"*"}]}`,
+			rules:    []string{"no-wildcard-resource-write"},
+		},
+		"resource policy scoped by attachment": {
+			document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"glue.amazonaws.com"},"Action":"kms:Decrypt","Resource":"*"}]}`,
+		},
+		"cross-account without condition": {
+			document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::999999999999:root"},"Action":"sts:AssumeRole"}]}`,
+			rules:    []string{"cross-account-condition"},
+		},
+		"cross-account with condition": {
+			document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"999999999999"},"Action":"sts:AssumeRole",
+				"Condition":{"StringEquals":{"sts:ExternalId":"abc"}}}]}`,
+		},
+		"anonymous principal": {
+			document: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject"}]}`,
+			rules:    []string{"cross-account-condition"},
+		},
+		"malformed statement": {
+			document: `{"Version":"2008-10-17","Statement":[{"Effect":"Permit","Resource":"arn:aws:s3:::b"}]}`,
+			rules:    []string{"policy-structure", "policy-structure"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			doc, err := Parse(tc.document)
+			require.NoError(t, err)
+
+			findings := Lint(doc, Options{AccountID: accountID})
+			assert.ElementsMatch(t, tc.rules, ruleNames(findings), "Findings: %v", findings)
+		})
+	}
+}
+
+func TestCustomRules(t *testing.T) {
+	requireSid := StatementRule("require-sid", "Statements must have a Sid",
+		func(statement Statement, _ Options) string {
+			if statement.Sid == "" {
+				return "missing Sid"
+			}
+			return ""
+		})
+
+	linter := NewLinter(BuiltinRules()...)
+	require.NoError(t, linter.Register(requireSid))
+	assert.Error(t, linter.Register(requireSid), "Duplicate rule names should be rejected")
+
+	doc, err := Parse(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::b/*"}]}`)
+	require.NoError(t, err)
+
+	findings := linter.Lint(doc, Options{})
+	require.Len(t, findings, 1)
+	assert.Equal(t, "[require-sid] statement 0: missing Sid", findings[0].String())
+
+	assert.Empty(t, linter.Lint(doc, Options{Skip: []string{"require-sid"}}))
+}
+
+func TestIsWriteAction(t *testing.T) {
+	assert.False(t, IsWriteAction("s3:GetObject"))
+	assert.False(t, IsWriteAction("s3:ListBucket"))
+	assert.False(t, IsWriteAction("glue:Get*"))
+	assert.True(t, IsWriteAction("s3:PutObject"))
+	assert.True(t, IsWriteAction("s3:*"))
+	assert.True(t, IsWriteAction("s3:G*"))
+	assert.True(t, IsWriteAction("*"))
+}
+
+func TestBlockingFindings(t *testing.T) {
+	findings := []aatypes.ValidatePolicyFinding{
+		{FindingType: aatypes.ValidatePolicyFindingTypeSuggestion, IssueCode: aws.String("EMPTY_ARRAY_ACTION")},
+		{FindingType: aatypes.ValidatePolicyFindingTypeSecurityWarning, IssueCode: aws.String("PASS_ROLE_WITH_STAR_IN_RESOURCE")},
+		{FindingType: aatypes.ValidatePolicyFindingTypeWarning, IssueCode: aws.String("MISSING_VERSION")},
+		{FindingType: aatypes.ValidatePolicyFindingTypeError, IssueCode: aws.String("INVALID_ACTION"), FindingDetails: aws.String("bad action")},
+	}
+
+	blocking := BlockingFindings(findings)
+	require.Len(t, blocking, 2)
+	assert.Equal(t, "PASS_ROLE_WITH_STAR_IN_RESOURCE", aws.ToString(blocking[0].IssueCode))
+	assert.Equal(t, "[ERROR] INVALID_ACTION: bad action", FormatFinding(blocking[1]))
+
+	assert.Empty(t, BlockingFindings(findings[:1]))
+}
+
+func TestValidate(t *testing.T) {
+	document := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`
+	client := &fakeAnalyzer{
+		findings: []aatypes.ValidatePolicyFinding{
+			{FindingType: aatypes.ValidatePolicyFindingTypeSuggestion, IssueCode: aws.String("EMPTY_ARRAY_ACTION")},
+			{FindingType: aatypes.ValidatePolicyFindingTypeSecurityWarning, IssueCode: aws.String("PASS_ROLE_WITH_STAR_IN_RESOURCE")},
+		},
+		public: aatypes.CheckNoPublicAccessResultPass,
+	}
+
+	validation, err := Validate(context.Background(), client, url.QueryEscape(document), S3BucketPolicy)
+	require.NoError(t, err)
+	assert.Len(t, validation.Findings, 2, "Every page of findings is read")
+	require.NotNil(t, validation.Public)
+	assert.Equal(t, aatypes.CheckNoPublicAccessResultPass, validation.Public.Result)
+
+	require.Len(t, client.validated, 2)
+	assert.Equal(t, document, aws.ToString(client.validated[0].PolicyDocument), "Documents from state are decoded first")
+	assert.Equal(t, aatypes.PolicyTypeResourcePolicy, client.validated[0].PolicyType)
+	assert.Equal(t, aatypes.ValidatePolicyResourceTypeS3Bucket, client.validated[0].ValidatePolicyResourceType)
+	require.Len(t, client.checked, 1)
+	assert.Equal(t, aatypes.AccessCheckResourceTypeS3Bucket, client.checked[0].ResourceType)
+}
+
+func TestValidateIdentityPolicy(t *testing.T) {
+	client := &fakeAnalyzer{}
+	validation, err := Validate(context.Background(), client, `{"Version":"2012-10-17","Statement":[]}`, IdentityPolicy)
+	require.NoError(t, err)
+	assert.Empty(t, validation.Findings)
+	assert.Nil(t, validation.Public, "Identity policies have no public access check")
+	assert.Empty(t, client.checked)
+
+	_, err = Validate(context.Background(), &fakeAnalyzer{err: errors.New("throttled")}, "{}", TrustPolicy)
+	assert.ErrorContains(t, err, "validate trust policy: throttled")
+}
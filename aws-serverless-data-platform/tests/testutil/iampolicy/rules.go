@@ -0,0 +1,293 @@
+// =============================================================================
+// IAM Policy Lint Rules
+// Built-in least-privilege rules and the rule registration API
+// =============================================================================
+
+package iampolicy
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Options carries the context rules need about the account under test
+type Options struct {
+	// AccountID is the account that owns the policy; principals in any other
+	// account are treated as cross-account
+	AccountID string
+
+	// Skip lists rule names that are not run
+	Skip []string
+}
+
+// Finding is a single rule violation
+type Finding struct {
+	Rule      string
+	Statement int
+	Sid       string
+	Message   string
+}
+
+// String formats the finding for test output
+func (f Finding) String() string {
+	location := fmt.Sprintf("statement %d", f.Statement)
+	if f.Sid != "" {
+		location = fmt.Sprintf("statement %d (%s)", f.Statement, f.Sid)
+	}
+	return fmt.Sprintf("[%s] %s: %s", f.Rule, location, f.Message)
+}
+
+// Rule is a named check over a policy document
+type Rule struct {
+	Name        string
+	Description string
+	Check       func(doc *Document, opts Options) []Finding
+}
+
+// StatementRule builds a Rule that checks each statement independently;
+// check returns an empty message when the statement passes
+func StatementRule(name, description string, check func(statement Statement, opts Options) string) Rule {
+	return Rule{
+		Name:        name,
+		Description: description,
+		Check: func(doc *Document, opts Options) []Finding {
+			var findings []Finding
+			for i, statement := range doc.Statement {
+				if message := check(statement, opts); message != "" {
+					findings = append(findings, Finding{
+						Rule:      name,
+						Statement: i,
+						Sid:       statement.Sid,
+						Message:   message,
+					})
+				}
+			}
+			return findings
+		},
+	}
+}
+
+// Linter runs a set of rules over policy documents
+type Linter struct {
+	mu    sync.RWMutex
+	rules map[string]Rule
+}
+
+// NewLinter returns a linter with the given rules
+func NewLinter(rules ...Rule) *Linter {
+	linter := &Linter{rules: make(map[string]Rule)}
+	for _, rule := range rules {
+		if err := linter.Register(rule); err != nil {
+			panic(err)
+		}
+	}
+	return linter
+}
+
+// Register adds a rule; rule names must be unique
+func (l *Linter) Register(rule Rule) error {
+	if rule.Name == "" || rule.Check == nil {
+		return fmt.Errorf("rule must have a name and a check")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, exists := l.rules[rule.Name]; exists {
+		return fmt.Errorf("rule %q is already registered", rule.Name)
+	}
+	l.rules[rule.Name] = rule
+	return nil
+}
+
+// Rules returns the registered rules sorted by name
+func (l *Linter) Rules() []Rule {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	rules := make([]Rule, 0, len(l.rules))
+	for _, rule := range l.rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
+
+// Lint runs every registered rule not skipped by opts
+func (l *Linter) Lint(doc *Document, opts Options) []Finding {
+	skip := make(map[string]bool, len(opts.Skip))
+	for _, name := range opts.Skip {
+		skip[name] = true
+	}
+
+	var findings []Finding
+	for _, rule := range l.Rules() {
+		if skip[rule.Name] {
+			continue
+		}
+		findings = append(findings, rule.Check(doc, opts)...)
+	}
+	return findings
+}
+
+// defaultLinter holds the built-in rules plus any registered with Register
+var defaultLinter = NewLinter(BuiltinRules()...)
+
+// Register adds an organisation-specific rule to the default linter,
+// typically from an init function in the test package
+func Register(rule Rule) error {
+	return defaultLinter.Register(rule)
+}
+
+// Lint runs the default linter's rules
+func Lint(doc *Document, opts Options) []Finding {
+	return defaultLinter.Lint(doc, opts)
+}
+
+// BuiltinRules returns the least-privilege rules every policy must pass
+func BuiltinRules() []Rule {
+	return []Rule{
+		structureRule,
+		wildcardActionRule,
+		wildcardResourceWriteRule,
+		crossAccountConditionRule,
+	}
+}
+
+// structureRule checks the document is well formed
+var structureRule = Rule{
+	Name:        "policy-structure",
+	Description: "Documents use version 2012-10-17 and every statement has an effect and actions",
+	Check: func(doc *Document, _ Options) []Finding {
+		var findings []Finding
+		if doc.Version != Version {
+			findings = append(findings, Finding{
+				Rule:      "policy-structure",
+				Statement: -1,
+				Message:   fmt.Sprintf("version is %q, want %q", doc.Version, Version),
+			})
+		}
+		if len(doc.Statement) == 0 {
+			findings = append(findings, Finding{Rule: "policy-structure", Statement: -1, Message: "document has no statements"})
+		}
+		for i, statement := range doc.Statement {
+			var problems []string
+			if statement.Effect != "Allow" && statement.Effect != "Deny" {
+				problems = append(problems, fmt.Sprintf("effect is %q, want Allow or Deny", statement.Effect))
+			}
+			if len(statement.Action) == 0 && len(statement.NotAction) == 0 {
+				problems = append(problems, "no Action or NotAction")
+			}
+			if len(problems) > 0 {
+				findings = append(findings, Finding{
+					Rule:      "policy-structure",
+					Statement: i,
+					Sid:       statement.Sid,
+					Message:   strings.Join(problems, "; "),
+				})
+			}
+		}
+		return findings
+	},
+}
+
+// wildcardActionRule forbids granting every action
+var wildcardActionRule = StatementRule("no-wildcard-action",
+	"Allow statements must not grant Action \"*\" or use NotAction",
+	func(statement Statement, _ Options) string {
+		if statement.Effect != "Allow" {
+			return ""
+		}
+		if statement.Action.Contains("*") {
+			return "grants Action \"*\""
+		}
+		if len(statement.NotAction) > 0 {
+			return "grants every action except NotAction"
+		}
+		return ""
+	})
+
+// wildcardResourceWriteRule forbids write actions on every resource in identity policies;
+// resource policies are exempt because "*" there means the resource the policy is attached to
+var wildcardResourceWriteRule = StatementRule("no-wildcard-resource-write",
+	"Identity policy statements granting write actions must scope Resource",
+	func(statement Statement, _ Options) string {
+		if statement.Effect != "Allow" || statement.Principal != nil {
+			return ""
+		}
+		if !statement.Resource.Contains("*") && len(statement.NotResource) == 0 {
+			return ""
+		}
+
+		var writes []string
+		for _, action := range statement.Action {
+			if IsWriteAction(action) {
+				writes = append(writes, action)
+			}
+		}
+		if len(writes) == 0 {
+			return ""
+		}
+		return fmt.Sprintf("grants write actions on every resource: %s", strings.Join(writes, ", "))
+	})
+
+// crossAccountConditionRule requires a condition whenever another account can use the policy
+var crossAccountConditionRule = StatementRule("cross-account-condition",
+	"Statements allowing anonymous or other-account principals must have a Condition",
+	func(statement Statement, opts Options) string {
+		if statement.Effect != "Allow" || statement.Principal == nil || len(statement.Condition) > 0 {
+			return ""
+		}
+		if statement.Principal.All {
+			return "allows any principal without a Condition"
+		}
+
+		for _, principal := range statement.Principal.AWS {
+			account := principalAccount(principal)
+			if account != "" && opts.AccountID != "" && account != opts.AccountID {
+				return fmt.Sprintf("allows account %s without a Condition", account)
+			}
+		}
+		return ""
+	})
+
+// readOnlyPrefixes are action verbs that never modify resources
+var readOnlyPrefixes = []string{
+	"Get", "List", "Describe", "BatchGet", "Search", "Query", "Scan", "Select", "Lookup", "Head", "View",
+}
+
+// IsWriteAction reports whether an action can modify resources; wildcard actions
+// count as writes unless every action they match is read-only
+func IsWriteAction(action string) bool {
+	if action == "*" {
+		return true
+	}
+
+	_, verb, ok := strings.Cut(action, ":")
+	if !ok || verb == "" || verb == "*" {
+		return true
+	}
+
+	for _, prefix := range readOnlyPrefixes {
+		// "Get*" and "GetObject" are read-only; "G*" might match writes
+		if strings.HasPrefix(verb, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// accountPattern matches a bare account ID or the account field of an ARN
+var accountPattern = regexp.MustCompile(`^(?:arn:[^:]+:[^:]*:[^:]*:)?(\d{12})(?::|$)`)
+
+// principalAccount extracts the account ID from an AWS principal
+func principalAccount(principal string) string {
+	match := accountPattern.FindStringSubmatch(principal)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
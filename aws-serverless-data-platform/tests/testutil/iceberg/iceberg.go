@@ -0,0 +1,227 @@
+// =============================================================================
+// Iceberg Table Metadata
+// Reads Iceberg table metadata through the Glue Data Catalog and checks snapshots
+// =============================================================================
+
+// Package iceberg checks Apache Iceberg tables registered in the Glue Data
+// Catalog: that the catalog entry points at Iceberg metadata, that the
+// metadata file and the manifest list of every snapshot are in S3, and that
+// the snapshots form one lineage, so the curated tables migrating to Iceberg
+// can be verified after each write, time-travel query or compaction.
+package iceberg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+)
+
+// Snapshot operations Iceberg records in a snapshot's summary
+const (
+	OperationAppend  = "append"
+	OperationReplace = "replace"
+)
+
+// Snapshot is one version of the table
+type Snapshot struct {
+	ID           int64             `json:"snapshot-id"`
+	ParentID     *int64            `json:"parent-snapshot-id,omitempty"`
+	TimestampMS  int64             `json:"timestamp-ms"`
+	ManifestList string            `json:"manifest-list"`
+	Summary      map[string]string `json:"summary"`
+}
+
+// Operation returns the kind of write that made the snapshot, e.g. "append"
+func (s Snapshot) Operation() string {
+	return s.Summary["operation"]
+}
+
+// Time returns when the snapshot was committed
+func (s Snapshot) Time() time.Time {
+	return time.UnixMilli(s.TimestampMS).UTC()
+}
+
+// TotalRecords returns the rows in the table as of the snapshot
+func (s Snapshot) TotalRecords() (int64, error) {
+	value, ok := s.Summary["total-records"]
+	if !ok {
+		return 0, fmt.Errorf("snapshot %d has no total-records in its summary", s.ID)
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// Metadata is the part of an Iceberg table metadata file the tests read
+type Metadata struct {
+	FormatVersion     int        `json:"format-version"`
+	TableUUID         string     `json:"table-uuid"`
+	Location          string     `json:"location"`
+	CurrentSnapshotID *int64     `json:"current-snapshot-id,omitempty"`
+	Snapshots         []Snapshot `json:"snapshots"`
+}
+
+// Parse reads a table metadata file
+func Parse(data []byte) (*Metadata, error) {
+	var metadata Metadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse Iceberg metadata: %w", err)
+	}
+	if metadata.FormatVersion == 0 || metadata.Location == "" {
+		return nil, errors.New("not Iceberg table metadata: no format-version or location")
+	}
+	// Iceberg writes -1 for a table without snapshots
+	if metadata.CurrentSnapshotID != nil && *metadata.CurrentSnapshotID == -1 {
+		metadata.CurrentSnapshotID = nil
+	}
+	return &metadata, nil
+}
+
+// Snapshot returns the snapshot with id
+func (m *Metadata) Snapshot(id int64) (Snapshot, bool) {
+	for _, snapshot := range m.Snapshots {
+		if snapshot.ID == id {
+			return snapshot, true
+		}
+	}
+	return Snapshot{}, false
+}
+
+// Lineage returns the current snapshot and its ancestors, oldest first; it
+// stops at a parent that has been expired
+func (m *Metadata) Lineage() []Snapshot {
+	var lineage []Snapshot
+	for id := m.CurrentSnapshotID; id != nil; {
+		snapshot, ok := m.Snapshot(*id)
+		if !ok {
+			break
+		}
+		lineage = append([]Snapshot{snapshot}, lineage...)
+		id = snapshot.ParentID
+	}
+	return lineage
+}
+
+// Check returns what is wrong with the snapshots in metadata: a current
+// snapshot that does not exist, or a lineage going back in time
+func Check(metadata *Metadata) []string {
+	var problems []string
+	if id := metadata.CurrentSnapshotID; id != nil {
+		if _, ok := metadata.Snapshot(*id); !ok {
+			problems = append(problems, fmt.Sprintf("current snapshot %d is not in the metadata", *id))
+		}
+	}
+
+	lineage := metadata.Lineage()
+	for i := 1; i < len(lineage); i++ {
+		if lineage[i].TimestampMS < lineage[i-1].TimestampMS {
+			problems = append(problems, fmt.Sprintf("snapshot %d was committed at %s, before its parent %d at %s",
+				lineage[i].ID, lineage[i].Time().Format(time.RFC3339), lineage[i-1].ID, lineage[i-1].Time().Format(time.RFC3339)))
+		}
+	}
+	for _, snapshot := range metadata.Snapshots {
+		if snapshot.ManifestList == "" {
+			problems = append(problems, fmt.Sprintf("snapshot %d has no manifest list", snapshot.ID))
+		}
+	}
+	return problems
+}
+
+// MetadataLocation returns the s3:// URI of the metadata file a Glue table
+// points at, failing for a table that is not an Iceberg table
+func MetadataLocation(table *gluetypes.Table) (string, error) {
+	name := aws.ToString(table.Name)
+	if tableType := table.Parameters["table_type"]; !strings.EqualFold(tableType, "ICEBERG") {
+		return "", fmt.Errorf("table %s has table_type %q, not ICEBERG", name, tableType)
+	}
+	location := table.Parameters["metadata_location"]
+	if location == "" {
+		return "", fmt.Errorf("table %s has no metadata_location", name)
+	}
+	return location, nil
+}
+
+// Load reads the metadata file the Glue table points at
+func Load(ctx context.Context, clients *awsclients.Clients, table *gluetypes.Table) (*Metadata, error) {
+	location, err := MetadataLocation(table)
+	if err != nil {
+		return nil, err
+	}
+	bucket, key, err := parseS3URI(location)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := clients.S3().GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", location, err)
+	}
+	defer output.Body.Close()
+
+	data, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", location, err)
+	}
+	metadata, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", location, err)
+	}
+	return metadata, nil
+}
+
+// AssertTable checks the Iceberg table database.name: its catalog entry,
+// its metadata file, and the snapshots and their manifest lists in S3
+func AssertTable(t *testing.T, clients *awsclients.Clients, database, name string) *Metadata {
+	t.Helper()
+
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	output, err := clients.Glue().GetTable(ctx, &glue.GetTableInput{DatabaseName: aws.String(database), Name: aws.String(name)})
+	require.NoError(t, err, "Failed to get table %s.%s", database, name)
+
+	metadata, err := Load(ctx, clients, output.Table)
+	require.NoError(t, err)
+
+	if descriptor := output.Table.StorageDescriptor; descriptor != nil && descriptor.Location != nil {
+		if strings.TrimSuffix(aws.ToString(descriptor.Location), "/") != strings.TrimSuffix(metadata.Location, "/") {
+			t.Errorf("Table %s.%s is catalogued at %s but its metadata is for %s", database, name, aws.ToString(descriptor.Location), metadata.Location)
+		}
+	}
+	for _, problem := range Check(metadata) {
+		t.Errorf("Table %s.%s: %s", database, name, problem)
+	}
+	for _, snapshot := range metadata.Lineage() {
+		bucket, key, err := parseS3URI(snapshot.ManifestList)
+		if err != nil {
+			t.Errorf("Table %s.%s: snapshot %d: %v", database, name, snapshot.ID, err)
+			continue
+		}
+		_, err = clients.S3().HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+		if err != nil {
+			t.Errorf("Table %s.%s: manifest list of snapshot %d is missing: %v", database, name, snapshot.ID, err)
+		}
+	}
+	return metadata
+}
+
+// parseS3URI splits s3://bucket/key into its bucket and key
+func parseS3URI(uri string) (bucket, key string, err error) {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "s3" || parsed.Host == "" {
+		return "", "", fmt.Errorf("not an s3:// URI: %q", uri)
+	}
+	return parsed.Host, strings.TrimPrefix(parsed.Path, "/"), nil
+}
@@ -0,0 +1,83 @@
+package iceberg
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// appended is the metadata of a table after two INSERT INTOs and a compaction,
+// trimmed to the fields the package reads
+const appended = `{
+  "format-version": 2,
+  "table-uuid": "5f0c2a4e-9d7a-4b8e-8a43-1f2d0c6b7e11",
+  "location": "s3://dl-curated-dev/iceberg/events",
+  "current-snapshot-id": 3,
+  "snapshots": [
+    {"snapshot-id": 1, "timestamp-ms": 1714644000000, "manifest-list": "s3://dl-curated-dev/iceberg/events/metadata/snap-1.avro",
+     "summary": {"operation": "append", "added-records": "2", "total-records": "2"}},
+    {"snapshot-id": 2, "parent-snapshot-id": 1, "timestamp-ms": 1714644060000, "manifest-list": "s3://dl-curated-dev/iceberg/events/metadata/snap-2.avro",
+     "summary": {"operation": "append", "added-records": "1", "total-records": "3"}},
+    {"snapshot-id": 3, "parent-snapshot-id": 2, "timestamp-ms": 1714644120000, "manifest-list": "s3://dl-curated-dev/iceberg/events/metadata/snap-3.avro",
+     "summary": {"operation": "replace", "total-records": "3"}}
+  ]
+}`
+
+func TestParse(t *testing.T) {
+	metadata, err := Parse([]byte(appended))
+	require.NoError(t, err)
+	assert.Equal(t, 2, metadata.FormatVersion)
+	assert.Equal(t, "s3://dl-curated-dev/iceberg/events", metadata.Location)
+	assert.Empty(t, Check(metadata))
+
+	lineage := metadata.Lineage()
+	require.Len(t, lineage, 3)
+	assert.Equal(t, []string{OperationAppend, OperationAppend, OperationReplace},
+		[]string{lineage[0].Operation(), lineage[1].Operation(), lineage[2].Operation()})
+	assert.Equal(t, "2024-05-02T10:01:00Z", lineage[1].Time().Format("2006-01-02T15:04:05Z07:00"))
+
+	total, err := lineage[0].TotalRecords()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	empty, err := Parse([]byte(`{"format-version": 2, "location": "s3://b/t", "current-snapshot-id": -1, "snapshots": []}`))
+	require.NoError(t, err)
+	assert.Nil(t, empty.CurrentSnapshotID, "-1 means the table has no snapshot yet")
+	assert.Empty(t, empty.Lineage())
+
+	_, err = Parse([]byte(`{"Records": []}`))
+	assert.ErrorContains(t, err, "not Iceberg table metadata")
+}
+
+func TestCheck(t *testing.T) {
+	metadata, err := Parse([]byte(appended))
+	require.NoError(t, err)
+	metadata.Snapshots[1].TimestampMS = metadata.Snapshots[2].TimestampMS + 1
+	metadata.Snapshots[0].ManifestList = ""
+	assert.Equal(t, []string{
+		"snapshot 3 was committed at 2024-05-02T10:02:00Z, before its parent 2 at 2024-05-02T10:02:00Z",
+		"snapshot 1 has no manifest list",
+	}, Check(metadata))
+
+	missing := int64(9)
+	metadata.CurrentSnapshotID = &missing
+	assert.Contains(t, Check(metadata), "current snapshot 9 is not in the metadata")
+}
+
+func TestMetadataLocation(t *testing.T) {
+	location, err := MetadataLocation(&gluetypes.Table{
+		Name:       aws.String("events"),
+		Parameters: map[string]string{"table_type": "ICEBERG", "metadata_location": "s3://b/t/metadata/00002.metadata.json"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "s3://b/t/metadata/00002.metadata.json", location)
+
+	_, err = MetadataLocation(&gluetypes.Table{Name: aws.String("events"), Parameters: map[string]string{"classification": "csv"}})
+	assert.EqualError(t, err, `table events has table_type "", not ICEBERG`)
+
+	_, err = MetadataLocation(&gluetypes.Table{Name: aws.String("events"), Parameters: map[string]string{"table_type": "iceberg"}})
+	assert.EqualError(t, err, "table events has no metadata_location")
+}
@@ -0,0 +1,121 @@
+// =============================================================================
+// Test Credential Hygiene
+// Refuses to run the suites as the wrong principal or in the wrong account
+// =============================================================================
+
+// Package identity checks who the suites are about to run as before any test
+// applies or destroys anything: the caller must be in the configured test
+// account, not a protected one, and must be a role under the runner path
+// using temporary credentials rather than IAM user or root keys.
+package identity
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/testconfig"
+)
+
+// longLivedKeyPrefix starts the access key IDs of IAM user and root keys;
+// temporary credentials start with ASIA
+const longLivedKeyPrefix = "AKIA"
+
+// Caller is the principal the suites run as
+type Caller struct {
+	Account     string
+	ARN         string
+	AccessKeyID string
+	// RoleName and RolePath are set when the caller is an assumed role
+	RoleName string
+	RolePath string
+}
+
+// Resolve identifies the principal cfg's credentials belong to, looking up the
+// path of its role when it is an assumed role
+func Resolve(ctx context.Context, cfg aws.Config) (Caller, error) {
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return Caller{}, fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	output, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return Caller{}, fmt.Errorf("failed to identify the caller: %w", err)
+	}
+
+	caller := Caller{
+		Account:     aws.ToString(output.Account),
+		ARN:         aws.ToString(output.Arn),
+		AccessKeyID: creds.AccessKeyID,
+		RoleName:    roleName(aws.ToString(output.Arn)),
+	}
+	if caller.RoleName != "" {
+		role, err := iam.NewFromConfig(cfg).GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(caller.RoleName)})
+		if err != nil {
+			return Caller{}, fmt.Errorf("failed to read the path of role %s; the test role needs iam:GetRole on itself: %w", caller.RoleName, err)
+		}
+		caller.RolePath = aws.ToString(role.Role.Path)
+	}
+	return caller, nil
+}
+
+// roleName returns the role of an STS assumed-role session ARN, or "" for
+// other principals
+func roleName(sessionARN string) string {
+	parsed, err := arn.Parse(sessionARN)
+	if err != nil || parsed.Service != "sts" {
+		return ""
+	}
+	parts := strings.Split(parsed.Resource, "/")
+	if len(parts) < 3 || parts[0] != "assumed-role" {
+		return ""
+	}
+	return parts[1]
+}
+
+// Check lists why the suites must not run as caller under config
+func Check(caller Caller, config testconfig.Config) []string {
+	var problems []string
+
+	for _, protected := range config.ProtectedAccountIDs {
+		if caller.Account == protected {
+			problems = append(problems, fmt.Sprintf("credentials are for protected account %s; switch to the test account %s",
+				caller.Account, config.AccountID))
+		}
+	}
+	if caller.Account != config.AccountID {
+		problems = append(problems, fmt.Sprintf("credentials are for account %s but the suites are configured for %s; "+
+			"set %s or account_id in config/testing.local.yaml", caller.Account, config.AccountID, testconfig.AccountIDEnvVar))
+	}
+
+	switch {
+	case strings.HasSuffix(caller.ARN, ":root"):
+		problems = append(problems, "credentials are the account root user's; assume a test runner role instead")
+	case strings.HasPrefix(caller.AccessKeyID, longLivedKeyPrefix):
+		problems = append(problems, fmt.Sprintf("%s uses long-lived access key %s; assume a test runner role instead",
+			caller.ARN, caller.AccessKeyID))
+	case caller.RoleName == "":
+		problems = append(problems, fmt.Sprintf("%s is not an assumed role; assume a test runner role instead", caller.ARN))
+	case config.RunnerRolePath != "" && !strings.HasPrefix(caller.RolePath, config.RunnerRolePath):
+		problems = append(problems, fmt.Sprintf("role %s has path %s, not under %s; assume a test runner role",
+			caller.RoleName, caller.RolePath, config.RunnerRolePath))
+	}
+	return problems
+}
+
+// Verify resolves the caller from cfg and returns an error listing every
+// reason the suites must not run as it
+func Verify(ctx context.Context, cfg aws.Config, config testconfig.Config) error {
+	caller, err := Resolve(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if problems := Check(caller, config); len(problems) > 0 {
+		return fmt.Errorf("refusing to run as %s:\n  %s", caller.ARN, strings.Join(problems, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package identity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/testconfig"
+)
+
+func TestRoleName(t *testing.T) {
+	assert.Equal(t, "TerratestRunner", roleName("arn:aws:sts::111111111111:assumed-role/TerratestRunner/terratest"))
+	assert.Empty(t, roleName("arn:aws:iam::111111111111:user/alice"))
+	assert.Empty(t, roleName("arn:aws:iam::111111111111:root"))
+	assert.Empty(t, roleName("not an arn"))
+}
+
+func TestCheck(t *testing.T) {
+	config := testconfig.Defaults()
+	config.AccountID = "111111111111"
+	config.ProtectedAccountIDs = []string{"999999999999"}
+	config.RunnerRolePath = "/TestRunner/"
+
+	runner := Caller{
+		Account:     "111111111111",
+		ARN:         "arn:aws:sts::111111111111:assumed-role/terratest/session",
+		AccessKeyID: "ASIAEXAMPLE",
+		RoleName:    "terratest",
+		RolePath:    "/TestRunner/",
+	}
+	assert.Empty(t, Check(runner, config))
+
+	nested := runner
+	nested.RolePath = "/TestRunner/ci/"
+	assert.Empty(t, Check(nested, config), "roles below the runner path qualify")
+
+	for name, tc := range map[string]struct {
+		caller Caller
+		want   []string
+	}{
+		"protected account": {
+			caller: Caller{Account: "999999999999", ARN: runner.ARN, AccessKeyID: "ASIAEXAMPLE", RoleName: "terratest", RolePath: "/TestRunner/"},
+			want: []string{
+				"credentials are for protected account 999999999999; switch to the test account 111111111111",
+				"credentials are for account 999999999999 but the suites are configured for 111111111111; set TERRATEST_ACCOUNT_ID or account_id in config/testing.local.yaml",
+			},
+		},
+		"root": {
+			caller: Caller{Account: "111111111111", ARN: "arn:aws:iam::111111111111:root", AccessKeyID: "AKIAEXAMPLE"},
+			want:   []string{"credentials are the account root user's; assume a test runner role instead"},
+		},
+		"user keys": {
+			caller: Caller{Account: "111111111111", ARN: "arn:aws:iam::111111111111:user/alice", AccessKeyID: "AKIAEXAMPLE"},
+			want:   []string{"arn:aws:iam::111111111111:user/alice uses long-lived access key AKIAEXAMPLE; assume a test runner role instead"},
+		},
+		"federated user": {
+			caller: Caller{Account: "111111111111", ARN: "arn:aws:sts::111111111111:federated-user/alice", AccessKeyID: "ASIAEXAMPLE"},
+			want:   []string{"arn:aws:sts::111111111111:federated-user/alice is not an assumed role; assume a test runner role instead"},
+		},
+		"role path": {
+			caller: Caller{Account: "111111111111", ARN: runner.ARN, AccessKeyID: "ASIAEXAMPLE", RoleName: "Admin", RolePath: "/"},
+			want:   []string{"role Admin has path /, not under /TestRunner/; assume a test runner role"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Check(tc.caller, config))
+		})
+	}
+}
@@ -0,0 +1,167 @@
+// =============================================================================
+// Object Integrity
+// SHA-256 checksummed uploads and verification of data-lake objects
+// =============================================================================
+
+// Package integrity uploads objects with a SHA-256 checksum S3 validates on
+// put, and verifies that stored objects carry a checksum matching their content.
+package integrity
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+)
+
+// rejectedCodes are the errors S3 returns when an upload does not match its checksum
+var rejectedCodes = map[string]bool{
+	"BadDigest":      true,
+	"InvalidRequest": true,
+}
+
+// Checksum returns the base64 SHA-256 digest S3 expects in x-amz-checksum-sha256
+func Checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Put uploads content with its SHA-256 checksum, which S3 validates before
+// storing the object, and returns the checksum
+func Put(t *testing.T, clients *awsclients.Clients, bucket, key string, content []byte) string {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	checksum := Checksum(content)
+	output, err := clients.S3().PutObject(ctx, &s3.PutObjectInput{
+		Bucket:         aws.String(bucket),
+		Key:            aws.String(key),
+		Body:           bytes.NewReader(content),
+		ChecksumSHA256: aws.String(checksum),
+	})
+	require.NoError(t, err, "Failed to upload s3://%s/%s", bucket, key)
+	assert.Equal(t, checksum, aws.ToString(output.ChecksumSHA256), "S3 should store the checksum of s3://%s/%s", bucket, key)
+	return checksum
+}
+
+// AssertRejectsCorruptUpload checks S3 refuses an upload whose content does
+// not match the checksum sent with it, as when data is corrupted in transit
+func AssertRejectsCorruptUpload(t *testing.T, clients *awsclients.Clients, bucket, key string) {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	_, err := clients.S3().PutObject(ctx, &s3.PutObjectInput{
+		Bucket:         aws.String(bucket),
+		Key:            aws.String(key),
+		Body:           bytes.NewReader([]byte("corrupted in transit")),
+		ChecksumSHA256: aws.String(Checksum([]byte("original content"))),
+	})
+	if err == nil {
+		_, _ = clients.S3().DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	}
+	require.Error(t, err, "S3 should reject an upload that does not match its checksum")
+
+	var apiErr smithy.APIError
+	require.True(t, errors.As(err, &apiErr), "Unexpected error uploading a corrupt object: %v", err)
+	assert.True(t, rejectedCodes[apiErr.ErrorCode()], "S3 rejected the corrupt upload with %s, want BadDigest: %v", apiErr.ErrorCode(), err)
+
+	logging.New(t).Success("S3 rejected a corrupt upload", "object", "s3://"+bucket+"/"+key, "code", apiErr.ErrorCode())
+}
+
+// Verify downloads an object with checksum validation and checks the stored
+// SHA-256 checksum matches its content; objects stored with another algorithm
+// are validated by the SDK while downloading
+func Verify(ctx context.Context, client *s3.Client, bucket, key string) error {
+	output, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:       aws.String(bucket),
+		Key:          aws.String(key),
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
+	if err != nil {
+		return err
+	}
+	defer output.Body.Close()
+
+	content, err := io.ReadAll(output.Body)
+	if err != nil {
+		return fmt.Errorf("read s3://%s/%s: %w", bucket, key, err)
+	}
+	return checkStored(storedChecksums{
+		SHA256: aws.ToString(output.ChecksumSHA256),
+		SHA1:   aws.ToString(output.ChecksumSHA1),
+		CRC32:  aws.ToString(output.ChecksumCRC32),
+		CRC32C: aws.ToString(output.ChecksumCRC32C),
+	}, content)
+}
+
+// storedChecksums are the checksums S3 returns for an object
+type storedChecksums struct {
+	SHA256, SHA1, CRC32, CRC32C string
+}
+
+// checkStored checks an object carries a checksum, and that a SHA-256 one
+// matches content
+func checkStored(stored storedChecksums, content []byte) error {
+	switch {
+	case stored.SHA256 != "":
+		if want := Checksum(content); stored.SHA256 != want {
+			return fmt.Errorf("stored SHA-256 checksum %s does not match the content's %s", stored.SHA256, want)
+		}
+		return nil
+	case stored.SHA1 != "" || stored.CRC32 != "" || stored.CRC32C != "":
+		return nil
+	default:
+		return errors.New("object has no stored checksum")
+	}
+}
+
+// AssertSample verifies up to n objects under prefix in bucket, the first n
+// in key order, failing when there are none to check
+func AssertSample(t *testing.T, clients *awsclients.Clients, bucket, prefix string, n int) {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	var keys []string
+	paginator := s3.NewListObjectsV2Paginator(clients.S3(), &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		require.NoError(t, err, "Failed to list s3://%s/%s", bucket, prefix)
+		for _, object := range page.Contents {
+			keys = append(keys, aws.ToString(object.Key))
+		}
+	}
+	keys = sample(keys, n)
+	require.NotEmpty(t, keys, "No objects under s3://%s/%s to verify", bucket, prefix)
+
+	for _, key := range keys {
+		assert.NoError(t, Verify(ctx, clients.S3(), bucket, key), "Integrity check failed for s3://%s/%s", bucket, key)
+	}
+	logging.New(t).Success("Verified checksums", "prefix", "s3://"+bucket+"/"+prefix, "objects", len(keys))
+}
+
+// sample returns the first n keys in sorted order, so reruns check the same objects
+func sample(keys []string, n int) []string {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
@@ -0,0 +1,28 @@
+package integrity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecksum(t *testing.T) {
+	// echo -n hello | openssl dgst -sha256 -binary | base64
+	assert.Equal(t, "LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=", Checksum([]byte("hello")))
+}
+
+func TestCheckStored(t *testing.T) {
+	content := []byte("id,name\n1,alice\n")
+
+	assert.NoError(t, checkStored(storedChecksums{SHA256: Checksum(content)}, content))
+	assert.ErrorContains(t, checkStored(storedChecksums{SHA256: Checksum([]byte("other"))}, content), "does not match")
+	assert.NoError(t, checkStored(storedChecksums{CRC32C: "yZRlqg=="}, content), "Other algorithms are validated by the SDK")
+	assert.EqualError(t, checkStored(storedChecksums{}, content), "object has no stored checksum")
+}
+
+func TestSample(t *testing.T) {
+	keys := []string{"c", "a", "d", "b"}
+	assert.Equal(t, []string{"a", "b"}, sample(keys, 2))
+	assert.Equal(t, []string{"a", "b", "c", "d"}, sample(keys, 10))
+	assert.Equal(t, []string{"c", "a", "d", "b"}, keys, "sample must not reorder its input")
+}
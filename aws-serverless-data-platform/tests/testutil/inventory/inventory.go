@@ -0,0 +1,126 @@
+// =============================================================================
+// Expected Resource Inventory
+// Declared resource counts per type, checked against plans and applied state
+// =============================================================================
+
+// Package inventory checks a module creates exactly the resources its tests
+// declare, e.g. {"aws_s3_bucket": 3, "aws_kms_key": 1}, so a refactor that
+// adds or drops a resource fails the plan and apply tests instead of relying
+// on review to notice.
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/report"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tfplan"
+)
+
+// Dir is where expected inventories live, relative to the test's package
+const Dir = "testdata/inventory"
+
+// Inventory is the number of managed resources of each type
+type Inventory map[string]int
+
+// Load reads the expected inventory Dir/<name>.json
+func Load(name string) (Inventory, error) {
+	path := filepath.Join(Dir, name+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inventory %s: %w", path, err)
+	}
+
+	var expected Inventory
+	if err := json.Unmarshal(data, &expected); err != nil {
+		return nil, fmt.Errorf("failed to parse inventory %s: %w", path, err)
+	}
+	return expected, nil
+}
+
+// FromPlan counts the managed resources a plan leaves in place or creates
+func FromPlan(plan *tfplan.Plan) Inventory {
+	inventory := Inventory{}
+	for _, resource := range plan.Resources() {
+		inventory[resource.Type]++
+	}
+	return inventory
+}
+
+// FromState counts the managed resources of a state inventory
+func FromState(resources []report.Resource) Inventory {
+	inventory := Inventory{}
+	for _, resource := range resources {
+		inventory[resource.Type]++
+	}
+	return inventory
+}
+
+// Diff lists the resource types whose count in actual differs from expected
+func Diff(expected, actual Inventory) []string {
+	types := map[string]bool{}
+	for resourceType := range expected {
+		types[resourceType] = true
+	}
+	for resourceType := range actual {
+		types[resourceType] = true
+	}
+	sorted := make([]string, 0, len(types))
+	for resourceType := range types {
+		sorted = append(sorted, resourceType)
+	}
+	sort.Strings(sorted)
+
+	var problems []string
+	for _, resourceType := range sorted {
+		want, declared := expected[resourceType]
+		got := actual[resourceType]
+		switch {
+		case !declared:
+			problems = append(problems, fmt.Sprintf("%s: found %d, not in the inventory", resourceType, got))
+		case want != got:
+			problems = append(problems, fmt.Sprintf("%s: expected %d, found %d", resourceType, want, got))
+		}
+	}
+	return problems
+}
+
+// AssertPlan checks the plan against the inventory declared in Dir/<name>.json
+func AssertPlan(t *testing.T, plan *tfplan.Plan, name string) bool {
+	t.Helper()
+	return assertInventory(t, name, "plan", FromPlan(plan))
+}
+
+// AssertState checks the applied state of terraformOptions against the
+// inventory declared in Dir/<name>.json
+func AssertState(t *testing.T, terraformOptions *terraform.Options, name string) bool {
+	t.Helper()
+
+	resources, err := report.ParseInventory(terraform.Show(t, terraformOptions))
+	require.NoError(t, err)
+	return assertInventory(t, name, "state", FromState(resources))
+}
+
+// assertInventory fails t with every difference between the declared inventory and actual
+func assertInventory(t *testing.T, name, source string, actual Inventory) bool {
+	t.Helper()
+
+	expected, err := Load(name)
+	require.NoError(t, err)
+
+	problems := Diff(expected, actual)
+	for _, problem := range problems {
+		t.Errorf("%s inventory %s: %s; update %s/%s.json if the change is intended", source, name, problem, Dir, name)
+	}
+	if len(problems) == 0 {
+		logging.New(t).Success("Resources match the declared inventory", "inventory", name, "source", source, "types", len(expected))
+	}
+	return len(problems) == 0
+}
@@ -0,0 +1,40 @@
+package inventory
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/report"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tfplan"
+)
+
+func TestFromPlan(t *testing.T) {
+	planJSON, err := os.ReadFile("../tfplan/testdata/plan.json")
+	require.NoError(t, err)
+	plan, err := tfplan.Parse(string(planJSON))
+	require.NoError(t, err)
+
+	assert.Equal(t, Inventory{"aws_vpc": 1, "aws_subnet": 2, "aws_s3_bucket_versioning": 1}, FromPlan(plan))
+}
+
+func TestFromState(t *testing.T) {
+	assert.Equal(t, Inventory{"aws_s3_bucket": 2, "aws_kms_key": 1}, FromState([]report.Resource{
+		{Address: "aws_s3_bucket.raw", Type: "aws_s3_bucket"},
+		{Address: "aws_s3_bucket.curated", Type: "aws_s3_bucket"},
+		{Address: "aws_kms_key.s3", Type: "aws_kms_key"},
+	}))
+}
+
+func TestDiff(t *testing.T) {
+	expected := Inventory{"aws_s3_bucket": 3, "aws_kms_key": 1, "aws_kms_alias": 1}
+	actual := Inventory{"aws_s3_bucket": 4, "aws_kms_key": 1, "aws_sqs_queue": 1}
+
+	assert.Equal(t, []string{
+		"aws_kms_alias: expected 1, found 0",
+		"aws_s3_bucket: expected 3, found 4",
+		"aws_sqs_queue: found 1, not in the inventory",
+	}, Diff(expected, actual))
+	assert.Empty(t, Diff(expected, Inventory{"aws_s3_bucket": 3, "aws_kms_key": 1, "aws_kms_alias": 1}))
+}
@@ -0,0 +1,113 @@
+// =============================================================================
+// Keep On Failure
+// Leaves a failed test's stacks deployed for triage instead of destroying them
+// =============================================================================
+
+package testutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/report"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/runprefix"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/testconfig"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/triage"
+)
+
+// KeepOnFailure reports whether the stacks of t should be left deployed, which
+// they are when t failed and triage.EnvVar is true. It then writes their
+// triage file to dir and registers them in the run's kept stack manifest, so
+// the sweeper lists them; cleanup tells whoever triages how to destroy them.
+// Callers skip their destroy when it returns true.
+func KeepOnFailure(t *testing.T, dir, cleanup string, stacks ...*terraform.Options) bool {
+	if !t.Failed() || !triage.Enabled() {
+		return false
+	}
+	logger := logging.New(t)
+
+	prefix, err := runprefix.Get()
+	assert.NoError(t, err)
+	region := DefaultRegion
+	if len(stacks) > 0 {
+		region = stackRegion(stacks[0])
+	}
+
+	r := triage.Report{
+		Test:    t.Name(),
+		Prefix:  prefix,
+		Region:  region,
+		Kept:    time.Now().UTC(),
+		Cleanup: cleanup,
+	}
+	for _, options := range stacks {
+		r.Stacks = append(r.Stacks, describeStack(t, options, region))
+	}
+
+	path, err := filepath.Abs(filepath.Join(dir, triage.FileName))
+	assert.NoError(t, err)
+	if !assert.NoError(t, triage.Write(path, r), "Failed to write the triage file") {
+		return true
+	}
+
+	if root, err := testconfig.FindRoot(); assert.NoError(t, err) {
+		kept := runprefix.Kept{Prefix: prefix, Suite: suiteName(), Test: t.Name(), Triage: path, Cleanup: cleanup, Kept: r.Kept}
+		assert.NoError(t, runprefix.AppendKept(runprefix.KeptPath(runprefix.ManifestPath(root, prefix)), kept),
+			"Failed to register the kept stacks for the sweeper")
+	}
+
+	logger.Warn("Kept the failed test's stacks for triage", "stacks", len(stacks), "triage", path, "cleanup", cleanup)
+	return true
+}
+
+// describeStack reads the outputs and state of one kept stack; what cannot be
+// read is logged and left out
+func describeStack(t *testing.T, options *terraform.Options, region string) triage.Stack {
+	logger := logging.New(t, "dir", options.TerraformDir)
+
+	var outputs map[string]interface{}
+	if outputJSON, err := terraform.RunTerraformCommandAndGetStdoutE(t, options, "output", "-no-color", "-json"); err != nil {
+		logger.Warn("Failed to read outputs for triage", "error", err)
+	} else if outputs, err = triage.ParseOutputs(outputJSON); err != nil {
+		logger.Warn("Failed to parse outputs for triage", "error", err)
+	}
+
+	var resources []report.Resource
+	if stateJSON, err := terraform.ShowE(t, options); err != nil {
+		logger.Warn("Failed to read state for triage", "error", err)
+	} else if resources, err = report.ParseInventory(stateJSON); err != nil {
+		logger.Warn("Failed to parse state for triage", "error", err)
+	}
+
+	return triage.NewStack(options.TerraformDir, region, outputs, resources)
+}
+
+// rerunTeardown is the command that destroys the stacks Teardown kept for t:
+// the test rerun from its package with only the teardown stage
+func rerunTeardown(t *testing.T) string {
+	names := strings.Split(t.Name(), "/")
+	for i, name := range names {
+		names[i] = "^" + regexp.QuoteMeta(name) + "$"
+	}
+	dir, _ := os.Getwd()
+	return fmt.Sprintf("cd %s && SKIP_%s=true SKIP_%s=true go test -timeout 60m -run '%s' .",
+		dir, StageSetup, StageValidate, strings.Join(names, "/"))
+}
+
+// SweepCommand is the command that sweeps the resources of the current run,
+// for stacks whose state does not outlive the test; the run has finished, so
+// no minimum age applies
+func SweepCommand() string {
+	prefix, _ := runprefix.Get()
+	root, _ := testconfig.FindRoot()
+	return fmt.Sprintf("cd %s && go run ./cmd/sweeper -manifest %s -min-age 0",
+		filepath.Join(root, "tests"), runprefix.ManifestPath(root, prefix))
+}
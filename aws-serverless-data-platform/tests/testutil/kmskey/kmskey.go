@@ -0,0 +1,221 @@
+// =============================================================================
+// KMS Key Assertions
+// Rotation, key policy, alias and round-trip checks for customer managed keys
+// =============================================================================
+
+package kmskey
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/iampolicy"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/wait"
+)
+
+// Expectations describes how a key must be configured
+type Expectations struct {
+	// Alias is the alias name, e.g. "alias/s3-dev-us-east-1", that must target the key
+	Alias string
+
+	// AccountID is the owning account; its root principal is always allowed
+	AccountID string
+
+	// ServicePrincipals must each be granted by the key policy and are the only services allowed
+	ServicePrincipals []string
+
+	// Roles are the IAM role ARNs the key policy may name directly
+	Roles []string
+
+	// RoundTripRoleARN is assumed for the encrypt/decrypt round-trip; the caller's
+	// own credentials are used when it is empty
+	RoundTripRoleARN string
+}
+
+// AssertKey fetches the key via the KMS API and checks it against exp
+func AssertKey(t *testing.T, clients *awsclients.Clients, keyID string, exp Expectations) {
+	t.Run("Enabled", func(t *testing.T) {
+		ctx, cancel := clients.Context()
+		defer cancel()
+
+		output, err := clients.KMS().DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(keyID)})
+		require.NoError(t, err, "Failed to describe KMS key %s", keyID)
+
+		metadata := output.KeyMetadata
+		assert.Equal(t, types.KeyStateEnabled, metadata.KeyState, "Key %s should be enabled", keyID)
+		assert.Equal(t, types.KeyManagerTypeCustomer, metadata.KeyManager, "Key %s should be customer managed", keyID)
+		assert.Equal(t, types.KeyUsageTypeEncryptDecrypt, metadata.KeyUsage)
+	})
+
+	t.Run("Rotation", func(t *testing.T) {
+		ctx, cancel := clients.Context()
+		defer cancel()
+
+		output, err := clients.KMS().GetKeyRotationStatus(ctx, &kms.GetKeyRotationStatusInput{KeyId: aws.String(keyID)})
+		require.NoError(t, err, "Failed to get rotation status for %s", keyID)
+		assert.True(t, output.KeyRotationEnabled, "Key rotation should be enabled for %s", keyID)
+
+		if output.KeyRotationEnabled {
+			logging.New(t).Success("Rotation enabled", "key", keyID, "next_rotation", aws.ToTime(output.NextRotationDate).Format(time.DateOnly))
+		}
+	})
+
+	t.Run("KeyPolicy", func(t *testing.T) {
+		ctx, cancel := clients.Context()
+		defer cancel()
+
+		output, err := clients.KMS().GetKeyPolicy(ctx, &kms.GetKeyPolicyInput{
+			KeyId:      aws.String(keyID),
+			PolicyName: aws.String("default"),
+		})
+		require.NoError(t, err, "Failed to get key policy for %s", keyID)
+
+		doc, err := iampolicy.Parse(aws.ToString(output.Policy))
+		require.NoError(t, err)
+
+		for _, problem := range CheckPolicy(doc, exp) {
+			t.Errorf("Key policy on %s: %s", keyID, problem)
+		}
+	})
+
+	t.Run("Alias", func(t *testing.T) {
+		if exp.Alias == "" {
+			t.Skip("No alias expected")
+		}
+		assertAlias(t, clients, keyID, exp.Alias)
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		caller := clients
+		if exp.RoundTripRoleARN != "" {
+			caller = awsclients.New(t,
+				awsclients.WithRegion(clients.Config.Region),
+				awsclients.WithAssumeRole(exp.RoundTripRoleARN),
+				awsclients.WithSessionName("terratest-kmskey"),
+			)
+		}
+		assertRoundTrip(t, caller, keyID)
+	})
+}
+
+// CheckPolicy returns the ways doc grants more, or less, than exp allows
+func CheckPolicy(doc *iampolicy.Document, exp Expectations) []string {
+	allowedAWS := map[string]bool{}
+	if exp.AccountID != "" {
+		allowedAWS["arn:aws:iam::"+exp.AccountID+":root"] = true
+		allowedAWS[exp.AccountID] = true
+	}
+	for _, role := range exp.Roles {
+		allowedAWS[role] = true
+	}
+	allowedServices := map[string]bool{}
+	for _, service := range exp.ServicePrincipals {
+		allowedServices[service] = true
+	}
+
+	var problems []string
+	granted := map[string]bool{}
+	for i, statement := range doc.Statement {
+		if statement.Effect != "Allow" || statement.Principal == nil {
+			continue
+		}
+		if statement.Principal.All {
+			problems = append(problems, fmt.Sprintf("statement %d allows any principal", i))
+			continue
+		}
+		for _, principal := range statement.Principal.AWS {
+			if !allowedAWS[principal] {
+				problems = append(problems, fmt.Sprintf("statement %d grants unexpected principal %s", i, principal))
+			}
+		}
+		for _, service := range statement.Principal.Service {
+			granted[service] = true
+			if !allowedServices[service] {
+				problems = append(problems, fmt.Sprintf("statement %d grants unexpected service %s", i, service))
+			}
+		}
+	}
+
+	var missing []string
+	for service := range allowedServices {
+		if !granted[service] {
+			missing = append(missing, service)
+		}
+	}
+	sort.Strings(missing)
+	for _, service := range missing {
+		problems = append(problems, fmt.Sprintf("does not grant service %s", service))
+	}
+	return problems
+}
+
+// assertAlias checks alias exists and targets keyID
+func assertAlias(t *testing.T, clients *awsclients.Clients, keyID, alias string) {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	paginator := kms.NewListAliasesPaginator(clients.KMS(), &kms.ListAliasesInput{KeyId: aws.String(keyID)})
+	var names []string
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		require.NoError(t, err, "Failed to list aliases for %s", keyID)
+
+		for _, entry := range page.Aliases {
+			names = append(names, aws.ToString(entry.AliasName))
+		}
+	}
+
+	// ListAliases filtered by key only returns aliases that target it
+	assert.Contains(t, names, alias, "Alias %s should target key %s", alias, keyID)
+
+	// Resolving the alias must land on the same key
+	output, err := clients.KMS().DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(alias)})
+	require.NoError(t, err, "Failed to describe key by alias %s", alias)
+	if assert.Equal(t, keyID, aws.ToString(output.KeyMetadata.KeyId), "Alias %s resolves to another key", alias) {
+		logging.New(t).Success("Alias targets key", "alias", alias, "key", keyID)
+	}
+}
+
+// assertRoundTrip generates a data key and decrypts its ciphertext with the same caller;
+// retried because freshly created roles and grants take a while to propagate
+func assertRoundTrip(t *testing.T, clients *awsclients.Clients, keyID string) {
+	encryptionContext := map[string]string{"purpose": "terratest-round-trip"}
+
+	wait.Until(t, "KMS round-trip with "+keyID, wait.Succeeds(func(ctx context.Context) error {
+		dataKey, err := clients.KMS().GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+			KeyId:             aws.String(keyID),
+			KeySpec:           types.DataKeySpecAes256,
+			EncryptionContext: encryptionContext,
+		})
+		if err != nil {
+			return err
+		}
+
+		decrypted, err := clients.KMS().Decrypt(ctx, &kms.DecryptInput{
+			KeyId:             aws.String(keyID),
+			CiphertextBlob:    dataKey.CiphertextBlob,
+			EncryptionContext: encryptionContext,
+		})
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(dataKey.Plaintext, decrypted.Plaintext) {
+			return wait.Permanent(fmt.Errorf("decrypted data key for %s does not match the generated one", keyID))
+		}
+		return nil
+	}), wait.DefaultOptions().WithTimeout(2*time.Minute).WithInterval(10*time.Second))
+
+	logging.New(t).Success("Encrypt/decrypt round-trip succeeded", "key", keyID)
+}
@@ -0,0 +1,57 @@
+package kmskey
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/iampolicy"
+)
+
+const accountID = "123456789012"
+
+// dataKeyPolicy mirrors the security module's data key policy
+const dataKeyPolicy = `{"Version":"2012-10-17","Statement":[
+	{"Sid":"Enable IAM policies","Effect":"Allow","Principal":{"AWS":"arn:aws:iam::123456789012:root"},"Action":"kms:*","Resource":"*"},
+	{"Sid":"Allow data platform services","Effect":"Allow",
+	 "Principal":{"Service":["s3.amazonaws.com","glue.amazonaws.com"]},
+	 "Action":["kms:Decrypt","kms:GenerateDataKey"],"Resource":"*"}
+]}`
+
+func TestCheckPolicy(t *testing.T) {
+	doc, err := iampolicy.Parse(dataKeyPolicy)
+	require.NoError(t, err)
+
+	exp := Expectations{
+		AccountID:         accountID,
+		ServicePrincipals: []string{"glue.amazonaws.com", "s3.amazonaws.com"},
+	}
+	assert.Empty(t, CheckPolicy(doc, exp))
+
+	// A service the key should serve but does not
+	exp.ServicePrincipals = append(exp.ServicePrincipals, "states.amazonaws.com")
+	assert.Equal(t, []string{"does not grant service states.amazonaws.com"}, CheckPolicy(doc, exp))
+
+	// A service the key should not serve
+	exp.ServicePrincipals = []string{"s3.amazonaws.com"}
+	assert.Equal(t, []string{"statement 1 grants unexpected service glue.amazonaws.com"}, CheckPolicy(doc, exp))
+}
+
+func TestCheckPolicyPrincipals(t *testing.T) {
+	doc, err := iampolicy.Parse(`{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::123456789012:root","arn:aws:iam::123456789012:role/glue"]},"Action":"kms:Decrypt","Resource":"*"},
+		{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::210987654321:root"},"Action":"kms:Decrypt","Resource":"*"},
+		{"Effect":"Deny","Principal":"*","Action":"kms:ScheduleKeyDeletion","Resource":"*"},
+		{"Effect":"Allow","Principal":"*","Action":"kms:Decrypt","Resource":"*"}
+	]}`)
+	require.NoError(t, err)
+
+	problems := CheckPolicy(doc, Expectations{
+		AccountID: accountID,
+		Roles:     []string{"arn:aws:iam::123456789012:role/glue"},
+	})
+	assert.Equal(t, []string{
+		"statement 1 grants unexpected principal arn:aws:iam::210987654321:root",
+		"statement 3 allows any principal",
+	}, problems)
+}
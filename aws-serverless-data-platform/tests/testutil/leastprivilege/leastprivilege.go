@@ -0,0 +1,144 @@
+// =============================================================================
+// Least Privilege Probes
+// Attempts actions a role must not perform and expects AccessDenied
+// =============================================================================
+
+// Package leastprivilege proves a role is scoped down by assuming it and
+// attempting actions its policies must not allow. It complements policy
+// simulation, which evaluates the documents, with real denied API calls.
+// Probes that unexpectedly succeed are undone with the test's own credentials.
+package leastprivilege
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/wait"
+)
+
+// deniedCodes are the error codes AWS services return when a request is refused by policy
+var deniedCodes = map[string]bool{
+	"AccessDenied":          true,
+	"AccessDeniedException": true,
+	"UnauthorizedOperation": true,
+}
+
+// Probe is one action the role must not be able to perform
+type Probe struct {
+	// Name describes the action and its target, e.g. "kms:ScheduleKeyDeletion on the data key"
+	Name string
+
+	// Attempt performs the action as the role
+	Attempt func(ctx context.Context, role *awsclients.Clients) error
+
+	// Undo reverts the action with the test's credentials when Attempt succeeded; optional
+	Undo func(ctx context.Context, admin *awsclients.Clients) error
+}
+
+// AssumeRole returns clients acting as roleARN, waiting until the role can be
+// assumed; trust policy changes take a while to propagate, and a failed
+// assumption must not be mistaken for a denied probe
+func AssumeRole(t *testing.T, admin *awsclients.Clients, roleARN string) *awsclients.Clients {
+	role := awsclients.New(t,
+		awsclients.WithRegion(admin.Config.Region),
+		awsclients.WithAssumeRole(roleARN),
+		awsclients.WithSessionName("terratest-leastprivilege"),
+	)
+
+	wait.Until(t, "assume "+roleARN, wait.Succeeds(func(ctx context.Context) error {
+		identity, err := role.STS().GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(aws.ToString(identity.Arn), ":assumed-role/") {
+			return wait.Permanent(fmt.Errorf("caller %s is not an assumed role", aws.ToString(identity.Arn)))
+		}
+		return nil
+	}), wait.DefaultOptions().WithTimeout(2*time.Minute).WithInterval(10*time.Second))
+
+	return role
+}
+
+// Assert runs every probe as role in its own subtest and checks each is denied
+func Assert(t *testing.T, admin, role *awsclients.Clients, probes []Probe) {
+	for _, probe := range probes {
+		t.Run(probe.Name, func(t *testing.T) {
+			ctx, cancel := role.Context()
+			defer cancel()
+
+			err := probe.Attempt(ctx, role)
+			AssertDenied(t, err, probe.Name)
+			if err != nil || probe.Undo == nil {
+				return
+			}
+
+			undoCtx, cancel := admin.Context()
+			defer cancel()
+			if err := probe.Undo(undoCtx, admin); err != nil {
+				t.Errorf("Failed to undo %s, which was allowed: %v", probe.Name, err)
+			}
+		})
+	}
+}
+
+// AssertDenied checks err is an access-denied error from any AWS service
+func AssertDenied(t *testing.T, err error, description string) bool {
+	t.Helper()
+
+	if !assert.Error(t, err, "%s should be denied", description) {
+		return false
+	}
+
+	code, denied := DeniedCode(err)
+	if !assert.True(t, denied, "%s failed with %q, want AccessDenied: %v", description, code, err) {
+		return false
+	}
+
+	logging.New(t).Success("Request denied", "request", description, "code", code)
+	return true
+}
+
+// DeniedCode returns the API error code of err and whether it means the
+// request was refused by policy
+func DeniedCode(err error) (string, bool) {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return "", false
+	}
+	return apiErr.ErrorCode(), deniedCodes[apiErr.ErrorCode()]
+}
+
+// CallerRoleARN returns the ARN of the role clients act as, or "" when they
+// are not using an assumed role
+func CallerRoleARN(ctx context.Context, clients *awsclients.Clients) (string, error) {
+	identity, err := clients.STS().GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+	return roleARN(aws.ToString(identity.Arn)), nil
+}
+
+// roleARN converts an STS assumed-role session ARN to the ARN of its role;
+// role paths are not part of the session ARN, so roles must live at "/"
+func roleARN(sessionARN string) string {
+	parsed, err := arn.Parse(sessionARN)
+	if err != nil || parsed.Service != "sts" {
+		return ""
+	}
+	parts := strings.Split(parsed.Resource, "/")
+	if len(parts) != 3 || parts[0] != "assumed-role" {
+		return ""
+	}
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", parsed.Partition, parsed.AccountID, parts[1])
+}
@@ -0,0 +1,51 @@
+package leastprivilege
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeniedCode(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   string
+		denied bool
+	}{
+		{"S3", &smithy.GenericAPIError{Code: "AccessDenied"}, "AccessDenied", true},
+		{"KMS", &smithy.GenericAPIError{Code: "AccessDeniedException"}, "AccessDeniedException", true},
+		{"EC2", &smithy.GenericAPIError{Code: "UnauthorizedOperation"}, "UnauthorizedOperation", true},
+		{"wrapped", fmt.Errorf("operation error: %w", &smithy.GenericAPIError{Code: "AccessDenied"}), "AccessDenied", true},
+		{"other API error", &smithy.GenericAPIError{Code: "NoSuchBucket"}, "NoSuchBucket", false},
+		{"not an API error", errors.New("connection reset"), "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, denied := DeniedCode(tt.err)
+			assert.Equal(t, tt.code, code)
+			assert.Equal(t, tt.denied, denied)
+		})
+	}
+}
+
+func TestRoleARN(t *testing.T) {
+	tests := []struct {
+		session string
+		want    string
+	}{
+		{"arn:aws:sts::123456789012:assumed-role/terratest/session", "arn:aws:iam::123456789012:role/terratest"},
+		{"arn:aws-us-gov:sts::123456789012:assumed-role/ci/1234", "arn:aws-us-gov:iam::123456789012:role/ci"},
+		{"arn:aws:iam::123456789012:user/alice", ""},
+		{"arn:aws:sts::123456789012:federated-user/alice", ""},
+		{"not-an-arn", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, roleARN(tt.session), tt.session)
+	}
+}
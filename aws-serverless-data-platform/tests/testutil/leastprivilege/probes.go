@@ -0,0 +1,129 @@
+// =============================================================================
+// Probe Catalogue
+// Destructive and escalating actions a data-processing role must not perform
+// =============================================================================
+
+package leastprivilege
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+)
+
+// administratorAccess is the AWS managed policy granting full administrative privileges
+const administratorAccess = "arn:aws:iam::aws:policy/AdministratorAccess"
+
+// probeKeyPrefix holds every object key the PutObject probe writes
+const probeKeyPrefix = "leastprivilege-probe/"
+
+// ScheduleKeyDeletion tries to schedule deletion of keyID with the shortest waiting period
+func ScheduleKeyDeletion(keyID, description string) Probe {
+	return Probe{
+		Name: "kms:ScheduleKeyDeletion on " + description,
+		Attempt: func(ctx context.Context, role *awsclients.Clients) error {
+			_, err := role.KMS().ScheduleKeyDeletion(ctx, &kms.ScheduleKeyDeletionInput{
+				KeyId:               aws.String(keyID),
+				PendingWindowInDays: aws.Int32(7),
+			})
+			return err
+		},
+		Undo: func(ctx context.Context, admin *awsclients.Clients) error {
+			if _, err := admin.KMS().CancelKeyDeletion(ctx, &kms.CancelKeyDeletionInput{KeyId: aws.String(keyID)}); err != nil {
+				return err
+			}
+			// A key whose deletion is cancelled stays disabled
+			_, err := admin.KMS().EnableKey(ctx, &kms.EnableKeyInput{KeyId: aws.String(keyID)})
+			return err
+		},
+	}
+}
+
+// DisableKey tries to disable keyID, which would make everything it encrypts unreadable
+func DisableKey(keyID, description string) Probe {
+	return Probe{
+		Name: "kms:DisableKey on " + description,
+		Attempt: func(ctx context.Context, role *awsclients.Clients) error {
+			_, err := role.KMS().DisableKey(ctx, &kms.DisableKeyInput{KeyId: aws.String(keyID)})
+			return err
+		},
+		Undo: func(ctx context.Context, admin *awsclients.Clients) error {
+			_, err := admin.KMS().EnableKey(ctx, &kms.EnableKeyInput{KeyId: aws.String(keyID)})
+			return err
+		},
+	}
+}
+
+// PutObject tries to write an object to bucket, which must be outside the role's data stage
+func PutObject(bucket, description string) Probe {
+	key := probeKeyPrefix + strings.ToLower(random.UniqueId())
+	return Probe{
+		Name: "s3:PutObject to " + description,
+		Attempt: func(ctx context.Context, role *awsclients.Clients) error {
+			_, err := role.S3().PutObject(ctx, &s3.PutObjectInput{
+				Bucket: aws.String(bucket),
+				Key:    aws.String(key),
+				Body:   strings.NewReader("probe"),
+			})
+			return err
+		},
+		Undo: func(ctx context.Context, admin *awsclients.Clients) error {
+			_, err := admin.S3().DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+			return err
+		},
+	}
+}
+
+// PassRole tries to pass roleARN to a new Glue job, which would let the role
+// run code with roleARN's permissions
+func PassRole(roleARN, description, scriptLocation string) Probe {
+	name := "leastprivilege-probe-" + strings.ToLower(random.UniqueId())
+	return Probe{
+		Name: "iam:PassRole of " + description,
+		Attempt: func(ctx context.Context, role *awsclients.Clients) error {
+			_, err := role.Glue().CreateJob(ctx, &glue.CreateJobInput{
+				Name: aws.String(name),
+				Role: aws.String(roleARN),
+				Command: &gluetypes.JobCommand{
+					Name:           aws.String("glueetl"),
+					ScriptLocation: aws.String(scriptLocation),
+				},
+			})
+			return err
+		},
+		Undo: func(ctx context.Context, admin *awsclients.Clients) error {
+			_, err := admin.Glue().DeleteJob(ctx, &glue.DeleteJobInput{JobName: aws.String(name)})
+			return err
+		},
+	}
+}
+
+// AttachAdministratorAccess tries to grant roleName AdministratorAccess, escalating its own privileges
+func AttachAdministratorAccess(roleName string) Probe {
+	return Probe{
+		Name: fmt.Sprintf("iam:AttachRolePolicy of AdministratorAccess to %s", roleName),
+		Attempt: func(ctx context.Context, role *awsclients.Clients) error {
+			_, err := role.IAM().AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
+				RoleName:  aws.String(roleName),
+				PolicyArn: aws.String(administratorAccess),
+			})
+			return err
+		},
+		Undo: func(ctx context.Context, admin *awsclients.Clients) error {
+			_, err := admin.IAM().DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
+				RoleName:  aws.String(roleName),
+				PolicyArn: aws.String(administratorAccess),
+			})
+			return err
+		},
+	}
+}
@@ -0,0 +1,281 @@
+// =============================================================================
+// S3 Lifecycle Rules
+// Reads bucket lifecycle rules, checks their thresholds and simulates them
+// =============================================================================
+
+// Package lifecycle reads S3 lifecycle rules into a small model, checks their
+// transition and expiration thresholds the way S3 and the platform's retention
+// policy require, and simulates the storage class an object of a given age is
+// in, so misordered thresholds are caught before objects move the wrong way.
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"gopkg.in/yaml.v3"
+)
+
+// Standard is the storage class objects start in
+const Standard = "STANDARD"
+
+// tiers orders the storage classes from warmest to coldest
+var tiers = map[string]int{
+	Standard:              0,
+	"INTELLIGENT_TIERING": 1,
+	"STANDARD_IA":         2,
+	"ONEZONE_IA":          3,
+	"GLACIER_IR":          4,
+	"GLACIER":             5,
+	"DEEP_ARCHIVE":        6,
+}
+
+// minimumDays are the ages below which S3 rejects a transition to a class
+var minimumDays = map[string]int32{
+	"STANDARD_IA": 30,
+	"ONEZONE_IA":  30,
+}
+
+// Transition moves current object versions to StorageClass at Days old
+type Transition struct {
+	Days         int32
+	StorageClass string
+}
+
+// Rule is one lifecycle rule; zero days mean the action is not configured
+type Rule struct {
+	ID      string
+	Enabled bool
+
+	// Prefix is the key prefix the rule applies to; "" is the whole bucket
+	Prefix string
+
+	// Filtered is set when the rule also filters on tags or object size, so it
+	// only applies to some of the objects under Prefix
+	Filtered bool
+
+	Transitions              []Transition
+	ExpirationDays           int32
+	NoncurrentExpirationDays int32
+}
+
+// FromS3 converts the rules of a GetBucketLifecycleConfiguration response,
+// with each rule's transitions sorted by age
+func FromS3(rules []s3types.LifecycleRule) []Rule {
+	converted := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		r := Rule{
+			ID:      aws.ToString(rule.ID),
+			Enabled: rule.Status == s3types.ExpirationStatusEnabled,
+			Prefix:  aws.ToString(rule.Prefix),
+		}
+		if filter := rule.Filter; filter != nil {
+			if filter.Prefix != nil {
+				r.Prefix = aws.ToString(filter.Prefix)
+			}
+			if and := filter.And; and != nil {
+				r.Prefix = aws.ToString(and.Prefix)
+				r.Filtered = len(and.Tags) > 0 || and.ObjectSizeGreaterThan != nil || and.ObjectSizeLessThan != nil
+			}
+			r.Filtered = r.Filtered || filter.Tag != nil || filter.ObjectSizeGreaterThan != nil || filter.ObjectSizeLessThan != nil
+		}
+
+		for _, transition := range rule.Transitions {
+			r.Transitions = append(r.Transitions, Transition{Days: aws.ToInt32(transition.Days), StorageClass: string(transition.StorageClass)})
+		}
+		sort.SliceStable(r.Transitions, func(i, j int) bool { return r.Transitions[i].Days < r.Transitions[j].Days })
+		if rule.Expiration != nil {
+			r.ExpirationDays = aws.ToInt32(rule.Expiration.Days)
+		}
+		if rule.NoncurrentVersionExpiration != nil {
+			r.NoncurrentExpirationDays = aws.ToInt32(rule.NoncurrentVersionExpiration.NoncurrentDays)
+		}
+		converted = append(converted, r)
+	}
+	return converted
+}
+
+// Get returns the lifecycle rules of bucket
+func Get(ctx context.Context, client *s3.Client, bucket string) ([]Rule, error) {
+	output, err := client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		return nil, fmt.Errorf("get lifecycle configuration of %s: %w", bucket, err)
+	}
+	return FromS3(output.Rules), nil
+}
+
+// AssertBucket checks that bucket has exactly the rule want, that S3 accepts
+// its thresholds and that it covers every object in the bucket
+func AssertBucket(t *testing.T, clients *awsclients.Clients, bucket string, want Rule) {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	rules, err := Get(ctx, clients.S3(), bucket)
+	require.NoError(t, err)
+	require.Len(t, rules, 1, "Bucket %s should have a single lifecycle rule", bucket)
+	assert.Equal(t, want, rules[0], "Lifecycle rule of %s should match the configured policy", bucket)
+	assert.Empty(t, Check(rules[0]), "Lifecycle rule of %s should have valid thresholds", bucket)
+	assert.Empty(t, Uncovered(rules, ""), "Lifecycle rule of %s should cover every object", bucket)
+}
+
+// Covers reports whether the rule applies to every object under key
+func (r Rule) Covers(key string) bool {
+	return r.Enabled && !r.Filtered && strings.HasPrefix(key, r.Prefix)
+}
+
+// Check lists the thresholds of the rule that S3 rejects or that move objects
+// the wrong way: transitions below a class's minimum age, transitions to a
+// class no colder than the one before, and expiration before a transition
+func Check(rule Rule) []string {
+	var problems []string
+	previous := Transition{StorageClass: Standard}
+	for _, transition := range rule.Transitions {
+		tier, known := tiers[transition.StorageClass]
+		switch {
+		case !known:
+			problems = append(problems, fmt.Sprintf("rule %s transitions to unknown storage class %s", rule.ID, transition.StorageClass))
+		case tier <= tiers[previous.StorageClass]:
+			problems = append(problems, fmt.Sprintf("rule %s moves objects to %s at %d days, which is not colder than %s at %d days",
+				rule.ID, transition.StorageClass, transition.Days, previous.StorageClass, previous.Days))
+		}
+		if minimum := minimumDays[transition.StorageClass]; transition.Days < minimum {
+			problems = append(problems, fmt.Sprintf("rule %s moves objects to %s at %d days, below the %d day minimum",
+				rule.ID, transition.StorageClass, transition.Days, minimum))
+		}
+		if rule.ExpirationDays > 0 && rule.ExpirationDays <= transition.Days {
+			problems = append(problems, fmt.Sprintf("rule %s expires objects at %d days, before they move to %s at %d days",
+				rule.ID, rule.ExpirationDays, transition.StorageClass, transition.Days))
+		}
+		previous = transition
+	}
+	return problems
+}
+
+// Fate is what the rules have done to an object
+type Fate struct {
+	StorageClass string
+	Expired      bool
+}
+
+// Simulate returns the storage class of an untagged object under key that is
+// ageDays old, and whether it has expired. Where several rules apply, S3 picks
+// the coldest class and the earliest expiration, and so does Simulate.
+func Simulate(rules []Rule, key string, ageDays int32) Fate {
+	fate := Fate{StorageClass: Standard}
+	for _, rule := range rules {
+		if !rule.Covers(key) {
+			continue
+		}
+		for _, transition := range rule.Transitions {
+			if transition.Days <= ageDays && tiers[transition.StorageClass] > tiers[fate.StorageClass] {
+				fate.StorageClass = transition.StorageClass
+			}
+		}
+		if rule.ExpirationDays > 0 && rule.ExpirationDays <= ageDays {
+			fate.Expired = true
+		}
+	}
+	return fate
+}
+
+// Uncovered returns the prefixes no enabled rule covers entirely
+func Uncovered(rules []Rule, prefixes ...string) []string {
+	var uncovered []string
+	for _, prefix := range prefixes {
+		covered := false
+		for _, rule := range rules {
+			covered = covered || rule.Covers(prefix)
+		}
+		if !covered {
+			uncovered = append(uncovered, prefix)
+		}
+	}
+	return uncovered
+}
+
+// Policy is the storage.lifecycle section of the platform configuration
+type Policy struct {
+	TransitionIADays          int32 `yaml:"transition_ia_days"`
+	TransitionGlacierDays     int32 `yaml:"transition_glacier_days"`
+	TransitionDeepArchiveDays int32 `yaml:"transition_deep_archive_days"`
+	ExpirationDays            int32 `yaml:"expiration_days"`
+}
+
+// LoadPolicy reads the lifecycle policy of environment from the configuration
+// under root. Like root.hcl, it takes the environment's whole storage section
+// when it has one, so keys it leaves out are zero rather than the defaults.
+func LoadPolicy(root, environment string) (Policy, error) {
+	var policy Policy
+	for _, path := range []string{
+		filepath.Join(root, "config", "common.yaml"),
+		filepath.Join(root, "config", "environments", environment+".yaml"),
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Policy{}, err
+		}
+		var config struct {
+			Storage *struct {
+				Lifecycle Policy `yaml:"lifecycle"`
+			} `yaml:"storage"`
+		}
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return Policy{}, fmt.Errorf("parse %s: %w", path, err)
+		}
+		if config.Storage != nil {
+			policy = config.Storage.Lifecycle
+		}
+	}
+	return policy, nil
+}
+
+// PolicyFromVars reads the policy from the storage module variables
+func PolicyFromVars(vars map[string]interface{}) (Policy, error) {
+	storage, _ := vars["storage"].(map[string]interface{})
+	data, err := yaml.Marshal(storage["lifecycle"])
+	if err != nil {
+		return Policy{}, err
+	}
+	var policy Policy
+	return policy, yaml.Unmarshal(data, &policy)
+}
+
+// Expected returns the rule the storage module derives from the policy for
+// the bucket of layer: raw, processed or curated
+func (p Policy) Expected(layer string) (Rule, error) {
+	rule := Rule{ID: layer + "_data_lifecycle", Enabled: true, ExpirationDays: p.ExpirationDays, NoncurrentExpirationDays: 30}
+	switch layer {
+	case "raw":
+		rule.Transitions = []Transition{
+			{Days: p.TransitionIADays, StorageClass: "STANDARD_IA"},
+			{Days: p.TransitionGlacierDays, StorageClass: "GLACIER"},
+			{Days: p.TransitionDeepArchiveDays, StorageClass: "DEEP_ARCHIVE"},
+		}
+	case "processed":
+		rule.Transitions = []Transition{
+			{Days: p.TransitionIADays, StorageClass: "STANDARD_IA"},
+			{Days: p.TransitionGlacierDays, StorageClass: "GLACIER"},
+		}
+	case "curated":
+		// Curated data stays in each class twice as long and its versions three times
+		rule.Transitions = []Transition{
+			{Days: p.TransitionIADays * 2, StorageClass: "STANDARD_IA"},
+			{Days: p.TransitionGlacierDays * 2, StorageClass: "GLACIER"},
+		}
+		rule.NoncurrentExpirationDays = 90
+	default:
+		return Rule{}, fmt.Errorf("unknown data lake layer %q", layer)
+	}
+	return rule, nil
+}
@@ -0,0 +1,201 @@
+package lifecycle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromS3(t *testing.T) {
+	rules := FromS3([]s3types.LifecycleRule{
+		{
+			ID:     aws.String("raw_data_lifecycle"),
+			Status: s3types.ExpirationStatusEnabled,
+			Filter: &s3types.LifecycleRuleFilter{Prefix: aws.String("")},
+			Transitions: []s3types.Transition{
+				{Days: aws.Int32(90), StorageClass: s3types.TransitionStorageClassGlacier},
+				{Days: aws.Int32(30), StorageClass: s3types.TransitionStorageClassStandardIa},
+			},
+			Expiration:                  &s3types.LifecycleExpiration{Days: aws.Int32(365)},
+			NoncurrentVersionExpiration: &s3types.NoncurrentVersionExpiration{NoncurrentDays: aws.Int32(30)},
+		},
+		{
+			ID:     aws.String("tagged"),
+			Status: s3types.ExpirationStatusDisabled,
+			Filter: &s3types.LifecycleRuleFilter{And: &s3types.LifecycleRuleAndOperator{
+				Prefix: aws.String("logs/"),
+				Tags:   []s3types.Tag{{Key: aws.String("retain"), Value: aws.String("false")}},
+			}},
+		},
+	})
+
+	assert.Equal(t, []Rule{
+		{
+			ID:      "raw_data_lifecycle",
+			Enabled: true,
+			Transitions: []Transition{
+				{Days: 30, StorageClass: "STANDARD_IA"},
+				{Days: 90, StorageClass: "GLACIER"},
+			},
+			ExpirationDays:           365,
+			NoncurrentExpirationDays: 30,
+		},
+		{ID: "tagged", Prefix: "logs/", Filtered: true},
+	}, rules)
+}
+
+func TestCheck(t *testing.T) {
+	valid := Rule{ID: "raw", Enabled: true, ExpirationDays: 730, Transitions: []Transition{
+		{Days: 30, StorageClass: "STANDARD_IA"},
+		{Days: 90, StorageClass: "GLACIER"},
+		{Days: 365, StorageClass: "DEEP_ARCHIVE"},
+	}}
+	assert.Empty(t, Check(valid))
+
+	// Glacier before infrequent access sends objects back to a warmer class
+	misordered := Rule{ID: "raw", Enabled: true, Transitions: []Transition{
+		{Days: 60, StorageClass: "GLACIER"},
+		{Days: 90, StorageClass: "STANDARD_IA"},
+	}}
+	assert.Equal(t, []string{"rule raw moves objects to STANDARD_IA at 90 days, which is not colder than GLACIER at 60 days"}, Check(misordered))
+
+	early := Rule{ID: "dev", Enabled: true, ExpirationDays: 20, Transitions: []Transition{{Days: 7, StorageClass: "STANDARD_IA"}, {Days: 30, StorageClass: "GLACIER"}}}
+	assert.Equal(t, []string{
+		"rule dev moves objects to STANDARD_IA at 7 days, below the 30 day minimum",
+		"rule dev expires objects at 20 days, before they move to GLACIER at 30 days",
+	}, Check(early))
+
+	unknown := Rule{ID: "odd", Transitions: []Transition{{Days: 30, StorageClass: "REDUCED_REDUNDANCY"}}}
+	assert.Equal(t, []string{"rule odd transitions to unknown storage class REDUCED_REDUNDANCY"}, Check(unknown))
+}
+
+func TestSimulate(t *testing.T) {
+	rules := []Rule{{ID: "raw", Enabled: true, ExpirationDays: 730, Transitions: []Transition{
+		{Days: 30, StorageClass: "STANDARD_IA"},
+		{Days: 90, StorageClass: "GLACIER"},
+		{Days: 365, StorageClass: "DEEP_ARCHIVE"},
+	}}}
+
+	tests := []struct {
+		age  int32
+		want Fate
+	}{
+		{0, Fate{StorageClass: "STANDARD"}},
+		{29, Fate{StorageClass: "STANDARD"}},
+		{30, Fate{StorageClass: "STANDARD_IA"}},
+		{100, Fate{StorageClass: "GLACIER"}},
+		{365, Fate{StorageClass: "DEEP_ARCHIVE"}},
+		{730, Fate{StorageClass: "DEEP_ARCHIVE", Expired: true}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Simulate(rules, "year=2024/events.json", tt.age), "age %d", tt.age)
+	}
+}
+
+func TestSimulateKeepsColdestClass(t *testing.T) {
+	// A misordered rule leaves objects in Glacier rather than moving them back
+	rules := []Rule{{ID: "curated", Enabled: true, Transitions: []Transition{
+		{Days: 60, StorageClass: "GLACIER"},
+		{Days: 90, StorageClass: "STANDARD_IA"},
+	}}}
+	assert.Equal(t, "GLACIER", Simulate(rules, "a", 120).StorageClass)
+
+	// Rules that are disabled, filtered or under another prefix do not apply
+	rules = []Rule{
+		{ID: "off", Transitions: []Transition{{Days: 1, StorageClass: "GLACIER"}}},
+		{ID: "tagged", Enabled: true, Filtered: true, Transitions: []Transition{{Days: 1, StorageClass: "GLACIER"}}},
+		{ID: "logs", Enabled: true, Prefix: "logs/", ExpirationDays: 1},
+	}
+	assert.Equal(t, Fate{StorageClass: "STANDARD"}, Simulate(rules, "data/a", 400))
+	assert.Equal(t, Fate{StorageClass: "STANDARD", Expired: true}, Simulate(rules, "logs/a", 400))
+}
+
+func TestUncovered(t *testing.T) {
+	rules := []Rule{
+		{ID: "logs", Enabled: true, Prefix: "logs/"},
+		{ID: "tagged", Enabled: true, Prefix: "data/", Filtered: true},
+	}
+	assert.Equal(t, []string{"", "data/"}, Uncovered(rules, "", "logs/", "logs/app/", "data/"))
+	assert.Empty(t, Uncovered([]Rule{{ID: "all", Enabled: true}}, "", "data/"))
+}
+
+func TestExpected(t *testing.T) {
+	policy := Policy{TransitionIADays: 90, TransitionGlacierDays: 210, TransitionDeepArchiveDays: 365, ExpirationDays: 730}
+
+	curated, err := policy.Expected("curated")
+	require.NoError(t, err)
+	assert.Equal(t, Rule{
+		ID:      "curated_data_lifecycle",
+		Enabled: true,
+		Transitions: []Transition{
+			{Days: 180, StorageClass: "STANDARD_IA"},
+			{Days: 420, StorageClass: "GLACIER"},
+		},
+		ExpirationDays:           730,
+		NoncurrentExpirationDays: 90,
+	}, curated)
+
+	for _, layer := range []string{"raw", "processed", "curated"} {
+		rule, err := policy.Expected(layer)
+		require.NoError(t, err)
+		assert.Empty(t, Check(rule), layer)
+	}
+
+	_, err = policy.Expected("bronze")
+	assert.Error(t, err)
+}
+
+func TestLoadPolicy(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "config", "environments"), 0o755))
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(root, "config", name), []byte(content), 0o644))
+	}
+	write("common.yaml", `
+storage:
+  lifecycle:
+    transition_ia_days: 30
+    transition_glacier_days: 90
+    transition_deep_archive_days: 365
+    expiration_days: 2555
+`)
+	write("environments/prod.yaml", "networking:\n  vpc_cidr: 10.0.0.0/16\n")
+	write("environments/dev.yaml", `
+storage:
+  lifecycle:
+    transition_ia_days: 30
+    expiration_days: 365
+`)
+
+	prod, err := LoadPolicy(root, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, Policy{TransitionIADays: 30, TransitionGlacierDays: 90, TransitionDeepArchiveDays: 365, ExpirationDays: 2555}, prod)
+
+	// The environment's storage section replaces the default one entirely
+	dev, err := LoadPolicy(root, "dev")
+	require.NoError(t, err)
+	assert.Equal(t, Policy{TransitionIADays: 30, ExpirationDays: 365}, dev)
+
+	_, err = LoadPolicy(root, "staging")
+	assert.Error(t, err)
+}
+
+func TestPolicyFromVars(t *testing.T) {
+	policy, err := PolicyFromVars(map[string]interface{}{
+		"storage": map[string]interface{}{
+			"lifecycle": map[string]interface{}{
+				"transition_ia_days":           90,
+				"transition_glacier_days":      210,
+				"transition_deep_archive_days": 365,
+				"expiration_days":              730,
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Policy{TransitionIADays: 90, TransitionGlacierDays: 210, TransitionDeepArchiveDays: 365, ExpirationDays: 730}, policy)
+}
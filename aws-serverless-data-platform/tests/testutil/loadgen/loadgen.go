@@ -0,0 +1,206 @@
+// =============================================================================
+// Kinesis Load Generation
+// Pushes a fixed record rate at a stream and measures how it copes
+// =============================================================================
+
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+)
+
+const (
+	// MaxBatchSize is the most records one PutRecords call accepts
+	MaxBatchSize = 500
+
+	// MaxPayloadSize is the largest record Kinesis accepts
+	MaxPayloadSize = 1024 * 1024
+
+	// ThrottledErrorCode marks a record rejected because its shard was over capacity
+	ThrottledErrorCode = "ProvisionedThroughputExceededException"
+
+	// tickInterval is how often the generator schedules the records that are due
+	tickInterval = 100 * time.Millisecond
+)
+
+// Putter is the part of the Kinesis client the generator uses
+type Putter interface {
+	PutRecords(ctx context.Context, params *kinesis.PutRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error)
+}
+
+// Config describes the load to generate
+type Config struct {
+	// StreamName is the stream records are put to
+	StreamName string
+
+	// Rate is the number of records offered per second
+	Rate int
+
+	// Duration is how long to keep offering records
+	Duration time.Duration
+
+	// MinPayload and MaxPayload bound the uniformly distributed record size in bytes
+	MinPayload int
+	MaxPayload int
+
+	// BatchSize is the number of records per PutRecords call
+	BatchSize int
+
+	// Concurrency is the number of PutRecords calls in flight
+	Concurrency int
+}
+
+// DefaultConfig offers 1,000 records/sec of 0.5-4 KiB for five minutes
+func DefaultConfig(streamName string) Config {
+	return Config{
+		StreamName:  streamName,
+		Rate:        1000,
+		Duration:    5 * time.Minute,
+		MinPayload:  512,
+		MaxPayload:  4096,
+		BatchSize:   250,
+		Concurrency: 8,
+	}
+}
+
+// Validate rejects configurations Kinesis or the generator cannot honour
+func (c Config) Validate() error {
+	switch {
+	case c.StreamName == "":
+		return fmt.Errorf("a stream name is required")
+	case c.Rate <= 0:
+		return fmt.Errorf("rate must be positive, got %d", c.Rate)
+	case c.Duration <= 0:
+		return fmt.Errorf("duration must be positive, got %s", c.Duration)
+	case c.MinPayload < minPayloadSize || c.MinPayload > c.MaxPayload:
+		return fmt.Errorf("payload sizes must satisfy %d <= min (%d) <= max (%d)", minPayloadSize, c.MinPayload, c.MaxPayload)
+	case c.MaxPayload > MaxPayloadSize:
+		return fmt.Errorf("max payload %d exceeds the %d byte record limit", c.MaxPayload, MaxPayloadSize)
+	case c.BatchSize <= 0 || c.BatchSize > MaxBatchSize:
+		return fmt.Errorf("batch size must be between 1 and %d, got %d", MaxBatchSize, c.BatchSize)
+	case c.Concurrency <= 0:
+		return fmt.Errorf("concurrency must be positive, got %d", c.Concurrency)
+	}
+	return nil
+}
+
+// AveragePayload is the mean record size the configuration produces
+func (c Config) AveragePayload() float64 {
+	return float64(c.MinPayload+c.MaxPayload) / 2
+}
+
+// batch is a run of consecutive sequence numbers to put in one call
+type batch struct {
+	first int64
+	count int
+}
+
+// Run offers cfg.Rate records per second to the stream for cfg.Duration and
+// returns what happened. Throttled records are counted, not retried, so the
+// result reflects the stream's real capacity. Cancelling ctx stops the run early.
+func Run(ctx context.Context, client Putter, cfg Config) (*Result, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	recorder := &recorder{}
+	batches := make(chan batch, cfg.Concurrency)
+	started := time.Now()
+
+	var workers sync.WaitGroup
+	for i := 0; i < cfg.Concurrency; i++ {
+		workers.Add(1)
+		go func(seed int64) {
+			defer workers.Done()
+			random := rand.New(rand.NewSource(seed))
+			for b := range batches {
+				put(ctx, client, cfg, random, b, recorder)
+			}
+		}(started.UnixNano() + int64(i))
+	}
+
+	schedule(ctx, cfg, started, batches)
+	close(batches)
+	workers.Wait()
+
+	return recorder.result(cfg, started, time.Since(started)), nil
+}
+
+// schedule emits the records due at each tick until the duration elapses. When
+// the workers fall behind, sends block and the achieved rate drops below cfg.Rate.
+func schedule(ctx context.Context, cfg Config, started time.Time, batches chan<- batch) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	var scheduled int64
+	for {
+		elapsed := time.Since(started)
+		if elapsed > cfg.Duration {
+			elapsed = cfg.Duration
+		}
+
+		due := int64(elapsed.Seconds() * float64(cfg.Rate))
+		for scheduled < due {
+			count := int(min(due-scheduled, int64(cfg.BatchSize)))
+			select {
+			case batches <- batch{first: scheduled, count: count}:
+				scheduled += int64(count)
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if elapsed >= cfg.Duration {
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// put sends one batch and records its latency and per-record outcome
+func put(ctx context.Context, client Putter, cfg Config, random *rand.Rand, b batch, recorder *recorder) {
+	entries := make([]kinesistypes.PutRecordsRequestEntry, b.count)
+	var size int64
+	for i := range entries {
+		payload := Payload(b.first+int64(i), PayloadSize(random, cfg.MinPayload, cfg.MaxPayload))
+		size += int64(len(payload))
+		entries[i] = kinesistypes.PutRecordsRequestEntry{
+			Data:         payload,
+			PartitionKey: awssdk.String(strconv.FormatUint(random.Uint64(), 16)),
+		}
+	}
+
+	start := time.Now()
+	output, err := client.PutRecords(ctx, &kinesis.PutRecordsInput{
+		StreamName: awssdk.String(cfg.StreamName),
+		Records:    entries,
+	})
+	latency := time.Since(start)
+
+	if err != nil {
+		recorder.callFailed(b.count, err)
+		return
+	}
+	recorder.callSucceeded(latency, entries, output.Records)
+}
+
+// PayloadSize picks a uniformly distributed size in [minSize, maxSize]
+func PayloadSize(random *rand.Rand, minSize, maxSize int) int {
+	if maxSize <= minSize {
+		return minSize
+	}
+	return minSize + random.Intn(maxSize-minSize+1)
+}
@@ -0,0 +1,153 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStream accepts records and throttles every throttleEvery-th one it sees
+type fakeStream struct {
+	mu            sync.Mutex
+	seen          int
+	throttleEvery int
+	sizes         []int
+}
+
+func (f *fakeStream) PutRecords(ctx context.Context, params *kinesis.PutRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	output := &kinesis.PutRecordsOutput{}
+	for _, record := range params.Records {
+		f.seen++
+		f.sizes = append(f.sizes, len(record.Data))
+
+		entry := kinesistypes.PutRecordsResultEntry{SequenceNumber: awssdk.String("1")}
+		if f.throttleEvery > 0 && f.seen%f.throttleEvery == 0 {
+			entry = kinesistypes.PutRecordsResultEntry{ErrorCode: awssdk.String(ThrottledErrorCode)}
+		}
+		output.Records = append(output.Records, entry)
+	}
+	return output, nil
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.NoError(t, DefaultConfig("ingestion").Validate())
+
+	invalid := map[string]func(*Config){
+		"no stream":         func(c *Config) { c.StreamName = "" },
+		"zero rate":         func(c *Config) { c.Rate = 0 },
+		"min above max":     func(c *Config) { c.MinPayload = c.MaxPayload + 1 },
+		"payload too small": func(c *Config) { c.MinPayload = 10 },
+		"payload too large": func(c *Config) { c.MaxPayload = MaxPayloadSize + 1 },
+		"batch too large":   func(c *Config) { c.BatchSize = MaxBatchSize + 1 },
+		"no concurrency":    func(c *Config) { c.Concurrency = 0 },
+		"non-positive dur":  func(c *Config) { c.Duration = 0 },
+	}
+	for name, mutate := range invalid {
+		cfg := DefaultConfig("ingestion")
+		mutate(&cfg)
+		assert.Error(t, cfg.Validate(), name)
+	}
+}
+
+func TestPayload(t *testing.T) {
+	for _, size := range []int{minPayloadSize, 1000, 4096} {
+		payload := Payload(42, size)
+		assert.Len(t, payload, size)
+
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal(payload, &event), "Payload must be valid JSON")
+		assert.Equal(t, float64(42), event["sequence"])
+		assert.NotEmpty(t, event["event_time"])
+	}
+
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		size := PayloadSize(random, 200, 300)
+		assert.True(t, size >= 200 && size <= 300, "size %d out of range", size)
+	}
+}
+
+func TestNewLatency(t *testing.T) {
+	var samples []time.Duration
+	for i := 100; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+
+	latency := NewLatency(samples)
+	assert.Equal(t, 50*time.Millisecond, latency.P50)
+	assert.Equal(t, 90*time.Millisecond, latency.P90)
+	assert.Equal(t, 99*time.Millisecond, latency.P99)
+	assert.Equal(t, 100*time.Millisecond, latency.Max)
+	assert.Equal(t, Latency{}, NewLatency(nil))
+}
+
+func TestRequiredShards(t *testing.T) {
+	assert.Equal(t, 1, RequiredShards(10, 1024))
+	assert.Equal(t, 3, RequiredShards(2500, 1024), "Record limit binds")
+	assert.Equal(t, 4, RequiredShards(1000, 3.5*ShardBytesPerSecond), "Byte limit binds")
+}
+
+func TestRun(t *testing.T) {
+	stream := &fakeStream{throttleEvery: 10}
+	cfg := DefaultConfig("ingestion")
+	cfg.Rate = 2000
+	cfg.Duration = 500 * time.Millisecond
+	cfg.MinPayload, cfg.MaxPayload = 200, 400
+	cfg.BatchSize = 100
+
+	result, err := Run(context.Background(), stream, cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1000), result.Offered, "Every due record is offered by the end of the run")
+	assert.Equal(t, result.Offered, result.Accepted+result.Throttled+result.Failed)
+	assert.Equal(t, int64(100), result.Throttled)
+	assert.GreaterOrEqual(t, result.Calls, 10)
+	assert.InDelta(t, 0.1, result.ThrottleRate(), 0.001)
+	assert.Positive(t, result.AcceptedBytes)
+
+	for _, size := range stream.sizes {
+		assert.True(t, size >= 200 && size <= 400, "record of %d bytes", size)
+	}
+}
+
+func TestReport(t *testing.T) {
+	cfg := DefaultConfig("ingestion")
+	result := &Result{
+		Config:        cfg,
+		Elapsed:       cfg.Duration,
+		Offered:       int64(cfg.Rate) * int64(cfg.Duration.Seconds()),
+		AcceptedBytes: 1 << 20,
+		Latency:       Latency{P99: 200 * time.Millisecond},
+	}
+	result.Accepted = result.Offered
+
+	report := NewReport(result, &StreamMetrics{OpenShards: 4}, DefaultThresholds())
+	assert.True(t, report.Passed(), "Problems: %v", report.Problems)
+	assert.Equal(t, 3, report.RequiredShards)
+
+	var text bytes.Buffer
+	require.NoError(t, report.WriteText(&text))
+	assert.Contains(t, text.String(), "✅ Stream sized for the offered load")
+
+	result.Throttled, result.Accepted = result.Offered/10, result.Offered-result.Offered/10
+	report = NewReport(result, &StreamMetrics{OpenShards: 2, MaxIteratorAge: 5 * time.Minute}, DefaultThresholds())
+	assert.False(t, report.Passed())
+	assert.Len(t, report.Problems, 3, "Throttling, shard count and iterator age: %v", report.Problems)
+
+	var encoded bytes.Buffer
+	require.NoError(t, report.WriteJSON(&encoded))
+	assert.Contains(t, encoded.String(), `"required_shards": 3`)
+}
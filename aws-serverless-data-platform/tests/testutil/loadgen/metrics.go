@@ -0,0 +1,116 @@
+// =============================================================================
+// Stream Metrics
+// Shard count and CloudWatch metrics observed over a load test
+// =============================================================================
+
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+)
+
+// metricsPeriod is the granularity of the CloudWatch statistics
+const metricsPeriod = 60
+
+// StreamMetrics is what the stream itself reported during the run
+type StreamMetrics struct {
+	// OpenShards is the stream's shard count when the metrics were read
+	OpenShards int `json:"open_shards"`
+
+	// MaxIteratorAge is the worst consumer lag; zero when nothing read the stream
+	MaxIteratorAge time.Duration `json:"max_iterator_age"`
+
+	// WriteThrottles is the number of records CloudWatch counted as throttled
+	WriteThrottles float64 `json:"write_throttles"`
+
+	// IncomingRecords and IncomingBytes are the stream's totals over the window
+	IncomingRecords float64 `json:"incoming_records"`
+	IncomingBytes   float64 `json:"incoming_bytes"`
+}
+
+// metricQueries maps the GetMetricData query IDs to a Kinesis metric and statistic
+var metricQueries = []struct {
+	id, metric, stat string
+}{
+	{"iterator_age", "GetRecords.IteratorAgeMilliseconds", "Maximum"},
+	{"write_throttles", "WriteProvisionedThroughputExceeded", "Sum"},
+	{"incoming_records", "IncomingRecords", "Sum"},
+	{"incoming_bytes", "IncomingBytes", "Sum"},
+}
+
+// FetchMetrics reads the shard count and the stream's CloudWatch metrics between
+// start and end. CloudWatch lags by a minute or two, so call it a little after the run.
+func FetchMetrics(ctx context.Context, kinesisClient *kinesis.Client, cwClient *cloudwatch.Client, stream string, start, end time.Time) (*StreamMetrics, error) {
+	summary, err := kinesisClient.DescribeStreamSummary(ctx, &kinesis.DescribeStreamSummaryInput{
+		StreamName: awssdk.String(stream),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe stream %s: %w", stream, err)
+	}
+
+	queries := make([]cwtypes.MetricDataQuery, len(metricQueries))
+	for i, q := range metricQueries {
+		queries[i] = cwtypes.MetricDataQuery{
+			Id: awssdk.String(q.id),
+			MetricStat: &cwtypes.MetricStat{
+				Metric: &cwtypes.Metric{
+					Namespace:  awssdk.String("AWS/Kinesis"),
+					MetricName: awssdk.String(q.metric),
+					Dimensions: []cwtypes.Dimension{{Name: awssdk.String("StreamName"), Value: awssdk.String(stream)}},
+				},
+				Period: awssdk.Int32(metricsPeriod),
+				Stat:   awssdk.String(q.stat),
+			},
+		}
+	}
+
+	values := map[string][]float64{}
+	paginator := cloudwatch.NewGetMetricDataPaginator(cwClient, &cloudwatch.GetMetricDataInput{
+		MetricDataQueries: queries,
+		StartTime:         awssdk.Time(start.Truncate(time.Minute)),
+		EndTime:           awssdk.Time(end.Truncate(time.Minute).Add(time.Minute)),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CloudWatch metrics for %s: %w", stream, err)
+		}
+		for _, result := range page.MetricDataResults {
+			id := awssdk.ToString(result.Id)
+			values[id] = append(values[id], result.Values...)
+		}
+	}
+
+	return &StreamMetrics{
+		OpenShards:      int(awssdk.ToInt32(summary.StreamDescriptionSummary.OpenShardCount)),
+		MaxIteratorAge:  time.Duration(maxOf(values["iterator_age"])) * time.Millisecond,
+		WriteThrottles:  sumOf(values["write_throttles"]),
+		IncomingRecords: sumOf(values["incoming_records"]),
+		IncomingBytes:   sumOf(values["incoming_bytes"]),
+	}, nil
+}
+
+// maxOf returns the largest value, or zero
+func maxOf(values []float64) float64 {
+	var largest float64
+	for _, v := range values {
+		largest = max(largest, v)
+	}
+	return largest
+}
+
+// sumOf returns the total of values
+func sumOf(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
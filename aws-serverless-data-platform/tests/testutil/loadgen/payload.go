@@ -0,0 +1,28 @@
+// =============================================================================
+// Synthetic Records
+// JSON events padded to an exact size
+// =============================================================================
+
+package loadgen
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// minPayloadSize leaves room for the event fields before any padding
+const minPayloadSize = 128
+
+// Payload returns a JSON event of exactly size bytes carrying the sequence
+// number and the time it was generated, so consumers can measure end-to-end lag
+func Payload(sequence int64, size int) []byte {
+	header := fmt.Sprintf(`{"sequence":%d,"event_time":%q,"padding":"`, sequence, time.Now().UTC().Format(time.RFC3339Nano))
+	const footer = `"}`
+
+	padding := size - len(header) - len(footer)
+	if padding < 0 {
+		padding = 0
+	}
+	return []byte(header + strings.Repeat("x", padding) + footer)
+}
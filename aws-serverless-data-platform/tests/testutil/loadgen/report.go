@@ -0,0 +1,153 @@
+// =============================================================================
+// Benchmark Report
+// Shard sizing verdict for a load test, as text or JSON
+// =============================================================================
+
+package loadgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	// ShardRecordsPerSecond is the write capacity of one provisioned shard in records
+	ShardRecordsPerSecond = 1000
+
+	// ShardBytesPerSecond is the write capacity of one provisioned shard in bytes
+	ShardBytesPerSecond = 1024 * 1024
+)
+
+// Thresholds are the limits a stream must stay within to pass
+type Thresholds struct {
+	// MaxThrottleRate is the largest tolerated fraction of throttled records
+	MaxThrottleRate float64 `json:"max_throttle_rate"`
+
+	// MaxP99Latency bounds the 99th percentile PutRecords latency
+	MaxP99Latency time.Duration `json:"max_p99_latency"`
+
+	// MaxIteratorAge bounds consumer lag; zero disables the check
+	MaxIteratorAge time.Duration `json:"max_iterator_age"`
+}
+
+// DefaultThresholds tolerate 1% throttling, a one second p99 and a minute of consumer lag
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		MaxThrottleRate: 0.01,
+		MaxP99Latency:   time.Second,
+		MaxIteratorAge:  time.Minute,
+	}
+}
+
+// RequiredShards is the number of provisioned shards needed to absorb the
+// given write rate, whichever of the record or byte limits binds first
+func RequiredShards(recordsPerSecond, bytesPerSecond float64) int {
+	shards := math.Max(recordsPerSecond/ShardRecordsPerSecond, bytesPerSecond/ShardBytesPerSecond)
+	return max(int(math.Ceil(shards)), 1)
+}
+
+// Report combines a run's result with the stream's metrics and a verdict
+type Report struct {
+	Result     *Result        `json:"result"`
+	Metrics    *StreamMetrics `json:"metrics,omitempty"`
+	Thresholds Thresholds     `json:"thresholds"`
+
+	// RequiredShards is the shard count the offered load needs
+	RequiredShards int `json:"required_shards"`
+
+	// Problems lists every threshold the run breached; empty means it passed
+	Problems []string `json:"problems"`
+}
+
+// NewReport evaluates result and metrics, which may be nil, against thresholds
+func NewReport(result *Result, metrics *StreamMetrics, thresholds Thresholds) *Report {
+	cfg := result.Config
+	report := &Report{
+		Result:         result,
+		Metrics:        metrics,
+		Thresholds:     thresholds,
+		RequiredShards: RequiredShards(float64(cfg.Rate), float64(cfg.Rate)*cfg.AveragePayload()),
+		Problems:       []string{},
+	}
+
+	if rate := result.ThrottleRate(); rate > thresholds.MaxThrottleRate {
+		report.fail("%.2f%% of records were throttled, above the %.2f%% limit", rate*100, thresholds.MaxThrottleRate*100)
+	}
+	if result.Failed > 0 {
+		report.fail("%d records failed: %s", result.Failed, result.LastError)
+	}
+	if p99 := result.Latency.P99; thresholds.MaxP99Latency > 0 && p99 > thresholds.MaxP99Latency {
+		report.fail("p99 put latency %s exceeds %s", p99, thresholds.MaxP99Latency)
+	}
+	if offered := perSecond(result.Offered, result.Elapsed); offered < 0.95*float64(cfg.Rate) {
+		report.fail("only %.0f records/sec were offered of the %d requested; raise the concurrency", offered, cfg.Rate)
+	}
+
+	if metrics != nil {
+		if metrics.OpenShards < report.RequiredShards {
+			report.fail("stream has %d shards but the load needs %d", metrics.OpenShards, report.RequiredShards)
+		}
+		if age := metrics.MaxIteratorAge; thresholds.MaxIteratorAge > 0 && age > thresholds.MaxIteratorAge {
+			report.fail("consumer iterator age reached %s, above %s", age, thresholds.MaxIteratorAge)
+		}
+	}
+	return report
+}
+
+// fail records a breached threshold
+func (r *Report) fail(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// Passed reports whether every threshold was met
+func (r *Report) Passed() bool {
+	return len(r.Problems) == 0
+}
+
+// WriteText writes a human-readable summary
+func (r *Report) WriteText(w io.Writer) error {
+	result, cfg := r.Result, r.Result.Config
+
+	lines := []string{
+		fmt.Sprintf("Stream:          %s", cfg.StreamName),
+		fmt.Sprintf("Offered load:    %d records/sec of %d-%d bytes for %s", cfg.Rate, cfg.MinPayload, cfg.MaxPayload, cfg.Duration),
+		fmt.Sprintf("Records:         %d offered, %d accepted, %d throttled, %d failed", result.Offered, result.Accepted, result.Throttled, result.Failed),
+		fmt.Sprintf("Throughput:      %.0f records/sec, %.1f KiB/sec accepted", result.RecordsPerSecond(), result.BytesPerSecond()/1024),
+		fmt.Sprintf("Throttle rate:   %.2f%%", result.ThrottleRate()*100),
+		fmt.Sprintf("Put latency:     p50 %s, p90 %s, p99 %s, max %s over %d calls",
+			result.Latency.P50, result.Latency.P90, result.Latency.P99, result.Latency.Max, result.Calls),
+		fmt.Sprintf("Required shards: %d", r.RequiredShards),
+	}
+	if m := r.Metrics; m != nil {
+		lines = append(lines,
+			fmt.Sprintf("Open shards:     %d", m.OpenShards),
+			fmt.Sprintf("Iterator age:    %s max", m.MaxIteratorAge),
+			fmt.Sprintf("CloudWatch:      %.0f records in, %.0f write throttles", m.IncomingRecords, m.WriteThrottles),
+		)
+	}
+
+	if r.Passed() {
+		lines = append(lines, "✅ Stream sized for the offered load")
+	} else {
+		for _, problem := range r.Problems {
+			lines = append(lines, "❌ "+problem)
+		}
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the report for archiving alongside promotion records
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
@@ -0,0 +1,168 @@
+// =============================================================================
+// Load Test Results
+// Put latency, throughput and throttling collected during a run
+// =============================================================================
+
+package loadgen
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/aws/smithy-go"
+)
+
+// Latency summarizes PutRecords call latencies
+type Latency struct {
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P99 time.Duration `json:"p99"`
+	Max time.Duration `json:"max"`
+}
+
+// NewLatency computes the percentiles of samples
+func NewLatency(samples []time.Duration) Latency {
+	if len(samples) == 0 {
+		return Latency{}
+	}
+
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return Latency{
+		P50: percentile(sorted, 0.50),
+		P90: percentile(sorted, 0.90),
+		P99: percentile(sorted, 0.99),
+		Max: sorted[len(sorted)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted samples
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// Result is what a run offered and what the stream accepted
+type Result struct {
+	Config  Config        `json:"config"`
+	Started time.Time     `json:"started"`
+	Elapsed time.Duration `json:"elapsed"`
+
+	// Offered counts every record sent; Accepted, Throttled and Failed partition it
+	Offered   int64 `json:"offered"`
+	Accepted  int64 `json:"accepted"`
+	Throttled int64 `json:"throttled"`
+	Failed    int64 `json:"failed"`
+
+	// AcceptedBytes is the payload volume the stream accepted
+	AcceptedBytes int64 `json:"accepted_bytes"`
+
+	// Calls and CallErrors count PutRecords requests and those that failed outright
+	Calls      int    `json:"calls"`
+	CallErrors int    `json:"call_errors"`
+	LastError  string `json:"last_error,omitempty"`
+
+	Latency Latency `json:"latency"`
+}
+
+// RecordsPerSecond is the accepted record rate
+func (r *Result) RecordsPerSecond() float64 {
+	return perSecond(r.Accepted, r.Elapsed)
+}
+
+// BytesPerSecond is the accepted payload rate
+func (r *Result) BytesPerSecond() float64 {
+	return perSecond(r.AcceptedBytes, r.Elapsed)
+}
+
+// ThrottleRate is the fraction of offered records rejected for exceeding shard capacity
+func (r *Result) ThrottleRate() float64 {
+	if r.Offered == 0 {
+		return 0
+	}
+	return float64(r.Throttled) / float64(r.Offered)
+}
+
+// perSecond divides count by elapsed seconds
+func perSecond(count int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(count) / elapsed.Seconds()
+}
+
+// recorder accumulates outcomes from concurrent workers
+type recorder struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	totals    Result
+}
+
+// callSucceeded records a completed call and the outcome of each of its records
+func (r *recorder) callSucceeded(latency time.Duration, entries []kinesistypes.PutRecordsRequestEntry, results []kinesistypes.PutRecordsResultEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.latencies = append(r.latencies, latency)
+	r.totals.Calls++
+	r.totals.Offered += int64(len(entries))
+
+	for i, entry := range entries {
+		var code string
+		if i < len(results) {
+			code = awssdk.ToString(results[i].ErrorCode)
+		}
+
+		switch code {
+		case "":
+			r.totals.Accepted++
+			r.totals.AcceptedBytes += int64(len(entry.Data))
+		case ThrottledErrorCode:
+			r.totals.Throttled++
+		default:
+			r.totals.Failed++
+			r.totals.LastError = code + ": " + awssdk.ToString(results[i].ErrorMessage)
+		}
+	}
+}
+
+// callFailed records a call whose records were all rejected, as throttled when
+// the whole request was
+func (r *recorder) callFailed(records int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.totals.Calls++
+	r.totals.CallErrors++
+	r.totals.Offered += int64(records)
+	r.totals.LastError = err.Error()
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == ThrottledErrorCode {
+		r.totals.Throttled += int64(records)
+		return
+	}
+	r.totals.Failed += int64(records)
+}
+
+// result snapshots the totals
+func (r *recorder) result(cfg Config, started time.Time, elapsed time.Duration) *Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := r.totals
+	result.Config = cfg
+	result.Started = started
+	result.Elapsed = elapsed
+	result.Latency = NewLatency(r.latencies)
+	return &result
+}
@@ -0,0 +1,182 @@
+// =============================================================================
+// Structured Test Logging
+// Levelled key/value logging through t.Log with optional JSON output
+// =============================================================================
+
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// LevelEnvVar sets the lowest level logged (debug, info, warn); info by default
+const LevelEnvVar = "TERRATEST_LOG_LEVEL"
+
+// FormatEnvVar selects the output format, text by default or json for one
+// object per line that CI can parse
+const FormatEnvVar = "TERRATEST_LOG_FORMAT"
+
+// Level orders log entries by importance
+type Level int
+
+// Log levels
+const (
+	Debug Level = iota
+	Info
+	Warn
+)
+
+// String returns the level name used in LevelEnvVar and JSON output
+func (l Level) String() string {
+	switch l {
+	case Debug:
+		return "debug"
+	case Warn:
+		return "warn"
+	default:
+		return "info"
+	}
+}
+
+// ParseLevel returns the level named s; an empty name is Info
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return Debug, nil
+	case "", "info":
+		return Info, nil
+	case "warn", "warning":
+		return Warn, nil
+	}
+	return Info, fmt.Errorf("unknown log level %q", s)
+}
+
+// Logger writes entries to a test's log with the fields added by With; the
+// zero value is not usable, use New
+type Logger struct {
+	t      testing.TB
+	level  Level
+	json   bool
+	fields []interface{}
+}
+
+// New returns a logger for t configured from LevelEnvVar and FormatEnvVar,
+// with optional key/value fields added to every entry
+func New(t testing.TB, keyvals ...interface{}) *Logger {
+	level, err := ParseLevel(os.Getenv(LevelEnvVar))
+	if err != nil {
+		t.Logf("⚠️ %v, logging at info", err)
+	}
+	return &Logger{
+		t:      t,
+		level:  level,
+		json:   strings.EqualFold(os.Getenv(FormatEnvVar), "json"),
+		fields: keyvals,
+	}
+}
+
+// With returns a logger that adds keyvals to every entry
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	child := *l
+	child.fields = append(append([]interface{}(nil), l.fields...), keyvals...)
+	return &child
+}
+
+// Debug logs detail only wanted when troubleshooting
+func (l *Logger) Debug(msg string, keyvals ...interface{}) {
+	l.t.Helper()
+	l.log(Debug, "", msg, keyvals)
+}
+
+// Info logs progress
+func (l *Logger) Info(msg string, keyvals ...interface{}) {
+	l.t.Helper()
+	l.log(Info, "", msg, keyvals)
+}
+
+// Success logs a check that passed
+func (l *Logger) Success(msg string, keyvals ...interface{}) {
+	l.t.Helper()
+	l.log(Info, "✅", msg, keyvals)
+}
+
+// Warn logs a problem that does not fail the test, such as a failed cleanup
+func (l *Logger) Warn(msg string, keyvals ...interface{}) {
+	l.t.Helper()
+	l.log(Warn, "⚠️", msg, keyvals)
+}
+
+// log writes one entry when level is at least the configured level
+func (l *Logger) log(level Level, icon, msg string, keyvals []interface{}) {
+	l.t.Helper()
+	if level < l.level {
+		return
+	}
+
+	fields := append(append([]interface{}(nil), l.fields...), keyvals...)
+	if l.json {
+		l.t.Log(encodeJSON(time.Now(), level, l.t.Name(), msg, fields))
+		return
+	}
+	l.t.Log(encodeText(icon, msg, fields))
+}
+
+// encodeText renders "icon msg key=value ...", quoting values with spaces
+func encodeText(icon, msg string, keyvals []interface{}) string {
+	var b strings.Builder
+	if icon != "" {
+		b.WriteString(icon + " ")
+	}
+	b.WriteString(msg)
+	for i := 0; i < len(keyvals); i += 2 {
+		key, value := pair(keyvals, i)
+		text := fmt.Sprint(value)
+		if text == "" || strings.ContainsAny(text, " \t\n\"=") {
+			text = fmt.Sprintf("%q", text)
+		}
+		fmt.Fprintf(&b, " %s=%s", key, text)
+	}
+	return b.String()
+}
+
+// encodeJSON renders one entry as a JSON object; values that do not marshal
+// are written as strings
+func encodeJSON(now time.Time, level Level, test, msg string, keyvals []interface{}) string {
+	entry := map[string]interface{}{}
+	for i := 0; i < len(keyvals); i += 2 {
+		key, value := pair(keyvals, i)
+		switch v := value.(type) {
+		case error:
+			value = v.Error()
+		case time.Duration:
+			entry[key+"_ms"] = v.Milliseconds()
+			value = v.String()
+		case fmt.Stringer:
+			value = v.String()
+		}
+		if _, err := json.Marshal(value); err != nil {
+			value = fmt.Sprint(value)
+		}
+		entry[key] = value
+	}
+	entry["time"] = now.UTC().Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["test"] = test
+	entry["msg"] = msg
+
+	encoded, _ := json.Marshal(entry)
+	return string(encoded)
+}
+
+// pair returns the key and value at i, naming a dangling value "extra"
+func pair(keyvals []interface{}, i int) (string, interface{}) {
+	if i+1 >= len(keyvals) {
+		return "extra", keyvals[i]
+	}
+	return fmt.Sprint(keyvals[i]), keyvals[i+1]
+}
@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	for input, expected := range map[string]Level{"": Info, "debug": Debug, "INFO": Info, "warning": Warn} {
+		level, err := ParseLevel(input)
+		require.NoError(t, err)
+		assert.Equal(t, expected, level, input)
+	}
+
+	_, err := ParseLevel("verbose")
+	assert.ErrorContains(t, err, "unknown log level")
+}
+
+func TestEncodeText(t *testing.T) {
+	assert.Equal(t, "✅ Alarm fired alarm=pipeline-errors", encodeText("✅", "Alarm fired", []interface{}{"alarm", "pipeline-errors"}))
+	assert.Equal(t, `Waiting for crawler timeout=5m0s error="access denied" extra=1`,
+		encodeText("", "Waiting for crawler", []interface{}{"timeout", 5 * time.Minute, "error", errors.New("access denied"), 1}))
+}
+
+func TestEncodeJSON(t *testing.T) {
+	now := time.Date(2024, 11, 22, 15, 0, 0, 0, time.UTC)
+	line := encodeJSON(now, Warn, "TestStorage/us-east-1", "Failed to delete queue",
+		[]interface{}{"queue", "s3-events-abc123", "error", errors.New("throttled"), "duration", 90 * time.Second, "objects", 3})
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(line), &entry))
+	assert.Equal(t, map[string]interface{}{
+		"time":        "2024-11-22T15:00:00Z",
+		"level":       "warn",
+		"test":        "TestStorage/us-east-1",
+		"msg":         "Failed to delete queue",
+		"queue":       "s3-events-abc123",
+		"error":       "throttled",
+		"duration":    "1m30s",
+		"duration_ms": float64(90000),
+		"objects":     float64(3),
+	}, entry)
+}
+
+func TestWith(t *testing.T) {
+	logger := New(t, "unit", "03-storage")
+	child := logger.With("region", "us-east-1")
+
+	assert.Equal(t, []interface{}{"unit", "03-storage"}, logger.fields, "With must not modify the parent")
+	assert.Equal(t, []interface{}{"unit", "03-storage", "region", "us-east-1"}, child.fields)
+}
+
+func TestLevelFilter(t *testing.T) {
+	t.Setenv(LevelEnvVar, "warn")
+	logger := New(t)
+	assert.Equal(t, Warn, logger.level)
+
+	t.Setenv(FormatEnvVar, "JSON")
+	assert.True(t, New(t).json)
+}
+
+func TestWriteSummary(t *testing.T) {
+	timingsMu.Lock()
+	saved := timings
+	timings = []Timing{
+		{Test: "TestStorage/us-east-1", Phase: "setup", Duration: 4*time.Minute + 10*time.Second},
+		{Test: "TestStorage/us-east-1", Phase: "validate", Duration: 6 * time.Minute, Failed: true},
+		{Test: "TestAnalytics/us-east-1", Phase: "setup", Duration: 2 * time.Minute},
+	}
+	timingsMu.Unlock()
+	defer func() {
+		timingsMu.Lock()
+		timings = saved
+		timingsMu.Unlock()
+	}()
+
+	var out bytes.Buffer
+	require.NoError(t, WriteSummary(&out))
+	assert.Contains(t, out.String(), "TestStorage/us-east-1    validate  6m0s      failed")
+	assert.Contains(t, out.String(), "total                    setup     6m10s")
+}
+
+func TestPhase(t *testing.T) {
+	before := len(Timings())
+	New(t).Phase("validate")()
+
+	recorded := Timings()
+	require.Len(t, recorded, before+1)
+	assert.Equal(t, t.Name(), recorded[before].Test)
+	assert.Equal(t, "validate", recorded[before].Phase)
+}
@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Timing is how long one test spent in one phase, such as a stage
+type Timing struct {
+	Test     string
+	Phase    string
+	Duration time.Duration
+	Failed   bool
+}
+
+var (
+	timingsMu sync.Mutex
+	timings   []Timing
+)
+
+// Phase logs the start of phase and returns a function that logs and records
+// its duration for the summary written by WriteSummary; call it when the phase
+// ends, typically with defer
+func (l *Logger) Phase(phase string) func() {
+	l.t.Helper()
+	started := time.Now()
+	l.Info("Phase started", "phase", phase)
+
+	return func() {
+		l.t.Helper()
+		timing := Timing{Test: l.t.Name(), Phase: phase, Duration: time.Since(started), Failed: l.t.Failed()}
+
+		timingsMu.Lock()
+		timings = append(timings, timing)
+		timingsMu.Unlock()
+
+		l.Info("Phase finished", "phase", phase, "duration", timing.Duration.Round(time.Second), "failed", timing.Failed)
+	}
+}
+
+// Timings returns every phase recorded so far, in the order they finished
+func Timings() []Timing {
+	timingsMu.Lock()
+	defer timingsMu.Unlock()
+	return append([]Timing(nil), timings...)
+}
+
+// WriteSummary writes a table of the recorded phases per test followed by the
+// total time spent in each phase; nothing is written when none were recorded
+func WriteSummary(w io.Writer) error {
+	recorded := Timings()
+	if len(recorded) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TEST\tPHASE\tDURATION\t")
+	totals := map[string]time.Duration{}
+	for _, timing := range recorded {
+		status := ""
+		if timing.Failed {
+			status = "failed"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", timing.Test, timing.Phase, timing.Duration.Round(time.Second), status)
+		totals[timing.Phase] += timing.Duration
+	}
+	fmt.Fprintln(tw, "\t\t\t")
+	for _, phase := range sortedKeys(totals) {
+		fmt.Fprintf(tw, "total\t%s\t%s\t\n", phase, totals[phase].Round(time.Second))
+	}
+	return tw.Flush()
+}
+
+// sortedKeys returns the keys of m in order, for stable summaries
+func sortedKeys(m map[string]time.Duration) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
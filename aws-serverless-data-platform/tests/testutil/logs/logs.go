@@ -0,0 +1,155 @@
+// =============================================================================
+// CloudWatch Logs Assertions
+// Log group configuration checks and waiting for log delivery
+// =============================================================================
+
+// Package logs checks the log groups modules create and scans the events
+// delivered to them, so suites for Glue, Lambda, flow logs and Step Functions
+// share one implementation of the DescribeLogGroups and FilterLogEvents paging.
+package logs
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/wait"
+)
+
+// pollInterval is how often WaitForLogPattern searches the group; delivery lags by seconds to minutes
+const pollInterval = 15 * time.Second
+
+// Describe returns the log group named group, or nil when it does not exist
+func Describe(ctx context.Context, client *cloudwatchlogs.Client, group string) (*types.LogGroup, error) {
+	paginator := cloudwatchlogs.NewDescribeLogGroupsPaginator(client, &cloudwatchlogs.DescribeLogGroupsInput{
+		LogGroupNamePrefix: aws.String(group),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for i := range page.LogGroups {
+			if aws.ToString(page.LogGroups[i].LogGroupName) == group {
+				return &page.LogGroups[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// AssertLogGroupExists checks group exists and returns it
+func AssertLogGroupExists(t *testing.T, clients *awsclients.Clients, group string) types.LogGroup {
+	t.Helper()
+
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	logGroup, err := Describe(ctx, clients.Logs(), group)
+	require.NoError(t, err, "Failed to describe log group %s", group)
+	require.NotNil(t, logGroup, "Log group %s not found", group)
+	return *logGroup
+}
+
+// AssertRetentionDays checks group keeps events for days; 0 means never expire
+func AssertRetentionDays(t *testing.T, clients *awsclients.Clients, group string, days int) bool {
+	t.Helper()
+
+	logGroup := AssertLogGroupExists(t, clients, group)
+	return assert.Equal(t, int32(days), aws.ToInt32(logGroup.RetentionInDays),
+		"Log group %s should retain events for %d days", group, days)
+}
+
+// AssertEncryptedWithKey checks group is encrypted with the KMS key keyARN
+func AssertEncryptedWithKey(t *testing.T, clients *awsclients.Clients, group, keyARN string) bool {
+	t.Helper()
+
+	logGroup := AssertLogGroupExists(t, clients, group)
+	return assert.Equal(t, keyARN, aws.ToString(logGroup.KmsKeyId),
+		"Log group %s should be encrypted with %s", group, keyARN)
+}
+
+// Messages returns the messages of events delivered to group since the given
+// time; filterPattern is a CloudWatch Logs filter pattern, "" for every event
+func Messages(ctx context.Context, client *cloudwatchlogs.Client, group, filterPattern string, since time.Time) ([]string, error) {
+	input := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName: aws.String(group),
+		StartTime:    aws.Int64(since.UnixMilli()),
+	}
+	if filterPattern != "" {
+		input.FilterPattern = aws.String(filterPattern)
+	}
+
+	var messages []string
+	paginator := cloudwatchlogs.NewFilterLogEventsPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range page.Events {
+			messages = append(messages, aws.ToString(event.Message))
+		}
+	}
+	return messages, nil
+}
+
+// Match returns the messages pattern matches
+func Match(messages []string, pattern *regexp.Regexp) []string {
+	var matched []string
+	for _, message := range messages {
+		if pattern.MatchString(message) {
+			matched = append(matched, message)
+		}
+	}
+	return matched
+}
+
+// WaitForLogPattern polls group until an event delivered since the given time
+// matches the regular expression pattern, and returns every matching message
+func WaitForLogPattern(t *testing.T, clients *awsclients.Clients, group, pattern string, since time.Time, timeout time.Duration) []string {
+	t.Helper()
+
+	re, err := regexp.Compile(pattern)
+	require.NoError(t, err, "Invalid log pattern %q", pattern)
+
+	var matched []string
+	wait.Until(t, fmt.Sprintf("events matching %q in %s", pattern, group), func(ctx context.Context) (bool, error) {
+		messages, err := Messages(ctx, clients.Logs(), group, "", since)
+		if err != nil {
+			return false, err
+		}
+		if matched = Match(messages, re); len(matched) == 0 {
+			return false, fmt.Errorf("no match among %d events", len(messages))
+		}
+		return true, nil
+	}, wait.DefaultOptions().WithTimeout(timeout).WithInterval(pollInterval))
+
+	return matched
+}
+
+// AssertNoLogPattern checks no event delivered to group since the given time
+// matches pattern, such as "(?i)error|exception"
+func AssertNoLogPattern(t *testing.T, clients *awsclients.Clients, group, pattern string, since time.Time) bool {
+	t.Helper()
+
+	re, err := regexp.Compile(pattern)
+	require.NoError(t, err, "Invalid log pattern %q", pattern)
+
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	messages, err := Messages(ctx, clients.Logs(), group, "", since)
+	require.NoError(t, err, "Failed to read events from %s", group)
+
+	matched := Match(messages, re)
+	return assert.Empty(t, matched, "Log group %s has events matching %q", group, pattern)
+}
@@ -0,0 +1,22 @@
+package logs
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	messages := []string{
+		"INFO  Job started",
+		"ERROR Failed to read s3://raw/events.csv",
+		"java.lang.IllegalStateException: boom",
+		"INFO  Job finished",
+	}
+
+	assert.Equal(t, []string{"ERROR Failed to read s3://raw/events.csv", "java.lang.IllegalStateException: boom"},
+		Match(messages, regexp.MustCompile(`(?i)error|exception`)))
+	assert.Empty(t, Match(messages, regexp.MustCompile(`FATAL`)))
+	assert.Empty(t, Match(nil, regexp.MustCompile(`.*`)))
+}
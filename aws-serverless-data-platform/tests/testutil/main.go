@@ -0,0 +1,92 @@
+// =============================================================================
+// Test Entry Point
+// Shared TestMain that selects the account every suite runs against
+// =============================================================================
+
+package testutil
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/identity"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/report"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/testconfig"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/transient"
+)
+
+// Runner runs a suite's tests and returns the exit code; *testing.M is one
+type Runner interface {
+	Run() int
+}
+
+// Main runs the suite as the test role, then prints the phase and retry
+// summaries and writes the run report
+func Main(m Runner) {
+	config, err := testconfig.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid test configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if err := awsclients.ExportCredentials(context.Background()); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to assume test role: %v\n", err)
+		os.Exit(1)
+	}
+
+	// -short applies nothing, so its offline checks run without credentials
+	flag.Parse()
+	if !testing.Short() {
+		if err := verifyCaller(config); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	report.Observe(recordDeployment)
+
+	code := m.Run()
+	if err := logging.WriteSummary(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write phase summary: %v\n", err)
+	}
+	if err := transient.WriteSummary(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write retry summary: %v\n", err)
+	}
+	if err := report.WriteFromEnv(suiteName()); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write test report: %v\n", err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+// verifyCaller checks the exported credentials belong to a principal the suites may run as
+func verifyCaller(config testconfig.Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), awsclients.DefaultTimeout)
+	defer cancel()
+
+	cfg, _, err := awsclients.LoadConfig(ctx)
+	if err != nil {
+		return err
+	}
+	return identity.Verify(ctx, cfg, config)
+}
+
+// suiteName names the report after the suite directory, e.g. "networking" for
+// modules/networking/tests and "integration" for tests/integration
+func suiteName() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "terratest"
+	}
+	if filepath.Base(dir) == "tests" {
+		dir = filepath.Dir(dir)
+	}
+	return filepath.Base(dir)
+}
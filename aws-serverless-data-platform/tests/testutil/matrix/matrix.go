@@ -0,0 +1,152 @@
+// =============================================================================
+// Multi-Region Test Matrix
+// Fans module tests out as parallel subtests, one per configured region
+// =============================================================================
+
+package matrix
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/testconfig"
+)
+
+const (
+	// RegionsEnvVar overrides the configured regions with a comma-separated list
+	RegionsEnvVar = testconfig.RegionsEnvVar
+
+	// cidrBlocksPerRegion is how many /16 networks each region may allocate
+	cidrBlocksPerRegion = 16
+)
+
+// Region is one entry of the matrix
+type Region struct {
+	Name string
+
+	// Index is the region's position in the matrix; it selects the CIDR range
+	Index int
+
+	// BaseCIDR is the configured VPC CIDR the region's ranges are counted from;
+	// empty means testconfig.DefaultVPCCIDR
+	BaseCIDR string
+}
+
+// VPCCIDR returns the n-th /16 reserved for the region, so VPCs in different
+// regions never overlap and can be peered
+func (r Region) VPCCIDR(n int) string {
+	if n < 0 || n >= cidrBlocksPerRegion {
+		panic(fmt.Sprintf("region %s has no CIDR block %d", r.Name, n))
+	}
+
+	base := r.BaseCIDR
+	if base == "" {
+		base = testconfig.DefaultVPCCIDR
+	}
+	ip, _, err := net.ParseCIDR(base)
+	if err != nil {
+		panic(fmt.Sprintf("invalid base CIDR %q: %v", base, err))
+	}
+	ip = ip.To4()
+
+	second := int(ip[1]) + r.Index*cidrBlocksPerRegion + n
+	if second > 255 {
+		panic(fmt.Sprintf("region %s CIDR block %d overflows %s", r.Name, n, base))
+	}
+	return fmt.Sprintf("%d.%d.0.0/16", ip[0], second)
+}
+
+// Suffix returns a unique resource name suffix carrying the region code
+func (r Region) Suffix() string {
+	return RegionCode(r.Name) + "-" + strings.ToLower(random.UniqueId())
+}
+
+// Options returns the testutil options for the region, followed by opts
+func (r Region) Options(opts ...testutil.Option) []testutil.Option {
+	return append([]testutil.Option{
+		testutil.WithRegion(r.Name),
+		testutil.WithUniqueSuffix(r.Suffix()),
+		testutil.WithVPCCIDR(r.VPCCIDR(0)),
+	}, opts...)
+}
+
+// Run runs test once per configured region as parallel subtests named after the region
+func Run(t *testing.T, test func(t *testing.T, region Region)) {
+	config, err := testconfig.Load()
+	require.NoError(t, err)
+
+	regions, err := ParseRegions(strings.Join(config.Regions, ","))
+	require.NoError(t, err)
+
+	for i, name := range regions {
+		region := Region{Name: name, Index: i, BaseCIDR: config.VPCCIDR}
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			test(t, region)
+		})
+	}
+}
+
+// Regions returns the regions from RegionsEnvVar, else the layered test
+// configuration, else testutil.DefaultRegion
+func Regions() ([]string, error) {
+	config, err := testconfig.Load()
+	if err != nil {
+		return nil, err
+	}
+	return ParseRegions(strings.Join(config.Regions, ","))
+}
+
+// ParseRegions splits a comma-separated region list, rejecting blanks and duplicates
+func ParseRegions(value string) ([]string, error) {
+	var regions []string
+	seen := map[string]bool{}
+	for _, region := range strings.Split(value, ",") {
+		region = strings.TrimSpace(region)
+		if region == "" {
+			continue
+		}
+		if seen[region] {
+			return nil, fmt.Errorf("region %s is listed twice", region)
+		}
+		seen[region] = true
+		regions = append(regions, region)
+	}
+
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("no regions in %q", value)
+	}
+	if len(regions)*cidrBlocksPerRegion > 256 {
+		return nil, fmt.Errorf("%d regions exceed the 10.0.0.0/8 CIDR allocation", len(regions))
+	}
+	return regions, nil
+}
+
+// directionCodes abbreviates the direction part of a region name
+var directionCodes = map[string]string{
+	"north":     "n",
+	"south":     "s",
+	"east":      "e",
+	"west":      "w",
+	"central":   "c",
+	"northeast": "ne",
+	"northwest": "nw",
+	"southeast": "se",
+	"southwest": "sw",
+}
+
+// RegionCode abbreviates a region name for resource names, e.g. ap-southeast-2 to apse2
+func RegionCode(region string) string {
+	parts := strings.Split(region, "-")
+	for i, part := range parts {
+		if code, ok := directionCodes[part]; ok {
+			parts[i] = code
+		}
+	}
+	return strings.Join(parts, "")
+}
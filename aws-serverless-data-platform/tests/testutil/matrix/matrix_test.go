@@ -0,0 +1,59 @@
+package matrix
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRegions(t *testing.T) {
+	regions, err := ParseRegions(" eu-west-1, ap-southeast-2 ,")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eu-west-1", "ap-southeast-2"}, regions)
+
+	_, err = ParseRegions("eu-west-1,eu-west-1")
+	assert.Error(t, err)
+
+	_, err = ParseRegions(" , ")
+	assert.Error(t, err)
+}
+
+func TestRegions(t *testing.T) {
+	t.Setenv(RegionsEnvVar, "eu-west-1,ap-southeast-2")
+	regions, err := Regions()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eu-west-1", "ap-southeast-2"}, regions)
+
+	// Falls back to the repository's config/testing.yaml
+	t.Setenv(RegionsEnvVar, "")
+	regions, err = Regions()
+	require.NoError(t, err)
+	assert.NotEmpty(t, regions)
+}
+
+func TestRegionCode(t *testing.T) {
+	assert.Equal(t, "use1", RegionCode("us-east-1"))
+	assert.Equal(t, "euw1", RegionCode("eu-west-1"))
+	assert.Equal(t, "apse2", RegionCode("ap-southeast-2"))
+	assert.Equal(t, "cac1", RegionCode("ca-central-1"))
+	assert.Equal(t, "usgovw1", RegionCode("us-gov-west-1"))
+}
+
+func TestRegionAllocation(t *testing.T) {
+	first := Region{Name: "eu-west-1", Index: 0}
+	second := Region{Name: "ap-southeast-2", Index: 1}
+
+	assert.Equal(t, "10.0.0.0/16", first.VPCCIDR(0))
+	assert.Equal(t, "10.1.0.0/16", first.VPCCIDR(1))
+	assert.Equal(t, "10.16.0.0/16", second.VPCCIDR(0))
+	assert.Panics(t, func() { second.VPCCIDR(cidrBlocksPerRegion) })
+
+	// A configured base CIDR shifts every region's range
+	shifted := Region{Name: "ap-southeast-2", Index: 1, BaseCIDR: "10.32.0.0/16"}
+	assert.Equal(t, "10.48.0.0/16", shifted.VPCCIDR(0))
+
+	assert.True(t, strings.HasPrefix(second.Suffix(), "apse2-"))
+	assert.NotEqual(t, second.Suffix(), second.Suffix())
+}
@@ -0,0 +1,218 @@
+// =============================================================================
+// Messaging Payloads
+// Unwraps SNS and EventBridge envelopes and matches platform notifications
+// =============================================================================
+
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Origin identifies which service delivered a message to the queue
+type Origin string
+
+const (
+	// OriginSNS marks messages delivered by an SNS subscription
+	OriginSNS Origin = "sns"
+	// OriginEvents marks events delivered by an EventBridge rule
+	OriginEvents Origin = "events"
+	// OriginSQS marks messages sent to the queue directly
+	OriginSQS Origin = "sqs"
+)
+
+// Message is one message received from a test queue with its envelope removed
+type Message struct {
+	ID     string
+	Body   string
+	Origin Origin
+
+	// Subject is set for SNS notifications that carry one
+	Subject string
+
+	// Source and DetailType are set for EventBridge events
+	Source     string
+	DetailType string
+
+	// Payload is the SNS message, the EventBridge detail or the raw body
+	Payload string
+}
+
+// snsEnvelope is the JSON wrapper SNS adds when delivering to SQS
+type snsEnvelope struct {
+	Type     string `json:"Type"`
+	TopicArn string `json:"TopicArn"`
+	Subject  string `json:"Subject"`
+	Message  string `json:"Message"`
+}
+
+// eventEnvelope is the EventBridge event structure
+type eventEnvelope struct {
+	Source     string          `json:"source"`
+	DetailType string          `json:"detail-type"`
+	Detail     json.RawMessage `json:"detail"`
+}
+
+// Unwrap removes the SNS or EventBridge envelope from an SQS message body
+func Unwrap(id, body string) Message {
+	message := Message{ID: id, Body: body, Origin: OriginSQS, Payload: body}
+
+	var notification snsEnvelope
+	if err := json.Unmarshal([]byte(body), &notification); err == nil &&
+		notification.Type == "Notification" && notification.TopicArn != "" {
+		message.Origin = OriginSNS
+		message.Subject = notification.Subject
+		message.Payload = notification.Message
+		return message
+	}
+
+	var event eventEnvelope
+	if err := json.Unmarshal([]byte(body), &event); err == nil && event.Source != "" && event.DetailType != "" {
+		message.Origin = OriginEvents
+		message.Source = event.Source
+		message.DetailType = event.DetailType
+		message.Payload = string(event.Detail)
+	}
+	return message
+}
+
+// Field returns the value at a dot-separated path in the JSON payload, e.g. "bucket.name"
+func (m Message) Field(path string) (interface{}, bool) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(m.Payload), &value); err != nil {
+		return nil, false
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// FieldString returns the value at path formatted as a string, or "" when absent
+func (m Message) FieldString(path string) string {
+	value, ok := m.Field(path)
+	if !ok || value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
+
+// AssertFields checks every path in expected holds the given value; numbers compare by their string form
+func AssertFields(t *testing.T, message Message, expected map[string]interface{}) bool {
+	t.Helper()
+
+	ok := true
+	for path, want := range expected {
+		value, found := message.Field(path)
+		if !assert.True(t, found, "Message %s has no field %s: %s", message.ID, path, message.Payload) {
+			ok = false
+			continue
+		}
+		if !assert.Equal(t, fmt.Sprint(want), fmt.Sprint(value), "Message %s field %s", message.ID, path) {
+			ok = false
+		}
+	}
+	return ok
+}
+
+// Match selects the message a test is waiting for
+type Match func(Message) bool
+
+// Any matches every message
+func Any() Match {
+	return func(Message) bool { return true }
+}
+
+// AlarmState matches the CloudWatch alarm notification SNS sends when alarmName enters state
+func AlarmState(alarmName, state string) Match {
+	return func(m Message) bool {
+		return m.Origin == OriginSNS &&
+			m.FieldString("AlarmName") == alarmName &&
+			m.FieldString("NewStateValue") == state
+	}
+}
+
+// ObjectCreated matches the EventBridge event S3 emits when key is written to bucket
+func ObjectCreated(bucket, key string) Match {
+	return func(m Message) bool {
+		return m.Origin == OriginEvents &&
+			m.Source == "aws.s3" && m.DetailType == "Object Created" &&
+			m.FieldString("bucket.name") == bucket &&
+			m.FieldString("object.key") == key
+	}
+}
+
+// ExecutionStatus matches the EventBridge event Step Functions emits when an execution reaches status
+func ExecutionStatus(stateMachineARN, status string) Match {
+	return func(m Message) bool {
+		return m.Origin == OriginEvents &&
+			m.Source == "aws.states" && m.DetailType == "Step Functions Execution Status Change" &&
+			m.FieldString("stateMachineArn") == stateMachineARN &&
+			m.FieldString("status") == status
+	}
+}
+
+// ObjectCreatedPattern is the EventBridge pattern for objects written to bucket
+func ObjectCreatedPattern(bucket string) string {
+	return pattern(map[string]interface{}{
+		"source":      []string{"aws.s3"},
+		"detail-type": []string{"Object Created"},
+		"detail": map[string]interface{}{
+			"bucket": map[string]interface{}{"name": []string{bucket}},
+		},
+	})
+}
+
+// ObjectCreatedPatternFor narrows ObjectCreatedPattern to keys with prefix and
+// suffix, either of which may be empty, the way a routing rule filters them
+func ObjectCreatedPatternFor(bucket, prefix, suffix string) string {
+	detail := map[string]interface{}{
+		"bucket": map[string]interface{}{"name": []string{bucket}},
+	}
+	switch {
+	case prefix != "" && suffix != "":
+		detail["object"] = map[string]interface{}{"key": []interface{}{map[string]string{"wildcard": prefix + "*" + suffix}}}
+	case prefix != "":
+		detail["object"] = map[string]interface{}{"key": []interface{}{map[string]string{"prefix": prefix}}}
+	case suffix != "":
+		detail["object"] = map[string]interface{}{"key": []interface{}{map[string]string{"suffix": suffix}}}
+	}
+
+	return pattern(map[string]interface{}{
+		"source":      []string{"aws.s3"},
+		"detail-type": []string{"Object Created"},
+		"detail":      detail,
+	})
+}
+
+// ExecutionStatusPattern is the EventBridge pattern for status changes of stateMachineARN's executions
+func ExecutionStatusPattern(stateMachineARN string) string {
+	return pattern(map[string]interface{}{
+		"source":      []string{"aws.states"},
+		"detail-type": []string{"Step Functions Execution Status Change"},
+		"detail": map[string]interface{}{
+			"stateMachineArn": []string{stateMachineARN},
+		},
+	})
+}
+
+// pattern encodes an event pattern; the maps above always marshal
+func pattern(value map[string]interface{}) string {
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
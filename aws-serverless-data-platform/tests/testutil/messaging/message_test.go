@@ -0,0 +1,116 @@
+package messaging
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	rawBucket       = "dl-raw-test-us-east-1-0a1b2c3d"
+	stateMachineARN = "arn:aws:states:us-east-1:123456789012:stateMachine:data-pipeline"
+)
+
+// s3Event is the EventBridge event S3 emits for a new object
+const s3Event = `{"version":"0","id":"17793124-05d4-b198-2fde-7ededc63b103","detail-type":"Object Created",
+	"source":"aws.s3","account":"123456789012","region":"us-east-1",
+	"detail":{"version":"0","bucket":{"name":"dl-raw-test-us-east-1-0a1b2c3d"},
+	"object":{"key":"landing/orders.csv","size":5,"etag":"b1946ac92492d2347c6235b4d2611184"},"reason":"PutObject"}}`
+
+// alarmNotification is the SNS envelope around a CloudWatch alarm state change
+func alarmNotification(t *testing.T) string {
+	alarm, err := json.Marshal(map[string]interface{}{
+		"AlarmName":        "dev-high-error-rate",
+		"NewStateValue":    "ALARM",
+		"OldStateValue":    "OK",
+		"NewStateReason":   "Threshold Crossed",
+		"AWSAccountId":     "123456789012",
+		"StateChangeTime":  "2024-11-20T10:15:00.000+0000",
+		"AlarmDescription": "Error rate is above threshold",
+	})
+	assert.NoError(t, err)
+
+	envelope, err := json.Marshal(map[string]string{
+		"Type":      "Notification",
+		"MessageId": "b3b8b5e4-1d7d-5d43-9a4b-3c6f0e0b2f4a",
+		"TopicArn":  "arn:aws:sns:us-east-1:123456789012:critical-alerts",
+		"Subject":   `ALARM: "dev-high-error-rate" in US East (N. Virginia)`,
+		"Message":   string(alarm),
+	})
+	assert.NoError(t, err)
+	return string(envelope)
+}
+
+func TestUnwrapSNS(t *testing.T) {
+	message := Unwrap("1", alarmNotification(t))
+
+	assert.Equal(t, OriginSNS, message.Origin)
+	assert.Contains(t, message.Subject, "dev-high-error-rate")
+	assert.Equal(t, "ALARM", message.FieldString("NewStateValue"))
+	assert.True(t, AlarmState("dev-high-error-rate", "ALARM")(message))
+	assert.False(t, AlarmState("dev-high-error-rate", "OK")(message))
+	assert.False(t, ObjectCreated(rawBucket, "landing/orders.csv")(message))
+}
+
+func TestUnwrapEvent(t *testing.T) {
+	message := Unwrap("2", s3Event)
+
+	assert.Equal(t, OriginEvents, message.Origin)
+	assert.Equal(t, "aws.s3", message.Source)
+	assert.Equal(t, "Object Created", message.DetailType)
+	assert.Equal(t, rawBucket, message.FieldString("bucket.name"))
+	assert.Equal(t, "5", message.FieldString("object.size"))
+	assert.True(t, ObjectCreated(rawBucket, "landing/orders.csv")(message))
+	assert.False(t, ObjectCreated(rawBucket, "landing/other.csv")(message))
+
+	AssertFields(t, message, map[string]interface{}{
+		"object.key":  "landing/orders.csv",
+		"object.size": 5,
+		"reason":      "PutObject",
+	})
+
+	_, ok := message.Field("object.key.missing")
+	assert.False(t, ok)
+}
+
+func TestUnwrapRaw(t *testing.T) {
+	message := Unwrap("3", "plain text")
+
+	assert.Equal(t, OriginSQS, message.Origin)
+	assert.Equal(t, "plain text", message.Payload)
+	assert.Equal(t, "", message.FieldString("anything"))
+	assert.True(t, Any()(message))
+}
+
+func TestExecutionStatus(t *testing.T) {
+	body, err := json.Marshal(map[string]interface{}{
+		"source":      "aws.states",
+		"detail-type": "Step Functions Execution Status Change",
+		"detail": map[string]string{
+			"stateMachineArn": stateMachineARN,
+			"status":          "SUCCEEDED",
+		},
+	})
+	assert.NoError(t, err)
+
+	message := Unwrap("4", string(body))
+	assert.True(t, ExecutionStatus(stateMachineARN, "SUCCEEDED")(message))
+	assert.False(t, ExecutionStatus(stateMachineARN, "FAILED")(message))
+}
+
+func TestPatterns(t *testing.T) {
+	assert.JSONEq(t,
+		`{"source":["aws.s3"],"detail-type":["Object Created"],"detail":{"bucket":{"name":["`+rawBucket+`"]}}}`,
+		ObjectCreatedPattern(rawBucket))
+	assert.JSONEq(t, ObjectCreatedPattern(rawBucket), ObjectCreatedPatternFor(rawBucket, "", ""))
+	assert.JSONEq(t,
+		`{"source":["aws.s3"],"detail-type":["Object Created"],"detail":{"bucket":{"name":["`+rawBucket+`"]},"object":{"key":[{"prefix":"landing/"}]}}}`,
+		ObjectCreatedPatternFor(rawBucket, "landing/", ""))
+	assert.JSONEq(t,
+		`{"source":["aws.s3"],"detail-type":["Object Created"],"detail":{"bucket":{"name":["`+rawBucket+`"]},"object":{"key":[{"wildcard":"landing/*.csv"}]}}}`,
+		ObjectCreatedPatternFor(rawBucket, "landing/", ".csv"))
+	assert.JSONEq(t,
+		`{"source":["aws.states"],"detail-type":["Step Functions Execution Status Change"],"detail":{"stateMachineArn":["`+stateMachineARN+`"]}}`,
+		ExecutionStatusPattern(stateMachineARN))
+}
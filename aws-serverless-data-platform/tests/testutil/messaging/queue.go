@@ -0,0 +1,253 @@
+// =============================================================================
+// Ephemeral Test Queues
+// SQS queues subscribed to platform topics and event rules for one test
+// =============================================================================
+
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/naming"
+)
+
+// receiveWait is the SQS long-poll duration for each receive call
+const receiveWait = 10 * time.Second
+
+// Queue is an SQS queue that exists for the duration of one test
+type Queue struct {
+	Name string
+	URL  string
+	ARN  string
+
+	clients *awsclients.Clients
+
+	mu         sync.Mutex
+	statements []map[string]interface{}
+	received   []Message
+}
+
+// NewQueue creates a queue named after prefix and deletes it, with any
+// subscriptions and rules added to it, when the test finishes
+func NewQueue(t *testing.T, clients *awsclients.Clients, prefix string) *Queue {
+	// The name doubles as the name of the queue's event rules, whose limits are stricter
+	name := naming.Unique(t, naming.EventRule, "test-"+prefix)
+	naming.AssertAvailable(t, clients, naming.SQSQueue, name)
+
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	created, err := clients.SQS().CreateQueue(ctx, &sqs.CreateQueueInput{
+		QueueName: aws.String(name),
+		Attributes: map[string]string{
+			"MessageRetentionPeriod": "3600",
+		},
+	})
+	require.NoError(t, err, "Failed to create queue %s", name)
+
+	q := &Queue{Name: name, URL: aws.ToString(created.QueueUrl), clients: clients}
+	t.Cleanup(func() {
+		if _, err := clients.SQS().DeleteQueue(context.Background(), &sqs.DeleteQueueInput{QueueUrl: aws.String(q.URL)}); err != nil {
+			logging.New(t).Warn("Failed to delete queue", "queue", name, "error", err)
+		}
+	})
+
+	attributes, err := clients.SQS().GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(q.URL),
+		AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNameQueueArn},
+	})
+	require.NoError(t, err, "Failed to get attributes of queue %s", name)
+	q.ARN = attributes.Attributes[string(sqstypes.QueueAttributeNameQueueArn)]
+
+	logging.New(t).Info("Created test queue", "queue", name)
+	return q
+}
+
+// SubscribeTopic delivers notifications published to topicARN to the queue
+func (q *Queue) SubscribeTopic(t *testing.T, topicARN string) {
+	q.allowSender(t, "sns.amazonaws.com", topicARN)
+
+	ctx, cancel := q.clients.Context()
+	defer cancel()
+
+	subscription, err := q.clients.SNS().Subscribe(ctx, &sns.SubscribeInput{
+		TopicArn:              aws.String(topicARN),
+		Protocol:              aws.String("sqs"),
+		Endpoint:              aws.String(q.ARN),
+		ReturnSubscriptionArn: true,
+	})
+	require.NoError(t, err, "Failed to subscribe %s to %s", q.Name, topicARN)
+
+	subscriptionARN := aws.ToString(subscription.SubscriptionArn)
+	t.Cleanup(func() {
+		if _, err := q.clients.SNS().Unsubscribe(context.Background(), &sns.UnsubscribeInput{
+			SubscriptionArn: aws.String(subscriptionARN),
+		}); err != nil {
+			logging.New(t).Warn("Failed to unsubscribe", "subscription", subscriptionARN, "error", err)
+		}
+	})
+}
+
+// SubscribeEvents routes events on the default bus that match eventPattern to the queue
+func (q *Queue) SubscribeEvents(t *testing.T, eventPattern string) {
+	ruleName := q.Name
+	if len(q.statements) > 0 {
+		ruleName = fmt.Sprintf("%s-%d", q.Name, len(q.statements))
+	}
+
+	ctx, cancel := q.clients.Context()
+	defer cancel()
+
+	rule, err := q.clients.EventBridge().PutRule(ctx, &eventbridge.PutRuleInput{
+		Name:         aws.String(ruleName),
+		EventPattern: aws.String(eventPattern),
+		State:        ebtypes.RuleStateEnabled,
+		Description:  aws.String("Routes events to test queue " + q.Name),
+	})
+	require.NoError(t, err, "Failed to create event rule %s", ruleName)
+
+	t.Cleanup(func() {
+		ctx := context.Background()
+		if _, err := q.clients.EventBridge().RemoveTargets(ctx, &eventbridge.RemoveTargetsInput{
+			Rule: aws.String(ruleName),
+			Ids:  []string{"queue"},
+		}); err != nil {
+			logging.New(t).Warn("Failed to remove targets of event rule", "rule", ruleName, "error", err)
+		}
+		if _, err := q.clients.EventBridge().DeleteRule(ctx, &eventbridge.DeleteRuleInput{Name: aws.String(ruleName)}); err != nil {
+			logging.New(t).Warn("Failed to delete event rule", "rule", ruleName, "error", err)
+		}
+	})
+
+	q.allowSender(t, "events.amazonaws.com", aws.ToString(rule.RuleArn))
+
+	targets, err := q.clients.EventBridge().PutTargets(ctx, &eventbridge.PutTargetsInput{
+		Rule:    aws.String(ruleName),
+		Targets: []ebtypes.Target{{Id: aws.String("queue"), Arn: aws.String(q.ARN)}},
+	})
+	require.NoError(t, err, "Failed to add queue target to event rule %s", ruleName)
+	require.Zero(t, targets.FailedEntryCount, "Event rule %s rejected the queue target", ruleName)
+}
+
+// allowSender grants service permission to send to the queue on behalf of sourceARN
+func (q *Queue) allowSender(t *testing.T, service, sourceARN string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.statements = append(q.statements, map[string]interface{}{
+		"Sid":       fmt.Sprintf("AllowSender%d", len(q.statements)),
+		"Effect":    "Allow",
+		"Principal": map[string]string{"Service": service},
+		"Action":    "sqs:SendMessage",
+		"Resource":  q.ARN,
+		"Condition": map[string]interface{}{
+			"ArnEquals": map[string]string{"aws:SourceArn": sourceARN},
+		},
+	})
+
+	policy, err := json.Marshal(map[string]interface{}{
+		"Version":   "2012-10-17",
+		"Statement": q.statements,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := q.clients.Context()
+	defer cancel()
+
+	_, err = q.clients.SQS().SetQueueAttributes(ctx, &sqs.SetQueueAttributesInput{
+		QueueUrl:   aws.String(q.URL),
+		Attributes: map[string]string{string(sqstypes.QueueAttributeNamePolicy): string(policy)},
+	})
+	require.NoError(t, err, "Failed to update policy of queue %s", q.Name)
+}
+
+// WaitForMessage polls the queue until a message satisfies match or timeout
+// elapses; every message received is deleted and kept for failure output
+func (q *Queue) WaitForMessage(t *testing.T, timeout time.Duration, match Match) Message {
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		for _, message := range q.receive(t) {
+			if match(message) {
+				logging.New(t).Success("Received matching message", "queue", q.Name, "message", message.ID)
+				return message
+			}
+		}
+	}
+
+	for _, message := range q.received {
+		logging.New(t).Info("Unmatched message", "queue", q.Name, "message", message.ID, "origin", message.Origin, "payload", message.Payload)
+	}
+	require.FailNow(t, "No matching message", "No matching message arrived on %s within %s (%d received)",
+		q.Name, timeout, len(q.received))
+	return Message{}
+}
+
+// AssertNoMessage fails if a message satisfying match was already received or
+// arrives within window, e.g. an event a rule's filter should have dropped
+func (q *Queue) AssertNoMessage(t *testing.T, window time.Duration, match Match) {
+	deadline := time.Now().Add(window)
+
+	for _, message := range q.Received() {
+		if match(message) {
+			t.Errorf("Unexpected message %s on %s: %s", message.ID, q.Name, message.Payload)
+			return
+		}
+	}
+	for time.Now().Before(deadline) {
+		for _, message := range q.receive(t) {
+			if match(message) {
+				t.Errorf("Unexpected message %s on %s: %s", message.ID, q.Name, message.Payload)
+				return
+			}
+		}
+	}
+	logging.New(t).Success("No matching message arrived", "queue", q.Name, "window", window)
+}
+
+// receive long-polls the queue once, deleting and recording every message
+func (q *Queue) receive(t *testing.T) []Message {
+	ctx, cancel := context.WithTimeout(context.Background(), receiveWait+30*time.Second)
+	output, err := q.clients.SQS().ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(q.URL),
+		MaxNumberOfMessages: 10,
+		WaitTimeSeconds:     int32(receiveWait / time.Second),
+	})
+	cancel()
+	require.NoError(t, err, "Failed to receive from queue %s", q.Name)
+
+	var messages []Message
+	for _, received := range output.Messages {
+		message := Unwrap(aws.ToString(received.MessageId), aws.ToString(received.Body))
+		messages = append(messages, message)
+
+		q.received = append(q.received, message)
+
+		if _, err := q.clients.SQS().DeleteMessage(context.Background(), &sqs.DeleteMessageInput{
+			QueueUrl:      aws.String(q.URL),
+			ReceiptHandle: received.ReceiptHandle,
+		}); err != nil {
+			logging.New(t).Warn("Failed to delete message", "queue", q.Name, "message", message.ID, "error", err)
+		}
+	}
+	return messages
+}
+
+// Received returns every message the queue has received so far
+func (q *Queue) Received() []Message {
+	return append([]Message(nil), q.received...)
+}
@@ -0,0 +1,144 @@
+package naming
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+)
+
+// lookupFunc reports whether a resource named name exists
+type lookupFunc func(ctx context.Context, clients *awsclients.Clients, name string) (bool, error)
+
+// Exists reports whether a resource of the rule's type named name already
+// exists in the clients' account and region
+func Exists(ctx context.Context, clients *awsclients.Clients, rule Rule, name string) (bool, error) {
+	if rule.lookup == nil {
+		return false, fmt.Errorf("cannot look up %s names", rule.Resource)
+	}
+	return rule.lookup(ctx, clients, name)
+}
+
+// AssertAvailable fails t unless every name is valid for the rule and unused,
+// so a collision fails before the create call rather than as a confusing
+// "already exists" error partway through the test
+func AssertAvailable(t testing.TB, clients *awsclients.Clients, rule Rule, names ...string) {
+	t.Helper()
+
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	for _, name := range names {
+		require.NoError(t, rule.Validate(name))
+
+		exists, err := Exists(ctx, clients, rule, name)
+		require.NoError(t, err, "Failed to check whether %s %s exists", rule.Resource, name)
+		require.False(t, exists, "%s %s already exists, likely left over from an interrupted run; "+
+			"delete it or run cmd/sweeper before retrying", rule.Resource, name)
+	}
+}
+
+// found reads a lookup's error: nil means the resource exists and the not-found
+// codes mean it does not
+func found(err error, codes ...string) (bool, error) {
+	if err == nil {
+		return true, nil
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		for _, code := range codes {
+			if apiErr.ErrorCode() == code {
+				return false, nil
+			}
+		}
+	}
+	return false, err
+}
+
+// bucketExists treats a bucket owned by another account or in another region
+// as taken, since bucket names are global
+func bucketExists(ctx context.Context, clients *awsclients.Clients, name string) (bool, error) {
+	_, err := clients.S3().HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(name)})
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "Forbidden", "MovedPermanently", "PermanentRedirect":
+			return true, nil
+		}
+	}
+	return found(err, "NotFound", "NoSuchBucket")
+}
+
+func roleExists(ctx context.Context, clients *awsclients.Clients, name string) (bool, error) {
+	_, err := clients.IAM().GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(name)})
+	return found(err, "NoSuchEntity")
+}
+
+func databaseExists(ctx context.Context, clients *awsclients.Clients, name string) (bool, error) {
+	_, err := clients.Glue().GetDatabase(ctx, &glue.GetDatabaseInput{Name: aws.String(name)})
+	return found(err, "EntityNotFoundException")
+}
+
+// workgroupExists reads Athena's InvalidRequestException for a missing
+// workgroup from its message, as the service has no dedicated code for it
+func workgroupExists(ctx context.Context, clients *awsclients.Clients, name string) (bool, error) {
+	_, err := clients.Athena().GetWorkGroup(ctx, &athena.GetWorkGroupInput{WorkGroup: aws.String(name)})
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && strings.Contains(strings.ToLower(apiErr.ErrorMessage()), "not found") {
+		return false, nil
+	}
+	return found(err)
+}
+
+func queueExists(ctx context.Context, clients *awsclients.Clients, name string) (bool, error) {
+	_, err := clients.SQS().GetQueueUrl(ctx, &sqs.GetQueueUrlInput{QueueName: aws.String(name)})
+	return found(err, "AWS.SimpleQueueService.NonExistentQueue", "QueueDoesNotExist")
+}
+
+func eventRuleExists(ctx context.Context, clients *awsclients.Clients, name string) (bool, error) {
+	_, err := clients.EventBridge().DescribeRule(ctx, &eventbridge.DescribeRuleInput{Name: aws.String(name)})
+	return found(err, "ResourceNotFoundException")
+}
+
+func functionExists(ctx context.Context, clients *awsclients.Clients, name string) (bool, error) {
+	_, err := clients.Lambda().GetFunction(ctx, &lambda.GetFunctionInput{FunctionName: aws.String(name)})
+	return found(err, "ResourceNotFoundException")
+}
+
+func streamExists(ctx context.Context, clients *awsclients.Clients, name string) (bool, error) {
+	_, err := clients.Kinesis().DescribeStreamSummary(ctx, &kinesis.DescribeStreamSummaryInput{StreamName: aws.String(name)})
+	return found(err, "ResourceNotFoundException")
+}
+
+func aliasExists(ctx context.Context, clients *awsclients.Clients, name string) (bool, error) {
+	_, err := clients.KMS().DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(name)})
+	return found(err, "NotFoundException")
+}
+
+// logGroupExists matches the name exactly, as the API only filters by prefix
+func logGroupExists(ctx context.Context, clients *awsclients.Clients, name string) (bool, error) {
+	output, err := clients.Logs().DescribeLogGroups(ctx, &cloudwatchlogs.DescribeLogGroupsInput{
+		LogGroupNamePrefix: aws.String(name),
+		Limit:              aws.Int32(1),
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(output.LogGroups) > 0 && aws.ToString(output.LogGroups[0].LogGroupName) == name, nil
+}
@@ -0,0 +1,228 @@
+// =============================================================================
+// Resource Naming
+// Unique, rule-abiding names for the resources tests create
+// =============================================================================
+
+// Package naming builds names for test resources that are unique to the run,
+// fit the character set and length limits of their resource type, e.g. 63
+// characters for S3 buckets and 64 for IAM roles, and are checked against
+// those limits, and against resources that already exist, before anything is
+// created with them.
+package naming
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/runprefix"
+)
+
+// Rule is the naming rule of one resource type
+type Rule struct {
+	// Resource names the resource type in messages, e.g. "S3 bucket"
+	Resource string
+
+	MinLength int
+	MaxLength int
+
+	// Lowercase forbids upper case letters
+	Lowercase bool
+
+	// Separator joins the parts of a composed name
+	Separator string
+
+	// chars is the regexp character class of the characters names may use
+	chars string
+
+	// check reports violations the character set and length cannot express
+	check func(name string) []string
+
+	// lookup reports whether a resource with the name exists; nil when the
+	// name alone cannot identify one
+	lookup lookupFunc
+}
+
+// Rules of the resource types tests create or modules name
+var (
+	S3Bucket = Rule{Resource: "S3 bucket", MinLength: 3, MaxLength: 63, Lowercase: true, Separator: "-",
+		chars: `a-z0-9.-`, check: checkBucket, lookup: bucketExists}
+	IAMRole = Rule{Resource: "IAM role", MinLength: 1, MaxLength: 64, Separator: "-",
+		chars: `\w+=,.@-`, lookup: roleExists}
+	IAMPolicy = Rule{Resource: "IAM policy", MinLength: 1, MaxLength: 128, Separator: "-",
+		chars: `\w+=,.@-`}
+	GlueDatabase = Rule{Resource: "Glue database", MinLength: 1, MaxLength: 255, Lowercase: true, Separator: "_",
+		chars: `a-z0-9_-`, lookup: databaseExists}
+	AthenaWorkgroup = Rule{Resource: "Athena workgroup", MinLength: 1, MaxLength: 128, Separator: "-",
+		chars: `a-zA-Z0-9._-`, lookup: workgroupExists}
+	SQSQueue = Rule{Resource: "SQS queue", MinLength: 1, MaxLength: 80, Separator: "-",
+		chars: `a-zA-Z0-9_.-`, check: checkQueue, lookup: queueExists}
+	EventRule = Rule{Resource: "EventBridge rule", MinLength: 1, MaxLength: 64, Separator: "-",
+		chars: `a-zA-Z0-9._-`, lookup: eventRuleExists}
+	LambdaFunction = Rule{Resource: "Lambda function", MinLength: 1, MaxLength: 64, Separator: "-",
+		chars: `a-zA-Z0-9_-`, lookup: functionExists}
+	KinesisStream = Rule{Resource: "Kinesis stream", MinLength: 1, MaxLength: 128, Separator: "-",
+		chars: `a-zA-Z0-9_.-`, lookup: streamExists}
+	KMSAlias = Rule{Resource: "KMS alias", MinLength: 7, MaxLength: 256, Separator: "-",
+		chars: `a-zA-Z0-9:/_-`, check: checkAlias, lookup: aliasExists}
+	LogGroup = Rule{Resource: "log group", MinLength: 1, MaxLength: 512, Separator: "-",
+		chars: `a-zA-Z0-9._/#-`, lookup: logGroupExists}
+	SNSTopic = Rule{Resource: "SNS topic", MinLength: 1, MaxLength: 256, Separator: "-",
+		chars: `a-zA-Z0-9_-`}
+	SecurityGroup = Rule{Resource: "security group", MinLength: 1, MaxLength: 255, Separator: "-",
+		chars: `a-zA-Z0-9 ._\-:/()#,@\[\]+=&;{}!$*`, check: checkSecurityGroup}
+)
+
+// Check lists every way name breaks the rule
+func (r Rule) Check(name string) []string {
+	var problems []string
+	if len(name) < r.MinLength || len(name) > r.MaxLength {
+		problems = append(problems, fmt.Sprintf("%s name %q is %d characters; it must be %d-%d",
+			r.Resource, name, len(name), r.MinLength, r.MaxLength))
+	}
+	// Upper case letters in a lower case name are reported once, below
+	checked := name
+	if r.Lowercase {
+		checked = strings.ToLower(name)
+	}
+	if invalid := regexp.MustCompile(`[^`+r.chars+`]`).FindAllString(checked, -1); len(invalid) > 0 {
+		problems = append(problems, fmt.Sprintf("%s name %q contains %q; only [%s] are allowed",
+			r.Resource, name, strings.Join(unique(invalid), ""), r.chars))
+	}
+	if r.Lowercase && name != strings.ToLower(name) {
+		problems = append(problems, fmt.Sprintf("%s name %q must be lower case", r.Resource, name))
+	}
+	if r.check != nil {
+		for _, problem := range r.check(name) {
+			problems = append(problems, fmt.Sprintf("%s name %q %s", r.Resource, name, problem))
+		}
+	}
+	return problems
+}
+
+// Validate returns an error listing every way name breaks the rule
+func (r Rule) Validate(name string) error {
+	if problems := r.Check(name); len(problems) > 0 {
+		return fmt.Errorf("invalid name: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Compose joins prefix, base and suffix with the rule's separator, replacing
+// characters the rule forbids and shortening base so the name fits
+func (r Rule) Compose(prefix, base, suffix string) string {
+	invalid := regexp.MustCompile(`[^` + r.chars + `]+`)
+	sanitize := func(part string) string {
+		if r.Lowercase {
+			part = strings.ToLower(part)
+		}
+		return invalid.ReplaceAllString(part, r.Separator)
+	}
+	prefix, base, suffix = sanitize(prefix), sanitize(base), sanitize(suffix)
+
+	fixed := 0
+	for _, part := range []string{prefix, suffix} {
+		if part != "" {
+			fixed += len(part) + len(r.Separator)
+		}
+	}
+	if budget := r.MaxLength - fixed; len(base) > budget && budget >= 0 {
+		base = strings.TrimRight(base[:budget], "-_.")
+	}
+
+	var parts []string
+	for _, part := range []string{prefix, base, suffix} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, r.Separator)
+}
+
+// Unique returns a name for base that is prefixed with the run prefix, so the
+// sweeper can find it, and ends in a random suffix, failing t if the result
+// breaks the rule
+func Unique(t testing.TB, rule Rule, base string) string {
+	t.Helper()
+
+	prefix, err := runprefix.Get()
+	require.NoError(t, err)
+
+	name := rule.Compose(prefix, base, strings.ToLower(random.UniqueId()))
+	require.NoError(t, rule.Validate(name))
+	return name
+}
+
+// checkBucket applies the S3 rules beyond the character set
+func checkBucket(name string) []string {
+	var problems []string
+	if name != "" && !isAlphanumeric(name[0]) || name != "" && !isAlphanumeric(name[len(name)-1]) {
+		problems = append(problems, "must start and end with a letter or digit")
+	}
+	if strings.Contains(name, "..") {
+		problems = append(problems, "must not contain adjacent periods")
+	}
+	if net.ParseIP(name) != nil {
+		problems = append(problems, "must not be formatted as an IP address")
+	}
+	for _, reserved := range []string{"xn--", "sthree-", "amzn-s3-demo-"} {
+		if strings.HasPrefix(name, reserved) {
+			problems = append(problems, fmt.Sprintf("must not start with the reserved prefix %q", reserved))
+		}
+	}
+	for _, reserved := range []string{"-s3alias", "--ol-s3", "--x-s3", ".mrap"} {
+		if strings.HasSuffix(name, reserved) {
+			problems = append(problems, fmt.Sprintf("must not end with the reserved suffix %q", reserved))
+		}
+	}
+	return problems
+}
+
+// checkQueue allows the period of a FIFO queue's .fifo suffix and no other
+func checkQueue(name string) []string {
+	if strings.Contains(strings.TrimSuffix(name, ".fifo"), ".") {
+		return []string{"may only contain a period in its .fifo suffix"}
+	}
+	return nil
+}
+
+// checkAlias requires the alias/ prefix and keeps out of the AWS managed namespace
+func checkAlias(name string) []string {
+	switch {
+	case !strings.HasPrefix(name, "alias/"):
+		return []string{`must start with "alias/"`}
+	case strings.HasPrefix(name, "alias/aws/"):
+		return []string{`must not start with the AWS reserved "alias/aws/"`}
+	}
+	return nil
+}
+
+// checkSecurityGroup keeps out of the namespace of security group IDs
+func checkSecurityGroup(name string) []string {
+	if strings.HasPrefix(name, "sg-") {
+		return []string{`must not start with "sg-"`}
+	}
+	return nil
+}
+
+// isAlphanumeric reports whether c is an ASCII letter or digit
+func isAlphanumeric(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// unique returns values without repeats, in first-seen order
+func unique(values []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,85 @@
+package naming
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/runprefix"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tfplan"
+)
+
+func TestCheck(t *testing.T) {
+	assert.Empty(t, S3Bucket.Check("k3x9qa-dl-raw-test-use1-a1b2c3"))
+	assert.Empty(t, IAMRole.Check("k3x9qa-security-test-Glue_Role@ci"))
+	assert.Empty(t, SQSQueue.Check("k3x9qa-test-events.fifo"))
+	assert.Empty(t, KMSAlias.Check("alias/k3x9qa-data-key"))
+
+	assert.Equal(t, []string{
+		`S3 bucket name "Raw_Bucket" contains "_"; only [a-z0-9.-] are allowed`,
+		`S3 bucket name "Raw_Bucket" must be lower case`,
+	}, S3Bucket.Check("Raw_Bucket"))
+	assert.Equal(t, []string{
+		`S3 bucket name "-raw..bucket" must start and end with a letter or digit`,
+		`S3 bucket name "-raw..bucket" must not contain adjacent periods`,
+	}, S3Bucket.Check("-raw..bucket"))
+	assert.Equal(t, []string{`S3 bucket name "10.0.0.1" must not be formatted as an IP address`}, S3Bucket.Check("10.0.0.1"))
+	assert.Equal(t, []string{`S3 bucket name "xn--raw" must not start with the reserved prefix "xn--"`}, S3Bucket.Check("xn--raw"))
+	assert.Equal(t, []string{`S3 bucket name "raw-s3alias" must not end with the reserved suffix "-s3alias"`}, S3Bucket.Check("raw-s3alias"))
+
+	long := strings.Repeat("r", 65)
+	assert.Equal(t, []string{`IAM role name "` + long + `" is 65 characters; it must be 1-64`}, IAMRole.Check(long))
+	assert.Equal(t, []string{`SQS queue name "test.events" may only contain a period in its .fifo suffix`}, SQSQueue.Check("test.events"))
+	assert.Equal(t, []string{`KMS alias name "alias/aws/s3" must not start with the AWS reserved "alias/aws/"`}, KMSAlias.Check("alias/aws/s3"))
+	assert.Equal(t, []string{`security group name "sg-data" must not start with "sg-"`}, SecurityGroup.Check("sg-data"))
+
+	assert.ErrorContains(t, GlueDatabase.Validate("raw db"), `contains " "`)
+}
+
+func TestCompose(t *testing.T) {
+	assert.Equal(t, "k3x9qa-test-s3-events-a1b2c3", SQSQueue.Compose("k3x9qa", "test-s3-events", "a1b2c3"))
+	assert.Equal(t, "k3x9qa_athena_test_a1b2c3", GlueDatabase.Compose("k3x9qa", "Athena Test", "a1b2c3"))
+	assert.Equal(t, "k3x9qa-test-lp", S3Bucket.Compose("k3x9qa", "Test_LP", ""))
+
+	// The base is shortened so the prefix and unique suffix always survive
+	name := S3Bucket.Compose("k3x9qa", strings.Repeat("raw-", 20), "a1b2c3d")
+	assert.Len(t, name, 62, "the trailing separator of the shortened base is dropped")
+	assert.True(t, strings.HasPrefix(name, "k3x9qa-raw-"))
+	assert.True(t, strings.HasSuffix(name, "-raw-a1b2c3d"))
+	assert.Empty(t, S3Bucket.Check(name))
+}
+
+func TestUnique(t *testing.T) {
+	t.Setenv(runprefix.EnvVar, "k3x9qa")
+
+	first := Unique(t, IAMRole, "test-glue-role")
+	second := Unique(t, IAMRole, "test-glue-role")
+	assert.NotEqual(t, first, second)
+	assert.Regexp(t, `^[a-z0-9]+-test-glue-role-[a-z0-9]{6}$`, first)
+}
+
+func TestCheckPlan(t *testing.T) {
+	plan, err := tfplan.Parse(`{
+  "format_version": "1.2",
+  "planned_values": {"root_module": {"resources": [
+    {"address": "aws_s3_bucket.raw", "mode": "managed", "type": "aws_s3_bucket", "name": "raw",
+     "values": {"bucket": "k3x9qa-dl-raw-test-use1-a1b2c3"}},
+    {"address": "aws_s3_bucket.curated", "mode": "managed", "type": "aws_s3_bucket", "name": "curated",
+     "values": {"bucket": "K3X9QA_curated"}},
+    {"address": "aws_iam_role.glue", "mode": "managed", "type": "aws_iam_role", "name": "glue",
+     "values": {"name": "` + strings.Repeat("g", 70) + `"}},
+    {"address": "aws_iam_role.computed", "mode": "managed", "type": "aws_iam_role", "name": "computed",
+     "values": {}},
+    {"address": "aws_vpc.main", "mode": "managed", "type": "aws_vpc", "name": "main",
+     "values": {"cidr_block": "10.0.0.0/16"}}
+  ]}}
+}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`aws_iam_role.glue: IAM role name "` + strings.Repeat("g", 70) + `" is 70 characters; it must be 1-64`,
+		`aws_s3_bucket.curated: S3 bucket name "K3X9QA_curated" contains "_"; only [a-z0-9.-] are allowed`,
+		`aws_s3_bucket.curated: S3 bucket name "K3X9QA_curated" must be lower case`,
+	}, CheckPlan(plan))
+}
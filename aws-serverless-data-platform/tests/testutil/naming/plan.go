@@ -0,0 +1,62 @@
+package naming
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tfplan"
+)
+
+// planned names the attribute holding each resource type's name and its rule
+var planned = map[string]struct {
+	attribute string
+	rule      Rule
+}{
+	"aws_s3_bucket":             {"bucket", S3Bucket},
+	"aws_iam_role":              {"name", IAMRole},
+	"aws_iam_policy":            {"name", IAMPolicy},
+	"aws_glue_catalog_database": {"name", GlueDatabase},
+	"aws_athena_workgroup":      {"name", AthenaWorkgroup},
+	"aws_sqs_queue":             {"name", SQSQueue},
+	"aws_cloudwatch_event_rule": {"name", EventRule},
+	"aws_lambda_function":       {"function_name", LambdaFunction},
+	"aws_kinesis_stream":        {"name", KinesisStream},
+	"aws_kms_alias":             {"name", KMSAlias},
+	"aws_cloudwatch_log_group":  {"name", LogGroup},
+	"aws_sns_topic":             {"name", SNSTopic},
+	"aws_security_group":        {"name", SecurityGroup},
+}
+
+// CheckPlan lists the planned resource names that break their type's rule;
+// names only known after apply are skipped
+func CheckPlan(plan *tfplan.Plan) []string {
+	var problems []string
+	for _, resource := range plan.Resources() {
+		naming, ok := planned[resource.Type]
+		if !ok {
+			continue
+		}
+		name, ok := resource.Values[naming.attribute].(string)
+		if !ok {
+			continue
+		}
+		for _, problem := range naming.rule.Check(name) {
+			problems = append(problems, fmt.Sprintf("%s: %s", resource.Address, problem))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// AssertPlan fails t for every planned name AWS would reject, so a name that
+// grew past its limit fails the plan test instead of the apply
+func AssertPlan(t *testing.T, plan *tfplan.Plan) bool {
+	t.Helper()
+
+	problems := CheckPlan(plan)
+	for _, problem := range problems {
+		t.Error(problem)
+	}
+	return len(problems) == 0
+}
@@ -0,0 +1,242 @@
+// =============================================================================
+// Network Path Assertions
+// Proves paths through a VPC open or blocked with the VPC Reachability Analyzer
+// =============================================================================
+
+// Package netpath asserts network paths through a deployed VPC without
+// sending a packet: it creates Network Insights paths, e.g. from a private
+// subnet ENI to an interface endpoint, runs the Reachability Analyzer on them
+// and compares whether each path was found with whether it should be. Blocked
+// paths are reported with the analyzer's explanation, such as the network ACL
+// or missing route that stops them.
+package netpath
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/runprefix"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/wait"
+)
+
+// analysisTimeout bounds one analysis; most finish within two minutes
+const analysisTimeout = 10 * time.Minute
+
+// Path is one network path and whether it should be open
+type Path struct {
+	// Description names the path in failure messages, e.g. "private subnet to the STS endpoint"
+	Description string
+
+	// Source and Destination are the IDs of ENIs, instances, gateways or VPC
+	// endpoints at either end of the path
+	Source      string
+	Destination string
+
+	// Protocol is "tcp" or "udp"
+	Protocol string
+	Port     int32
+
+	// Reachable is true for a path that must be open and false for one that
+	// must be blocked
+	Reachable bool
+}
+
+// Result is the outcome of analyzing one path
+type Result struct {
+	Path       Path
+	AnalysisID string
+	Found      bool
+
+	// Explanations say why a path was not found, e.g. "ENI_SG_RULES_MISMATCH at sg-0abc"
+	Explanations []string
+}
+
+// Check lists the paths whose reachability is not what they declare
+func Check(results []Result) []string {
+	var problems []string
+	for _, result := range results {
+		path := result.Path
+		switch {
+		case path.Reachable && !result.Found:
+			problems = append(problems, fmt.Sprintf("%s (%s -> %s %s/%d) should be reachable but is blocked: %s",
+				path.Description, path.Source, path.Destination, path.Protocol, path.Port, strings.Join(result.Explanations, "; ")))
+		case !path.Reachable && result.Found:
+			problems = append(problems, fmt.Sprintf("%s (%s -> %s %s/%d) should be blocked but is reachable",
+				path.Description, path.Source, path.Destination, path.Protocol, path.Port))
+		}
+	}
+	return problems
+}
+
+// Assert analyzes every path and fails t for each whose reachability is not
+// what it declares; the paths and their analyses are deleted when t finishes
+func Assert(t *testing.T, clients *awsclients.Clients, paths ...Path) bool {
+	t.Helper()
+	client := clients.EC2()
+	logger := logging.New(t)
+
+	// The analyses run concurrently in the service, so start them all before waiting
+	analysisIDs := make([]string, len(paths))
+	for i, path := range paths {
+		analysisIDs[i] = start(t, clients, path)
+	}
+
+	results := make([]Result, len(paths))
+	for i, path := range paths {
+		var analysis *ec2types.NetworkInsightsAnalysis
+		wait.Until(t, "reachability analysis of "+path.Description,
+			wait.AnalysisFinished(client, analysisIDs[i], &analysis), wait.DefaultOptions().WithTimeout(analysisTimeout))
+
+		results[i] = Result{
+			Path:         path,
+			AnalysisID:   analysisIDs[i],
+			Found:        awssdk.ToBool(analysis.NetworkPathFound),
+			Explanations: explain(analysis.Explanations),
+		}
+		logger.Debug("Analyzed network path", "path", path.Description, "found", results[i].Found, "analysis", analysisIDs[i])
+	}
+
+	problems := Check(results)
+	for _, problem := range problems {
+		t.Error(problem)
+	}
+	if len(problems) == 0 {
+		logger.Success("Network paths match their expected reachability", "paths", len(paths))
+	}
+	return len(problems) == 0
+}
+
+// start creates the Network Insights path and starts its analysis, returning
+// the analysis ID
+func start(t *testing.T, clients *awsclients.Clients, path Path) string {
+	client := clients.EC2()
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	created, err := client.CreateNetworkInsightsPath(ctx, &ec2.CreateNetworkInsightsPathInput{
+		ClientToken:       awssdk.String(random.UniqueId()),
+		Source:            awssdk.String(path.Source),
+		Destination:       awssdk.String(path.Destination),
+		Protocol:          ec2types.Protocol(path.Protocol),
+		DestinationPort:   awssdk.Int32(path.Port),
+		TagSpecifications: tags(t, ec2types.ResourceTypeNetworkInsightsPath, path.Description),
+	})
+	require.NoError(t, err, "Failed to create network insights path for %s", path.Description)
+	pathID := awssdk.ToString(created.NetworkInsightsPath.NetworkInsightsPathId)
+
+	started, err := client.StartNetworkInsightsAnalysis(ctx, &ec2.StartNetworkInsightsAnalysisInput{
+		ClientToken:           awssdk.String(random.UniqueId()),
+		NetworkInsightsPathId: awssdk.String(pathID),
+	})
+	if err != nil {
+		deletePath(t, clients, pathID, "")
+	}
+	require.NoError(t, err, "Failed to start reachability analysis of %s", path.Description)
+	analysisID := awssdk.ToString(started.NetworkInsightsAnalysis.NetworkInsightsAnalysisId)
+
+	t.Cleanup(func() { deletePath(t, clients, pathID, analysisID) })
+	return analysisID
+}
+
+// deletePath deletes the path and, when set, its analysis, which must go first
+func deletePath(t *testing.T, clients *awsclients.Clients, pathID, analysisID string) {
+	client := clients.EC2()
+	if analysisID != "" {
+		// A still-running analysis cannot be deleted, e.g. after a timeout
+		_ = wait.WaitFor(context.Background(), wait.AnalysisFinished(client, analysisID, nil), wait.DefaultOptions())
+	}
+
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	if analysisID != "" {
+		if _, err := client.DeleteNetworkInsightsAnalysis(ctx, &ec2.DeleteNetworkInsightsAnalysisInput{
+			NetworkInsightsAnalysisId: awssdk.String(analysisID),
+		}); err != nil {
+			logging.New(t).Warn("Failed to delete network insights analysis", "analysis", analysisID, "error", err)
+		}
+	}
+	if _, err := client.DeleteNetworkInsightsPath(ctx, &ec2.DeleteNetworkInsightsPathInput{
+		NetworkInsightsPathId: awssdk.String(pathID),
+	}); err != nil {
+		logging.New(t).Warn("Failed to delete network insights path", "path", pathID, "error", err)
+	}
+}
+
+// tags names a resource the helper creates and marks it with the run prefix,
+// so the sweeper finds it if the test dies before its cleanup
+func tags(t *testing.T, resourceType ec2types.ResourceType, name string) []ec2types.TagSpecification {
+	prefix, err := runprefix.Get()
+	require.NoError(t, err)
+
+	return []ec2types.TagSpecification{{
+		ResourceType: resourceType,
+		Tags: []ec2types.Tag{
+			{Key: awssdk.String("Name"), Value: awssdk.String(fmt.Sprintf("%s-%s", prefix, name))},
+			{Key: awssdk.String(runprefix.Tag), Value: awssdk.String(prefix)},
+		},
+	}}
+}
+
+// explain renders each explanation as its code and the component it concerns
+func explain(explanations []ec2types.Explanation) []string {
+	var rendered []string
+	for _, explanation := range explanations {
+		code := awssdk.ToString(explanation.ExplanationCode)
+		component := firstComponent(explanation.Acl, explanation.SecurityGroup, explanation.RouteTable,
+			explanation.Subnet, explanation.Component)
+		if component != "" {
+			code += " at " + component
+		}
+		rendered = append(rendered, code)
+	}
+	return rendered
+}
+
+// firstComponent returns the ID of the first component that is set
+func firstComponent(components ...*ec2types.AnalysisComponent) string {
+	for _, component := range components {
+		if component != nil && component.Id != nil {
+			return awssdk.ToString(component.Id)
+		}
+	}
+	return ""
+}
+
+// NewProbe creates an unattached ENI in subnetID to stand at one end of a
+// path, in the VPC's default security group unless securityGroupIDs are
+// given, and deletes it when t finishes
+func NewProbe(t *testing.T, clients *awsclients.Clients, subnetID, description string, securityGroupIDs ...string) string {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	created, err := clients.EC2().CreateNetworkInterface(ctx, &ec2.CreateNetworkInterfaceInput{
+		SubnetId:          awssdk.String(subnetID),
+		Description:       awssdk.String("netpath probe: " + description),
+		Groups:            securityGroupIDs,
+		TagSpecifications: tags(t, ec2types.ResourceTypeNetworkInterface, "netpath-"+description),
+	})
+	require.NoError(t, err, "Failed to create probe ENI in %s", subnetID)
+	eniID := awssdk.ToString(created.NetworkInterface.NetworkInterfaceId)
+
+	t.Cleanup(func() {
+		ctx, cancel := clients.Context()
+		defer cancel()
+		if _, err := clients.EC2().DeleteNetworkInterface(ctx, &ec2.DeleteNetworkInterfaceInput{
+			NetworkInterfaceId: awssdk.String(eniID),
+		}); err != nil {
+			logging.New(t).Warn("Failed to delete probe ENI", "eni", eniID, "error", err)
+		}
+	})
+	return eniID
+}
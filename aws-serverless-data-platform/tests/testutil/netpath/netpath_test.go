@@ -0,0 +1,46 @@
+package netpath
+
+import (
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	endpoint := Path{Description: "private to STS", Source: "eni-priv", Destination: "vpce-sts", Protocol: "tcp", Port: 443, Reachable: true}
+	database := Path{Description: "public to database", Source: "eni-pub", Destination: "eni-db", Protocol: "tcp", Port: 5432}
+
+	assert.Empty(t, Check([]Result{
+		{Path: endpoint, Found: true},
+		{Path: database, Found: false, Explanations: []string{"ENI_ACL_RULES_MISMATCH at acl-0db"}},
+	}))
+
+	assert.Equal(t, []string{
+		"private to STS (eni-priv -> vpce-sts tcp/443) should be reachable but is blocked: ENI_SG_RULES_MISMATCH at sg-0ep; NO_ROUTE_TO_DESTINATION at rtb-0priv",
+		"public to database (eni-pub -> eni-db tcp/5432) should be blocked but is reachable",
+	}, Check([]Result{
+		{Path: endpoint, Found: false, Explanations: []string{"ENI_SG_RULES_MISMATCH at sg-0ep", "NO_ROUTE_TO_DESTINATION at rtb-0priv"}},
+		{Path: database, Found: true},
+	}))
+}
+
+func TestExplain(t *testing.T) {
+	assert.Equal(t, []string{
+		"ENI_ACL_RULES_MISMATCH at acl-0db",
+		"NO_ROUTE_TO_DESTINATION at rtb-0priv",
+		"CANNOT_ROUTE",
+	}, explain([]ec2types.Explanation{
+		{
+			ExplanationCode: awssdk.String("ENI_ACL_RULES_MISMATCH"),
+			Acl:             &ec2types.AnalysisComponent{Id: awssdk.String("acl-0db")},
+			Subnet:          &ec2types.AnalysisComponent{Id: awssdk.String("subnet-0db")},
+		},
+		{
+			ExplanationCode: awssdk.String("NO_ROUTE_TO_DESTINATION"),
+			RouteTable:      &ec2types.AnalysisComponent{Id: awssdk.String("rtb-0priv")},
+		},
+		{ExplanationCode: awssdk.String("CANNOT_ROUTE")},
+	}))
+}
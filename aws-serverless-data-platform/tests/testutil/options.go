@@ -0,0 +1,230 @@
+// =============================================================================
+// Test Options
+// Functional options shared by all module variable builders
+// =============================================================================
+
+package testutil
+
+import (
+	"strings"
+
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/runprefix"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/testconfig"
+)
+
+const (
+	// DefaultRegion is the AWS region used when no region is configured
+	DefaultRegion = testconfig.DefaultRegion
+
+	// DefaultEnvironment is the environment name used when none is configured
+	DefaultEnvironment = testconfig.DefaultEnvironment
+
+	// DefaultProjectTag is the Project tag applied to every test resource
+	DefaultProjectTag = "terratest"
+
+	// RunTag carries the unique suffix so one run's resources can be found by tag
+	RunTag = "TestRun"
+
+	// RunPrefixTag carries the run prefix shared by every module a test run deploys
+	RunPrefixTag = runprefix.Tag
+
+	// BaseStackTag marks the long-lived test base stack in tests/fixtures/base,
+	// which the sweeper never deletes
+	BaseStackTag = "TestBaseStack"
+)
+
+// Settings holds the values shared by every module variable builder
+type Settings struct {
+	Region       string
+	Environment  string
+	AccountID    string
+	UniqueSuffix string
+	Tags         map[string]string
+
+	// RunPrefix is put in front of resource names so concurrent runs by
+	// different engineers do not collide; see runprefix.Get
+	RunPrefix string
+
+	// VPCID is passed to modules that attach to an existing VPC
+	VPCID string
+
+	// RoleARN and ExternalID select the account Terraform runs against;
+	// an empty RoleARN uses the ambient credentials
+	RoleARN    string
+	ExternalID string
+
+	// Overrides replace top-level Terraform variables after the builder
+	// has populated its defaults
+	Overrides map[string]interface{}
+
+	// Networking-specific settings
+	VPCCIDR          string
+	SingleNATGateway bool
+	FlowLogsEnabled  bool
+	FlowLogRetention int
+}
+
+// Option mutates Settings before a builder renders its variable map
+type Option func(*Settings)
+
+// NewSettings applies opts on top of the layered test configuration; it
+// panics if the configuration is invalid, which Main reports up front
+func NewSettings(opts ...Option) *Settings {
+	config := testconfig.MustLoad()
+	prefix, err := runprefix.Get()
+	if err != nil {
+		panic(err)
+	}
+
+	settings := &Settings{
+		RunPrefix:        prefix,
+		Region:           config.Region(),
+		Environment:      config.Environment,
+		AccountID:        config.AccountID,
+		VPCID:            config.VPCID,
+		Tags:             map[string]string{},
+		Overrides:        map[string]interface{}{},
+		VPCCIDR:          config.VPCCIDR,
+		FlowLogsEnabled:  true,
+		FlowLogRetention: 30,
+	}
+	for key, value := range config.Tags {
+		settings.Tags[key] = value
+	}
+
+	for _, opt := range opts {
+		opt(settings)
+	}
+
+	return settings
+}
+
+// WithRegion sets the AWS region resources are created in
+func WithRegion(region string) Option {
+	return func(s *Settings) {
+		s.Region = region
+	}
+}
+
+// WithEnvironment sets the environment variable passed to the module
+func WithEnvironment(environment string) Option {
+	return func(s *Settings) {
+		s.Environment = environment
+	}
+}
+
+// WithAccountID sets the AWS account ID passed to the module
+func WithAccountID(accountID string) Option {
+	return func(s *Settings) {
+		s.AccountID = accountID
+	}
+}
+
+// WithAssumeRole runs Terraform with credentials from assuming roleARN; externalID may be empty
+func WithAssumeRole(roleARN, externalID string) Option {
+	return func(s *Settings) {
+		s.RoleARN = roleARN
+		s.ExternalID = externalID
+	}
+}
+
+// WithUniqueSuffix appends suffix to resource names so parallel tests do not collide
+func WithUniqueSuffix(suffix string) Option {
+	return func(s *Settings) {
+		s.UniqueSuffix = strings.ToLower(suffix)
+	}
+}
+
+// WithRunPrefix replaces the run prefix, e.g. to keep plan snapshots stable
+func WithRunPrefix(prefix string) Option {
+	return func(s *Settings) {
+		s.RunPrefix = prefix
+	}
+}
+
+// WithTag adds a tag to the common_tags passed to the module
+func WithTag(key, value string) Option {
+	return func(s *Settings) {
+		s.Tags[key] = value
+	}
+}
+
+// WithVar overrides a top-level Terraform variable
+func WithVar(name string, value interface{}) Option {
+	return func(s *Settings) {
+		s.Overrides[name] = value
+	}
+}
+
+// WithVPCCIDR sets the VPC CIDR; subnet CIDRs are derived from it
+func WithVPCCIDR(cidr string) Option {
+	return func(s *Settings) {
+		s.VPCCIDR = cidr
+	}
+}
+
+// WithVPCID sets the VPC passed to modules that attach to an existing one
+func WithVPCID(vpcID string) Option {
+	return func(s *Settings) {
+		s.VPCID = vpcID
+	}
+}
+
+// WithSingleNATGateway deploys one shared NAT gateway instead of one per AZ
+func WithSingleNATGateway() Option {
+	return func(s *Settings) {
+		s.SingleNATGateway = true
+	}
+}
+
+// WithFlowLogs toggles VPC flow logs and sets their retention
+func WithFlowLogs(enable bool, retentionDays int) Option {
+	return func(s *Settings) {
+		s.FlowLogsEnabled = enable
+		s.FlowLogRetention = retentionDays
+	}
+}
+
+// name puts the run prefix in front of base and appends the unique suffix, if any
+func (s *Settings) name(base, separator string) string {
+	name := s.prefixed(base, separator)
+	if s.UniqueSuffix == "" {
+		return name
+	}
+	return name + separator + s.UniqueSuffix
+}
+
+// prefixed puts the run prefix, if any, in front of base
+func (s *Settings) prefixed(base, separator string) string {
+	if s.RunPrefix == "" {
+		return base
+	}
+	return s.RunPrefix + separator + base
+}
+
+// commonTags returns the tags applied to every test resource
+func (s *Settings) commonTags() map[string]interface{} {
+	tags := map[string]interface{}{
+		"Environment": s.Environment,
+		"Project":     DefaultProjectTag,
+		"Testing":     "true",
+	}
+	if s.UniqueSuffix != "" {
+		tags[RunTag] = s.UniqueSuffix
+	}
+	if s.RunPrefix != "" {
+		tags[RunPrefixTag] = s.RunPrefix
+	}
+	for key, value := range s.Tags {
+		tags[key] = value
+	}
+	return tags
+}
+
+// apply merges the overrides into vars
+func (s *Settings) apply(vars map[string]interface{}) map[string]interface{} {
+	for name, value := range s.Overrides {
+		vars[name] = value
+	}
+	return vars
+}
@@ -0,0 +1,215 @@
+// =============================================================================
+// Parquet Output Schema
+// Reads Parquet footers and checks columns, codec and row counts
+// =============================================================================
+
+// Package parquet verifies the files Glue jobs write to the processed and
+// curated buckets against an expected schema, so drift in a job's output
+// fails the ETL tests instead of surfacing in Athena queries later.
+package parquet
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// Column is a top-level column and its type in Glue catalog notation, e.g.
+// "bigint", "string", "timestamp" or "decimal(10,2)"
+type Column struct {
+	Name string
+	Type string
+}
+
+// Schema is the expected shape of a job's output
+type Schema struct {
+	// Columns in file order
+	Columns []Column
+
+	// Codec is the compression every column chunk must use, e.g. "SNAPPY";
+	// empty accepts any
+	Codec string
+
+	// MinRows and MaxRows bound the row count; a zero MaxRows has no upper bound
+	MinRows int64
+	MaxRows int64
+}
+
+// File is what was read from one file's footer
+type File struct {
+	Columns []Column
+	Codecs  []string
+	Rows    int64
+}
+
+// Inspect reads the footer of the Parquet file in r
+func Inspect(r io.ReaderAt, size int64) (File, error) {
+	f, err := pq.OpenFile(r, size, pq.SkipPageIndex(true), pq.SkipBloomFilters(true))
+	if err != nil {
+		return File{}, err
+	}
+	metadata := f.Metadata()
+
+	file := File{
+		Columns: columns(metadata.Schema),
+		Rows:    metadata.NumRows,
+	}
+	seen := map[string]bool{}
+	for _, group := range metadata.RowGroups {
+		for _, chunk := range group.Columns {
+			codec := chunk.MetaData.Codec.String()
+			if !seen[codec] {
+				seen[codec] = true
+				file.Codecs = append(file.Codecs, codec)
+			}
+		}
+	}
+	return file, nil
+}
+
+// Check returns how file differs from expected; rows are checked by the
+// caller when a job's output spans several files
+func Check(file File, expected Schema) []string {
+	var problems []string
+
+	actual := map[string]string{}
+	for _, column := range file.Columns {
+		actual[column.Name] = column.Type
+	}
+	wanted := map[string]bool{}
+	for i, column := range expected.Columns {
+		wanted[column.Name] = true
+		got, ok := actual[column.Name]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("missing column %s %s", column.Name, column.Type))
+		case !strings.EqualFold(got, column.Type):
+			problems = append(problems, fmt.Sprintf("column %s is %s, expected %s", column.Name, got, column.Type))
+		case i < len(file.Columns) && file.Columns[i].Name != column.Name:
+			problems = append(problems, fmt.Sprintf("column %s is at position %d, expected %d", column.Name, position(file.Columns, column.Name), i))
+		}
+	}
+	for _, column := range file.Columns {
+		if !wanted[column.Name] {
+			problems = append(problems, fmt.Sprintf("unexpected column %s %s", column.Name, column.Type))
+		}
+	}
+
+	if expected.Codec != "" {
+		for _, codec := range file.Codecs {
+			if !strings.EqualFold(codec, expected.Codec) {
+				problems = append(problems, fmt.Sprintf("compressed with %s, expected %s", codec, expected.Codec))
+			}
+		}
+	}
+	return problems
+}
+
+// CheckRows returns a problem when rows is outside the schema's bounds
+func CheckRows(rows int64, expected Schema) string {
+	if rows < expected.MinRows || (expected.MaxRows > 0 && rows > expected.MaxRows) {
+		if expected.MaxRows > 0 {
+			return fmt.Sprintf("%d rows, expected between %d and %d", rows, expected.MinRows, expected.MaxRows)
+		}
+		return fmt.Sprintf("%d rows, expected at least %d", rows, expected.MinRows)
+	}
+	return ""
+}
+
+// position returns the index of the named column, or -1
+func position(columns []Column, name string) int {
+	for i, column := range columns {
+		if column.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// columns returns the top-level columns of a depth-first schema, whose first
+// element is the root; nested groups are reported as struct, array or map
+func columns(elements []format.SchemaElement) []Column {
+	if len(elements) == 0 {
+		return nil
+	}
+
+	var result []Column
+	i := 1
+	for child := int32(0); child < elements[0].NumChildren && i < len(elements); child++ {
+		element := elements[i]
+		result = append(result, Column{Name: element.Name, Type: glueType(element)})
+		i = skip(elements, i)
+	}
+	return result
+}
+
+// skip returns the index after the element at i and all its descendants
+func skip(elements []format.SchemaElement, i int) int {
+	children := elements[i].NumChildren
+	i++
+	for ; children > 0 && i < len(elements); children-- {
+		i = skip(elements, i)
+	}
+	return i
+}
+
+// glueType names a schema element's type the way the Glue catalog and Athena do
+func glueType(element format.SchemaElement) string {
+	logical := element.LogicalType
+	converted := element.ConvertedType
+	is := func(c deprecated.ConvertedType) bool { return converted != nil && *converted == c }
+
+	if element.Type == nil {
+		switch {
+		case (logical != nil && logical.List != nil) || is(deprecated.List):
+			return "array"
+		case (logical != nil && logical.Map != nil) || is(deprecated.Map) || is(deprecated.MapKeyValue):
+			return "map"
+		default:
+			return "struct"
+		}
+	}
+
+	if logical != nil && logical.Decimal != nil {
+		return fmt.Sprintf("decimal(%d,%d)", logical.Decimal.Precision, logical.Decimal.Scale)
+	}
+	if is(deprecated.Decimal) && element.Precision != nil && element.Scale != nil {
+		return fmt.Sprintf("decimal(%d,%d)", *element.Precision, *element.Scale)
+	}
+
+	switch *element.Type {
+	case format.Boolean:
+		return "boolean"
+	case format.Int32:
+		switch {
+		case (logical != nil && logical.Date != nil) || is(deprecated.Date):
+			return "date"
+		case (logical != nil && logical.Integer != nil && logical.Integer.BitWidth == 8) || is(deprecated.Int8):
+			return "tinyint"
+		case (logical != nil && logical.Integer != nil && logical.Integer.BitWidth == 16) || is(deprecated.Int16):
+			return "smallint"
+		}
+		return "int"
+	case format.Int64:
+		if (logical != nil && logical.Timestamp != nil) || is(deprecated.TimestampMillis) || is(deprecated.TimestampMicros) {
+			return "timestamp"
+		}
+		return "bigint"
+	case format.Int96:
+		return "timestamp"
+	case format.Float:
+		return "float"
+	case format.Double:
+		return "double"
+	case format.ByteArray:
+		if (logical != nil && (logical.UTF8 != nil || logical.Enum != nil || logical.Json != nil)) ||
+			is(deprecated.UTF8) || is(deprecated.Enum) || is(deprecated.Json) {
+			return "string"
+		}
+	}
+	return "binary"
+}
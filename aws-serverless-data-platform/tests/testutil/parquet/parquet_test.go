@@ -0,0 +1,110 @@
+package parquet
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type event struct {
+	ID        int64     `parquet:"id"`
+	Name      string    `parquet:"name"`
+	Amount    float64   `parquet:"amount"`
+	Active    bool      `parquet:"active"`
+	CreatedAt time.Time `parquet:"created_at,timestamp(millisecond)"`
+	Tags      []string  `parquet:"tags,list"`
+}
+
+// write encodes rows as a Snappy-compressed Parquet file
+func write(t *testing.T, rows []event) []byte {
+	var buf bytes.Buffer
+	writer := pq.NewGenericWriter[event](&buf, pq.Compression(&snappy.Codec{}))
+	_, err := writer.Write(rows)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+var eventSchema = Schema{
+	Columns: []Column{
+		{Name: "id", Type: "bigint"},
+		{Name: "name", Type: "string"},
+		{Name: "amount", Type: "double"},
+		{Name: "active", Type: "boolean"},
+		{Name: "created_at", Type: "timestamp"},
+		{Name: "tags", Type: "array"},
+	},
+	Codec:   "SNAPPY",
+	MinRows: 1,
+}
+
+func TestInspect(t *testing.T) {
+	content := write(t, []event{
+		{ID: 1, Name: "signup", Amount: 9.5, Active: true, CreatedAt: time.Now(), Tags: []string{"web"}},
+		{ID: 2, Name: "purchase", Amount: 20, CreatedAt: time.Now()},
+	})
+
+	file, err := Inspect(bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+
+	assert.Equal(t, eventSchema.Columns, file.Columns)
+	assert.Equal(t, []string{"SNAPPY"}, file.Codecs)
+	assert.Equal(t, int64(2), file.Rows)
+	assert.Empty(t, Check(file, eventSchema))
+
+	_, err = Inspect(bytes.NewReader([]byte("id,name\n1,signup\n")), 17)
+	assert.Error(t, err, "CSV must not pass as Parquet")
+}
+
+func TestCheck(t *testing.T) {
+	file := File{
+		Columns: []Column{
+			{Name: "name", Type: "string"},
+			{Name: "id", Type: "int"},
+			{Name: "amount", Type: "double"},
+			{Name: "debug", Type: "string"},
+		},
+		Codecs: []string{"GZIP"},
+	}
+	expected := Schema{
+		Columns: []Column{
+			{Name: "id", Type: "bigint"},
+			{Name: "name", Type: "string"},
+			{Name: "amount", Type: "DOUBLE"},
+			{Name: "region", Type: "string"},
+		},
+		Codec: "snappy",
+	}
+
+	assert.Equal(t, []string{
+		"column id is int, expected bigint",
+		"column name is at position 0, expected 1",
+		"missing column region string",
+		"unexpected column debug string",
+		"compressed with GZIP, expected snappy",
+	}, Check(file, expected))
+}
+
+func TestCheckRows(t *testing.T) {
+	assert.Empty(t, CheckRows(5, Schema{MinRows: 1}))
+	assert.Empty(t, CheckRows(5, Schema{MinRows: 5, MaxRows: 5}))
+	assert.Equal(t, "0 rows, expected at least 1", CheckRows(0, Schema{MinRows: 1}))
+	assert.Equal(t, "6 rows, expected between 1 and 5", CheckRows(6, Schema{MinRows: 1, MaxRows: 5}))
+}
+
+func TestSchemaFromTable(t *testing.T) {
+	table := &gluetypes.Table{StorageDescriptor: &gluetypes.StorageDescriptor{Columns: []gluetypes.Column{
+		{Name: aws.String("id"), Type: aws.String("bigint")},
+		{Name: aws.String("price"), Type: aws.String("decimal(10,2)")},
+	}}}
+
+	assert.Equal(t, []Column{{Name: "id", Type: "bigint"}, {Name: "price", Type: "decimal(10,2)"}}, SchemaFromTable(table))
+	assert.Empty(t, SchemaFromTable(&gluetypes.Table{}))
+}
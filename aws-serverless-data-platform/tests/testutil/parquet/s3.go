@@ -0,0 +1,122 @@
+package parquet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+)
+
+// Suffix marks the data files under a job's output prefix; markers such as
+// _SUCCESS and Spark's .crc files are ignored
+const Suffix = ".parquet"
+
+// SchemaFromTable returns the columns of a catalog table, so a job's output
+// can be checked against the table Athena queries it through
+func SchemaFromTable(table *gluetypes.Table) []Column {
+	var columns []Column
+	if table == nil || table.StorageDescriptor == nil {
+		return columns
+	}
+	for _, column := range table.StorageDescriptor.Columns {
+		columns = append(columns, Column{Name: aws.ToString(column.Name), Type: aws.ToString(column.Type)})
+	}
+	return columns
+}
+
+// Read downloads s3://bucket/key and reads its footer
+func Read(ctx context.Context, client *s3.Client, bucket, key string) (File, error) {
+	output, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return File{}, fmt.Errorf("get s3://%s/%s: %w", bucket, key, err)
+	}
+	defer output.Body.Close()
+
+	content, err := io.ReadAll(output.Body)
+	if err != nil {
+		return File{}, fmt.Errorf("read s3://%s/%s: %w", bucket, key, err)
+	}
+	file, err := Inspect(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return File{}, fmt.Errorf("s3://%s/%s is not a Parquet file: %w", bucket, key, err)
+	}
+	return file, nil
+}
+
+// AssertObject checks one Parquet object against expected, including its row count
+func AssertObject(t *testing.T, clients *awsclients.Clients, bucket, key string, expected Schema) File {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	file, err := Read(ctx, clients.S3(), bucket, key)
+	require.NoError(t, err)
+
+	for _, problem := range Check(file, expected) {
+		t.Errorf("s3://%s/%s: %s", bucket, key, problem)
+	}
+	if problem := CheckRows(file.Rows, expected); problem != "" {
+		t.Errorf("s3://%s/%s: %s", bucket, key, problem)
+	}
+	return file
+}
+
+// AssertPrefix checks every Parquet object under prefix against expected and
+// their combined row count, failing when there are none
+func AssertPrefix(t *testing.T, clients *awsclients.Clients, bucket, prefix string, expected Schema) int64 {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	var keys []string
+	paginator := s3.NewListObjectsV2Paginator(clients.S3(), &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		require.NoError(t, err, "Failed to list s3://%s/%s", bucket, prefix)
+		for _, object := range page.Contents {
+			if key := aws.ToString(object.Key); strings.HasSuffix(key, Suffix) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	require.NotEmpty(t, keys, "No Parquet files under s3://%s/%s", bucket, prefix)
+
+	var rows int64
+	failed := false
+	for _, key := range keys {
+		file, err := Read(ctx, clients.S3(), bucket, key)
+		if !assert.NoError(t, err) {
+			failed = true
+			continue
+		}
+		for _, problem := range Check(file, expected) {
+			t.Errorf("s3://%s/%s: %s", bucket, key, problem)
+			failed = true
+		}
+		rows += file.Rows
+	}
+	if problem := CheckRows(rows, expected); problem != "" {
+		t.Errorf("s3://%s/%s: %s", bucket, prefix, problem)
+		failed = true
+	}
+
+	if !failed {
+		logging.New(t).Success("Parquet output matches its schema",
+			"prefix", "s3://"+bucket+"/"+prefix, "files", len(keys), "rows", rows)
+	}
+	return rows
+}
@@ -0,0 +1,472 @@
+// =============================================================================
+// Version Pinning Audit
+// Checks provider constraints, lockfiles and module sources against policy
+// =============================================================================
+
+// Package pinning audits how the repository pins what Terraform downloads:
+// every required_providers constraint, including the one root.hcl generates
+// from config/, must stay within the approved range for its provider; every
+// lockfile must lock a version in that range; remote module and Terragrunt
+// sources must pin a tag or commit rather than a branch; and each provider
+// must be on one major version everywhere. It parses files only.
+package pinning
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"gopkg.in/yaml.v3"
+)
+
+// Range is the approved versions of a provider, from Min up to but excluding Max
+type Range struct {
+	Min *version.Version
+	Max *version.Version
+}
+
+// NewRange returns the range [min, max), panicking on an invalid version
+func NewRange(min, max string) Range {
+	return Range{Min: version.Must(version.NewVersion(min)), Max: version.Must(version.NewVersion(max))}
+}
+
+// String formats the range as a Terraform constraint
+func (r Range) String() string {
+	return fmt.Sprintf(">= %s, < %s", r.Min.Original(), r.Max.Original())
+}
+
+// Policy maps provider sources, e.g. "hashicorp/aws", to their approved range
+type Policy map[string]Range
+
+// FloatingRefs are git refs that move, so a source pinned to one is not pinned
+var FloatingRefs = []string{"main", "master", "develop", "trunk", "HEAD"}
+
+// Offender is one place that breaks the policy
+type Offender struct {
+	// File is relative to the audited root
+	File    string
+	Subject string
+	Problem string
+}
+
+// Audit checks every Terraform file, lockfile, Terragrunt config and config/
+// YAML file below root against policy, returning the offenders sorted by file
+func Audit(root string, policy Policy) ([]Offender, error) {
+	a := &audit{root: root, policy: policy, majors: map[string]map[uint64][]string{}}
+
+	err := filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Skips .git and working directories such as .terraform and .terragrunt-cache
+		if entry.IsDir() {
+			if path != root && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		switch name := entry.Name(); {
+		case name == ".terraform.lock.hcl":
+			return a.lockfile(path)
+		case strings.HasSuffix(name, ".tf"):
+			return a.terraform(path)
+		case strings.HasSuffix(name, ".hcl"):
+			return a.terragrunt(path)
+		case strings.HasSuffix(name, ".yaml") && strings.HasPrefix(filepath.ToSlash(rel), "config/"):
+			return a.config(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.checkMajors()
+	sort.SliceStable(a.offenders, func(i, j int) bool {
+		if a.offenders[i].File != a.offenders[j].File {
+			return a.offenders[i].File < a.offenders[j].File
+		}
+		return a.offenders[i].Subject < a.offenders[j].Subject
+	})
+	return a.offenders, nil
+}
+
+// audit collects offenders while the tree is walked
+type audit struct {
+	root      string
+	policy    Policy
+	offenders []Offender
+
+	// majors records, per provider, the files using each major version
+	majors map[string]map[uint64][]string
+}
+
+func (a *audit) report(path, subject, format string, args ...interface{}) {
+	rel, err := filepath.Rel(a.root, path)
+	if err != nil {
+		rel = path
+	}
+	a.offenders = append(a.offenders, Offender{File: filepath.ToSlash(rel), Subject: subject, Problem: fmt.Sprintf(format, args...)})
+}
+
+func (a *audit) useMajor(provider string, major uint64, path string) {
+	if a.majors[provider] == nil {
+		a.majors[provider] = map[uint64][]string{}
+	}
+	a.majors[provider][major] = append(a.majors[provider][major], path)
+}
+
+// checkMajors reports every use of a provider whose major version is not the
+// one most files use
+func (a *audit) checkMajors() {
+	for provider, majors := range a.majors {
+		if len(majors) < 2 {
+			continue
+		}
+		var common uint64
+		for major, paths := range majors {
+			if len(paths) > len(majors[common]) || len(paths) == len(majors[common]) && major > common {
+				common = major
+			}
+		}
+		for major, paths := range majors {
+			if major == common {
+				continue
+			}
+			for _, path := range paths {
+				a.report(path, provider, "uses major version %d while the rest of the repository uses %d", major, common)
+			}
+		}
+	}
+}
+
+// parse reads the HCL file at path
+func parse(path string) (*hclsyntax.Body, []byte, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, nil, fmt.Errorf("failed to parse %s: %s", path, diags.Error())
+	}
+	return file.Body.(*hclsyntax.Body), src, nil
+}
+
+// literal returns the value of a literal string attribute, or "" when it is
+// missing or computed
+func literal(body *hclsyntax.Body, name string) string {
+	attribute, ok := body.Attributes[name]
+	if !ok {
+		return ""
+	}
+	value, diags := attribute.Expr.Value(nil)
+	if diags.HasErrors() || !value.Type().Equals(cty.String) || value.IsNull() {
+		return ""
+	}
+	return value.AsString()
+}
+
+// terraform checks the required_providers and module sources of a .tf file
+func (a *audit) terraform(path string) error {
+	body, src, err := parse(path)
+	if err != nil {
+		return err
+	}
+
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "terraform":
+			for _, inner := range block.Body.Blocks {
+				if inner.Type == "required_providers" {
+					a.requiredProviders(path, inner.Body)
+				}
+			}
+		case "module":
+			subject := "module." + block.Labels[0]
+			source := literal(block.Body, "source")
+			if source == "" {
+				source = sourceText(block.Body, "source", src)
+			}
+			if problem := CheckSource(source, literal(block.Body, "version")); problem != "" {
+				a.report(path, subject, "%s", problem)
+			}
+		}
+	}
+	return nil
+}
+
+// requiredProviders checks each provider requirement in a required_providers block
+func (a *audit) requiredProviders(path string, body *hclsyntax.Body) {
+	names := make([]string, 0, len(body.Attributes))
+	for name := range body.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, diags := body.Attributes[name].Expr.Value(nil)
+		if diags.HasErrors() || !value.Type().IsObjectType() {
+			a.report(path, name, "requirement is not a literal { source, version } object")
+			continue
+		}
+		source := "hashicorp/" + name
+		if value.Type().HasAttribute("source") {
+			source = value.GetAttr("source").AsString()
+		}
+		constraint := ""
+		if value.Type().HasAttribute("version") {
+			constraint = value.GetAttr("version").AsString()
+		}
+		a.constraint(path, NormalizeSource(source), constraint)
+	}
+}
+
+// constraint checks one provider version constraint against the policy
+func (a *audit) constraint(path, provider, constraint string) {
+	approved, ok := a.policy[provider]
+	if !ok {
+		a.report(path, provider, "provider has no approved version range")
+		return
+	}
+	if constraint == "" {
+		a.report(path, provider, "no version constraint; pin one within %s", approved)
+		return
+	}
+
+	lower, problem := CheckConstraint(constraint, approved)
+	if problem != "" {
+		a.report(path, provider, "%s", problem)
+	}
+	if lower != nil {
+		a.useMajor(provider, uint64(lower.Segments64()[0]), path)
+	}
+}
+
+// lockfile checks the version each provider is locked to
+func (a *audit) lockfile(path string) error {
+	body, _, err := parse(path)
+	if err != nil {
+		return err
+	}
+
+	for _, block := range body.Blocks {
+		if block.Type != "provider" || len(block.Labels) != 1 {
+			continue
+		}
+		provider := NormalizeSource(block.Labels[0])
+		locked, err := version.NewVersion(literal(block.Body, "version"))
+		if err != nil {
+			a.report(path, provider, "locked version is not a version: %v", err)
+			continue
+		}
+		a.useMajor(provider, uint64(locked.Segments64()[0]), path)
+
+		approved, ok := a.policy[provider]
+		switch {
+		case !ok:
+			a.report(path, provider, "provider has no approved version range")
+		case locked.LessThan(approved.Min) || !locked.LessThan(approved.Max):
+			a.report(path, provider, "locked version %s is outside %s", locked.Original(), approved)
+		}
+	}
+	return nil
+}
+
+// terragrunt checks the terraform source of a Terragrunt configuration
+func (a *audit) terragrunt(path string) error {
+	body, src, err := parse(path)
+	if err != nil {
+		return err
+	}
+
+	for _, block := range body.Blocks {
+		if block.Type != "terraform" {
+			continue
+		}
+		if _, ok := block.Body.Attributes["source"]; !ok {
+			continue
+		}
+		source := literal(block.Body, "source")
+		if source == "" {
+			source = sourceText(block.Body, "source", src)
+		}
+		if problem := CheckSource(source, ""); problem != "" {
+			a.report(path, "terraform.source", "%s", problem)
+		}
+	}
+	return nil
+}
+
+// sourceText returns the source code of a computed attribute, enough to tell
+// a local path from a remote one
+func sourceText(body *hclsyntax.Body, name string, src []byte) string {
+	attribute, ok := body.Attributes[name]
+	if !ok {
+		return ""
+	}
+	return strings.Trim(string(attribute.Expr.Range().SliceBytes(src)), `"`)
+}
+
+// config checks the provider constraint root.hcl renders from config/ YAML
+func (a *audit) config(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var config struct {
+		Terraform struct {
+			AWSProviderVersion string `yaml:"aws_provider_version"`
+		} `yaml:"terraform"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if config.Terraform.AWSProviderVersion != "" {
+		a.constraint(path, "hashicorp/aws", config.Terraform.AWSProviderVersion)
+	}
+	return nil
+}
+
+// NormalizeSource strips the default registry host from a provider source, so
+// "registry.terraform.io/hashicorp/aws" and "hashicorp/aws" compare equal
+func NormalizeSource(source string) string {
+	return strings.TrimPrefix(strings.ToLower(source), "registry.terraform.io/")
+}
+
+// constraintPart splits one comma-separated part of a constraint into its
+// operator and version
+var constraintPart = regexp.MustCompile(`^\s*(=|!=|>=|<=|>|<|~>)?\s*(\S+)\s*$`)
+
+// CheckConstraint returns the lowest version constraint allows and what makes
+// it break approved: allowing versions below approved.Min or from
+// approved.Max on, including having no upper bound
+func CheckConstraint(constraint string, approved Range) (*version.Version, string) {
+	if _, err := version.NewConstraint(constraint); err != nil {
+		return nil, fmt.Sprintf("constraint %q is invalid: %v", constraint, err)
+	}
+
+	var lower, upper *version.Version
+	upperInclusive := false
+	for _, part := range strings.Split(constraint, ",") {
+		match := constraintPart.FindStringSubmatch(part)
+		if match == nil {
+			return nil, fmt.Sprintf("constraint %q is invalid", constraint)
+		}
+		v, err := version.NewVersion(match[2])
+		if err != nil {
+			return nil, fmt.Sprintf("constraint %q is invalid: %v", constraint, err)
+		}
+
+		switch match[1] {
+		case "", "=":
+			lower, upper, upperInclusive = v, v, true
+		case ">", ">=":
+			lower = v
+		case "<":
+			upper, upperInclusive = v, false
+		case "<=":
+			upper, upperInclusive = v, true
+		case "~>":
+			lower, upper, upperInclusive = v, pessimisticBound(v), false
+		}
+	}
+
+	switch {
+	case lower == nil || lower.LessThan(approved.Min):
+		return lower, fmt.Sprintf("constraint %q allows versions below %s", constraint, approved)
+	case upper == nil:
+		return lower, fmt.Sprintf("constraint %q has no upper bound; pin one within %s", constraint, approved)
+	case upper.GreaterThan(approved.Max) || upperInclusive && upper.Equal(approved.Max):
+		return lower, fmt.Sprintf("constraint %q allows versions above %s", constraint, approved)
+	}
+	return lower, ""
+}
+
+// pessimisticBound is the exclusive upper bound of ~> v: the next version of
+// the second to last segment given, e.g. 6.0 for ~> 5.0 and 5.32 for ~> 5.31.0
+func pessimisticBound(v *version.Version) *version.Version {
+	segments := v.Segments64()
+	given := strings.Count(strings.SplitN(v.Original(), "-", 2)[0], ".") + 1
+	bump := given - 2
+	if bump < 0 {
+		bump = 0
+	}
+
+	parts := make([]string, bump+1)
+	for i := 0; i < bump; i++ {
+		parts[i] = fmt.Sprint(segments[i])
+	}
+	parts[bump] = fmt.Sprint(segments[bump] + 1)
+	return version.Must(version.NewVersion(strings.Join(parts, ".")))
+}
+
+// CheckSource returns what is wrong with how a module source is pinned, or ""
+// for local paths and pinned remote sources; registry modules are pinned by
+// versionConstraint
+func CheckSource(source, versionConstraint string) string {
+	switch {
+	case source == "" || strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") ||
+		strings.HasPrefix(source, "/") || strings.HasPrefix(source, "${"):
+		return ""
+	case strings.Contains(source, "::") || strings.HasPrefix(source, "github.com/") ||
+		strings.HasPrefix(source, "bitbucket.org/") || strings.HasPrefix(source, "git@"):
+		return checkRef(source)
+	case strings.HasPrefix(source, "tfr://"):
+		if !strings.Contains(source, "version=") {
+			return fmt.Sprintf("registry source %q has no ?version=", source)
+		}
+		return ""
+	case strings.Count(source, "/") >= 2:
+		if versionConstraint == "" {
+			return fmt.Sprintf("registry module %q has no version", source)
+		}
+		return ""
+	}
+	return ""
+}
+
+// checkRef requires a remote source to pin a ref that does not move
+func checkRef(source string) string {
+	_, query, ok := strings.Cut(source, "?")
+	if !ok {
+		return fmt.Sprintf("remote source %q has no ?ref=; pin a tag or commit", source)
+	}
+	for _, param := range strings.Split(query, "&") {
+		name, ref, _ := strings.Cut(param, "=")
+		if name != "ref" {
+			continue
+		}
+		for _, floating := range FloatingRefs {
+			if ref == floating {
+				return fmt.Sprintf("remote source %q uses the floating ref %s; pin a tag or commit", source, ref)
+			}
+		}
+		return ""
+	}
+	return fmt.Sprintf("remote source %q has no ?ref=; pin a tag or commit", source)
+}
+
+// WriteTable writes the offenders as an aligned table
+func WriteTable(w io.Writer, offenders []Offender) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tSUBJECT\tPROBLEM")
+	for _, o := range offenders {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", o.File, o.Subject, o.Problem)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,123 @@
+package pinning
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var policy = Policy{
+	"hashicorp/aws":    NewRange("5.0", "6.0"),
+	"hashicorp/random": NewRange("3.5", "4.0"),
+}
+
+func TestCheckConstraint(t *testing.T) {
+	for constraint, problem := range map[string]string{
+		"~> 5.0":          "",
+		"~> 5.31.0":       "",
+		">= 5.10, < 6.0":  "",
+		"5.31.0":          "",
+		">= 5.0, <= 5.99": "",
+		">= 5.0":          `constraint ">= 5.0" has no upper bound; pin one within >= 5.0, < 6.0`,
+		"~> 4.0":          `constraint "~> 4.0" allows versions below >= 5.0, < 6.0`,
+		"~> 5":            "",
+		">= 5.0, <= 6.0":  `constraint ">= 5.0, <= 6.0" allows versions above >= 5.0, < 6.0`,
+		"< 6.0":           `constraint "< 6.0" allows versions below >= 5.0, < 6.0`,
+		"latest":          `constraint "latest" is invalid: Malformed constraint: latest`,
+	} {
+		_, got := CheckConstraint(constraint, policy["hashicorp/aws"])
+		assert.Equal(t, problem, got, constraint)
+	}
+
+	lower, _ := CheckConstraint("~> 5.31.0", policy["hashicorp/aws"])
+	assert.Equal(t, "5.31.0", lower.Original())
+	_, problem := CheckConstraint("~> 6.0", policy["hashicorp/aws"])
+	assert.Contains(t, problem, "allows versions above")
+}
+
+func TestCheckSource(t *testing.T) {
+	for source, problem := range map[string]string{
+		"${get_repo_root()}/modules//storage":                      "",
+		"../../../modules/networking":                              "",
+		"git::https://github.com/acme/modules.git//vpc?ref=v1.4.0": "",
+		"git::https://github.com/acme/modules.git//vpc?ref=main":   `remote source "git::https://github.com/acme/modules.git//vpc?ref=main" uses the floating ref main; pin a tag or commit`,
+		"github.com/acme/modules//vpc":                             `remote source "github.com/acme/modules//vpc" has no ?ref=; pin a tag or commit`,
+		"git::ssh://git@github.com/acme/modules.git?depth=1":       `remote source "git::ssh://git@github.com/acme/modules.git?depth=1" has no ?ref=; pin a tag or commit`,
+		"tfr:///terraform-aws-modules/vpc/aws?version=5.1.0":       "",
+		"tfr:///terraform-aws-modules/vpc/aws":                     `registry source "tfr:///terraform-aws-modules/vpc/aws" has no ?version=`,
+	} {
+		assert.Equal(t, problem, CheckSource(source, ""), source)
+	}
+
+	assert.Equal(t, `registry module "terraform-aws-modules/vpc/aws" has no version`, CheckSource("terraform-aws-modules/vpc/aws", ""))
+	assert.Empty(t, CheckSource("terraform-aws-modules/vpc/aws", "~> 5.1"))
+}
+
+// writeTree creates files below a temporary root from a map of relative paths to contents
+func writeTree(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return root
+}
+
+const versionsTF = `terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "%s"
+    }
+  }
+}
+`
+
+const lockfile = `provider "registry.terraform.io/hashicorp/aws" {
+  version     = "%s"
+  constraints = "~> 5.0"
+}
+`
+
+func TestAudit(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"modules/storage/versions.tf":                             strings.Replace(versionsTF, "%s", "~> 5.0", 1),
+		"modules/storage/.terraform.lock.hcl":                     strings.Replace(lockfile, "%s", "5.100.0", 1),
+		"modules/analytics/versions.tf":                           strings.Replace(versionsTF, "%s", "~> 5.0", 1),
+		"modules/legacy/versions.tf":                              strings.Replace(versionsTF, "%s", "~> 4.67", 1),
+		"modules/legacy/.terraform.lock.hcl":                      strings.Replace(lockfile, "%s", "4.67.0", 1),
+		"modules/legacy/main.tf":                                  "module \"vpc\" {\n  source = \"git::https://github.com/acme/modules.git//vpc?ref=main\"\n}\n",
+		"modules/open/versions.tf":                                "terraform {\n  required_providers {\n    aws = { source = \"hashicorp/aws\" }\n    null = { source = \"hashicorp/null\", version = \"~> 3.0\" }\n  }\n}\n",
+		"environments/dev/us-east-1/01-networking/terragrunt.hcl": "terraform {\n  source = \"${get_repo_root()}/modules//networking\"\n}\n",
+		"environments/dev/us-east-1/02-shared/terragrunt.hcl":     "terraform {\n  source = \"github.com/acme/live//shared\"\n}\n",
+		"config/common.yaml":                                      "terraform:\n  aws_provider_version: \">= 5.0\"\n",
+
+		// Working directories are not audited
+		"modules/storage/.terraform/modules/vpc/versions.tf": strings.Replace(versionsTF, "%s", ">= 1.0", 1),
+	})
+
+	offenders, err := Audit(root, policy)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Offender{
+		{File: "config/common.yaml", Subject: "hashicorp/aws", Problem: `constraint ">= 5.0" has no upper bound; pin one within >= 5.0, < 6.0`},
+		{File: "environments/dev/us-east-1/02-shared/terragrunt.hcl", Subject: "terraform.source", Problem: `remote source "github.com/acme/live//shared" has no ?ref=; pin a tag or commit`},
+		{File: "modules/legacy/.terraform.lock.hcl", Subject: "hashicorp/aws", Problem: "locked version 4.67.0 is outside >= 5.0, < 6.0"},
+		{File: "modules/legacy/.terraform.lock.hcl", Subject: "hashicorp/aws", Problem: "uses major version 4 while the rest of the repository uses 5"},
+		{File: "modules/legacy/main.tf", Subject: "module.vpc", Problem: `remote source "git::https://github.com/acme/modules.git//vpc?ref=main" uses the floating ref main; pin a tag or commit`},
+		{File: "modules/legacy/versions.tf", Subject: "hashicorp/aws", Problem: `constraint "~> 4.67" allows versions below >= 5.0, < 6.0`},
+		{File: "modules/legacy/versions.tf", Subject: "hashicorp/aws", Problem: "uses major version 4 while the rest of the repository uses 5"},
+		{File: "modules/open/versions.tf", Subject: "hashicorp/aws", Problem: "no version constraint; pin one within >= 5.0, < 6.0"},
+		{File: "modules/open/versions.tf", Subject: "hashicorp/null", Problem: "provider has no approved version range"},
+	}, offenders)
+
+	var table strings.Builder
+	require.NoError(t, WriteTable(&table, offenders[:1]))
+	assert.Equal(t, "FILE                SUBJECT        PROBLEM\n"+
+		`config/common.yaml  hashicorp/aws  constraint ">= 5.0" has no upper bound; pin one within >= 5.0, < 6.0`+"\n", table.String())
+}
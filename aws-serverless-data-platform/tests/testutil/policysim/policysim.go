@@ -0,0 +1,270 @@
+// =============================================================================
+// Policy Simulation Matrix
+// Declared (principal, action, resource) decisions checked with the IAM simulator
+// =============================================================================
+
+// Package policysim checks a matrix of expected access decisions against the
+// IAM policy simulator. Cells are declared in Go or loaded from YAML, with
+// ${name} placeholders for values only known after apply, such as key ARNs.
+package policysim
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// Decision is the outcome a cell expects from the simulator
+type Decision string
+
+const (
+	// Allowed means an identity policy allows the request
+	Allowed Decision = "allowed"
+
+	// Denied accepts either an implicit or an explicit deny
+	Denied Decision = "denied"
+
+	// ImplicitDeny means no policy allows the request
+	ImplicitDeny Decision = "implicitDeny"
+
+	// ExplicitDeny means a Deny statement matches the request
+	ExplicitDeny Decision = "explicitDeny"
+)
+
+// Matches reports whether the simulator's decision satisfies d
+func (d Decision) Matches(decision types.PolicyEvaluationDecisionType) bool {
+	switch d {
+	case Denied:
+		return decision == types.PolicyEvaluationDecisionTypeImplicitDeny ||
+			decision == types.PolicyEvaluationDecisionTypeExplicitDeny
+	default:
+		return string(d) == string(decision)
+	}
+}
+
+// ContextKey is a condition key value supplied to the simulation, e.g. iam:PassedToService
+type ContextKey struct {
+	Name   string   `yaml:"name"`
+	Type   string   `yaml:"type"`
+	Values []string `yaml:"values"`
+}
+
+// Cell is one expected decision: whether Principal may perform Action on Resource
+type Cell struct {
+	// Principal names an entry in the principals passed to Run, e.g. "glue_role"
+	Principal string `yaml:"principal"`
+
+	Action   string `yaml:"action"`
+	Resource string `yaml:"resource"`
+
+	// Context supplies condition keys the principal's policies test
+	Context []ContextKey `yaml:"context,omitempty"`
+
+	// Grant adds an identity policy allowing the action on the resource to the
+	// simulation, so only a permissions boundary or an explicit deny can deny it
+	Grant bool `yaml:"grant,omitempty"`
+
+	Expect Decision `yaml:"expect"`
+}
+
+// String identifies the cell in failure messages
+func (c Cell) String() string {
+	s := fmt.Sprintf("%s %s on %s", c.Principal, c.Action, c.Resource)
+	for _, key := range c.Context {
+		s += fmt.Sprintf(" [%s=%s]", key.Name, strings.Join(key.Values, ","))
+	}
+	if c.Grant {
+		s += " [granted]"
+	}
+	return s
+}
+
+// Matrix is the document Load reads
+type Matrix struct {
+	Cells []Cell `yaml:"cells"`
+}
+
+// Load reads a matrix from a YAML file
+func Load(path string) ([]Cell, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var matrix Matrix
+	if err := yaml.Unmarshal(data, &matrix); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := Validate(matrix.Cells); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return matrix.Cells, nil
+}
+
+// Validate checks every cell names a principal, action, resource and known decision
+func Validate(cells []Cell) error {
+	for i, cell := range cells {
+		if cell.Principal == "" || cell.Action == "" || cell.Resource == "" {
+			return fmt.Errorf("cell %d: principal, action and resource are required", i)
+		}
+		switch cell.Expect {
+		case Allowed, Denied, ImplicitDeny, ExplicitDeny:
+		default:
+			return fmt.Errorf("cell %d (%s): unknown decision %q", i, cell, cell.Expect)
+		}
+		for _, key := range cell.Context {
+			if key.Name == "" || len(key.Values) == 0 {
+				return fmt.Errorf("cell %d (%s): context keys need a name and values", i, cell)
+			}
+		}
+	}
+	return nil
+}
+
+// Expand replaces ${name} placeholders in each cell's resource and context
+// values, failing on any placeholder vars does not define
+func Expand(cells []Cell, vars map[string]string) ([]Cell, error) {
+	var missing []string
+	mapping := func(name string) string {
+		value, ok := vars[name]
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	}
+
+	expanded := make([]Cell, len(cells))
+	for i, cell := range cells {
+		cell.Resource = os.Expand(cell.Resource, mapping)
+
+		context := make([]ContextKey, len(cell.Context))
+		for j, key := range cell.Context {
+			values := make([]string, len(key.Values))
+			for k, value := range key.Values {
+				values[k] = os.Expand(value, mapping)
+			}
+			key.Values = values
+			context[j] = key
+		}
+		cell.Context = context
+		expanded[i] = cell
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("undefined placeholders: %s", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// Run simulates every cell in its own subtest, looking up each cell's
+// principal ARN in principals
+func Run(t *testing.T, clients *awsclients.Clients, principals map[string]string, cells []Cell) {
+	for i, cell := range cells {
+		t.Run(fmt.Sprintf("%02d_%s_%s", i, cell.Principal, cell.Action), func(t *testing.T) {
+			principalARN, ok := principals[cell.Principal]
+			if !ok {
+				t.Fatalf("cell %d (%s): unknown principal %q", i, cell, cell.Principal)
+			}
+
+			ctx, cancel := clients.Context()
+			defer cancel()
+
+			results, err := Simulate(ctx, clients.IAM(), principalARN, cell)
+			if err != nil {
+				t.Fatalf("cell %d (%s): %v", i, cell, err)
+			}
+			if err := Check(cell, results); err != nil {
+				t.Errorf("cell %d: %v", i, err)
+				return
+			}
+			logging.New(t).Success(cell.String(), "expect", cell.Expect)
+		})
+	}
+}
+
+// Simulate evaluates the principal's policies for one cell, following every result page
+func Simulate(ctx context.Context, client iam.SimulatePrincipalPolicyAPIClient, principalARN string, cell Cell) ([]types.EvaluationResult, error) {
+	input := &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: awssdk.String(principalARN),
+		ActionNames:     []string{cell.Action},
+		ResourceArns:    []string{cell.Resource},
+	}
+	if cell.Grant {
+		input.PolicyInputList = []string{GrantPolicy(cell.Action, cell.Resource)}
+	}
+	for _, key := range cell.Context {
+		keyType := types.ContextKeyTypeEnum(key.Type)
+		if keyType == "" {
+			keyType = types.ContextKeyTypeEnumString
+		}
+		input.ContextEntries = append(input.ContextEntries, types.ContextEntry{
+			ContextKeyName:   awssdk.String(key.Name),
+			ContextKeyType:   keyType,
+			ContextKeyValues: key.Values,
+		})
+	}
+
+	var results []types.EvaluationResult
+	paginator := iam.NewSimulatePrincipalPolicyPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, page.EvaluationResults...)
+	}
+	return results, nil
+}
+
+// GrantPolicy is an identity policy document allowing action on resource
+func GrantPolicy(action, resource string) string {
+	document, _ := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{{
+			"Sid":      "SimulatedGrant",
+			"Effect":   "Allow",
+			"Action":   action,
+			"Resource": resource,
+		}},
+	})
+	return string(document)
+}
+
+// Check compares the simulator's results for a cell with its expected decision,
+// describing the decision, matched statements and missing context keys when they diverge
+func Check(cell Cell, results []types.EvaluationResult) error {
+	for _, result := range results {
+		if awssdk.ToString(result.EvalActionName) != cell.Action {
+			continue
+		}
+		if cell.Expect.Matches(result.EvalDecision) {
+			return nil
+		}
+
+		message := fmt.Sprintf("%s: got %s, want %s", cell, result.EvalDecision, cell.Expect)
+		var matched []string
+		for _, statement := range result.MatchedStatements {
+			matched = append(matched, awssdk.ToString(statement.SourcePolicyId))
+		}
+		if len(matched) > 0 {
+			message += "; matched statements in " + strings.Join(matched, ", ")
+		}
+		if len(result.MissingContextValues) > 0 {
+			message += "; missing context keys " + strings.Join(result.MissingContextValues, ", ")
+		}
+		return fmt.Errorf("%s", message)
+	}
+	return fmt.Errorf("%s: the simulator returned no result for the action", cell)
+}
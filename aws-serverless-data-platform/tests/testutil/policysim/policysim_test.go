@@ -0,0 +1,148 @@
+package policysim
+
+import (
+	"context"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSimulator returns one evaluation result per page for the requested
+// action, recording the requests it receives
+type fakeSimulator struct {
+	decisions []types.PolicyEvaluationDecisionType
+	requests  []*iam.SimulatePrincipalPolicyInput
+}
+
+func (f *fakeSimulator) SimulatePrincipalPolicy(_ context.Context, params *iam.SimulatePrincipalPolicyInput, _ ...func(*iam.Options)) (*iam.SimulatePrincipalPolicyOutput, error) {
+	f.requests = append(f.requests, params)
+	page := len(f.requests) - 1
+	output := &iam.SimulatePrincipalPolicyOutput{EvaluationResults: []types.EvaluationResult{{
+		EvalActionName: awssdk.String(params.ActionNames[0]),
+		EvalDecision:   f.decisions[page],
+	}}}
+	if page+1 < len(f.decisions) {
+		output.IsTruncated = true
+		output.Marker = awssdk.String("next")
+	}
+	return output, nil
+}
+
+func TestLoad(t *testing.T) {
+	cells, err := Load("testdata/matrix.yaml")
+	require.NoError(t, err)
+	require.Len(t, cells, 4)
+
+	assert.Equal(t, Cell{
+		Principal: "glue_role",
+		Action:    "iam:PassRole",
+		Resource:  "arn:aws:iam::${account_id}:role/AWSGlueServiceRole-etl",
+		Context:   []ContextKey{{Name: "iam:PassedToService", Values: []string{"glue.amazonaws.com"}}},
+		Expect:    Allowed,
+	}, cells[1])
+	assert.Equal(t, Denied, cells[2].Expect)
+	assert.True(t, cells[3].Grant)
+	assert.Equal(t, "glue_role iam:CreateUser on arn:aws:iam::${account_id}:user/intruder [granted]", cells[3].String())
+}
+
+func TestGrantPolicy(t *testing.T) {
+	assert.JSONEq(t,
+		`{"Version":"2012-10-17","Statement":[{"Sid":"SimulatedGrant","Effect":"Allow","Action":"iam:CreateUser","Resource":"*"}]}`,
+		GrantPolicy("iam:CreateUser", "*"))
+}
+
+func TestValidate(t *testing.T) {
+	valid := Cell{Principal: "glue_role", Action: "s3:GetObject", Resource: "arn:aws:s3:::bucket/*", Expect: Allowed}
+	assert.NoError(t, Validate([]Cell{valid}))
+
+	missingAction := valid
+	missingAction.Action = ""
+	assert.Error(t, Validate([]Cell{missingAction}))
+
+	unknownDecision := valid
+	unknownDecision.Expect = "maybe"
+	assert.ErrorContains(t, Validate([]Cell{valid, unknownDecision}), "cell 1")
+
+	emptyContext := valid
+	emptyContext.Context = []ContextKey{{Name: "aws:SourceVpc"}}
+	assert.Error(t, Validate([]Cell{emptyContext}))
+}
+
+func TestExpand(t *testing.T) {
+	cells := []Cell{{
+		Principal: "glue_role",
+		Action:    "kms:Decrypt",
+		Resource:  "${data_key_arn}",
+		Context:   []ContextKey{{Name: "kms:ViaService", Values: []string{"s3.${region}.amazonaws.com"}}},
+		Expect:    Allowed,
+	}}
+
+	expanded, err := Expand(cells, map[string]string{"data_key_arn": "arn:aws:kms:us-east-1:123456789012:key/abc", "region": "us-east-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:kms:us-east-1:123456789012:key/abc", expanded[0].Resource)
+	assert.Equal(t, []string{"s3.us-east-1.amazonaws.com"}, expanded[0].Context[0].Values)
+	assert.Equal(t, "${data_key_arn}", cells[0].Resource, "Expand must not modify its input")
+
+	_, err = Expand(cells, map[string]string{"region": "us-east-1"})
+	assert.EqualError(t, err, "undefined placeholders: data_key_arn")
+}
+
+func TestDecisionMatches(t *testing.T) {
+	assert.True(t, Allowed.Matches(types.PolicyEvaluationDecisionTypeAllowed))
+	assert.False(t, Allowed.Matches(types.PolicyEvaluationDecisionTypeImplicitDeny))
+	assert.True(t, Denied.Matches(types.PolicyEvaluationDecisionTypeImplicitDeny))
+	assert.True(t, Denied.Matches(types.PolicyEvaluationDecisionTypeExplicitDeny))
+	assert.False(t, Denied.Matches(types.PolicyEvaluationDecisionTypeAllowed))
+	assert.False(t, ExplicitDeny.Matches(types.PolicyEvaluationDecisionTypeImplicitDeny))
+}
+
+func TestCheck(t *testing.T) {
+	cell := Cell{Principal: "glue_role", Action: "iam:PassRole", Resource: "arn:aws:iam::123456789012:role/admin", Expect: Denied}
+
+	assert.NoError(t, Check(cell, []types.EvaluationResult{{
+		EvalActionName: awssdk.String("iam:PassRole"),
+		EvalDecision:   types.PolicyEvaluationDecisionTypeImplicitDeny,
+	}}))
+
+	err := Check(cell, []types.EvaluationResult{{
+		EvalActionName:       awssdk.String("iam:PassRole"),
+		EvalDecision:         types.PolicyEvaluationDecisionTypeAllowed,
+		MatchedStatements:    []types.Statement{{SourcePolicyId: awssdk.String("AWSGlueServiceRole")}},
+		MissingContextValues: []string{"iam:PassedToService"},
+	}})
+	assert.EqualError(t, err, "glue_role iam:PassRole on arn:aws:iam::123456789012:role/admin: got allowed, want denied; "+
+		"matched statements in AWSGlueServiceRole; missing context keys iam:PassedToService")
+
+	assert.ErrorContains(t, Check(cell, nil), "no result")
+}
+
+func TestSimulate(t *testing.T) {
+	client := &fakeSimulator{decisions: []types.PolicyEvaluationDecisionType{
+		types.PolicyEvaluationDecisionTypeAllowed,
+		types.PolicyEvaluationDecisionTypeExplicitDeny,
+	}}
+	cell := Cell{
+		Principal: "glue_role",
+		Action:    "iam:CreateUser",
+		Resource:  "*",
+		Context:   []ContextKey{{Name: "aws:SourceVpc", Values: []string{"vpc-0abc"}}, {Name: "aws:MultiFactorAuthAge", Type: "numeric", Values: []string{"300"}}},
+		Grant:     true,
+		Expect:    ExplicitDeny,
+	}
+
+	results, err := Simulate(context.Background(), client, "arn:aws:iam::123456789012:role/glue", cell)
+	require.NoError(t, err)
+	assert.Len(t, results, 2, "Every page of results is read")
+
+	request := client.requests[0]
+	assert.Equal(t, "arn:aws:iam::123456789012:role/glue", awssdk.ToString(request.PolicySourceArn))
+	assert.Equal(t, []string{"iam:CreateUser"}, request.ActionNames)
+	assert.Equal(t, []string{GrantPolicy("iam:CreateUser", "*")}, request.PolicyInputList, "Granted cells simulate an extra allow")
+	require.Len(t, request.ContextEntries, 2)
+	assert.Equal(t, types.ContextKeyTypeEnumString, request.ContextEntries[0].ContextKeyType, "Context keys default to strings")
+	assert.Equal(t, types.ContextKeyTypeEnumNumeric, request.ContextEntries[1].ContextKeyType)
+}
@@ -0,0 +1,57 @@
+// =============================================================================
+// HTML Report
+// Human-readable run summary with the resource inventory of each test
+// =============================================================================
+
+package report
+
+import (
+	"html/template"
+	"io"
+)
+
+// htmlTemplate renders a Report
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"seconds": seconds,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Suite}} test report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+.passed { color: #1a7f37; } .failed { color: #cf222e; } .skipped, .running { color: #9a6700; }
+code { font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>{{.Suite}}</h1>
+<p>Started {{.Started.UTC.Format "2006-01-02 15:04:05 MST"}}, ran for {{seconds .Duration}}s.
+{{len .Tests}} tests: {{.Count "passed"}} passed, {{.Count "failed"}} failed, {{.Count "skipped"}} skipped.
+{{.Resources}} resources inventoried.</p>
+
+<h2>Tests</h2>
+<table>
+<tr><th>Test</th><th>Outcome</th><th>Duration (s)</th><th>Modules</th></tr>
+{{range .Tests}}<tr><td>{{.Name}}</td><td class="{{.Outcome}}">{{.Outcome}}</td><td>{{seconds .Duration}}</td><td>{{len .Modules}}</td></tr>
+{{end}}</table>
+
+<h2>Resource inventory</h2>
+{{range .Tests}}{{$test := .Name}}{{range .Modules}}
+<h3>{{$test}} &mdash; <code>{{.Dir}}</code></h3>
+<p>{{if .Failed}}<span class="failed">Apply failed</span>{{else}}Applied{{end}} in {{seconds .Duration}}s.</p>
+<table>
+<tr><th>Address</th><th>Type</th><th>ID</th><th>ARN</th></tr>
+{{range .Resources}}<tr><td><code>{{.Address}}</code></td><td>{{.Type}}</td><td>{{.ID}}</td><td>{{.ARN}}</td></tr>
+{{end}}</table>
+{{end}}{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes the report as a standalone HTML page
+func (r *Report) WriteHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, r)
+}
@@ -0,0 +1,103 @@
+// =============================================================================
+// Resource Inventory
+// Applies a module and lists the resources left in its state
+// =============================================================================
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+)
+
+// state is the subset of `terraform show -json` state output used for the inventory
+type state struct {
+	Values struct {
+		RootModule stateModule `json:"root_module"`
+	} `json:"values"`
+}
+
+// stateModule is a root or child module within the state
+type stateModule struct {
+	Resources []struct {
+		Address string                 `json:"address"`
+		Mode    string                 `json:"mode"`
+		Type    string                 `json:"type"`
+		Values  map[string]interface{} `json:"values"`
+	} `json:"resources"`
+	ChildModules []stateModule `json:"child_modules"`
+}
+
+// Apply times apply, then records the module in terraformOptions.TerraformDir with
+// its resource inventory against t; the inventory is read with TerraformBinary, so
+// Terragrunt units work too
+func Apply(t *testing.T, terraformOptions *terraform.Options, apply func()) {
+	Default.Apply(t, terraformOptions, apply)
+}
+
+// Apply times apply and records the module with its resource inventory against t,
+// then passes it to the observers
+func (r *Recorder) Apply(t *testing.T, terraformOptions *terraform.Options, apply func()) {
+	started := time.Now()
+	module := Module{Dir: terraformOptions.TerraformDir, Failed: true}
+
+	// Record even when apply fails the test, so partial deployments are reported
+	defer func() {
+		module.Duration = time.Since(started)
+		if stateJSON, err := terraform.ShowE(t, terraformOptions); err == nil {
+			if resources, err := ParseInventory(stateJSON); err == nil {
+				module.Resources = resources
+			} else {
+				logging.New(t).Warn("Failed to read resource inventory", "module", module.Dir, "error", err)
+			}
+		}
+		r.RecordModule(t, module)
+
+		r.mu.Lock()
+		observers := append([]Observer(nil), r.observers...)
+		r.mu.Unlock()
+		for _, observe := range observers {
+			observe(t, terraformOptions, module)
+		}
+	}()
+
+	apply()
+	module.Failed = false
+}
+
+// ParseInventory lists the managed resources in `terraform show -json` state output
+func ParseInventory(stateJSON string) ([]Resource, error) {
+	var parsed state
+	if err := json.Unmarshal([]byte(stateJSON), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse state JSON: %w", err)
+	}
+
+	var resources []Resource
+	var walk func(module stateModule)
+	walk = func(module stateModule) {
+		for _, resource := range module.Resources {
+			if resource.Mode != "managed" {
+				continue
+			}
+			id, _ := resource.Values["id"].(string)
+			arn, _ := resource.Values["arn"].(string)
+			resources = append(resources, Resource{
+				Address: resource.Address,
+				Type:    resource.Type,
+				ID:      id,
+				ARN:     arn,
+			})
+		}
+		for _, child := range module.ChildModules {
+			walk(child)
+		}
+	}
+	walk(parsed.Values.RootModule)
+
+	return resources, nil
+}
@@ -0,0 +1,139 @@
+// =============================================================================
+// JUnit Report
+// JUnit XML output for CI test dashboards
+// =============================================================================
+
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// junitSuites is the <testsuites> root element
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+// junitSuite is one <testsuite>
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitCase     `xml:"testcase"`
+}
+
+// junitProperty is a <property> of a suite
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// junitCase is one <testcase>
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// junitMessage is a <failure> or <skipped> element
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// WriteJUnit writes the report as JUnit XML; each test's applied modules and
+// resources are listed in its system-out and every module is a suite property
+func (r *Report) WriteJUnit(w io.Writer) error {
+	suite := junitSuite{
+		Name:      r.Suite,
+		Tests:     len(r.Tests),
+		Failures:  r.Count(Failed),
+		Skipped:   r.Count(Skipped),
+		Time:      seconds(r.Duration),
+		Timestamp: r.Started.UTC().Format(time.RFC3339),
+	}
+
+	for _, testCase := range r.Tests {
+		className, _, _ := strings.Cut(testCase.Name, "/")
+		junit := junitCase{
+			ClassName: r.Suite + "." + className,
+			Name:      testCase.Name,
+			Time:      seconds(testCase.Duration),
+			SystemOut: inventoryText(testCase.Modules),
+		}
+		switch testCase.Outcome {
+		case Failed:
+			junit.Failure = &junitMessage{Message: "test failed; see the test log for assertion details"}
+		case Skipped:
+			junit.Skipped = &junitMessage{Message: "test skipped"}
+		case Running:
+			junit.Failure = &junitMessage{Message: "test did not finish"}
+		}
+		suite.Cases = append(suite.Cases, junit)
+
+		for _, module := range testCase.Modules {
+			suite.Properties = append(suite.Properties, junitProperty{Name: "module", Value: module.Dir})
+		}
+	}
+
+	document := junitSuites{
+		Name:     r.Suite,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// inventoryText lists modules and their resources, one resource per line
+func inventoryText(modules []Module) string {
+	var b strings.Builder
+	for _, module := range modules {
+		status := "applied"
+		if module.Failed {
+			status = "apply failed"
+		}
+		fmt.Fprintf(&b, "module %s (%s in %s, %d resources)\n", module.Dir, status, seconds(module.Duration), len(module.Resources))
+		for _, resource := range module.Resources {
+			identifier := resource.ARN
+			if identifier == "" {
+				identifier = resource.ID
+			}
+			fmt.Fprintf(&b, "  %s %s\n", resource.Address, identifier)
+		}
+	}
+	return b.String()
+}
+
+// seconds formats a duration the way JUnit expects
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,211 @@
+// =============================================================================
+// Test Run Reporting
+// Records tests, applied modules and their resources for JUnit/HTML reports
+// =============================================================================
+
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// DirEnvVar names the directory reports are written to; no report is written when unset
+const DirEnvVar = "TERRATEST_REPORT_DIR"
+
+// Outcome is the result of one test
+type Outcome string
+
+// Test outcomes
+const (
+	Passed  Outcome = "passed"
+	Failed  Outcome = "failed"
+	Skipped Outcome = "skipped"
+	Running Outcome = "running"
+)
+
+// TestCase is one tracked test and the modules it applied
+type TestCase struct {
+	Name     string
+	Outcome  Outcome
+	Started  time.Time
+	Duration time.Duration
+	Modules  []Module
+}
+
+// Module is one Terraform or Terragrunt apply and the resources in its state afterwards
+type Module struct {
+	Dir       string
+	Duration  time.Duration
+	Failed    bool
+	Resources []Resource
+}
+
+// Resource is one managed resource from the state inventory
+type Resource struct {
+	Address string
+	Type    string
+	ID      string
+	ARN     string
+}
+
+// Report is a snapshot of a recorded run
+type Report struct {
+	Suite    string
+	Started  time.Time
+	Duration time.Duration
+	Tests    []TestCase
+}
+
+// Recorder collects test outcomes and applied modules; it is safe for parallel tests
+type Recorder struct {
+	mu        sync.Mutex
+	started   time.Time
+	tests     map[string]*TestCase
+	observers []Observer
+}
+
+// Observer is called with every module recorded by Apply and the options it was applied with
+type Observer func(t *testing.T, terraformOptions *terraform.Options, module Module)
+
+// NewRecorder returns an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{started: time.Now(), tests: map[string]*TestCase{}}
+}
+
+// Default is the recorder used by the package-level functions and testutil.Main
+var Default = NewRecorder()
+
+// Track records t's outcome and duration when it finishes; repeated calls are no-ops
+func Track(t *testing.T) {
+	Default.Track(t)
+}
+
+// Track records t's outcome and duration when it finishes; repeated calls are no-ops
+func (r *Recorder) Track(t *testing.T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tests[t.Name()]; ok {
+		return
+	}
+	testCase := &TestCase{Name: t.Name(), Outcome: Running, Started: time.Now()}
+	r.tests[t.Name()] = testCase
+
+	t.Cleanup(func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		testCase.Duration = time.Since(testCase.Started)
+		switch {
+		case t.Failed():
+			testCase.Outcome = Failed
+		case t.Skipped():
+			testCase.Outcome = Skipped
+		default:
+			testCase.Outcome = Passed
+		}
+	})
+}
+
+// Observe registers observer for the modules recorded by Apply
+func Observe(observer Observer) {
+	Default.Observe(observer)
+}
+
+// Observe registers observer for the modules recorded by Apply
+func (r *Recorder) Observe(observer Observer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observers = append(r.observers, observer)
+}
+
+// RecordModule attaches an applied module to t, tracking t if needed
+func (r *Recorder) RecordModule(t *testing.T, module Module) {
+	r.Track(t)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	testCase := r.tests[t.Name()]
+	testCase.Modules = append(testCase.Modules, module)
+}
+
+// Report returns a snapshot of the recorded tests sorted by name
+func (r *Recorder) Report(suite string) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &Report{Suite: suite, Started: r.started, Duration: time.Since(r.started)}
+	for _, testCase := range r.tests {
+		copied := *testCase
+		copied.Modules = append([]Module(nil), testCase.Modules...)
+		report.Tests = append(report.Tests, copied)
+	}
+	sort.Slice(report.Tests, func(i, j int) bool { return report.Tests[i].Name < report.Tests[j].Name })
+	return report
+}
+
+// Write writes <suite>.xml and <suite>.html to dir
+func (r *Recorder) Write(dir, suite string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	report := r.Report(suite)
+	writers := map[string]func(*os.File) error{
+		suite + ".xml":  func(f *os.File) error { return report.WriteJUnit(f) },
+		suite + ".html": func(f *os.File) error { return report.WriteHTML(f) },
+	}
+	for name, write := range writers {
+		file, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", name, err)
+		}
+		if err := write(file); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFromEnv writes the default recorder's reports to DirEnvVar, if set
+func WriteFromEnv(suite string) error {
+	dir := os.Getenv(DirEnvVar)
+	if dir == "" {
+		return nil
+	}
+	return Default.Write(dir, suite)
+}
+
+// Count returns the number of tests with outcome
+func (r *Report) Count(outcome Outcome) int {
+	count := 0
+	for _, testCase := range r.Tests {
+		if testCase.Outcome == outcome {
+			count++
+		}
+	}
+	return count
+}
+
+// Resources returns the number of resources across every applied module
+func (r *Report) Resources() int {
+	total := 0
+	for _, testCase := range r.Tests {
+		for _, module := range testCase.Modules {
+			total += len(module.Resources)
+		}
+	}
+	return total
+}
@@ -0,0 +1,120 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInventory(t *testing.T) {
+	stateJSON, err := os.ReadFile("testdata/state.json")
+	require.NoError(t, err)
+
+	resources, err := ParseInventory(string(stateJSON))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Resource{
+		{Address: "aws_s3_bucket.raw", Type: "aws_s3_bucket", ID: "dl-test-raw-abc123", ARN: "arn:aws:s3:::dl-test-raw-abc123"},
+		{
+			Address: "module.catalog.aws_glue_catalog_database.raw",
+			Type:    "aws_glue_catalog_database",
+			ID:      "123456789012:dl_test_raw",
+			ARN:     "arn:aws:glue:us-east-1:123456789012:database/dl_test_raw",
+		},
+	}, resources)
+}
+
+func TestRecorder(t *testing.T) {
+	recorder := NewRecorder()
+
+	t.Run("passes", func(t *testing.T) {
+		recorder.Track(t)
+		recorder.Track(t)
+		recorder.RecordModule(t, Module{Dir: "../", Resources: []Resource{{Address: "aws_vpc.main"}}})
+	})
+	t.Run("skips", func(t *testing.T) {
+		recorder.Track(t)
+		t.Skip("not applicable")
+	})
+
+	report := recorder.Report("networking")
+	require.Len(t, report.Tests, 2)
+	assert.Equal(t, "TestRecorder/passes", report.Tests[0].Name)
+	assert.Equal(t, Passed, report.Tests[0].Outcome)
+	assert.Len(t, report.Tests[0].Modules, 1)
+	assert.Equal(t, Skipped, report.Tests[1].Outcome)
+	assert.Equal(t, 1, report.Resources())
+
+	dir := t.TempDir()
+	require.NoError(t, recorder.Write(dir, "networking"))
+	assert.FileExists(t, filepath.Join(dir, "networking.xml"))
+	assert.FileExists(t, filepath.Join(dir, "networking.html"))
+}
+
+func sampleReport() *Report {
+	return &Report{
+		Suite:    "storage",
+		Started:  time.Date(2024, 11, 20, 10, 0, 0, 0, time.UTC),
+		Duration: 90 * time.Second,
+		Tests: []TestCase{
+			{
+				Name:     "TestStorage/us-east-1",
+				Outcome:  Passed,
+				Duration: 80 * time.Second,
+				Modules: []Module{{
+					Dir:       "../",
+					Duration:  60 * time.Second,
+					Resources: []Resource{{Address: "aws_s3_bucket.raw", Type: "aws_s3_bucket", ARN: "arn:aws:s3:::raw"}},
+				}},
+			},
+			{Name: "TestStoragePlan", Outcome: Failed, Duration: 5 * time.Second},
+		},
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, sampleReport().WriteJUnit(&out))
+
+	xml := out.String()
+	assert.True(t, strings.HasPrefix(xml, "<?xml"))
+	assert.Contains(t, xml, `<testsuite name="storage" tests="2" failures="1" skipped="0" time="90.000" timestamp="2024-11-20T10:00:00Z">`)
+	assert.Contains(t, xml, `<property name="module" value="../"></property>`)
+	assert.Contains(t, xml, `<testcase classname="storage.TestStorage" name="TestStorage/us-east-1" time="80.000">`)
+	assert.Contains(t, xml, "aws_s3_bucket.raw arn:aws:s3:::raw")
+	assert.Contains(t, xml, `<failure message="test failed; see the test log for assertion details"></failure>`)
+}
+
+func TestWriteHTML(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, sampleReport().WriteHTML(&out))
+
+	html := out.String()
+	assert.Contains(t, html, "2 tests: 1 passed, 1 failed, 0 skipped.")
+	assert.Contains(t, html, `<td class="failed">failed</td>`)
+	assert.Contains(t, html, "<td>arn:aws:s3:::raw</td>")
+}
+
+func TestApplyNotifiesObservers(t *testing.T) {
+	recorder := NewRecorder()
+
+	var observed []Module
+	recorder.Observe(func(t *testing.T, terraformOptions *terraform.Options, module Module) {
+		observed = append(observed, module)
+	})
+
+	// The inventory cannot be read without a Terraform binary, which leaves the module without resources
+	options := &terraform.Options{TerraformDir: t.TempDir(), TerraformBinary: "terraform-not-installed"}
+	recorder.Apply(t, options, func() {})
+
+	require.Len(t, observed, 1)
+	assert.Equal(t, options.TerraformDir, observed[0].Dir)
+	assert.False(t, observed[0].Failed)
+}
@@ -0,0 +1,373 @@
+// =============================================================================
+// Teardown Residue
+// Verifies the resources a stack recorded are gone after destroy
+// =============================================================================
+
+// Package residue re-queries AWS after a destroy for every resource the stack's
+// state recorded beforehand, and fails the test for any still there. Destroy
+// can report success while leaving resources behind, such as a bucket whose
+// objects were written outside Terraform or a VPC held by a stray network
+// interface; this catches them before they bill and collide with later runs.
+package residue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/smithy-go"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logs"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/report"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/wait"
+)
+
+// Residue is a recorded resource still present after destroy
+type Residue struct {
+	Resource report.Resource
+
+	// State describes what is left, e.g. "exists and is not empty"
+	State string
+}
+
+func (r Residue) String() string {
+	return fmt.Sprintf("%s (%s): %s", r.Resource.Address, r.Resource.ID, r.State)
+}
+
+// lookupFunc returns the state of a resource, or "" once it is gone
+type lookupFunc func(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error)
+
+// lookups are keyed by Terraform resource type; types without one, mostly
+// sub-resources such as bucket policies that go with their parent, are skipped
+var lookups = map[string]lookupFunc{
+	"aws_s3_bucket":             bucketState,
+	"aws_kms_key":               keyState,
+	"aws_kms_alias":             aliasState,
+	"aws_vpc":                   vpcState,
+	"aws_subnet":                subnetState,
+	"aws_security_group":        securityGroupState,
+	"aws_internet_gateway":      internetGatewayState,
+	"aws_nat_gateway":           natGatewayState,
+	"aws_eip":                   addressState,
+	"aws_vpc_endpoint":          vpcEndpointState,
+	"aws_route_table":           routeTableState,
+	"aws_network_acl":           networkACLState,
+	"aws_iam_role":              roleState,
+	"aws_iam_policy":            policyState,
+	"aws_glue_catalog_database": databaseState,
+	"aws_cloudwatch_log_group":  logGroupState,
+	"aws_sns_topic":             topicState,
+	"aws_athena_workgroup":      workGroupState,
+	"aws_ssm_parameter":         parameterState,
+}
+
+// Checked reports whether resources of resourceType are verified after destroy
+func Checked(resourceType string) bool {
+	_, ok := lookups[resourceType]
+	return ok
+}
+
+// Polling controls how long Assert waits for deletions still in progress
+var Polling = wait.Options{
+	Timeout:    3 * time.Minute,
+	Initial:    5 * time.Second,
+	Max:        30 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// Record reads the resources in the state of the stack in options, to be
+// passed to Assert once it is destroyed; a stack whose state cannot be read
+// is logged and records nothing
+func Record(t *testing.T, options *terraform.Options) []report.Resource {
+	logger := logging.New(t, "dir", options.TerraformDir)
+
+	stateJSON, err := terraform.ShowE(t, options)
+	if err != nil {
+		logger.Warn("Failed to read state, skipping the residue check", "error", err)
+		return nil
+	}
+	resources, err := report.ParseInventory(stateJSON)
+	if err != nil {
+		logger.Warn("Failed to parse state, skipping the residue check", "error", err)
+		return nil
+	}
+	return resources
+}
+
+// Check looks up every checked resource once and returns those still present
+func Check(ctx context.Context, clients *awsclients.Clients, resources []report.Resource) ([]Residue, error) {
+	var residue []Residue
+	var errs []error
+	for _, resource := range resources {
+		lookup, ok := lookups[resource.Type]
+		if !ok || resource.ID == "" {
+			continue
+		}
+		state, err := lookup(ctx, clients, resource)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", resource.Address, err))
+			continue
+		}
+		if state != "" {
+			residue = append(residue, Residue{Resource: resource, State: state})
+		}
+	}
+	sort.Slice(residue, func(i, j int) bool { return residue[i].Resource.Address < residue[j].Resource.Address })
+	return residue, errors.Join(errs...)
+}
+
+// Assert fails t for every recorded resource still present once destroy has
+// returned, polling with Polling since deletions such as NAT gateways and
+// network interfaces finish asynchronously
+func Assert(t *testing.T, clients *awsclients.Clients, resources []report.Resource) {
+	t.Helper()
+	logger := logging.New(t)
+
+	checked := 0
+	for _, resource := range resources {
+		if Checked(resource.Type) && resource.ID != "" {
+			checked++
+		}
+	}
+	if checked == 0 {
+		return
+	}
+
+	var residue []Residue
+	err := wait.WaitFor(context.Background(), func(ctx context.Context) (bool, error) {
+		var err error
+		residue, err = Check(ctx, clients, resources)
+		return len(residue) == 0, err
+	}, Polling)
+
+	if err != nil && len(residue) == 0 {
+		logger.Warn("Failed to verify teardown left nothing behind", "error", err)
+		return
+	}
+	if !assert.Empty(t, residue, "Destroy left resources behind; delete them or run cmd/sweeper:\n%s", Describe(residue)) {
+		return
+	}
+	logger.Success("Teardown left nothing behind", "checked", checked, "skipped", len(resources)-checked)
+}
+
+// Describe lists residue one resource per line
+func Describe(residue []Residue) string {
+	lines := make([]string, len(residue))
+	for i, r := range residue {
+		lines[i] = "  " + r.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// gone reads a lookup's error: the not-found codes mean the resource is gone,
+// nil means it is still there and anything else is returned
+func gone(err error, codes ...string) (bool, error) {
+	if err == nil {
+		return false, nil
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		for _, code := range codes {
+			if apiErr.ErrorCode() == code {
+				return true, nil
+			}
+		}
+	}
+	return false, err
+}
+
+// present turns a lookup's error into its state: "" when gone, "exists" when not
+func present(err error, codes ...string) (string, error) {
+	isGone, err := gone(err, codes...)
+	if err != nil || isGone {
+		return "", err
+	}
+	return "exists", nil
+}
+
+// bucketState reports whether a leftover bucket still holds objects, the usual
+// reason destroy failed to delete it
+func bucketState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	_, err := clients.S3().HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(resource.ID)})
+	if isGone, err := gone(err, "NotFound", "NoSuchBucket"); err != nil || isGone {
+		return "", err
+	}
+
+	versions, err := clients.S3().ListObjectVersions(ctx, &s3.ListObjectVersionsInput{
+		Bucket:  aws.String(resource.ID),
+		MaxKeys: aws.Int32(1),
+	})
+	if err != nil {
+		return "exists", nil
+	}
+	if len(versions.Versions) > 0 || len(versions.DeleteMarkers) > 0 {
+		return "exists and is not empty", nil
+	}
+	return "exists", nil
+}
+
+// keyState accepts a key scheduled for deletion, since KMS keys cannot be
+// deleted immediately
+func keyState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	output, err := clients.KMS().DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(resource.ID)})
+	if isGone, err := gone(err, "NotFoundException"); err != nil || isGone {
+		return "", err
+	}
+	switch state := output.KeyMetadata.KeyState; state {
+	case kmstypes.KeyStatePendingDeletion, kmstypes.KeyStatePendingReplicaDeletion:
+		return "", nil
+	default:
+		return fmt.Sprintf("key is %s, not scheduled for deletion", state), nil
+	}
+}
+
+func aliasState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	_, err := clients.KMS().DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(resource.ID)})
+	return present(err, "NotFoundException")
+}
+
+// vpcState names the network interfaces still attached, which keep a VPC and
+// its subnets and security groups from being deleted
+func vpcState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	_, err := clients.EC2().DescribeVpcs(ctx, &ec2.DescribeVpcsInput{VpcIds: []string{resource.ID}})
+	if isGone, err := gone(err, "InvalidVpcID.NotFound"); err != nil || isGone {
+		return "", err
+	}
+
+	interfaces, err := clients.EC2().DescribeNetworkInterfaces(ctx, &ec2.DescribeNetworkInterfacesInput{
+		Filters: []ec2types.Filter{{Name: aws.String("vpc-id"), Values: []string{resource.ID}}},
+	})
+	if err != nil || len(interfaces.NetworkInterfaces) == 0 {
+		return "exists", nil
+	}
+	ids := make([]string, len(interfaces.NetworkInterfaces))
+	for i, eni := range interfaces.NetworkInterfaces {
+		ids[i] = aws.ToString(eni.NetworkInterfaceId)
+	}
+	return "exists with network interfaces " + strings.Join(ids, ", "), nil
+}
+
+func subnetState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	_, err := clients.EC2().DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{SubnetIds: []string{resource.ID}})
+	return present(err, "InvalidSubnetID.NotFound")
+}
+
+func securityGroupState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	_, err := clients.EC2().DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{GroupIds: []string{resource.ID}})
+	return present(err, "InvalidGroup.NotFound")
+}
+
+func internetGatewayState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	_, err := clients.EC2().DescribeInternetGateways(ctx, &ec2.DescribeInternetGatewaysInput{InternetGatewayIds: []string{resource.ID}})
+	return present(err, "InvalidInternetGatewayID.NotFound")
+}
+
+// natGatewayState accepts a deleted gateway, which stays visible for about an hour
+func natGatewayState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	output, err := clients.EC2().DescribeNatGateways(ctx, &ec2.DescribeNatGatewaysInput{NatGatewayIds: []string{resource.ID}})
+	if isGone, err := gone(err, "NatGatewayNotFound"); err != nil || isGone {
+		return "", err
+	}
+	for _, gateway := range output.NatGateways {
+		if gateway.State != ec2types.NatGatewayStateDeleted {
+			return fmt.Sprintf("gateway is %s", gateway.State), nil
+		}
+	}
+	return "", nil
+}
+
+func addressState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	_, err := clients.EC2().DescribeAddresses(ctx, &ec2.DescribeAddressesInput{AllocationIds: []string{resource.ID}})
+	return present(err, "InvalidAllocationID.NotFound")
+}
+
+// vpcEndpointState accepts a deleted endpoint, which stays visible for a while
+func vpcEndpointState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	output, err := clients.EC2().DescribeVpcEndpoints(ctx, &ec2.DescribeVpcEndpointsInput{VpcEndpointIds: []string{resource.ID}})
+	if isGone, err := gone(err, "InvalidVpcEndpointId.NotFound"); err != nil || isGone {
+		return "", err
+	}
+	for _, endpoint := range output.VpcEndpoints {
+		if !strings.EqualFold(string(endpoint.State), "deleted") {
+			return fmt.Sprintf("endpoint is %s", endpoint.State), nil
+		}
+	}
+	return "", nil
+}
+
+func routeTableState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	_, err := clients.EC2().DescribeRouteTables(ctx, &ec2.DescribeRouteTablesInput{RouteTableIds: []string{resource.ID}})
+	return present(err, "InvalidRouteTableID.NotFound")
+}
+
+func networkACLState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	_, err := clients.EC2().DescribeNetworkAcls(ctx, &ec2.DescribeNetworkAclsInput{NetworkAclIds: []string{resource.ID}})
+	return present(err, "InvalidNetworkAclID.NotFound")
+}
+
+func roleState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	_, err := clients.IAM().GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(resource.ID)})
+	return present(err, "NoSuchEntity")
+}
+
+// policyState looks the policy up by ARN, which is its ID in state
+func policyState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	_, err := clients.IAM().GetPolicy(ctx, &iam.GetPolicyInput{PolicyArn: aws.String(resource.ID)})
+	return present(err, "NoSuchEntity")
+}
+
+// databaseState strips the catalog ID from the state ID, which is catalog:name
+func databaseState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	name := resource.ID[strings.Index(resource.ID, ":")+1:]
+	_, err := clients.Glue().GetDatabase(ctx, &glue.GetDatabaseInput{Name: aws.String(name)})
+	return present(err, "EntityNotFoundException")
+}
+
+func logGroupState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	group, err := logs.Describe(ctx, clients.Logs(), resource.ID)
+	if err != nil || group == nil {
+		return "", err
+	}
+	return "exists", nil
+}
+
+// topicState looks the topic up by ARN, which is its ID in state
+func topicState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	_, err := clients.SNS().GetTopicAttributes(ctx, &sns.GetTopicAttributesInput{TopicArn: aws.String(resource.ID)})
+	return present(err, "NotFound")
+}
+
+// workGroupState reads Athena's InvalidRequestException for a missing
+// workgroup from its message, as the service has no dedicated code for it
+func workGroupState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	_, err := clients.Athena().GetWorkGroup(ctx, &athena.GetWorkGroupInput{WorkGroup: aws.String(resource.ID)})
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && strings.Contains(strings.ToLower(apiErr.ErrorMessage()), "not found") {
+		return "", nil
+	}
+	return present(err)
+}
+
+func parameterState(ctx context.Context, clients *awsclients.Clients, resource report.Resource) (string, error) {
+	_, err := clients.SSM().GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(resource.ID)})
+	return present(err, "ParameterNotFound")
+}
@@ -0,0 +1,70 @@
+package residue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/report"
+)
+
+func TestGone(t *testing.T) {
+	isGone, err := gone(nil, "NoSuchEntity")
+	assert.False(t, isGone)
+	assert.NoError(t, err)
+
+	isGone, err = gone(fmt.Errorf("get role: %w", &smithy.GenericAPIError{Code: "NoSuchEntity"}), "NoSuchEntity")
+	assert.True(t, isGone)
+	assert.NoError(t, err)
+
+	denied := &smithy.GenericAPIError{Code: "AccessDenied"}
+	isGone, err = gone(denied, "NoSuchEntity")
+	assert.False(t, isGone)
+	assert.ErrorIs(t, err, denied)
+
+	isGone, err = gone(errors.New("connection reset"), "NoSuchEntity")
+	assert.False(t, isGone)
+	assert.Error(t, err)
+}
+
+func TestPresent(t *testing.T) {
+	state, err := present(nil, "InvalidVpcID.NotFound")
+	assert.Equal(t, "exists", state)
+	assert.NoError(t, err)
+
+	state, err = present(&smithy.GenericAPIError{Code: "InvalidVpcID.NotFound"}, "InvalidVpcID.NotFound")
+	assert.Empty(t, state)
+	assert.NoError(t, err)
+}
+
+func TestChecked(t *testing.T) {
+	for _, resourceType := range []string{"aws_s3_bucket", "aws_kms_key", "aws_vpc", "aws_nat_gateway", "aws_iam_role"} {
+		assert.True(t, Checked(resourceType), resourceType)
+	}
+	for _, resourceType := range []string{"aws_s3_bucket_policy", "aws_route_table_association", "random_id"} {
+		assert.False(t, Checked(resourceType), resourceType)
+	}
+}
+
+func TestCheckSkipsUncheckedResources(t *testing.T) {
+	// None of these are looked up, so no clients are needed
+	residue, err := Check(context.Background(), nil, []report.Resource{
+		{Address: "aws_s3_bucket_versioning.raw", Type: "aws_s3_bucket_versioning", ID: "dl-raw-dev"},
+		{Address: "random_id.suffix", Type: "random_id", ID: "abcd"},
+		{Address: "aws_s3_bucket.raw", Type: "aws_s3_bucket"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, residue)
+}
+
+func TestDescribe(t *testing.T) {
+	assert.Equal(t, "  aws_vpc.main (vpc-0abc): exists with network interfaces eni-1\n"+
+		"  aws_s3_bucket.raw (dl-raw-dev): exists and is not empty", Describe([]Residue{
+		{Resource: report.Resource{Address: "aws_vpc.main", ID: "vpc-0abc"}, State: "exists with network interfaces eni-1"},
+		{Resource: report.Resource{Address: "aws_s3_bucket.raw", ID: "dl-raw-dev"}, State: "exists and is not empty"},
+	}))
+}
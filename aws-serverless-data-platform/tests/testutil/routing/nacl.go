@@ -0,0 +1,311 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+)
+
+// Rule is one entry of a network ACL
+type Rule struct {
+	Number   int32
+	Egress   bool
+	Allow    bool
+	Protocol string
+	CIDR     string
+	Ports    string
+}
+
+// String renders the rule as it appears in the console, e.g. "100 allow all from 10.0.1.0/24"
+func (r Rule) String() string {
+	action, direction := "deny", "from"
+	if r.Allow {
+		action = "allow"
+	}
+	if r.Egress {
+		direction = "to"
+	}
+	protocol := r.Protocol
+	if protocol == "-1" {
+		protocol = "all"
+	}
+	if r.Ports != "" {
+		protocol += " " + r.Ports
+	}
+
+	number := fmt.Sprint(r.Number)
+	if r.Number == 32767 {
+		number = "*"
+	}
+	return fmt.Sprintf("%s %s %s %s %s", number, action, protocol, direction, r.CIDR)
+}
+
+// EphemeralPorts is the port range of a rule admitting replies to connections
+// the subnet opens, as the Rule's Ports renders it
+const EphemeralPorts = "1024-65535"
+
+// ACL is the network ACL a subnet uses
+type ACL struct {
+	ID    string
+	Rules []Rule
+}
+
+// AssertIngressOnlyFrom checks the network ACL of every subnet admits traffic
+// from each of sources and from nothing outside them, apart from TCP replies on
+// the ephemeral ports from replies; failures list the ACL's inbound rules
+func AssertIngressOnlyFrom(t *testing.T, clients *awsclients.Clients, vpcID, tier string, subnetIDs, sources, replies []string) {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	acls, err := SubnetACLs(ctx, clients.EC2(), vpcID)
+	require.NoError(t, err, "Failed to describe network ACLs of %s", vpcID)
+
+	failed := false
+	for _, subnetID := range subnetIDs {
+		acl, ok := acls[subnetID]
+		if !ok {
+			t.Errorf("%s subnet %s has no network ACL", tier, subnetID)
+			failed = true
+			continue
+		}
+		if problems := CheckIngress(acl, sources, replies); len(problems) > 0 {
+			t.Errorf("%s subnet %s network ACL admits the wrong sources (%s):\n%s", tier, subnetID,
+				strings.Join(problems, "; "), FormatIngress(acl))
+			failed = true
+		}
+	}
+	if !failed {
+		logging.New(t).Success("Subnets only admit traffic from their sources", "tier", tier, "subnets", len(subnetIDs), "sources", strings.Join(sources, ","))
+	}
+}
+
+// SubnetACLs returns the network ACL associated with every subnet in the VPC
+func SubnetACLs(ctx context.Context, client *ec2.Client, vpcID string) (map[string]ACL, error) {
+	acls := map[string]ACL{}
+	paginator := ec2.NewDescribeNetworkAclsPaginator(client, &ec2.DescribeNetworkAclsInput{
+		Filters: []ec2types.Filter{{Name: awssdk.String("vpc-id"), Values: []string{vpcID}}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, networkACL := range page.NetworkAcls {
+			acl := newACL(networkACL)
+			for _, association := range networkACL.Associations {
+				acls[awssdk.ToString(association.SubnetId)] = acl
+			}
+		}
+	}
+	return acls, nil
+}
+
+// newACL converts an EC2 network ACL, ordering its rules as AWS evaluates them
+func newACL(networkACL ec2types.NetworkAcl) ACL {
+	acl := ACL{ID: awssdk.ToString(networkACL.NetworkAclId)}
+	for _, entry := range networkACL.Entries {
+		cidr := awssdk.ToString(entry.CidrBlock)
+		if cidr == "" {
+			cidr = awssdk.ToString(entry.Ipv6CidrBlock)
+		}
+		rule := Rule{
+			Number:   awssdk.ToInt32(entry.RuleNumber),
+			Egress:   awssdk.ToBool(entry.Egress),
+			Allow:    entry.RuleAction == ec2types.RuleActionAllow,
+			Protocol: awssdk.ToString(entry.Protocol),
+			CIDR:     cidr,
+		}
+		if entry.PortRange != nil {
+			rule.Ports = fmt.Sprintf("%d-%d", awssdk.ToInt32(entry.PortRange.From), awssdk.ToInt32(entry.PortRange.To))
+		}
+		acl.Rules = append(acl.Rules, rule)
+	}
+	sort.SliceStable(acl.Rules, func(i, j int) bool { return acl.Rules[i].Number < acl.Rules[j].Number })
+	return acl
+}
+
+// CheckIngress lists the inbound allow rules that admit addresses outside
+// sources, and the sources no inbound rule admits; TCP rules on the ephemeral
+// ports within replies are allowed. Rules are evaluated in ascending rule
+// number, so an allow rule an earlier deny covers admits nothing
+func CheckIngress(acl ACL, sources, replies []string) []string {
+	var problems []string
+	admitted := map[string]bool{}
+	rules := inbound(acl)
+	for i, rule := range rules {
+		if !rule.Allow || shadowed(rules[:i], rule) {
+			continue
+		}
+
+		within := false
+		for _, source := range sources {
+			if contained(rule.CIDR, source) {
+				within = true
+			}
+			if contained(source, rule.CIDR) && !denied(rules[:i], source) {
+				admitted[source] = true
+			}
+		}
+		if rule.Protocol == "6" && rule.Ports == EphemeralPorts {
+			for _, reply := range replies {
+				if contained(rule.CIDR, reply) {
+					within = true
+				}
+			}
+		}
+		if !within {
+			problems = append(problems, fmt.Sprintf("rule %s admits traffic from outside %s", rule, strings.Join(sources, ", ")))
+		}
+	}
+
+	for _, source := range sources {
+		if !admitted[source] {
+			problems = append(problems, fmt.Sprintf("no rule admits traffic from %s", source))
+		}
+	}
+	return problems
+}
+
+// Admits evaluates the ACL's inbound rules in ascending rule number and returns
+// the rule deciding traffic from cidr on protocol and port: the first one whose
+// range holds all of cidr. The default rule denies what no rule matches
+func Admits(acl ACL, cidr, protocol string, port int32) (Rule, bool) {
+	for _, rule := range inbound(acl) {
+		if rule.matches(protocol, port) && contained(cidr, rule.CIDR) {
+			return rule, rule.Allow
+		}
+	}
+	return Rule{Number: 32767, Protocol: "-1", CIDR: "0.0.0.0/0"}, false
+}
+
+// AssertDenied checks the network ACL of every subnet denies traffic from each
+// of sources on protocol and port, e.g. the public tier on a database port
+func AssertDenied(t *testing.T, clients *awsclients.Clients, vpcID, tier string, subnetIDs, sources []string, protocol string, port int32) {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	acls, err := SubnetACLs(ctx, clients.EC2(), vpcID)
+	require.NoError(t, err, "Failed to describe network ACLs of %s", vpcID)
+
+	for _, subnetID := range subnetIDs {
+		acl, ok := acls[subnetID]
+		if !ok {
+			t.Errorf("%s subnet %s has no network ACL", tier, subnetID)
+			continue
+		}
+		for _, source := range sources {
+			if rule, allowed := Admits(acl, source, protocol, port); allowed {
+				t.Errorf("%s subnet %s network ACL admits %s on %s/%d through rule %s:\n%s", tier, subnetID,
+					source, protocol, port, rule, FormatIngress(acl))
+			}
+		}
+	}
+}
+
+// inbound returns the ACL's inbound rules in evaluation order
+func inbound(acl ACL) []Rule {
+	var rules []Rule
+	for _, rule := range acl.Rules {
+		if !rule.Egress {
+			rules = append(rules, rule)
+		}
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].Number < rules[j].Number })
+	return rules
+}
+
+// shadowed reports whether a deny rule among earlier covers all of rule's
+// addresses, protocol and ports
+func shadowed(earlier []Rule, rule Rule) bool {
+	for _, deny := range earlier {
+		if !deny.Allow && contained(rule.CIDR, deny.CIDR) && deny.covers(rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// denied reports whether a deny rule among earlier covers every protocol from cidr
+func denied(earlier []Rule, cidr string) bool {
+	for _, deny := range earlier {
+		if !deny.Allow && deny.Protocol == "-1" && contained(cidr, deny.CIDR) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether the rule applies to protocol, a protocol number such
+// as "6" for TCP, and port
+func (r Rule) matches(protocol string, port int32) bool {
+	if r.Protocol == "-1" {
+		return true
+	}
+	if r.Protocol != protocol {
+		return false
+	}
+	from, to, ok := r.portRange()
+	return !ok || (port >= from && port <= to)
+}
+
+// covers reports whether the rule applies to every protocol and port other does
+func (r Rule) covers(other Rule) bool {
+	if r.Protocol == "-1" {
+		return true
+	}
+	if r.Protocol != other.Protocol {
+		return false
+	}
+	from, to, ok := r.portRange()
+	if !ok {
+		return true
+	}
+	otherFrom, otherTo, otherOK := other.portRange()
+	return otherOK && otherFrom >= from && otherTo <= to
+}
+
+// portRange parses Ports; ok is false for a rule on every port
+func (r Rule) portRange() (from, to int32, ok bool) {
+	if _, err := fmt.Sscanf(r.Ports, "%d-%d", &from, &to); err != nil {
+		return 0, 0, false
+	}
+	return from, to, true
+}
+
+// FormatIngress lists the ACL's inbound rules in evaluation order
+func FormatIngress(acl ACL) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "network ACL %s inbound rules:\n", acl.ID)
+	for _, rule := range acl.Rules {
+		if !rule.Egress {
+			fmt.Fprintf(&b, "    %s\n", rule)
+		}
+	}
+	return b.String()
+}
+
+// contained reports whether the inner CIDR lies within outer
+func contained(inner, outer string) bool {
+	innerIP, innerNet, err := net.ParseCIDR(inner)
+	if err != nil {
+		return false
+	}
+	_, outerNet, err := net.ParseCIDR(outer)
+	if err != nil {
+		return false
+	}
+
+	innerOnes, innerBits := innerNet.Mask.Size()
+	outerOnes, outerBits := outerNet.Mask.Size()
+	return innerBits == outerBits && innerOnes >= outerOnes && outerNet.Contains(innerIP)
+}
@@ -0,0 +1,262 @@
+// =============================================================================
+// Subnet Routing Assertions
+// Checks the route tables and network ACLs subnets actually use, via the EC2 API
+// =============================================================================
+
+package routing
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+)
+
+const (
+	// DefaultIPv4 and DefaultIPv6 are the destinations of a default route
+	DefaultIPv4 = "0.0.0.0/0"
+	DefaultIPv6 = "::/0"
+)
+
+// Route is one entry of a route table
+type Route struct {
+	Destination string
+	Target      string
+	State       string
+}
+
+// String renders the route as "destination -> target", noting a blackhole
+func (r Route) String() string {
+	if r.State != "" && r.State != string(ec2types.RouteStateActive) {
+		return fmt.Sprintf("%s -> %s (%s)", r.Destination, r.Target, r.State)
+	}
+	return fmt.Sprintf("%s -> %s", r.Destination, r.Target)
+}
+
+// Table is the route table a subnet uses
+type Table struct {
+	ID     string
+	Main   bool
+	Routes []Route
+}
+
+// Expectation describes the routing of one subnet tier
+type Expectation struct {
+	// Tier names the subnets in failure messages, e.g. "private"
+	Tier      string
+	SubnetIDs []string
+
+	// DefaultTargets are the gateways the default route may use, e.g. every NAT
+	// gateway; empty requires the tier to have no route to the internet at all
+	DefaultTargets []string
+}
+
+// AssertRoutes checks the route table each subnet uses, explicitly associated
+// or the VPC's main table, against the expectations; failures show the table
+// with the wrong or missing routes marked
+func AssertRoutes(t *testing.T, clients *awsclients.Clients, vpcID string, expectations ...Expectation) {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	tables, err := SubnetTables(ctx, clients.EC2(), vpcID)
+	require.NoError(t, err, "Failed to describe route tables of %s", vpcID)
+
+	for _, expectation := range expectations {
+		failed := false
+		for _, subnetID := range expectation.SubnetIDs {
+			table := tables[subnetID]
+			if problems := Check(table, expectation); len(problems) > 0 {
+				t.Errorf("%s subnet %s has wrong routes (%s):\n%s", expectation.Tier, subnetID,
+					strings.Join(problems, "; "), Diff(table, expectation))
+				failed = true
+			}
+		}
+		if !failed {
+			logging.New(t).Success("Subnets route as expected", "tier", expectation.Tier, "subnets", len(expectation.SubnetIDs))
+		}
+	}
+}
+
+// SubnetTables returns the route table of every subnet in the VPC; subnets
+// without an explicit association use the main table
+func SubnetTables(ctx context.Context, client *ec2.Client, vpcID string) (map[string]Table, error) {
+	vpcFilter := []ec2types.Filter{{Name: awssdk.String("vpc-id"), Values: []string{vpcID}}}
+
+	tables := map[string]Table{}
+	var main Table
+	routeTables := ec2.NewDescribeRouteTablesPaginator(client, &ec2.DescribeRouteTablesInput{Filters: vpcFilter})
+	for routeTables.HasMorePages() {
+		page, err := routeTables.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, routeTable := range page.RouteTables {
+			table := newTable(routeTable)
+			if table.Main {
+				main = table
+			}
+			for _, association := range routeTable.Associations {
+				if subnetID := awssdk.ToString(association.SubnetId); subnetID != "" {
+					tables[subnetID] = table
+				}
+			}
+		}
+	}
+
+	subnets := ec2.NewDescribeSubnetsPaginator(client, &ec2.DescribeSubnetsInput{Filters: vpcFilter})
+	for subnets.HasMorePages() {
+		page, err := subnets.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, subnet := range page.Subnets {
+			if _, ok := tables[awssdk.ToString(subnet.SubnetId)]; !ok {
+				tables[awssdk.ToString(subnet.SubnetId)] = main
+			}
+		}
+	}
+	return tables, nil
+}
+
+// newTable converts an EC2 route table
+func newTable(routeTable ec2types.RouteTable) Table {
+	table := Table{ID: awssdk.ToString(routeTable.RouteTableId)}
+	for _, association := range routeTable.Associations {
+		if awssdk.ToBool(association.Main) {
+			table.Main = true
+		}
+	}
+
+	for _, route := range routeTable.Routes {
+		destination := awssdk.ToString(route.DestinationCidrBlock)
+		if destination == "" {
+			destination = awssdk.ToString(route.DestinationIpv6CidrBlock)
+		}
+		if destination == "" {
+			destination = awssdk.ToString(route.DestinationPrefixListId)
+		}
+		table.Routes = append(table.Routes, Route{
+			Destination: destination,
+			Target:      routeTarget(route),
+			State:       string(route.State),
+		})
+	}
+	sort.Slice(table.Routes, func(i, j int) bool { return table.Routes[i].Destination < table.Routes[j].Destination })
+	return table
+}
+
+// routeTarget returns the ID of whatever the route sends traffic to
+func routeTarget(route ec2types.Route) string {
+	for _, target := range []*string{
+		route.GatewayId, route.NatGatewayId, route.TransitGatewayId, route.VpcPeeringConnectionId,
+		route.EgressOnlyInternetGatewayId, route.NetworkInterfaceId, route.InstanceId,
+		route.LocalGatewayId, route.CarrierGatewayId, route.CoreNetworkArn,
+	} {
+		if id := awssdk.ToString(target); id != "" {
+			return id
+		}
+	}
+	return "unknown"
+}
+
+// Check lists how table fails the expectation
+func Check(table Table, expectation Expectation) []string {
+	if table.ID == "" {
+		return []string{"no route table is associated and the VPC has no main table"}
+	}
+
+	var problems []string
+	if len(expectation.DefaultTargets) == 0 {
+		for _, route := range table.Routes {
+			if isInternetRoute(route) {
+				problems = append(problems, fmt.Sprintf("unexpected internet route %s", route))
+			}
+		}
+		return problems
+	}
+
+	route, ok := defaultRoute(table)
+	switch {
+	case !ok:
+		problems = append(problems, fmt.Sprintf("no %s route, want one to %s", DefaultIPv4, strings.Join(expectation.DefaultTargets, " or ")))
+	case !contains(expectation.DefaultTargets, route.Target):
+		problems = append(problems, fmt.Sprintf("%s routes to %s, want %s", DefaultIPv4, route.Target, strings.Join(expectation.DefaultTargets, " or ")))
+	case route.State != "" && route.State != string(ec2types.RouteStateActive):
+		problems = append(problems, fmt.Sprintf("%s route is %s", DefaultIPv4, route.State))
+	}
+	return problems
+}
+
+// defaultRoute returns the table's IPv4 default route
+func defaultRoute(table Table) (Route, bool) {
+	for _, route := range table.Routes {
+		if route.Destination == DefaultIPv4 {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+// isInternetRoute reports whether the route is a default route or leaves the
+// VPC through an internet, egress-only or NAT gateway
+func isInternetRoute(route Route) bool {
+	if route.Destination == DefaultIPv4 || route.Destination == DefaultIPv6 {
+		return true
+	}
+	for _, prefix := range []string{"igw-", "eigw-", "nat-"} {
+		if strings.HasPrefix(route.Target, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff renders the table with the routes the expectation wants marked "-" and
+// the offending routes marked "+", e.g.
+//
+//	route table rtb-0abc:
+//	    10.0.0.0/16 -> local
+//	  - 0.0.0.0/0 -> nat-0a or nat-0b
+//	  + 0.0.0.0/0 -> igw-0def
+func Diff(table Table, expectation Expectation) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "route table %s:\n", table.ID)
+
+	wantDefault := len(expectation.DefaultTargets) > 0
+	current, hasDefault := defaultRoute(table)
+	defaultOK := hasDefault && contains(expectation.DefaultTargets, current.Target) &&
+		(current.State == "" || current.State == string(ec2types.RouteStateActive))
+
+	for _, route := range table.Routes {
+		marker := " "
+		switch {
+		case !wantDefault && isInternetRoute(route):
+			marker = "+"
+		case wantDefault && route.Destination == DefaultIPv4 && !defaultOK:
+			marker = "+"
+		}
+		fmt.Fprintf(&b, "  %s %s\n", marker, route)
+	}
+	if wantDefault && !defaultOK {
+		fmt.Fprintf(&b, "  - %s -> %s\n", DefaultIPv4, strings.Join(expectation.DefaultTargets, " or "))
+	}
+	return b.String()
+}
+
+// contains reports whether values includes value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
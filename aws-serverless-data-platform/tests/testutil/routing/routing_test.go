@@ -0,0 +1,159 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var privateTable = Table{ID: "rtb-private", Routes: []Route{
+	{Destination: "0.0.0.0/0", Target: "nat-0a", State: "active"},
+	{Destination: "10.0.0.0/16", Target: "local", State: "active"},
+	{Destination: "pl-63a5400a", Target: "vpce-0s3", State: "active"},
+}}
+
+func TestCheckDefaultRoute(t *testing.T) {
+	private := Expectation{Tier: "private", DefaultTargets: []string{"nat-0a", "nat-0b"}}
+	assert.Empty(t, Check(privateTable, private))
+
+	public := Expectation{Tier: "public", DefaultTargets: []string{"igw-0def"}}
+	assert.Equal(t, []string{"0.0.0.0/0 routes to nat-0a, want igw-0def"}, Check(privateTable, public))
+
+	local := Table{ID: "rtb-main", Routes: []Route{{Destination: "10.0.0.0/16", Target: "local"}}}
+	assert.Equal(t, []string{"no 0.0.0.0/0 route, want one to igw-0def"}, Check(local, public))
+
+	blackhole := Table{ID: "rtb-private", Routes: []Route{{Destination: "0.0.0.0/0", Target: "nat-0a", State: "blackhole"}}}
+	assert.Equal(t, []string{"0.0.0.0/0 route is blackhole"}, Check(blackhole, private))
+
+	assert.NotEmpty(t, Check(Table{}, private), "A subnet without a table fails")
+}
+
+func TestCheckNoInternetRoute(t *testing.T) {
+	database := Expectation{Tier: "database"}
+	isolated := Table{ID: "rtb-database", Routes: []Route{
+		{Destination: "10.0.0.0/16", Target: "local"},
+		{Destination: "pl-63a5400a", Target: "vpce-0s3"},
+	}}
+	assert.Empty(t, Check(isolated, database), "Gateway endpoint routes stay inside AWS")
+
+	problems := Check(privateTable, database)
+	assert.Equal(t, []string{"unexpected internet route 0.0.0.0/0 -> nat-0a"}, problems)
+
+	egress := Table{ID: "rtb-database", Routes: []Route{{Destination: "::/0", Target: "eigw-0abc"}}}
+	assert.Len(t, Check(egress, database), 1)
+}
+
+func TestDiff(t *testing.T) {
+	public := Expectation{Tier: "public", DefaultTargets: []string{"igw-0def"}}
+	assert.Equal(t, `route table rtb-private:
+  + 0.0.0.0/0 -> nat-0a
+    10.0.0.0/16 -> local
+    pl-63a5400a -> vpce-0s3
+  - 0.0.0.0/0 -> igw-0def
+`, Diff(privateTable, public))
+
+	assert.Equal(t, `route table rtb-private:
+  + 0.0.0.0/0 -> nat-0a
+    10.0.0.0/16 -> local
+    pl-63a5400a -> vpce-0s3
+`, Diff(privateTable, Expectation{Tier: "database"}))
+}
+
+func TestCheckIngress(t *testing.T) {
+	private := []string{"10.0.1.0/24", "10.0.2.0/24"}
+
+	restricted := ACL{ID: "acl-database", Rules: []Rule{
+		{Number: 100, Allow: true, Protocol: "-1", CIDR: "10.0.1.0/24"},
+		{Number: 101, Allow: true, Protocol: "-1", CIDR: "10.0.2.0/24"},
+		{Number: 100, Egress: true, Allow: true, Protocol: "-1", CIDR: "0.0.0.0/0"},
+		{Number: 32767, Protocol: "-1", CIDR: "0.0.0.0/0"},
+	}}
+	assert.Empty(t, CheckIngress(restricted, private, nil))
+
+	defaultACL := ACL{ID: "acl-default", Rules: []Rule{
+		{Number: 100, Allow: true, Protocol: "-1", CIDR: "0.0.0.0/0"},
+		{Number: 32767, Protocol: "-1", CIDR: "0.0.0.0/0"},
+	}}
+	assert.Equal(t, []string{"rule 100 allow all from 0.0.0.0/0 admits traffic from outside 10.0.1.0/24, 10.0.2.0/24"},
+		CheckIngress(defaultACL, private, nil))
+
+	partial := ACL{ID: "acl-database", Rules: []Rule{
+		{Number: 100, Allow: true, Protocol: "6", CIDR: "10.0.1.0/25", Ports: "5432-5432"},
+	}}
+	assert.Equal(t, []string{"no rule admits traffic from 10.0.1.0/24", "no rule admits traffic from 10.0.2.0/24"},
+		CheckIngress(partial, private, nil), "A narrower rule stays within the source but does not admit all of it")
+
+	endpoints := ACL{ID: "acl-database", Rules: []Rule{
+		{Number: 100, Allow: true, Protocol: "-1", CIDR: "10.0.1.0/24"},
+		{Number: 101, Allow: true, Protocol: "-1", CIDR: "10.0.2.0/24"},
+		{Number: 300, Allow: true, Protocol: "6", CIDR: "0.0.0.0/0", Ports: "1024-65535"},
+	}}
+	assert.Empty(t, CheckIngress(endpoints, private, []string{"0.0.0.0/0"}))
+	assert.Equal(t, []string{"rule 300 allow 6 1024-65535 from 0.0.0.0/0 admits traffic from outside 10.0.1.0/24, 10.0.2.0/24"},
+		CheckIngress(endpoints, private, nil), "Replies are only allowed from the expected addresses")
+
+	shadowed := ACL{ID: "acl-database", Rules: []Rule{
+		{Number: 200, Allow: true, Protocol: "-1", CIDR: "10.0.0.0/16"},
+		{Number: 100, Allow: true, Protocol: "-1", CIDR: "10.0.1.0/24"},
+		{Number: 101, Allow: true, Protocol: "-1", CIDR: "10.0.2.0/24"},
+		{Number: 150, Protocol: "-1", CIDR: "10.0.0.0/16"},
+	}}
+	assert.Empty(t, CheckIngress(shadowed, private, nil), "An allow rule after a deny covering it admits nothing")
+
+	denied := ACL{ID: "acl-database", Rules: []Rule{
+		{Number: 100, Protocol: "-1", CIDR: "10.0.2.0/24"},
+		{Number: 101, Allow: true, Protocol: "-1", CIDR: "10.0.1.0/24"},
+		{Number: 102, Allow: true, Protocol: "-1", CIDR: "10.0.2.0/24"},
+	}}
+	assert.Equal(t, []string{"no rule admits traffic from 10.0.2.0/24"}, CheckIngress(denied, private, nil),
+		"A source an earlier rule denies is not admitted")
+}
+
+func TestAdmits(t *testing.T) {
+	acl := ACL{ID: "acl-database", Rules: []Rule{
+		{Number: 300, Allow: true, Protocol: "6", CIDR: "0.0.0.0/0", Ports: "1024-65535"},
+		{Number: 100, Allow: true, Protocol: "-1", CIDR: "10.0.1.0/24"},
+		{Number: 290, Protocol: "-1", CIDR: "10.0.0.0/16"},
+		{Number: 100, Egress: true, Allow: true, Protocol: "-1", CIDR: "0.0.0.0/0"},
+	}}
+
+	rule, allowed := Admits(acl, "10.0.1.0/24", "6", 5432)
+	assert.True(t, allowed)
+	assert.EqualValues(t, 100, rule.Number)
+
+	rule, allowed = Admits(acl, "10.0.101.0/24", "6", 5432)
+	assert.False(t, allowed, "The public tier is denied before the reply rule")
+	assert.EqualValues(t, 290, rule.Number)
+
+	rule, allowed = Admits(acl, "52.216.0.0/15", "6", 40000)
+	assert.True(t, allowed)
+	assert.EqualValues(t, 300, rule.Number)
+
+	rule, allowed = Admits(acl, "52.216.0.0/15", "6", 443)
+	assert.False(t, allowed, "Ports outside the reply rule fall through to the default rule")
+	assert.Equal(t, "* deny all from 0.0.0.0/0", rule.String())
+
+	acl.Rules = acl.Rules[:2]
+	_, allowed = Admits(acl, "10.0.101.0/24", "6", 5432)
+	assert.True(t, allowed, "Without the deny the reply rule admits the public tier")
+}
+
+func TestFormatIngress(t *testing.T) {
+	acl := ACL{ID: "acl-database", Rules: []Rule{
+		{Number: 100, Allow: true, Protocol: "6", CIDR: "10.0.1.0/24", Ports: "5432-5432"},
+		{Number: 100, Egress: true, Allow: true, Protocol: "-1", CIDR: "10.0.1.0/24"},
+		{Number: 32767, Protocol: "-1", CIDR: "0.0.0.0/0"},
+	}}
+	assert.Equal(t, `network ACL acl-database inbound rules:
+    100 allow 6 5432-5432 from 10.0.1.0/24
+    * deny all from 0.0.0.0/0
+`, FormatIngress(acl))
+}
+
+func TestContained(t *testing.T) {
+	assert.True(t, contained("10.0.1.0/24", "10.0.1.0/24"))
+	assert.True(t, contained("10.0.1.128/25", "10.0.1.0/24"))
+	assert.False(t, contained("10.0.0.0/16", "10.0.1.0/24"))
+	assert.False(t, contained("::/0", "0.0.0.0/0"))
+	assert.False(t, contained("not-a-cidr", "10.0.0.0/16"))
+}
@@ -0,0 +1,263 @@
+// =============================================================================
+// Terragrunt Run-All
+// Runs `terragrunt run-all` over an environment and reports each unit
+// =============================================================================
+
+// Package runall wraps `terragrunt run-all apply` and `run-all destroy` for the
+// integration suites. Terragrunt runs the units of an environment in
+// dependency order on its own; the wrapper streams the interleaved output to
+// the test log and parses it into a result per unit, so a failed run names the
+// units that failed, with their Terraform errors, and the units that were
+// skipped because a dependency failed, instead of a single exit status.
+package runall
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/deadline"
+)
+
+// Status is how a unit's part of a run ended
+type Status string
+
+const (
+	// StatusNotFinished is a unit Terragrunt did not report on, e.g. because the
+	// run was interrupted or the unit was not part of it
+	StatusNotFinished Status = "not finished"
+
+	StatusSucceeded        Status = "succeeded"
+	StatusFailed           Status = "failed"
+	StatusDependencyFailed Status = "dependency failed"
+)
+
+// Options selects the units a run covers
+type Options struct {
+	// Dir is the environment directory holding the units
+	Dir string
+
+	// EnvVars are set for Terragrunt, e.g. the credentials and region
+	EnvVars map[string]string
+
+	// IncludeDirs are the units to run, relative to Dir; Terragrunt runs their
+	// dependencies too. Empty runs every unit
+	IncludeDirs []string
+
+	// Parallelism limits the units run at once; zero leaves it to Terragrunt
+	Parallelism int
+
+	// IgnoreDependencyErrors runs units whose dependencies failed, which a
+	// destroy wants so one stuck unit does not keep the others deployed
+	IgnoreDependencyErrors bool
+}
+
+// Unit is the outcome of one unit in a run
+type Unit struct {
+	// Name is the unit directory relative to Options.Dir, e.g. "03-storage"
+	Name string
+
+	Status Status
+
+	// Errors are the Terraform "Error:" lines the unit printed
+	Errors []string
+}
+
+// Result is the outcome of a run
+type Result struct {
+	// Command is the Terraform command run in every unit, e.g. "apply"
+	Command string
+
+	// Output is the combined output of the run
+	Output string
+
+	mu    sync.Mutex
+	root  string
+	units map[string]*Unit
+}
+
+// Args returns the Terragrunt arguments for running command over o's units;
+// run-all approves applies and destroys itself
+func (o Options) Args(command string) []string {
+	args := []string{
+		"run-all", command,
+		"--terragrunt-non-interactive",
+		"--terragrunt-include-module-prefix",
+		"--terragrunt-working-dir", o.Dir,
+	}
+	for _, dir := range o.IncludeDirs {
+		args = append(args, "--terragrunt-include-dir", dir)
+	}
+	if o.Parallelism > 0 {
+		args = append(args, "--terragrunt-parallelism", strconv.Itoa(o.Parallelism))
+	}
+	if o.IgnoreDependencyErrors {
+		args = append(args, "--terragrunt-ignore-dependency-errors")
+	}
+	return args
+}
+
+// Run runs `terragrunt run-all <command>` over o's units, streaming the output
+// to the test log; the error names the units that failed. When ctx ends the
+// run is interrupted as deadline.Command would
+func Run(ctx context.Context, t testing.TB, command string, o Options) (*Result, error) {
+	// Terragrunt resolves a relative working directory against its own
+	if dir, err := filepath.Abs(o.Dir); err == nil {
+		o.Dir = dir
+	}
+	result := newResult(command, o.Dir)
+	output, err := deadline.Stream(ctx, o.Dir, o.EnvVars, func(line string) {
+		t.Log(line)
+		result.record(line)
+	}, "terragrunt", o.Args(command)...)
+	result.Output = output
+	if err != nil {
+		return result, result.wrap(err)
+	}
+	return result, nil
+}
+
+// newResult returns an empty result for a run over the units below dir, an absolute path
+func newResult(command, dir string) *Result {
+	return &Result{Command: command, root: dir, units: map[string]*Unit{}}
+}
+
+var (
+	// ansi matches terminal color codes
+	ansi = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+	// prefixed matches the unit prefix --terragrunt-include-module-prefix puts
+	// on Terraform output
+	prefixed = regexp.MustCompile(`^\[([^\]]+)\] (.*)$`)
+
+	succeeded        = regexp.MustCompile(`Module (\S+) has finished successfully`)
+	failed           = regexp.MustCompile(`Module (\S+) has finished with an error`)
+	dependencyFailed = regexp.MustCompile(`Dependency (\S+) of module (\S+) just finished with an error`)
+
+	// terraformError matches an error Terraform prints, inside its box or not
+	terraformError = regexp.MustCompile(`^[│|]?\s*(Error: .+)$`)
+)
+
+// record updates the result from one line of run-all output
+func (r *Result) record(line string) {
+	line = ansi.ReplaceAllString(line, "")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Terragrunt also reports a unit skipped for a failed dependency as having
+	// finished with an error, so that is not a failure of its own
+	if m := failed.FindStringSubmatch(line); m != nil {
+		if unit := r.unit(m[1]); unit.Status != StatusDependencyFailed {
+			unit.Status = StatusFailed
+		}
+		return
+	}
+	if m := succeeded.FindStringSubmatch(line); m != nil {
+		r.unit(m[1]).Status = StatusSucceeded
+		return
+	}
+	if m := dependencyFailed.FindStringSubmatch(line); m != nil {
+		if unit := r.unit(m[2]); unit.Status != StatusFailed {
+			unit.Status = StatusDependencyFailed
+		}
+		return
+	}
+
+	m := prefixed.FindStringSubmatch(line)
+	if m == nil {
+		return
+	}
+	if e := terraformError.FindStringSubmatch(strings.TrimSpace(m[2])); e != nil {
+		unit := r.unit(m[1])
+		unit.Errors = append(unit.Errors, e[1])
+	}
+}
+
+// unit returns the unit at path, which Terragrunt gives absolute or relative
+// to the working directory, adding it on first use
+func (r *Result) unit(path string) *Unit {
+	name := path
+	if filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(r.root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		} else {
+			name = filepath.Base(path)
+		}
+	}
+	name = filepath.ToSlash(filepath.Clean(name))
+
+	unit, ok := r.units[name]
+	if !ok {
+		unit = &Unit{Name: name, Status: StatusNotFinished}
+		r.units[name] = unit
+	}
+	return unit
+}
+
+// Unit returns the outcome of the named unit, which is StatusNotFinished when
+// Terragrunt did not report on it
+func (r *Result) Unit(name string) Unit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if unit, ok := r.units[name]; ok {
+		return *unit
+	}
+	return Unit{Name: name, Status: StatusNotFinished}
+}
+
+// Units returns the units Terragrunt reported on, ordered by name
+func (r *Result) Units() []Unit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	units := make([]Unit, 0, len(r.units))
+	for _, unit := range r.units {
+		units = append(units, *unit)
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i].Name < units[j].Name })
+	return units
+}
+
+// Failed returns the units that failed themselves, ordered by name
+func (r *Result) Failed() []Unit {
+	var units []Unit
+	for _, unit := range r.Units() {
+		if unit.Status == StatusFailed {
+			units = append(units, unit)
+		}
+	}
+	return units
+}
+
+// wrap adds the units that failed, with their first error, and those skipped
+// for a failed dependency to err
+func (r *Result) wrap(err error) error {
+	var failures, skipped []string
+	for _, unit := range r.Units() {
+		switch unit.Status {
+		case StatusFailed:
+			failure := unit.Name
+			if len(unit.Errors) > 0 {
+				failure += " (" + unit.Errors[0] + ")"
+			}
+			failures = append(failures, failure)
+		case StatusDependencyFailed:
+			skipped = append(skipped, unit.Name)
+		}
+	}
+	if len(failures) == 0 {
+		return err
+	}
+
+	message := fmt.Sprintf("run-all %s failed in %s", r.Command, strings.Join(failures, ", "))
+	if len(skipped) > 0 {
+		message += fmt.Sprintf("; skipped %s for a failed dependency", strings.Join(skipped, ", "))
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
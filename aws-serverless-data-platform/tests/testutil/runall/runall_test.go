@@ -0,0 +1,96 @@
+package runall
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failedApply is trimmed run-all apply output in which storage fails and
+// analytics, which depends on it, is skipped
+const failedApply = `time=2024-05-02T10:00:01Z level=info msg=The stack at /env/dev/ap-southeast-1 will be processed in the following order for command apply:
+[/env/dev/ap-southeast-1/01-networking] aws_vpc.main: Creation complete after 2s [id=vpc-0abc]
+[/env/dev/ap-southeast-1/01-networking] Apply complete! Resources: 12 added, 0 changed, 0 destroyed.
+time=2024-05-02T10:03:00Z level=info msg=Module /env/dev/ap-southeast-1/01-networking has finished successfully! prefix=[/env/dev/ap-southeast-1/01-networking]
+[/env/dev/ap-southeast-1/03-storage] ╷
+[/env/dev/ap-southeast-1/03-storage] │ Error: creating S3 Bucket (dl-raw-dev): BucketAlreadyExists
+[/env/dev/ap-southeast-1/03-storage] │
+[/env/dev/ap-southeast-1/03-storage] ╵
+time=2024-05-02T10:04:00Z level=error msg=Module /env/dev/ap-southeast-1/03-storage has finished with an error: exit status 1 prefix=[/env/dev/ap-southeast-1/03-storage]
+time=2024-05-02T10:04:00Z level=error msg=Dependency /env/dev/ap-southeast-1/03-storage of module /env/dev/ap-southeast-1/05-analytics just finished with an error. Module /env/dev/ap-southeast-1/05-analytics will have to return an error too.
+time=2024-05-02T10:04:00Z level=error msg=Module /env/dev/ap-southeast-1/05-analytics has finished with an error: Cannot process module Module /env/dev/ap-southeast-1/05-analytics because one of its dependencies, Module /env/dev/ap-southeast-1/03-storage, finished with an error
+`
+
+func parse(output string) *Result {
+	result := newResult("apply", "/env/dev/ap-southeast-1")
+	for _, line := range strings.Split(output, "\n") {
+		result.record(line)
+	}
+	return result
+}
+
+func TestRecord(t *testing.T) {
+	result := parse(failedApply)
+
+	assert.Equal(t, StatusSucceeded, result.Unit("01-networking").Status)
+	assert.Empty(t, result.Unit("01-networking").Errors)
+
+	storage := result.Unit("03-storage")
+	assert.Equal(t, StatusFailed, storage.Status)
+	assert.Equal(t, []string{"Error: creating S3 Bucket (dl-raw-dev): BucketAlreadyExists"}, storage.Errors)
+
+	assert.Equal(t, StatusDependencyFailed, result.Unit("05-analytics").Status,
+		"the error reported for a skipped unit does not make it a failure of its own")
+	assert.Equal(t, StatusNotFinished, result.Unit("07-monitoring").Status)
+
+	failed := result.Failed()
+	require.Len(t, failed, 1)
+	assert.Equal(t, "03-storage", failed[0].Name)
+}
+
+func TestRecordStripsColors(t *testing.T) {
+	result := parse("\x1b[32m[/env/dev/ap-southeast-1/01-networking] \x1b[31mError: \x1b[0mInvalid provider configuration\n" +
+		"time=2024-05-02T10:00:01Z level=error msg=Module /env/dev/ap-southeast-1/01-networking has finished with an error: exit status 1\n")
+
+	networking := result.Unit("01-networking")
+	assert.Equal(t, StatusFailed, networking.Status)
+	assert.Equal(t, []string{"Error: Invalid provider configuration"}, networking.Errors)
+}
+
+func TestWrap(t *testing.T) {
+	exit := errors.New("exit status 1")
+
+	err := parse(failedApply).wrap(exit)
+	assert.EqualError(t, err, "run-all apply failed in 03-storage (Error: creating S3 Bucket (dl-raw-dev): BucketAlreadyExists); "+
+		"skipped 05-analytics for a failed dependency: exit status 1")
+	assert.ErrorIs(t, err, exit)
+
+	assert.Same(t, exit, parse("").wrap(exit), "an error no unit reported is returned as is")
+}
+
+func TestArgs(t *testing.T) {
+	assert.Equal(t, []string{
+		"run-all", "apply",
+		"--terragrunt-non-interactive",
+		"--terragrunt-include-module-prefix",
+		"--terragrunt-working-dir", "environments/dev/ap-southeast-1",
+	}, Options{Dir: "environments/dev/ap-southeast-1"}.Args("apply"))
+
+	assert.Equal(t, []string{
+		"run-all", "destroy",
+		"--terragrunt-non-interactive",
+		"--terragrunt-include-module-prefix",
+		"--terragrunt-working-dir", "environments/dev/ap-southeast-1",
+		"--terragrunt-include-dir", "03-storage",
+		"--terragrunt-parallelism", "2",
+		"--terragrunt-ignore-dependency-errors",
+	}, Options{
+		Dir:                    "environments/dev/ap-southeast-1",
+		IncludeDirs:            []string{"03-storage"},
+		Parallelism:            2,
+		IgnoreDependencyErrors: true,
+	}.Args("destroy"))
+}
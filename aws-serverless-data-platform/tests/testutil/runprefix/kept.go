@@ -0,0 +1,54 @@
+// =============================================================================
+// Kept Stack Manifest
+// Records the stacks of failed tests left deployed for triage
+// =============================================================================
+
+package runprefix
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// keptExt names the kept stack manifest next to a run manifest
+const keptExt = ".kept.jsonl"
+
+// Kept is a failed test whose stacks were left deployed for triage
+type Kept struct {
+	Prefix string `json:"prefix"`
+	Suite  string `json:"suite"`
+	Test   string `json:"test"`
+
+	// Triage is the triage file describing the stacks
+	Triage string `json:"triage"`
+
+	// Cleanup is how to destroy the stacks; the sweeper removes what it can by prefix
+	Cleanup string    `json:"cleanup"`
+	Kept    time.Time `json:"kept"`
+}
+
+// KeptPath returns the kept stack manifest that belongs to the run manifest at manifestPath
+func KeptPath(manifestPath string) string {
+	return strings.TrimSuffix(manifestPath, filepath.Ext(manifestPath)) + keptExt
+}
+
+// AppendKept adds k to the kept stack manifest at path as one JSON line
+func AppendKept(path string, k Kept) error {
+	return appendLine(path, k)
+}
+
+// ReadKept returns the kept stacks in the manifest at path
+func ReadKept(path string) ([]Kept, error) {
+	var kept []Kept
+	err := readLines(path, func(line []byte) error {
+		var k Kept
+		if err := json.Unmarshal(line, &k); err != nil {
+			return err
+		}
+		kept = append(kept, k)
+		return nil
+	})
+	return kept, err
+}
@@ -0,0 +1,136 @@
+// =============================================================================
+// Run Manifest
+// Records which suites ran under which prefix, for targeted cleanup
+// =============================================================================
+
+package runprefix
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// ManifestDirEnvVar overrides the directory manifests are written to
+	ManifestDirEnvVar = "TERRATEST_RUN_MANIFEST_DIR"
+
+	// DefaultManifestDir holds the manifests, relative to the repository root
+	DefaultManifestDir = ".test-runs"
+)
+
+// Entry is one suite started under a prefix
+type Entry struct {
+	Prefix  string    `json:"prefix"`
+	Suite   string    `json:"suite"`
+	Regions []string  `json:"regions"`
+	User    string    `json:"user"`
+	Host    string    `json:"host"`
+	Started time.Time `json:"started"`
+}
+
+// NewEntry describes a suite started now by the current user on this host
+func NewEntry(prefix, suite string, regions []string) Entry {
+	entry := Entry{Prefix: prefix, Suite: suite, Regions: regions, Started: time.Now().UTC()}
+	if current, err := user.Current(); err == nil {
+		entry.User = current.Username
+	}
+	entry.Host, _ = os.Hostname()
+	return entry
+}
+
+// ManifestPath returns the manifest of prefix in ManifestDirEnvVar, else in
+// DefaultManifestDir under root; an empty root is the working directory
+func ManifestPath(root, prefix string) string {
+	dir := os.Getenv(ManifestDirEnvVar)
+	if dir == "" {
+		dir = filepath.Join(root, DefaultManifestDir)
+	}
+	return filepath.Join(dir, prefix+".jsonl")
+}
+
+// Append adds entry to the manifest at path as one JSON line; suites in
+// separate processes append to the same file
+func Append(path string, entry Entry) error {
+	return appendLine(path, entry)
+}
+
+// appendLine appends value to the JSON lines file at path
+func appendLine(path string, value interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create manifest directory: %w", err)
+	}
+
+	line, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open manifest: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return file.Close()
+}
+
+// ReadManifest returns the entries of the manifest at path
+func ReadManifest(path string) ([]Entry, error) {
+	var entries []Entry
+	err := readLines(path, func(line []byte) error {
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return err
+		}
+		if err := Validate(entry.Prefix); err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
+
+// readLines calls parse with every non-empty line of the file at path
+func readLines(path string, parse func(line []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	// A deployment line lists every resource of a module
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := parse(scanner.Bytes()); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// Prefixes returns the distinct prefixes of entries, sorted
+func Prefixes(entries []Entry) []string {
+	seen := map[string]bool{}
+	var prefixes []string
+	for _, entry := range entries {
+		if !seen[entry.Prefix] {
+			seen[entry.Prefix] = true
+			prefixes = append(prefixes, entry.Prefix)
+		}
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
@@ -0,0 +1,65 @@
+// =============================================================================
+// Run Resource Manifest
+// Records every resource a run deployed, for cleanup and cost attribution
+// =============================================================================
+
+package runprefix
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// resourcesExt names the resource manifest next to a run manifest
+const resourcesExt = ".resources.jsonl"
+
+// Deployment is one module applied during a run and the resources in its state afterwards
+type Deployment struct {
+	Prefix string `json:"prefix"`
+	Suite  string `json:"suite"`
+	Test   string `json:"test"`
+	Module string `json:"module"`
+
+	// RunID is the deployment's testutil.RunTag value, which Cost Explorer groups by
+	RunID string `json:"run_id,omitempty"`
+
+	// Failed is set when the apply failed; the resources it created still cost money
+	Failed bool `json:"failed,omitempty"`
+
+	Applied   time.Time  `json:"applied"`
+	Resources []Resource `json:"resources"`
+}
+
+// Resource is one managed resource from a module's state
+type Resource struct {
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	ID      string `json:"id,omitempty"`
+	ARN     string `json:"arn,omitempty"`
+}
+
+// ResourcesPath returns the resource manifest that belongs to the run manifest at manifestPath
+func ResourcesPath(manifestPath string) string {
+	return strings.TrimSuffix(manifestPath, filepath.Ext(manifestPath)) + resourcesExt
+}
+
+// AppendDeployment adds d to the resource manifest at path as one JSON line
+func AppendDeployment(path string, d Deployment) error {
+	return appendLine(path, d)
+}
+
+// ReadDeployments returns the deployments in the resource manifest at path
+func ReadDeployments(path string) ([]Deployment, error) {
+	var deployments []Deployment
+	err := readLines(path, func(line []byte) error {
+		var d Deployment
+		if err := json.Unmarshal(line, &d); err != nil {
+			return err
+		}
+		deployments = append(deployments, d)
+		return nil
+	})
+	return deployments, err
+}
@@ -0,0 +1,63 @@
+// =============================================================================
+// Test Run Prefix
+// Isolates concurrent test runs by prefixing every resource name they create
+// =============================================================================
+
+// Package runprefix generates the prefix that keeps resources from concurrent
+// test runs apart. Every suite started with the same EnvVar shares one prefix;
+// without it each test binary generates its own. The prefixes in use are
+// recorded in a manifest so the sweeper can clean up a single run.
+package runprefix
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+)
+
+const (
+	// EnvVar pins the prefix, so suites run in separate processes share one
+	EnvVar = "TERRATEST_RUN_PREFIX"
+
+	// Tag carries the prefix on every resource so a run can be found by tag
+	Tag = "TestRunPrefix"
+
+	// MaxLength keeps prefixed S3 bucket names within the 63 character limit
+	MaxLength = 12
+)
+
+// pattern restricts prefixes to characters valid in every resource name
+var pattern = regexp.MustCompile(`^[a-z0-9]+$`)
+
+var (
+	once   sync.Once
+	prefix string
+	err    error
+)
+
+// Get returns the run's prefix: EnvVar when set, else one generated on first
+// use and exported to EnvVar so Terraform and child processes inherit it
+func Get() (string, error) {
+	once.Do(func() {
+		prefix = os.Getenv(EnvVar)
+		if prefix == "" {
+			prefix = strings.ToLower(random.UniqueId())
+			err = os.Setenv(EnvVar, prefix)
+			return
+		}
+		err = Validate(prefix)
+	})
+	return prefix, err
+}
+
+// Validate reports whether prefix can be put in front of every resource name
+func Validate(prefix string) error {
+	if len(prefix) > MaxLength || !pattern.MatchString(prefix) {
+		return fmt.Errorf("%s %q must be 1-%d lowercase letters and digits", EnvVar, prefix, MaxLength)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package runprefix
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("k3x9qa"))
+	assert.NoError(t, Validate("jdoe1"))
+	assert.Error(t, Validate(""))
+	assert.Error(t, Validate("K3X9QA"), "Bucket names must be lowercase")
+	assert.Error(t, Validate("j-doe"), "The separator is added by the name builders")
+	assert.Error(t, Validate("averyverylongprefix"))
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	t.Setenv(ManifestDirEnvVar, t.TempDir())
+	path := ManifestPath("ignored", "k3x9qa")
+	assert.Equal(t, "k3x9qa.jsonl", filepath.Base(path))
+
+	require.NoError(t, Append(path, NewEntry("k3x9qa", "storage", []string{"us-east-1"})))
+	require.NoError(t, Append(path, NewEntry("k3x9qa", "security", []string{"us-east-1"})))
+	require.NoError(t, Append(path, NewEntry("b7m2zp", "networking", []string{"eu-west-1"})))
+
+	entries, err := ReadManifest(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "security", entries[1].Suite)
+	assert.Equal(t, []string{"eu-west-1"}, entries[2].Regions)
+	assert.False(t, entries[0].Started.IsZero())
+	assert.Equal(t, []string{"b7m2zp", "k3x9qa"}, Prefixes(entries))
+}
+
+func TestManifestPathDefaultsUnderRoot(t *testing.T) {
+	t.Setenv(ManifestDirEnvVar, "")
+	assert.Equal(t, filepath.Join("/repo", DefaultManifestDir, "k3x9qa.jsonl"), ManifestPath("/repo", "k3x9qa"))
+}
+
+func TestReadManifestRejectsBadPrefix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"prefix":"Not-Valid","suite":"storage"}`+"\n"), 0o644))
+
+	_, err := ReadManifest(path)
+	assert.ErrorContains(t, err, "bad.jsonl:1")
+}
+
+func TestDeploymentsRoundTrip(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "k3x9qa.jsonl")
+	path := ResourcesPath(manifest)
+	assert.Equal(t, "k3x9qa.resources.jsonl", filepath.Base(path))
+
+	deployment := Deployment{
+		Prefix:    "k3x9qa",
+		Suite:     "storage",
+		Test:      "TestStorage/us-east-1",
+		Module:    "modules/storage",
+		RunID:     "use1-abc123",
+		Resources: []Resource{{Address: "aws_s3_bucket.raw", Type: "aws_s3_bucket", ARN: "arn:aws:s3:::raw"}},
+	}
+	require.NoError(t, AppendDeployment(path, deployment))
+	require.NoError(t, AppendDeployment(path, Deployment{Prefix: "k3x9qa", Suite: "storage", Failed: true}))
+
+	deployments, err := ReadDeployments(path)
+	require.NoError(t, err)
+	require.Len(t, deployments, 2)
+	assert.Equal(t, deployment, deployments[0])
+	assert.True(t, deployments[1].Failed)
+}
+
+func TestKeptRoundTrip(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "k3x9qa.jsonl")
+	path := KeptPath(manifest)
+	assert.Equal(t, "k3x9qa.kept.jsonl", filepath.Base(path))
+
+	kept := Kept{
+		Prefix:  "k3x9qa",
+		Suite:   "storage",
+		Test:    "TestStorage/us-east-1",
+		Triage:  "/repo/modules/storage/tests/.test-data/TestStorage_us-east-1/triage.json",
+		Cleanup: "SKIP_setup=true SKIP_validate=true go test -run 'TestStorage/us-east-1' .",
+	}
+	require.NoError(t, AppendKept(path, kept))
+
+	read, err := ReadKept(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Kept{kept}, read)
+}
@@ -0,0 +1,257 @@
+// =============================================================================
+// S3 Security Assertions
+// Negative access tests and policy checks for data-lake buckets
+// =============================================================================
+
+package s3sec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/iampolicy"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+)
+
+// CrossAccountRoleEnvVar names a role in another account used for the cross-account probes
+const CrossAccountRoleEnvVar = "TERRATEST_CROSS_ACCOUNT_ROLE_ARN"
+
+// probePrefix holds every object key the probes try to read or write
+const probePrefix = "s3sec-probe/"
+
+// deniedCodes are the error codes S3 returns when a request is refused by policy
+var deniedCodes = map[string]bool{
+	"AccessDenied":       true,
+	"AllAccessDisabled":  true,
+	"InvalidAccessKeyId": true,
+}
+
+// Options describes what a bucket's policy must enforce
+type Options struct {
+	// SSEAlgorithm is the encryption uploads must request when they set one: "AES256" or "aws:kms"
+	SSEAlgorithm string
+
+	// KMSKeyARN is the key uploads must request when SSEAlgorithm is "aws:kms"
+	KMSKeyARN string
+
+	// CrossAccountRoleARN is assumed for the cross-account probes, which are skipped when it is empty
+	CrossAccountRoleARN string
+}
+
+// AssertBucketSecure runs the public access, policy and negative access checks against bucket
+func AssertBucketSecure(t *testing.T, clients *awsclients.Clients, bucket string, opts Options) {
+	t.Run("PublicAccessBlock", func(t *testing.T) {
+		assertPublicAccessBlocked(t, clients, bucket)
+	})
+
+	t.Run("BucketPolicy", func(t *testing.T) {
+		assertPolicyEnforced(t, clients, bucket, opts)
+	})
+
+	t.Run("AnonymousAccessDenied", func(t *testing.T) {
+		anonymous := s3.NewFromConfig(clients.Config, func(o *s3.Options) {
+			o.Credentials = aws.AnonymousCredentials{}
+		})
+		assertReadWriteDenied(t, clients, anonymous, bucket, "anonymous")
+	})
+
+	t.Run("CrossAccountAccessDenied", func(t *testing.T) {
+		if opts.CrossAccountRoleARN == "" {
+			t.Skipf("Set %s to run cross-account probes", CrossAccountRoleEnvVar)
+		}
+		other := awsclients.New(t,
+			awsclients.WithRegion(clients.Config.Region),
+			awsclients.WithAssumeRole(opts.CrossAccountRoleARN),
+			awsclients.WithSessionName("terratest-s3sec"),
+		)
+		assertReadWriteDenied(t, clients, other.S3(), bucket, "cross-account")
+	})
+
+	t.Run("InsecureTransportDenied", func(t *testing.T) {
+		plaintext := s3.NewFromConfig(clients.Config, func(o *s3.Options) {
+			o.EndpointOptions.DisableHTTPS = true
+		})
+		assertReadWriteDenied(t, clients, plaintext, bucket, "plain HTTP")
+	})
+
+	t.Run("IncorrectEncryptionDenied", func(t *testing.T) {
+		assertIncorrectEncryptionDenied(t, clients, bucket, opts)
+	})
+}
+
+// assertPublicAccessBlocked checks all four public access block settings are on
+func assertPublicAccessBlocked(t *testing.T, clients *awsclients.Clients, bucket string) {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	output, err := clients.S3().GetPublicAccessBlock(ctx, &s3.GetPublicAccessBlockInput{Bucket: aws.String(bucket)})
+	require.NoError(t, err, "Failed to get public access block for %s", bucket)
+
+	config := output.PublicAccessBlockConfiguration
+	assert.True(t, aws.ToBool(config.BlockPublicAcls), "BlockPublicAcls should be enabled on %s", bucket)
+	assert.True(t, aws.ToBool(config.BlockPublicPolicy), "BlockPublicPolicy should be enabled on %s", bucket)
+	assert.True(t, aws.ToBool(config.IgnorePublicAcls), "IgnorePublicAcls should be enabled on %s", bucket)
+	assert.True(t, aws.ToBool(config.RestrictPublicBuckets), "RestrictPublicBuckets should be enabled on %s", bucket)
+}
+
+// assertPolicyEnforced fetches the bucket policy and checks its deny statements
+func assertPolicyEnforced(t *testing.T, clients *awsclients.Clients, bucket string, opts Options) {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	output, err := clients.S3().GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{Bucket: aws.String(bucket)})
+	require.NoError(t, err, "Failed to get bucket policy for %s", bucket)
+
+	doc, err := iampolicy.Parse(aws.ToString(output.Policy))
+	require.NoError(t, err)
+
+	for _, problem := range CheckPolicy(doc, "arn:aws:s3:::"+bucket, opts) {
+		t.Errorf("Bucket policy on %s: %s", bucket, problem)
+	}
+}
+
+// CheckPolicy returns what doc fails to enforce for the bucket with bucketARN
+func CheckPolicy(doc *iampolicy.Document, bucketARN string, opts Options) []string {
+	var problems []string
+
+	if !hasDeny(doc, "s3:GetObject", bucketARN+"/*", "Bool", "aws:SecureTransport", "false") {
+		problems = append(problems, "does not deny requests without aws:SecureTransport")
+	}
+	if opts.SSEAlgorithm != "" &&
+		!hasDeny(doc, "s3:PutObject", bucketARN+"/*", "StringNotEquals", "s3:x-amz-server-side-encryption", opts.SSEAlgorithm) {
+		problems = append(problems, fmt.Sprintf("does not deny uploads requesting encryption other than %s", opts.SSEAlgorithm))
+	}
+	if opts.SSEAlgorithm == string(types.ServerSideEncryptionAwsKms) && opts.KMSKeyARN != "" &&
+		!hasDeny(doc, "s3:PutObject", bucketARN+"/*", "StringNotEquals", "s3:x-amz-server-side-encryption-aws-kms-key-id", opts.KMSKeyARN) {
+		problems = append(problems, fmt.Sprintf("does not deny uploads requesting a KMS key other than %s", opts.KMSKeyARN))
+	}
+	return problems
+}
+
+// hasDeny reports whether a Deny statement covering action on resource has the given condition
+func hasDeny(doc *iampolicy.Document, action, resource, operator, key, value string) bool {
+	for _, statement := range doc.Statement {
+		if statement.Effect != "Deny" || !matchesAny(statement.Action, action) || !matchesAny(statement.Resource, resource) {
+			continue
+		}
+		if statement.Condition[operator][key].Contains(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny reports whether any pattern matches value, treating a trailing * as a prefix wildcard
+func matchesAny(patterns iampolicy.StringList, value string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == value {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// assertReadWriteDenied checks client can neither read nor write objects in bucket
+func assertReadWriteDenied(t *testing.T, clients *awsclients.Clients, client *s3.Client, bucket, caller string) {
+	key := probeKey()
+
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	_, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	assertDenied(t, err, "%s GetObject on %s", caller, bucket)
+
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   strings.NewReader("probe"),
+	})
+	if !assertDenied(t, err, "%s PutObject on %s", caller, bucket) {
+		removeProbe(t, clients, bucket, key)
+	}
+}
+
+// assertIncorrectEncryptionDenied uploads with the wrong algorithm and, for KMS buckets, the wrong key
+func assertIncorrectEncryptionDenied(t *testing.T, clients *awsclients.Clients, bucket string, opts Options) {
+	if opts.SSEAlgorithm == "" {
+		t.Skip("No encryption requirement configured")
+	}
+
+	wrong := []*s3.PutObjectInput{}
+	if opts.SSEAlgorithm == string(types.ServerSideEncryptionAwsKms) {
+		wrong = append(wrong, &s3.PutObjectInput{ServerSideEncryption: types.ServerSideEncryptionAes256})
+		if opts.KMSKeyARN != "" {
+			// A syntactically valid key that is not the bucket's
+			arn := opts.KMSKeyARN[:strings.LastIndex(opts.KMSKeyARN, "/")+1] + "00000000-0000-0000-0000-000000000000"
+			wrong = append(wrong, &s3.PutObjectInput{
+				ServerSideEncryption: types.ServerSideEncryptionAwsKms,
+				SSEKMSKeyId:          aws.String(arn),
+			})
+		}
+	} else {
+		wrong = append(wrong, &s3.PutObjectInput{ServerSideEncryption: types.ServerSideEncryptionAwsKms})
+	}
+
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	for _, input := range wrong {
+		key := probeKey()
+		input.Bucket = aws.String(bucket)
+		input.Key = aws.String(key)
+		input.Body = strings.NewReader("probe")
+
+		_, err := clients.S3().PutObject(ctx, input)
+		if !assertDenied(t, err, "PutObject with %s encryption on %s", input.ServerSideEncryption, bucket) {
+			removeProbe(t, clients, bucket, key)
+		}
+	}
+}
+
+// assertDenied checks err is an access-denied error from S3
+func assertDenied(t *testing.T, err error, format string, args ...interface{}) bool {
+	t.Helper()
+	description := fmt.Sprintf(format, args...)
+
+	if !assert.Error(t, err, "%s should be denied", description) {
+		return false
+	}
+
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return assert.Fail(t, "Unexpected error", "%s failed without an S3 error: %v", description, err)
+	}
+	if !assert.True(t, deniedCodes[apiErr.ErrorCode()], "%s failed with %s, want AccessDenied", description, apiErr.ErrorCode()) {
+		return false
+	}
+
+	logging.New(t).Success("Request denied", "request", description, "code", apiErr.ErrorCode())
+	return true
+}
+
+// removeProbe deletes an object a probe managed to write
+func removeProbe(t *testing.T, clients *awsclients.Clients, bucket, key string) {
+	_, err := clients.S3().DeleteObject(context.Background(), &s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		logging.New(t).Warn("Failed to remove probe object", "object", "s3://"+bucket+"/"+key, "error", err)
+	}
+}
+
+// probeKey returns a fresh object key under probePrefix
+func probeKey() string {
+	return probePrefix + strings.ToLower(random.UniqueId())
+}
@@ -0,0 +1,64 @@
+package s3sec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/iampolicy"
+)
+
+const (
+	bucketARN = "arn:aws:s3:::data-lake-raw"
+	keyARN    = "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+)
+
+// enforcedPolicy mirrors the bucket policy the storage module attaches to each data-lake bucket
+const enforcedPolicy = `{"Version":"2012-10-17","Statement":[
+	{"Sid":"DenyInsecureTransport","Effect":"Deny","Principal":"*","Action":"s3:*",
+	 "Resource":["arn:aws:s3:::data-lake-raw","arn:aws:s3:::data-lake-raw/*"],
+	 "Condition":{"Bool":{"aws:SecureTransport":"false"}}},
+	{"Sid":"DenyIncorrectEncryptionHeader","Effect":"Deny","Principal":"*","Action":"s3:PutObject",
+	 "Resource":"arn:aws:s3:::data-lake-raw/*",
+	 "Condition":{"StringNotEquals":{"s3:x-amz-server-side-encryption":"aws:kms"},"Null":{"s3:x-amz-server-side-encryption":"false"}}},
+	{"Sid":"DenyIncorrectKMSKey","Effect":"Deny","Principal":"*","Action":"s3:PutObject",
+	 "Resource":"arn:aws:s3:::data-lake-raw/*",
+	 "Condition":{"StringNotEquals":{"s3:x-amz-server-side-encryption-aws-kms-key-id":"arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"}}}
+]}`
+
+func TestCheckPolicy(t *testing.T) {
+	doc, err := iampolicy.Parse(enforcedPolicy)
+	require.NoError(t, err)
+
+	assert.Empty(t, CheckPolicy(doc, bucketARN, Options{SSEAlgorithm: "aws:kms", KMSKeyARN: keyARN}))
+
+	// The policy pins aws:kms, so an AES256 requirement is not met
+	problems := CheckPolicy(doc, bucketARN, Options{SSEAlgorithm: "AES256"})
+	require.Len(t, problems, 1)
+	assert.Contains(t, problems[0], "AES256")
+
+	// Statements scoped to another bucket do not count
+	problems = CheckPolicy(doc, "arn:aws:s3:::data-lake-curated", Options{SSEAlgorithm: "aws:kms", KMSKeyARN: keyARN})
+	assert.Len(t, problems, 3)
+}
+
+func TestCheckPolicyMissingDenies(t *testing.T) {
+	doc, err := iampolicy.Parse(`{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::data-lake-raw/*",
+		 "Condition":{"Bool":{"aws:SecureTransport":"false"}}}]}`)
+	require.NoError(t, err)
+
+	problems := CheckPolicy(doc, bucketARN, Options{SSEAlgorithm: "aws:kms", KMSKeyARN: keyARN})
+	assert.Len(t, problems, 3)
+
+	// Without an encryption requirement only the transport check applies
+	assert.Len(t, CheckPolicy(doc, bucketARN, Options{}), 1)
+}
+
+func TestMatchesAny(t *testing.T) {
+	assert.True(t, matchesAny(iampolicy.StringList{"s3:*"}, "s3:PutObject"))
+	assert.True(t, matchesAny(iampolicy.StringList{"*"}, "s3:GetObject"))
+	assert.True(t, matchesAny(iampolicy.StringList{bucketARN + "/*"}, bucketARN+"/raw/file.csv"))
+	assert.False(t, matchesAny(iampolicy.StringList{bucketARN}, bucketARN+"/file.csv"))
+	assert.False(t, matchesAny(iampolicy.StringList{"s3:Get*"}, "s3:PutObject"))
+}
@@ -0,0 +1,310 @@
+// =============================================================================
+// Security Group Rule Matrix
+// Checks a VPC's security groups against the rules each is expected to have
+// =============================================================================
+
+package secgroups
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+)
+
+// Self is the Source of a rule that references its own group
+const Self = "self"
+
+// Rule is one source or destination of a security group permission
+type Rule struct {
+	Egress   bool
+	Protocol string
+	Ports    string
+	// Source is a CIDR, prefix list ID, security group ID or Self
+	Source string
+}
+
+// String renders the rule, e.g. "ingress tcp 443 from 10.0.0.0/16"
+func (r Rule) String() string {
+	direction, preposition := "ingress", "from"
+	if r.Egress {
+		direction, preposition = "egress", "to"
+	}
+	protocol := r.Protocol
+	if protocol == "-1" {
+		protocol = "all"
+	}
+	if r.Ports != "" {
+		protocol += " " + r.Ports
+	}
+	return fmt.Sprintf("%s %s %s %s", direction, protocol, preposition, r.Source)
+}
+
+// Group is a security group with its permissions flattened into rules
+type Group struct {
+	ID      string
+	Name    string
+	Ingress []Rule
+	Egress  []Rule
+}
+
+// Expectation declares the rules of one security group
+type Expectation struct {
+	// GroupID selects the group; when empty the group is selected by Name
+	GroupID string
+	Name    string
+	// Ingress lists the exact inbound rules, unless IngressFrom is set
+	Ingress []Rule
+	// IngressFrom, when set, admits any inbound rule whose source is the
+	// group itself or lies within one of these CIDRs
+	IngressFrom []string
+	// Egress lists the exact outbound rules
+	Egress []Rule
+}
+
+// label names the expected group in failures
+func (e Expectation) label() string {
+	if e.Name != "" && e.GroupID != "" {
+		return e.Name + " (" + e.GroupID + ")"
+	}
+	return e.Name + e.GroupID
+}
+
+// openCIDRs are sources no group may admit inbound traffic from
+var openCIDRs = []string{"0.0.0.0/0", "::/0"}
+
+// AssertMatrix checks every security group in the VPC against expectations:
+// no group admits inbound traffic from anywhere, each expected group has its
+// declared rules, and there are no groups beyond the expected ones
+func AssertMatrix(t *testing.T, clients *awsclients.Clients, vpcID string, expectations ...Expectation) {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	groups, err := Describe(ctx, clients.EC2(), []ec2types.Filter{{Name: awssdk.String("vpc-id"), Values: []string{vpcID}}})
+	require.NoError(t, err, "Failed to describe security groups of %s", vpcID)
+
+	problems := Check(groups, expectations, true)
+	for _, problem := range problems {
+		t.Error(problem)
+	}
+	if len(problems) == 0 {
+		logging.New(t).Success("Security groups match the expected matrix", "vpc", vpcID, "groups", len(groups))
+	}
+}
+
+// AssertGroup checks one security group against expected, for groups in a VPC
+// shared with groups the test does not own
+func AssertGroup(t *testing.T, clients *awsclients.Clients, expected Expectation) {
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	groups, err := Describe(ctx, clients.EC2(), []ec2types.Filter{{Name: awssdk.String("group-id"), Values: []string{expected.GroupID}}})
+	require.NoError(t, err, "Failed to describe security group %s", expected.GroupID)
+
+	problems := Check(groups, []Expectation{expected}, false)
+	for _, problem := range problems {
+		t.Error(problem)
+	}
+	if len(problems) == 0 {
+		logging.New(t).Success("Security group matches its expected rules", "group", expected.label())
+	}
+}
+
+// Describe returns the security groups matching filters
+func Describe(ctx context.Context, client *ec2.Client, filters []ec2types.Filter) ([]Group, error) {
+	var groups []Group
+	paginator := ec2.NewDescribeSecurityGroupsPaginator(client, &ec2.DescribeSecurityGroupsInput{Filters: filters})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range page.SecurityGroups {
+			id := awssdk.ToString(group.GroupId)
+			groups = append(groups, Group{
+				ID:      id,
+				Name:    awssdk.ToString(group.GroupName),
+				Ingress: rules(id, false, group.IpPermissions),
+				Egress:  rules(id, true, group.IpPermissionsEgress),
+			})
+		}
+	}
+	return groups, nil
+}
+
+// SubnetCIDRs returns the IPv4 CIDRs of subnetIDs, e.g. to restrict a group's
+// ingress to a subnet tier
+func SubnetCIDRs(ctx context.Context, client *ec2.Client, subnetIDs []string) ([]string, error) {
+	output, err := client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{SubnetIds: subnetIDs})
+	if err != nil {
+		return nil, err
+	}
+	var cidrs []string
+	for _, subnet := range output.Subnets {
+		cidrs = append(cidrs, awssdk.ToString(subnet.CidrBlock))
+	}
+	return cidrs, nil
+}
+
+// rules flattens permissions into one rule per source
+func rules(groupID string, egress bool, permissions []ec2types.IpPermission) []Rule {
+	var result []Rule
+	for _, permission := range permissions {
+		rule := Rule{Egress: egress, Protocol: awssdk.ToString(permission.IpProtocol)}
+		if permission.FromPort != nil && rule.Protocol != "-1" {
+			rule.Ports = ports(awssdk.ToInt32(permission.FromPort), awssdk.ToInt32(permission.ToPort))
+		}
+
+		var sources []string
+		for _, ipRange := range permission.IpRanges {
+			sources = append(sources, awssdk.ToString(ipRange.CidrIp))
+		}
+		for _, ipRange := range permission.Ipv6Ranges {
+			sources = append(sources, awssdk.ToString(ipRange.CidrIpv6))
+		}
+		for _, prefixList := range permission.PrefixListIds {
+			sources = append(sources, awssdk.ToString(prefixList.PrefixListId))
+		}
+		for _, pair := range permission.UserIdGroupPairs {
+			source := awssdk.ToString(pair.GroupId)
+			if source == groupID {
+				source = Self
+			}
+			sources = append(sources, source)
+		}
+
+		for _, source := range sources {
+			rule.Source = source
+			result = append(result, rule)
+		}
+	}
+	return result
+}
+
+// ports renders a port range, collapsing a single port
+func ports(from, to int32) string {
+	if from == to {
+		return fmt.Sprint(from)
+	}
+	return fmt.Sprintf("%d-%d", from, to)
+}
+
+// Check lists how groups differ from expectations; with exhaustive set, groups
+// no expectation selects and expectations no group matches are problems too
+func Check(groups []Group, expectations []Expectation, exhaustive bool) []string {
+	var problems []string
+	matched := make([]bool, len(expectations))
+
+	for _, group := range groups {
+		for _, rule := range group.Ingress {
+			for _, open := range openCIDRs {
+				if rule.Source == open {
+					problems = append(problems, fmt.Sprintf("%s (%s) admits %s", group.Name, group.ID, rule))
+				}
+			}
+		}
+
+		index := match(group, expectations)
+		if index < 0 {
+			if exhaustive {
+				problems = append(problems, fmt.Sprintf("unexpected security group %s (%s)", group.Name, group.ID))
+			}
+			continue
+		}
+		matched[index] = true
+		expected := expectations[index]
+
+		if len(expected.IngressFrom) > 0 {
+			for _, rule := range group.Ingress {
+				if rule.Source != Self && !within(rule.Source, expected.IngressFrom) {
+					problems = append(problems, fmt.Sprintf("%s %s, expected only sources within %s",
+						expected.label(), rule, strings.Join(expected.IngressFrom, ",")))
+				}
+			}
+		} else {
+			problems = append(problems, diff(expected.label(), group.Ingress, expected.Ingress)...)
+		}
+		problems = append(problems, diff(expected.label(), group.Egress, expected.Egress)...)
+	}
+
+	for i, expected := range expectations {
+		if !matched[i] && (exhaustive || expected.GroupID != "") {
+			problems = append(problems, fmt.Sprintf("missing security group %s", expected.label()))
+		}
+	}
+	return problems
+}
+
+// match returns the index of the expectation selecting group, or -1
+func match(group Group, expectations []Expectation) int {
+	for i, expected := range expectations {
+		if expected.GroupID != "" && expected.GroupID == group.ID {
+			return i
+		}
+		if expected.GroupID == "" && expected.Name == group.Name {
+			return i
+		}
+	}
+	return -1
+}
+
+// diff lists the rules in only one of actual and expected
+func diff(label string, actual, expected []Rule) []string {
+	counts := map[string]int{}
+	for _, rule := range actual {
+		counts[rule.String()]++
+	}
+	for _, rule := range expected {
+		counts[rule.String()]--
+	}
+
+	var problems []string
+	for _, rule := range sortedKeys(counts) {
+		switch {
+		case counts[rule] > 0:
+			problems = append(problems, fmt.Sprintf("%s has unexpected rule %s", label, rule))
+		case counts[rule] < 0:
+			problems = append(problems, fmt.Sprintf("%s is missing rule %s", label, rule))
+		}
+	}
+	return problems
+}
+
+// within reports whether source is a CIDR inside one of cidrs
+func within(source string, cidrs []string) bool {
+	_, sourceNet, err := net.ParseCIDR(source)
+	if err != nil {
+		return false
+	}
+	sourceOnes, _ := sourceNet.Mask.Size()
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		ones, _ := network.Mask.Size()
+		if ones <= sourceOnes && network.Contains(sourceNet.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
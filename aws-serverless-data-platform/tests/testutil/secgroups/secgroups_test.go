@@ -0,0 +1,81 @@
+package secgroups
+
+import (
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules(t *testing.T) {
+	permissions := []ec2types.IpPermission{
+		{
+			IpProtocol: awssdk.String("tcp"),
+			FromPort:   awssdk.Int32(443),
+			ToPort:     awssdk.Int32(443),
+			IpRanges:   []ec2types.IpRange{{CidrIp: awssdk.String("10.0.0.0/16")}, {CidrIp: awssdk.String("10.1.0.0/16")}},
+		},
+		{
+			IpProtocol:       awssdk.String("-1"),
+			UserIdGroupPairs: []ec2types.UserIdGroupPair{{GroupId: awssdk.String("sg-1")}, {GroupId: awssdk.String("sg-2")}},
+		},
+		{
+			IpProtocol:    awssdk.String("tcp"),
+			FromPort:      awssdk.Int32(1024),
+			ToPort:        awssdk.Int32(65535),
+			PrefixListIds: []ec2types.PrefixListId{{PrefixListId: awssdk.String("pl-63a5400a")}},
+		},
+	}
+
+	var rendered []string
+	for _, rule := range rules("sg-1", false, permissions) {
+		rendered = append(rendered, rule.String())
+	}
+	assert.Equal(t, []string{
+		"ingress tcp 443 from 10.0.0.0/16",
+		"ingress tcp 443 from 10.1.0.0/16",
+		"ingress all from self",
+		"ingress all from sg-2",
+		"ingress tcp 1024-65535 from pl-63a5400a",
+	}, rendered)
+}
+
+func TestCheck(t *testing.T) {
+	https := Rule{Protocol: "tcp", Ports: "443", Source: "10.0.0.0/16"}
+	egressAll := Rule{Egress: true, Protocol: "-1", Source: "0.0.0.0/0"}
+
+	groups := []Group{
+		{ID: "sg-default", Name: "default", Ingress: []Rule{{Protocol: "-1", Source: Self}}, Egress: []Rule{egressAll}},
+		{ID: "sg-vpce", Name: "vpce-dev", Ingress: []Rule{https, {Protocol: "tcp", Ports: "22", Source: "0.0.0.0/0"}}},
+		{ID: "sg-glue", Name: "glue", Ingress: []Rule{{Protocol: "-1", Source: Self}, {Protocol: "tcp", Ports: "5432", Source: "10.0.201.0/24"}}},
+		{ID: "sg-stray", Name: "launch-wizard-1"},
+	}
+	expectations := []Expectation{
+		{Name: "default", Ingress: []Rule{{Protocol: "-1", Source: Self}}, Egress: []Rule{egressAll}},
+		{GroupID: "sg-vpce", Name: "endpoints", Ingress: []Rule{https}, Egress: []Rule{egressAll}},
+		{GroupID: "sg-glue", IngressFrom: []string{"10.0.11.0/24", "10.0.12.0/24"}},
+		{GroupID: "sg-gone"},
+	}
+
+	assert.Equal(t, []string{
+		"vpce-dev (sg-vpce) admits ingress tcp 22 from 0.0.0.0/0",
+		"endpoints (sg-vpce) has unexpected rule ingress tcp 22 from 0.0.0.0/0",
+		"endpoints (sg-vpce) is missing rule egress all to 0.0.0.0/0",
+		"sg-glue ingress tcp 5432 from 10.0.201.0/24, expected only sources within 10.0.11.0/24,10.0.12.0/24",
+		"unexpected security group launch-wizard-1 (sg-stray)",
+		"missing security group sg-gone",
+	}, Check(groups, expectations, true))
+
+	// A single group in a shared VPC ignores the groups the test does not own
+	assert.Empty(t, Check(groups[:1], expectations[:1], false))
+}
+
+func TestWithin(t *testing.T) {
+	cidrs := []string{"10.0.0.0/16"}
+	assert.True(t, within("10.0.11.0/24", cidrs))
+	assert.True(t, within("10.0.0.0/16", cidrs))
+	assert.False(t, within("10.0.0.0/8", cidrs), "a wider source must not pass as within")
+	assert.False(t, within("10.1.0.0/24", cidrs))
+	assert.False(t, within("pl-63a5400a", cidrs))
+}
@@ -0,0 +1,102 @@
+// =============================================================================
+// Test Stages
+// Setup/validate/teardown stages that can be skipped between runs
+// =============================================================================
+
+package testutil
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/report"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/residue"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/transient"
+)
+
+// Stage names; set SKIP_<stage>=true to skip one, e.g. SKIP_teardown=true keeps
+// the stack deployed so the validate stage can be re-run against it
+const (
+	StageSetup    = "setup"
+	StageValidate = "validate"
+	StageTeardown = "teardown"
+)
+
+// stageDataRoot holds the persisted stage data, relative to the test package
+const stageDataRoot = ".test-data"
+
+// StageDir returns the folder persisting stage data for the running test and
+// optional stack name, so tests sharing a module directory stay independent
+func StageDir(t *testing.T, stack ...string) string {
+	testName := strings.ReplaceAll(t.Name(), "/", "_")
+	return filepath.Join(append([]string{stageDataRoot, testName}, stack...)...)
+}
+
+// Deploy runs the setup stage, which saves the options from newOptions to
+// stageDir and applies them. It returns the saved options, so they are also
+// available when setup is skipped
+func Deploy(t *testing.T, stageDir string, newOptions func() *terraform.Options) *terraform.Options {
+	report.Track(t)
+
+	test_structure.RunTestStage(t, StageSetup, func() {
+		defer logging.New(t).Phase(StageSetup)()
+
+		terraformOptions := newOptions()
+		test_structure.SaveTerraformOptions(t, stageDir, terraformOptions)
+		report.Apply(t, terraformOptions, func() {
+			_, err := transient.Do(context.Background(), t, "init", func() (string, error) {
+				return terraform.InitE(t, terraformOptions)
+			})
+			require.NoError(t, err)
+			_, err = transient.Do(context.Background(), t, "apply", func() (string, error) {
+				return terraform.ApplyE(t, terraformOptions)
+			})
+			require.NoError(t, err)
+		})
+	})
+
+	return test_structure.LoadTerraformOptions(t, stageDir)
+}
+
+// Validate runs the validate stage, recording its duration for the phase summary
+func Validate(t *testing.T, validate func()) {
+	test_structure.RunTestStage(t, StageValidate, func() {
+		defer logging.New(t).Phase(StageValidate)()
+		validate()
+	})
+}
+
+// Teardown runs the teardown stage, which destroys the stack saved in stageDir
+// and removes its stage data, unless KeepOnFailure keeps them
+func Teardown(t *testing.T, stageDir string) {
+	test_structure.RunTestStage(t, StageTeardown, func() {
+		defer logging.New(t).Phase(StageTeardown)()
+
+		terraformOptions := test_structure.LoadTerraformOptions(t, stageDir)
+		if KeepOnFailure(t, stageDir, rerunTeardown(t), terraformOptions) {
+			return
+		}
+		recorded := residue.Record(t, terraformOptions)
+		_, err := transient.Do(context.Background(), t, "destroy", func() (string, error) {
+			return terraform.DestroyE(t, terraformOptions)
+		})
+		require.NoError(t, err)
+		residue.Assert(t, awsclients.New(t, awsclients.WithRegion(stackRegion(terraformOptions))), recorded)
+		test_structure.CleanupTestDataFolder(t, stageDir)
+	})
+}
+
+// stackRegion returns the region the stack in options deploys to
+func stackRegion(options *terraform.Options) string {
+	if region := options.EnvVars["AWS_DEFAULT_REGION"]; region != "" {
+		return region
+	}
+	return DefaultRegion
+}
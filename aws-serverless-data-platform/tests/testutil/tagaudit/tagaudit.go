@@ -0,0 +1,249 @@
+// =============================================================================
+// Tag Compliance
+// Enumerates a test run's resources and checks their mandatory tags
+// =============================================================================
+
+package tagaudit
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	taggingtypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/wait"
+)
+
+// MandatoryTags must be present on every resource the platform creates
+var MandatoryTags = []string{"Environment", "Project", "Owner", "CostCenter", "DataClassification"}
+
+// DataClassifications are the allowed DataClassification values
+var DataClassifications = []string{"public", "internal", "confidential", "restricted"}
+
+// maxValueLength is the longest tag value AWS accepts
+const maxValueLength = 256
+
+// indexTimeout gives the tagging index time to see new resources
+const indexTimeout = 5 * time.Minute
+
+// Policy describes what a compliant tag set looks like
+type Policy struct {
+	// Required keys must be present with a non-empty value
+	Required []string
+
+	// Allowed restricts a key to a fixed set of values
+	Allowed map[string][]string
+
+	// Patterns restricts a key to values matching a regular expression
+	Patterns map[string]*regexp.Regexp
+}
+
+// DefaultPolicy requires the mandatory tags and a known data classification
+func DefaultPolicy() Policy {
+	return Policy{
+		Required: MandatoryTags,
+		Allowed:  map[string][]string{"DataClassification": DataClassifications},
+		Patterns: map[string]*regexp.Regexp{"CostCenter": regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)},
+	}
+}
+
+// WithValue returns a copy of the policy that only accepts value for key,
+// e.g. the environment the test deployed
+func (p Policy) WithValue(key, value string) Policy {
+	allowed := map[string][]string{}
+	for k, v := range p.Allowed {
+		allowed[k] = v
+	}
+	allowed[key] = []string{value}
+	p.Allowed = allowed
+	return p
+}
+
+// Options selects the resources to audit
+type Options struct {
+	// Filter selects the run's resources by tag, e.g. the run ID tag
+	Filter map[string]string
+
+	// Expected ARNs must be among the enumerated resources; a missing one is
+	// reported because the tagging index cannot find resources without the filter tags
+	Expected []string
+
+	Policy Policy
+}
+
+// Violation is one resource that does not satisfy the policy
+type Violation struct {
+	ARN      string
+	Problems []string
+}
+
+// String renders the violation for a failure message
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.ARN, strings.Join(v.Problems, "; "))
+}
+
+// Check returns what is wrong with tags under policy
+func Check(tags map[string]string, policy Policy) []string {
+	var problems []string
+
+	for _, key := range policy.Required {
+		if value, ok := tags[key]; !ok || value == "" {
+			problems = append(problems, fmt.Sprintf("missing tag %s", key))
+		}
+	}
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := tags[key]
+		if value != strings.TrimSpace(value) {
+			problems = append(problems, fmt.Sprintf("tag %s has surrounding whitespace", key))
+		}
+		if len(value) > maxValueLength {
+			problems = append(problems, fmt.Sprintf("tag %s is longer than %d characters", key, maxValueLength))
+		}
+		if allowed, ok := policy.Allowed[key]; ok && value != "" && !contains(allowed, value) {
+			problems = append(problems, fmt.Sprintf("tag %s=%q is not one of %s", key, value, strings.Join(allowed, ", ")))
+		}
+		if pattern, ok := policy.Patterns[key]; ok && value != "" && !pattern.MatchString(value) {
+			problems = append(problems, fmt.Sprintf("tag %s=%q does not match %s", key, value, pattern))
+		}
+	}
+	return problems
+}
+
+// Audit checks every resource against the policy and lists expected ARNs that were not found
+func Audit(resources map[string]map[string]string, opts Options) []Violation {
+	var violations []Violation
+
+	arns := make([]string, 0, len(resources))
+	for arn := range resources {
+		arns = append(arns, arn)
+	}
+	sort.Strings(arns)
+
+	for _, arn := range arns {
+		if problems := Check(resources[arn], opts.Policy); len(problems) > 0 {
+			violations = append(violations, Violation{ARN: arn, Problems: problems})
+		}
+	}
+	for _, arn := range opts.Expected {
+		if _, ok := resources[arn]; !ok {
+			violations = append(violations, Violation{ARN: arn, Problems: []string{"not found by the run's tag filter"}})
+		}
+	}
+	return violations
+}
+
+// Resources returns the tags of every resource in the clients' region matching all filter tags
+func Resources(t *testing.T, clients *awsclients.Clients, filter map[string]string) map[string]map[string]string {
+	var tagFilters []taggingtypes.TagFilter
+	for key, value := range filter {
+		tagFilters = append(tagFilters, taggingtypes.TagFilter{Key: awssdk.String(key), Values: []string{value}})
+	}
+
+	ctx, cancel := clients.Context()
+	defer cancel()
+
+	resources := map[string]map[string]string{}
+	paginator := resourcegroupstaggingapi.NewGetResourcesPaginator(clients.Tagging(), &resourcegroupstaggingapi.GetResourcesInput{
+		TagFilters: tagFilters,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		require.NoError(t, err, "Failed to list resources tagged %v", filter)
+
+		for _, mapping := range page.ResourceTagMappingList {
+			tags := map[string]string{}
+			for _, tag := range mapping.Tags {
+				tags[awssdk.ToString(tag.Key)] = awssdk.ToString(tag.Value)
+			}
+			resources[awssdk.ToString(mapping.ResourceARN)] = tags
+		}
+	}
+	return resources
+}
+
+// AssertCompliant enumerates the run's resources, waiting for the expected ones to
+// be indexed, and reports every ARN that violates the policy
+func AssertCompliant(t *testing.T, clients *awsclients.Clients, opts Options) bool {
+	t.Helper()
+	require.NotEmpty(t, opts.Filter, "A tag filter is required to scope the audit to one run")
+
+	var resources map[string]map[string]string
+	err := wait.WaitFor(context.Background(), func(ctx context.Context) (bool, error) {
+		resources = Resources(t, clients, opts.Filter)
+		if len(resources) == 0 {
+			return false, fmt.Errorf("no resources tagged %v yet", opts.Filter)
+		}
+		for _, arn := range opts.Expected {
+			if _, ok := resources[arn]; !ok {
+				return false, fmt.Errorf("%s not indexed yet", arn)
+			}
+		}
+		return true, nil
+	}, wait.DefaultOptions().WithTimeout(indexTimeout).WithInterval(30*time.Second))
+	if err != nil {
+		logging.New(t).Warn("Auditing the resources found so far", "resources", len(resources), "error", err)
+	}
+	require.NotEmpty(t, resources, "No resources tagged %v were found", opts.Filter)
+
+	violations := Audit(resources, opts)
+	if len(violations) > 0 {
+		lines := make([]string, len(violations))
+		for i, violation := range violations {
+			lines[i] = violation.String()
+		}
+		t.Errorf("%d of %d resources violate the tag policy:\n  %s", len(violations), len(resources), strings.Join(lines, "\n  "))
+		return false
+	}
+
+	logging.New(t).Success("All resources carry the mandatory tags", "resources", len(resources))
+	return true
+}
+
+// AssertRun audits everything deployed with terraformOptions, which must carry the
+// testutil.RunTag, against DefaultPolicy with the Environment tag pinned to the
+// deployed environment; expected lists ARNs that must be among them
+func AssertRun(t *testing.T, clients *awsclients.Clients, terraformOptions *terraform.Options, expected ...string) bool {
+	t.Helper()
+
+	runID := testutil.RunID(terraformOptions)
+	require.NotEmpty(t, runID, "The module must be deployed with a unique suffix to audit its tags")
+
+	policy := DefaultPolicy()
+	if environment, ok := terraformOptions.Vars["environment"].(string); ok {
+		policy = policy.WithValue("Environment", environment)
+	}
+
+	return AssertCompliant(t, clients, Options{
+		Filter:   map[string]string{testutil.RunTag: runID},
+		Expected: expected,
+		Policy:   policy,
+	})
+}
+
+// contains reports whether values includes value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
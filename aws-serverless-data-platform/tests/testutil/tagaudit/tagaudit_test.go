@@ -0,0 +1,72 @@
+package tagaudit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// compliantTags satisfies DefaultPolicy
+func compliantTags() map[string]string {
+	return map[string]string{
+		"Environment":        "test",
+		"Project":            "terratest",
+		"Owner":              "data-platform",
+		"CostCenter":         "DataEngineering-Test",
+		"DataClassification": "internal",
+	}
+}
+
+func TestCheck(t *testing.T) {
+	policy := DefaultPolicy().WithValue("Environment", "test")
+
+	assert.Empty(t, Check(compliantTags(), policy))
+
+	tags := compliantTags()
+	delete(tags, "Owner")
+	tags["CostCenter"] = ""
+	tags["DataClassification"] = "secret"
+	tags["Environment"] = "prod"
+	tags["Name"] = " padded "
+	tags["Notes"] = strings.Repeat("x", maxValueLength+1)
+
+	assert.Equal(t, []string{
+		"missing tag Owner",
+		"missing tag CostCenter",
+		`tag DataClassification="secret" is not one of public, internal, confidential, restricted`,
+		`tag Environment="prod" is not one of test`,
+		"tag Name has surrounding whitespace",
+		"tag Notes is longer than 256 characters",
+	}, Check(tags, policy))
+
+	tags = compliantTags()
+	tags["CostCenter"] = "Data Engineering"
+	assert.Len(t, Check(tags, policy), 1, "CostCenter must match its pattern")
+}
+
+func TestWithValueDoesNotModifyPolicy(t *testing.T) {
+	policy := DefaultPolicy()
+	_ = policy.WithValue("DataClassification", "public")
+
+	assert.Equal(t, DataClassifications, policy.Allowed["DataClassification"])
+}
+
+func TestAudit(t *testing.T) {
+	untagged := compliantTags()
+	delete(untagged, "DataClassification")
+
+	violations := Audit(map[string]map[string]string{
+		"arn:aws:s3:::ok":     compliantTags(),
+		"arn:aws:s3:::broken": untagged,
+	}, Options{
+		Expected: []string{"arn:aws:s3:::ok", "arn:aws:kms:us-east-1:111111111111:key/missing"},
+		Policy:   DefaultPolicy(),
+	})
+
+	assert.Equal(t, []Violation{
+		{ARN: "arn:aws:s3:::broken", Problems: []string{"missing tag DataClassification"}},
+		{ARN: "arn:aws:kms:us-east-1:111111111111:key/missing", Problems: []string{"not found by the run's tag filter"}},
+	}, violations)
+	assert.Equal(t, "arn:aws:s3:::broken: missing tag DataClassification", violations[0].String())
+}
@@ -0,0 +1,133 @@
+// =============================================================================
+// Terraform Options
+// Builds terratest options around the module variable maps
+// =============================================================================
+
+package testutil
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/awsclients"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/report"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/runprefix"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/testconfig"
+)
+
+// NewTerraformOptions returns terratest options for the module in terraformDir,
+// recording the run prefix in its manifest the first time it is called
+func NewTerraformOptions(t *testing.T, terraformDir string, vars map[string]interface{}, opts ...Option) *terraform.Options {
+	recordOnce.Do(func() { recordErr = recordRun() })
+	require.NoError(t, recordErr, "Failed to record the test run in its manifest")
+
+	return &terraform.Options{
+		TerraformDir: terraformDir,
+		Vars:         vars,
+		EnvVars:      EnvVars(t, opts...),
+	}
+}
+
+var (
+	recordOnce sync.Once
+	recordErr  error
+)
+
+// recordRun adds the suite to the manifest of the run prefix, so the sweeper
+// can clean up this run alone with -manifest
+func recordRun() error {
+	prefix, err := runprefix.Get()
+	if err != nil {
+		return err
+	}
+	config, err := testconfig.Load()
+	if err != nil {
+		return err
+	}
+	root, err := testconfig.FindRoot()
+	if err != nil {
+		return err
+	}
+
+	path := runprefix.ManifestPath(root, prefix)
+	return runprefix.Append(path, runprefix.NewEntry(prefix, suiteName(), config.Regions))
+}
+
+// recordDeployment adds a module applied through report.Apply, with the
+// resources in its state, to the run's resource manifest for testcost
+func recordDeployment(t *testing.T, terraformOptions *terraform.Options, module report.Module) {
+	prefix, err := runprefix.Get()
+	if !assert.NoError(t, err) {
+		return
+	}
+	root, err := testconfig.FindRoot()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	dir, err := filepath.Abs(module.Dir)
+	if err == nil && root != "" {
+		if rel, err := filepath.Rel(root, dir); err == nil {
+			dir = filepath.ToSlash(rel)
+		}
+	}
+
+	deployment := runprefix.Deployment{
+		Prefix:  prefix,
+		Suite:   suiteName(),
+		Test:    t.Name(),
+		Module:  dir,
+		RunID:   RunID(terraformOptions),
+		Failed:  module.Failed,
+		Applied: time.Now().UTC(),
+	}
+	for _, resource := range module.Resources {
+		deployment.Resources = append(deployment.Resources, runprefix.Resource(resource))
+	}
+
+	path := runprefix.ResourcesPath(runprefix.ManifestPath(root, prefix))
+	assert.NoError(t, runprefix.AppendDeployment(path, deployment), "Failed to record the deployment in the resource manifest")
+}
+
+// EnvVars returns the environment for Terraform and Terragrunt processes; when a role is
+// configured its credentials are resolved here so every child process runs as that role
+func EnvVars(t *testing.T, opts ...Option) map[string]string {
+	s := NewSettings(opts...)
+
+	env := map[string]string{
+		"AWS_DEFAULT_REGION": s.Region,
+	}
+	if s.RoleARN == "" {
+		return env
+	}
+
+	ctx := context.Background()
+	cfg, _, err := awsclients.LoadConfig(ctx,
+		awsclients.WithRegion(s.Region),
+		awsclients.WithAssumeRole(s.RoleARN),
+		awsclients.WithExternalID(s.ExternalID),
+	)
+	require.NoError(t, err)
+
+	credentials, err := awsclients.CredentialEnvVars(ctx, cfg)
+	require.NoError(t, err, "Failed to assume role %s", s.RoleARN)
+
+	for name, value := range credentials {
+		env[name] = value
+	}
+	return env
+}
+
+// RunID returns the RunTag value in the options' common_tags, or "" when the
+// module was deployed without a unique suffix
+func RunID(options *terraform.Options) string {
+	tags, _ := options.Vars["common_tags"].(map[string]interface{})
+	runID, _ := tags[RunTag].(string)
+	return runID
+}
@@ -0,0 +1,318 @@
+// =============================================================================
+// Test Configuration
+// Layered YAML and environment settings for the account, regions and network
+// =============================================================================
+
+package testconfig
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultRegion is the AWS region used when no configuration sets one
+	DefaultRegion = "us-east-1"
+
+	// DefaultEnvironment is the environment name passed to modules under test
+	DefaultEnvironment = "test"
+
+	// DefaultVPCCIDR is the /16 module tests build their VPC in
+	DefaultVPCCIDR = "10.0.0.0/16"
+
+	// DefaultVPCID is a placeholder for modules that only reference a VPC
+	DefaultVPCID = "vpc-0123456789abcdef0"
+
+	// DefaultProject is the project name in config/common.yaml
+	DefaultProject = "aws-serverless-data-platform"
+)
+
+const (
+	// BaseFile is the committed configuration, relative to the repository root
+	BaseFile = "config/testing.yaml"
+
+	// LocalFile holds untracked per-developer overrides, relative to the repository root
+	LocalFile = "config/testing.local.yaml"
+
+	// EnvironmentEnvVar selects the config/testing.<name>.yaml layer
+	EnvironmentEnvVar = "TERRATEST_CONFIG_ENV"
+
+	// AccountIDEnvVar overrides the configured account ID
+	AccountIDEnvVar = "TERRATEST_ACCOUNT_ID"
+
+	// RegionsEnvVar overrides the configured regions with a comma-separated list
+	RegionsEnvVar = "TERRATEST_REGIONS"
+
+	// VPCCIDREnvVar overrides the configured VPC CIDR
+	VPCCIDREnvVar = "TERRATEST_VPC_CIDR"
+
+	// VPCIDEnvVar overrides the configured VPC ID
+	VPCIDEnvVar = "TERRATEST_VPC_ID"
+
+	// BaseParameterPathEnvVar overrides the configured test base stack path
+	BaseParameterPathEnvVar = "TERRATEST_BASE_PARAMETER_PATH"
+)
+
+// accountIDPattern matches a 12 digit AWS account ID
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// Config is the merged test configuration
+type Config struct {
+	// AccountID is the account modules are told they run in
+	AccountID string `yaml:"account_id"`
+
+	// Environment is the environment name passed to modules under test
+	Environment string `yaml:"environment"`
+
+	// Regions are the regions module suites fan out to; the first is the default
+	Regions []string `yaml:"regions"`
+
+	// VPCCIDR is the /16 module tests build their VPC in
+	VPCCIDR string `yaml:"vpc_cidr"`
+
+	// VPCID is passed to modules that attach to an existing VPC
+	VPCID string `yaml:"vpc_id"`
+
+	// BaseParameterPath is where the long-lived test base stack exports its
+	// outputs in SSM Parameter Store; empty provisions base infrastructure per test
+	BaseParameterPath string `yaml:"base_parameter_path"`
+
+	// ProtectedAccountIDs are accounts, such as production, the suites refuse to run in
+	ProtectedAccountIDs []string `yaml:"protected_account_ids"`
+
+	// RunnerRolePath is the IAM path the caller's role must live under; empty
+	// admits any role
+	RunnerRolePath string `yaml:"runner_role_path"`
+
+	// Tags are added to the common tags of every test resource
+	Tags map[string]string `yaml:"tags"`
+
+	// Integration selects the Terragrunt environment the integration suite deploys
+	Integration Integration `yaml:"integration"`
+}
+
+// Integration configures the end-to-end suite under tests/integration
+type Integration struct {
+	Environment string `yaml:"environment"`
+	Region      string `yaml:"region"`
+	VPCCIDR     string `yaml:"vpc_cidr"`
+
+	// Project is the Project tag Terragrunt applies to the environment's resources
+	Project string `yaml:"project"`
+
+	// CatalogConsumers are the accounts the environment's Glue Data Catalog may be shared with
+	CatalogConsumers []string `yaml:"catalog_consumers"`
+}
+
+// Defaults returns the built-in configuration every layer is merged onto; the
+// account and the integration region have no default and come from BaseFile
+func Defaults() Config {
+	return Config{
+		Environment: DefaultEnvironment,
+		Regions:     []string{DefaultRegion},
+		VPCCIDR:     DefaultVPCCIDR,
+		VPCID:       DefaultVPCID,
+		Tags:        map[string]string{},
+		Integration: Integration{
+			Environment: "dev",
+			VPCCIDR:     DefaultVPCCIDR,
+			Project:     DefaultProject,
+		},
+	}
+}
+
+// Region returns the default region, the first configured one
+func (c Config) Region() string {
+	return c.Regions[0]
+}
+
+// Load merges the defaults, the repository's configuration files and the
+// environment, in that order
+func Load() (Config, error) {
+	root, err := FindRoot()
+	if err != nil {
+		return Config{}, err
+	}
+	return LoadFrom(root, os.Getenv(EnvironmentEnvVar))
+}
+
+// MustLoad is Load for callers without a *testing.T; it panics on invalid configuration
+func MustLoad() Config {
+	config, err := Load()
+	if err != nil {
+		panic(err)
+	}
+	return config
+}
+
+// LoadFrom merges the configuration under root: BaseFile, then
+// config/testing.<environment>.yaml when environment is set, then LocalFile,
+// then the TERRATEST_* environment variables. An empty root uses only the
+// defaults and the environment.
+func LoadFrom(root, environment string) (Config, error) {
+	config := Defaults()
+
+	if root != "" {
+		layers := []string{BaseFile}
+		if environment != "" {
+			layers = append(layers, fmt.Sprintf("config/testing.%s.yaml", environment))
+		}
+		layers = append(layers, LocalFile)
+
+		for _, layer := range layers {
+			// The base file must exist; a missing environment layer is a typo worth failing on
+			required := layer != LocalFile
+			if err := merge(&config, filepath.Join(root, layer), required); err != nil {
+				return Config{}, err
+			}
+		}
+	}
+
+	applyEnv(&config)
+
+	if err := config.Validate(); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// merge overlays the YAML file at path onto config; keys absent from the file keep their value
+func merge(config *Config, path string, required bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overrides config with any TERRATEST_* variables that are set
+func applyEnv(config *Config) {
+	if value := os.Getenv(AccountIDEnvVar); value != "" {
+		config.AccountID = value
+	}
+	if value := os.Getenv(RegionsEnvVar); value != "" {
+		config.Regions = strings.Split(value, ",")
+	}
+	if value := os.Getenv(VPCCIDREnvVar); value != "" {
+		config.VPCCIDR = value
+	}
+	if value := os.Getenv(VPCIDEnvVar); value != "" {
+		config.VPCID = value
+	}
+	if value := os.Getenv(BaseParameterPathEnvVar); value != "" {
+		config.BaseParameterPath = value
+	}
+}
+
+// Validate normalizes the region list and rejects values the suites cannot use
+func (c *Config) Validate() error {
+	if c.AccountID == "" {
+		return fmt.Errorf("account_id must be set in %s or %s", BaseFile, AccountIDEnvVar)
+	}
+	if !accountIDPattern.MatchString(c.AccountID) {
+		return fmt.Errorf("account_id %q is not a 12 digit AWS account ID", c.AccountID)
+	}
+	if c.Environment == "" {
+		return fmt.Errorf("environment must not be empty")
+	}
+
+	var regions []string
+	seen := map[string]bool{}
+	for _, region := range c.Regions {
+		region = strings.TrimSpace(region)
+		if region == "" {
+			continue
+		}
+		if seen[region] {
+			return fmt.Errorf("region %s is listed twice", region)
+		}
+		seen[region] = true
+		regions = append(regions, region)
+	}
+	if len(regions) == 0 {
+		return fmt.Errorf("no regions configured")
+	}
+	c.Regions = regions
+
+	for name, cidr := range map[string]string{"vpc_cidr": c.VPCCIDR, "integration.vpc_cidr": c.Integration.VPCCIDR} {
+		if err := validateVPCCIDR(cidr); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if !strings.HasPrefix(c.VPCID, "vpc-") {
+		return fmt.Errorf("vpc_id %q is not a VPC ID", c.VPCID)
+	}
+	if c.BaseParameterPath != "" && (!strings.HasPrefix(c.BaseParameterPath, "/") || strings.HasSuffix(c.BaseParameterPath, "/")) {
+		return fmt.Errorf("base_parameter_path %q must start with a slash and not end with one", c.BaseParameterPath)
+	}
+	for _, accountID := range c.ProtectedAccountIDs {
+		if !accountIDPattern.MatchString(accountID) {
+			return fmt.Errorf("protected_account_ids: %q is not a 12 digit AWS account ID", accountID)
+		}
+		if accountID == c.AccountID {
+			return fmt.Errorf("account_id %s is protected; point the suites at a test account", c.AccountID)
+		}
+	}
+	if c.RunnerRolePath != "" && (!strings.HasPrefix(c.RunnerRolePath, "/") || !strings.HasSuffix(c.RunnerRolePath, "/")) {
+		return fmt.Errorf("runner_role_path %q must start and end with a slash", c.RunnerRolePath)
+	}
+	if c.Integration.Environment == "" || c.Integration.Region == "" || c.Integration.Project == "" {
+		return fmt.Errorf("integration.environment, integration.region and integration.project must be set")
+	}
+	for _, accountID := range c.Integration.CatalogConsumers {
+		if !accountIDPattern.MatchString(accountID) {
+			return fmt.Errorf("integration.catalog_consumers: %q is not a 12 digit AWS account ID", accountID)
+		}
+	}
+	return nil
+}
+
+// validateVPCCIDR requires a /16, which subnet CIDRs are carved from
+func validateVPCCIDR(cidr string) error {
+	ip, network, err := net.ParseCIDR(cidr)
+	if err != nil || ip.To4() == nil {
+		return fmt.Errorf("%q is not an IPv4 CIDR", cidr)
+	}
+	if ones, _ := network.Mask.Size(); ones != 16 {
+		return fmt.Errorf("%q must be a /16", cidr)
+	}
+	if !ip.Equal(network.IP) {
+		return fmt.Errorf("%q is not a network address", cidr)
+	}
+	return nil
+}
+
+// FindRoot walks up from the working directory to the repository containing
+// BaseFile; it returns an empty path when there is none
+func FindRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, BaseFile)); err == nil {
+			return dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
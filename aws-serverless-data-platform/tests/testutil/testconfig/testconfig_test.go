@@ -0,0 +1,131 @@
+package testconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeLayer writes a configuration file below root
+func writeLayer(t *testing.T, root, name, content string) {
+	path := filepath.Join(root, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+// clearEnv unsets the overrides so the developer's environment does not leak in
+func clearEnv(t *testing.T) {
+	for _, name := range []string{AccountIDEnvVar, RegionsEnvVar, VPCCIDREnvVar, VPCIDEnvVar, BaseParameterPathEnvVar} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestLoadFromLayers(t *testing.T) {
+	clearEnv(t)
+	root := t.TempDir()
+	writeLayer(t, root, BaseFile, `
+account_id: "111111111111"
+regions: [us-east-1, eu-west-1]
+tags:
+  Owner: platform
+integration:
+  region: ap-southeast-1
+`)
+	writeLayer(t, root, "config/testing.partner.yaml", `
+account_id: "222222222222"
+vpc_cidr: 10.32.0.0/16
+tags:
+  CostCenter: partner
+`)
+	writeLayer(t, root, LocalFile, `
+regions: [ap-southeast-2]
+`)
+
+	config, err := LoadFrom(root, "partner")
+	require.NoError(t, err)
+
+	assert.Equal(t, "222222222222", config.AccountID)
+	assert.Equal(t, []string{"ap-southeast-2"}, config.Regions)
+	assert.Equal(t, "ap-southeast-2", config.Region())
+	assert.Equal(t, "10.32.0.0/16", config.VPCCIDR)
+	assert.Equal(t, DefaultVPCID, config.VPCID, "Unset keys keep their default")
+	assert.Empty(t, config.BaseParameterPath, "Without a base stack every test provisions its own")
+	assert.Equal(t, map[string]string{"Owner": "platform", "CostCenter": "partner"}, config.Tags)
+
+	// The environment overrides every file
+	t.Setenv(AccountIDEnvVar, "333333333333")
+	t.Setenv(RegionsEnvVar, " eu-central-1 , ")
+	t.Setenv(BaseParameterPathEnvVar, "/terratest/base")
+	config, err = LoadFrom(root, "partner")
+	require.NoError(t, err)
+	assert.Equal(t, "333333333333", config.AccountID)
+	assert.Equal(t, "/terratest/base", config.BaseParameterPath)
+	assert.Equal(t, []string{"eu-central-1"}, config.Regions)
+}
+
+func TestLoadFromErrors(t *testing.T) {
+	clearEnv(t)
+	root := t.TempDir()
+
+	_, err := LoadFrom(root, "")
+	assert.Error(t, err, "The base file is required")
+
+	writeLayer(t, root, BaseFile, `
+environment: test
+account_id: "111111111111"
+integration:
+  region: ap-southeast-1
+`)
+	_, err = LoadFrom(root, "missing")
+	assert.Error(t, err, "A selected environment layer must exist")
+
+	for name, content := range map[string]string{
+		"account":   `account_id: "1234"`,
+		"duplicate": "regions: [us-east-1, us-east-1]",
+		"cidr":      "vpc_cidr: 10.0.0.0/24",
+		"vpc":       "vpc_id: subnet-123",
+		"base":      "base_parameter_path: terratest/base/",
+		"protected": `protected_account_ids: ["111111111111"]`,
+		"prod id":   "protected_account_ids: [prod]",
+		"role path": "runner_role_path: TestRunner",
+		"consumer":  "integration: {catalog_consumers: [analytics]}",
+		"yaml":      "regions: [",
+	} {
+		writeLayer(t, root, LocalFile, content)
+		_, err = LoadFrom(root, "")
+		assert.Error(t, err, name)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	clearEnv(t)
+	_, err := LoadFrom("", "")
+	assert.EqualError(t, err, "account_id must be set in config/testing.yaml or TERRATEST_ACCOUNT_ID",
+		"The account has no default")
+
+	t.Setenv(AccountIDEnvVar, "111111111111")
+	_, err = LoadFrom("", "")
+	assert.Error(t, err, "The integration region has no default")
+
+	root := t.TempDir()
+	writeLayer(t, root, BaseFile, "integration: {region: ap-southeast-1}\n")
+	config, err := LoadFrom(root, "")
+	require.NoError(t, err)
+	expected := Defaults()
+	expected.AccountID = "111111111111"
+	expected.Integration.Region = "ap-southeast-1"
+	assert.Equal(t, expected, config)
+}
+
+func TestRepositoryConfig(t *testing.T) {
+	clearEnv(t)
+	root, err := FindRoot()
+	require.NoError(t, err)
+	require.NotEmpty(t, root, "config/testing.yaml should be found from the test directory")
+
+	_, err = LoadFrom(root, "")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,174 @@
+// =============================================================================
+// Terraform Module Interface
+// Reads the variables and outputs a module declares, without running Terraform
+// =============================================================================
+
+// Package tfmodule parses the variable and output blocks of a Terraform module
+// so tests can check its interface statically.
+package tfmodule
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Variable is one input variable of a module
+type Variable struct {
+	Name string
+
+	// Required is set when the variable has no default, so every caller must set it
+	Required bool
+
+	// Sensitive is set when the variable is marked sensitive = true
+	Sensitive bool
+
+	// File is the file the variable is declared in, e.g. "variables.tf"
+	File string
+}
+
+// Output is one output value of a module
+type Output struct {
+	Name string
+	File string
+}
+
+// Module is the interface of the module in Dir
+type Module struct {
+	Dir       string
+	Variables map[string]Variable
+	Outputs   map[string]Output
+}
+
+// fileSchema selects the blocks that make up the interface; everything else is ignored
+var fileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "variable", LabelNames: []string{"name"}},
+		{Type: "output", LabelNames: []string{"name"}},
+	},
+}
+
+// variableSchema selects the variable attributes the interface cares about
+var variableSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "default"}, {Name: "sensitive"}},
+}
+
+// Load parses every *.tf file in dir; a variable or output declared twice is an error
+func Load(dir string) (*Module, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no .tf files found in %s", dir)
+	}
+	sort.Strings(paths)
+
+	module := &Module{Dir: dir, Variables: map[string]Variable{}, Outputs: map[string]Output{}}
+	parser := hclparse.NewParser()
+	for _, path := range paths {
+		if err := module.parseFile(parser, path); err != nil {
+			return nil, err
+		}
+	}
+	return module, nil
+}
+
+// parseFile adds the variables and outputs declared in path
+func (m *Module) parseFile(parser *hclparse.Parser, path string) error {
+	file, diags := parser.ParseHCLFile(path)
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to parse %s: %s", path, diags.Error())
+	}
+	content, _, diags := file.Body.PartialContent(fileSchema)
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to read %s: %s", path, diags.Error())
+	}
+
+	name := filepath.Base(path)
+	for _, block := range content.Blocks {
+		label := block.Labels[0]
+
+		switch block.Type {
+		case "variable":
+			if _, ok := m.Variables[label]; ok {
+				return fmt.Errorf("%s: variable %q is declared twice", path, label)
+			}
+			variable, err := parseVariable(block)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			variable.File = name
+			m.Variables[label] = variable
+
+		case "output":
+			if _, ok := m.Outputs[label]; ok {
+				return fmt.Errorf("%s: output %q is declared twice", path, label)
+			}
+			m.Outputs[label] = Output{Name: label, File: name}
+		}
+	}
+	return nil
+}
+
+// parseVariable reads whether a variable block has a default and is sensitive
+func parseVariable(block *hcl.Block) (Variable, error) {
+	variable := Variable{Name: block.Labels[0]}
+
+	content, _, diags := block.Body.PartialContent(variableSchema)
+	if diags.HasErrors() {
+		return variable, fmt.Errorf("variable %q: %s", variable.Name, diags.Error())
+	}
+
+	_, hasDefault := content.Attributes["default"]
+	variable.Required = !hasDefault
+
+	if attribute, ok := content.Attributes["sensitive"]; ok {
+		value, diags := attribute.Expr.Value(nil)
+		if diags.HasErrors() || !value.Type().Equals(cty.Bool) {
+			return variable, fmt.Errorf("variable %q: sensitive must be a literal bool", variable.Name)
+		}
+		variable.Sensitive = value.True()
+	}
+	return variable, nil
+}
+
+// RequiredVariables returns the names of the variables without a default, sorted
+func (m *Module) RequiredVariables() []string {
+	var names []string
+	for name, variable := range m.Variables {
+		if variable.Required {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MissingVariables returns the names that the module does not declare, sorted
+func (m *Module) MissingVariables(names []string) []string {
+	var missing []string
+	for _, name := range names {
+		if _, ok := m.Variables[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// MissingOutputs returns the names that the module does not output, sorted
+func (m *Module) MissingOutputs(names []string) []string {
+	var missing []string
+	for _, name := range names {
+		if _, ok := m.Outputs[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
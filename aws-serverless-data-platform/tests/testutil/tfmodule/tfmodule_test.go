@@ -0,0 +1,70 @@
+package tfmodule
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeModule(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"variables.tf": `
+variable "environment" {
+  type = string
+}
+
+variable "retention_days" {
+  type    = number
+  default = 30
+}
+
+variable "password" {
+  type      = string
+  sensitive = true
+}`,
+		"outputs.tf": `
+output "bucket_id" {
+  value = aws_s3_bucket.this.id
+}`,
+		"main.tf": `
+resource "aws_s3_bucket" "this" {
+  bucket = "${var.environment}-data"
+}`,
+	})
+
+	module, err := Load(dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"environment", "password"}, module.RequiredVariables())
+	assert.True(t, module.Variables["password"].Sensitive)
+	assert.Equal(t, "variables.tf", module.Variables["retention_days"].File)
+	assert.Equal(t, []string{"missing"}, module.MissingVariables([]string{"retention_days", "missing"}))
+	assert.Empty(t, module.MissingOutputs([]string{"bucket_id"}))
+	assert.Equal(t, []string{"bucket_arn"}, module.MissingOutputs([]string{"bucket_arn", "bucket_id"}))
+}
+
+func TestLoadRejectsDuplicates(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"a.tf": `output "id" { value = 1 }`,
+		"b.tf": `output "id" { value = 2 }`,
+	})
+
+	_, err := Load(dir)
+	assert.ErrorContains(t, err, `output "id" is declared twice`)
+}
+
+func TestLoadWithoutFiles(t *testing.T) {
+	_, err := Load(t.TempDir())
+	assert.ErrorContains(t, err, "no .tf files")
+}
@@ -0,0 +1,63 @@
+// =============================================================================
+// Terraform Plan Assertions
+// Assertion helpers for validating configuration without applying it
+// =============================================================================
+
+package tfplan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// AssertResourceCount checks the plan contains exactly expected resources of resourceType
+func AssertResourceCount(t *testing.T, plan *Plan, resourceType string, expected int) bool {
+	t.Helper()
+
+	actual := len(plan.ResourcesOfType(resourceType))
+	return assert.Equal(t, expected, actual,
+		"Plan should contain %d %s resources, found %d", expected, resourceType, actual)
+}
+
+// AssertAttributeEquals checks the planned value of attribute on the resource at address
+func AssertAttributeEquals(t *testing.T, plan *Plan, address, attribute string, expected interface{}) bool {
+	t.Helper()
+
+	resource, ok := plan.Resource(address)
+	if !assert.True(t, ok, "Plan should contain resource %s", address) {
+		return false
+	}
+
+	actual, ok := resource.Attribute(attribute)
+	if !assert.True(t, ok, "Resource %s should have attribute %s", address, attribute) {
+		return false
+	}
+
+	want, err := normalize(expected)
+	if !assert.NoError(t, err) {
+		return false
+	}
+
+	return assert.Equal(t, want, actual, "Unexpected value for %s.%s", address, attribute)
+}
+
+// AssertNoDestroys checks the plan does not delete or replace any resource
+func AssertNoDestroys(t *testing.T, plan *Plan) bool {
+	t.Helper()
+
+	destroys := plan.Destroys()
+	return assert.Empty(t, destroys,
+		"Plan should not destroy any resources, but would destroy: %s", strings.Join(destroys, ", "))
+}
+
+// AssertEverySets checks every resource of resourceType the plan creates or
+// updates sets attribute, e.g. that no role is created without a permissions boundary
+func AssertEverySets(t *testing.T, plan *Plan, resourceType, attribute string) bool {
+	t.Helper()
+
+	unset := plan.Unset(resourceType, attribute)
+	return assert.Empty(t, unset,
+		"Every %s should set %s, but these do not: %s", resourceType, attribute, strings.Join(unset, ", "))
+}
@@ -0,0 +1,200 @@
+// =============================================================================
+// Terraform Plan Harness
+// Runs plan-only and parses `terraform show -json` output into Go structs
+// =============================================================================
+
+package tfplan
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/report"
+)
+
+// Plan is the subset of the Terraform JSON plan format used by the assertions
+type Plan struct {
+	FormatVersion    string           `json:"format_version"`
+	TerraformVersion string           `json:"terraform_version"`
+	PlannedValues    PlannedValues    `json:"planned_values"`
+	ResourceChanges  []ResourceChange `json:"resource_changes"`
+}
+
+// PlannedValues describes the state Terraform expects after apply
+type PlannedValues struct {
+	RootModule Module `json:"root_module"`
+}
+
+// Module is a root or child module within the planned values
+type Module struct {
+	Address      string     `json:"address"`
+	Resources    []Resource `json:"resources"`
+	ChildModules []Module   `json:"child_modules"`
+}
+
+// Resource is a single resource instance within the planned values
+type Resource struct {
+	Address string                 `json:"address"`
+	Mode    string                 `json:"mode"`
+	Type    string                 `json:"type"`
+	Name    string                 `json:"name"`
+	Index   interface{}            `json:"index"`
+	Values  map[string]interface{} `json:"values"`
+}
+
+// ResourceChange is a planned action against one resource instance
+type ResourceChange struct {
+	Address string `json:"address"`
+	Mode    string `json:"mode"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Change  Change `json:"change"`
+}
+
+// Change holds the actions and before/after values of a resource change;
+// AfterUnknown marks the attributes only known after apply
+type Change struct {
+	Actions      []string               `json:"actions"`
+	Before       map[string]interface{} `json:"before"`
+	After        map[string]interface{} `json:"after"`
+	AfterUnknown map[string]interface{} `json:"after_unknown"`
+}
+
+// Run executes `terraform init`, `terraform plan -out` and `terraform show -json`
+// and returns the parsed plan; nothing is applied
+func Run(t *testing.T, terraformOptions *terraform.Options) *Plan {
+	report.Track(t)
+	terraformOptions.PlanFilePath = filepath.Join(t.TempDir(), "tfplan")
+
+	planJSON := terraform.InitAndPlanAndShow(t, terraformOptions)
+
+	plan, err := Parse(planJSON)
+	require.NoError(t, err, "Failed to parse plan JSON")
+
+	return plan
+}
+
+// Parse decodes the output of `terraform show -json <planfile>`
+func Parse(planJSON string) (*Plan, error) {
+	var plan Plan
+	if err := json.Unmarshal([]byte(planJSON), &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// Resources returns every managed resource in the planned values, including child modules
+func (p *Plan) Resources() []Resource {
+	var resources []Resource
+	var walk func(module Module)
+	walk = func(module Module) {
+		for _, resource := range module.Resources {
+			if resource.Mode == "managed" {
+				resources = append(resources, resource)
+			}
+		}
+		for _, child := range module.ChildModules {
+			walk(child)
+		}
+	}
+	walk(p.PlannedValues.RootModule)
+
+	return resources
+}
+
+// ResourcesOfType returns the planned resources of the given type
+func (p *Plan) ResourcesOfType(resourceType string) []Resource {
+	var matches []Resource
+	for _, resource := range p.Resources() {
+		if resource.Type == resourceType {
+			matches = append(matches, resource)
+		}
+	}
+	return matches
+}
+
+// Resource returns the planned resource at address
+func (p *Plan) Resource(address string) (Resource, bool) {
+	for _, resource := range p.Resources() {
+		if resource.Address == address {
+			return resource, true
+		}
+	}
+	return Resource{}, false
+}
+
+// Attribute resolves a dotted attribute path such as "versioning_configuration.0.status"
+func (r Resource) Attribute(path string) (interface{}, bool) {
+	var current interface{} = r.Values
+	for _, segment := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[segment]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// Destroys returns the addresses of every resource the plan would delete or replace
+func (p *Plan) Destroys() []string {
+	var addresses []string
+	for _, change := range p.ResourceChanges {
+		for _, action := range change.Change.Actions {
+			if action == "delete" {
+				addresses = append(addresses, change.Address)
+				break
+			}
+		}
+	}
+	return addresses
+}
+
+// Unset returns the addresses of resources of resourceType the plan creates or
+// updates without attribute, which is set when it has a value or one only known after apply
+func (p *Plan) Unset(resourceType, attribute string) []string {
+	var addresses []string
+	for _, change := range p.ResourceChanges {
+		if change.Type != resourceType || change.Mode == "data" || change.Change.After == nil {
+			continue
+		}
+		if change.Change.After[attribute] != nil {
+			continue
+		}
+		if unknown, _ := change.Change.AfterUnknown[attribute].(bool); unknown {
+			continue
+		}
+		addresses = append(addresses, change.Address)
+	}
+	return addresses
+}
+
+// normalize round-trips value through JSON so Go literals compare equal to decoded plan values
+func normalize(value interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %v: %w", value, err)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
@@ -0,0 +1,54 @@
+package tfplan
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadFixture(t *testing.T) *Plan {
+	planJSON, err := os.ReadFile("testdata/plan.json")
+	require.NoError(t, err)
+
+	plan, err := Parse(string(planJSON))
+	require.NoError(t, err)
+	return plan
+}
+
+func TestParseAndQuery(t *testing.T) {
+	plan := loadFixture(t)
+
+	assert.Len(t, plan.Resources(), 4)
+	AssertResourceCount(t, plan, "aws_subnet", 2)
+	AssertAttributeEquals(t, plan, "aws_vpc.main", "cidr_block", "10.0.0.0/16")
+	AssertAttributeEquals(t, plan, "aws_vpc.main", "tags.Environment", "test")
+	AssertAttributeEquals(t, plan, "aws_s3_bucket_versioning.raw", "versioning_configuration.0.status", "Enabled")
+
+	_, ok := plan.Resources()[0].Attribute("versioning_configuration.1.status")
+	assert.False(t, ok, "Out of range index should not resolve")
+}
+
+func TestDestroys(t *testing.T) {
+	plan := loadFixture(t)
+
+	assert.Equal(t, []string{"aws_subnet.private[0]"}, plan.Destroys())
+}
+
+func TestUnset(t *testing.T) {
+	plan, err := Parse(`{"resource_changes": [
+		{"address": "aws_iam_role.glue", "mode": "managed", "type": "aws_iam_role",
+		 "change": {"actions": ["create"], "after": {"name": "glue", "permissions_boundary": null}, "after_unknown": {"permissions_boundary": true}}},
+		{"address": "aws_iam_role.flow_log[0]", "mode": "managed", "type": "aws_iam_role",
+		 "change": {"actions": ["create"], "after": {"name": "flow-log", "permissions_boundary": null}, "after_unknown": {}}},
+		{"address": "aws_iam_role.pinned", "mode": "managed", "type": "aws_iam_role",
+		 "change": {"actions": ["update"], "after": {"permissions_boundary": "arn:aws:iam::123456789012:policy/boundary"}}},
+		{"address": "aws_iam_role.old", "mode": "managed", "type": "aws_iam_role",
+		 "change": {"actions": ["delete"], "before": {"name": "old"}, "after": null}}
+	]}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"aws_iam_role.flow_log[0]"}, plan.Unset("aws_iam_role", "permissions_boundary"))
+	assert.Empty(t, plan.Unset("aws_kms_key", "policy"))
+}
@@ -0,0 +1,267 @@
+// =============================================================================
+// Terraform Plan Snapshots
+// Golden-file comparison of normalized planned values
+// =============================================================================
+
+package tfplan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+)
+
+// SnapshotDir is where golden plan snapshots live, relative to the test's package
+const SnapshotDir = "testdata/snapshots"
+
+// maxDiffLines bounds how many differences a failing comparison reports
+const maxDiffLines = 50
+
+// Snapshot is the normalized, comparable form of a plan
+type Snapshot struct {
+	Resources []SnapshotResource `json:"resources"`
+}
+
+// SnapshotResource is one planned resource with its volatile attributes removed
+type SnapshotResource struct {
+	Address string                 `json:"address"`
+	Values  map[string]interface{} `json:"values"`
+}
+
+// snapshotOptions controls normalization
+type snapshotOptions struct {
+	ignore map[string]bool
+	redact map[string]string
+	update bool
+}
+
+// SnapshotOption customizes how a plan is normalized
+type SnapshotOption func(*snapshotOptions)
+
+// IgnoreAttributes drops attributes with these names at any depth
+func IgnoreAttributes(names ...string) SnapshotOption {
+	return func(o *snapshotOptions) {
+		for _, name := range names {
+			o.ignore[name] = true
+		}
+	}
+}
+
+// Redact replaces every occurrence of value in string attributes with placeholder,
+// e.g. the account ID a data source resolved at plan time
+func Redact(value, placeholder string) SnapshotOption {
+	return func(o *snapshotOptions) {
+		if value != "" {
+			o.redact[value] = placeholder
+		}
+	}
+}
+
+// Update rewrites the snapshot instead of comparing against it when update is
+// set; suites pass their -update flag
+func Update(update bool) SnapshotOption {
+	return func(o *snapshotOptions) {
+		o.update = update
+	}
+}
+
+// NewSnapshot normalizes the plan's managed resources; tags_all is always
+// ignored because it reflects provider default tags rather than the module
+func NewSnapshot(plan *Plan, opts ...SnapshotOption) Snapshot {
+	return newSnapshotOptions(opts).snapshot(plan)
+}
+
+// newSnapshotOptions applies opts over the defaults
+func newSnapshotOptions(opts []SnapshotOption) *snapshotOptions {
+	o := &snapshotOptions{ignore: map[string]bool{"tags_all": true}, redact: map[string]string{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// snapshot normalizes the plan's managed resources
+func (o *snapshotOptions) snapshot(plan *Plan) Snapshot {
+	snapshot := Snapshot{Resources: []SnapshotResource{}}
+	for _, resource := range plan.Resources() {
+		values, _ := o.normalize(resource.Values).(map[string]interface{})
+		snapshot.Resources = append(snapshot.Resources, SnapshotResource{Address: resource.Address, Values: values})
+	}
+	sort.Slice(snapshot.Resources, func(i, j int) bool {
+		return snapshot.Resources[i].Address < snapshot.Resources[j].Address
+	})
+	return snapshot
+}
+
+// normalize strips ignored attributes and redacts strings recursively
+func (o *snapshotOptions) normalize(value interface{}) interface{} {
+	switch node := value.(type) {
+	case map[string]interface{}:
+		result := map[string]interface{}{}
+		for key, child := range node {
+			if !o.ignore[key] {
+				result[key] = o.normalize(child)
+			}
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(node))
+		for i, child := range node {
+			result[i] = o.normalize(child)
+		}
+		return result
+	case string:
+		for secret, placeholder := range o.redact {
+			node = strings.ReplaceAll(node, secret, placeholder)
+		}
+		return node
+	default:
+		return node
+	}
+}
+
+// AssertSnapshot compares the plan with testdata/snapshots/<name>.json; with
+// Update the file is written instead so it can be reviewed and committed
+func AssertSnapshot(t *testing.T, plan *Plan, name string, opts ...SnapshotOption) bool {
+	t.Helper()
+
+	path := filepath.Join(SnapshotDir, name+".json")
+	o := newSnapshotOptions(opts)
+	actual := o.snapshot(plan)
+
+	encoded, err := json.MarshalIndent(actual, "", "  ")
+	require.NoError(t, err)
+	encoded = append(encoded, '\n')
+
+	if o.update {
+		require.NoError(t, os.MkdirAll(SnapshotDir, 0o755))
+		require.NoError(t, os.WriteFile(path, encoded, 0o644))
+		logging.New(t).Warn("Wrote plan snapshot; review and commit it", "path", path, "resources", len(actual.Resources))
+		return true
+	}
+
+	golden, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Plan snapshot %s does not exist; run with -update to write it, then commit it", path)
+		return false
+	}
+	require.NoError(t, err, "Failed to read plan snapshot %s", path)
+
+	var expected Snapshot
+	require.NoError(t, json.Unmarshal(golden, &expected), "Failed to parse plan snapshot %s", path)
+
+	differences := DiffSnapshots(expected, actual)
+	if len(differences) == 0 {
+		logging.New(t).Success("Plan matches snapshot", "path", path)
+		return true
+	}
+
+	if len(differences) > maxDiffLines {
+		differences = append(differences[:maxDiffLines], fmt.Sprintf("... and %d more", len(differences)-maxDiffLines))
+	}
+	t.Errorf("Plan differs from snapshot %s; rerun with -update if the change is intended:\n  %s",
+		path, strings.Join(differences, "\n  "))
+	return false
+}
+
+// DiffSnapshots lists the resources and attribute paths that differ between two snapshots
+func DiffSnapshots(expected, actual Snapshot) []string {
+	expectedByAddress := map[string]map[string]interface{}{}
+	for _, resource := range expected.Resources {
+		expectedByAddress[resource.Address] = resource.Values
+	}
+	actualByAddress := map[string]map[string]interface{}{}
+	for _, resource := range actual.Resources {
+		actualByAddress[resource.Address] = resource.Values
+	}
+
+	var differences []string
+	for _, resource := range expected.Resources {
+		if _, ok := actualByAddress[resource.Address]; !ok {
+			differences = append(differences, "- "+resource.Address)
+		}
+	}
+	for _, resource := range actual.Resources {
+		before, ok := expectedByAddress[resource.Address]
+		if !ok {
+			differences = append(differences, "+ "+resource.Address)
+			continue
+		}
+
+		beforePaths, afterPaths := flatten(before), flatten(resource.Values)
+		for _, path := range unionKeys(beforePaths, afterPaths) {
+			old, hadOld := beforePaths[path]
+			updated, hasNew := afterPaths[path]
+			switch {
+			case !hadOld:
+				differences = append(differences, fmt.Sprintf("~ %s.%s: (absent) => %s", resource.Address, path, updated))
+			case !hasNew:
+				differences = append(differences, fmt.Sprintf("~ %s.%s: %s => (absent)", resource.Address, path, old))
+			case old != updated:
+				differences = append(differences, fmt.Sprintf("~ %s.%s: %s => %s", resource.Address, path, old, updated))
+			}
+		}
+	}
+	return differences
+}
+
+// flatten maps each leaf's dotted path to its JSON encoding
+func flatten(values map[string]interface{}) map[string]string {
+	leaves := map[string]string{}
+	var walk func(prefix string, value interface{})
+	walk = func(prefix string, value interface{}) {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			for key, child := range node {
+				walk(join(prefix, key), child)
+			}
+			if len(node) == 0 {
+				leaves[prefix] = "{}"
+			}
+		case []interface{}:
+			for i, child := range node {
+				walk(join(prefix, fmt.Sprint(i)), child)
+			}
+			if len(node) == 0 {
+				leaves[prefix] = "[]"
+			}
+		default:
+			encoded, _ := json.Marshal(node)
+			leaves[prefix] = string(encoded)
+		}
+	}
+	walk("", values)
+	delete(leaves, "")
+	return leaves
+}
+
+// join appends a path segment
+func join(prefix, segment string) string {
+	if prefix == "" {
+		return segment
+	}
+	return prefix + "." + segment
+}
+
+// unionKeys returns the sorted keys present in either map
+func unionKeys(a, b map[string]string) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,62 @@
+package tfplan
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// update rewrites the plan snapshots instead of comparing against them: go test -run Plan -update
+var update = flag.Bool("update", false, "rewrite Terraform plan snapshots under "+SnapshotDir)
+
+func TestNewSnapshot(t *testing.T) {
+	plan := loadFixture(t)
+
+	snapshot := NewSnapshot(plan, IgnoreAttributes("enable_dns_support"), Redact("10.0.", "10.X."))
+
+	var addresses []string
+	for _, resource := range snapshot.Resources {
+		addresses = append(addresses, resource.Address)
+	}
+	assert.Equal(t, []string{
+		"aws_s3_bucket_versioning.raw",
+		"aws_subnet.private[0]",
+		"aws_subnet.private[1]",
+		"aws_vpc.main",
+	}, addresses, "Resources should be sorted by address")
+
+	vpc := snapshot.Resources[3].Values
+	assert.NotContains(t, vpc, "enable_dns_support")
+	assert.Equal(t, "10.X.0.0/16", vpc["cidr_block"])
+}
+
+func TestPlanSnapshot(t *testing.T) {
+	AssertSnapshot(t, loadFixture(t), "fixture", Update(*update))
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	expected := Snapshot{Resources: []SnapshotResource{
+		{Address: "aws_vpc.main", Values: map[string]interface{}{
+			"cidr_block": "10.0.0.0/16",
+			"tags":       map[string]interface{}{"Environment": "test"},
+		}},
+		{Address: "aws_subnet.old", Values: map[string]interface{}{}},
+	}}
+	actual := Snapshot{Resources: []SnapshotResource{
+		{Address: "aws_vpc.main", Values: map[string]interface{}{
+			"cidr_block": "10.1.0.0/16",
+			"tags":       map[string]interface{}{"Environment": "test", "Owner": "data"},
+		}},
+		{Address: "aws_subnet.new", Values: map[string]interface{}{}},
+	}}
+
+	assert.Equal(t, []string{
+		"- aws_subnet.old",
+		`~ aws_vpc.main.cidr_block: "10.0.0.0/16" => "10.1.0.0/16"`,
+		`~ aws_vpc.main.tags.Owner: (absent) => "data"`,
+		"+ aws_subnet.new",
+	}, DiffSnapshots(expected, actual))
+
+	assert.Empty(t, DiffSnapshots(expected, expected))
+}
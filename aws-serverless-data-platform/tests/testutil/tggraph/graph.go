@@ -0,0 +1,320 @@
+// =============================================================================
+// Terragrunt Dependency Graph
+// Builds the unit DAG from terragrunt.hcl dependency blocks
+// =============================================================================
+
+package tggraph
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ConfigFile is the file that marks a directory as a Terragrunt unit
+const ConfigFile = "terragrunt.hcl"
+
+// Unit is one Terragrunt unit and the units it depends on
+type Unit struct {
+	// Name is the unit directory relative to the graph root, e.g. "03-storage"
+	Name string
+
+	// Dir is the unit directory
+	Dir string
+
+	// Dependencies are the names of the units this unit reads outputs from or
+	// must be applied after; names outside the graph are kept so Validate can report them
+	Dependencies []string
+}
+
+// Graph is the dependency graph of every unit below a root directory
+type Graph struct {
+	Root  string
+	Units map[string]*Unit
+}
+
+// configSchema selects the blocks that declare dependencies; everything else is ignored
+var configSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "dependency", LabelNames: []string{"name"}},
+		{Type: "dependencies"},
+	},
+}
+
+// Load parses every terragrunt.hcl below root
+func Load(root string) (*Graph, error) {
+	graph := &Graph{Root: root, Units: map[string]*Unit{}}
+	parser := hclparse.NewParser()
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && (entry.Name() == ".terragrunt-cache" || entry.Name() == ".terraform") {
+			return filepath.SkipDir
+		}
+		if entry.IsDir() || entry.Name() != ConfigFile {
+			return nil
+		}
+
+		unit, err := parseUnit(parser, root, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		graph.Units[unit.Name] = unit
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(graph.Units) == 0 {
+		return nil, fmt.Errorf("no %s files found below %s", ConfigFile, root)
+	}
+
+	return graph, nil
+}
+
+// parseUnit reads the dependency and dependencies blocks of the unit in dir
+func parseUnit(parser *hclparse.Parser, root, dir string) (*Unit, error) {
+	name, err := filepath.Rel(root, dir)
+	if err != nil {
+		return nil, err
+	}
+	unit := &Unit{Name: filepath.ToSlash(name), Dir: dir}
+
+	path := filepath.Join(dir, ConfigFile)
+	file, diags := parser.ParseHCLFile(path)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %s: %s", path, diags.Error())
+	}
+	content, _, diags := file.Body.PartialContent(configSchema)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to read %s: %s", path, diags.Error())
+	}
+
+	var paths []string
+	for _, block := range content.Blocks {
+		attribute := "config_path"
+		if block.Type == "dependencies" {
+			attribute = "paths"
+		}
+
+		values, err := stringAttribute(block, attribute)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		paths = append(paths, values...)
+	}
+
+	seen := map[string]bool{}
+	for _, dependency := range paths {
+		if !filepath.IsAbs(dependency) {
+			dependency = filepath.Join(dir, dependency)
+		}
+		rel, err := filepath.Rel(root, dependency)
+		if err != nil {
+			return nil, err
+		}
+		rel = filepath.ToSlash(rel)
+		if !seen[rel] {
+			seen[rel] = true
+			unit.Dependencies = append(unit.Dependencies, rel)
+		}
+	}
+	sort.Strings(unit.Dependencies)
+
+	return unit, nil
+}
+
+// stringAttribute evaluates a literal string or list-of-strings attribute of block
+func stringAttribute(block *hcl.Block, name string) ([]string, error) {
+	content, _, diags := block.Body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: name, Required: true}},
+	})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("%s block: %s", block.Type, diags.Error())
+	}
+
+	value, diags := content.Attributes[name].Expr.Value(nil)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("%s.%s must be a literal path: %s", block.Type, name, diags.Error())
+	}
+
+	switch {
+	case value.Type() == cty.String:
+		return []string{value.AsString()}, nil
+	case value.Type().IsTupleType() || value.Type().IsListType():
+		var values []string
+		for _, element := range value.AsValueSlice() {
+			if element.Type() != cty.String {
+				return nil, fmt.Errorf("%s.%s must only contain strings", block.Type, name)
+			}
+			values = append(values, element.AsString())
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("%s.%s must be a string or list of strings", block.Type, name)
+	}
+}
+
+// Validate reports dependencies on units that do not exist and dependency cycles
+func (g *Graph) Validate() error {
+	var problems []string
+	for _, name := range g.names() {
+		for _, dependency := range g.Units[name].Dependencies {
+			if _, ok := g.Units[dependency]; !ok {
+				problems = append(problems, fmt.Sprintf("%s depends on missing unit %s", name, dependency))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid dependency graph:\n%s", strings.Join(problems, "\n"))
+	}
+
+	if cycle := g.findCycle(); cycle != nil {
+		return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+// Subgraph returns the graph of the named units and every unit they depend
+// on, directly or not, which is what `terragrunt run-all` runs for a set of
+// --terragrunt-include-dir filters
+func (g *Graph) Subgraph(names ...string) (*Graph, error) {
+	sub := &Graph{Root: g.Root, Units: map[string]*Unit{}}
+	var include func(name string) error
+	include = func(name string) error {
+		if _, ok := sub.Units[name]; ok {
+			return nil
+		}
+		unit, ok := g.Units[name]
+		if !ok {
+			return fmt.Errorf("no unit %s under %s; units are %s", name, g.Root, strings.Join(g.names(), ", "))
+		}
+		sub.Units[name] = unit
+		for _, dependency := range unit.Dependencies {
+			if err := include(dependency); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, name := range names {
+		if err := include(name); err != nil {
+			return nil, err
+		}
+	}
+	return sub, nil
+}
+
+// ApplyOrder returns the units with every dependency before its dependents;
+// units that become ready together are ordered by name
+func (g *Graph) ApplyOrder() ([]string, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+
+	remaining := map[string]int{}
+	dependents := map[string][]string{}
+	for name, unit := range g.Units {
+		remaining[name] = len(unit.Dependencies)
+		for _, dependency := range unit.Dependencies {
+			dependents[dependency] = append(dependents[dependency], name)
+		}
+	}
+
+	var ready, order []string
+	for name, count := range remaining {
+		if count == 0 {
+			ready = append(ready, name)
+		}
+	}
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+
+		for _, dependent := range dependents[name] {
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+	return order, nil
+}
+
+// DestroyOrder returns the reverse of ApplyOrder, so dependents are destroyed first
+func (g *Graph) DestroyOrder() ([]string, error) {
+	order, err := g.ApplyOrder()
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	return order, nil
+}
+
+// findCycle returns the units of one dependency cycle, starting and ending with the same unit
+func (g *Graph) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	var stack []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		stack = append(stack, name)
+
+		for _, dependency := range g.Units[name].Dependencies {
+			switch state[dependency] {
+			case visiting:
+				for i, entry := range stack {
+					if entry == dependency {
+						return append(append([]string{}, stack[i:]...), dependency)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dependency); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, name := range g.names() {
+		if state[name] == unvisited {
+			if cycle := visit(name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// names returns the unit names in sorted order
+func (g *Graph) names() []string {
+	names := make([]string, 0, len(g.Units))
+	for name := range g.Units {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
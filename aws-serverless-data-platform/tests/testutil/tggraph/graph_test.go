@@ -0,0 +1,106 @@
+package tggraph
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeUnits creates a terragrunt.hcl per unit name with the given contents
+func writeUnits(t *testing.T, units map[string]string) string {
+	root := t.TempDir()
+	for name, config := range units {
+		dir := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ConfigFile), []byte(config), 0o644))
+	}
+	return root
+}
+
+func TestOrder(t *testing.T) {
+	root := writeUnits(t, map[string]string{
+		"01-networking": `include "root" { path = find_in_parent_folders("root.hcl") }`,
+		"02-security":   `dependency "networking" { config_path = "../01-networking" }`,
+		"03-storage": `
+dependency "networking" {
+  config_path  = "../01-networking"
+  mock_outputs = { vpc_id = "vpc-12345678" }
+}
+dependency "security" { config_path = "../02-security" }
+inputs = { vpc_id = dependency.networking.outputs.vpc_id }`,
+		"07-analytics": `dependencies { paths = ["../03-storage", "../02-security"] }`,
+	})
+
+	graph, err := Load(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"01-networking", "02-security"}, graph.Units["03-storage"].Dependencies)
+
+	apply, err := graph.ApplyOrder()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"01-networking", "02-security", "03-storage", "07-analytics"}, apply)
+
+	destroy, err := graph.DestroyOrder()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"07-analytics", "03-storage", "02-security", "01-networking"}, destroy)
+}
+
+func TestSubgraph(t *testing.T) {
+	root := writeUnits(t, map[string]string{
+		"01-networking": ``,
+		"02-security":   `dependency "networking" { config_path = "../01-networking" }`,
+		"03-storage":    `dependency "security" { config_path = "../02-security" }`,
+		"04-monitoring": `dependency "networking" { config_path = "../01-networking" }`,
+	})
+	graph, err := Load(root)
+	require.NoError(t, err)
+
+	sub, err := graph.Subgraph("03-storage")
+	require.NoError(t, err)
+	order, err := sub.ApplyOrder()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"01-networking", "02-security", "03-storage"}, order)
+
+	_, err = graph.Subgraph("05-analytics")
+	assert.ErrorContains(t, err, "no unit 05-analytics")
+}
+
+func TestValidate(t *testing.T) {
+	missing := writeUnits(t, map[string]string{
+		"03-storage": `dependency "catalog" { config_path = "../04-data-catalog" }`,
+	})
+	graph, err := Load(missing)
+	require.NoError(t, err)
+	assert.ErrorContains(t, graph.Validate(), "03-storage depends on missing unit 04-data-catalog")
+
+	cyclic := writeUnits(t, map[string]string{
+		"a": `dependency "b" { config_path = "../b" }`,
+		"b": `dependency "c" { config_path = "../c" }`,
+		"c": `dependency "a" { config_path = "../a" }`,
+	})
+	graph, err = Load(cyclic)
+	require.NoError(t, err)
+	assert.EqualError(t, graph.Validate(), "dependency cycle: a -> b -> c -> a")
+
+	_, err = graph.ApplyOrder()
+	assert.Error(t, err)
+}
+
+func TestLoadRejectsComputedPaths(t *testing.T) {
+	root := writeUnits(t, map[string]string{
+		"storage": `dependency "networking" { config_path = find_in_parent_folders("networking") }`,
+	})
+	_, err := Load(root)
+	assert.ErrorContains(t, err, "must be a literal path")
+}
+
+func TestDevEnvironment(t *testing.T) {
+	graph, err := Load("../../../environments/dev/ap-southeast-1")
+	require.NoError(t, err)
+
+	order, err := graph.ApplyOrder()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"01-networking", "03-storage"}, order)
+}
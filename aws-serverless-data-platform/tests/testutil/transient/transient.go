@@ -0,0 +1,197 @@
+// =============================================================================
+// Transient Error Retries
+// Retries Terraform runs that hit known capacity and throttling errors
+// =============================================================================
+
+// Package transient retries operations, such as a Terraform apply, that fail
+// with a known transient AWS error: a NAT gateway or Elastic IP limit, Glue
+// DPU or concurrent run limits, throttling or IAM propagation delay. Each
+// pattern has its own retry budget, shared by every test in the run, so a
+// capacity problem that does not clear fails the run instead of retrying every
+// test into its timeout; any other error fails at once. The retries consumed
+// are summarized when the run ends.
+package transient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"sync"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+)
+
+// Pattern is a known transient error and how often a run may retry it
+type Pattern struct {
+	// Name identifies the pattern in logs and the summary, e.g. "nat-gateway-limit"
+	Name string
+
+	// Match finds the error in the failed operation's output
+	Match *regexp.Regexp
+
+	// Budget is the number of retries the whole run may spend on the pattern
+	Budget int
+
+	// Wait is the pause before retrying, long enough for the condition to clear
+	Wait time.Duration
+}
+
+// Known are the transient errors integration runs have hit, most specific first
+var Known = []Pattern{
+	{Name: "nat-gateway-limit", Match: regexp.MustCompile(`NatGatewayLimitExceeded`), Budget: 2, Wait: 2 * time.Minute},
+	{Name: "address-limit", Match: regexp.MustCompile(`AddressLimitExceeded`), Budget: 2, Wait: 2 * time.Minute},
+	{Name: "glue-capacity", Match: regexp.MustCompile(`ResourceNumberLimitExceededException|ConcurrentRunsExceededException`), Budget: 3, Wait: time.Minute},
+	{Name: "insufficient-capacity", Match: regexp.MustCompile(`InsufficientInstanceCapacity|InsufficientCapacity|(?i)insufficient capacity`), Budget: 3, Wait: time.Minute},
+	{Name: "iam-propagation", Match: regexp.MustCompile(`(?i)role .*cannot be assumed|not authorized to perform: sts:AssumeRole|InvalidParameterValueException: The role`), Budget: 3, Wait: 15 * time.Second},
+	{Name: "throttling", Match: regexp.MustCompile(`Throttling|TooManyRequestsException|RequestLimitExceeded|(?i)rate exceeded`), Budget: 5, Wait: 15 * time.Second},
+	{Name: "dependency-violation", Match: regexp.MustCompile(`DependencyViolation`), Budget: 3, Wait: 30 * time.Second},
+	{Name: "state-lock", Match: regexp.MustCompile(`Error acquiring the state lock`), Budget: 2, Wait: 30 * time.Second},
+	{Name: "network", Match: regexp.MustCompile(`(?i)connection reset by peer|TLS handshake timeout|i/o timeout`), Budget: 3, Wait: 10 * time.Second},
+}
+
+// Classify returns the first pattern among patterns that matches output
+func Classify(patterns []Pattern, output string) (Pattern, bool) {
+	for _, pattern := range patterns {
+		if pattern.Match.MatchString(output) {
+			return pattern, true
+		}
+	}
+	return Pattern{}, false
+}
+
+// Retry is one retry spent against a pattern's budget
+type Retry struct {
+	Test      string
+	Operation string
+	Pattern   string
+	Time      time.Time
+}
+
+// Budgets tracks the retries spent on each pattern; it is safe for parallel tests
+type Budgets struct {
+	mu       sync.Mutex
+	patterns []Pattern
+	retries  []Retry
+}
+
+// NewBudgets returns budgets for patterns with nothing spent
+func NewBudgets(patterns []Pattern) *Budgets {
+	return &Budgets{patterns: patterns}
+}
+
+// Default holds the Known budgets used by Do and testutil.Main
+var Default = NewBudgets(Known)
+
+// take spends one retry of pattern on behalf of test, reporting false when its
+// budget is used up
+func (b *Budgets) take(pattern Pattern, test, operation string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	spent := 0
+	for _, retry := range b.retries {
+		if retry.Pattern == pattern.Name {
+			spent++
+		}
+	}
+	if spent >= pattern.Budget {
+		return false
+	}
+	b.retries = append(b.retries, Retry{Test: test, Operation: operation, Pattern: pattern.Name, Time: time.Now()})
+	return true
+}
+
+// Retries returns every retry spent so far, in the order they were spent
+func (b *Budgets) Retries() []Retry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Retry(nil), b.retries...)
+}
+
+// Do runs operation with the Default budgets; see Budgets.Do
+func Do(ctx context.Context, t testing.TB, description string, operation func() (string, error)) (string, error) {
+	return Default.Do(ctx, t, description, operation)
+}
+
+// Do runs operation until it succeeds, retrying while its output and error
+// match a pattern with budget left and returning the last output and error
+// otherwise, or when ctx ends during a wait
+func (b *Budgets) Do(ctx context.Context, t testing.TB, description string, operation func() (string, error)) (string, error) {
+	logger := logging.New(t, "operation", description)
+	for {
+		output, err := operation()
+		if err == nil {
+			return output, nil
+		}
+
+		pattern, ok := Classify(b.patterns, output+"\n"+err.Error())
+		if !ok {
+			return output, err
+		}
+		if !b.take(pattern, t.Name(), description) {
+			logger.Warn("Retry budget used up", "pattern", pattern.Name, "budget", pattern.Budget)
+			return output, fmt.Errorf("%w (retry budget of %d for %s used up)", err, pattern.Budget, pattern.Name)
+		}
+		logger.Info("Retrying after transient error", "pattern", pattern.Name, "wait", pattern.Wait)
+
+		select {
+		case <-ctx.Done():
+			return output, err
+		case <-time.After(pattern.Wait):
+		}
+	}
+}
+
+// WriteSummary writes the Default budgets' summary; see Budgets.WriteSummary
+func WriteSummary(w io.Writer) error {
+	return Default.WriteSummary(w)
+}
+
+// WriteSummary writes a table of the retries spent per pattern and test,
+// followed by each pattern's use of its budget; nothing is written when no
+// retries were spent
+func (b *Budgets) WriteSummary(w io.Writer) error {
+	retries := b.Retries()
+	if len(retries) == 0 {
+		return nil
+	}
+
+	type key struct{ pattern, test, operation string }
+	counts := map[key]int{}
+	spent := map[string]int{}
+	for _, retry := range retries {
+		counts[key{retry.Pattern, retry.Test, retry.Operation}]++
+		spent[retry.Pattern]++
+	}
+	keys := make([]key, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].pattern != keys[j].pattern {
+			return keys[i].pattern < keys[j].pattern
+		}
+		if keys[i].test != keys[j].test {
+			return keys[i].test < keys[j].test
+		}
+		return keys[i].operation < keys[j].operation
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RETRY PATTERN\tTEST\tOPERATION\tRETRIES\t")
+	for _, k := range keys {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t\n", k.pattern, k.test, k.operation, counts[k])
+	}
+	fmt.Fprintln(tw, "\t\t\t\t")
+	for _, pattern := range b.patterns {
+		if spent[pattern.Name] > 0 {
+			fmt.Fprintf(tw, "total\t%s\t\t%d/%d\t\n", pattern.Name, spent[pattern.Name], pattern.Budget)
+		}
+	}
+	return tw.Flush()
+}
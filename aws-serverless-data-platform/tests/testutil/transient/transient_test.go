@@ -0,0 +1,99 @@
+package transient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	cases := map[string]string{
+		"Error: creating EC2 NAT Gateway: NatGatewayLimitExceeded: The maximum number of NAT Gateways has been reached.": "nat-gateway-limit",
+		"Error: allocating EC2 EIP: AddressLimitExceeded: The maximum number of addresses has been reached.":             "address-limit",
+		"ResourceNumberLimitExceededException: Failed to meet resource allocation for 10 DPUs":                           "glue-capacity",
+		"api error ThrottlingException: Rate exceeded":                                                                   "throttling",
+		"InvalidParameterValueException: The role defined for the function cannot be assumed by Lambda.":                 "iam-propagation",
+		"Error: deleting EC2 Subnet: DependencyViolation: The subnet has dependencies and cannot be deleted.":            "dependency-violation",
+		"Error: Error acquiring the state lock":                                                                          "state-lock",
+		"read tcp 10.0.0.1:443: read: connection reset by peer":                                                          "network",
+	}
+	for output, name := range cases {
+		pattern, ok := Classify(Known, output)
+		if assert.True(t, ok, output) {
+			assert.Equal(t, name, pattern.Name, output)
+		}
+	}
+
+	_, ok := Classify(Known, "Error: Unsupported argument: An argument named \"bucket_name\" is not expected here.")
+	assert.False(t, ok, "configuration errors are not transient")
+}
+
+func TestDo(t *testing.T) {
+	budgets := NewBudgets([]Pattern{{Name: "throttling", Match: regexp.MustCompile(`Throttling`), Budget: 2}})
+
+	calls := 0
+	output, err := budgets.Do(context.Background(), t, "apply", func() (string, error) {
+		calls++
+		if calls == 1 {
+			return "ThrottlingException", errors.New("exit status 1")
+		}
+		return "Apply complete!", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Apply complete!", output)
+	assert.Equal(t, 2, calls)
+
+	// The budget is shared across operations, so one retry is left for the run
+	calls = 0
+	_, err = budgets.Do(context.Background(), t, "destroy", func() (string, error) {
+		calls++
+		return "", errors.New("ThrottlingException")
+	})
+	assert.ErrorContains(t, err, "retry budget of 2 for throttling used up")
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	_, err = budgets.Do(context.Background(), t, "plan", func() (string, error) {
+		calls++
+		return "Error: Invalid reference", errors.New("exit status 1")
+	})
+	assert.EqualError(t, err, "exit status 1")
+	assert.Equal(t, 1, calls, "unknown errors are not retried")
+
+	retries := budgets.Retries()
+	require.Len(t, retries, 2)
+	assert.Equal(t, "apply", retries[0].Operation)
+	assert.Equal(t, "destroy", retries[1].Operation)
+	assert.Equal(t, t.Name(), retries[1].Test)
+}
+
+func TestWriteSummary(t *testing.T) {
+	budgets := NewBudgets(Known)
+
+	var empty bytes.Buffer
+	require.NoError(t, budgets.WriteSummary(&empty))
+	assert.Empty(t, empty.String())
+
+	glue, _ := Classify(Known, "ConcurrentRunsExceededException")
+	nat, _ := Classify(Known, "NatGatewayLimitExceeded")
+	budgets.take(glue, "TestAnalytics", "apply")
+	budgets.take(glue, "TestAnalytics", "apply")
+	budgets.take(nat, "TestNetworking/us-east-1", "apply")
+
+	var summary bytes.Buffer
+	require.NoError(t, budgets.WriteSummary(&summary))
+	// tabwriter pads every cell, so trailing spaces are trimmed before comparing
+	trimmed := regexp.MustCompile(`(?m) +$`).ReplaceAllString(summary.String(), "")
+	assert.Equal(t, `RETRY PATTERN      TEST                      OPERATION  RETRIES
+glue-capacity      TestAnalytics             apply      2
+nat-gateway-limit  TestNetworking/us-east-1  apply      1
+
+total              nat-gateway-limit                    1/2
+total              glue-capacity                        2/3
+`, trimmed)
+}
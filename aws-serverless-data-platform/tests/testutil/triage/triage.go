@@ -0,0 +1,248 @@
+// =============================================================================
+// Failure Triage
+// Describes a failed test's stacks so they can be inspected before cleanup
+// =============================================================================
+
+// Package triage describes the stacks of a failed test kept deployed with
+// KEEP_ON_FAILURE=true: their outputs, the resources in their state with
+// console links, and the log groups worth reading first. The description is
+// written as triage.json next to the test's stage data.
+package triage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/report"
+)
+
+const (
+	// EnvVar keeps the stacks of a failed test deployed when set to true
+	EnvVar = "KEEP_ON_FAILURE"
+
+	// FileName is the triage file written for a kept test
+	FileName = "triage.json"
+)
+
+// Enabled reports whether EnvVar asks for failed tests' stacks to be kept
+func Enabled() bool {
+	keep, _ := strconv.ParseBool(os.Getenv(EnvVar))
+	return keep
+}
+
+// Report is everything known about a kept test
+type Report struct {
+	Test   string    `json:"test"`
+	Prefix string    `json:"run_prefix"`
+	Region string    `json:"region"`
+	Kept   time.Time `json:"kept"`
+
+	// Cleanup is how to destroy the stacks once triage is done
+	Cleanup string  `json:"cleanup"`
+	Stacks  []Stack `json:"stacks"`
+}
+
+// Stack is one kept Terraform module or Terragrunt unit
+type Stack struct {
+	Dir       string                 `json:"dir"`
+	Outputs   map[string]interface{} `json:"outputs"`
+	Resources []Resource             `json:"resources"`
+	LogGroups []LogGroup             `json:"log_groups"`
+}
+
+// Resource is one managed resource in a stack's state
+type Resource struct {
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	ID      string `json:"id,omitempty"`
+	ARN     string `json:"arn,omitempty"`
+	Console string `json:"console,omitempty"`
+}
+
+// LogGroup is a log group a stack writes to
+type LogGroup struct {
+	Name    string `json:"name"`
+	Console string `json:"console"`
+}
+
+// Output is one value of `terraform output -json`
+type Output struct {
+	Sensitive bool        `json:"sensitive"`
+	Value     interface{} `json:"value"`
+}
+
+// ParseOutputs reads `terraform output -json`, hiding sensitive values so the
+// triage file can be shared
+func ParseOutputs(outputJSON string) (map[string]interface{}, error) {
+	parsed := map[string]Output{}
+	if err := json.Unmarshal([]byte(outputJSON), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse outputs: %w", err)
+	}
+
+	outputs := make(map[string]interface{}, len(parsed))
+	for name, output := range parsed {
+		outputs[name] = output.Value
+		if output.Sensitive {
+			outputs[name] = "(sensitive)"
+		}
+	}
+	return outputs, nil
+}
+
+// NewStack describes the stack in dir from its outputs and state inventory
+func NewStack(dir, region string, outputs map[string]interface{}, resources []report.Resource) Stack {
+	stack := Stack{Dir: dir, Outputs: outputs, Resources: []Resource{}, LogGroups: []LogGroup{}}
+	groups := map[string]bool{}
+
+	for _, resource := range resources {
+		stack.Resources = append(stack.Resources, Resource{
+			Address: resource.Address,
+			Type:    resource.Type,
+			ID:      resource.ID,
+			ARN:     resource.ARN,
+			Console: ConsoleURL(region, resource.ARN),
+		})
+
+		switch resource.Type {
+		case "aws_cloudwatch_log_group":
+			groups[resource.ID] = true
+		case "aws_lambda_function":
+			groups["/aws/lambda/"+resource.ID] = true
+		}
+	}
+
+	for name := range groups {
+		if name != "" && name != "/aws/lambda/" {
+			stack.LogGroups = append(stack.LogGroups, LogGroup{Name: name, Console: LogGroupURL(region, name)})
+		}
+	}
+	sort.Slice(stack.LogGroups, func(i, j int) bool { return stack.LogGroups[i].Name < stack.LogGroups[j].Name })
+	return stack
+}
+
+// consoleURLs build the console page of a resource from its region and the
+// resource part of its ARN, by service
+var consoleURLs = map[string]func(region, resource string, parsed arn.ARN) string{
+	"s3": func(region, resource string, _ arn.ARN) string {
+		if strings.Contains(resource, "/") {
+			return ""
+		}
+		return fmt.Sprintf("https://s3.console.aws.amazon.com/s3/buckets/%s?region=%s", resource, region)
+	},
+	"lambda": func(region, resource string, _ arn.ARN) string {
+		name, ok := strings.CutPrefix(resource, "function:")
+		if !ok {
+			return ""
+		}
+		return fmt.Sprintf("https://%s.console.aws.amazon.com/lambda/home?region=%[1]s#/functions/%s", region, name)
+	},
+	"logs": func(region, resource string, _ arn.ARN) string {
+		name, ok := strings.CutPrefix(resource, "log-group:")
+		if !ok {
+			return ""
+		}
+		return LogGroupURL(region, strings.TrimSuffix(name, ":*"))
+	},
+	"ec2": func(region, resource string, _ arn.ARN) string {
+		kind, id, _ := strings.Cut(resource, "/")
+		switch kind {
+		case "vpc":
+			return fmt.Sprintf("https://%s.console.aws.amazon.com/vpcconsole/home?region=%[1]s#VpcDetails:VpcId=%s", region, id)
+		case "subnet":
+			return fmt.Sprintf("https://%s.console.aws.amazon.com/vpcconsole/home?region=%[1]s#SubnetDetails:subnetId=%s", region, id)
+		case "security-group":
+			return fmt.Sprintf("https://%s.console.aws.amazon.com/ec2/home?region=%[1]s#SecurityGroup:groupId=%s", region, id)
+		}
+		return ""
+	},
+	"iam": func(_, resource string, parsed arn.ARN) string {
+		kind, path, _ := strings.Cut(resource, "/")
+		switch kind {
+		case "role":
+			return "https://console.aws.amazon.com/iam/home#/roles/details/" + path[strings.LastIndex(path, "/")+1:]
+		case "policy":
+			return "https://console.aws.amazon.com/iam/home#/policies/details/" + url.PathEscape(parsed.String())
+		}
+		return ""
+	},
+	"kms": func(region, resource string, _ arn.ARN) string {
+		id, ok := strings.CutPrefix(resource, "key/")
+		if !ok {
+			return ""
+		}
+		return fmt.Sprintf("https://%s.console.aws.amazon.com/kms/home?region=%[1]s#/kms/keys/%s", region, id)
+	},
+	"glue": func(region, resource string, _ arn.ARN) string {
+		name, ok := strings.CutPrefix(resource, "database/")
+		if !ok {
+			return ""
+		}
+		return fmt.Sprintf("https://%s.console.aws.amazon.com/glue/home?region=%[1]s#/v2/data-catalog/databases/view/%s", region, name)
+	},
+	"sns": func(region, _ string, parsed arn.ARN) string {
+		return fmt.Sprintf("https://%s.console.aws.amazon.com/sns/v3/home?region=%[1]s#/topic/%s", region, parsed.String())
+	},
+	"sqs": func(region, resource string, parsed arn.ARN) string {
+		queueURL := fmt.Sprintf("https://sqs.%s.amazonaws.com/%s/%s", region, parsed.AccountID, resource)
+		return fmt.Sprintf("https://%s.console.aws.amazon.com/sqs/v3/home?region=%[1]s#/queues/%s", region, url.QueryEscape(queueURL))
+	},
+	"kinesis": func(region, resource string, _ arn.ARN) string {
+		name, ok := strings.CutPrefix(resource, "stream/")
+		if !ok {
+			return ""
+		}
+		return fmt.Sprintf("https://%s.console.aws.amazon.com/kinesis/home?region=%[1]s#/streams/details/%s/monitoring", region, name)
+	},
+	"athena": func(region, resource string, _ arn.ARN) string {
+		name, ok := strings.CutPrefix(resource, "workgroup/")
+		if !ok {
+			return ""
+		}
+		return fmt.Sprintf("https://%s.console.aws.amazon.com/athena/home?region=%[1]s#/workgroups/details/%s", region, name)
+	},
+}
+
+// ConsoleURL returns the console page of the resource with resourceARN, or ""
+// for resources without a known page; ARNs without a region, such as S3 and
+// IAM ones, open in region
+func ConsoleURL(region, resourceARN string) string {
+	parsed, err := arn.Parse(resourceARN)
+	if err != nil {
+		return ""
+	}
+	build, ok := consoleURLs[parsed.Service]
+	if !ok {
+		return ""
+	}
+	if parsed.Region != "" {
+		region = parsed.Region
+	}
+	return build(region, parsed.Resource, parsed)
+}
+
+// LogGroupURL returns the console page of the log group name, which CloudWatch
+// expects escaped twice with $ in place of %
+func LogGroupURL(region, name string) string {
+	escaped := strings.ReplaceAll(url.QueryEscape(url.QueryEscape(name)), "%", "$")
+	return fmt.Sprintf("https://%s.console.aws.amazon.com/cloudwatch/home?region=%[1]s#logsV2:log-groups/log-group/%s", region, escaped)
+}
+
+// Write writes r as indented JSON to path, creating its directory
+func Write(path string, r Report) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
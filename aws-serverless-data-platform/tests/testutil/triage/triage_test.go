@@ -0,0 +1,94 @@
+package triage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/report"
+)
+
+func TestEnabled(t *testing.T) {
+	t.Setenv(EnvVar, "true")
+	assert.True(t, Enabled())
+	t.Setenv(EnvVar, "false")
+	assert.False(t, Enabled())
+	t.Setenv(EnvVar, "")
+	assert.False(t, Enabled())
+}
+
+func TestParseOutputs(t *testing.T) {
+	outputs, err := ParseOutputs(`{
+  "vpc_id": {"sensitive": false, "type": "string", "value": "vpc-0abc"},
+  "subnet_ids": {"sensitive": false, "type": ["list", "string"], "value": ["subnet-1", "subnet-2"]},
+  "db_password": {"sensitive": true, "type": "string", "value": "hunter2"}
+}`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"vpc_id":      "vpc-0abc",
+		"subnet_ids":  []interface{}{"subnet-1", "subnet-2"},
+		"db_password": "(sensitive)",
+	}, outputs)
+
+	_, err = ParseOutputs("not json")
+	assert.Error(t, err)
+}
+
+func TestConsoleURL(t *testing.T) {
+	for resourceARN, want := range map[string]string{
+		"arn:aws:s3:::dl-raw-dev":                                    "https://s3.console.aws.amazon.com/s3/buckets/dl-raw-dev?region=us-east-1",
+		"arn:aws:lambda:ap-southeast-1:111111111111:function:ingest": "https://ap-southeast-1.console.aws.amazon.com/lambda/home?region=ap-southeast-1#/functions/ingest",
+		"arn:aws:ec2:us-east-1:111111111111:vpc/vpc-0abc":            "https://us-east-1.console.aws.amazon.com/vpcconsole/home?region=us-east-1#VpcDetails:VpcId=vpc-0abc",
+		"arn:aws:iam::111111111111:role/service/glue-role":           "https://console.aws.amazon.com/iam/home#/roles/details/glue-role",
+		"arn:aws:kms:us-east-1:111111111111:key/1234abcd":            "https://us-east-1.console.aws.amazon.com/kms/home?region=us-east-1#/kms/keys/1234abcd",
+		"arn:aws:logs:us-east-1:111111111111:log-group:/aws/vpc/flow-logs:*": "https://us-east-1.console.aws.amazon.com/cloudwatch/home?region=us-east-1" +
+			"#logsV2:log-groups/log-group/$252Faws$252Fvpc$252Fflow-logs",
+		"arn:aws:s3:::dl-raw-dev/object":                          "",
+		"arn:aws:ec2:us-east-1:111111111111:route-table/rtb-0abc": "",
+		"arn:aws:states:us-east-1:111111111111:stateMachine:etl":  "",
+		"not-an-arn": "",
+	} {
+		assert.Equal(t, want, ConsoleURL("us-east-1", resourceARN), resourceARN)
+	}
+}
+
+func TestNewStack(t *testing.T) {
+	stack := NewStack("modules/storage", "us-east-1", map[string]interface{}{"raw_bucket_id": "dl-raw-dev"}, []report.Resource{
+		{Address: "aws_s3_bucket.raw", Type: "aws_s3_bucket", ID: "dl-raw-dev", ARN: "arn:aws:s3:::dl-raw-dev"},
+		{Address: "aws_lambda_function.ingest", Type: "aws_lambda_function", ID: "ingest", ARN: "arn:aws:lambda:us-east-1:111111111111:function:ingest"},
+		{Address: "aws_cloudwatch_log_group.glue", Type: "aws_cloudwatch_log_group", ID: "/aws-glue/jobs/etl"},
+		{Address: "aws_s3_bucket_versioning.raw", Type: "aws_s3_bucket_versioning", ID: "dl-raw-dev"},
+	})
+
+	require.Len(t, stack.Resources, 4)
+	assert.Equal(t, "https://s3.console.aws.amazon.com/s3/buckets/dl-raw-dev?region=us-east-1", stack.Resources[0].Console)
+	assert.Empty(t, stack.Resources[3].Console, "Resources without an ARN have no console link")
+
+	var names []string
+	for _, group := range stack.LogGroups {
+		names = append(names, group.Name)
+		assert.NotEmpty(t, group.Console)
+	}
+	assert.Equal(t, []string{"/aws-glue/jobs/etl", "/aws/lambda/ingest"}, names)
+}
+
+func TestWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".test-data", "TestStorage", FileName)
+	r := Report{
+		Test:    "TestStorage",
+		Prefix:  "k3x9qa",
+		Region:  "us-east-1",
+		Cleanup: "go test -run TestStorage",
+		Stacks:  []Stack{NewStack("modules/storage", "us-east-1", nil, nil)},
+	}
+	require.NoError(t, Write(path, r))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var written Report
+	require.NoError(t, json.Unmarshal(data, &written))
+	assert.Equal(t, r, written)
+}
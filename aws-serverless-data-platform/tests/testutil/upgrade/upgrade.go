@@ -0,0 +1,271 @@
+// =============================================================================
+// Module Upgrade Compatibility
+// Applies a previous release of a module and plans the working tree over it
+// =============================================================================
+
+// Package upgrade checks a module can be upgraded in place. It applies the
+// module as it was at a previous release, exported from git, then plans the
+// working tree against the resulting state and fails for every resource the
+// upgrade would destroy or replace, so a provider bump or a renamed resource
+// cannot silently recreate a data lake bucket. Resources expected to be
+// replaced by a release are allowed by address.
+package upgrade
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/report"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tfmodule"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tfplan"
+)
+
+// FromEnvVar names the git ref to upgrade from, e.g. v1.4.0; the newest v* tag by default
+const FromEnvVar = "TERRATEST_UPGRADE_FROM"
+
+// Options describes one upgrade check
+type Options struct {
+	// ModuleDir is the module in the working tree, e.g. "../" from its tests
+	ModuleDir string
+
+	// From is the git ref to upgrade from; empty uses FromEnvVar, then the newest v* tag
+	From string
+
+	// NewOptions returns the Terraform options for a copy of the module in
+	// terraformDir; it is called for the release and for the working tree
+	NewOptions func(terraformDir string) *terraform.Options
+
+	// Allowed are the addresses, or path.Match patterns such as
+	// "aws_s3_bucket_lifecycle_configuration.*", the upgrade may destroy or replace
+	Allowed []string
+}
+
+// Run applies the module at opts.From, plans the working tree against its
+// state and fails t for every destroy or replacement opts.Allowed does not
+// cover, destroying the release's resources before returning the plan; t is
+// skipped when there is no release to upgrade from
+func Run(t *testing.T, opts Options) *tfplan.Plan {
+	t.Helper()
+
+	moduleDir, err := filepath.Abs(opts.ModuleDir)
+	require.NoError(t, err)
+	root, err := git(moduleDir, "rev-parse", "--show-toplevel")
+	require.NoError(t, err, "Failed to find the git repository of %s", moduleDir)
+	root = strings.TrimSpace(root)
+	rel, err := filepath.Rel(root, moduleDir)
+	require.NoError(t, err)
+
+	from := opts.From
+	if from == "" {
+		from = os.Getenv(FromEnvVar)
+	}
+	if from == "" {
+		from, err = LatestRelease(root)
+		require.NoError(t, err, "Failed to list release tags")
+	}
+	if from == "" {
+		t.Skipf("No v* release tag to upgrade from; set %s to a ref", FromEnvVar)
+	}
+	logger := logging.New(t, "module", filepath.ToSlash(rel), "from", from)
+
+	exported := filepath.Join(t.TempDir(), "release")
+	require.NoError(t, Export(root, from, rel, exported), "Failed to export %s at %s", rel, from)
+	release := opts.NewOptions(filepath.Join(exported, rel))
+	dropUndeclared(t, release)
+
+	// The release's state lives in a temporary directory, so a kept release is left to the sweeper
+	defer func() {
+		if !testutil.KeepOnFailure(t, testutil.StageDir(t), testutil.SweepCommand(), release) {
+			terraform.Destroy(t, release)
+		}
+	}()
+	report.Apply(t, release, func() {
+		terraform.InitAndApply(t, release)
+	})
+	logger.Info("Applied release")
+
+	// The working tree is planned from a copy, so its state and .terraform stay out of the repository
+	current := filepath.Join(t.TempDir(), "current")
+	require.NoError(t, copyModule(moduleDir, current))
+	require.NoError(t, copyFile(filepath.Join(release.TerraformDir, "terraform.tfstate"), filepath.Join(current, "terraform.tfstate")),
+		"Failed to copy the release's state")
+
+	plan := tfplan.Run(t, opts.NewOptions(current))
+	problems := Check(plan, opts.Allowed)
+	for _, problem := range problems {
+		t.Error(problem)
+	}
+	if len(problems) == 0 {
+		logger.Success("Module upgrades in place")
+	}
+	return plan
+}
+
+// Check lists the resources the plan destroys or replaces that allowed does not cover
+func Check(plan *tfplan.Plan, allowed []string) []string {
+	var problems []string
+	for _, change := range plan.ResourceChanges {
+		deletes, creates := false, false
+		for _, action := range change.Change.Actions {
+			deletes = deletes || action == "delete"
+			creates = creates || action == "create"
+		}
+		if !deletes || isAllowed(change.Address, allowed) {
+			continue
+		}
+		if creates {
+			problems = append(problems, fmt.Sprintf("%s would be replaced (%s)", change.Address, strings.Join(change.Change.Actions, ", ")))
+		} else {
+			problems = append(problems, fmt.Sprintf("%s would be destroyed", change.Address))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// isAllowed reports whether address matches an allowed address or pattern
+func isAllowed(address string, allowed []string) bool {
+	for _, pattern := range allowed {
+		if matched, err := path.Match(pattern, address); pattern == address || err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// LatestRelease returns the newest v* tag in the repository at root by
+// version order, or "" when there are none
+func LatestRelease(root string) (string, error) {
+	tags, err := git(root, "tag", "--list", "v*", "--sort=-v:refname")
+	if err != nil {
+		return "", err
+	}
+	latest, _, _ := strings.Cut(strings.TrimSpace(tags), "\n")
+	return latest, nil
+}
+
+// Export writes the directory rel of the repository at root, as it was at
+// ref, below dest, keeping its path; the module's tests are left out
+func Export(root, ref, rel, dest string) error {
+	rel = filepath.ToSlash(rel)
+	archive, err := git(root, "archive", "--format=tar", ref, "--", rel, ":(exclude)"+path.Join(rel, "tests"))
+	if err != nil {
+		return err
+	}
+
+	reader := tar.NewReader(strings.NewReader(archive))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dest, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dest)+string(filepath.Separator)) {
+			return fmt.Errorf("archive entry %q escapes %s", header.Name, dest)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0o777)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(file, reader); err != nil {
+				file.Close()
+				return err
+			}
+			if err := file.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// skipped are the module entries copyModule leaves behind: its tests and any
+// local Terraform working files
+var skipped = map[string]bool{"tests": true, ".terraform": true, ".test-data": true}
+
+// copyModule copies the module in src to dest without its tests, working
+// directory or state
+func copyModule(src, dest string) error {
+	return filepath.WalkDir(src, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if skipped[entry.Name()] || strings.HasPrefix(entry.Name(), "terraform.tfstate") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return os.MkdirAll(filepath.Join(dest, rel), 0o755)
+		}
+		return copyFile(path, filepath.Join(dest, rel))
+	})
+}
+
+// copyFile copies the regular file src to dest
+func copyFile(src, dest string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dest, data, 0o644)
+}
+
+// dropUndeclared removes the inputs the release's module does not declare yet,
+// which Terraform would reject
+func dropUndeclared(t *testing.T, options *terraform.Options) {
+	module, err := tfmodule.Load(options.TerraformDir)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(options.Vars))
+	for name := range options.Vars {
+		names = append(names, name)
+	}
+	for _, name := range module.MissingVariables(names) {
+		logging.New(t).Info("Input not declared by the release, skipped", "variable", name)
+		delete(options.Vars, name)
+	}
+}
+
+// git runs git in dir and returns its standard output
+func git(dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,119 @@
+package upgrade
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/tfplan"
+)
+
+func TestCheck(t *testing.T) {
+	plan, err := tfplan.Parse(`{
+  "format_version": "1.2",
+  "resource_changes": [
+    {"address": "aws_s3_bucket.raw", "type": "aws_s3_bucket", "change": {"actions": ["delete", "create"]}},
+    {"address": "aws_s3_bucket.curated", "type": "aws_s3_bucket", "change": {"actions": ["update"]}},
+    {"address": "aws_kms_alias.s3", "type": "aws_kms_alias", "change": {"actions": ["create", "delete"]}},
+    {"address": "aws_s3_bucket_lifecycle_configuration.raw", "type": "aws_s3_bucket_lifecycle_configuration", "change": {"actions": ["delete"]}},
+    {"address": "aws_glue_catalog_database.raw", "type": "aws_glue_catalog_database", "change": {"actions": ["delete"]}},
+    {"address": "aws_s3_bucket_policy.raw", "type": "aws_s3_bucket_policy", "change": {"actions": ["create"]}}
+  ]
+}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"aws_glue_catalog_database.raw would be destroyed",
+		"aws_kms_alias.s3 would be replaced (create, delete)",
+		"aws_s3_bucket.raw would be replaced (delete, create)",
+		"aws_s3_bucket_lifecycle_configuration.raw would be destroyed",
+	}, Check(plan, nil))
+
+	assert.Equal(t, []string{"aws_s3_bucket.raw would be replaced (delete, create)"},
+		Check(plan, []string{"aws_kms_alias.s3", "aws_s3_bucket_lifecycle_configuration.*", "aws_glue_*"}))
+}
+
+// testRepo creates a git repository holding modules/storage at two tagged releases
+func testRepo(t *testing.T) string {
+	root := t.TempDir()
+	run := func(args ...string) {
+		_, err := git(root, args...)
+		require.NoError(t, err)
+	}
+	write := func(path, content string) {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "test")
+
+	write("modules/storage/main.tf", "# v1.2.0\n")
+	write("modules/storage/tests/storage_test.go", "package test\n")
+	run("add", "-A")
+	run("commit", "-qm", "v1.2.0")
+	run("tag", "v1.2.0")
+
+	write("modules/storage/main.tf", "# v1.10.0\n")
+	write("modules/storage/variables.tf", "# v1.10.0\n")
+	run("add", "-A")
+	run("commit", "-qm", "v1.10.0")
+	run("tag", "v1.10.0")
+
+	write("modules/storage/main.tf", "# working tree\n")
+	return root
+}
+
+func TestLatestRelease(t *testing.T) {
+	root := testRepo(t)
+
+	latest, err := LatestRelease(root)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.10.0", latest, "tags are ordered by version, not by name")
+
+	none, err := LatestRelease(t.TempDir())
+	if err == nil {
+		assert.Empty(t, none)
+	}
+}
+
+func TestExport(t *testing.T) {
+	root := testRepo(t)
+	dest := t.TempDir()
+
+	require.NoError(t, Export(root, "v1.2.0", "modules/storage", dest))
+
+	main, err := os.ReadFile(filepath.Join(dest, "modules/storage/main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, "# v1.2.0\n", string(main))
+	assert.NoFileExists(t, filepath.Join(dest, "modules/storage/variables.tf"), "added after the release")
+	assert.NoDirExists(t, filepath.Join(dest, "modules/storage/tests"))
+
+	assert.Error(t, Export(root, "v9.9.9", "modules/storage", t.TempDir()))
+}
+
+func TestCopyModule(t *testing.T) {
+	root := testRepo(t)
+	for path, content := range map[string]string{
+		"modules/storage/terraform.tfstate":         "{}",
+		"modules/storage/.terraform/providers/lock": "",
+		"modules/storage/templates/policy.json":     "{}",
+	} {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	dest := filepath.Join(t.TempDir(), "current")
+
+	require.NoError(t, copyModule(filepath.Join(root, "modules/storage"), dest))
+
+	assert.FileExists(t, filepath.Join(dest, "main.tf"))
+	assert.FileExists(t, filepath.Join(dest, "templates/policy.json"))
+	assert.NoFileExists(t, filepath.Join(dest, "terraform.tfstate"))
+	assert.NoDirExists(t, filepath.Join(dest, ".terraform"))
+	assert.NoDirExists(t, filepath.Join(dest, "tests"))
+}
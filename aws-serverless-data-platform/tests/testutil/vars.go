@@ -0,0 +1,137 @@
+// =============================================================================
+// Module Variable Builders
+// Terraform variable maps for each module under test
+// =============================================================================
+
+package testutil
+
+import (
+	"fmt"
+	"net"
+)
+
+// Subnet third octets used for each tier within the VPC /16
+var (
+	privateSubnetOctets  = []int{1, 2, 3}
+	publicSubnetOctets   = []int{101, 102, 103}
+	databaseSubnetOctets = []int{201, 202, 203}
+)
+
+// NewNetworkingVars returns the variables for the networking module
+func NewNetworkingVars(opts ...Option) map[string]interface{} {
+	s := NewSettings(opts...)
+
+	return s.apply(map[string]interface{}{
+		"environment": s.Environment,
+		"region":      s.Region,
+		"account_id":  s.AccountID,
+		"vpc_name":    s.name("test-vpc", "-"),
+		"networking": map[string]interface{}{
+			"vpc": map[string]interface{}{
+				"cidr":                 s.VPCCIDR,
+				"enable_dns_hostnames": true,
+				"enable_dns_support":   true,
+			},
+			"subnets": map[string]interface{}{
+				"private":  SubnetCIDRs(s.VPCCIDR, privateSubnetOctets),
+				"public":   SubnetCIDRs(s.VPCCIDR, publicSubnetOctets),
+				"database": SubnetCIDRs(s.VPCCIDR, databaseSubnetOctets),
+			},
+			"availability_zones": len(privateSubnetOctets),
+			"nat_gateway": map[string]interface{}{
+				"enable":             true,
+				"single_nat_gateway": s.SingleNATGateway,
+			},
+			"flow_logs": map[string]interface{}{
+				"enable":         s.FlowLogsEnabled,
+				"retention_days": s.FlowLogRetention,
+			},
+		},
+		"common_tags": s.commonTags(),
+	})
+}
+
+// NewStorageVars returns the variables for the storage module; bucket names
+// carry only the run prefix because the module appends the environment,
+// region and a random suffix, and S3 limits names to 63 characters
+func NewStorageVars(opts ...Option) map[string]interface{} {
+	s := NewSettings(opts...)
+
+	return s.apply(map[string]interface{}{
+		"environment":           s.Environment,
+		"project_name":          s.name("dl-test", "-"),
+		"region":                s.Region,
+		"account_id":            s.AccountID,
+		"raw_bucket_name":       s.prefixed("dl-raw", "-"),
+		"processed_bucket_name": s.prefixed("dl-processed", "-"),
+		"curated_bucket_name":   s.prefixed("dl-curated", "-"),
+		"storage": map[string]interface{}{
+			"s3": map[string]interface{}{
+				"versioning":          true,
+				"encryption":          "AES256",
+				"public_access_block": true,
+				"force_destroy":       true,
+			},
+			"lifecycle": map[string]interface{}{
+				"transition_ia_days":           90,
+				"transition_glacier_days":      210,
+				"transition_deep_archive_days": 365,
+				"expiration_days":              730,
+			},
+		},
+		"security": map[string]interface{}{
+			"kms": map[string]interface{}{
+				"deletion_window":     30,
+				"enable_key_rotation": true,
+			},
+		},
+		"common_tags": s.commonTags(),
+	})
+}
+
+// NewSecurityVars returns the variables for the security module, which reads
+// the region and account from the provider
+func NewSecurityVars(opts ...Option) map[string]interface{} {
+	s := NewSettings(opts...)
+
+	return s.apply(map[string]interface{}{
+		"project_name": s.name("security-test", "-"),
+		"environment":  s.Environment,
+		"vpc_id":       s.VPCID,
+		"common_tags":  s.commonTags(),
+	})
+}
+
+// NewAnalyticsVars returns the variables for the analytics module; the results
+// bucket, KMS key and Glue database come from the storage module and are
+// supplied with WithVar
+func NewAnalyticsVars(opts ...Option) map[string]interface{} {
+	s := NewSettings(opts...)
+
+	return s.apply(map[string]interface{}{
+		"project_name":                s.name("analytics-test", "-"),
+		"environment":                 s.Environment,
+		"vpc_id":                      s.VPCID,
+		"create_sample_queries":       true,
+		"enable_opensearch":           false,
+		"enable_quicksight":           false,
+		"create_cloudwatch_dashboard": false,
+		"log_retention_days":          7,
+		"common_tags":                 s.commonTags(),
+	})
+}
+
+// SubnetCIDRs carves /24 subnets with the given third octets out of a /16 VPC CIDR
+func SubnetCIDRs(vpcCIDR string, thirdOctets []int) []string {
+	ip, _, err := net.ParseCIDR(vpcCIDR)
+	if err != nil {
+		panic(fmt.Sprintf("invalid VPC CIDR %q: %v", vpcCIDR, err))
+	}
+	ip = ip.To4()
+
+	cidrs := make([]string, 0, len(thirdOctets))
+	for _, octet := range thirdOctets {
+		cidrs = append(cidrs, fmt.Sprintf("%d.%d.%d.0/24", ip[0], ip[1], octet))
+	}
+	return cidrs
+}
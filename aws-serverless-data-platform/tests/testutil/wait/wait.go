@@ -0,0 +1,172 @@
+// =============================================================================
+// Polling and Backoff
+// Waits for eventually consistent AWS state with exponential backoff and jitter
+// =============================================================================
+
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/your-org/aws-serverless-data-platform/tests/testutil/logging"
+)
+
+// Condition reports whether the awaited state has been reached. Returning an
+// error keeps polling unless it is wrapped with Permanent.
+type Condition func(ctx context.Context) (done bool, err error)
+
+// Options controls how often and how long a condition is polled
+type Options struct {
+	// Timeout bounds the whole wait
+	Timeout time.Duration
+
+	// Initial is the delay before the second attempt
+	Initial time.Duration
+
+	// Max caps the delay between attempts
+	Max time.Duration
+
+	// Multiplier grows the delay after every attempt
+	Multiplier float64
+
+	// Jitter randomizes each delay by up to this fraction in either direction
+	Jitter float64
+}
+
+// DefaultOptions polls for up to five minutes, starting at two seconds and backing off to thirty
+func DefaultOptions() Options {
+	return Options{
+		Timeout:    5 * time.Minute,
+		Initial:    2 * time.Second,
+		Max:        30 * time.Second,
+		Multiplier: 2,
+		Jitter:     0.2,
+	}
+}
+
+// WithTimeout returns a copy of the options with a different timeout
+func (o Options) WithTimeout(timeout time.Duration) Options {
+	o.Timeout = timeout
+	return o
+}
+
+// WithInterval returns a copy of the options polling every interval, with jitter
+func (o Options) WithInterval(interval time.Duration) Options {
+	o.Initial = interval
+	o.Max = interval
+	o.Multiplier = 1
+	return o
+}
+
+// Delay returns the jittered delay after the given zero-based attempt; random
+// is a value in [0, 1)
+func (o Options) Delay(attempt int, random float64) time.Duration {
+	delay := float64(o.Initial)
+	for i := 0; i < attempt && delay < float64(o.Max); i++ {
+		delay *= o.Multiplier
+	}
+	if o.Max > 0 && delay > float64(o.Max) {
+		delay = float64(o.Max)
+	}
+
+	delay *= 1 + o.Jitter*(2*random-1)
+	if delay < 0 {
+		return 0
+	}
+	return time.Duration(delay)
+}
+
+// permanentError stops polling immediately
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as one that retrying will not fix
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// TimeoutError is returned when the condition was not met in time
+type TimeoutError struct {
+	Attempts int
+	Elapsed  time.Duration
+
+	// Last is the most recent error the condition returned, if any
+	Last error
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Last == nil {
+		return fmt.Sprintf("condition not met after %d attempts in %s", e.Attempts, e.Elapsed.Round(time.Second))
+	}
+	return fmt.Sprintf("condition not met after %d attempts in %s: %v", e.Attempts, e.Elapsed.Round(time.Second), e.Last)
+}
+
+func (e *TimeoutError) Unwrap() error { return e.Last }
+
+// WaitFor polls cond until it is done, it returns a Permanent error, ctx is
+// cancelled or opts.Timeout elapses
+func WaitFor(ctx context.Context, cond Condition, opts Options) error {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	var last error
+	for attempt := 0; ; attempt++ {
+		done, err := cond(ctx)
+		if done && err == nil {
+			return nil
+		}
+
+		var permanent permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if err != nil {
+			last = err
+		}
+
+		timer := time.NewTimer(opts.Delay(attempt, rand.Float64()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &TimeoutError{Attempts: attempt + 1, Elapsed: time.Since(start), Last: last}
+		case <-timer.C:
+		}
+	}
+}
+
+// Until is WaitFor for tests: it fails t with description when the condition is not met
+func Until(t *testing.T, description string, cond Condition, opts Options) {
+	t.Helper()
+	logging.New(t).Info("Waiting for "+description, "timeout", opts.Timeout)
+
+	err := WaitFor(context.Background(), cond, opts)
+	require.NoError(t, err, "Failed waiting for %s", description)
+}
+
+// Succeeds adapts an action that only reports failure, such as a create call
+// racing IAM propagation, into a Condition
+func Succeeds(action func(ctx context.Context) error) Condition {
+	return func(ctx context.Context) (bool, error) {
+		if err := action(ctx); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+}
@@ -0,0 +1,86 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastOptions polls quickly so the tests run in milliseconds
+func fastOptions() Options {
+	return Options{Timeout: time.Second, Initial: time.Millisecond, Max: 4 * time.Millisecond, Multiplier: 2}
+}
+
+func TestDelay(t *testing.T) {
+	opts := Options{Initial: time.Second, Max: 10 * time.Second, Multiplier: 2}
+
+	assert.Equal(t, time.Second, opts.Delay(0, 0.5))
+	assert.Equal(t, 2*time.Second, opts.Delay(1, 0.5))
+	assert.Equal(t, 8*time.Second, opts.Delay(3, 0.5))
+	assert.Equal(t, 10*time.Second, opts.Delay(4, 0.5), "Delay is capped at Max")
+	assert.Equal(t, 10*time.Second, opts.Delay(1000, 0.5), "Large attempts do not overflow")
+
+	opts.Jitter = 0.2
+	assert.Equal(t, 800*time.Millisecond, opts.Delay(0, 0))
+	assert.Equal(t, 1200*time.Millisecond, opts.Delay(0, 1))
+
+	fixed := DefaultOptions().WithInterval(5 * time.Second)
+	fixed.Jitter = 0
+	assert.Equal(t, 5*time.Second, fixed.Delay(10, 0.5))
+}
+
+func TestWaitForSucceeds(t *testing.T) {
+	attempts := 0
+	err := WaitFor(context.Background(), func(ctx context.Context) (bool, error) {
+		attempts++
+		if attempts < 3 {
+			return false, errors.New("not yet")
+		}
+		return true, nil
+	}, fastOptions())
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestWaitForPermanent(t *testing.T) {
+	attempts := 0
+	failed := errors.New("crawler failed")
+	err := WaitFor(context.Background(), func(ctx context.Context) (bool, error) {
+		attempts++
+		return false, Permanent(failed)
+	}, fastOptions())
+
+	assert.Same(t, failed, err)
+	assert.Equal(t, 1, attempts, "Permanent errors stop polling")
+}
+
+func TestWaitForTimeout(t *testing.T) {
+	opts := fastOptions().WithTimeout(20 * time.Millisecond)
+	err := WaitFor(context.Background(), func(ctx context.Context) (bool, error) {
+		return false, errors.New("still creating")
+	}, opts)
+
+	var timeout *TimeoutError
+	require.ErrorAs(t, err, &timeout)
+	assert.Greater(t, timeout.Attempts, 1)
+	assert.EqualError(t, timeout.Last, "still creating")
+}
+
+func TestSucceeds(t *testing.T) {
+	calls := 0
+	err := WaitFor(context.Background(), Succeeds(func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			return errors.New("role not propagated")
+		}
+		return nil
+	}), fastOptions())
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
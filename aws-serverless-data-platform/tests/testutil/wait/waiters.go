@@ -0,0 +1,256 @@
+// =============================================================================
+// Service Waiters
+// Conditions for the AWS resources the suites wait on
+// =============================================================================
+
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/athena"
+	athenatypes "github.com/aws/aws-sdk-go-v2/service/athena/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/glue"
+	gluetypes "github.com/aws/aws-sdk-go-v2/service/glue/types"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	sfntypes "github.com/aws/aws-sdk-go-v2/service/sfn/types"
+	"github.com/aws/smithy-go"
+)
+
+// BucketExists waits until HeadBucket succeeds; access errors are permanent
+func BucketExists(client *s3.Client, bucket string) Condition {
+	return func(ctx context.Context) (bool, error) {
+		_, err := client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: awssdk.String(bucket)})
+		if err == nil {
+			return true, nil
+		}
+
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "Forbidden" {
+			return false, Permanent(fmt.Errorf("no access to bucket %s: %w", bucket, err))
+		}
+		return false, fmt.Errorf("bucket %s not available: %w", bucket, err)
+	}
+}
+
+// VPCAvailable waits for a VPC to leave the pending state
+func VPCAvailable(client *ec2.Client, vpcID string) Condition {
+	return func(ctx context.Context) (bool, error) {
+		output, err := client.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{VpcIds: []string{vpcID}})
+		if err != nil {
+			return false, err
+		}
+		if len(output.Vpcs) == 0 {
+			return false, fmt.Errorf("VPC %s not found yet", vpcID)
+		}
+		if state := output.Vpcs[0].State; state != ec2types.VpcStateAvailable {
+			return false, fmt.Errorf("VPC %s is %s", vpcID, state)
+		}
+		return true, nil
+	}
+}
+
+// AnalysisFinished waits for a Reachability Analyzer analysis to finish and
+// stores it in analysis; a failed analysis is permanent
+func AnalysisFinished(client *ec2.Client, analysisID string, analysis **ec2types.NetworkInsightsAnalysis) Condition {
+	return func(ctx context.Context) (bool, error) {
+		output, err := client.DescribeNetworkInsightsAnalyses(ctx, &ec2.DescribeNetworkInsightsAnalysesInput{
+			NetworkInsightsAnalysisIds: []string{analysisID},
+		})
+		if err != nil {
+			return false, err
+		}
+		if len(output.NetworkInsightsAnalyses) == 0 {
+			return false, fmt.Errorf("analysis %s not found", analysisID)
+		}
+
+		current := output.NetworkInsightsAnalyses[0]
+		switch current.Status {
+		case ec2types.AnalysisStatusSucceeded:
+			if analysis != nil {
+				*analysis = &current
+			}
+			return true, nil
+		case ec2types.AnalysisStatusFailed:
+			return false, Permanent(fmt.Errorf("analysis %s failed: %s", analysisID, awssdk.ToString(current.StatusMessage)))
+		default:
+			return false, fmt.Errorf("analysis %s is %s", analysisID, current.Status)
+		}
+	}
+}
+
+// CrawlerFinished waits for the crawler to return to READY after a crawl;
+// a failed or cancelled crawl is permanent
+func CrawlerFinished(client *glue.Client, name string) Condition {
+	return func(ctx context.Context) (bool, error) {
+		output, err := client.GetCrawler(ctx, &glue.GetCrawlerInput{Name: awssdk.String(name)})
+		if err != nil {
+			return false, err
+		}
+
+		crawler := output.Crawler
+		if crawler.State != gluetypes.CrawlerStateReady || crawler.LastCrawl == nil {
+			return false, fmt.Errorf("crawler %s is %s", name, crawler.State)
+		}
+		if crawler.LastCrawl.Status != gluetypes.LastCrawlStatusSucceeded {
+			return false, Permanent(fmt.Errorf("crawler %s finished with %s: %s",
+				name, crawler.LastCrawl.Status, awssdk.ToString(crawler.LastCrawl.ErrorMessage)))
+		}
+		return true, nil
+	}
+}
+
+// JobRunStarted waits for a Glue job run to leave the queue; a run that ends
+// without succeeding is permanent
+func JobRunStarted(client *glue.Client, jobName, runID string) Condition {
+	return func(ctx context.Context) (bool, error) {
+		output, err := client.GetJobRun(ctx, &glue.GetJobRunInput{
+			JobName: awssdk.String(jobName),
+			RunId:   awssdk.String(runID),
+		})
+		if err != nil {
+			return false, err
+		}
+
+		run := output.JobRun
+		switch run.JobRunState {
+		case gluetypes.JobRunStateRunning, gluetypes.JobRunStateSucceeded:
+			return true, nil
+		case gluetypes.JobRunStateFailed, gluetypes.JobRunStateError, gluetypes.JobRunStateTimeout,
+			gluetypes.JobRunStateStopping, gluetypes.JobRunStateStopped:
+			return false, Permanent(fmt.Errorf("job run %s of %s finished in state %s: %s",
+				runID, jobName, run.JobRunState, awssdk.ToString(run.ErrorMessage)))
+		default:
+			return false, fmt.Errorf("job run %s of %s is %s", runID, jobName, run.JobRunState)
+		}
+	}
+}
+
+// TableExists waits for a Data Catalog table and stores it in table
+func TableExists(client *glue.Client, database, name string, table **gluetypes.Table) Condition {
+	return func(ctx context.Context) (bool, error) {
+		output, err := client.GetTable(ctx, &glue.GetTableInput{
+			DatabaseName: awssdk.String(database),
+			Name:         awssdk.String(name),
+		})
+		if err != nil {
+			return false, err
+		}
+		if table != nil {
+			*table = output.Table
+		}
+		return true, nil
+	}
+}
+
+// StreamActive waits for a Kinesis stream to become ACTIVE
+func StreamActive(client *kinesis.Client, name string) Condition {
+	return func(ctx context.Context) (bool, error) {
+		output, err := client.DescribeStreamSummary(ctx, &kinesis.DescribeStreamSummaryInput{
+			StreamName: awssdk.String(name),
+		})
+		if err != nil {
+			return false, err
+		}
+
+		status := output.StreamDescriptionSummary.StreamStatus
+		switch status {
+		case kinesistypes.StreamStatusActive:
+			return true, nil
+		case kinesistypes.StreamStatusDeleting:
+			return false, Permanent(fmt.Errorf("stream %s is being deleted", name))
+		default:
+			return false, fmt.Errorf("stream %s is %s", name, status)
+		}
+	}
+}
+
+// ExecutionSucceeded waits for a Step Functions execution to finish; any
+// terminal status other than SUCCEEDED is permanent
+func ExecutionSucceeded(client *sfn.Client, executionARN string) Condition {
+	return func(ctx context.Context) (bool, error) {
+		output, err := client.DescribeExecution(ctx, &sfn.DescribeExecutionInput{
+			ExecutionArn: awssdk.String(executionARN),
+		})
+		if err != nil {
+			return false, err
+		}
+
+		switch output.Status {
+		case sfntypes.ExecutionStatusSucceeded:
+			return true, nil
+		case sfntypes.ExecutionStatusFailed, sfntypes.ExecutionStatusTimedOut, sfntypes.ExecutionStatusAborted:
+			return false, Permanent(fmt.Errorf("execution %s finished with %s: %s: %s", executionARN,
+				output.Status, awssdk.ToString(output.Error), awssdk.ToString(output.Cause)))
+		default:
+			return false, fmt.Errorf("execution %s is %s", executionARN, output.Status)
+		}
+	}
+}
+
+// QueryGetter is the part of the Athena client QuerySucceeded uses
+type QueryGetter interface {
+	GetQueryExecution(ctx context.Context, params *athena.GetQueryExecutionInput, optFns ...func(*athena.Options)) (*athena.GetQueryExecutionOutput, error)
+}
+
+// QuerySucceeded waits for an Athena query to finish and stores its execution in
+// execution; a failed or cancelled query is permanent
+func QuerySucceeded(client QueryGetter, queryExecutionID string, execution **athenatypes.QueryExecution) Condition {
+	return func(ctx context.Context) (bool, error) {
+		output, err := client.GetQueryExecution(ctx, &athena.GetQueryExecutionInput{
+			QueryExecutionId: awssdk.String(queryExecutionID),
+		})
+		if err != nil {
+			return false, err
+		}
+		if execution != nil {
+			*execution = output.QueryExecution
+		}
+
+		status := output.QueryExecution.Status
+		switch status.State {
+		case athenatypes.QueryExecutionStateSucceeded:
+			return true, nil
+		case athenatypes.QueryExecutionStateFailed, athenatypes.QueryExecutionStateCancelled:
+			return false, Permanent(fmt.Errorf("query %s finished in state %s: %s",
+				queryExecutionID, status.State, awssdk.ToString(status.StateChangeReason)))
+		default:
+			return false, fmt.Errorf("query %s is %s", queryExecutionID, status.State)
+		}
+	}
+}
+
+// AlarmInState waits for a CloudWatch metric alarm to reach one of states; a
+// missing alarm is permanent
+func AlarmInState(client *cloudwatch.Client, name string, states ...cwtypes.StateValue) Condition {
+	return func(ctx context.Context) (bool, error) {
+		output, err := client.DescribeAlarms(ctx, &cloudwatch.DescribeAlarmsInput{
+			AlarmNames: []string{name},
+			AlarmTypes: []cwtypes.AlarmType{cwtypes.AlarmTypeMetricAlarm},
+		})
+		if err != nil {
+			return false, err
+		}
+		if len(output.MetricAlarms) == 0 {
+			return false, Permanent(fmt.Errorf("alarm %s not found", name))
+		}
+
+		current := output.MetricAlarms[0].StateValue
+		for _, state := range states {
+			if current == state {
+				return true, nil
+			}
+		}
+		return false, fmt.Errorf("alarm %s is %s", name, current)
+	}
+}